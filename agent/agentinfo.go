@@ -0,0 +1,52 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"net/url"
+
+	"github.com/spf13/pflag"
+	"k8s.io/component-base/featuregate"
+
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/version"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+)
+
+// featureGatesFlag is the flag of the feature gates, reported on their own
+const featureGatesFlag = "feature-gates"
+
+// agentInfo returns the build of the agent, its feature gates and its flags set to another value than their
+// default. The configuration file sets the flag variables, its settings are reported along with the flags.
+func agentInfo(flags *pflag.FlagSet, gates featuregate.MutableFeatureGate) *infrastructurev1beta1.AgentInfo {
+	info := version.Get()
+	agentInfo := &infrastructurev1beta1.AgentInfo{
+		Version:      info.GitVersion,
+		GitCommit:    info.GitCommit,
+		GoVersion:    info.GoVersion,
+		Platform:     info.Platform,
+		FeatureGates: map[string]bool{},
+		Flags:        map[string]string{},
+	}
+	for feature := range gates.GetAll() {
+		agentInfo.FeatureGates[string(feature)] = gates.Enabled(feature)
+	}
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Hidden || flag.Name == featureGatesFlag {
+			return
+		}
+		if value := flag.Value.String(); value != flag.DefValue {
+			agentInfo.Flags[flag.Name] = redactURL(value)
+		}
+	})
+	return agentInfo
+}
+
+// redactURL redacts the password of a URL, e.g. of the proxies. The other values are returned as is.
+func redactURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil || u.User == nil {
+		return value
+	}
+	return u.Redacted()
+}
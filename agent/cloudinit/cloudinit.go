@@ -4,6 +4,7 @@
 package cloudinit
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"path/filepath"
@@ -11,6 +12,8 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/common"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/common/tracing"
+	"go.opentelemetry.io/otel/attribute"
 	"sigs.k8s.io/yaml"
 )
 
@@ -41,44 +44,77 @@ type Files struct {
 //  - execute the write_files directive
 //  - execute the run_cmd directive
 func (se ScriptExecutor) Execute(bootstrapScript string) error {
+	return se.ExecuteContext(context.Background(), bootstrapScript)
+}
+
+// ExecuteContext executes the bootstrap script like Execute, tracing the write_files directive and each
+// command of the run_cmd directive, e.g. the kubeadm join of the node, in the trace of ctx
+func (se ScriptExecutor) ExecuteContext(ctx context.Context, bootstrapScript string) error {
 	cloudInitData := bootstrapConfig{}
 	if err := yaml.Unmarshal([]byte(bootstrapScript), &cloudInitData); err != nil {
 		return errors.Wrapf(err, "error parsing write_files action: %s", bootstrapScript)
 	}
 
-	for i := range cloudInitData.FilesToWrite {
-		directoryToCreate := filepath.Dir(cloudInitData.FilesToWrite[i].Path)
-		err := se.WriteFilesExecutor.MkdirIfNotExists(directoryToCreate)
+	_, span := tracing.Start(ctx, "WriteFiles", attribute.Int("files", len(cloudInitData.FilesToWrite)))
+	err := se.writeFiles(cloudInitData.FilesToWrite)
+	tracing.End(span, err)
+	if err != nil {
+		return err
+	}
+
+	for _, cmd := range cloudInitData.CommandsToExecute {
+		_, span := tracing.Start(ctx, commandName(cmd))
+		err := se.RunCmdExecutor.RunCmd(cmd)
+		tracing.End(span, err)
 		if err != nil {
-			return errors.Wrap(err, fmt.Sprintf("Error creating the directory %s", directoryToCreate))
+			return errors.Wrap(err, fmt.Sprintf("Error running the command %s", cmd))
 		}
+	}
+	return nil
+}
 
-		encodings := parseEncodingScheme(cloudInitData.FilesToWrite[i].Encoding)
-		cloudInitData.FilesToWrite[i].Content, err = decodeContent(cloudInitData.FilesToWrite[i].Content, encodings)
+// writeFiles executes the write_files directive
+func (se ScriptExecutor) writeFiles(filesToWrite []Files) error {
+	for i := range filesToWrite {
+		directoryToCreate := filepath.Dir(filesToWrite[i].Path)
+		err := se.WriteFilesExecutor.MkdirIfNotExists(directoryToCreate)
 		if err != nil {
-			return errors.Wrap(err, fmt.Sprintf("error decoding content for %s", cloudInitData.FilesToWrite[i].Path))
+			return errors.Wrap(err, fmt.Sprintf("Error creating the directory %s", directoryToCreate))
 		}
 
-		cloudInitData.FilesToWrite[i].Content, err = se.ParseTemplateExecutor.ParseTemplate(cloudInitData.FilesToWrite[i].Content)
+		encodings := parseEncodingScheme(filesToWrite[i].Encoding)
+		filesToWrite[i].Content, err = decodeContent(filesToWrite[i].Content, encodings)
 		if err != nil {
-			return errors.Wrap(err, fmt.Sprintf("error parse template content for %s", cloudInitData.FilesToWrite[i].Path))
+			return errors.Wrap(err, fmt.Sprintf("error decoding content for %s", filesToWrite[i].Path))
 		}
 
-		err = se.WriteFilesExecutor.WriteToFile(&cloudInitData.FilesToWrite[i])
+		filesToWrite[i].Content, err = se.ParseTemplateExecutor.ParseTemplate(filesToWrite[i].Content)
 		if err != nil {
-			return errors.Wrap(err, fmt.Sprintf("Error writing the file %s", cloudInitData.FilesToWrite[i].Path))
+			return errors.Wrap(err, fmt.Sprintf("error parse template content for %s", filesToWrite[i].Path))
 		}
-	}
 
-	for _, cmd := range cloudInitData.CommandsToExecute {
-		err := se.RunCmdExecutor.RunCmd(cmd)
+		err = se.WriteFilesExecutor.WriteToFile(&filesToWrite[i])
 		if err != nil {
-			return errors.Wrap(err, fmt.Sprintf("Error running the command %s", cmd))
+			return errors.Wrap(err, fmt.Sprintf("Error writing the file %s", filesToWrite[i].Path))
 		}
 	}
 	return nil
 }
 
+// commandName names the span of a command after its program, along with the phase of kubeadm, e.g.
+// kubeadm join, leaving out the arguments which may hold secrets
+func commandName(cmd string) string {
+	fields := strings.Fields(cmd)
+	if len(fields) == 0 {
+		return "RunCmd"
+	}
+	name := filepath.Base(fields[0])
+	if name == "kubeadm" && len(fields) > 1 && !strings.HasPrefix(fields[1], "-") {
+		name += " " + fields[1]
+	}
+	return name
+}
+
 func parseEncodingScheme(e string) []string {
 	e = strings.ToLower(e)
 	e = strings.TrimSpace(e)
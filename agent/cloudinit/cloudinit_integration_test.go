@@ -60,6 +60,12 @@ runCmd:
 		Expect(string(fileContents)).To(Equal(fileNewContent))
 	})
 
+	It("should end the error of a failed command with its error output", func() {
+		err := scriptExecutor.Execute(`runCmd:
+- echo 'token id "abcdef" is invalid for this cluster or it has expired' >&2; exit 1`)
+		Expect(err).To(MatchError(HaveSuffix(`exit status 1: token id "abcdef" is invalid for this cluster or it has expired`)))
+	})
+
 	It("should be able to write files with the correct permissions and in append mode", func() {
 		fileName := path.Join(workDir, "file-2.txt")
 		fileOriginContent := "some-content-2"
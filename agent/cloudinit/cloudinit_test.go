@@ -4,6 +4,7 @@
 package cloudinit_test
 
 import (
+	"context"
 	"encoding/base64"
 	"errors"
 	"fmt"
@@ -15,6 +16,10 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/cloudinit"
 	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/cloudinit/cloudinitfakes"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var _ = Describe("Cloudinit", func() {
@@ -149,6 +154,26 @@ runCmd:
 			Expect(fakeFileWriter.WriteToFileCallCount()).To(Equal(0))
 		})
 
+		It("should trace the write_files directive and each command of the runCmd directive", func() {
+			recorder := tracetest.NewSpanRecorder()
+			otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+			defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+			err := scriptExecutor.ExecuteContext(context.Background(), fmt.Sprintf(`write_files:
+- path: %s/defaultFile.txt
+  content: some-content
+runCmd:
+- kubeadm join --config /run/kubeadm/kubeadm-join-config.yaml
+- /usr/bin/echo success`, workDir))
+			Expect(err).NotTo(HaveOccurred())
+
+			names := []string{}
+			for _, span := range recorder.Ended() {
+				names = append(names, span.Name())
+			}
+			Expect(names).To(Equal([]string{"WriteFiles", "kubeadm join", "echo"}))
+		})
+
 		It("should error out when command execution fails", func() {
 			fakeCmdExecutor.RunCmdReturns(errors.New("command execution failed"))
 			err := scriptExecutor.Execute(defaultBootstrapSecret)
@@ -4,23 +4,43 @@
 package cloudinit
 
 import (
+	"bytes"
+	"fmt"
+	"io"
 	"os"
-	"os/exec"
+
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/privilege"
 )
 
+// maxStderrTail is how much of the end of the error output of a failed command is kept in its error
+const maxStderrTail = 1024
+
 //counterfeiter:generate . ICmdRunner
 type ICmdRunner interface {
 	RunCmd(string) error
 }
 
 // CmdRunner default implementer of ICmdRunner
-// TODO reevaluate empty interface/struct
 type CmdRunner struct {
+	// Escalator runs the commands as root
+	Escalator privilege.Escalator
 }
 
-// RunCmd executes the command string
+// RunCmd executes the command string. The error of a failed command ends with the end of its error
+// output, e.g. the reason kubeadm failed to join the node
 func (r CmdRunner) RunCmd(cmd string) error {
-	command := exec.Command("/bin/sh", "-c", cmd)
-	command.Stderr = os.Stderr
-	return command.Run()
+	var stderr bytes.Buffer
+	command := r.Escalator.Command("/bin/sh", "-c", cmd)
+	command.Stderr = io.MultiWriter(os.Stderr, &stderr)
+	if err := command.Run(); err != nil {
+		tail := bytes.TrimSpace(stderr.Bytes())
+		if len(tail) > maxStderrTail {
+			tail = tail[len(tail)-maxStderrTail:]
+		}
+		if len(tail) == 0 {
+			return err
+		}
+		return fmt.Errorf("%w: %s", err, tail)
+	}
+	return nil
 }
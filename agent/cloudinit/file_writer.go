@@ -12,6 +12,7 @@ import (
 	"strings"
 
 	"github.com/pkg/errors"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/privilege"
 )
 
 const (
@@ -29,6 +30,8 @@ type IFileWriter interface {
 
 // FileWriter default implementation of IFileWriter
 type FileWriter struct {
+	// Escalator writes the files as root, for an agent escalating with sudo
+	Escalator privilege.Escalator
 }
 
 // MkdirIfNotExists creates the directory if it does not exist already
@@ -36,7 +39,7 @@ func (w FileWriter) MkdirIfNotExists(dirName string) error {
 	_, err := os.Stat(dirName)
 
 	if os.IsNotExist(err) {
-		return os.MkdirAll(dirName, dirPermission)
+		return w.Escalator.MkdirAll(dirName, dirPermission)
 	}
 
 	if err != nil {
@@ -48,6 +51,10 @@ func (w FileWriter) MkdirIfNotExists(dirName string) error {
 // WriteToFile writes contents to file with appropriate permissions
 // as provided in the write_files directive of cloud-config file
 func (w FileWriter) WriteToFile(file *Files) error {
+	if w.Escalator.Sudo {
+		return w.writeToFileWithSudo(file)
+	}
+
 	initPermission := fs.FileMode(filePermission)
 	if stats, err := os.Stat(file.Path); os.IsExist(err) {
 		initPermission = stats.Mode()
@@ -116,3 +123,25 @@ func (w FileWriter) WriteToFile(file *Files) error {
 
 	return f.Close()
 }
+
+// writeToFileWithSudo writes the file as root through sudo, the agent user having no access to it
+func (w FileWriter) writeToFileWithSudo(file *Files) error {
+	if err := w.Escalator.WriteFile(file.Path, []byte(file.Content), filePermission, file.Append); err != nil {
+		return err
+	}
+
+	if len(file.Permissions) > 0 {
+		fileMode, err := strconv.ParseUint(file.Permissions, 8, 32)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error parse the file permission %s", file.Permissions))
+		}
+		if err = w.Escalator.Chmod(file.Path, fs.FileMode(fileMode)); err != nil {
+			return err
+		}
+	}
+
+	if len(file.Owner) > 0 {
+		return w.Escalator.Chown(file.Path, file.Owner)
+	}
+	return nil
+}
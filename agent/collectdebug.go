@@ -0,0 +1,115 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/debugbundle"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/privilege"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/version"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/feature"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// collectDebugCommand is the agent subcommand collecting the debug bundle of the host
+	collectDebugCommand = "collect-debug"
+	// debugJournalSince is how far back the journals of the debug bundle go
+	debugJournalSince = "24 hours ago"
+)
+
+// debugKubeConfig returns the config of the management cluster for the debug bundle. Unlike
+// loadHostKubeConfig, it does not request a host kubeconfig with the bootstrap kubeconfig.
+func debugKubeConfig() (*rest.Config, error) {
+	if !feature.Gates.Enabled(feature.SecureAccess) {
+		return ctrl.GetConfig()
+	}
+	kubeConfigPath, err := hostKubeConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	sealer, err := newSealer()
+	if err != nil {
+		return nil, err
+	}
+	store, err := newKeyStore(sealer)
+	if err != nil {
+		return nil, err
+	}
+	return hostRESTConfig(kubeConfigPath, store, sealer)
+}
+
+// debugBundleSources returns the logs and the state of the host the debug bundle is made of.
+// The output of the bootstrap commands, e.g. kubeadm, is part of the agent journal.
+func debugBundleSources(k8sClient client.Client, hostName string, escalator privilege.Escalator) []debugbundle.Source {
+	journal := func(name, unit string) debugbundle.Source {
+		return debugbundle.Command("journal/"+name+".log", escalator, "journalctl", "--unit", unit, "--since", debugJournalSince, "--no-pager")
+	}
+	sources := []debugbundle.Source{
+		{Name: "agent-version.txt", Collect: func(context.Context) ([]byte, error) {
+			return []byte(fmt.Sprintf("%#v\n", version.Get())), nil
+		}},
+		journal("agent", "byoh-hostagent*"),
+		journal("kubelet", "kubelet"),
+		journal("containerd", "containerd"),
+		debugbundle.File("kubelet/config.yaml", "/var/lib/kubelet/config.yaml"),
+		debugbundle.File("kubelet/kubeadm-flags.env", "/var/lib/kubelet/kubeadm-flags.env"),
+	}
+	if installerAuditLog != "" {
+		sources = append(sources, debugbundle.File("installer-audit.log", installerAuditLog))
+	}
+	if k8sClient != nil {
+		sources = append(sources, debugbundle.Object("byohost.yaml", k8sClient,
+			types.NamespacedName{Name: hostName, Namespace: namespace}, &infrastructurev1beta1.ByoHost{}))
+	}
+	return sources
+}
+
+// collectDebug writes the debug bundle of the host to a tarball, and uploads it as a ConfigMap
+// in the namespace of the ByoHost when requested. The bundle is collected without a management
+// cluster, k8sClient is nil then, but it cannot be uploaded.
+func collectDebug(ctx context.Context, k8sClient client.Client, hostName string, escalator privilege.Escalator, out io.Writer, logger logr.Logger) error {
+	path := debugBundlePath
+	if path == "" {
+		path = fmt.Sprintf("byoh-debug-%s-%s.tar.gz", hostName, time.Now().UTC().Format("20060102T150405Z"))
+	}
+
+	var bundle bytes.Buffer
+	if err := debugbundle.Write(ctx, &bundle, debugBundleSources(k8sClient, hostName, escalator)); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, bundle.Bytes(), 0600); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Debug bundle written to %s\n", path)
+
+	if !uploadDebugBundle {
+		return nil
+	}
+	if k8sClient == nil {
+		return fmt.Errorf("cannot upload the debug bundle without a management cluster, the bundle is kept at %s", path)
+	}
+	byoHost := &infrastructurev1beta1.ByoHost{}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: hostName, Namespace: namespace}, byoHost); err != nil {
+		return err
+	}
+	configMap, err := debugbundle.Upload(ctx, k8sClient, byoHost, bundle.Bytes())
+	if err != nil {
+		return err
+	}
+	logger.Info("Debug bundle uploaded", "configmap", configMap.Name, "namespace", configMap.Namespace)
+	fmt.Fprintf(out, "Debug bundle uploaded to the ConfigMap %s/%s\n", configMap.Namespace, configMap.Name)
+	return nil
+}
@@ -0,0 +1,263 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/go-logr/logr"
+	pflag "github.com/spf13/pflag"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/registration"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/agentconfig"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/agentconfig/v1alpha1"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/feature"
+	"k8s.io/apimachinery/pkg/api/resource"
+	klog "k8s.io/klog/v2"
+)
+
+// labelsMutex guards the labels, reloaded while the host reconciler syncs them
+var labelsMutex sync.RWMutex
+
+// applyAgentConfig sets the settings of the configuration file which are not set on the command line
+func applyAgentConfig(config *v1alpha1.AgentConfiguration, flags *pflag.FlagSet) error {
+	stringSettings := []struct {
+		flag   string
+		value  string
+		target *string
+	}{
+		{"namespace", config.Namespace, &namespace},
+		{"hostname-override", config.HostnameOverride, &hostnameOverride},
+		{"host-instance-suffix", config.HostInstanceSuffix, &hostInstanceSuffix},
+		{"host-identity", config.HostIdentity, &hostIdentity},
+		{"byohost-name-template", config.ByoHostNameTemplate, &byohostNameTemplate},
+		{"byohost-name-prefix", config.ByoHostNamePrefix, &byohostNamePrefix},
+		{"metricsbindaddress", config.Metrics.BindAddress, &metricsbindaddress},
+		{"metrics-tls-cert-file", config.Metrics.TLSCertFile, &metricsCertFile},
+		{"metrics-tls-key-file", config.Metrics.TLSKeyFile, &metricsKeyFile},
+		{"metrics-tls-client-ca-file", config.Metrics.TLSClientCAFile, &metricsClientCAFile},
+		{"local-api-socket", config.LocalAPISocket, &localAPISocket},
+		{"default-network-interface", config.DefaultNetworkInterface, &defaultNetworkInterface},
+		{"node-ip", config.NodeIP, &nodeIP},
+		{"downloadpath", config.DownloadPath, &downloadpath},
+		{"staged-bundle-path", config.StagedBundlePath, &stagedBundlePath},
+		{"install-mode", config.InstallMode, &installMode},
+		{"installer-audit-log", config.InstallerAuditLog, &installerAuditLog},
+		{"download-rate-limit", config.DownloadRateLimit, &downloadRateLimit},
+		{"bootstrap-kubeconfig", config.BootstrapKubeconfig, &bootstrapKubeConfig},
+		{"host-kubeconfig", config.HostKubeconfig, &hostKubeConfig},
+		{"key-type", config.KeyType, &keyType},
+		{"csr-signer-name", config.CSRSignerName, &csrSignerName},
+		{"key-store", config.KeyStore.Type, &keyStore},
+		{"private-key-dir", config.KeyStore.PrivateKeyDir, &privateKeyDir},
+		{"tpm-device", config.KeyStore.TPMDevice, &tpmDevice},
+		{"pkcs11-module", config.KeyStore.PKCS11Module, &pkcs11Module},
+		{"pkcs11-token-label", config.KeyStore.PKCS11TokenLabel, &pkcs11TokenLabel},
+		{"pkcs11-key-label", config.KeyStore.PKCS11KeyLabel, &pkcs11KeyLabel},
+		{"credential-encryption", config.CredentialEncryption.Type, &credentialEncryption},
+		{"credential-encryption-key-file", config.CredentialEncryption.KeyFile, &credentialKeyFile},
+		{"http-proxy", config.Proxy.HTTPProxy, &proxy.HTTPProxy},
+		{"https-proxy", config.Proxy.HTTPSProxy, &proxy.HTTPSProxy},
+		{"no-proxy", config.Proxy.NoProxy, &proxy.NoProxy},
+		{"agent-upgrade-public-key", config.AgentUpgradePublicKey, &agentUpgradePublicKey},
+		{"tracing-endpoint", config.Tracing.Endpoint, &tracingEndpoint},
+		{"log-format", config.Logging.Format, &logFormat},
+		{"log-file", config.Logging.File.Path, &logFile},
+	}
+	for _, setting := range stringSettings {
+		if setting.value != "" && !flags.Changed(setting.flag) {
+			*setting.target = setting.value
+		}
+	}
+	if config.SkipInstallation && !flags.Changed("skip-installation") {
+		skipInstallation = true
+	}
+	if config.UseInstallerController && !flags.Changed("use-installer-controller") {
+		useInstallerController = true
+	}
+	if config.SkipPreflightChecks && !flags.Changed("skip-preflight-checks") {
+		skipPreflightChecks = true
+	}
+	if config.EscalateWithSudo && !flags.Changed("escalate-with-sudo") {
+		escalateWithSudo = true
+	}
+	if config.RefuseInsecurePermissions && !flags.Changed("refuse-insecure-permissions") {
+		refuseInsecurePaths = true
+	}
+	if config.Tracing.Insecure && !flags.Changed("tracing-insecure") {
+		tracingInsecure = true
+	}
+	if config.FIPS && !flags.Changed("fips") {
+		fipsMode = true
+	}
+	if config.Logging.Stream && !flags.Changed("stream-logs") {
+		streamLogs = true
+	}
+	if config.Logging.StreamMaxSize > 0 && !flags.Changed("stream-logs-max-size") {
+		streamLogsMaxSize = config.Logging.StreamMaxSize
+	}
+	if config.Logging.File.MaxSize > 0 && !flags.Changed("log-file-max-size") {
+		logFileMaxSize = config.Logging.File.MaxSize
+	}
+	if config.Logging.File.MaxBackups > 0 && !flags.Changed("log-file-max-backups") {
+		logFileMaxBackups = config.Logging.File.MaxBackups
+	}
+	if config.Logging.File.MaxAge > 0 && !flags.Changed("log-file-max-age") {
+		logFileMaxAge = config.Logging.File.MaxAge
+	}
+	if len(config.Failover.Kubeconfigs) > 0 && !flags.Changed("failover-kubeconfigs") {
+		failoverKubeconfigs = strings.Join(config.Failover.Kubeconfigs, ",")
+	}
+	if config.Failover.ProbeInterval != nil && !flags.Changed("failover-probe-interval") {
+		failoverProbeInterval = config.Failover.ProbeInterval.Duration
+	}
+	if config.Failover.FailureThreshold > 0 && !flags.Changed("failover-threshold") {
+		failoverThreshold = config.Failover.FailureThreshold
+	}
+	if config.CSRApprovalTimeout != nil && !flags.Changed("csr-approval-timeout") {
+		csrApprovalTimeout = config.CSRApprovalTimeout.Duration
+	}
+	if config.CSRMaxRequests > 0 && !flags.Changed("csr-max-requests") {
+		csrMaxRequests = config.CSRMaxRequests
+	}
+	if config.CSRRetryJitter != nil && !flags.Changed("csr-retry-jitter") {
+		csrRetryJitter = config.CSRRetryJitter.Duration
+	}
+	if config.Watchdog.HungTimeout != nil && !flags.Changed("watchdog-hung-timeout") {
+		reconcileHungTimeout = config.Watchdog.HungTimeout.Duration
+	}
+	if config.Watchdog.CrashLoopThreshold > 0 && !flags.Changed("watchdog-crash-loop-threshold") {
+		crashLoopThreshold = config.Watchdog.CrashLoopThreshold
+	}
+	if config.Watchdog.CrashLoopWindow != nil && !flags.Changed("watchdog-crash-loop-window") {
+		crashLoopWindow = config.Watchdog.CrashLoopWindow.Duration
+	}
+	if config.NodeComponents.Units != nil && !flags.Changed("node-components") {
+		nodeComponents = strings.Join(config.NodeComponents.Units, ",")
+	}
+	if config.NodeComponents.CheckInterval != nil && !flags.Changed("node-components-check-interval") {
+		nodeComponentsCheckInterval = config.NodeComponents.CheckInterval.Duration
+	}
+	if len(config.FeatureGates) > 0 && !flags.Changed("feature-gates") {
+		if err := feature.MutableGates.SetFromMap(config.FeatureGates); err != nil {
+			return err
+		}
+	}
+	return applyReloadableAgentConfig(config, flags)
+}
+
+// applyReloadableAgentConfig sets the settings of the configuration file which can change
+// while the agent runs, the labels and the log verbosity of the agent and of its modules
+func applyReloadableAgentConfig(config *v1alpha1.AgentConfiguration, flags *pflag.FlagSet) error {
+	if config.Labels != nil && !flags.Changed("label") {
+		labelsMutex.Lock()
+		labels = make(labelFlags, len(config.Labels))
+		for key, value := range config.Labels {
+			labels[key] = value
+		}
+		labelsMutex.Unlock()
+	}
+	if config.Logging.Verbosity != nil && !flags.Changed("v") {
+		// klog.Level.Set sets the verbosity of the global klog logger
+		var level klog.Level
+		if err := level.Set(strconv.Itoa(int(*config.Logging.Verbosity))); err != nil {
+			return err
+		}
+	}
+	if config.Logging.ModuleVerbosity != nil && !flags.Changed("log-module-verbosity") {
+		levels := make(map[string]int, len(config.Logging.ModuleVerbosity))
+		for module, level := range config.Logging.ModuleVerbosity {
+			levels[module] = int(level)
+		}
+		moduleVerbosity.SetLevels(levels)
+	}
+	return nil
+}
+
+// configReloader reloads the configuration file on SIGHUP, applying the reloadable settings
+type configReloader struct {
+	path     string
+	hostName string
+	flags    *pflag.FlagSet
+	logger   logr.Logger
+	signals  chan os.Signal
+}
+
+// newConfigReloader starts catching SIGHUP, which would otherwise terminate the agent
+func newConfigReloader(path, hostName string, flags *pflag.FlagSet, logger logr.Logger) *configReloader {
+	r := &configReloader{
+		path:     path,
+		hostName: hostName,
+		flags:    flags,
+		logger:   logger,
+		signals:  make(chan os.Signal, 1),
+	}
+	signal.Notify(r.signals, syscall.SIGHUP)
+	return r
+}
+
+// Start implements manager.Runnable
+func (r *configReloader) Start(ctx context.Context) error {
+	defer signal.Stop(r.signals)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-r.signals:
+			if err := r.reload(ctx); err != nil {
+				r.logger.Error(err, "failed to reload the agent configuration", "path", r.path)
+			}
+		}
+	}
+}
+
+func (r *configReloader) reload(ctx context.Context) error {
+	r.logger.Info("Reloading the agent configuration", "path", r.path)
+	config, err := agentconfig.Load(r.path)
+	if err != nil {
+		return err
+	}
+
+	previousLabels := hostLabels()
+	if err := applyReloadableAgentConfig(config, r.flags); err != nil {
+		return err
+	}
+	if reflect.DeepEqual(previousLabels, hostLabels()) {
+		return nil
+	}
+	return registration.LocalHostRegistrar.UpdateLabels(ctx, r.hostName, namespace, hostLabels())
+}
+
+// hostLabels returns a copy of the labels of the agent, which are changed on reload
+func hostLabels() map[string]string {
+	labelsMutex.RLock()
+	defer labelsMutex.RUnlock()
+	hostLabels := make(map[string]string, len(labels))
+	for key, value := range labels {
+		hostLabels[key] = value
+	}
+	return hostLabels
+}
+
+// parseDownloadRateLimit parses the download rate limit, a quantity of bytes per second, zero when not set
+func parseDownloadRateLimit(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return 0, err
+	}
+	if quantity.Sign() <= 0 {
+		return 0, fmt.Errorf("the rate limit must be positive")
+	}
+	return quantity.Value(), nil
+}
@@ -0,0 +1,224 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// nolint: testpackage
+package main
+
+import (
+	"flag"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	pflag "github.com/spf13/pflag"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/agentconfig/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Agent configuration file", func() {
+	var (
+		flags  *pflag.FlagSet
+		config *v1alpha1.AgentConfiguration
+	)
+
+	BeforeEach(func() {
+		namespace = "default"
+		downloadpath = "/var/lib/byoh/bundles"
+		labels = make(labelFlags)
+
+		goFlags := flag.NewFlagSet("agent", flag.ContinueOnError)
+		goFlags.StringVar(&namespace, "namespace", "default", "")
+		goFlags.Var(&labels, "label", "")
+		flags = pflag.NewFlagSet("agent", pflag.ContinueOnError)
+		flags.AddGoFlagSet(goFlags)
+
+		config = &v1alpha1.AgentConfiguration{
+			Namespace:    "byoh-hosts",
+			DownloadPath: "/opt/byoh/bundles",
+			Labels:       map[string]string{"site": "apac"},
+		}
+	})
+
+	It("should apply the settings of the configuration file", func() {
+		Expect(flags.Parse(nil)).To(Succeed())
+		Expect(applyAgentConfig(config, flags)).To(Succeed())
+		Expect(namespace).To(Equal("byoh-hosts"))
+		Expect(downloadpath).To(Equal("/opt/byoh/bundles"))
+		Expect(labels).To(Equal(labelFlags{"site": "apac"}))
+	})
+
+	It("should apply the failover settings", func() {
+		failoverKubeconfigs = ""
+		failoverThreshold = 3
+		config.Failover = v1alpha1.FailoverConfiguration{
+			Kubeconfigs:      []string{"/etc/byoh/standby-a.conf", "/etc/byoh/standby-b.conf"},
+			ProbeInterval:    &metav1.Duration{Duration: 30 * time.Second},
+			FailureThreshold: 5,
+		}
+		Expect(flags.Parse(nil)).To(Succeed())
+		Expect(applyAgentConfig(config, flags)).To(Succeed())
+		Expect(failoverKubeconfigs).To(Equal("/etc/byoh/standby-a.conf,/etc/byoh/standby-b.conf"))
+		Expect(failoverProbeInterval).To(Equal(30 * time.Second))
+		Expect(failoverThreshold).To(Equal(5))
+	})
+
+	It("should apply the watchdog settings", func() {
+		crashLoopThreshold = 3
+		config.Watchdog = v1alpha1.WatchdogConfiguration{
+			HungTimeout:        &metav1.Duration{Duration: time.Hour},
+			CrashLoopThreshold: 5,
+			CrashLoopWindow:    &metav1.Duration{Duration: 30 * time.Minute},
+		}
+		Expect(flags.Parse(nil)).To(Succeed())
+		Expect(applyAgentConfig(config, flags)).To(Succeed())
+		Expect(reconcileHungTimeout).To(Equal(time.Hour))
+		Expect(crashLoopThreshold).To(Equal(5))
+		Expect(crashLoopWindow).To(Equal(30 * time.Minute))
+	})
+
+	It("should apply the node components settings", func() {
+		nodeComponents = "containerd,kubelet"
+		config.NodeComponents = v1alpha1.NodeComponentsConfiguration{
+			Units:         []string{"docker", "kubelet"},
+			CheckInterval: &metav1.Duration{Duration: time.Minute},
+		}
+		Expect(flags.Parse(nil)).To(Succeed())
+		Expect(applyAgentConfig(config, flags)).To(Succeed())
+		Expect(nodeComponents).To(Equal("docker,kubelet"))
+		Expect(nodeComponentsCheckInterval).To(Equal(time.Minute))
+	})
+
+	It("should apply the FIPS settings", func() {
+		fipsMode = false
+		keyType = "ECDSA-P256"
+		config.FIPS = true
+		config.KeyType = "RSA-3072"
+		Expect(flags.Parse(nil)).To(Succeed())
+		Expect(applyAgentConfig(config, flags)).To(Succeed())
+		Expect(fipsMode).To(BeTrue())
+		Expect(keyType).To(Equal("RSA-3072"))
+	})
+
+	It("should apply the CSR settings", func() {
+		csrMaxRequests = 0
+		config.CSRApprovalTimeout = &metav1.Duration{Duration: 10 * time.Minute}
+		config.CSRMaxRequests = 6
+		config.CSRRetryJitter = &metav1.Duration{Duration: time.Minute}
+		Expect(flags.Parse(nil)).To(Succeed())
+		Expect(applyAgentConfig(config, flags)).To(Succeed())
+		Expect(csrApprovalTimeout).To(Equal(10 * time.Minute))
+		Expect(csrMaxRequests).To(Equal(6))
+		Expect(csrRetryJitter).To(Equal(time.Minute))
+	})
+
+	It("should apply the download rate limit", func() {
+		downloadRateLimit = ""
+		config.DownloadRateLimit = "2Mi"
+		Expect(flags.Parse(nil)).To(Succeed())
+		Expect(applyAgentConfig(config, flags)).To(Succeed())
+		Expect(downloadRateLimit).To(Equal("2Mi"))
+
+		bytesPerSecond, err := parseDownloadRateLimit(downloadRateLimit)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bytesPerSecond).To(Equal(int64(2 * 1024 * 1024)))
+	})
+
+	It("should reject an invalid download rate limit", func() {
+		_, err := parseDownloadRateLimit("fast")
+		Expect(err).To(HaveOccurred())
+		_, err = parseDownloadRateLimit("-1M")
+		Expect(err).To(MatchError("the rate limit must be positive"))
+
+		bytesPerSecond, err := parseDownloadRateLimit("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bytesPerSecond).To(BeZero())
+	})
+
+	It("should apply the security self-check settings", func() {
+		refuseInsecurePaths = false
+		config.RefuseInsecurePermissions = true
+		Expect(flags.Parse(nil)).To(Succeed())
+		Expect(applyAgentConfig(config, flags)).To(Succeed())
+		Expect(refuseInsecurePaths).To(BeTrue())
+	})
+
+	It("should apply the tracing settings", func() {
+		tracingEndpoint = ""
+		tracingInsecure = false
+		config.Tracing = v1alpha1.TracingConfiguration{Endpoint: "otel-collector:4318", Insecure: true}
+		Expect(flags.Parse(nil)).To(Succeed())
+		Expect(applyAgentConfig(config, flags)).To(Succeed())
+		Expect(tracingEndpoint).To(Equal("otel-collector:4318"))
+		Expect(tracingInsecure).To(BeTrue())
+	})
+
+	It("should apply the logging settings", func() {
+		logFormat = "json"
+		logFile = ""
+		logFileMaxBackups = 5
+		moduleVerbosity.SetLevels(nil)
+		config.Logging = v1alpha1.LoggingConfiguration{
+			Format:          "text",
+			ModuleVerbosity: map[string]int32{"installer": 4},
+			File:            v1alpha1.LogFileConfiguration{Path: "/var/log/byoh/agent.log", MaxBackups: 10},
+		}
+		Expect(flags.Parse(nil)).To(Succeed())
+		Expect(applyAgentConfig(config, flags)).To(Succeed())
+		Expect(logFormat).To(Equal("text"))
+		Expect(logFile).To(Equal("/var/log/byoh/agent.log"))
+		Expect(logFileMaxBackups).To(Equal(10))
+		Expect(moduleVerbosity.String()).To(Equal("installer=4"))
+
+		config.Logging.ModuleVerbosity = map[string]int32{"controller": 2}
+		Expect(applyReloadableAgentConfig(config, flags)).To(Succeed())
+		Expect(moduleVerbosity.String()).To(Equal("controller=2"))
+	})
+
+	It("should apply the CSR signer", func() {
+		csrSignerName = "kubernetes.io/kube-apiserver-client"
+		config.CSRSignerName = "clusterissuers.cert-manager.io/byoh-ca"
+		Expect(flags.Parse(nil)).To(Succeed())
+		Expect(applyAgentConfig(config, flags)).To(Succeed())
+		Expect(csrSignerName).To(Equal("clusterissuers.cert-manager.io/byoh-ca"))
+	})
+
+	It("should apply the key store settings", func() {
+		keyStore = "file"
+		privateKeyDir = ""
+		pkcs11KeyLabel = "byoh-client"
+		config.KeyStore = v1alpha1.KeyStoreConfiguration{
+			Type:             "pkcs11",
+			PrivateKeyDir:    "/etc/byoh/keys",
+			PKCS11Module:     "/usr/lib/softhsm/libsofthsm2.so",
+			PKCS11TokenLabel: "byoh",
+		}
+		Expect(flags.Parse(nil)).To(Succeed())
+		Expect(applyAgentConfig(config, flags)).To(Succeed())
+		Expect(keyStore).To(Equal("pkcs11"))
+		Expect(privateKeyDir).To(Equal("/etc/byoh/keys"))
+		Expect(pkcs11Module).To(Equal("/usr/lib/softhsm/libsofthsm2.so"))
+		Expect(pkcs11TokenLabel).To(Equal("byoh"))
+		Expect(pkcs11KeyLabel).To(Equal("byoh-client"))
+	})
+
+	It("should apply the credential encryption settings", func() {
+		credentialEncryption = "none"
+		credentialKeyFile = ""
+		config.CredentialEncryption = v1alpha1.CredentialEncryptionConfiguration{
+			Type:    "key-file",
+			KeyFile: "/run/byoh/credentials.key",
+		}
+		Expect(flags.Parse(nil)).To(Succeed())
+		Expect(applyAgentConfig(config, flags)).To(Succeed())
+		Expect(credentialEncryption).To(Equal("key-file"))
+		Expect(credentialKeyFile).To(Equal("/run/byoh/credentials.key"))
+	})
+
+	It("should prefer the flags set on the command line", func() {
+		Expect(flags.Parse([]string{"--namespace", "team-a", "--label", "site=emea"})).To(Succeed())
+		Expect(applyAgentConfig(config, flags)).To(Succeed())
+		Expect(namespace).To(Equal("team-a"))
+		Expect(downloadpath).To(Equal("/opt/byoh/bundles"))
+		Expect(labels).To(Equal(labelFlags{"site": "emea"}))
+	})
+})
@@ -0,0 +1,153 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package debugbundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/privilege"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// BundleKey is the key of the tarball in the ConfigMap the debug bundle is uploaded as
+	BundleKey = "debug-bundle.tar.gz"
+
+	// HostLabel records the name of the ByoHost on the ConfigMaps of its debug bundles
+	HostLabel = "byoh.infrastructure.cluster.x-k8s.io/debug-bundle-host"
+
+	// MaxUploadSize keeps the ConfigMap of a debug bundle under the 1MiB object size limit of etcd
+	MaxUploadSize = 1000 * 1024
+
+	// errorsFile lists the sources of the bundle which could not be collected
+	errorsFile = "errors.txt"
+)
+
+// Source is a file of the debug bundle
+type Source struct {
+	// Name is the path of the file in the bundle
+	Name    string
+	Collect func(ctx context.Context) ([]byte, error)
+}
+
+// File collects a local file
+func File(name, path string) Source {
+	return Source{
+		Name: name,
+		Collect: func(context.Context) ([]byte, error) {
+			return os.ReadFile(path)
+		},
+	}
+}
+
+// Command collects the output of a command, e.g. the journal of a service, run with the
+// escalator as the journals of the other services are only readable by root
+func Command(name string, escalator privilege.Escalator, command string, args ...string) Source {
+	return Source{
+		Name: name,
+		Collect: func(context.Context) ([]byte, error) {
+			output, err := escalator.Command(command, args...).CombinedOutput()
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w: %s", command, strings.Join(args, " "), err, output)
+			}
+			return output, nil
+		},
+	}
+}
+
+// Object collects an object of the management cluster as YAML, without its managed fields
+func Object(name string, c client.Client, key client.ObjectKey, obj client.Object) Source {
+	return Source{
+		Name: name,
+		Collect: func(ctx context.Context) ([]byte, error) {
+			if err := c.Get(ctx, key, obj); err != nil {
+				return nil, err
+			}
+			obj.SetManagedFields(nil)
+			return yaml.Marshal(obj)
+		},
+	}
+}
+
+// Write writes the gzipped tarball of the sources. A source failing to be collected does not
+// fail the bundle, its error is listed in the errors.txt file of the bundle instead.
+func Write(ctx context.Context, w io.Writer, sources []Source) error {
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+	now := time.Now()
+
+	var failures []string
+	for _, source := range sources {
+		content, err := source.Collect(ctx)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", source.Name, err))
+			continue
+		}
+		if err := writeFile(tarWriter, source.Name, content, now); err != nil {
+			return err
+		}
+	}
+	if len(failures) > 0 {
+		if err := writeFile(tarWriter, errorsFile, []byte(strings.Join(failures, "\n")+"\n"), now); err != nil {
+			return err
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
+
+func writeFile(tarWriter *tar.Writer, name string, content []byte, modTime time.Time) error {
+	err := tarWriter.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(content)),
+		ModTime: modTime,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = tarWriter.Write(content)
+	return err
+}
+
+// Upload uploads the debug bundle as a ConfigMap in the namespace of the ByoHost. The ConfigMap
+// is owned by the ByoHost, so that it is garbage collected along with it.
+func Upload(ctx context.Context, c client.Client, byoHost *infrastructurev1beta1.ByoHost, bundle []byte) (*corev1.ConfigMap, error) {
+	if len(bundle) > MaxUploadSize {
+		return nil, fmt.Errorf("the debug bundle of %d bytes exceeds the %d bytes a ConfigMap can hold", len(bundle), MaxUploadSize)
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: byoHost.Name + "-debug-",
+			Namespace:    byoHost.Namespace,
+			Labels:       map[string]string{HostLabel: byoHost.Name},
+			// the host agent is not allowed to block the deletion of its ByoHost
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: infrastructurev1beta1.GroupVersion.String(),
+				Kind:       "ByoHost",
+				Name:       byoHost.Name,
+				UID:        byoHost.UID,
+			}},
+		},
+		BinaryData: map[string][]byte{BundleKey: bundle},
+	}
+	if err := c.Create(ctx, configMap); err != nil {
+		return nil, err
+	}
+	return configMap, nil
+}
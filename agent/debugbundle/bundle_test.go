@@ -0,0 +1,125 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package debugbundle_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/debugbundle"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/privilege"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/test/builder"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// readBundle returns the files of a debug bundle by name
+func readBundle(bundle []byte) map[string]string {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(bundle))
+	Expect(err).NotTo(HaveOccurred())
+	tarReader := tar.NewReader(gzipReader)
+	files := map[string]string{}
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return files
+		}
+		Expect(err).NotTo(HaveOccurred())
+		content, err := io.ReadAll(tarReader)
+		Expect(err).NotTo(HaveOccurred())
+		files[header.Name] = string(content)
+	}
+}
+
+var _ = Describe("Debug bundle", func() {
+	var (
+		ctx     context.Context
+		c       client.Client
+		byoHost *infrastructurev1beta1.ByoHost
+	)
+
+	BeforeEach(func() {
+		ctx = context.TODO()
+		scheme := runtime.NewScheme()
+		Expect(infrastructurev1beta1.AddToScheme(scheme)).To(Succeed())
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		byoHost = builder.ByoHost("default", "edge-host").Build()
+		byoHost.Name = "edge-host"
+		c = fake.NewClientBuilder().WithScheme(scheme).WithObjects(byoHost).Build()
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(byoHost), byoHost)).To(Succeed())
+	})
+
+	It("should collect the files, the command outputs and the ByoHost", func() {
+		dir, err := os.MkdirTemp("", "debugbundle")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		auditLog := filepath.Join(dir, "installer-audit.log")
+		Expect(os.WriteFile(auditLog, []byte(`{"command":"apt-get install kubelet"}`+"\n"), 0600)).To(Succeed())
+
+		var bundle bytes.Buffer
+		Expect(debugbundle.Write(ctx, &bundle, []debugbundle.Source{
+			debugbundle.File("installer-audit.log", auditLog),
+			debugbundle.Command("journal/kubelet.log", privilege.Escalator{}, "echo", "kubelet started"),
+			debugbundle.Object("byohost.yaml", c, client.ObjectKeyFromObject(byoHost), &infrastructurev1beta1.ByoHost{}),
+		})).To(Succeed())
+
+		files := readBundle(bundle.Bytes())
+		Expect(files).To(HaveKeyWithValue("installer-audit.log", `{"command":"apt-get install kubelet"}`+"\n"))
+		Expect(files).To(HaveKeyWithValue("journal/kubelet.log", "kubelet started\n"))
+		Expect(files["byohost.yaml"]).To(ContainSubstring("name: edge-host"))
+		Expect(files["byohost.yaml"]).NotTo(ContainSubstring("managedFields"))
+		Expect(files).NotTo(HaveKey("errors.txt"))
+	})
+
+	It("should list the sources which could not be collected", func() {
+		var bundle bytes.Buffer
+		Expect(debugbundle.Write(ctx, &bundle, []debugbundle.Source{
+			{Name: "journal/containerd.log", Collect: func(context.Context) ([]byte, error) {
+				return nil, errors.New("no journal files were found")
+			}},
+			debugbundle.File("installer-audit.log", "/nonexistent/installer-audit.log"),
+			debugbundle.Object("byohost.yaml", c, client.ObjectKeyFromObject(byoHost), &infrastructurev1beta1.ByoHost{}),
+		})).To(Succeed())
+
+		files := readBundle(bundle.Bytes())
+		Expect(files).To(HaveKey("byohost.yaml"))
+		Expect(files).NotTo(HaveKey("journal/containerd.log"))
+		Expect(files["errors.txt"]).To(ContainSubstring("journal/containerd.log: no journal files were found"))
+		Expect(files["errors.txt"]).To(ContainSubstring("installer-audit.log: open /nonexistent/installer-audit.log"))
+	})
+
+	It("should upload the bundle as a ConfigMap owned by the ByoHost", func() {
+		configMap, err := debugbundle.Upload(ctx, c, byoHost, []byte("bundle"))
+		Expect(err).NotTo(HaveOccurred())
+
+		uploaded := &corev1.ConfigMap{}
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(configMap), uploaded)).To(Succeed())
+		Expect(uploaded.Name).To(HavePrefix("edge-host-debug-"))
+		Expect(uploaded.Labels).To(HaveKeyWithValue(debugbundle.HostLabel, "edge-host"))
+		Expect(uploaded.BinaryData).To(HaveKeyWithValue(debugbundle.BundleKey, []byte("bundle")))
+		Expect(uploaded.OwnerReferences).To(HaveLen(1))
+		Expect(uploaded.OwnerReferences[0].Name).To(Equal("edge-host"))
+		Expect(uploaded.OwnerReferences[0].Kind).To(Equal("ByoHost"))
+	})
+
+	It("should not upload a bundle larger than a ConfigMap can hold", func() {
+		_, err := debugbundle.Upload(ctx, c, byoHost, make([]byte, debugbundle.MaxUploadSize+1))
+		Expect(err).To(MatchError(ContainSubstring("exceeds the")))
+
+		configMaps := &corev1.ConfigMapList{}
+		Expect(c.List(ctx, configMaps)).To(Succeed())
+		Expect(configMaps.Items).To(BeEmpty())
+	})
+})
@@ -0,0 +1,6 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package debugbundle collects the logs and the state of a host into a tarball, and uploads it to
+// the management cluster, so that the failed enrollments can be triaged without logging in to the host.
+package debugbundle
@@ -0,0 +1,104 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/registration"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/feature"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// decommissionCommand is the agent subcommand removing the host from the management cluster
+	decommissionCommand = "decommission"
+	// decommissionTimeout is how long to wait for the host to be detached and cleaned up
+	decommissionTimeout = 30 * time.Minute
+	// decommissionPollInterval is how often the ByoHost is checked while it is detached
+	decommissionPollInterval = 5 * time.Second
+)
+
+// decommission removes the host from the management cluster. The ByoHost is marked
+// unschedulable and for decommission, which has the controller manager delete the
+// Machine it is attached to so that the node is drained. Once the running agent has
+// cleaned the host up, the ByoHost and the local credentials are deleted.
+func decommission(ctx context.Context, k8sClient client.Client, hostName string, logger logr.Logger) error {
+	key := types.NamespacedName{Name: hostName, Namespace: namespace}
+	byoHost := &infrastructurev1beta1.ByoHost{}
+	err := k8sClient.Get(ctx, key, byoHost)
+	switch {
+	case apierrors.IsNotFound(err):
+		logger.Info("ByoHost not found, removing the local credentials only", "name", hostName)
+		return removeCredentials(logger)
+	case err != nil:
+		return err
+	}
+
+	helper, err := patch.NewHelper(byoHost, k8sClient)
+	if err != nil {
+		return err
+	}
+	if byoHost.Annotations == nil {
+		byoHost.Annotations = map[string]string{}
+	}
+	byoHost.Annotations[infrastructurev1beta1.UnschedulableAnnotation] = ""
+	byoHost.Annotations[infrastructurev1beta1.DecommissionAnnotation] = ""
+	if err = helper.Patch(ctx, byoHost); err != nil {
+		return err
+	}
+
+	logger.Info("Waiting for the host to be detached and cleaned up", "name", hostName)
+	err = wait.PollImmediate(decommissionPollInterval, decommissionTimeout, func() (bool, error) {
+		if err := k8sClient.Get(ctx, key, byoHost); err != nil {
+			return false, client.IgnoreNotFound(err)
+		}
+		_, cleaningUp := byoHost.Annotations[infrastructurev1beta1.HostCleanupAnnotation]
+		return byoHost.Status.MachineRef == nil && !cleaningUp, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Deleting ByoHost", "name", hostName)
+	if err := k8sClient.Delete(ctx, byoHost); client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	return removeCredentials(logger)
+}
+
+// removeCredentials deletes the kubeconfig, private key and client certificate generated with
+// secure access. The keys of the tpm and pkcs11 key stores are kept in their store.
+func removeCredentials(logger logr.Logger) error {
+	if !feature.Gates.Enabled(feature.SecureAccess) {
+		return nil
+	}
+	kubeConfigPath, err := hostKubeConfigPath()
+	if err != nil {
+		return err
+	}
+	keyPath, err := privateKeyDirPath(registration.TmpPrivateKey)
+	if err != nil {
+		return err
+	}
+	certPath, err := privateKeyDirPath(registration.ClientCertificateFile)
+	if err != nil {
+		return err
+	}
+	for _, path := range []string{kubeConfigPath, keyPath, certPath} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		logger.Info("Removed credentials", "path", path)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/go-logr/logr"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/installer"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/privilege"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// defaultBundleRepo is the bundle repository of the ByoClusters without BundleLookupBaseRegistry
+	defaultBundleRepo = "projects.registry.vmware.com/cluster_api_provider_bringyourownhost"
+	// unknownBundleTag stands for the BundleLookupTag of the ByoCluster, before the host is attached to a machine
+	unknownBundleTag = "<bundle-lookup-tag>"
+)
+
+// bundlePreviewer previews the install steps of a bundle, implemented by the in-tree installer
+type bundlePreviewer interface {
+	Preview(bundleRepo, k8sVersion, tag string) (bundleAddr, steps string, err error)
+}
+
+// dryRun registers the host, resolves the bundle of its OS and prints the steps which would install
+// it, without running them. The ByoHost registered by the dry run is deleted afterwards, so that it
+// is not attached to a machine without an agent to bootstrap it.
+func dryRun(ctx context.Context, k8sClient client.Client, hostName string, out io.Writer, logger logr.Logger) (reterr error) {
+	key := types.NamespacedName{Name: hostName, Namespace: namespace}
+	byoHost := &infrastructurev1beta1.ByoHost{}
+	err := k8sClient.Get(ctx, key, byoHost)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	registered := err == nil
+
+	if err = handleHostRegistration(k8sClient, hostName, logger); err != nil {
+		return err
+	}
+	if err = k8sClient.Get(ctx, key, byoHost); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Registered host %s in namespace %s\n", hostName, namespace)
+	if !registered {
+		defer func() {
+			if err := k8sClient.Delete(ctx, byoHost); client.IgnoreNotFound(err) != nil && reterr == nil {
+				reterr = err
+			}
+		}()
+	}
+
+	if skipInstallation || useInstallerController {
+		fmt.Fprintln(out, "The kubernetes components are not installed by the agent")
+		return nil
+	}
+
+	annotations := byoHost.GetAnnotations()
+	k8sVersion := dryRunK8sVersion
+	if k8sVersion == "" {
+		k8sVersion = annotations[infrastructurev1beta1.K8sVersionAnnotation]
+	}
+	if k8sVersion == "" {
+		return fmt.Errorf("the host is not attached to a machine, set the kubernetes version with --dry-run-k8s-version")
+	}
+	bundleRepo := annotations[infrastructurev1beta1.BundleLookupBaseRegistryAnnotation]
+	if bundleRepo == "" {
+		bundleRepo = defaultBundleRepo
+	}
+	tag := annotations[infrastructurev1beta1.BundleLookupTagAnnotation]
+	if tag == "" {
+		tag = unknownBundleTag
+	}
+
+	bundleType := installer.BundleTypeK8s
+	if annotations[infrastructurev1beta1.K8sDistributionAnnotation] == string(infrastructurev1beta1.KubernetesDistributionRKE2) {
+		bundleType = installer.BundleTypeRKE2
+	}
+	i, err := installer.New(downloadpath, bundleType, logger.V(1))
+	if err != nil {
+		return err
+	}
+	i.SetProxy(proxy)
+	if bundleType == installer.BundleTypeK8s {
+		i.SetInstallMode(installer.InstallMode(installMode))
+	}
+	i.SetEscalator(privilege.Escalator{Sudo: escalateWithSudo})
+
+	var previewer bundlePreviewer = i
+	bundleAddr, steps, err := previewer.Preview(bundleRepo, k8sVersion, tag)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Kubernetes %s would be installed from the bundle %s with the steps:\n%s\n", k8sVersion, bundleAddr, steps)
+	return nil
+}
@@ -0,0 +1,7 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package failover runs the host agent against one of several management clusters, and fails
+// over to a standby management cluster when the active one becomes unreachable, e.g. in the
+// disaster recovery of the management cluster.
+package failover
@@ -0,0 +1,169 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package failover
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// DefaultProbeInterval is how often the active management cluster is probed
+	DefaultProbeInterval = 10 * time.Second
+
+	// DefaultFailureThreshold is the number of probes in a row the active management cluster
+	// has to fail before the agent fails over
+	DefaultFailureThreshold = 3
+
+	// probeTimeout bounds a probe of a management cluster
+	probeTimeout = 5 * time.Second
+)
+
+// errUnreachable stops the agent running against an unreachable management cluster
+var errUnreachable = errors.New("management cluster unreachable")
+
+// Endpoint is a management cluster the agent can run against
+type Endpoint struct {
+	// Name identifies the management cluster in the logs, e.g. the path of its kubeconfig
+	Name   string
+	Config *rest.Config
+}
+
+// Failover runs the agent against the first reachable of its Endpoints, the primary management
+// cluster first, and restarts it against the next reachable endpoint once the active one failed
+// FailureThreshold probes in a row. The agent does not fail back to the primary management
+// cluster on its own, it has to be restarted.
+type Failover struct {
+	Endpoints []Endpoint
+	// ProbeInterval defaults to DefaultProbeInterval
+	ProbeInterval time.Duration
+	// FailureThreshold defaults to DefaultFailureThreshold
+	FailureThreshold int
+	// Probe checks that a management cluster is reachable, defaults to querying its readyz endpoint
+	Probe  func(ctx context.Context, config *rest.Config) error
+	Logger logr.Logger
+}
+
+// Run runs the agent until the context is done, or the agent fails while its management cluster
+// is reachable. The context passed to run is cancelled to fail over, run has to return then.
+func (f *Failover) Run(ctx context.Context, run func(ctx context.Context, endpoint Endpoint) error) error {
+	if len(f.Endpoints) == 0 {
+		return errors.New("no management cluster to run against")
+	}
+	active, ok := f.nextReachable(ctx, 0)
+	if !ok {
+		return nil
+	}
+	for {
+		endpoint := f.Endpoints[active]
+		f.Logger.Info("Running against the management cluster", "endpoint", endpoint.Name)
+		err := f.runEndpoint(ctx, endpoint, run)
+		if !errors.Is(err, errUnreachable) {
+			return err
+		}
+		if len(f.Endpoints) == 1 {
+			f.Logger.Info("Management cluster unreachable, no standby to fail over to", "endpoint", endpoint.Name)
+		}
+		if active, ok = f.nextReachable(ctx, active+1); !ok {
+			return nil
+		}
+		f.Logger.Info("Failing over to the management cluster", "from", endpoint.Name, "to", f.Endpoints[active].Name)
+	}
+}
+
+// runEndpoint runs the agent against the endpoint until it fails the probes in a row, the agent
+// stops, or the context is done
+func (f *Failover) runEndpoint(ctx context.Context, endpoint Endpoint, run func(ctx context.Context, endpoint Endpoint) error) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- run(runCtx, endpoint) }()
+
+	ticker := time.NewTicker(f.probeInterval())
+	defer ticker.Stop()
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return <-done
+		case err := <-done:
+			// the agent stopping on its own, e.g. failing to register its host, is only failed
+			// over when its management cluster is unreachable
+			if err != nil && f.probe(ctx, endpoint) != nil {
+				return errUnreachable
+			}
+			return err
+		case <-ticker.C:
+		}
+		if err := f.probe(ctx, endpoint); err != nil {
+			failures++
+			f.Logger.Info("Management cluster probe failed", "endpoint", endpoint.Name, "failures", failures, "error", err.Error())
+			if failures >= f.failureThreshold() {
+				cancel()
+				<-done
+				return errUnreachable
+			}
+			continue
+		}
+		failures = 0
+	}
+}
+
+// nextReachable returns the first reachable endpoint starting from the given one, the active
+// endpoint being probed last. False is returned when the context is done first.
+func (f *Failover) nextReachable(ctx context.Context, from int) (int, bool) {
+	for {
+		for i := 0; i < len(f.Endpoints); i++ {
+			next := (from + i) % len(f.Endpoints)
+			if err := f.probe(ctx, f.Endpoints[next]); err != nil {
+				f.Logger.Info("Management cluster unreachable", "endpoint", f.Endpoints[next].Name, "error", err.Error())
+				continue
+			}
+			return next, true
+		}
+		select {
+		case <-ctx.Done():
+			return 0, false
+		case <-time.After(f.probeInterval()):
+		}
+	}
+}
+
+func (f *Failover) probe(ctx context.Context, endpoint Endpoint) error {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	if f.Probe != nil {
+		return f.Probe(ctx, endpoint.Config)
+	}
+	return probeReadyz(ctx, endpoint.Config)
+}
+
+// probeReadyz checks the readiness of the API server of the management cluster
+func probeReadyz(ctx context.Context, config *rest.Config) error {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	_, err = clientset.Discovery().RESTClient().Get().AbsPath("/readyz").DoRaw(ctx)
+	return err
+}
+
+func (f *Failover) probeInterval() time.Duration {
+	if f.ProbeInterval == 0 {
+		return DefaultProbeInterval
+	}
+	return f.ProbeInterval
+}
+
+func (f *Failover) failureThreshold() int {
+	if f.FailureThreshold == 0 {
+		return DefaultFailureThreshold
+	}
+	return f.FailureThreshold
+}
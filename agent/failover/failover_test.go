@@ -0,0 +1,164 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package failover_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/failover"
+	"k8s.io/client-go/rest"
+)
+
+// managementClusters fakes the reachability of the management clusters, by host
+type managementClusters struct {
+	sync.Mutex
+	down map[string]bool
+	runs []string
+}
+
+func (m *managementClusters) setDown(host string, down bool) {
+	m.Lock()
+	defer m.Unlock()
+	m.down[host] = down
+}
+
+func (m *managementClusters) probe(_ context.Context, config *rest.Config) error {
+	m.Lock()
+	defer m.Unlock()
+	if m.down[config.Host] {
+		return errors.New("connect: no route to host")
+	}
+	return nil
+}
+
+func (m *managementClusters) ran() []string {
+	m.Lock()
+	defer m.Unlock()
+	return append([]string{}, m.runs...)
+}
+
+var _ = Describe("Management cluster failover", func() {
+	var (
+		clusters *managementClusters
+		f        *failover.Failover
+		ctx      context.Context
+		cancel   context.CancelFunc
+		// agentErr is returned by the agent when it stops on its own
+		agentErr chan error
+		// done is closed once failover returned runErr
+		done   chan struct{}
+		runErr error
+	)
+
+	// run runs the agent until its context is done or it fails
+	run := func(ctx context.Context, endpoint failover.Endpoint) error {
+		clusters.Lock()
+		clusters.runs = append(clusters.runs, endpoint.Name)
+		clusters.Unlock()
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-agentErr:
+			return err
+		}
+	}
+
+	BeforeEach(func() {
+		clusters = &managementClusters{down: map[string]bool{}}
+		agentErr = make(chan error, 1)
+		f = &failover.Failover{
+			Endpoints: []failover.Endpoint{
+				{Name: "primary", Config: &rest.Config{Host: "https://primary:6443"}},
+				{Name: "standby", Config: &rest.Config{Host: "https://standby:6443"}},
+			},
+			ProbeInterval:    10 * time.Millisecond,
+			FailureThreshold: 2,
+			Probe:            clusters.probe,
+			Logger:           logr.Discard(),
+		}
+		ctx, cancel = context.WithCancel(context.Background())
+		done = make(chan struct{})
+		runErr = nil
+	})
+
+	AfterEach(func() {
+		cancel()
+		Eventually(done).Should(BeClosed())
+	})
+
+	startFailover := func() {
+		go func() {
+			defer close(done)
+			runErr = f.Run(ctx, run)
+		}()
+	}
+
+	It("should run against the primary management cluster while it is reachable", func() {
+		startFailover()
+		Eventually(clusters.ran).Should(Equal([]string{"primary"}))
+
+		Consistently(clusters.ran, 100*time.Millisecond).Should(Equal([]string{"primary"}))
+		cancel()
+		Eventually(done).Should(BeClosed())
+		Expect(runErr).NotTo(HaveOccurred())
+	})
+
+	It("should fail over to the standby management cluster when the primary is unreachable", func() {
+		startFailover()
+		Eventually(clusters.ran).Should(Equal([]string{"primary"}))
+
+		clusters.setDown("https://primary:6443", true)
+		Eventually(clusters.ran).Should(Equal([]string{"primary", "standby"}))
+
+		// the agent does not fail back on its own
+		clusters.setDown("https://primary:6443", false)
+		Consistently(clusters.ran, 100*time.Millisecond).Should(Equal([]string{"primary", "standby"}))
+		cancel()
+		Eventually(done).Should(BeClosed())
+		Expect(runErr).NotTo(HaveOccurred())
+	})
+
+	It("should start against the standby management cluster when the primary is unreachable", func() {
+		clusters.setDown("https://primary:6443", true)
+		startFailover()
+
+		Eventually(clusters.ran).Should(Equal([]string{"standby"}))
+	})
+
+	It("should wait for a management cluster to be reachable", func() {
+		clusters.setDown("https://primary:6443", true)
+		clusters.setDown("https://standby:6443", true)
+		startFailover()
+		Consistently(clusters.ran, 100*time.Millisecond).Should(BeEmpty())
+
+		clusters.setDown("https://standby:6443", false)
+		Eventually(clusters.ran).Should(Equal([]string{"standby"}))
+	})
+
+	It("should return the error of the agent while its management cluster is reachable", func() {
+		startFailover()
+		Eventually(clusters.ran).Should(Equal([]string{"primary"}))
+
+		agentErr <- errors.New("unable to create controller")
+		Eventually(done).Should(BeClosed())
+		Expect(runErr).To(MatchError("unable to create controller"))
+		Expect(clusters.ran()).To(Equal([]string{"primary"}))
+	})
+
+	It("should fail over when the agent fails against an unreachable management cluster", func() {
+		f.FailureThreshold = 1000
+		startFailover()
+		Eventually(clusters.ran).Should(Equal([]string{"primary"}))
+
+		clusters.setDown("https://primary:6443", true)
+		agentErr <- errors.New("error registering host")
+		Eventually(clusters.ran).Should(Equal([]string{"primary", "standby"}))
+	})
+})
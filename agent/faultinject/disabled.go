@@ -0,0 +1,10 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !faultinject
+// +build !faultinject
+
+package faultinject
+
+// Enabled is true when the agent is built with the faultinject tag
+const Enabled = false
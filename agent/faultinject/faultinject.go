@@ -0,0 +1,101 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package faultinject injects faults in the host agent, e.g. failed bundle downloads, delayed
+// CSR issuance or dropped heartbeats, so that the e2e tests can verify the behavior of the
+// controllers under failure. The faults are only injected by the agent built with the
+// faultinject tag, they are controlled by the annotations of the ByoHost or, for the agent
+// which has not registered its host yet, by the BYOH_FAULT_* environment variables.
+package faultinject
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fault is a fault the agent can inject
+type Fault string
+
+const (
+	// FailBundleDownload fails the download of the bundle when set to "true"
+	FailBundleDownload Fault = "fail-bundle-download"
+	// DelayCSRIssuance delays the wait for the issuance of the client certificate by the
+	// duration it is set to, e.g. "5m"
+	DelayCSRIssuance Fault = "delay-csr-issuance"
+	// DropHeartbeats drops the heartbeats of the agent when set to "true"
+	DropHeartbeats Fault = "drop-heartbeats"
+)
+
+const (
+	// AnnotationPrefix is the prefix of the ByoHost annotations injecting the faults, e.g.
+	// faults.byoh.infrastructure.cluster.x-k8s.io/drop-heartbeats
+	AnnotationPrefix = "faults.byoh.infrastructure.cluster.x-k8s.io/"
+	// EnvPrefix is the prefix of the environment variables injecting the faults, e.g.
+	// BYOH_FAULT_DROP_HEARTBEATS
+	EnvPrefix = "BYOH_FAULT_"
+)
+
+// ErrInjected is the error of the injected failures
+var ErrInjected = errors.New("injected fault")
+
+// Annotation returns the ByoHost annotation injecting the fault
+func (f Fault) Annotation() string {
+	return AnnotationPrefix + string(f)
+}
+
+// Env returns the environment variable injecting the fault
+func (f Fault) Env() string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(string(f), "-", "_"))
+}
+
+// Active returns true if the fault is set to "true" and the agent is built with the faultinject tag
+func Active(fault Fault, annotations map[string]string) bool {
+	value, ok := lookup(fault, annotations)
+	if !ok {
+		return false
+	}
+	active, _ := strconv.ParseBool(value)
+	return active
+}
+
+// Error returns an ErrInjected error for the fault if it is Active
+func Error(fault Fault, annotations map[string]string) error {
+	if !Active(fault, annotations) {
+		return nil
+	}
+	return fmt.Errorf("%s: %w", fault, ErrInjected)
+}
+
+// Delay returns the duration the fault is set to if the agent is built with the faultinject tag,
+// zero if it is not set or is not a valid duration
+func Delay(fault Fault, annotations map[string]string) time.Duration {
+	value, ok := lookup(fault, annotations)
+	if !ok {
+		return 0
+	}
+	delay, err := time.ParseDuration(value)
+	if err != nil || delay < 0 {
+		return 0
+	}
+	return delay
+}
+
+// lookup returns the value of the fault, the annotation taking precedence over the environment
+// variable, if the agent is built with the faultinject tag
+func lookup(fault Fault, annotations map[string]string) (string, bool) {
+	if !Enabled {
+		return "", false
+	}
+	return lookupValue(fault, annotations, os.LookupEnv)
+}
+
+func lookupValue(fault Fault, annotations map[string]string, lookupEnv func(string) (string, bool)) (string, bool) {
+	if value, ok := annotations[fault.Annotation()]; ok {
+		return value, true
+	}
+	return lookupEnv(fault.Env())
+}
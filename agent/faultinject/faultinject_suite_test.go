@@ -0,0 +1,17 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// nolint: testpackage
+package faultinject
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestFaultInject(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Fault Injection Suite")
+}
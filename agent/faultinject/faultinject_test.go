@@ -0,0 +1,81 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// nolint: testpackage
+package faultinject
+
+import (
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Fault injection", func() {
+	It("should name the annotations and the environment variables of the faults", func() {
+		Expect(DropHeartbeats.Annotation()).To(Equal("faults.byoh.infrastructure.cluster.x-k8s.io/drop-heartbeats"))
+		Expect(FailBundleDownload.Env()).To(Equal("BYOH_FAULT_FAIL_BUNDLE_DOWNLOAD"))
+		Expect(DelayCSRIssuance.Env()).To(Equal("BYOH_FAULT_DELAY_CSR_ISSUANCE"))
+	})
+
+	It("should prefer the annotation over the environment variable", func() {
+		lookupEnv := func(name string) (string, bool) {
+			return "false", name == DropHeartbeats.Env()
+		}
+		value, ok := lookupValue(DropHeartbeats, map[string]string{DropHeartbeats.Annotation(): "true"}, lookupEnv)
+		Expect(ok).To(BeTrue())
+		Expect(value).To(Equal("true"))
+
+		value, ok = lookupValue(DropHeartbeats, nil, lookupEnv)
+		Expect(ok).To(BeTrue())
+		Expect(value).To(Equal("false"))
+
+		_, ok = lookupValue(FailBundleDownload, nil, lookupEnv)
+		Expect(ok).To(BeFalse())
+	})
+
+	Context("When the agent is built without the faultinject tag", func() {
+		BeforeEach(func() {
+			if Enabled {
+				Skip("the agent is built with the faultinject tag")
+			}
+		})
+
+		It("should not inject the faults", func() {
+			annotations := map[string]string{
+				FailBundleDownload.Annotation(): "true",
+				DelayCSRIssuance.Annotation():   "1m",
+			}
+			Expect(Active(FailBundleDownload, annotations)).To(BeFalse())
+			Expect(Error(FailBundleDownload, annotations)).To(Succeed())
+			Expect(Delay(DelayCSRIssuance, annotations)).To(BeZero())
+		})
+	})
+
+	Context("When the agent is built with the faultinject tag", func() {
+		BeforeEach(func() {
+			if !Enabled {
+				Skip("the agent is built without the faultinject tag")
+			}
+		})
+
+		It("should return an error for the faults set to true", func() {
+			Expect(Error(FailBundleDownload, map[string]string{FailBundleDownload.Annotation(): "true"})).To(MatchError(ErrInjected))
+			Expect(Error(FailBundleDownload, map[string]string{FailBundleDownload.Annotation(): "false"})).To(Succeed())
+			Expect(Error(FailBundleDownload, nil)).To(Succeed())
+		})
+
+		It("should inject the faults of the environment variables", func() {
+			Expect(os.Setenv(DropHeartbeats.Env(), "true")).To(Succeed())
+			defer os.Unsetenv(DropHeartbeats.Env())
+			Expect(Active(DropHeartbeats, nil)).To(BeTrue())
+		})
+
+		It("should delay by the valid durations only", func() {
+			Expect(Delay(DelayCSRIssuance, map[string]string{DelayCSRIssuance.Annotation(): "90s"})).To(Equal(90 * time.Second))
+			Expect(Delay(DelayCSRIssuance, map[string]string{DelayCSRIssuance.Annotation(): "soon"})).To(BeZero())
+			Expect(Delay(DelayCSRIssuance, map[string]string{DelayCSRIssuance.Annotation(): "-1s"})).To(BeZero())
+		})
+	})
+})
@@ -0,0 +1,16 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build boringcrypto
+// +build boringcrypto
+
+package fips
+
+import (
+	// restricts the TLS configurations of the agent, e.g. of its client of the management
+	// cluster, to the approved settings
+	_ "crypto/tls/fipsonly"
+)
+
+// BoringCrypto is true when the agent is built with the BoringCrypto crypto module
+const BoringCrypto = true
@@ -0,0 +1,9 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package fips enforces the FIPS 140-2 approved algorithms in the host agent, for the
+// deployments which cannot run agents generating non-FIPS keys. The FIPS mode is always on
+// for the agent built with the boringcrypto tag and a BoringCrypto Go toolchain, whose crypto
+// module is validated, and it can be enforced at run-time for the agent built with the
+// standard Go toolchain, in which case only the algorithms are checked.
+package fips
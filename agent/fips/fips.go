@@ -0,0 +1,81 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package fips
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/tls"
+	"fmt"
+)
+
+// MinRSAKeySize is the size in bits of the smallest approved RSA key
+const MinRSAKeySize = 2048
+
+// approvedCipherSuites are the approved TLS 1.2 cipher suites, with an ECDHE key exchange
+var approvedCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+}
+
+// approvedCurves are the approved curves of the TLS key exchanges
+var approvedCurves = []tls.CurveID{tls.CurveP256, tls.CurveP384}
+
+// enforced is set when the FIPS mode is enforced at run-time
+var enforced bool
+
+// SetEnforced turns the FIPS mode on or off at run-time, e.g. with the --fips flag of the agent.
+// It cannot be turned off for the agent built with BoringCrypto.
+func SetEnforced(enforce bool) {
+	enforced = enforce
+}
+
+// Enabled returns true if the FIPS mode is on, i.e. the agent is built with BoringCrypto or the
+// FIPS mode is enforced at run-time
+func Enabled() bool {
+	return BoringCrypto || enforced
+}
+
+// CheckKey returns an error if the private key is not approved, see CheckPublicKey
+func CheckKey(key crypto.PrivateKey) error {
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		return CheckPublicKey(k.Public())
+	case *rsa.PrivateKey:
+		return CheckPublicKey(k.Public())
+	}
+	return fmt.Errorf("%T key is not FIPS approved", key)
+}
+
+// CheckPublicKey returns an error if the key pair of the public key is not approved, e.g. a key
+// held by a hardware token. The ECDSA keys must be on the P-256 or P-384 curve, and the RSA keys
+// at least MinRSAKeySize bits long.
+func CheckPublicKey(key crypto.PublicKey) error {
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		if k.Curve == elliptic.P256() || k.Curve == elliptic.P384() {
+			return nil
+		}
+		return fmt.Errorf("ECDSA key on the %s curve is not FIPS approved, P-256 or P-384 is required", k.Curve.Params().Name)
+	case *rsa.PublicKey:
+		if size := k.N.BitLen(); size < MinRSAKeySize {
+			return fmt.Errorf("RSA key of %d bits is not FIPS approved, at least %d bits are required", size, MinRSAKeySize)
+		}
+		return nil
+	}
+	return fmt.Errorf("%T key is not FIPS approved", key)
+}
+
+// ConfigureTLS restricts the TLS config to TLS 1.2, with the approved cipher suites and curves.
+// TLS 1.3 is disabled since its cipher suites cannot be restricted.
+func ConfigureTLS(config *tls.Config) {
+	config.MinVersion = tls.VersionTLS12
+	config.MaxVersion = tls.VersionTLS12
+	config.CipherSuites = approvedCipherSuites
+	config.CurvePreferences = approvedCurves
+}
@@ -0,0 +1,88 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// nolint: testpackage
+package fips
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FIPS mode", func() {
+	Context("When the FIPS mode is enforced at run-time", func() {
+		AfterEach(func() {
+			SetEnforced(false)
+		})
+
+		It("should be enabled", func() {
+			SetEnforced(true)
+			Expect(Enabled()).To(BeTrue())
+		})
+	})
+
+	Context("When a key is checked", func() {
+		It("should approve the ECDSA keys on the P-256 and P-384 curves", func() {
+			for _, curve := range []elliptic.Curve{elliptic.P256(), elliptic.P384()} {
+				key, err := ecdsa.GenerateKey(curve, rand.Reader)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(CheckKey(key)).To(Succeed())
+			}
+		})
+
+		It("should not approve the ECDSA keys on the other curves", func() {
+			key, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(CheckKey(key)).To(MatchError("ECDSA key on the P-224 curve is not FIPS approved, P-256 or P-384 is required"))
+		})
+
+		It("should approve the RSA keys of at least 2048 bits only", func() {
+			key, err := rsa.GenerateKey(rand.Reader, MinRSAKeySize)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(CheckKey(key)).To(Succeed())
+
+			key, err = rsa.GenerateKey(rand.Reader, 1024)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(CheckKey(key)).To(MatchError("RSA key of 1024 bits is not FIPS approved, at least 2048 bits are required"))
+		})
+
+		It("should not approve the Ed25519 keys", func() {
+			_, key, err := ed25519.GenerateKey(rand.Reader)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(CheckKey(key)).To(MatchError("ed25519.PrivateKey key is not FIPS approved"))
+		})
+
+		It("should check the public key of a key pair held elsewhere", func() {
+			key, err := rsa.GenerateKey(rand.Reader, 1024)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(CheckPublicKey(key.Public())).To(MatchError("RSA key of 1024 bits is not FIPS approved, at least 2048 bits are required"))
+
+			public, _, err := ed25519.GenerateKey(rand.Reader)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(CheckPublicKey(public)).To(MatchError("ed25519.PublicKey key is not FIPS approved"))
+		})
+	})
+
+	Context("When a TLS config is configured", func() {
+		It("should restrict it to TLS 1.2 with the approved cipher suites and curves", func() {
+			config := &tls.Config{MinVersion: tls.VersionTLS13}
+			ConfigureTLS(config)
+			Expect(config.MinVersion).To(Equal(uint16(tls.VersionTLS12)))
+			Expect(config.MaxVersion).To(Equal(uint16(tls.VersionTLS12)))
+			Expect(config.CipherSuites).To(ConsistOf(
+				tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+				tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+				tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+				tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			))
+			Expect(config.CurvePreferences).To(Equal([]tls.CurveID{tls.CurveP256, tls.CurveP384}))
+		})
+	})
+})
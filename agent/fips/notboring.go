@@ -0,0 +1,10 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !boringcrypto
+// +build !boringcrypto
+
+package fips
+
+// BoringCrypto is true when the agent is built with the BoringCrypto crypto module
+const BoringCrypto = false
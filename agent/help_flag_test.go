@@ -17,13 +17,44 @@ var _ = Describe("Help flag for host agent", func() {
 	Context("When the help flag is provided", func() {
 		var (
 			expectedOptions = []string{
+				"--agent-upgrade-public-key string",
 				"--bootstrap-kubeconfig string",
+				"--config string",
+				"--credential-encryption string",
+				"--credential-encryption-key-file string",
+				"--default-network-interface string",
 				"--downloadpath string",
+				"--staged-bundle-path string",
+				"--dry-run",
+				"--dry-run-k8s-version string",
+				"--escalate-with-sudo",
+				"--fips",
+				"--host-identity string",
+				"--host-kubeconfig string",
+				"--hostname-override string",
+				"--http-proxy string",
+				"--https-proxy string",
+				"--install-mode string",
+				"--installer-audit-log string",
+				"--key-store string",
+				"--key-type string",
 				"--kubeconfig string",
 				"--label labelFlags",
+				"--local-api-socket string",
+				"--metrics-tls-cert-file string",
+				"--metrics-tls-client-ca-file string",
+				"--metrics-tls-key-file string",
 				"--metricsbindaddress string",
 				"--namespace string",
+				"--no-proxy string",
+				"--node-ip string",
+				"--pkcs11-key-label string",
+				"--pkcs11-module string",
+				"--pkcs11-token-label string",
+				"--private-key-dir string",
 				"--skip-installation",
+				"--skip-preflight-checks",
+				"--tpm-device string",
 				"--use-installer-controller",
 				"--version",
 				"-v, --v",
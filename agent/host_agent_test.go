@@ -635,7 +635,7 @@ var _ = Describe("Agent", func() {
 				AttachStdin:  false,
 				AttachStdout: true,
 				AttachStderr: true,
-				Cmd:          []string{"cat", registration.TmpPrivateKey},
+				Cmd:          []string{"cat", "/root/.byoh/" + registration.TmpPrivateKey},
 			})
 			Expect(err).ShouldNot(HaveOccurred())
 			result, err := cli.ContainerExecAttach(ctx, response.ID, dockertypes.ExecStartCheck{})
@@ -0,0 +1,8 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package hostsecurity contains the security self-check of the host agent, run on startup. It checks
+// the permissions of the configuration, the credentials, the downloads and the sudoers rules of the
+// agent, as another user of the host able to write them would take over the agent, and through it
+// the node, and one able to read the credentials would impersonate the host.
+package hostsecurity
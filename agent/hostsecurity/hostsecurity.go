@@ -0,0 +1,162 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package hostsecurity
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"syscall"
+
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+const (
+	// SudoersFile is the drop-in of the sudo rules of an agent running as a dedicated user
+	SudoersFile = "/etc/sudoers.d/byoh-hostagent"
+
+	// writableByOthers are the write permissions of the group and the others
+	writableByOthers fs.FileMode = 0022
+	// accessibleByOthers are the permissions of the group and the others
+	accessibleByOthers fs.FileMode = 0077
+)
+
+// Path is a file or directory of the agent checked by the self-check
+type Path struct {
+	// Path of the file or directory, it is skipped when it does not exist
+	Path string
+	// Secret paths hold credentials, they are only accessible by their owner
+	Secret bool
+	// RootOwned paths must be owned by root, e.g. the sudoers rules of the agent, rather than by the agent user
+	RootOwned bool
+}
+
+// Checker runs the security self-check
+type Checker struct {
+	// Paths are the paths checked
+	Paths []Path
+	// Refuse leaves the permissions untouched: the world-writable paths are violations the agent refuses
+	// to start with, rather than being fixed
+	Refuse bool
+
+	// uid is the user the agent runs as
+	uid int
+}
+
+// Posture is the result of the self-check
+type Posture struct {
+	// Fixed are the permissions changed by the self-check
+	Fixed []string
+	// Findings are the weaknesses left, reported by the HostSecurityPosture condition
+	Findings []string
+	// Violations are the world-writable paths the agent refuses to start with
+	Violations []string
+}
+
+// Check runs the self-check, fixing the permissions unless Refuse is set
+func (c *Checker) Check() (*Posture, error) {
+	uid := c.uid
+	if uid == 0 {
+		uid = os.Geteuid()
+	}
+
+	posture := &Posture{}
+	for _, path := range c.Paths {
+		if path.Path == "" {
+			continue
+		}
+		info, err := os.Stat(path.Path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		c.checkOwner(path, info, uid, posture)
+		c.checkMode(path, info, posture)
+	}
+	return posture, nil
+}
+
+// checkOwner reports the paths owned by another user than root and the agent user, who could change
+// their permissions back
+func (c *Checker) checkOwner(path Path, info fs.FileInfo, uid int, posture *Posture) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return
+	}
+	owner := int(stat.Uid)
+	if owner == 0 || (owner == uid && !path.RootOwned) {
+		return
+	}
+	posture.Findings = append(posture.Findings, fmt.Sprintf("%s is owned by uid %d", path.Path, owner))
+}
+
+// checkMode removes the write permissions of the group and the others, and all their permissions on
+// the secret paths
+func (c *Checker) checkMode(path Path, info fs.FileInfo, posture *Posture) {
+	mode := info.Mode().Perm()
+	excess := mode & writableByOthers
+	if path.Secret {
+		excess = mode & accessibleByOthers
+	}
+	if excess == 0 {
+		return
+	}
+
+	worldWritable := mode&0002 != 0
+	if !c.Refuse {
+		err := os.Chmod(path.Path, info.Mode()&^excess)
+		if err == nil {
+			posture.Fixed = append(posture.Fixed, fmt.Sprintf("%s from %04o to %04o", path.Path, mode, mode&^excess))
+			return
+		}
+		if !worldWritable {
+			posture.Findings = append(posture.Findings, fmt.Sprintf("%s has permissions %04o and could not be fixed: %v", path.Path, mode, err))
+			return
+		}
+	}
+	if worldWritable {
+		posture.Violations = append(posture.Violations, fmt.Sprintf("%s is world-writable with permissions %04o", path.Path, mode))
+		return
+	}
+	posture.Findings = append(posture.Findings, fmt.Sprintf("%s has permissions %04o, expected at most %04o", path.Path, mode, mode&^excess))
+}
+
+// Refused tells if the agent must refuse to start
+func (p *Posture) Refused() bool {
+	return len(p.Violations) > 0
+}
+
+// Condition returns the HostSecurityPosture condition of a host the agent starts on, false with the
+// findings as message when weaknesses are left
+func (p *Posture) Condition() *clusterv1.Condition {
+	if len(p.Findings) > 0 {
+		return &clusterv1.Condition{
+			Type:     infrav1.HostSecurityPosture,
+			Status:   corev1.ConditionFalse,
+			Severity: clusterv1.ConditionSeverityWarning,
+			Reason:   infrav1.InsecurePermissionsReason,
+			Message:  strings.Join(p.Findings, ", "),
+		}
+	}
+	return &clusterv1.Condition{
+		Type:   infrav1.HostSecurityPosture,
+		Status: corev1.ConditionTrue,
+	}
+}
+
+// ErrorCondition returns the HostSecurityPosture condition of a host the self-check could not be run on
+func ErrorCondition(err error) *clusterv1.Condition {
+	return &clusterv1.Condition{
+		Type:     infrav1.HostSecurityPosture,
+		Status:   corev1.ConditionFalse,
+		Severity: clusterv1.ConditionSeverityWarning,
+		Reason:   infrav1.SecurityCheckErrorReason,
+		Message:  err.Error(),
+	}
+}
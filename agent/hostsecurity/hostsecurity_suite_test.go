@@ -0,0 +1,17 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// nolint: testpackage
+package hostsecurity
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestHostSecurity(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Host Security Suite")
+}
@@ -0,0 +1,152 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// nolint: testpackage
+package hostsecurity
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+var _ = Describe("Host security self-check", func() {
+	var (
+		root    string
+		checker *Checker
+	)
+
+	writeFile := func(name string, perm fs.FileMode) string {
+		path := filepath.Join(root, name)
+		Expect(os.WriteFile(path, []byte("content"), perm)).To(Succeed())
+		// the permissions are not masked by the umask
+		Expect(os.Chmod(path, perm)).To(Succeed())
+		return path
+	}
+
+	mkdir := func(name string, perm fs.FileMode) string {
+		path := filepath.Join(root, name)
+		Expect(os.Mkdir(path, perm)).To(Succeed())
+		Expect(os.Chmod(path, perm)).To(Succeed())
+		return path
+	}
+
+	perm := func(path string) fs.FileMode {
+		info, err := os.Stat(path)
+		Expect(err).NotTo(HaveOccurred())
+		return info.Mode().Perm()
+	}
+
+	BeforeEach(func() {
+		var err error
+		root, err = os.MkdirTemp("", "hostsecurity")
+		Expect(err).NotTo(HaveOccurred())
+		checker = &Checker{uid: os.Geteuid()}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(root)).To(Succeed())
+	})
+
+	It("should report a secure posture when the paths are protected", func() {
+		checker.Paths = []Path{
+			{Path: writeFile("config.yaml", 0644)},
+			{Path: writeFile("kubeconfig", 0600), Secret: true},
+			{Path: mkdir("bundles", 0755)},
+			{Path: filepath.Join(root, "missing"), Secret: true},
+		}
+
+		posture, err := checker.Check()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(posture.Fixed).To(BeEmpty())
+		Expect(posture.Findings).To(BeEmpty())
+		Expect(posture.Refused()).To(BeFalse())
+		Expect(posture.Condition().Status).To(Equal(corev1.ConditionTrue))
+	})
+
+	It("should remove the write permissions of the others and the permissions of the others on the secrets", func() {
+		config := writeFile("config.yaml", 0666)
+		kubeconfig := writeFile("kubeconfig", 0644)
+		keyDir := mkdir("keys", 0755)
+		bundles := mkdir("bundles", 0777)
+		checker.Paths = []Path{
+			{Path: config},
+			{Path: kubeconfig, Secret: true},
+			{Path: keyDir, Secret: true},
+			{Path: bundles},
+		}
+
+		posture, err := checker.Check()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(posture.Fixed).To(HaveLen(4))
+		Expect(posture.Refused()).To(BeFalse())
+		Expect(posture.Condition().Status).To(Equal(corev1.ConditionTrue))
+
+		Expect(perm(config)).To(Equal(fs.FileMode(0644)))
+		Expect(perm(kubeconfig)).To(Equal(fs.FileMode(0600)))
+		Expect(perm(keyDir)).To(Equal(fs.FileMode(0700)))
+		Expect(perm(bundles)).To(Equal(fs.FileMode(0755)))
+	})
+
+	Context("When the permissions are not fixed", func() {
+		BeforeEach(func() {
+			checker.Refuse = true
+		})
+
+		It("should refuse to start with world-writable paths", func() {
+			bundles := mkdir("bundles", 0777)
+			checker.Paths = []Path{{Path: bundles}}
+
+			posture, err := checker.Check()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(posture.Refused()).To(BeTrue())
+			Expect(posture.Violations).To(ConsistOf(ContainSubstring("%s is world-writable", bundles)))
+			Expect(perm(bundles)).To(Equal(fs.FileMode(0777)))
+		})
+
+		It("should report the readable secrets and the group-writable paths as findings", func() {
+			kubeconfig := writeFile("kubeconfig", 0640)
+			config := writeFile("config.yaml", 0664)
+			checker.Paths = []Path{{Path: kubeconfig, Secret: true}, {Path: config}}
+
+			posture, err := checker.Check()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(posture.Refused()).To(BeFalse())
+			Expect(posture.Findings).To(HaveLen(2))
+
+			condition := posture.Condition()
+			Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+			Expect(condition.Severity).To(Equal(clusterv1.ConditionSeverityWarning))
+			Expect(condition.Reason).To(Equal(infrav1.InsecurePermissionsReason))
+			Expect(condition.Message).To(ContainSubstring("%s has permissions 0640", kubeconfig))
+			Expect(perm(kubeconfig)).To(Equal(fs.FileMode(0640)))
+		})
+	})
+
+	It("should report the root-owned paths owned by the agent user", func() {
+		if os.Geteuid() == 0 {
+			Skip("the files of the test are owned by root")
+		}
+		sudoers := writeFile("byoh-hostagent", 0440)
+		checker.Paths = []Path{{Path: sudoers, RootOwned: true}}
+
+		posture, err := checker.Check()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(posture.Findings).To(ConsistOf(ContainSubstring("%s is owned by uid", sudoers)))
+	})
+
+	It("should return the error condition of a failed self-check", func() {
+		condition := ErrorCondition(errors.New("permission denied"))
+		Expect(condition.Type).To(Equal(infrav1.HostSecurityPosture))
+		Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(infrav1.SecurityCheckErrorReason))
+		Expect(condition.Message).To(Equal("permission denied"))
+	})
+})
@@ -0,0 +1,7 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package ignition executes the bootstrap data of the ignition format on a running host.
+// The files and the systemd units of the ignition config are written, then the enabled
+// units are started, as ignition would have done on the first boot of the host.
+package ignition
@@ -0,0 +1,257 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package ignition
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/cloudinit"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/common"
+)
+
+// systemdUnitDir is where the systemd units of the ignition config are written
+const systemdUnitDir = "/etc/systemd/system"
+
+// Executor executes ignition bootstrap data
+type Executor struct {
+	WriteFilesExecutor cloudinit.IFileWriter
+	RunCmdExecutor     cloudinit.ICmdRunner
+}
+
+// config is the subset of the ignition config, of the spec versions 2.x and 3.x, which
+// is applied on the host
+type config struct {
+	Ignition struct {
+		Version string `json:"version"`
+	} `json:"ignition"`
+	Storage struct {
+		Directories []node `json:"directories"`
+		Files       []file `json:"files"`
+	} `json:"storage"`
+	Systemd struct {
+		Units []unit `json:"units"`
+	} `json:"systemd"`
+}
+
+type node struct {
+	Path  string `json:"path"`
+	Mode  *int   `json:"mode"`
+	User  owner  `json:"user"`
+	Group owner  `json:"group"`
+}
+
+type owner struct {
+	Name string `json:"name"`
+}
+
+type file struct {
+	node
+	Contents resource `json:"contents"`
+	// Append is a bool with the spec 2.x, and the list of the appended resources with 3.x
+	Append json.RawMessage `json:"append"`
+}
+
+type resource struct {
+	Source      *string `json:"source"`
+	Compression string  `json:"compression"`
+}
+
+type unit struct {
+	Name     string  `json:"name"`
+	Enabled  *bool   `json:"enabled"`
+	Contents *string `json:"contents"`
+	Mask     bool    `json:"mask"`
+	Dropins  []struct {
+		Name     string  `json:"name"`
+		Contents *string `json:"contents"`
+	} `json:"dropins"`
+}
+
+// Execute writes the directories, files and systemd units of the ignition config,
+// then starts the enabled units
+func (e Executor) Execute(bootstrapData string) error {
+	ignitionConfig := config{}
+	if err := json.Unmarshal([]byte(bootstrapData), &ignitionConfig); err != nil {
+		return errors.Wrap(err, "error parsing the ignition config")
+	}
+	version := ignitionConfig.Ignition.Version
+	if !strings.HasPrefix(version, "2.") && !strings.HasPrefix(version, "3.") {
+		return errors.Errorf("unsupported ignition config version %q", version)
+	}
+
+	for _, dir := range ignitionConfig.Storage.Directories {
+		if err := e.WriteFilesExecutor.MkdirIfNotExists(dir.Path); err != nil {
+			return errors.Wrapf(err, "error creating the directory %s", dir.Path)
+		}
+	}
+	for i := range ignitionConfig.Storage.Files {
+		if err := e.writeFile(&ignitionConfig.Storage.Files[i]); err != nil {
+			return err
+		}
+	}
+
+	started := []string{}
+	for _, u := range ignitionConfig.Systemd.Units {
+		if err := e.writeUnit(&u); err != nil {
+			return err
+		}
+		if u.Mask {
+			if err := e.RunCmdExecutor.RunCmd(fmt.Sprintf("systemctl mask %s", u.Name)); err != nil {
+				return errors.Wrapf(err, "error masking the unit %s", u.Name)
+			}
+		} else if u.Enabled != nil && *u.Enabled {
+			started = append(started, u.Name)
+		}
+	}
+	if len(ignitionConfig.Systemd.Units) > 0 {
+		if err := e.RunCmdExecutor.RunCmd("systemctl daemon-reload"); err != nil {
+			return errors.Wrap(err, "error reloading the systemd units")
+		}
+	}
+	for _, name := range started {
+		if err := e.RunCmdExecutor.RunCmd(fmt.Sprintf("systemctl enable --now %s", name)); err != nil {
+			return errors.Wrapf(err, "error starting the unit %s", name)
+		}
+	}
+	return nil
+}
+
+func (e Executor) writeFile(f *file) error {
+	contents := []resource{f.Contents}
+	appendContents := false
+	if len(f.Append) > 0 {
+		var appendList []resource
+		if err := json.Unmarshal(f.Append, &appendList); err == nil {
+			contents = append(contents, appendList...)
+		} else if err := json.Unmarshal(f.Append, &appendContents); err != nil {
+			return errors.Wrapf(err, "error parsing the append of the file %s", f.Path)
+		}
+	}
+
+	var content strings.Builder
+	for _, r := range contents {
+		data, err := r.decode()
+		if err != nil {
+			return errors.Wrapf(err, "error decoding the content of the file %s", f.Path)
+		}
+		content.WriteString(data)
+	}
+
+	if err := e.WriteFilesExecutor.MkdirIfNotExists(filepath.Dir(f.Path)); err != nil {
+		return errors.Wrapf(err, "error creating the directory of the file %s", f.Path)
+	}
+	if err := e.WriteFilesExecutor.WriteToFile(&cloudinit.Files{
+		Path:        f.Path,
+		Owner:       f.owner(),
+		Permissions: f.permissions(),
+		Content:     content.String(),
+		Append:      appendContents,
+	}); err != nil {
+		return errors.Wrapf(err, "error writing the file %s", f.Path)
+	}
+	return nil
+}
+
+func (e Executor) writeUnit(u *unit) error {
+	if u.Contents != nil {
+		if err := e.WriteFilesExecutor.WriteToFile(&cloudinit.Files{
+			Path:        filepath.Join(systemdUnitDir, u.Name),
+			Content:     *u.Contents,
+			Permissions: "0644",
+		}); err != nil {
+			return errors.Wrapf(err, "error writing the unit %s", u.Name)
+		}
+	}
+	if len(u.Dropins) == 0 {
+		return nil
+	}
+	dropinDir := filepath.Join(systemdUnitDir, u.Name+".d")
+	if err := e.WriteFilesExecutor.MkdirIfNotExists(dropinDir); err != nil {
+		return errors.Wrapf(err, "error creating the drop-in directory of the unit %s", u.Name)
+	}
+	for _, dropin := range u.Dropins {
+		if dropin.Contents == nil {
+			continue
+		}
+		if err := e.WriteFilesExecutor.WriteToFile(&cloudinit.Files{
+			Path:        filepath.Join(dropinDir, dropin.Name),
+			Content:     *dropin.Contents,
+			Permissions: "0644",
+		}); err != nil {
+			return errors.Wrapf(err, "error writing the drop-in %s of the unit %s", dropin.Name, u.Name)
+		}
+	}
+	return nil
+}
+
+// permissions returns the octal permissions of the decimal mode of the ignition config
+func (n *node) permissions() string {
+	if n.Mode == nil {
+		return ""
+	}
+	return fmt.Sprintf("%04o", *n.Mode)
+}
+
+// owner returns the owner in the user:group format of cloud-init
+func (n *node) owner() string {
+	if n.User.Name == "" {
+		return ""
+	}
+	group := n.Group.Name
+	if group == "" {
+		group = n.User.Name
+	}
+	return n.User.Name + ":" + group
+}
+
+// decode returns the content of a data URL resource, the other sources are not supported
+// as the host does not fetch remote content while bootstrapping
+func (r resource) decode() (string, error) {
+	if r.Source == nil {
+		return "", nil
+	}
+	source := *r.Source
+	if !strings.HasPrefix(source, "data:") {
+		return "", errors.Errorf("unsupported source %q, only data URLs are supported", source)
+	}
+	separator := strings.Index(source, ",")
+	if separator < 0 {
+		return "", errors.Errorf("invalid data URL %q", source)
+	}
+	mediaType, data := source[len("data:"):separator], source[separator+1:]
+
+	var content []byte
+	if strings.HasSuffix(mediaType, ";base64") {
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		content = decoded
+	} else {
+		unescaped, err := url.PathUnescape(data)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		content = []byte(unescaped)
+	}
+
+	switch r.Compression {
+	case "":
+	case "gzip":
+		gunzipped, err := common.GunzipData(content)
+		if err != nil {
+			return "", err
+		}
+		content = gunzipped
+	default:
+		return "", errors.Errorf("unsupported compression %q", r.Compression)
+	}
+	return string(content), nil
+}
@@ -0,0 +1,133 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package ignition_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/cloudinit"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/cloudinit/cloudinitfakes"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/ignition"
+)
+
+var _ = Describe("Ignition", func() {
+	var (
+		fakeFileWriter  *cloudinitfakes.FakeIFileWriter
+		fakeCmdExecutor *cloudinitfakes.FakeICmdRunner
+		executor        ignition.Executor
+	)
+
+	BeforeEach(func() {
+		fakeFileWriter = &cloudinitfakes.FakeIFileWriter{}
+		fakeCmdExecutor = &cloudinitfakes.FakeICmdRunner{}
+		executor = ignition.Executor{
+			WriteFilesExecutor: fakeFileWriter,
+			RunCmdExecutor:     fakeCmdExecutor,
+		}
+	})
+
+	It("should write the files of the ignition config", func() {
+		var gzipped bytes.Buffer
+		gz := gzip.NewWriter(&gzipped)
+		_, err := gz.Write([]byte("compressed-content"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(gz.Close()).To(Succeed())
+
+		err = executor.Execute(fmt.Sprintf(`{
+  "ignition": {"version": "3.2.0"},
+  "storage": {
+    "files": [
+      {"path": "/etc/kubeadm.yml", "mode": 384, "user": {"name": "root"}, "contents": {"source": "data:,kind%%3A%%20InitConfiguration%%0A"}},
+      {"path": "/etc/encoded", "contents": {"source": "data:;base64,%s"}},
+      {"path": "/etc/compressed", "contents": {"compression": "gzip", "source": "data:;base64,%s"}}
+    ]
+  }
+}`, base64.StdEncoding.EncodeToString([]byte("encoded-content")), base64.StdEncoding.EncodeToString(gzipped.Bytes())))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fakeFileWriter.MkdirIfNotExistsCallCount()).To(Equal(3))
+		Expect(fakeFileWriter.MkdirIfNotExistsArgsForCall(0)).To(Equal("/etc"))
+		Expect(fakeFileWriter.WriteToFileCallCount()).To(Equal(3))
+		Expect(fakeFileWriter.WriteToFileArgsForCall(0)).To(Equal(&cloudinit.Files{
+			Path:        "/etc/kubeadm.yml",
+			Owner:       "root:root",
+			Permissions: "0600",
+			Content:     "kind: InitConfiguration\n",
+		}))
+		Expect(fakeFileWriter.WriteToFileArgsForCall(1).Content).To(Equal("encoded-content"))
+		Expect(fakeFileWriter.WriteToFileArgsForCall(2).Content).To(Equal("compressed-content"))
+		Expect(fakeCmdExecutor.RunCmdCallCount()).To(Equal(0))
+	})
+
+	It("should append the contents of the spec 3.x", func() {
+		err := executor.Execute(`{
+  "ignition": {"version": "3.0.0"},
+  "storage": {"files": [{"path": "/etc/hosts", "append": [{"source": "data:,127.0.0.1%20kubernetes"}]}]}
+}`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeFileWriter.WriteToFileArgsForCall(0).Content).To(Equal("127.0.0.1 kubernetes"))
+	})
+
+	It("should append the file of the spec 2.x", func() {
+		err := executor.Execute(`{
+  "ignition": {"version": "2.3.0"},
+  "storage": {"files": [{"path": "/etc/hosts", "append": true, "contents": {"source": "data:,127.0.0.1%20kubernetes"}}]}
+}`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeFileWriter.WriteToFileArgsForCall(0).Append).To(BeTrue())
+	})
+
+	It("should write and start the enabled systemd units", func() {
+		err := executor.Execute(`{
+  "ignition": {"version": "3.2.0"},
+  "systemd": {
+    "units": [
+      {"name": "kubeadm.service", "enabled": true, "contents": "[Service]\nExecStart=/usr/bin/kubeadm init"},
+      {"name": "kubelet.service", "dropins": [{"name": "10-byoh.conf", "contents": "[Service]\nEnvironment=A=B"}]},
+      {"name": "swap.target", "mask": true}
+    ]
+  }
+}`)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fakeFileWriter.WriteToFileCallCount()).To(Equal(2))
+		Expect(fakeFileWriter.WriteToFileArgsForCall(0).Path).To(Equal("/etc/systemd/system/kubeadm.service"))
+		Expect(fakeFileWriter.WriteToFileArgsForCall(1).Path).To(Equal("/etc/systemd/system/kubelet.service.d/10-byoh.conf"))
+
+		Expect(fakeCmdExecutor.RunCmdCallCount()).To(Equal(3))
+		Expect(fakeCmdExecutor.RunCmdArgsForCall(0)).To(Equal("systemctl mask swap.target"))
+		Expect(fakeCmdExecutor.RunCmdArgsForCall(1)).To(Equal("systemctl daemon-reload"))
+		Expect(fakeCmdExecutor.RunCmdArgsForCall(2)).To(Equal("systemctl enable --now kubeadm.service"))
+	})
+
+	It("should reject the unsupported config versions", func() {
+		err := executor.Execute(`{"ignition": {"version": "1.0.0"}}`)
+		Expect(err).To(MatchError(`unsupported ignition config version "1.0.0"`))
+	})
+
+	It("should reject the remote sources", func() {
+		err := executor.Execute(`{
+  "ignition": {"version": "3.2.0"},
+  "storage": {"files": [{"path": "/etc/remote", "contents": {"source": "https://example.com/file"}}]}
+}`)
+		Expect(err).To(MatchError(ContainSubstring(`unsupported source "https://example.com/file"`)))
+		Expect(fakeFileWriter.WriteToFileCallCount()).To(Equal(0))
+	})
+
+	It("should return the error of the failed units", func() {
+		fakeCmdExecutor.RunCmdReturns(errors.New("unit not found"))
+		err := executor.Execute(`{"ignition": {"version": "3.2.0"}, "systemd": {"units": [{"name": "kubeadm.service", "enabled": true}]}}`)
+		Expect(err).To(MatchError("error reloading the systemd units: unit not found"))
+	})
+
+	It("should return an error if the config is not JSON", func() {
+		Expect(executor.Execute("write_files: []")).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,98 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package installer
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/installer/internal/algo"
+)
+
+// AuditRecord is the audit record of a command run by the installer
+type AuditRecord = algo.CommandRecord
+
+// AuditLogPermissions file mode permissions of the audit log, which holds the output of the commands
+const AuditLogPermissions = 0600
+
+// redactedValue replaces the redacted values in the audit records
+const redactedValue = "<redacted>"
+
+// commandAuditor appends the records of the commands run by the installer to the audit log,
+// one JSON object per line, and forwards them to the audit func
+type commandAuditor struct {
+	mu        sync.Mutex
+	path      string
+	auditFunc func(AuditRecord)
+	logger    logr.Logger
+}
+
+// Record implements algo.Auditor
+func (a *commandAuditor) Record(record algo.CommandRecord) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.auditFunc != nil {
+		a.auditFunc(record)
+	}
+	if a.path == "" {
+		return
+	}
+	// failing to audit the command does not fail the installation, which already ran it
+	if err := a.write(record); err != nil {
+		a.logger.Error(err, "failed to write the audit log", "path", a.path)
+	}
+}
+
+func (a *commandAuditor) write(record algo.CommandRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(a.path), 0750); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(a.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, AuditLogPermissions)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// runAudited runs the command outside of the installer steps, e.g. to pull the bundle, and
+// records it with the auditor. It returns the combined output of the command, unless its
+// stdout is already set. The redacted values, e.g. the registry credentials, are left out
+// of the record and of the output.
+func runAudited(auditor algo.Auditor, step string, cmd *exec.Cmd, redacted ...string) ([]byte, error) {
+	var stdOut, stdErr bytes.Buffer
+	if cmd.Stdout == nil {
+		cmd.Stdout = &stdOut
+	}
+	cmd.Stderr = &stdErr
+	start := time.Now()
+	err := cmd.Run()
+	redact := func(s string) string {
+		for _, value := range redacted {
+			if value != "" {
+				s = strings.ReplaceAll(s, value, redactedValue)
+			}
+		}
+		return s
+	}
+	if auditor != nil {
+		auditor.Record(algo.NewCommandRecord(step, redact(cmd.String()), start, err, redact(stdOut.String()), redact(stdErr.String())))
+	}
+	return []byte(redact(stdOut.String() + stdErr.String())), err
+}
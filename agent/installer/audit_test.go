@@ -0,0 +1,81 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// nolint: testpackage
+package installer
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Byohost Installer Tests", func() {
+	Context("When the commands are audited", func() {
+		var (
+			auditDir string
+			auditor  *commandAuditor
+			records  []AuditRecord
+		)
+
+		BeforeEach(func() {
+			var err error
+			auditDir, err = os.MkdirTemp("", "auditTest")
+			Expect(err).NotTo(HaveOccurred())
+			records = nil
+			auditor = &commandAuditor{
+				path:      filepath.Join(auditDir, "byoh", "installer-audit.log"),
+				auditFunc: func(record AuditRecord) { records = append(records, record) },
+				logger:    logr.Discard(),
+			}
+		})
+		AfterEach(func() {
+			Expect(os.RemoveAll(auditDir)).To(Succeed())
+		})
+
+		It("Should append the records to the audit log and forward them", func() {
+			_, err := runAudited(auditor, "first step", exec.Command("bash", "-c", "echo first"))
+			Expect(err).NotTo(HaveOccurred())
+			out, err := runAudited(auditor, "second step", exec.Command("bash", "-c", "echo second >&2; exit 2"))
+			Expect(err).To(HaveOccurred())
+			Expect(string(out)).To(Equal("second\n"))
+
+			Expect(records).To(HaveLen(2))
+			Expect(records[1].Step).To(Equal("second step"))
+			Expect(records[1].ExitCode).To(Equal(2))
+
+			f, err := os.Open(auditor.path)
+			Expect(err).NotTo(HaveOccurred())
+			defer f.Close()
+			info, err := f.Stat()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(AuditLogPermissions)))
+
+			logged := []AuditRecord{}
+			scanner := bufio.NewScanner(f)
+			for scanner.Scan() {
+				var record AuditRecord
+				Expect(json.Unmarshal(scanner.Bytes(), &record)).To(Succeed())
+				logged = append(logged, record)
+			}
+			Expect(logged).To(HaveLen(2))
+			Expect(logged[0].Stdout).To(Equal("first\n"))
+			Expect(logged[0].ExitCode).To(Equal(0))
+			Expect(logged[1].Stderr).To(Equal("second\n"))
+			Expect(logged[1].ExitCode).To(Equal(2))
+		})
+		It("Should not write the audit log when its path is empty", func() {
+			auditor.path = ""
+			_, err := runAudited(auditor, "step", exec.Command("true"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records).To(HaveLen(1))
+			Expect(filepath.Join(auditDir, "byoh")).NotTo(BeADirectory())
+		})
+	})
+})
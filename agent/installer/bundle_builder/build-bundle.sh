@@ -14,15 +14,43 @@ echo Building bundle...
 echo Ingredients $INGREDIENTS_PATH
 ls -l $INGREDIENTS_PATH
 
+echo Detect package format
+PKG=deb
+if ls $INGREDIENTS_PATH/*kubelet*.rpm > /dev/null 2>&1
+then
+PKG=rpm
+fi
+if [ -f $INGREDIENTS_PATH/kubelet ]
+then
+PKG=bin
+fi
+if [ -f $INGREDIENTS_PATH/rke2.tar.gz ]
+then
+PKG=rke2
+fi
+echo Package format $PKG
+
+if [ $PKG = rke2 ]
+then
+echo Copy the rke2 release tarball
+# Mandatory
+cp $INGREDIENTS_PATH/rke2.tar.gz .
+elif [ $PKG = bin ]
+then
+echo Copy the binaries and the kubelet systemd units of the immutable hosts
+# Mandatory
+cp $INGREDIENTS_PATH/{kubelet,kubeadm,kubectl,crictl,kubelet.service,10-kubeadm.conf,kubernetes-cni.tar} .
+else
 echo Strip version to well-known names
 # Mandatory
 cp $INGREDIENTS_PATH/*containerd* containerd.tar
-cp $INGREDIENTS_PATH/*kubeadm*.deb ./kubeadm.deb
-cp $INGREDIENTS_PATH/*kubelet*.deb ./kubelet.deb
-cp $INGREDIENTS_PATH/*kubectl*.deb ./kubectl.deb
+cp $INGREDIENTS_PATH/*kubeadm*.$PKG ./kubeadm.$PKG
+cp $INGREDIENTS_PATH/*kubelet*.$PKG ./kubelet.$PKG
+cp $INGREDIENTS_PATH/*kubectl*.$PKG ./kubectl.$PKG
 # Optional
-cp  $INGREDIENTS_PATH/*cri-tools*.deb cri-tools.deb > /dev/null | true
-cp  $INGREDIENTS_PATH/*kubernetes-cni*.deb kubernetes-cni.deb > /dev/null | true
+cp  $INGREDIENTS_PATH/*cri-tools*.$PKG cri-tools.$PKG > /dev/null | true
+cp  $INGREDIENTS_PATH/*kubernetes-cni*.$PKG kubernetes-cni.$PKG > /dev/null | true
+fi
 
 echo Configuration $CONFIG_PATH
 ls -l $CONFIG_PATH
@@ -0,0 +1,22 @@
+#!/bin/bash
+
+# Copyright 2022 VMware, Inc. All Rights Reserved.
+# SPDX-License-Identifier: Apache-2.0
+
+set -e
+
+echo Download kubelet, kubeadm and kubectl
+for binary in kubelet kubeadm kubectl
+do
+curl -fsSLo $binary https://dl.k8s.io/release/v${KUBERNETES_VERSION}/bin/linux/${ARCH}/$binary
+done
+
+echo Download the kubelet systemd units
+curl -fsSL https://raw.githubusercontent.com/kubernetes/release/v${RELEASE_VERSION}/cmd/kubepkg/templates/latest/deb/kubelet/lib/systemd/system/kubelet.service -o kubelet.service
+curl -fsSL https://raw.githubusercontent.com/kubernetes/release/v${RELEASE_VERSION}/cmd/kubepkg/templates/latest/deb/kubeadm/10-kubeadm.conf -o 10-kubeadm.conf
+
+echo Download crictl
+curl -fsSL https://github.com/kubernetes-sigs/cri-tools/releases/download/v${CRICTL_VERSION}/crictl-v${CRICTL_VERSION}-linux-${ARCH}.tar.gz | tar -xz crictl
+
+echo Download the CNI plugins
+curl -fsSL https://github.com/containernetworking/plugins/releases/download/v${CNI_VERSION}/cni-plugins-linux-${ARCH}-v${CNI_VERSION}.tgz | gunzip > kubernetes-cni.tar
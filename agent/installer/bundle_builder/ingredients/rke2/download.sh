@@ -0,0 +1,11 @@
+#!/bin/bash
+
+# Copyright 2022 VMware, Inc. All Rights Reserved.
+# SPDX-License-Identifier: Apache-2.0
+
+set -e
+
+echo Download and verify the rke2 release tarball
+RELEASE_URL=https://github.com/rancher/rke2/releases/download/${RKE2_VERSION/+/%2B}
+curl -fsSLo rke2.tar.gz ${RELEASE_URL}/rke2.linux-${ARCH}.tar.gz
+curl -fsSL ${RELEASE_URL}/sha256sum-${ARCH}.txt | grep "rke2.linux-${ARCH}.tar.gz$" | sed 's/rke2.linux-.*/rke2.tar.gz/' | sha256sum -c
@@ -0,0 +1,25 @@
+#!/bin/bash
+
+# Copyright 2022 VMware, Inc. All Rights Reserved.
+# SPDX-License-Identifier: Apache-2.0
+
+set -e
+
+echo Download containerd
+curl -LOJR https://github.com/containerd/containerd/releases/download/v${CONTAINERD_VERSION}/cri-containerd-cni-${CONTAINERD_VERSION}-linux-${CONTAINERD_ARCH}.tar.gz
+
+echo Add the Kubernetes yum repository
+cat <<REPO > /etc/yum.repos.d/kubernetes.repo
+[kubernetes]
+name=Kubernetes
+baseurl=https://packages.cloud.google.com/yum/repos/kubernetes-el7-${ARCH}
+enabled=1
+gpgcheck=1
+repo_gpgcheck=1
+gpgkey=https://packages.cloud.google.com/yum/doc/yum-key.gpg https://packages.cloud.google.com/yum/doc/rpm-package-key.gpg
+REPO
+
+echo Download kubelet, kubeadm and kubectl
+yumdownloader --arch $ARCH {kubelet,kubeadm,kubectl}-$KUBERNETES_VERSION
+yumdownloader --arch $ARCH kubernetes-cni-0.8.7-0
+yumdownloader --arch $ARCH cri-tools-1.23.0-0
@@ -0,0 +1,30 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package installer
+
+// BundleRepositories returns the repositories under repoAddr of the bundles of the bundle type, keyed by
+// the OS bundle they are built for, e.g. Ubuntu_20.04.1_x86-64, or by the architecture for rke2
+func BundleRepositories(bundleType BundleType, repoAddr string) map[string]string {
+	reg := getRegistry(bundleType, nil)
+	_, osBundles := reg.ListOS()
+	repositories := make(map[string]string, len(osBundles))
+	for _, osBundle := range osBundles {
+		repositories[osBundle] = repoAddr + "/" + getBundleName(osBundle, bundleType)
+	}
+	return repositories
+}
+
+// SupportsK8s returns whether the host agent installs the bundle of the OS bundle for the k8s version
+func SupportsK8s(bundleType BundleType, osBundle, k8sVer string) bool {
+	reg := getRegistry(bundleType, nil)
+	_, ok := reg.osk8sInstallerMap[osBundle][reg.resolveK8sToK8sBundle(k8sVer)]
+	return ok
+}
+
+// ResolveOsBundle returns the OS bundle of the bundle type installing the OS of a host, e.g.
+// Ubuntu_20.04.1_x86-64 for Ubuntu_20.04.4_LTS_x86-64, or an empty string when there is none
+func ResolveOsBundle(bundleType BundleType, osHost string) string {
+	reg := getRegistry(bundleType, nil)
+	return reg.resolveOsToOsBundle(osHost)
+}
@@ -9,15 +9,22 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
-	"github.com/cppforlife/go-cli-ui/ui"
 	"github.com/go-logr/logr"
-	"github.com/k14s/imgpkg/pkg/imgpkg/cmd"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/installer/internal/algo"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/metrics"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/privilege"
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
 )
 
 var (
 	// DownloadPathPermissions file mode permissions for download path
 	DownloadPathPermissions fs.FileMode = 0777
+	// DownloadBackoff is the backoff of the bundle download retries of the new bundle downloaders
+	DownloadBackoff = wait.Backoff{Duration: 5 * time.Second, Factor: 2, Jitter: 0.1, Steps: 6}
 )
 
 // bundleDownloader for downloading an OCI image.
@@ -25,7 +32,21 @@ type bundleDownloader struct {
 	bundleType   BundleType
 	repoAddr     string
 	downloadPath string
-	logger       logr.Logger
+	// stagedPath is where the bundles are pre-staged, e.g. the mount point of a removable drive
+	stagedPath string
+	logger     logr.Logger
+	eventFunc  func(eventType, reason, message string)
+	// auditor records the commands run to pull the bundle
+	auditor algo.Auditor
+	// escalator runs the commands pulling the bundle with containerd as root
+	escalator privilege.Escalator
+	// proxy is passed to the commands pulling the bundle with containerd, sudo not passing
+	// on the environment of the agent
+	proxy algo.ProxyConfig
+	// rateLimiter throttles the downloads of the bundle layers, they are not throttled when nil
+	rateLimiter *rate.Limiter
+	// backoff is the backoff of the download retries
+	backoff wait.Backoff
 }
 
 // NewBundleDownloader will return a new bundle downloader instance
@@ -35,6 +56,7 @@ func NewBundleDownloader(bundleType BundleType, repoAddr, downloadPath string, l
 		repoAddr:     repoAddr,
 		downloadPath: downloadPath,
 		logger:       logger,
+		backoff:      DownloadBackoff,
 	}
 }
 
@@ -42,7 +64,8 @@ func NewBundleDownloader(bundleType BundleType, repoAddr, downloadPath string, l
 // It automatically downloads and extracts the given version for the current linux
 // distribution. Creates the folder where the bundle should be saved if it does not exist.
 // Download is performed in a temp directory which in case of successful download is renamed.
-// If a cache for the bundle exists, nothing is downloaded.
+// If a cache for the bundle exists, nothing is downloaded, and a verified staged bundle is
+// copied instead of being downloaded. Failed downloads are retried with an exponential backoff.
 func (bd *bundleDownloader) Download(
 	normalizedOsVersion,
 	k8sVersion string,
@@ -52,7 +75,7 @@ func (bd *bundleDownloader) Download(
 		normalizedOsVersion,
 		k8sVersion,
 		tag,
-		bd.downloadByImgpkg)
+		bd.downloadByOCI)
 }
 
 // DownloadFromRepo downloads the required bundle with the given method.
@@ -86,6 +109,10 @@ func (bd *bundleDownloader) DownloadFromRepo(
 
 	bd.logger.Info("Cache miss", "path", bundleDirPath)
 
+	if bd.copyStagedBundle(k8sVersion, bundleDirPath) {
+		return nil
+	}
+
 	dir, err := os.MkdirTemp(downloadPathWithRepo, "tempBundle")
 	// It is fine if the dir path does not exist.
 	defer func() {
@@ -98,26 +125,46 @@ func (bd *bundleDownloader) DownloadFromRepo(
 		return err
 	}
 	bundleAddr := bd.GetBundleAddr(normalizedOsVersion, k8sVersion, tag)
-	err = convertError(downloadByTool(bundleAddr, dir))
+	bd.emitEvent(corev1.EventTypeNormal, "BundleDownloadStarted", fmt.Sprintf("Downloading bundle %s", bundleAddr))
+	start := time.Now()
+	err = bd.retryDownload(func() error { return convertError(downloadByTool(bundleAddr, dir)) })
 	if err != nil {
+		bd.emitEvent(corev1.EventTypeWarning, "BundleDownloadFailed", fmt.Sprintf("Downloading bundle %s failed: %v", bundleAddr, err))
 		return err
 	}
+	metrics.ObservePhase(metrics.PhaseDownload, start)
+	size := dirSize(dir)
+	metrics.BundleDownloadBytes.Add(float64(size))
+	bd.emitEvent(corev1.EventTypeNormal, "BundleDownloadFinished", fmt.Sprintf("Downloaded bundle %s (%d bytes)", bundleAddr, size))
 	return os.Rename(dir, bundleDirPath)
 }
 
-// downloadByImgpkg downloads the required bundle from the given repo using imgpkg.
-func (bd *bundleDownloader) downloadByImgpkg(
-	bundleAddr,
-	bundleDirPath string) error {
-
-	bd.logger.Info("Downloading bundle", "from", bundleAddr)
-
-	var confUI = ui.NewConfUI(ui.NewNoopLogger())
-	defer confUI.Flush()
+// emitEvent reports a bundle download lifecycle transition, if an event func is set.
+func (bd *bundleDownloader) emitEvent(eventType, reason, message string) {
+	if bd.eventFunc != nil {
+		bd.eventFunc(eventType, reason, message)
+	}
+}
 
-	imgpkgCmd := cmd.NewDefaultImgpkgCmd(confUI)
-	imgpkgCmd.SetArgs([]string{"pull", "--recursive", "-i", bundleAddr, "-o", bundleDirPath})
-	return imgpkgCmd.Execute()
+// retryDownload calls download until it succeeds, backing off exponentially between the attempts.
+// It returns the error of the last attempt, or right away when the bundle cannot be extracted.
+func (bd *bundleDownloader) retryDownload(download func() error) error {
+	var err error
+	waitErr := wait.ExponentialBackoff(bd.backoff, func() (bool, error) {
+		err = download()
+		if err == nil {
+			return true, nil
+		}
+		if err == ErrBundleExtract {
+			return false, err
+		}
+		bd.logger.Error(err, "Bundle download failed, retrying")
+		return false, nil
+	})
+	if waitErr != nil {
+		return err
+	}
+	return nil
 }
 
 // convertError returns known errors in standardized format.
@@ -148,9 +195,19 @@ func (bd *bundleDownloader) GetBundleDirPath(k8sVersion string) string {
 	return fmt.Sprintf("%s-%s", filepath.Join(bd.getBundlePathWithRepo(), string(bd.bundleType)), k8sVersion)
 }
 
-// GetBundleName returns the name of the bundle in normalized format.
+// GetBundleName returns the name of the k8s bundle in normalized format.
 func GetBundleName(normalizedOsVersion string) string {
-	return strings.ToLower(fmt.Sprintf("byoh-bundle-%s_k8s", normalizedOsVersion))
+	return getBundleName(normalizedOsVersion, BundleTypeK8s)
+}
+
+// GetRKE2BundleName returns the name of the rke2 bundle of the architecture in normalized format.
+func GetRKE2BundleName(arch string) string {
+	return getBundleName(arch, BundleTypeRKE2)
+}
+
+// getBundleName returns the name of the bundle of the type in normalized format.
+func getBundleName(normalizedOsVersion string, bundleType BundleType) string {
+	return strings.ToLower(fmt.Sprintf("byoh-bundle-%s_%s", normalizedOsVersion, bundleType))
 }
 
 // getBundlePathWithRepo returns the path
@@ -160,7 +217,7 @@ func (bd *bundleDownloader) getBundlePathWithRepo() string {
 
 // GetBundleAddr returns the exact address to the bundle in the repo.
 func (bd *bundleDownloader) GetBundleAddr(normalizedOsVersion, k8sVersion, tag string) string {
-	return fmt.Sprintf("%s/%s:%s", bd.repoAddr, GetBundleName(normalizedOsVersion), tag)
+	return fmt.Sprintf("%s/%s:%s", bd.repoAddr, getBundleName(normalizedOsVersion, bd.bundleType), tag)
 }
 
 // checkDirExist checks if a dirrectory exists.
@@ -171,6 +228,21 @@ func checkDirExist(dirPath string) bool {
 	return true
 }
 
+// dirSize returns the total size of the regular files under dirPath.
+func dirSize(dirPath string) int64 {
+	var size int64
+	_ = filepath.WalkDir(dirPath, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
 // ensureDirExist ensures that a bundle directory already exists or creates a new one recursively.
 func ensureDirExist(dirPath string) error {
 	return os.MkdirAll(dirPath, DownloadPathPermissions)
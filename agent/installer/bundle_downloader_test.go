@@ -9,19 +9,25 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/go-logr/logr"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/util/wait"
 )
 
 type mockImgpkg struct {
-	callCount int
-	err       error
+	callCount  int
+	err        error
+	errorCount int
 }
 
 func (mi *mockImgpkg) Get(_, _ string) error {
 	mi.callCount++
+	if mi.errorCount > 0 && mi.callCount > mi.errorCount {
+		return nil
+	}
 	return mi.err
 }
 
@@ -47,7 +53,8 @@ var _ = Describe("Byohost Installer Tests", func() {
 		if err != nil {
 			log.Fatal(err)
 		}
-		bd = &bundleDownloader{BundleTypeK8s, repoAddr, downloadPath, logr.Discard()}
+		bd = &bundleDownloader{bundleType: BundleTypeK8s, repoAddr: repoAddr, downloadPath: downloadPath, logger: logr.Discard(),
+			backoff: wait.Backoff{Duration: time.Millisecond, Factor: 2, Steps: 3}}
 		mi = &mockImgpkg{}
 	})
 	AfterEach(func() {
@@ -144,7 +151,136 @@ var _ = Describe("Byohost Installer Tests", func() {
 				mi.Get)
 			Expect(err).Should(HaveOccurred())
 			Expect(err.Error()).Should(Equal(ErrBundleExtract.Error()))
+			Expect(mi.callCount).Should(Equal(1))
+		})
+		It("Should retry the download", func() {
+			mi.err = errors.New("extracting image into directory: read tcp 192.168.0.1:1->1.1.1.1:1: read: connection timed out")
+			mi.errorCount = 2
+			err := bd.DownloadFromRepo(
+				normalizedOsVersion,
+				k8sVersion,
+				testTag,
+				mi.Get)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(mi.callCount).Should(Equal(3))
+		})
+		It("Should give up after the last retry", func() {
+			mi.err = ErrBundleChecksum
+			err := bd.DownloadFromRepo(
+				normalizedOsVersion,
+				k8sVersion,
+				testTag,
+				mi.Get)
+			Expect(err).Should(Equal(ErrBundleChecksum))
+			Expect(mi.callCount).Should(Equal(bd.backoff.Steps))
+		})
+
+	})
+	Context("When an event func is set", func() {
+		var events []string
+
+		BeforeEach(func() {
+			events = nil
+			bd.eventFunc = func(eventType, reason, _ string) {
+				events = append(events, eventType+" "+reason)
+			}
 		})
 
+		It("Should emit download started and finished events", func() {
+			err := bd.DownloadFromRepo(
+				normalizedOsVersion,
+				k8sVersion,
+				testTag,
+				mi.Get)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(events).Should(Equal([]string{"Normal BundleDownloadStarted", "Normal BundleDownloadFinished"}))
+
+			// no events on cache hit
+			events = nil
+			err = bd.DownloadFromRepo(
+				normalizedOsVersion,
+				k8sVersion,
+				testTag,
+				mi.Get)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(events).Should(BeEmpty())
+		})
+		It("Should emit a download failed event", func() {
+			mi.err = errors.New("fetching image: Get \"a.a.com/\": dial tcp: lookup a.a.com: no such host")
+			err := bd.DownloadFromRepo(
+				normalizedOsVersion,
+				k8sVersion,
+				testTag,
+				mi.Get)
+			Expect(err).Should(HaveOccurred())
+			Expect(events).Should(Equal([]string{"Normal BundleDownloadStarted", "Warning BundleDownloadFailed"}))
+		})
+	})
+	Context("When the bundle is staged", func() {
+		var (
+			stagedPath    string
+			stagedDirPath string
+			events        []string
+		)
+
+		BeforeEach(func() {
+			var err error
+			stagedPath, err = os.MkdirTemp("", "stagedTest")
+			Expect(err).ShouldNot(HaveOccurred())
+			bd.stagedPath = stagedPath
+			staged := NewBundleDownloader(BundleTypeK8s, repoAddr, stagedPath, logr.Discard())
+			stagedDirPath = staged.GetBundleDirPath(k8sVersion)
+			Expect(os.MkdirAll(filepath.Join(stagedDirPath, "bin"), 0755)).Should(Succeed())
+			Expect(os.WriteFile(filepath.Join(stagedDirPath, "conf.tar"), []byte("conf"), 0644)).Should(Succeed())
+			Expect(os.WriteFile(filepath.Join(stagedDirPath, "bin", "kubelet"), []byte("kubelet"), 0755)).Should(Succeed())
+			Expect(writeBundleChecksums(stagedDirPath)).Should(Succeed())
+
+			events = nil
+			bd.eventFunc = func(eventType, reason, _ string) {
+				events = append(events, eventType+" "+reason)
+			}
+		})
+		AfterEach(func() {
+			Expect(os.RemoveAll(stagedPath)).Should(Succeed())
+		})
+
+		It("Should copy the staged bundle instead of downloading it", func() {
+			err := bd.DownloadFromRepo(
+				normalizedOsVersion,
+				k8sVersion,
+				testTag,
+				mi.Get)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(mi.callCount).Should(Equal(0))
+			Expect(events).Should(Equal([]string{"Normal StagedBundleUsed"}))
+
+			kubelet, err := os.ReadFile(filepath.Join(bd.GetBundleDirPath(k8sVersion), "bin", "kubelet"))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(string(kubelet)).Should(Equal("kubelet"))
+			Expect(verifyBundleChecksums(bd.GetBundleDirPath(k8sVersion))).Should(Succeed())
+		})
+		It("Should download the bundle when the staged one does not match its checksums", func() {
+			Expect(os.WriteFile(filepath.Join(stagedDirPath, "bin", "kubelet"), []byte("tampered"), 0755)).Should(Succeed())
+
+			err := bd.DownloadFromRepo(
+				normalizedOsVersion,
+				k8sVersion,
+				testTag,
+				mi.Get)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(mi.callCount).Should(Equal(1))
+			Expect(events).Should(Equal([]string{"Warning StagedBundleInvalid", "Normal BundleDownloadStarted", "Normal BundleDownloadFinished"}))
+		})
+		It("Should download the bundle when the staged one has no checksums", func() {
+			Expect(os.Remove(filepath.Join(stagedDirPath, stagedBundleChecksums))).Should(Succeed())
+
+			err := bd.DownloadFromRepo(
+				normalizedOsVersion,
+				k8sVersion,
+				testTag,
+				mi.Get)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(mi.callCount).Should(Equal(1))
+		})
 	})
 })
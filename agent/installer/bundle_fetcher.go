@@ -0,0 +1,401 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package installer
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+const (
+	// blobsDir is the directory, under the download path of the repo, caching the bundle layers
+	blobsDir = "blobs"
+	// partialBlobSuffix is the suffix of the layers which are not completely downloaded yet
+	partialBlobSuffix = ".partial"
+	// containerdNamespace is the containerd namespace the bundles are pulled into
+	containerdNamespace = "byoh"
+)
+
+// downloadByOCI downloads the layers of the bundle image and extracts them into bundleDirPath.
+// The layers are cached under the download path until the bundle is extracted, so an interrupted
+// download resumes where it stopped, and are verified against the digests of the image manifest.
+func (bd *bundleDownloader) downloadByOCI(bundleAddr, bundleDirPath string) error {
+	ctx := context.Background()
+	bd.logger.Info("Downloading bundle", "from", bundleAddr)
+
+	ref, err := name.ParseReference(bundleAddr)
+	if err != nil {
+		return err
+	}
+	auth, err := authn.DefaultKeychain.Resolve(ref.Context())
+	if err != nil {
+		return err
+	}
+	img, err := remote.Image(ref, remote.WithAuth(auth), remote.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	layers, err := img.Layers()
+	if err != nil {
+		return err
+	}
+
+	registry := ref.Context().Registry
+	rt, err := transport.NewWithContext(ctx, registry, auth, http.DefaultTransport, []string{ref.Scope(transport.PullScope)})
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Transport: rt}
+
+	blobsPath := filepath.Join(bd.getBundlePathWithRepo(), blobsDir)
+	if err = ensureDirExist(blobsPath); err != nil {
+		return err
+	}
+
+	blobPaths := make([]string, 0, len(layers))
+	for _, layer := range layers {
+		digest, err := layer.Digest()
+		if err != nil {
+			return err
+		}
+		blobURL := fmt.Sprintf("%s://%s/v2/%s/blobs/%s",
+			registry.Scheme(), registry.RegistryStr(), ref.Context().RepositoryStr(), digest)
+		blobPath := filepath.Join(blobsPath, digest.Algorithm+"-"+digest.Hex)
+		if err = bd.downloadBlob(ctx, client, blobURL, digest, blobPath); err != nil {
+			return err
+		}
+		blobPaths = append(blobPaths, blobPath)
+	}
+
+	for _, blobPath := range blobPaths {
+		if err = extractLayer(blobPath, bundleDirPath); err != nil {
+			return err
+		}
+	}
+	for _, blobPath := range blobPaths {
+		if err = os.Remove(blobPath); err != nil {
+			bd.logger.Error(err, "Failed to remove bundle layer", "path", blobPath)
+		}
+	}
+	return nil
+}
+
+// downloadByContainerd pulls the bundle image with the containerd of the host, mounts it and
+// copies its content into bundleDirPath. The image is removed from containerd afterwards.
+// The image is resolved like in downloadByOCI and pulled by the digest of its manifest, with the
+// credentials of the registry and the proxy of the agent. The layers pulled by containerd are
+// verified against the digests of the manifest, and containerd resumes the interrupted pulls
+// from the layers it partially downloaded when the download is retried.
+func (bd *bundleDownloader) downloadByContainerd(bundleAddr, bundleDirPath string) error {
+	ctx := context.Background()
+	bd.logger.Info("Pulling bundle with containerd", "from", bundleAddr)
+
+	ref, err := name.ParseReference(bundleAddr)
+	if err != nil {
+		return err
+	}
+	auth, err := authn.DefaultKeychain.Resolve(ref.Context())
+	if err != nil {
+		return err
+	}
+	img, err := remote.Image(ref, remote.WithAuth(auth), remote.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	manifestDigest, err := img.Digest()
+	if err != nil {
+		return err
+	}
+	layers, err := img.Layers()
+	if err != nil {
+		return err
+	}
+	credentials, err := registryCredentials(auth)
+	if err != nil {
+		return err
+	}
+	image := ref.Context().Digest(manifestDigest.String()).String()
+
+	ctr := func(step string, args ...string) error {
+		out, err := runAudited(bd.auditor, step, bd.ctrCommand(args...), credentials)
+		if err != nil {
+			return fmt.Errorf("ctr %s: %w: %s", strings.ReplaceAll(strings.Join(args, " "), credentials, redactedValue), err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+
+	pullArgs := []string{"images", "pull"}
+	if credentials != "" {
+		pullArgs = append(pullArgs, "--user", credentials)
+	}
+	if ref.Context().Registry.Scheme() == "http" {
+		pullArgs = append(pullArgs, "--plain-http")
+	}
+	if err = ctr("Pulling bundle with containerd", append(pullArgs, image)...); err != nil {
+		return err
+	}
+	defer func() {
+		if err := ctr("Pulling bundle with containerd", "images", "remove", image); err != nil {
+			bd.logger.Error(err, "Failed to remove bundle image", "image", image)
+		}
+	}()
+
+	for _, layer := range layers {
+		digest, err := layer.Digest()
+		if err != nil {
+			return err
+		}
+		if err = bd.verifyContainerdLayer(digest); err != nil {
+			return err
+		}
+	}
+
+	mountPath, err := os.MkdirTemp(bd.getBundlePathWithRepo(), "mount")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(mountPath)
+	if err = ctr("Pulling bundle with containerd", "images", "mount", image, mountPath); err != nil {
+		return err
+	}
+	defer func() {
+		if err := ctr("Pulling bundle with containerd", "images", "unmount", mountPath); err != nil {
+			bd.logger.Error(err, "Failed to unmount bundle image", "path", mountPath)
+		}
+	}()
+
+	if out, err := runAudited(bd.auditor, "Copying bundle", bd.escalator.Command("cp", "-a", mountPath+"/.", bundleDirPath)); err != nil {
+		return fmt.Errorf("copying bundle: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// ctrCommand returns the ctr command run as root in the containerd namespace of the bundles,
+// with the proxy of the agent set in its environment
+func (bd *bundleDownloader) ctrCommand(args ...string) *exec.Cmd {
+	ctrArgs := append([]string{"-n", containerdNamespace}, args...)
+	proxyEnv := bd.proxy.Env()
+	if len(proxyEnv) == 0 {
+		return bd.escalator.Command("ctr", ctrArgs...)
+	}
+	envArgs := make([]string, 0, len(proxyEnv)+1+len(ctrArgs))
+	for name, value := range proxyEnv {
+		envArgs = append(envArgs, name+"="+value)
+	}
+	sort.Strings(envArgs)
+	envArgs = append(envArgs, "ctr")
+	return bd.escalator.Command("env", append(envArgs, ctrArgs...)...)
+}
+
+// verifyContainerdLayer compares the digest of the layer in the content store of containerd with
+// the digest of the layer in the image manifest
+func (bd *bundleDownloader) verifyContainerdLayer(digest v1.Hash) error {
+	if digest.Algorithm != "sha256" {
+		return fmt.Errorf("unsupported digest algorithm %s", digest.Algorithm)
+	}
+	content, contentWriter := io.Pipe()
+	verified := make(chan error, 1)
+	go func() {
+		verified <- verifyDigest(content, digest)
+	}()
+
+	cmd := bd.ctrCommand("content", "get", digest.String())
+	cmd.Stdout = contentWriter
+	out, err := runAudited(bd.auditor, "Verifying bundle layer", cmd)
+	contentWriter.CloseWithError(err)
+	verifyErr := <-verified
+	if err != nil {
+		return fmt.Errorf("ctr content get %s: %w: %s", digest, err, strings.TrimSpace(string(out)))
+	}
+	return verifyErr
+}
+
+// registryCredentials returns the user:password credentials of the registry for ctr, which takes
+// no registry tokens. It returns an empty string for the anonymous access.
+func registryCredentials(auth authn.Authenticator) (string, error) {
+	config, err := auth.Authorization()
+	if err != nil {
+		return "", err
+	}
+	if config.Username == "" {
+		return "", nil
+	}
+	return config.Username + ":" + config.Password, nil
+}
+
+// downloadBlob downloads the blob at blobURL to blobPath, resuming from the partially downloaded
+// blob if any. The partial blob is removed when it does not match the digest once completed.
+func (bd *bundleDownloader) downloadBlob(ctx context.Context, client *http.Client, blobURL string, digest v1.Hash, blobPath string) error {
+	if verifyBlob(blobPath, digest) == nil {
+		bd.logger.Info("Bundle layer already downloaded", "digest", digest)
+		return nil
+	}
+
+	partialPath := blobPath + partialBlobSuffix
+	partial, err := os.OpenFile(partialPath, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer partial.Close()
+	offset, err := partial.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, blobURL, http.NoBody)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		bd.logger.Info("Resuming bundle layer download", "digest", digest, "offset", offset)
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		// the registry does not support range requests, start over
+		if err = partial.Truncate(0); err != nil {
+			return err
+		}
+		if _, err = partial.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		_ = os.Remove(partialPath)
+		return fmt.Errorf("downloading bundle layer %s: partial download is larger than the layer", digest)
+	default:
+		return fmt.Errorf("downloading bundle layer %s: unexpected status %s", digest, resp.Status)
+	}
+
+	if _, err = io.Copy(partial, rateLimited(ctx, resp.Body, bd.rateLimiter)); err != nil {
+		return err
+	}
+	if err = partial.Close(); err != nil {
+		return err
+	}
+	if err = verifyBlob(partialPath, digest); err != nil {
+		_ = os.Remove(partialPath)
+		return err
+	}
+	return os.Rename(partialPath, blobPath)
+}
+
+// verifyBlob compares the digest of the blob at blobPath with the expected one
+func verifyBlob(blobPath string, digest v1.Hash) error {
+	f, err := os.Open(blobPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return verifyDigest(f, digest)
+}
+
+// verifyDigest compares the digest of the content read from r with the expected one
+func verifyDigest(r io.Reader, digest v1.Hash) error {
+	if digest.Algorithm != "sha256" {
+		return fmt.Errorf("unsupported digest algorithm %s", digest.Algorithm)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return err
+	}
+	if hex.EncodeToString(h.Sum(nil)) != digest.Hex {
+		return ErrBundleChecksum
+	}
+	return nil
+}
+
+// extractLayer extracts the, optionally gzip compressed, tar layer at blobPath into dir
+func extractLayer(blobPath, dir string) error {
+	f, err := os.Open(blobPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = bufio.NewReader(f)
+	if magic, _ := r.(*bufio.Reader).Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dir, hdr.Name) // nolint: gosec // checked right below
+		if target != filepath.Clean(dir) && !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("bundle layer entry %s is outside of the bundle", hdr.Name)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err = os.MkdirAll(target, DownloadPathPermissions); err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA: // nolint: staticcheck // written by older tar implementations
+			if err = writeLayerFile(target, tr, hdr.FileInfo().Mode()); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err = os.MkdirAll(filepath.Dir(target), DownloadPathPermissions); err != nil {
+				return err
+			}
+			_ = os.Remove(target)
+			if err = os.Symlink(hdr.Linkname, target); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// writeLayerFile writes the content of a layer entry to path
+func writeLayerFile(path string, r io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), DownloadPathPermissions); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, r); err != nil { // nolint: gosec // the layer digest is verified
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,319 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// nolint: testpackage
+package installer
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-containerregistry/pkg/name"
+	ociregistry "github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// rangeHandler serves the blobs of the registry honoring the range requests
+type rangeHandler struct {
+	registry     http.Handler
+	rangeHeaders []string
+}
+
+func (h *rangeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet || !strings.Contains(r.URL.Path, "/blobs/") {
+		h.registry.ServeHTTP(w, r)
+		return
+	}
+	h.rangeHeaders = append(h.rangeHeaders, r.Header.Get("Range"))
+	rec := httptest.NewRecorder()
+	h.registry.ServeHTTP(rec, r)
+	if rec.Code != http.StatusOK {
+		w.WriteHeader(rec.Code)
+		return
+	}
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(rec.Body.Bytes()))
+}
+
+var _ = Describe("Byohost Installer Tests", func() {
+	var (
+		ctx          = context.Background()
+		bd           *bundleDownloader
+		server       *httptest.Server
+		handler      *rangeHandler
+		downloadPath string
+		bundleDir    string
+		bundleAddr   string
+		layer        v1.Layer
+		digest       v1.Hash
+		blobPath     string
+	)
+
+	BeforeEach(func() {
+		handler = &rangeHandler{registry: ociregistry.New(ociregistry.Logger(log.New(io.Discard, "", 0)))}
+		server = httptest.NewServer(handler)
+		repoAddr := strings.TrimPrefix(server.URL, "http://") + "/repo"
+
+		img, err := random.Image(1024, 1)
+		Expect(err).ShouldNot(HaveOccurred())
+		bundleAddr = repoAddr + "/byoh-bundle:test-tag"
+		ref, err := name.ParseReference(bundleAddr)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(remote.Write(ref, img)).Should(Succeed())
+
+		layers, err := img.Layers()
+		Expect(err).ShouldNot(HaveOccurred())
+		layer = layers[0]
+		digest, err = layer.Digest()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		downloadPath, err = os.MkdirTemp("", "fetcherTest")
+		Expect(err).ShouldNot(HaveOccurred())
+		bd = NewBundleDownloader(BundleTypeK8s, repoAddr, downloadPath, logr.Discard())
+		bundleDir = filepath.Join(downloadPath, "bundle")
+		blobPath = filepath.Join(bd.getBundlePathWithRepo(), blobsDir, digest.Algorithm+"-"+digest.Hex)
+	})
+
+	AfterEach(func() {
+		server.Close()
+		Expect(os.RemoveAll(downloadPath)).Should(Succeed())
+	})
+
+	layerContent := func() []byte {
+		rc, err := layer.Compressed()
+		Expect(err).ShouldNot(HaveOccurred())
+		defer rc.Close()
+		content, err := io.ReadAll(rc)
+		Expect(err).ShouldNot(HaveOccurred())
+		return content
+	}
+
+	Context("When the bundle is downloaded from the registry", func() {
+		It("Should extract the bundle layers and remove them", func() {
+			Expect(bd.downloadByOCI(bundleAddr, bundleDir)).Should(Succeed())
+
+			files, err := os.ReadDir(bundleDir)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(files).Should(HaveLen(1))
+			Expect(files[0].Name()).Should(HavePrefix("random_file_"))
+			_, err = os.Stat(blobPath)
+			Expect(os.IsNotExist(err)).Should(BeTrue())
+		})
+
+		It("Should resume a partially downloaded layer", func() {
+			content := layerContent()
+			Expect(ensureDirExist(filepath.Dir(blobPath))).Should(Succeed())
+			Expect(os.WriteFile(blobPath+partialBlobSuffix, content[:len(content)/2], 0644)).Should(Succeed())
+
+			Expect(bd.downloadByOCI(bundleAddr, bundleDir)).Should(Succeed())
+			Expect(handler.rangeHeaders).Should(ContainElement(HavePrefix("bytes=")))
+			files, err := os.ReadDir(bundleDir)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(files).Should(HaveLen(1))
+		})
+
+		It("Should discard a corrupted layer", func() {
+			content := layerContent()
+			Expect(ensureDirExist(filepath.Dir(blobPath))).Should(Succeed())
+			corrupted := append([]byte("corrupted"), content[len("corrupted"):len(content)/2]...)
+			Expect(os.WriteFile(blobPath+partialBlobSuffix, corrupted, 0644)).Should(Succeed())
+
+			Expect(bd.downloadByOCI(bundleAddr, bundleDir)).Should(Equal(ErrBundleChecksum))
+			_, err := os.Stat(blobPath + partialBlobSuffix)
+			Expect(os.IsNotExist(err)).Should(BeTrue())
+
+			// the next attempt downloads the whole layer again
+			Expect(bd.downloadByOCI(bundleAddr, bundleDir)).Should(Succeed())
+		})
+
+		It("Should throttle the layer downloads to the rate limit", func() {
+			content := layerContent()
+			bytesPerSecond := int64(len(content))
+			bd.rateLimiter = newRateLimiter(bytesPerSecond)
+			// the burst of the limiter is spent first
+			Expect(bd.rateLimiter.WaitN(ctx, bd.rateLimiter.Burst())).Should(Succeed())
+
+			start := time.Now()
+			Expect(bd.downloadByOCI(bundleAddr, bundleDir)).Should(Succeed())
+			Expect(time.Since(start)).Should(BeNumerically(">=", 900*time.Millisecond))
+		})
+	})
+
+	Context("When the bundle is pulled with containerd", func() {
+		var (
+			imgpkgAddr  string
+			imgpkgLayer v1.Layer
+		)
+
+		BeforeEach(func() {
+			// imgpkg push -f pushes the files of the bundle directory as a single layer
+			img := imgpkgBundleImage(map[string]string{
+				"kubelet":         "kubelet",
+				"kubeadm":         "kubeadm",
+				"kubelet.service": "[Service]\n",
+				"10-kubeadm.conf": "[Service]\n",
+				"conf.tar":        "conf",
+			})
+			imgpkgAddr = strings.TrimPrefix(server.URL, "http://") + "/repo/byoh-bundle-immutable_x86-64_k8s:v1.22.3"
+			ref, err := name.ParseReference(imgpkgAddr)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(remote.Write(ref, img)).Should(Succeed())
+			layers, err := img.Layers()
+			Expect(err).ShouldNot(HaveOccurred())
+			imgpkgLayer = layers[0]
+
+			Expect(ensureDirExist(bd.getBundlePathWithRepo())).Should(Succeed())
+			Expect(ensureDirExist(bundleDir)).Should(Succeed())
+		})
+
+		expectBundleLayout := func() {
+			for _, file := range []string{"kubelet", "kubeadm", "kubelet.service", "10-kubeadm.conf", "conf.tar"} {
+				Expect(filepath.Join(bundleDir, file)).Should(BeARegularFile())
+			}
+		}
+
+		Context("with a fake ctr", func() {
+			var (
+				ctrDir  string
+				envVars map[string]string
+				records []AuditRecord
+			)
+
+			BeforeEach(func() {
+				var err error
+				ctrDir, err = os.MkdirTemp("", "ctrTest")
+				Expect(err).ShouldNot(HaveOccurred())
+				// the fake ctr logs its args and serves the layers of the content store, and
+				// mounts the image by extracting its layer like the containerd snapshotter
+				Expect(os.WriteFile(filepath.Join(ctrDir, "ctr"), []byte(`#!/bin/sh
+echo "$* HTTPS_PROXY=$HTTPS_PROXY" >> "$CTR_LOG"
+case "$3 $4" in
+"content get") cat "$CTR_CONTENT/$5" ;;
+"images mount") tar -xzf "$CTR_CONTENT/$CTR_LAYER" -C "$6" ;;
+esac
+`), 0755)).Should(Succeed())
+
+				digest, err := imgpkgLayer.Digest()
+				Expect(err).ShouldNot(HaveOccurred())
+				content, err := os.MkdirTemp(ctrDir, "content")
+				Expect(err).ShouldNot(HaveOccurred())
+				rc, err := imgpkgLayer.Compressed()
+				Expect(err).ShouldNot(HaveOccurred())
+				layerContent, err := io.ReadAll(rc)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(os.WriteFile(filepath.Join(content, digest.String()), layerContent, 0644)).Should(Succeed())
+
+				dockerConfig := filepath.Join(ctrDir, "config.json")
+				registry := strings.TrimPrefix(server.URL, "http://")
+				Expect(os.WriteFile(dockerConfig, []byte(`{"auths": {"`+registry+`": {"auth": "`+
+					base64.StdEncoding.EncodeToString([]byte("byoh:s3cret"))+`"}}}`), 0600)).Should(Succeed())
+
+				envVars = map[string]string{
+					"PATH":          ctrDir + string(os.PathListSeparator) + os.Getenv("PATH"),
+					"CTR_LOG":       filepath.Join(ctrDir, "ctr.log"),
+					"CTR_CONTENT":   content,
+					"CTR_LAYER":     digest.String(),
+					"DOCKER_CONFIG": ctrDir,
+				}
+				for name, value := range envVars {
+					previous, set := os.LookupEnv(name)
+					Expect(os.Setenv(name, value)).Should(Succeed())
+					if set {
+						envVars[name] = previous
+					} else {
+						envVars[name] = ""
+					}
+				}
+
+				records = nil
+				bd.auditor = &commandAuditor{auditFunc: func(record AuditRecord) { records = append(records, record) }}
+				bd.proxy = ProxyConfig{HTTPSProxy: "http://proxy.local:3128"}
+			})
+
+			AfterEach(func() {
+				for name, value := range envVars {
+					if value == "" {
+						Expect(os.Unsetenv(name)).Should(Succeed())
+					} else {
+						Expect(os.Setenv(name, value)).Should(Succeed())
+					}
+				}
+				Expect(os.RemoveAll(ctrDir)).Should(Succeed())
+			})
+
+			It("Should pull the bundle by digest with the credentials and the proxy of the agent", func() {
+				Expect(bd.downloadByContainerd(imgpkgAddr, bundleDir)).Should(Succeed())
+				expectBundleLayout()
+
+				ctrLog, err := os.ReadFile(filepath.Join(ctrDir, "ctr.log"))
+				Expect(err).ShouldNot(HaveOccurred())
+				calls := strings.Split(strings.TrimSpace(string(ctrLog)), "\n")
+				Expect(calls[0]).Should(MatchRegexp(`^-n byoh images pull --user byoh:s3cret --plain-http .*/repo/byoh-bundle-immutable_x86-64_k8s@sha256:[0-9a-f]{64} HTTPS_PROXY=http://proxy.local:3128$`))
+				Expect(calls[1]).Should(HavePrefix("-n byoh content get sha256:"))
+				Expect(calls[2]).Should(HavePrefix("-n byoh images mount "))
+
+				Expect(records).ShouldNot(BeEmpty())
+				for _, record := range records {
+					Expect(record.Command).ShouldNot(ContainSubstring("s3cret"))
+				}
+				Expect(records[0].Command).Should(ContainSubstring("--user <redacted>"))
+			})
+
+			It("Should not install a bundle whose layers do not match the manifest", func() {
+				Expect(os.WriteFile(filepath.Join(os.Getenv("CTR_CONTENT"), os.Getenv("CTR_LAYER")), []byte("corrupted"), 0644)).Should(Succeed())
+
+				Expect(bd.downloadByContainerd(imgpkgAddr, bundleDir)).Should(Equal(ErrBundleChecksum))
+				Expect(filepath.Join(bundleDir, "kubelet")).ShouldNot(BeAnExistingFile())
+			})
+		})
+
+		It("Should mount the bundle pushed by imgpkg with the layout of the bundle directory", func() {
+			if err := exec.Command("ctr", "version").Run(); err != nil {
+				Skip("containerd is not available: " + err.Error())
+			}
+
+			Expect(bd.downloadByContainerd(imgpkgAddr, bundleDir)).Should(Succeed())
+			expectBundleLayout()
+			content, err := os.ReadFile(filepath.Join(bundleDir, "10-kubeadm.conf"))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(string(content)).Should(Equal("[Service]\n"))
+		})
+	})
+})
+
+// imgpkgBundleImage returns the image imgpkg pushes for a bundle directory holding the files, i.e. a single
+// gzip compressed layer of the files of the directory
+func imgpkgBundleImage(files map[string]string) v1.Image {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range files {
+		Expect(tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg})).Should(Succeed())
+		_, err := tw.Write([]byte(content))
+		Expect(err).ShouldNot(HaveOccurred())
+	}
+	Expect(tw.Close()).Should(Succeed())
+
+	layer, err := tarball.LayerFromReader(&buf)
+	Expect(err).ShouldNot(HaveOccurred())
+	img, err := mutate.AppendLayers(empty.Image, layer)
+	Expect(err).ShouldNot(HaveOccurred())
+	return img
+}
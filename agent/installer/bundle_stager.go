@@ -0,0 +1,215 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package installer
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// stagedBundleChecksums is the file of a staged bundle listing the sha256 checksums of its files,
+// in the format of sha256sum
+const stagedBundleChecksums = "SHA256SUMS"
+
+// Stage downloads the bundle of the k8s version for the OS under stagingPath, with the layout of
+// the download path of the installer, and writes the checksums of its files along with them.
+// The OS is detected when empty, otherwise the bundle is staged for another host, e.g. onto a
+// removable drive. The bundle is verified instead when it is already staged.
+// It returns the path of the staged bundle.
+func Stage(stagingPath, normalizedOs string, bundleType BundleType, mode InstallMode, bundleRepo, k8sVer, tag string, logger logr.Logger) (string, error) {
+	if stagingPath == "" {
+		return "", fmt.Errorf("empty staging path")
+	}
+	if normalizedOs == "" {
+		detectedOs, err := (&osDetector{}).Detect()
+		if err != nil {
+			return "", ErrDetectOs
+		}
+		normalizedOs = detectedOs
+	}
+	i, err := newUnchecked(normalizedOs, bundleType, stagingPath, logger, &logPrinter{logger})
+	if err != nil {
+		return "", err
+	}
+	i.SetInstallMode(mode)
+	i.setBundleRepo(bundleRepo)
+	_, osBundle, err := i.resolveAlgoInstaller(k8sVer, tag)
+	if err != nil {
+		return "", err
+	}
+
+	bundleDirPath := i.bundleDownloader.GetBundleDirPath(k8sVer)
+	if _, err := os.Stat(filepath.Join(bundleDirPath, stagedBundleChecksums)); err == nil {
+		logger.Info("Bundle already staged, verifying it", "path", bundleDirPath)
+		return bundleDirPath, verifyBundleChecksums(bundleDirPath)
+	}
+	// the bundles pulled with containerd are OCI images as well, containerd is not needed to stage them
+	if err := i.bundleDownloader.Download(osBundle, k8sVer, tag); err != nil {
+		return "", err
+	}
+	return bundleDirPath, writeBundleChecksums(bundleDirPath)
+}
+
+// copyStagedBundle copies the bundle of the k8s version staged under the staged path, if any,
+// to bundleDirPath once its checksums are verified. It returns false when the bundle has to be
+// downloaded, i.e. when it is not staged or fails to be verified or copied.
+func (bd *bundleDownloader) copyStagedBundle(k8sVersion, bundleDirPath string) bool {
+	if bd.stagedPath == "" {
+		return false
+	}
+	staged := *bd
+	staged.downloadPath = bd.stagedPath
+	stagedDirPath := staged.GetBundleDirPath(k8sVersion)
+	if !checkDirExist(stagedDirPath) {
+		bd.logger.Info("Bundle not staged", "path", stagedDirPath)
+		return false
+	}
+
+	if err := verifyBundleChecksums(stagedDirPath); err != nil {
+		bd.emitEvent(corev1.EventTypeWarning, "StagedBundleInvalid", fmt.Sprintf("Staged bundle %s failed to be verified, downloading it: %v", stagedDirPath, err))
+		return false
+	}
+	dir, err := os.MkdirTemp(filepath.Dir(bundleDirPath), "stagedBundle")
+	if err == nil {
+		err = copyDir(stagedDirPath, dir)
+		if err == nil {
+			err = os.Rename(dir, bundleDirPath)
+		}
+		if err != nil {
+			_ = os.RemoveAll(dir)
+		}
+	}
+	if err != nil {
+		bd.emitEvent(corev1.EventTypeWarning, "StagedBundleInvalid", fmt.Sprintf("Staged bundle %s failed to be copied, downloading it: %v", stagedDirPath, err))
+		return false
+	}
+	bd.emitEvent(corev1.EventTypeNormal, "StagedBundleUsed", fmt.Sprintf("Using the bundle staged at %s", stagedDirPath))
+	return true
+}
+
+// writeBundleChecksums writes the checksums of the files of the bundle to its stagedBundleChecksums
+func writeBundleChecksums(bundleDirPath string) error {
+	var lines []string
+	err := filepath.WalkDir(bundleDirPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		relPath, err := filepath.Rel(bundleDirPath, path)
+		if err != nil || relPath == stagedBundleChecksums {
+			return err
+		}
+		checksum, err := fileChecksum(path)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, fmt.Sprintf("%s  %s\n", checksum, filepath.ToSlash(relPath)))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Strings(lines)
+	return os.WriteFile(filepath.Join(bundleDirPath, stagedBundleChecksums), []byte(strings.Join(lines, "")), 0644)
+}
+
+// verifyBundleChecksums compares the files of the bundle with its stagedBundleChecksums
+func verifyBundleChecksums(bundleDirPath string) error {
+	f, err := os.Open(filepath.Join(bundleDirPath, stagedBundleChecksums))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	verified := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), "  ", 2)
+		if len(fields) != 2 {
+			return fmt.Errorf("malformed %s line %q", stagedBundleChecksums, scanner.Text())
+		}
+		checksum, err := fileChecksum(filepath.Join(bundleDirPath, filepath.FromSlash(fields[1])))
+		if err != nil {
+			return err
+		}
+		if checksum != fields[0] {
+			return fmt.Errorf("%w: %s", ErrBundleChecksum, fields[1])
+		}
+		verified++
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if verified == 0 {
+		return fmt.Errorf("empty %s", stagedBundleChecksums)
+	}
+	return nil
+}
+
+// fileChecksum returns the hex encoded sha256 checksum of the file
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// copyDir copies the directories and the regular files under src to dst, keeping their modes
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, relPath)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case d.Type().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		}
+		return nil
+	})
+}
+
+// copyFile copies the regular file at src to dst with the mode
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
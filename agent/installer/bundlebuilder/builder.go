@@ -0,0 +1,250 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundlebuilder
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	"github.com/go-logr/logr"
+	"github.com/k14s/imgpkg/pkg/imgpkg/cmd"
+)
+
+// confTar is the well-known name of the configuration of the bundle
+const confTar = "conf.tar"
+
+// Builder downloads the ingredients of the bundles and builds them
+type Builder struct {
+	Client *http.Client
+	Logger logr.Logger
+}
+
+// Download downloads the ingredients of the recipe to ingredientsDir, verifying their checksums.
+// The ingredients already in ingredientsDir are kept, e.g. custom kubernetes components.
+func (b *Builder) Download(ctx context.Context, recipe *Recipe, ingredientsDir string) error {
+	if err := os.MkdirAll(ingredientsDir, 0755); err != nil {
+		return err
+	}
+	for _, ingredient := range recipe.Ingredients {
+		ingredientPath := filepath.Join(ingredientsDir, ingredient.Name)
+		if _, err := os.Stat(ingredientPath); err == nil {
+			b.Logger.Info("Keeping the ingredient", "path", ingredientPath)
+			continue
+		}
+		b.Logger.Info("Downloading the ingredient", "name", ingredient.Name, "url", ingredient.URL)
+		if err := b.download(ctx, ingredient, ingredientPath); err != nil {
+			return fmt.Errorf("downloading %s: %w", ingredient.Name, err)
+		}
+	}
+	return nil
+}
+
+// download downloads the ingredient to ingredientPath, once verified and extracted
+func (b *Builder) download(ctx context.Context, ingredient Ingredient, ingredientPath string) error {
+	expected := ingredient.SHA256
+	if expected == "" && ingredient.SHA256URL != "" {
+		var err error
+		if expected, err = b.checksumOf(ctx, ingredient); err != nil {
+			return err
+		}
+	}
+
+	downloaded, err := os.CreateTemp(filepath.Dir(ingredientPath), ".download")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(downloaded.Name())
+	defer downloaded.Close()
+	body, err := get(ctx, b.Client, ingredient.URL)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(downloaded, h), body); err != nil {
+		return err
+	}
+	if actual := hex.EncodeToString(h.Sum(nil)); expected != "" && !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("checksum mismatch, expected %s, got %s", expected, actual)
+	}
+	if _, err := downloaded.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	var r io.Reader = downloaded
+	if ingredient.Gunzip || ingredient.Extract != "" {
+		gz, err := gzip.NewReader(downloaded)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	mode := os.FileMode(0644)
+	if ingredient.Extract != "" {
+		if r, err = extract(r, ingredient.Extract); err != nil {
+			return err
+		}
+		mode = 0755
+	}
+	return writeFile(ingredientPath, r, mode)
+}
+
+// checksumOf returns the checksum of the ingredient listed in its checksum file
+func (b *Builder) checksumOf(ctx context.Context, ingredient Ingredient) (string, error) {
+	body, err := get(ctx, b.Client, ingredient.SHA256URL)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	fileName := path.Base(ingredient.URL)
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		switch {
+		case len(fields) == 1:
+			return fields[0], nil
+		case len(fields) == 2 && path.Base(strings.TrimPrefix(fields[1], "*")) == fileName:
+			return fields[0], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("no checksum of %s in %s", fileName, ingredient.SHA256URL)
+}
+
+// extract returns the reader of the file of the tar archive
+func extract(r io.Reader, name string) (io.Reader, error) {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("no %s in the archive", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if path.Clean(header.Name) == name {
+			return tr, nil
+		}
+	}
+}
+
+// Build assembles the bundle of the recipe in bundleDir, from the ingredients in ingredientsDir
+// and the configuration directory of the recipe under configRoot
+func (b *Builder) Build(recipe *Recipe, ingredientsDir, configRoot, bundleDir string) error {
+	if err := os.MkdirAll(bundleDir, 0755); err != nil {
+		return err
+	}
+	for _, ingredient := range recipe.Ingredients {
+		if err := copyFile(filepath.Join(ingredientsDir, ingredient.Name), filepath.Join(bundleDir, ingredient.Name)); err != nil {
+			return err
+		}
+	}
+	configDir := filepath.Join(configRoot, filepath.FromSlash(recipe.Config))
+	b.Logger.Info("Adding the configuration", "path", configDir)
+	return writeConfTar(configDir, filepath.Join(bundleDir, confTar))
+}
+
+// Push pushes the bundle in bundleDir to the image, e.g. <repo>/<bundle name>:<tag>, with imgpkg
+func (b *Builder) Push(bundleDir, image string) error {
+	var confUI = ui.NewConfUI(ui.NewNoopLogger())
+	defer confUI.Flush()
+
+	imgpkgCmd := cmd.NewDefaultImgpkgCmd(confUI)
+	imgpkgCmd.SetArgs([]string{"push", "-f", bundleDir, "-i", image})
+	return imgpkgCmd.Execute()
+}
+
+// writeConfTar writes the files under configDir to a tar archive at tarPath
+func writeConfTar(configDir, tarPath string) error {
+	f, err := os.Create(tarPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	tw := tar.NewWriter(f)
+	err = filepath.WalkDir(configDir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(configDir, filePath)
+		if err != nil || relPath == "." {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relPath)
+		if d.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		src, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(tw, src)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// copyFile copies the file at src to dst, keeping its mode
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	return writeFile(dst, in, info.Mode().Perm())
+}
+
+// writeFile writes the content of r to filePath with the mode
+func writeFile(filePath string, r io.Reader, mode os.FileMode) error {
+	out, err := os.OpenFile(filePath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
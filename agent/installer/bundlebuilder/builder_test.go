@@ -0,0 +1,158 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundlebuilder_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/installer/bundlebuilder"
+)
+
+// gzipped returns the gzip compressed tar archive of the files
+func gzipped(files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		Expect(tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content))})).To(Succeed())
+		_, err := tw.Write([]byte(content))
+		Expect(err).NotTo(HaveOccurred())
+	}
+	Expect(tw.Close()).To(Succeed())
+	Expect(gz.Close()).To(Succeed())
+	return buf.Bytes()
+}
+
+func checksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+var _ = Describe("Bundle builder", func() {
+	var (
+		ctx            context.Context
+		server         *httptest.Server
+		builder        *bundlebuilder.Builder
+		ingredientsDir string
+		bundleDir      string
+		configRoot     string
+	)
+
+	BeforeEach(func() {
+		ctx = context.TODO()
+		crictl := gzipped(map[string]string{"crictl": "crictl binary"})
+		mux := http.NewServeMux()
+		mux.HandleFunc("/kubelet", func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte("kubelet binary"))
+		})
+		mux.HandleFunc("/kubelet.sha256", func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(checksum("kubelet binary")))
+		})
+		mux.HandleFunc("/rke2.linux-amd64.tar.gz", func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte("rke2 tarball"))
+		})
+		mux.HandleFunc("/sha256sum-amd64.txt", func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprintf(w, "%s  rke2-images.linux-amd64.tar.zst\n%s  rke2.linux-amd64.tar.gz\n", checksum("images"), checksum("rke2 tarball"))
+		})
+		mux.HandleFunc("/crictl.tar.gz", func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write(crictl)
+		})
+		server = httptest.NewServer(mux)
+		builder = &bundlebuilder.Builder{Client: server.Client(), Logger: logr.Discard()}
+
+		tmpDir, err := os.MkdirTemp("", "bundlebuilder")
+		Expect(err).NotTo(HaveOccurred())
+		ingredientsDir = filepath.Join(tmpDir, "ingredients")
+		bundleDir = filepath.Join(tmpDir, "bundle")
+		configRoot = filepath.Join(tmpDir, "config")
+		Expect(os.MkdirAll(filepath.Join(configRoot, "ubuntu", "etc", "sysctl.d"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(configRoot, "ubuntu", "etc", "sysctl.d", "99-kubernetes-cri.conf"), []byte("net.ipv4.ip_forward = 1\n"), 0644)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		server.Close()
+		Expect(os.RemoveAll(filepath.Dir(ingredientsDir))).To(Succeed())
+	})
+
+	It("should download and verify the ingredients", func() {
+		recipe := &bundlebuilder.Recipe{Ingredients: []bundlebuilder.Ingredient{
+			{Name: "kubelet", URL: server.URL + "/kubelet", SHA256URL: server.URL + "/kubelet.sha256"},
+			{Name: "rke2.tar.gz", URL: server.URL + "/rke2.linux-amd64.tar.gz", SHA256URL: server.URL + "/sha256sum-amd64.txt"},
+			{Name: "crictl", URL: server.URL + "/crictl.tar.gz", Extract: "crictl"},
+		}}
+		Expect(builder.Download(ctx, recipe, ingredientsDir)).To(Succeed())
+
+		kubelet, err := os.ReadFile(filepath.Join(ingredientsDir, "kubelet"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(kubelet)).To(Equal("kubelet binary"))
+		crictl, err := os.ReadFile(filepath.Join(ingredientsDir, "crictl"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(crictl)).To(Equal("crictl binary"))
+	})
+
+	It("should fail on a checksum mismatch", func() {
+		recipe := &bundlebuilder.Recipe{Ingredients: []bundlebuilder.Ingredient{
+			{Name: "kubelet.deb", URL: server.URL + "/kubelet", SHA256: checksum("another kubelet")},
+		}}
+		Expect(builder.Download(ctx, recipe, ingredientsDir)).To(MatchError(ContainSubstring("checksum mismatch")))
+		_, err := os.Stat(filepath.Join(ingredientsDir, "kubelet.deb"))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("should keep the ingredients already downloaded", func() {
+		Expect(os.MkdirAll(ingredientsDir, 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(ingredientsDir, "kubelet"), []byte("custom kubelet"), 0644)).To(Succeed())
+		recipe := &bundlebuilder.Recipe{Ingredients: []bundlebuilder.Ingredient{
+			{Name: "kubelet", URL: server.URL + "/missing"},
+		}}
+		Expect(builder.Download(ctx, recipe, ingredientsDir)).To(Succeed())
+	})
+
+	It("should build the bundle with the ingredients and the configuration", func() {
+		Expect(os.MkdirAll(ingredientsDir, 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(ingredientsDir, "kubelet.deb"), []byte("kubelet package"), 0644)).To(Succeed())
+		recipe := &bundlebuilder.Recipe{
+			Format:      bundlebuilder.FormatDeb,
+			Config:      "ubuntu",
+			Ingredients: []bundlebuilder.Ingredient{{Name: "kubelet.deb"}},
+		}
+		Expect(builder.Build(recipe, ingredientsDir, configRoot, bundleDir)).To(Succeed())
+
+		kubelet, err := os.ReadFile(filepath.Join(bundleDir, "kubelet.deb"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(kubelet)).To(Equal("kubelet package"))
+
+		conf, err := os.Open(filepath.Join(bundleDir, "conf.tar"))
+		Expect(err).NotTo(HaveOccurred())
+		defer conf.Close()
+		entries := map[string]string{}
+		tr := tar.NewReader(conf)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			Expect(err).NotTo(HaveOccurred())
+			content, err := io.ReadAll(tr)
+			Expect(err).NotTo(HaveOccurred())
+			entries[header.Name] = string(content)
+		}
+		Expect(entries).To(HaveKey("etc/"))
+		Expect(entries).To(HaveKeyWithValue("etc/sysctl.d/99-kubernetes-cri.conf", "net.ipv4.ip_forward = 1\n"))
+	})
+})
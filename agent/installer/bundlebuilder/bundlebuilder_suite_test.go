@@ -0,0 +1,16 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundlebuilder_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestBundleBuilder(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Bundle Builder Suite")
+}
@@ -0,0 +1,78 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundlebuilder
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/installer"
+	"k8s.io/klog/v2/klogr"
+)
+
+// Main entry point for the bundle builder CLI
+func Main() {
+	spec := Spec{}
+	flag.StringVar(&spec.KubernetesVersion, "k8s", "", "Kubernetes version of the bundle, e.g. v1.23.5, or rke2 release of the rke2 bundle, e.g. v1.23.5+rke2r1")
+	flag.StringVar(&spec.OS, "os", "Ubuntu_20.04.1", "OS of the bundle as named by the installer, without its architecture, e.g. Ubuntu_20.04.1, RHEL_8, SLES_15, Immutable or Flatcar")
+	flag.StringVar(&spec.Arch, "arch", "x86-64", "Architecture of the bundle, x86-64 or arm64")
+	rke2 := flag.Bool("rke2", false, "Build the rke2 bundle of the architecture instead of the bundle of the OS")
+	flag.StringVar(&spec.ContainerdVersion, "containerd-version", defaultContainerdVersion, "containerd version of the package bundles")
+	flag.StringVar(&spec.CNIVersion, "cni-version", defaultCNIVersion, "Version of the CNI plugins")
+	flag.StringVar(&spec.CRIToolsVersion, "cri-tools-version", defaultCRIToolsVersion, "Version of the CRI tools")
+	flag.StringVar(&spec.ReleaseVersion, "release-version", defaultReleaseVersion, "Version of the kubernetes/release repository the kubelet systemd units of the binary bundles come from")
+	flag.StringVar(&spec.AptRepo, "apt-repo", defaultAptRepo, "apt repository of the Debian packages")
+	flag.StringVar(&spec.YumRepo, "yum-repo", "", "yum repository of the RPM packages, defaults to the Kubernetes yum repository of the architecture")
+	configRoot := flag.String("config-root", "agent/installer/bundle_builder/config", "Root of the bundle configuration directories")
+	ingredientsDir := flag.String("ingredients-path", "byoh-ingredients-download", "Path the ingredients are downloaded to. The ingredients already there, e.g. custom kubernetes components, are kept")
+	bundleDir := flag.String("bundle-path", "byoh-bundle", "Path the bundle is built in")
+	repo := flag.String("push-repo", "", "Repository the bundle is pushed to as <repo>/<bundle name>:<tag>. The bundle is only built when empty")
+	tag := flag.String("tag", "", "Tag of the pushed bundle, defaults to the Kubernetes version")
+	flag.Parse()
+
+	if *rke2 {
+		spec.BundleType = installer.BundleTypeRKE2
+	}
+	logger := klogr.New()
+	b := &Builder{Client: http.DefaultClient, Logger: logger}
+	ctx := context.Background()
+
+	recipe, err := Resolve(ctx, b.Client, spec)
+	if err != nil {
+		logger.Error(err, "unable to resolve the bundle ingredients")
+		os.Exit(1)
+	}
+	if err := b.Download(ctx, recipe, *ingredientsDir); err != nil {
+		logger.Error(err, "unable to download the bundle ingredients")
+		os.Exit(1)
+	}
+	if err := b.Build(recipe, *ingredientsDir, *configRoot, *bundleDir); err != nil {
+		logger.Error(err, "unable to build the bundle")
+		os.Exit(1)
+	}
+	fmt.Printf("Bundle %s built in %s\n", recipe.BundleName, *bundleDir)
+
+	if *repo == "" {
+		return
+	}
+	if *tag == "" {
+		*tag = spec.KubernetesVersion
+	}
+	image := fmt.Sprintf("%s/%s:%s", *repo, recipe.BundleName, sanitizeTag(*tag))
+	if err := b.Push(*bundleDir, image); err != nil {
+		logger.Error(err, "unable to push the bundle", "image", image)
+		os.Exit(1)
+	}
+	fmt.Printf("Bundle pushed to %s\n", image)
+}
+
+// sanitizeTag replaces the characters which are not allowed in the image tags, i.e. the + of the
+// rke2 releases, with _
+func sanitizeTag(tag string) string {
+	return strings.ReplaceAll(tag, "+", "_")
+}
@@ -0,0 +1,12 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/installer/bundlebuilder"
+)
+
+func main() {
+	bundlebuilder.Main()
+}
@@ -0,0 +1,288 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package bundlebuilder builds the BYOH bundles of any Kubernetes version, OS and architecture:
+// it resolves the ingredients of the bundle, downloads and verifies them, assembles the bundle
+// under the well-known names expected by the installer and pushes it as an ImgPkg bundle.
+package bundlebuilder
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/installer"
+)
+
+// Format is the format of the kubernetes components of a bundle
+type Format string
+
+const (
+	// FormatDeb bundles hold the Debian packages of the kubernetes components
+	FormatDeb Format = "deb"
+	// FormatRPM bundles hold the RPM packages of the kubernetes components
+	FormatRPM Format = "rpm"
+	// FormatBin bundles hold the plain binaries of the kubernetes components, for the immutable hosts
+	FormatBin Format = "bin"
+	// FormatRKE2 bundles hold the rke2 release tarball
+	FormatRKE2 Format = "rke2"
+)
+
+const (
+	defaultContainerdVersion = "1.6.0"
+	defaultCNIVersion        = "0.8.7"
+	defaultCRIToolsVersion   = "1.23.0"
+	// defaultReleaseVersion is the version of the kubernetes/release repository the kubelet
+	// systemd units of the binary bundles come from
+	defaultReleaseVersion = "0.4.0"
+	defaultAptRepo        = "https://apt.kubernetes.io"
+	defaultAptDist        = "kubernetes-xenial"
+	defaultYumRepo        = "https://packages.cloud.google.com/yum/repos/kubernetes-el7-"
+)
+
+// osFormat is the format of the components and the default configuration of the bundles of an OS
+type osFormat struct {
+	// prefix matches the bundle OS
+	prefix string
+	format Format
+	// config is the configuration directory, relative to the configuration root
+	config string
+}
+
+// osFormats are the formats of the bundle OSes, new OSes are added here
+var osFormats = []osFormat{
+	{prefix: "Ubuntu_", format: FormatDeb, config: "ubuntu/20_04/k8s/1_22"},
+	{prefix: "RHEL_", format: FormatRPM, config: "rhel/8/k8s/1_22"},
+	{prefix: "SLES_", format: FormatRPM, config: "suse/15/k8s/1_22"},
+	{prefix: "Immutable", format: FormatBin, config: "immutable/k8s/1_22"},
+	{prefix: "Flatcar", format: FormatBin, config: "flatcar/k8s/1_22"},
+}
+
+// rke2Config is the configuration directory of the rke2 bundles
+const rke2Config = "rke2/1_22"
+
+// Spec selects the bundle to build and the versions of its ingredients.
+// The versions left empty default to those of the ingredients images of the bundle builder.
+type Spec struct {
+	// KubernetesVersion is the version of the kubernetes components, e.g. v1.23.5, or the
+	// rke2 release, e.g. v1.23.5+rke2r1, of the rke2 bundles
+	KubernetesVersion string
+	// OS is the OS the bundle is built for, as named by the installer without its architecture,
+	// e.g. Ubuntu_20.04.1, RHEL_8, SLES_15, Immutable or Flatcar. The rke2 bundles are built for
+	// any OS.
+	OS string
+	// Arch is the architecture the bundle is built for, x86-64 or arm64
+	Arch string
+	// BundleType is the type of the bundle, defaults to installer.BundleTypeK8s
+	BundleType installer.BundleType
+
+	ContainerdVersion string
+	CNIVersion        string
+	CRIToolsVersion   string
+	ReleaseVersion    string
+
+	// AptRepo is the apt repository of the Debian packages
+	AptRepo string
+	// YumRepo is the yum repository of the RPM packages, defaults to the one of the architecture
+	YumRepo string
+}
+
+// Ingredient is a file the bundle is built from
+type Ingredient struct {
+	// Name is the well-known name the installer expects the ingredient under in the bundle
+	Name string
+	URL  string
+	// SHA256 is the checksum of the downloaded file, when listed by its repository
+	SHA256 string
+	// SHA256URL is the address of the checksum file of the downloaded file, in the format
+	// of sha256sum, or holding the checksum only
+	SHA256URL string
+	// Extract is the file of the downloaded tar.gz archive which is the ingredient
+	Extract string
+	// Gunzip decompresses the downloaded file into the ingredient
+	Gunzip bool
+}
+
+// Recipe is how a bundle is built
+type Recipe struct {
+	Format Format
+	// BundleName is the name of the bundle in the repository, e.g. byoh-bundle-ubuntu_20.04.1_x86-64_k8s
+	BundleName string
+	// Config is the configuration directory of the bundle, relative to the configuration root
+	Config      string
+	Ingredients []Ingredient
+}
+
+// Resolve returns the recipe of the bundle of the spec. The packages of the Debian and RPM bundles
+// are looked up in the indexes of their repositories.
+func Resolve(ctx context.Context, c *http.Client, spec Spec) (*Recipe, error) {
+	spec = withDefaults(spec)
+	if spec.KubernetesVersion == "" {
+		return nil, fmt.Errorf("empty kubernetes version")
+	}
+	goArch, err := goArchOf(spec.Arch)
+	if err != nil {
+		return nil, err
+	}
+
+	if spec.BundleType == installer.BundleTypeRKE2 {
+		return &Recipe{
+			Format:      FormatRKE2,
+			BundleName:  installer.GetRKE2BundleName(spec.Arch),
+			Config:      rke2Config,
+			Ingredients: rke2Ingredients(spec, goArch),
+		}, nil
+	}
+
+	osf, err := formatOf(spec.OS)
+	if err != nil {
+		return nil, err
+	}
+	recipe := &Recipe{
+		Format:     osf.format,
+		BundleName: installer.GetBundleName(spec.OS + "_" + spec.Arch),
+		Config:     osf.config,
+	}
+	k8sVersion := strings.TrimPrefix(spec.KubernetesVersion, "v")
+	switch osf.format {
+	case FormatDeb:
+		recipe.Ingredients, err = debIngredients(ctx, c, spec, k8sVersion, goArch)
+	case FormatRPM:
+		recipe.Ingredients, err = rpmIngredients(ctx, c, spec, k8sVersion, goArch)
+	case FormatBin:
+		recipe.Ingredients = binIngredients(spec, k8sVersion, goArch)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return recipe, nil
+}
+
+// withDefaults returns the spec with the default versions and repositories
+func withDefaults(spec Spec) Spec {
+	defaults := []struct {
+		value        *string
+		defaultValue string
+	}{
+		{&spec.ContainerdVersion, defaultContainerdVersion},
+		{&spec.CNIVersion, defaultCNIVersion},
+		{&spec.CRIToolsVersion, defaultCRIToolsVersion},
+		{&spec.ReleaseVersion, defaultReleaseVersion},
+		{&spec.AptRepo, defaultAptRepo},
+		{&spec.YumRepo, defaultYumRepo + rpmArchOf(spec.Arch)},
+	}
+	for _, d := range defaults {
+		if *d.value == "" {
+			*d.value = d.defaultValue
+		}
+	}
+	if spec.BundleType == "" {
+		spec.BundleType = installer.BundleTypeK8s
+	}
+	return spec
+}
+
+// formatOf returns the format of the bundles of the OS
+func formatOf(bundleOS string) (osFormat, error) {
+	known := []string{}
+	for _, osf := range osFormats {
+		if strings.HasPrefix(bundleOS, osf.prefix) {
+			return osf, nil
+		}
+		known = append(known, osf.prefix)
+	}
+	return osFormat{}, fmt.Errorf("no bundle format for OS %q, the OS must start with one of %s", bundleOS, strings.Join(known, ", "))
+}
+
+// goArchOf returns the architecture of the bundle as named by the Go and kubernetes releases
+func goArchOf(arch string) (string, error) {
+	switch arch {
+	case "x86-64":
+		return "amd64", nil
+	case "arm64":
+		return "arm64", nil
+	}
+	return "", fmt.Errorf("unsupported architecture %q, must be x86-64 or arm64", arch)
+}
+
+// rpmArchOf returns the architecture of the bundle as named by the RPM packages
+func rpmArchOf(arch string) string {
+	if arch == "arm64" {
+		return "aarch64"
+	}
+	return "x86_64"
+}
+
+// containerdIngredient returns the containerd release tarball of the package bundles
+func containerdIngredient(spec Spec, goArch string) Ingredient {
+	url := fmt.Sprintf("https://github.com/containerd/containerd/releases/download/v%s/cri-containerd-cni-%s-linux-%s.tar.gz",
+		spec.ContainerdVersion, spec.ContainerdVersion, goArch)
+	// the installer extracts the tarball, whatever its name
+	return Ingredient{Name: "containerd.tar", URL: url, SHA256URL: url + ".sha256sum"}
+}
+
+// debIngredients returns the ingredients of the Debian bundles, looked up in the apt repository
+func debIngredients(ctx context.Context, c *http.Client, spec Spec, k8sVersion, goArch string) ([]Ingredient, error) {
+	packages := map[string]string{
+		"kubeadm":        k8sVersion + "-00",
+		"kubelet":        k8sVersion + "-00",
+		"kubectl":        k8sVersion + "-00",
+		"cri-tools":      spec.CRIToolsVersion + "-00",
+		"kubernetes-cni": spec.CNIVersion + "-00",
+	}
+	ingredients, err := resolveAptPackages(ctx, c, spec.AptRepo, defaultAptDist, goArch, packages)
+	if err != nil {
+		return nil, err
+	}
+	return append([]Ingredient{containerdIngredient(spec, goArch)}, ingredients...), nil
+}
+
+// rpmIngredients returns the ingredients of the RPM bundles, looked up in the yum repository
+func rpmIngredients(ctx context.Context, c *http.Client, spec Spec, k8sVersion, goArch string) ([]Ingredient, error) {
+	packages := map[string]string{
+		"kubeadm":        k8sVersion + "-0",
+		"kubelet":        k8sVersion + "-0",
+		"kubectl":        k8sVersion + "-0",
+		"cri-tools":      spec.CRIToolsVersion + "-0",
+		"kubernetes-cni": spec.CNIVersion + "-0",
+	}
+	ingredients, err := resolveYumPackages(ctx, c, spec.YumRepo, rpmArchOf(spec.Arch), packages)
+	if err != nil {
+		return nil, err
+	}
+	return append([]Ingredient{containerdIngredient(spec, goArch)}, ingredients...), nil
+}
+
+// binIngredients returns the ingredients of the binary bundles, from the kubernetes releases
+func binIngredients(spec Spec, k8sVersion, goArch string) []Ingredient {
+	ingredients := []Ingredient{}
+	for _, binary := range []string{"kubelet", "kubeadm", "kubectl"} {
+		url := fmt.Sprintf("https://dl.k8s.io/release/v%s/bin/linux/%s/%s", k8sVersion, goArch, binary)
+		ingredients = append(ingredients, Ingredient{Name: binary, URL: url, SHA256URL: url + ".sha256"})
+	}
+	templates := fmt.Sprintf("https://raw.githubusercontent.com/kubernetes/release/v%s/cmd/kubepkg/templates/latest/deb", spec.ReleaseVersion)
+	return append(ingredients,
+		Ingredient{Name: "kubelet.service", URL: templates + "/kubelet/lib/systemd/system/kubelet.service"},
+		Ingredient{Name: "10-kubeadm.conf", URL: templates + "/kubeadm/10-kubeadm.conf"},
+		Ingredient{
+			Name:    "crictl",
+			URL:     fmt.Sprintf("https://github.com/kubernetes-sigs/cri-tools/releases/download/v%s/crictl-v%s-linux-%s.tar.gz", spec.CRIToolsVersion, spec.CRIToolsVersion, goArch),
+			Extract: "crictl",
+		},
+		Ingredient{
+			Name:   "kubernetes-cni.tar",
+			URL:    fmt.Sprintf("https://github.com/containernetworking/plugins/releases/download/v%s/cni-plugins-linux-%s-v%s.tgz", spec.CNIVersion, goArch, spec.CNIVersion),
+			Gunzip: true,
+		})
+}
+
+// rke2Ingredients returns the rke2 release tarball, verified against the checksums of the release
+func rke2Ingredients(spec Spec, goArch string) []Ingredient {
+	release := fmt.Sprintf("https://github.com/rancher/rke2/releases/download/%s", strings.ReplaceAll(spec.KubernetesVersion, "+", "%2B"))
+	return []Ingredient{{
+		Name:      "rke2.tar.gz",
+		URL:       fmt.Sprintf("%s/rke2.linux-%s.tar.gz", release, goArch),
+		SHA256URL: fmt.Sprintf("%s/sha256sum-%s.txt", release, goArch),
+	}}
+}
@@ -0,0 +1,219 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundlebuilder_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/installer"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/installer/bundlebuilder"
+)
+
+const aptPackages = `Package: kubelet
+Version: 1.23.4-00
+Architecture: amd64
+Filename: pool/kubelet-1.23.4-00_amd64.deb
+SHA256: 1111
+
+Package: kubelet
+Version: 1.23.5-00
+Architecture: amd64
+Description: Kubernetes Node Agent
+ The node agent of Kubernetes, the container cluster manager
+Filename: pool/kubelet-1.23.5-00_amd64.deb
+SHA256: 2222
+
+Package: kubeadm
+Version: 1.23.5-00
+Filename: pool/kubeadm-1.23.5-00_amd64.deb
+SHA256: 3333
+
+Package: kubectl
+Version: 1.23.5-00
+Filename: pool/kubectl-1.23.5-00_amd64.deb
+SHA256: 4444
+
+Package: cri-tools
+Version: 1.23.0-00
+Filename: pool/cri-tools-1.23.0-00_amd64.deb
+SHA256: 5555
+
+Package: kubernetes-cni
+Version: 0.8.7-00
+Filename: pool/kubernetes-cni-0.8.7-00_amd64.deb
+SHA256: 6666
+`
+
+const repoMD = `<?xml version="1.0" encoding="UTF-8"?>
+<repomd xmlns="http://linux.duke.edu/metadata/repo">
+  <data type="filelists"><location href="repodata/filelists.xml.gz"/></data>
+  <data type="primary"><location href="repodata/primary.xml.gz"/></data>
+</repomd>`
+
+const primaryMD = `<?xml version="1.0" encoding="UTF-8"?>
+<metadata xmlns="http://linux.duke.edu/metadata/common" packages="6">
+  <package type="rpm">
+    <name>kubelet</name><arch>aarch64</arch><version epoch="0" ver="1.23.5" rel="0"/>
+    <checksum type="sha256" pkgid="YES">aaaa</checksum><location href="../../pool/kubelet-aarch64"/>
+  </package>
+  <package type="rpm">
+    <name>kubelet</name><arch>x86_64</arch><version epoch="0" ver="1.23.5" rel="0"/>
+    <checksum type="sha256" pkgid="YES">bbbb</checksum><location href="../../pool/kubelet-x86_64"/>
+  </package>
+  <package type="rpm">
+    <name>kubeadm</name><arch>x86_64</arch><version epoch="0" ver="1.23.5" rel="0"/>
+    <checksum type="sha256" pkgid="YES">cccc</checksum><location href="../../pool/kubeadm-x86_64"/>
+  </package>
+  <package type="rpm">
+    <name>kubectl</name><arch>x86_64</arch><version epoch="0" ver="1.23.5" rel="0"/>
+    <checksum type="sha256" pkgid="YES">dddd</checksum><location href="../../pool/kubectl-x86_64"/>
+  </package>
+  <package type="rpm">
+    <name>cri-tools</name><arch>x86_64</arch><version epoch="0" ver="1.23.0" rel="0"/>
+    <checksum type="sha256" pkgid="YES">eeee</checksum><location href="../../pool/cri-tools-x86_64"/>
+  </package>
+  <package type="rpm">
+    <name>kubernetes-cni</name><arch>x86_64</arch><version epoch="0" ver="0.8.7" rel="0"/>
+    <checksum type="sha1" pkgid="YES">ffff</checksum><location href="../../pool/kubernetes-cni-x86_64"/>
+  </package>
+</metadata>`
+
+var _ = Describe("Bundle recipes", func() {
+	var (
+		ctx    context.Context
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		ctx = context.TODO()
+		var primary bytes.Buffer
+		gz := gzip.NewWriter(&primary)
+		_, err := gz.Write([]byte(primaryMD))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(gz.Close()).To(Succeed())
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/apt/dists/kubernetes-xenial/main/binary-amd64/Packages", func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(aptPackages))
+		})
+		mux.HandleFunc("/yum/repos/el7/repodata/repomd.xml", func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(repoMD))
+		})
+		mux.HandleFunc("/yum/repos/el7/repodata/primary.xml.gz", func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write(primary.Bytes())
+		})
+		server = httptest.NewServer(mux)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should resolve the Debian packages of the kubernetes version", func() {
+		recipe, err := bundlebuilder.Resolve(ctx, server.Client(), bundlebuilder.Spec{
+			KubernetesVersion: "v1.23.5",
+			OS:                "Ubuntu_20.04.1",
+			Arch:              "x86-64",
+			AptRepo:           server.URL + "/apt",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recipe.Format).To(Equal(bundlebuilder.FormatDeb))
+		Expect(recipe.BundleName).To(Equal("byoh-bundle-ubuntu_20.04.1_x86-64_k8s"))
+		Expect(recipe.Config).To(Equal("ubuntu/20_04/k8s/1_22"))
+		Expect(recipe.Ingredients).To(HaveLen(6))
+		Expect(recipe.Ingredients[0].Name).To(Equal("containerd.tar"))
+		Expect(recipe.Ingredients[0].URL).To(HaveSuffix("/v1.6.0/cri-containerd-cni-1.6.0-linux-amd64.tar.gz"))
+		Expect(recipe.Ingredients).To(ContainElement(bundlebuilder.Ingredient{
+			Name:   "kubelet.deb",
+			URL:    server.URL + "/apt/pool/kubelet-1.23.5-00_amd64.deb",
+			SHA256: "2222",
+		}))
+	})
+
+	It("should fail when a package is missing from the apt repository", func() {
+		_, err := bundlebuilder.Resolve(ctx, server.Client(), bundlebuilder.Spec{
+			KubernetesVersion: "v1.23.5",
+			OS:                "Ubuntu_22.04",
+			Arch:              "x86-64",
+			AptRepo:           server.URL + "/apt",
+			CNIVersion:        "1.1.1",
+		})
+		Expect(err).To(MatchError(ContainSubstring("packages kubernetes-cni=1.1.1-00 not found")))
+	})
+
+	It("should resolve the RPM packages of the architecture", func() {
+		recipe, err := bundlebuilder.Resolve(ctx, server.Client(), bundlebuilder.Spec{
+			KubernetesVersion: "v1.23.5",
+			OS:                "RHEL_8",
+			Arch:              "x86-64",
+			YumRepo:           server.URL + "/yum/repos/el7",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recipe.Format).To(Equal(bundlebuilder.FormatRPM))
+		Expect(recipe.BundleName).To(Equal("byoh-bundle-rhel_8_x86-64_k8s"))
+		Expect(recipe.Ingredients).To(HaveLen(6))
+		Expect(recipe.Ingredients).To(ContainElement(bundlebuilder.Ingredient{
+			Name:   "kubelet.rpm",
+			URL:    server.URL + "/yum/pool/kubelet-x86_64",
+			SHA256: "bbbb",
+		}))
+		// only the sha256 checksums are verified
+		Expect(recipe.Ingredients).To(ContainElement(bundlebuilder.Ingredient{
+			Name: "kubernetes-cni.rpm",
+			URL:  server.URL + "/yum/pool/kubernetes-cni-x86_64",
+		}))
+	})
+
+	It("should fail when a package is missing from the yum repository", func() {
+		_, err := bundlebuilder.Resolve(ctx, server.Client(), bundlebuilder.Spec{
+			KubernetesVersion: "v1.23.5",
+			OS:                "SLES_15",
+			Arch:              "arm64",
+			YumRepo:           server.URL + "/yum/repos/el7",
+		})
+		Expect(err).To(MatchError(ContainSubstring("packages cri-tools=1.23.0-0, kubeadm=1.23.5-0, kubectl=1.23.5-0, kubernetes-cni=0.8.7-0 not found")))
+	})
+
+	It("should resolve the binaries of the immutable hosts from the kubernetes releases", func() {
+		recipe, err := bundlebuilder.Resolve(ctx, server.Client(), bundlebuilder.Spec{
+			KubernetesVersion: "v1.22.9",
+			OS:                "Immutable",
+			Arch:              "arm64",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recipe.Format).To(Equal(bundlebuilder.FormatBin))
+		Expect(recipe.BundleName).To(Equal("byoh-bundle-immutable_arm64_k8s"))
+		Expect(recipe.Ingredients[0]).To(Equal(bundlebuilder.Ingredient{
+			Name:      "kubelet",
+			URL:       "https://dl.k8s.io/release/v1.22.9/bin/linux/arm64/kubelet",
+			SHA256URL: "https://dl.k8s.io/release/v1.22.9/bin/linux/arm64/kubelet.sha256",
+		}))
+	})
+
+	It("should resolve the rke2 release tarball of the architecture", func() {
+		recipe, err := bundlebuilder.Resolve(ctx, server.Client(), bundlebuilder.Spec{
+			KubernetesVersion: "v1.23.5+rke2r1",
+			Arch:              "x86-64",
+			BundleType:        installer.BundleTypeRKE2,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recipe.BundleName).To(Equal("byoh-bundle-x86-64_rke2"))
+		Expect(recipe.Ingredients).To(Equal([]bundlebuilder.Ingredient{{
+			Name:      "rke2.tar.gz",
+			URL:       "https://github.com/rancher/rke2/releases/download/v1.23.5%2Brke2r1/rke2.linux-amd64.tar.gz",
+			SHA256URL: "https://github.com/rancher/rke2/releases/download/v1.23.5%2Brke2r1/sha256sum-amd64.txt",
+		}}))
+	})
+
+	It("should fail for an OS without a bundle format", func() {
+		_, err := bundlebuilder.Resolve(ctx, server.Client(), bundlebuilder.Spec{KubernetesVersion: "v1.23.5", OS: "Windows_2019", Arch: "x86-64"})
+		Expect(err).To(MatchError(ContainSubstring(`no bundle format for OS "Windows_2019"`)))
+	})
+})
@@ -0,0 +1,221 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundlebuilder
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// resolveAptPackages looks the packages, by name and version, up in the Packages index of the
+// apt repository and returns them as ingredients named <name>.deb
+func resolveAptPackages(ctx context.Context, c *http.Client, repo, dist, debArch string, packages map[string]string) ([]Ingredient, error) {
+	indexURL := fmt.Sprintf("%s/dists/%s/main/binary-%s/Packages", strings.TrimSuffix(repo, "/"), dist, debArch)
+	body, err := get(ctx, c, indexURL)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	found := map[string]Ingredient{}
+	stanza := map[string]string{}
+	match := func() error {
+		name := stanza["Package"]
+		if version, ok := packages[name]; ok && stanza["Version"] == version {
+			location, err := resolveURL(repo, stanza["Filename"])
+			if err != nil {
+				return err
+			}
+			found[name] = Ingredient{Name: name + ".deb", URL: location, SHA256: stanza["SHA256"]}
+		}
+		stanza = map[string]string{}
+		return nil
+	}
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if err := match(); err != nil {
+				return nil, err
+			}
+		case !strings.HasPrefix(line, " "):
+			// the multi-line fields, e.g. the description, are continued by indented lines
+			if key, value, ok := cut(line, ": "); ok {
+				stanza[key] = value
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := match(); err != nil {
+		return nil, err
+	}
+	return foundPackages(found, packages, indexURL)
+}
+
+// repoMD is the index of the metadata of a yum repository
+type repoMD struct {
+	Data []struct {
+		Type     string `xml:"type,attr"`
+		Location struct {
+			Href string `xml:"href,attr"`
+		} `xml:"location"`
+	} `xml:"data"`
+}
+
+// primaryMD is the primary metadata of a yum repository, listing its packages
+type primaryMD struct {
+	Packages []struct {
+		Name    string `xml:"name"`
+		Arch    string `xml:"arch"`
+		Version struct {
+			Ver string `xml:"ver,attr"`
+			Rel string `xml:"rel,attr"`
+		} `xml:"version"`
+		Checksum struct {
+			Type  string `xml:"type,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"checksum"`
+		Location struct {
+			Href string `xml:"href,attr"`
+		} `xml:"location"`
+	} `xml:"package"`
+}
+
+// resolveYumPackages looks the packages, by name and version-release, up in the primary metadata
+// of the yum repository and returns them as ingredients named <name>.rpm
+func resolveYumPackages(ctx context.Context, c *http.Client, repo, rpmArch string, packages map[string]string) ([]Ingredient, error) {
+	repoMDURL := strings.TrimSuffix(repo, "/") + "/repodata/repomd.xml"
+	index := &repoMD{}
+	if err := getXML(ctx, c, repoMDURL, false, index); err != nil {
+		return nil, err
+	}
+	primaryURL := ""
+	for _, data := range index.Data {
+		if data.Type == "primary" {
+			location, err := resolveURL(repo, data.Location.Href)
+			if err != nil {
+				return nil, err
+			}
+			primaryURL = location
+		}
+	}
+	if primaryURL == "" {
+		return nil, fmt.Errorf("no primary metadata in %s", repoMDURL)
+	}
+	primary := &primaryMD{}
+	if err := getXML(ctx, c, primaryURL, strings.HasSuffix(primaryURL, ".gz"), primary); err != nil {
+		return nil, err
+	}
+
+	found := map[string]Ingredient{}
+	for _, pkg := range primary.Packages {
+		version, ok := packages[pkg.Name]
+		if !ok || pkg.Arch != rpmArch || pkg.Version.Ver+"-"+pkg.Version.Rel != version {
+			continue
+		}
+		location, err := resolveURL(repo, pkg.Location.Href)
+		if err != nil {
+			return nil, err
+		}
+		ingredient := Ingredient{Name: pkg.Name + ".rpm", URL: location}
+		if pkg.Checksum.Type == "sha256" {
+			ingredient.SHA256 = strings.TrimSpace(pkg.Checksum.Value)
+		}
+		found[pkg.Name] = ingredient
+	}
+	return foundPackages(found, packages, primaryURL)
+}
+
+// foundPackages returns the ingredients of the packages, sorted by name, or an error listing
+// the packages missing from the index
+func foundPackages(found map[string]Ingredient, packages map[string]string, indexURL string) ([]Ingredient, error) {
+	missing := []string{}
+	ingredients := []Ingredient{}
+	for name, version := range packages {
+		ingredient, ok := found[name]
+		if !ok {
+			missing = append(missing, name+"="+version)
+			continue
+		}
+		ingredients = append(ingredients, ingredient)
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("packages %s not found in %s", strings.Join(missing, ", "), indexURL)
+	}
+	sort.Slice(ingredients, func(i, j int) bool { return ingredients[i].Name < ingredients[j].Name })
+	return ingredients, nil
+}
+
+// getXML decodes the XML document at the address, optionally gzip compressed
+func getXML(ctx context.Context, c *http.Client, location string, gzipped bool, v interface{}) error {
+	body, err := get(ctx, c, location)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	var r io.Reader = body
+	if gzipped {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	if err := xml.NewDecoder(r).Decode(v); err != nil {
+		return fmt.Errorf("decoding %s: %w", location, err)
+	}
+	return nil
+}
+
+// get requests the address and returns the body of the response, failing on a status other than 200
+func get(ctx context.Context, c *http.Client, location string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", location, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// resolveURL resolves the location, relative to the repository, into an absolute address
+func resolveURL(repo, location string) (string, error) {
+	base, err := url.Parse(strings.TrimSuffix(repo, "/") + "/")
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(location)
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+// cut slices s around the first instance of sep
+func cut(s, sep string) (before, after string, found bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
@@ -4,12 +4,21 @@
 package installer
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/installer/internal/algo"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/metrics"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/privilege"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/common/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Error string wrapper for errors returned by the installer
@@ -24,6 +33,8 @@ const (
 	ErrOsK8sNotSupported = Error("No k8s support for OS")
 	// ErrBundleDownload error type when the bundle download fails
 	ErrBundleDownload = Error("Error downloading bundle")
+	// ErrBundleChecksum error type when a downloaded bundle layer does not match its digest
+	ErrBundleChecksum = Error("Bundle checksum mismatch")
 	// ErrBundleExtract error type when the bundle extraction fails
 	ErrBundleExtract = Error("Error extracting bundle")
 	// ErrBundleInstall error type when the bundle installation fails
@@ -32,21 +43,72 @@ const (
 	ErrBundleUninstall = Error("Error uninstalling bundle")
 )
 
+// InstallMode selects how the kubernetes components are installed
+type InstallMode string
+
+const (
+	// InstallModePackage installs the kubernetes components with the package manager of the OS
+	InstallModePackage InstallMode = "package"
+	// InstallModeContainerd pulls the bundle with containerd and installs the plain binaries of
+	// the kubernetes components, for the immutable hosts without a package manager
+	InstallModeContainerd InstallMode = "containerd"
+)
+
+const (
+	// immutableOSBundle is the prefix of the bundles installed in the InstallModeContainerd
+	immutableOSBundle = "Immutable_"
+	// flatcarOSBundle is the prefix of the Flatcar Container Linux bundles
+	flatcarOSBundle = "Flatcar_"
+)
+
+// rpmOSBundles are the prefixes of the bundles of RPM packages, whose kubelet service reads its
+// environment from /etc/sysconfig/kubelet rather than /etc/default/kubelet
+var rpmOSBundles = []string{"RHEL_8_", "SLES_15_"}
+
+// containerdOSBundles are the prefixes of the bundles pulled with containerd, for the hosts without a package manager
+var containerdOSBundles = []string{immutableOSBundle, flatcarOSBundle}
+
 // BundleType is used to support various bundles
 type BundleType string
 
 const (
 	// BundleTypeK8s represents a vanilla k8s bundle
 	BundleTypeK8s BundleType = "k8s"
+	// BundleTypeRKE2 represents a bundle of the rke2 release, for any OS of an architecture
+	BundleTypeRKE2 BundleType = "rke2"
 )
 
 var preRequisitePackages = []string{"socat", "ebtables", "ethtool", "conntrack"}
 
+// supportedArchs are the architectures, as reported by hostnamectl, with a bundle for every supported OS.
+// The architecture is part of the bundle name.
+var supportedArchs = []string{"x86-64", "arm64"}
+
+// ProxyConfig is the proxy configuration propagated to containerd and kubelet
+type ProxyConfig = algo.ProxyConfig
+
+// InstallPhase is a phase of the installation of the k8s components, see SetProgressFunc
+type InstallPhase string
+
+const (
+	// InstallPhaseDownload is the download of the bundle of the k8s components
+	InstallPhaseDownload InstallPhase = "Download"
+	// InstallPhaseInstall is the installation of the k8s components of the downloaded bundle
+	InstallPhaseInstall InstallPhase = "Install"
+)
+
 type installer struct {
 	algoRegistry registry
 	bundleDownloader
-	detectedOs string
-	logger     logr.Logger
+	detectedOs  string
+	installMode InstallMode
+	proxy       ProxyConfig
+	auditor     commandAuditor
+	escalator   privilege.Escalator
+	reboot      rebootChecker
+	traceParent trace.SpanContext
+	progress    func(InstallPhase)
+	logger      logr.Logger
 }
 
 // GetSupportedRegistry returns a registry with installers for the supported OS and K8s
@@ -62,39 +124,135 @@ func GetSupportedRegistry(ob algo.OutputBuilder) registry {
 		reg.AddBundleInstaller(osBundle, k8sBundle, &a)
 	}
 
-	{
-		// Ubuntu
-
-		// BYOH Bundle Repository. Associate bundle with installer
-		linuxDistro := "Ubuntu_20.04.1_x86-64"
-		addBundleInstaller(linuxDistro, "v1.21.*", &algo.Ubuntu20_4K8s1_22{})
-		addBundleInstaller(linuxDistro, "v1.22.*", &algo.Ubuntu20_4K8s1_22{})
-		addBundleInstaller(linuxDistro, "v1.23.*", &algo.Ubuntu20_4K8s1_22{})
+	// Match any patch version of the specified Major & Minor K8s version
+	reg.AddK8sFilter("v1.21.*")
+	reg.AddK8sFilter("v1.22.*")
+	reg.AddK8sFilter("v1.23.*")
+
+	for _, arch := range supportedArchs {
+		{
+			// Ubuntu
+
+			// BYOH Bundle Repository. Associate bundle with installer
+			linuxDistro := "Ubuntu_20.04.1_" + arch
+			addBundleInstaller(linuxDistro, "v1.21.*", &algo.Ubuntu20_4K8s1_22{})
+			addBundleInstaller(linuxDistro, "v1.22.*", &algo.Ubuntu20_4K8s1_22{})
+			addBundleInstaller(linuxDistro, "v1.23.*", &algo.Ubuntu20_4K8s1_22{})
+
+			/*
+			 * PLACEHOLDER - ADD MORE K8S VERSIONS HERE
+			 */
+
+			// Match concrete os version to repository os version
+			reg.AddOsFilter("Ubuntu_20.04.*_"+arch, linuxDistro)
+
+			/*
+			 * PLACEHOLDER - POINT MORE DISTRO VERSIONS
+			 */
+		}
+
+		{
+			// RHEL and its rebuilds
+
+			// BYOH Bundle Repository. Associate bundle with installer
+			linuxDistro := "RHEL_8_" + arch
+			addBundleInstaller(linuxDistro, "v1.21.*", &algo.Rhel8K8s1_22{})
+			addBundleInstaller(linuxDistro, "v1.22.*", &algo.Rhel8K8s1_22{})
+			addBundleInstaller(linuxDistro, "v1.23.*", &algo.Rhel8K8s1_22{})
+
+			// Match concrete os version to repository os version
+			reg.AddOsFilter("RHEL_8.*_"+arch, linuxDistro)
+			reg.AddOsFilter("Red_Hat_Enterprise_Linux_8.*_"+arch, linuxDistro)
+			reg.AddOsFilter("Rocky_Linux_8.*_"+arch, linuxDistro)
+			reg.AddOsFilter("AlmaLinux_8.*_"+arch, linuxDistro)
+		}
+
+		{
+			// SUSE
+
+			// BYOH Bundle Repository. Associate bundle with installer
+			linuxDistro := "SLES_15_" + arch
+			addBundleInstaller(linuxDistro, "v1.21.*", &algo.Suse15K8s1_22{})
+			addBundleInstaller(linuxDistro, "v1.22.*", &algo.Suse15K8s1_22{})
+			addBundleInstaller(linuxDistro, "v1.23.*", &algo.Suse15K8s1_22{})
+
+			// Match concrete os version to repository os version
+			reg.AddOsFilter("SLES_15.*_"+arch, linuxDistro)
+			reg.AddOsFilter("SUSE_Linux_Enterprise_Server_15.*_"+arch, linuxDistro)
+			reg.AddOsFilter("openSUSE_Leap_15.*_"+arch, linuxDistro)
+			// Transactional variants
+			reg.AddOsFilter("SUSE_Linux_Enterprise_Micro_5.*_"+arch, linuxDistro)
+			reg.AddOsFilter("openSUSE_Leap_Micro_5.*_"+arch, linuxDistro)
+			reg.AddOsFilter("openSUSE_MicroOS_rolling_"+arch, linuxDistro)
+		}
+
+		{
+			// Immutable hosts, without a package manager
+
+			// BYOH Bundle Repository. Associate bundle with installer
+			linuxDistro := immutableOSBundle + arch
+			addBundleInstaller(linuxDistro, "v1.21.*", &algo.ImmutableK8s1_22{})
+			addBundleInstaller(linuxDistro, "v1.22.*", &algo.ImmutableK8s1_22{})
+			addBundleInstaller(linuxDistro, "v1.23.*", &algo.ImmutableK8s1_22{})
+
+			// Match concrete os version to repository os version
+			reg.AddOsFilter(immutableOSBundle+arch, linuxDistro)
+			reg.AddOsFilter("Fedora_CoreOS_.*_"+arch, linuxDistro)
+		}
+
+		{
+			// Flatcar Container Linux
+
+			// BYOH Bundle Repository. Associate bundle with installer
+			linuxDistro := flatcarOSBundle + arch
+			addBundleInstaller(linuxDistro, "v1.21.*", &algo.FlatcarK8s1_22{})
+			addBundleInstaller(linuxDistro, "v1.22.*", &algo.FlatcarK8s1_22{})
+			addBundleInstaller(linuxDistro, "v1.23.*", &algo.FlatcarK8s1_22{})
+
+			// Match concrete os version to repository os version
+			reg.AddOsFilter(flatcarOSBundle+arch, linuxDistro)
+			reg.AddOsFilter("Flatcar_Container_Linux_.*_"+arch, linuxDistro)
+		}
 
 		/*
-		 * PLACEHOLDER - ADD MORE K8S VERSIONS HERE
+		 * PLACEHOLDER - ADD MORE OS HERE
 		 */
+	}
 
-		// Match any patch version of the specified Major & Minor K8s version
-		reg.AddK8sFilter("v1.21.*")
-		reg.AddK8sFilter("v1.22.*")
-		reg.AddK8sFilter("v1.23.*")
+	return reg
+}
 
-		// Match concrete os version to repository os version
-		reg.AddOsFilter("Ubuntu_20.04.*_x86-64", linuxDistro)
+// getRKE2Registry returns a registry with the rke2 installer for any OS of the supported architectures
+func getRKE2Registry(ob algo.OutputBuilder) registry {
+	reg := newRegistry()
 
-		/*
-		 * PLACEHOLDER - POINT MORE DISTRO VERSIONS
-		 */
+	// Match any patch version, and any rke2 release, of the specified Major & Minor K8s version
+	reg.AddK8sFilter("v1.21.*")
+	reg.AddK8sFilter("v1.22.*")
+	reg.AddK8sFilter("v1.23.*")
+
+	for _, arch := range supportedArchs {
+		// the rke2 bundles are named after the architecture only
+		osBundle := arch
+		for _, k8s := range []string{"v1.21.*", "v1.22.*", "v1.23.*"} {
+			reg.AddBundleInstaller(osBundle, k8s, &algo.BaseK8sInstaller{
+				K8sStepProvider: &algo.RKE2{},
+				OutputBuilder:   ob})
+		}
+		reg.AddOsFilter(".*_"+arch+"$", osBundle)
 	}
 
-	/*
-	 * PLACEHOLDER - ADD MORE OS HERE
-	 */
-
 	return reg
 }
 
+// getRegistry returns the registry of the installers of the bundle type
+func getRegistry(bundleType BundleType, ob algo.OutputBuilder) registry {
+	if bundleType == BundleTypeRKE2 {
+		return getRKE2Registry(ob)
+	}
+	return GetSupportedRegistry(ob)
+}
+
 func (bd *bundleDownloader) getBundlePathDirOrPreview(k8s, tag string) string {
 	if bd == nil || bd.downloadPath == "" {
 		return ""
@@ -113,6 +271,16 @@ func (bd *bundleDownloader) DownloadOrPreview(os, k8s, tag string) error {
 	return bd.Download(os, k8s, tag)
 }
 
+// PullOrPreview pulls the bundle with containerd if bundleDownloader is configured with a download path else runs in preview mode without pulling
+func (bd *bundleDownloader) PullOrPreview(os, k8s, tag string) error {
+	if bd == nil || bd.downloadPath == "" {
+		bd.logger.Info("Running in preview mode, skip bundle pull")
+		return nil
+	}
+
+	return bd.DownloadFromRepo(os, k8s, tag, bd.downloadByContainerd)
+}
+
 // New returns an installer that downloads bundles for the current OS from OCI repository with
 // address bundleRepo and stores them under downloadPath. Download path is created,
 // if it does not exist.
@@ -142,7 +310,7 @@ func New(downloadPath string, bundleType BundleType, logger logr.Logger) (*insta
 func newUnchecked(currentOs string, bundleType BundleType, downloadPath string, logger logr.Logger, outputBuilder algo.OutputBuilder) (*installer, error) {
 	bd := NewBundleDownloader(bundleType, "", downloadPath, logger)
 
-	reg := GetSupportedRegistry(outputBuilder)
+	reg := getRegistry(bundleType, outputBuilder)
 	if len(reg.ListK8s(currentOs)) == 0 {
 		return nil, ErrOsK8sNotSupported
 	}
@@ -151,6 +319,7 @@ func newUnchecked(currentOs string, bundleType BundleType, downloadPath string,
 		algoRegistry:     reg,
 		bundleDownloader: *bd,
 		detectedOs:       currentOs,
+		auditor:          commandAuditor{logger: logger},
 		logger:           logger}, nil
 }
 
@@ -159,18 +328,101 @@ func (i *installer) setBundleRepo(bundleRepo string) {
 	i.bundleDownloader.repoAddr = bundleRepo
 }
 
-// Install installs the specified k8s version on the current OS
+// SetProxy sets the proxy configuration written to the containerd and kubelet services.
+// The bundles are downloaded through the proxy set in the environment of the agent, and
+// pulled with containerd through this one.
+func (i *installer) SetProxy(proxy ProxyConfig) {
+	i.proxy = proxy
+	i.bundleDownloader.proxy = proxy
+}
+
+// SetDownloadRateLimit throttles the downloads of the bundles to bytesPerSecond, so that the hosts
+// enrolled at once do not saturate the uplink of their site. The bundles pulled with containerd
+// in the InstallModeContainerd are not throttled. Zero leaves the downloads unthrottled.
+func (i *installer) SetDownloadRateLimit(bytesPerSecond int64) {
+	i.bundleDownloader.rateLimiter = newRateLimiter(bytesPerSecond)
+}
+
+// SetInstallMode sets how the kubernetes components are installed. In the InstallModeContainerd
+// the current OS is handled as an immutable host, whatever its package manager, unless its
+// bundle is already pulled with containerd.
+func (i *installer) SetInstallMode(mode InstallMode) {
+	i.installMode = mode
+}
+
+// SetStagedBundlePath sets the path the bundles are pre-staged under, see Stage. A staged bundle
+// is copied to the download path once its checksums are verified, instead of being downloaded.
+func (i *installer) SetStagedBundlePath(path string) {
+	i.bundleDownloader.stagedPath = path
+}
+
+// SetEventFunc sets the func called on the installer lifecycle transitions,
+// e.g. to record them as events on the ByoHost.
+func (i *installer) SetEventFunc(eventFunc func(eventType, reason, message string)) {
+	i.bundleDownloader.eventFunc = eventFunc
+}
+
+// SetProgressFunc sets the func called when the installation of the k8s components enters a phase,
+// e.g. to report its progress in the ByoHost status.
+func (i *installer) SetProgressFunc(progressFunc func(InstallPhase)) {
+	i.progress = progressFunc
+}
+
+// SetTraceContext sets the trace the downloads and the installations of the bundles are traced in,
+// as children of the span of ctx, e.g. of the reconcile of the ByoHost.
+func (i *installer) SetTraceContext(ctx context.Context) {
+	i.traceParent = trace.SpanContextFromContext(ctx)
+}
+
+// SetEscalator sets how the installer steps and the containerd commands run as root,
+// e.g. through sudo for an agent running as a dedicated user.
+func (i *installer) SetEscalator(escalator privilege.Escalator) {
+	i.escalator = escalator
+}
+
+// SetAuditLog sets the path of the local audit log the commands run by the installer are
+// appended to, as JSON lines. Empty disables the audit log.
+func (i *installer) SetAuditLog(path string) {
+	i.auditor.path = path
+}
+
+// SetAuditFunc sets the func called with the record of each command run by the installer,
+// e.g. to summarize them in the ByoHost status.
+func (i *installer) SetAuditFunc(auditFunc func(AuditRecord)) {
+	i.auditor.auditFunc = auditFunc
+}
+
+// Install installs the specified k8s version on the current OS. It returns a RebootRequiredError
+// when the host has to reboot before the components can run.
 func (i *installer) Install(bundleRepo, k8sVer, tag string) error {
 	i.setBundleRepo(bundleRepo)
+	i.reportProgress(InstallPhaseDownload)
 	algoInst, err := i.getAlgoInstallerWithBundle(k8sVer, tag)
 	if err != nil {
 		return err
 	}
+	i.reportProgress(InstallPhaseInstall)
+	start := time.Now()
+	_, span := tracing.Start(i.traceContext(), "InstallBundle", attribute.String("k8sVersion", k8sVer))
 	err = algoInst.(algo.Installer).Install()
+	tracing.End(span, err)
 	if err != nil {
 		return ErrBundleInstall
 	}
+	metrics.ObservePhase(metrics.PhaseInstall, start)
 
+	// nothing is installed in preview mode
+	if i.bundleDownloader.downloadPath == "" {
+		return nil
+	}
+	reason, err := i.reboot.check()
+	if err != nil {
+		return err
+	}
+	if reason != "" {
+		i.logger.Info("Host reboot required", "reason", reason)
+		return &RebootRequiredError{Reason: reason}
+	}
 	return nil
 }
 
@@ -181,21 +433,64 @@ func (i *installer) Uninstall(bundleRepo, k8sVer, tag string) error {
 	if err != nil {
 		return err
 	}
+	start := time.Now()
 	err = algoInst.(algo.Installer).Uninstall()
 	if err != nil {
 		return ErrBundleUninstall
 	}
+	metrics.ObservePhase(metrics.PhaseUninstall, start)
 
 	return nil
 }
 
-// getAlgoInstallerWithBundle returns an algo.Installer instance and downloads its bundle
-func (i *installer) getAlgoInstallerWithBundle(k8sVer, tag string) (osk8sInstaller, error) {
+// RemoveBundles removes the bundles of the type of the installer downloaded under the download
+// path from any repository, e.g. once the k8s components are uninstalled for good. The bundles
+// pre-staged under the staged bundle path are kept.
+func (i *installer) RemoveBundles() error {
+	bundleDirs, err := filepath.Glob(filepath.Join(i.bundleDownloader.downloadPath, "*", string(i.bundleDownloader.bundleType)+"-*"))
+	if err != nil {
+		return err
+	}
+	for _, bundleDir := range bundleDirs {
+		i.logger.Info("Removing bundle", "path", bundleDir)
+		// the bundles pulled with containerd are copied as root
+		if err := i.escalator.RemoveAll(bundleDir); err != nil {
+			return err
+		}
+		// the directory of the repository is removed along with its last bundle
+		_ = os.Remove(filepath.Dir(bundleDir))
+	}
+	return nil
+}
+
+// Preview resolves the bundle of the k8s version for the current OS and returns its address,
+// along with the install steps which would be run with it. Nothing is downloaded nor run.
+func (i *installer) Preview(bundleRepo, k8sVer, tag string) (bundleAddr, steps string, err error) {
+	i.setBundleRepo(bundleRepo)
+	algoInstCopy, osBundle, err := i.resolveAlgoInstaller(k8sVer, tag)
+	if err != nil {
+		return "", "", err
+	}
+	stepPrinter := stringPrinter{msgFmt: "# %s"}
+	algoInstCopy.OutputBuilder = &stepPrinter
+	algoInstCopy.DryRun = true
+	if err = algoInstCopy.Install(); err != nil {
+		return "", "", err
+	}
+	return i.bundleDownloader.GetBundleAddr(osBundle, k8sVer, tag), stepPrinter.String(), nil
+}
+
+// resolveAlgoInstaller returns a copy of the algo.Installer of the k8s version for the current OS,
+// configured with the bundle path and the settings of the installer, and the name of its OS bundle
+func (i *installer) resolveAlgoInstaller(k8sVer, tag string) (*algo.BaseK8sInstaller, string, error) {
 	// This OS supports at least 1 k8s version. See New.
 
 	algoInst, osBundle := i.algoRegistry.GetInstaller(i.detectedOs, k8sVer)
+	if i.installMode == InstallModeContainerd && !isContainerdOSBundle(osBundle) {
+		algoInst, osBundle = i.algoRegistry.GetInstaller(immutableOSBundle+osArch(i.detectedOs), k8sVer)
+	}
 	if algoInst == nil {
-		return nil, ErrOsK8sNotSupported
+		return nil, "", ErrOsK8sNotSupported
 	}
 	i.logger.Info("Current OS will be handled as", "OS", osBundle)
 
@@ -203,13 +498,76 @@ func (i *installer) getAlgoInstallerWithBundle(k8sVer, tag string) (osk8sInstall
 	// empty means preview mode
 	algoInstCopy := *algoInst.(*algo.BaseK8sInstaller)
 	algoInstCopy.BundlePath = i.bundleDownloader.getBundlePathDirOrPreview(k8sVer, tag)
+	algoInstCopy.Proxy = i.proxy
+	algoInstCopy.Auditor = &i.auditor
+	algoInstCopy.Escalator = i.escalator
+	return &algoInstCopy, osBundle, nil
+}
 
-	bdErr := i.bundleDownloader.DownloadOrPreview(osBundle, k8sVer, tag)
+// getAlgoInstallerWithBundle returns an algo.Installer instance and downloads its bundle
+func (i *installer) getAlgoInstallerWithBundle(k8sVer, tag string) (osk8sInstaller, error) {
+	algoInstCopy, osBundle, err := i.resolveAlgoInstaller(k8sVer, tag)
+	if err != nil {
+		return nil, err
+	}
+	i.bundleDownloader.auditor = &i.auditor
+	i.bundleDownloader.escalator = i.escalator
+	var bdErr error
+	_, span := tracing.Start(i.traceContext(), "DownloadBundle", attribute.String("os", osBundle), attribute.String("k8sVersion", k8sVer),
+		attribute.String("bundle", i.bundleDownloader.GetBundleAddr(osBundle, k8sVer, tag)))
+	if isContainerdOSBundle(osBundle) {
+		// immutable hosts have no package manager but containerd to pull the bundle
+		bdErr = i.bundleDownloader.PullOrPreview(osBundle, k8sVer, tag)
+	} else {
+		bdErr = i.bundleDownloader.DownloadOrPreview(osBundle, k8sVer, tag)
+	}
+	tracing.End(span, bdErr)
 	if bdErr != nil {
 		return nil, bdErr
 	}
 
-	return &algoInstCopy, nil
+	return algoInstCopy, nil
+}
+
+// KubeletEnvironmentFile returns the environment file the kubeadm drop-in of the kubelet service reads the
+// KUBELET_EXTRA_ARGS from, on the current OS
+func (i *installer) KubeletEnvironmentFile() string {
+	osBundle := i.algoRegistry.resolveOsToOsBundle(i.detectedOs)
+	if i.installMode != InstallModeContainerd {
+		for _, prefix := range rpmOSBundles {
+			if strings.HasPrefix(osBundle, prefix) {
+				return "/etc/sysconfig/kubelet"
+			}
+		}
+	}
+	return "/etc/default/kubelet"
+}
+
+// reportProgress reports the phase the installation enters, if a progress func is set
+func (i *installer) reportProgress(phase InstallPhase) {
+	if i.progress != nil {
+		i.progress(phase)
+	}
+}
+
+// traceContext returns the context of the spans of the installer, see SetTraceContext
+func (i *installer) traceContext() context.Context {
+	return trace.ContextWithSpanContext(context.Background(), i.traceParent)
+}
+
+// isContainerdOSBundle returns true if the bundle is pulled with containerd
+func isContainerdOSBundle(osBundle string) bool {
+	for _, prefix := range containerdOSBundles {
+		if strings.HasPrefix(osBundle, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// osArch returns the architecture of the normalized OS, i.e. its last part
+func osArch(normalizedOs string) string {
+	return normalizedOs[strings.LastIndex(normalizedOs, "_")+1:]
 }
 
 // ListSupportedOS returns the list of all supported OS-es. Can be invoked on a non-supported OS.
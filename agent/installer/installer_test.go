@@ -5,10 +5,18 @@
 package installer
 
 import (
+	"context"
+	"os"
+	"path/filepath"
+
 	"github.com/go-logr/logr"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/installer/internal/algo"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var _ = Describe("Byohost Installer Tests", func() {
@@ -67,7 +75,7 @@ var _ = Describe("Byohost Installer Tests", func() {
 						i := NewPreviewInstaller(os, &ob)
 						err := i.Install("", k8s, testTag)
 						Expect(err).ShouldNot(HaveOccurred())
-						Expect(ob.LogCalledCnt).Should(Equal(22))
+						Expect(ob.LogCalledCnt).Should(Equal(24))
 					}
 
 					{
@@ -75,12 +83,61 @@ var _ = Describe("Byohost Installer Tests", func() {
 						i := NewPreviewInstaller(os, &ob)
 						err := i.Uninstall("", k8s, testTag)
 						Expect(err).ShouldNot(HaveOccurred())
-						Expect(ob.LogCalledCnt).Should(Equal(22))
+						Expect(ob.LogCalledCnt).Should(Equal(24))
 					}
 				}
 			}
 		})
 	})
+	Context("When installer is given a trace context", func() {
+		It("Should trace the download and the installation of the bundle in the trace", func() {
+			recorder := tracetest.NewSpanRecorder()
+			otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+			defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+			ctx, parent := otel.Tracer("test").Start(context.Background(), "ByoHost.Bootstrap")
+			defer parent.End()
+
+			_, osList := ListSupportedOS()
+			i := NewPreviewInstaller(osList[0], &algo.OutputBuilderCounter{})
+			i.SetTraceContext(ctx)
+			Expect(i.Install("", ListSupportedK8s(osList[0])[0], testTag)).To(Succeed())
+
+			spans := recorder.Ended()
+			Expect(spans).To(HaveLen(2))
+			Expect(spans[0].Name()).To(Equal("DownloadBundle"))
+			Expect(spans[1].Name()).To(Equal("InstallBundle"))
+			for _, span := range spans {
+				Expect(span.Parent().SpanID()).To(Equal(parent.SpanContext().SpanID()))
+			}
+		})
+	})
+	Context("When installer is given a progress func", func() {
+		It("Should report the download and the installation phases", func() {
+			var phases []InstallPhase
+			_, osList := ListSupportedOS()
+			i := NewPreviewInstaller(osList[0], &algo.OutputBuilderCounter{})
+			i.SetProgressFunc(func(phase InstallPhase) {
+				phases = append(phases, phase)
+			})
+			Expect(i.Install("", ListSupportedK8s(osList[0])[0], testTag)).To(Succeed())
+			Expect(phases).To(Equal([]InstallPhase{InstallPhaseDownload, InstallPhaseInstall}))
+		})
+	})
+	Context("When installer is created with a proxy", func() {
+		It("Install/uninstall should also write the proxy configuration", func() {
+			_, osList := ListSupportedOS()
+			for _, os := range osList {
+				for _, k8s := range ListSupportedK8s(os) {
+					ob := algo.OutputBuilderCounter{}
+					i := NewPreviewInstaller(os, &ob)
+					i.SetProxy(ProxyConfig{HTTPSProxy: "http://proxy:3128"})
+					err := i.Install("", k8s, testTag)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(ob.LogCalledCnt).Should(Equal(26))
+				}
+			}
+		})
+	})
 	Context("When ListSupportedOS is called", func() {
 		It("Should return non-empty result", func() {
 			_, osList := ListSupportedOS()
@@ -98,6 +155,19 @@ var _ = Describe("Byohost Installer Tests", func() {
 	Context("When ListSupportedK8s is called for supported host OS", func() {
 		It("Should return non-empty result", func() {
 			Expect(ListSupportedK8s("Ubuntu_20.04.3_x86-64")).ShouldNot(BeEmpty())
+			Expect(ListSupportedK8s("Red_Hat_Enterprise_Linux_8.6_x86-64")).ShouldNot(BeEmpty())
+			Expect(ListSupportedK8s("Rocky_Linux_8.6_x86-64")).ShouldNot(BeEmpty())
+			Expect(ListSupportedK8s("AlmaLinux_8.6_x86-64")).ShouldNot(BeEmpty())
+			Expect(ListSupportedK8s("SUSE_Linux_Enterprise_Server_15_x86-64")).ShouldNot(BeEmpty())
+			Expect(ListSupportedK8s("openSUSE_Leap_15.4_x86-64")).ShouldNot(BeEmpty())
+			Expect(ListSupportedK8s("openSUSE_Leap_Micro_5.2_x86-64")).ShouldNot(BeEmpty())
+			Expect(ListSupportedK8s("openSUSE_MicroOS_rolling_x86-64")).ShouldNot(BeEmpty())
+			Expect(ListSupportedK8s("Ubuntu_20.04.3_arm64")).ShouldNot(BeEmpty())
+			Expect(ListSupportedK8s("Rocky_Linux_8.6_arm64")).ShouldNot(BeEmpty())
+			Expect(ListSupportedK8s("openSUSE_Leap_15.4_arm64")).ShouldNot(BeEmpty())
+			Expect(ListSupportedK8s("Flatcar_Container_Linux_by_Kinvolk_3139.2.0_x86-64")).ShouldNot(BeEmpty())
+			Expect(ListSupportedK8s("Flatcar_Container_Linux_by_Kinvolk_3227.2.1_arm64")).ShouldNot(BeEmpty())
+			Expect(ListSupportedK8s("Fedora_CoreOS_36.20220618.3.1_arm64")).ShouldNot(BeEmpty())
 		})
 	})
 	Context("When PreviewChanges is called for all supported os and k8s", func() {
@@ -133,10 +203,120 @@ var _ = Describe("Byohost Installer Tests", func() {
 			Expect(err).Should(Equal(ErrOsK8sNotSupported))
 		})
 	})
+	Context("When installer is set to the containerd install mode", func() {
+		It("Should handle the current OS as an immutable host", func() {
+			stepPreviewer := stringPrinter{}
+			i := NewPreviewInstaller("Ubuntu_20.04.3_arm64", &stepPreviewer)
+			i.SetInstallMode(InstallModeContainerd)
+			Expect(i.Install("", "v1.22.3", testTag)).Should(Succeed())
+			Expect(stepPreviewer.String()).Should(ContainSubstring("/opt/bin/kubeadm"))
+			Expect(stepPreviewer.String()).ShouldNot(ContainSubstring("apt"))
+		})
+		It("Should keep the installer of the Flatcar hosts", func() {
+			stepPreviewer := stringPrinter{}
+			i := NewPreviewInstaller("Flatcar_Container_Linux_by_Kinvolk_3139.2.0_x86-64", &stepPreviewer)
+			i.SetInstallMode(InstallModeContainerd)
+			Expect(i.Install("", "v1.22.3", testTag)).Should(Succeed())
+			Expect(stepPreviewer.String()).Should(ContainSubstring("CONTAINERD_CONFIG=/etc/containerd/config.toml"))
+		})
+	})
+	Context("When the kubelet environment file is resolved", func() {
+		It("Should return the file read by the kubelet service of the OS packages", func() {
+			for os, file := range map[string]string{
+				"Ubuntu_20.04.3_x86-64":                              "/etc/default/kubelet",
+				"Rocky_Linux_8.6_x86-64":                             "/etc/sysconfig/kubelet",
+				"SUSE_Linux_Enterprise_Server_15.4_arm64":            "/etc/sysconfig/kubelet",
+				"Flatcar_Container_Linux_by_Kinvolk_3139.2.0_x86-64": "/etc/default/kubelet",
+			} {
+				i := NewPreviewInstaller(os, &stringPrinter{})
+				Expect(i.KubeletEnvironmentFile()).To(Equal(file), os)
+			}
+		})
+		It("Should return the file of the plain binaries in the containerd install mode", func() {
+			i := NewPreviewInstaller("Rocky_Linux_8.6_x86-64", &stringPrinter{})
+			i.SetInstallMode(InstallModeContainerd)
+			Expect(i.KubeletEnvironmentFile()).To(Equal("/etc/default/kubelet"))
+		})
+	})
+	Context("When installer is created for the rke2 bundles", func() {
+		It("Should install the rke2 bundle on any OS", func() {
+			for _, os := range []string{"Ubuntu_20.04.3_x86-64", "Rocky_Linux_8.6_arm64", "Debian_GNU/Linux_11_x86-64"} {
+				stepPreviewer := stringPrinter{}
+				i, err := newUnchecked(os, BundleTypeRKE2, "", logr.Discard(), &stepPreviewer)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(i.Install("", "v1.22.9+rke2r2", testTag)).Should(Succeed())
+				Expect(stepPreviewer.String()).Should(ContainSubstring("tar -C /usr/local -xzf"))
+				Expect(stepPreviewer.String()).ShouldNot(ContainSubstring("kubeadm"))
+				Expect(stepPreviewer.String()).ShouldNot(ContainSubstring("apt"))
+			}
+		})
+		It("Should uninstall rke2 with its uninstall script", func() {
+			stepPreviewer := stringPrinter{}
+			i, err := newUnchecked("Ubuntu_20.04.3_x86-64", BundleTypeRKE2, "", logr.Discard(), &stepPreviewer)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(i.Uninstall("", "v1.22.9", testTag)).Should(Succeed())
+			Expect(stepPreviewer.String()).Should(ContainSubstring("/usr/local/bin/rke2-uninstall.sh"))
+		})
+		It("Should write the proxy environment of the rke2 services", func() {
+			stepPreviewer := stringPrinter{}
+			i, err := newUnchecked("Ubuntu_20.04.3_x86-64", BundleTypeRKE2, "", logr.Discard(), &stepPreviewer)
+			Expect(err).ShouldNot(HaveOccurred())
+			i.SetProxy(ProxyConfig{HTTPSProxy: "http://proxy:3128"})
+			Expect(i.Install("", "v1.22.9", testTag)).Should(Succeed())
+			Expect(stepPreviewer.String()).Should(ContainSubstring("/etc/default/rke2-server"))
+		})
+		It("Should download the rke2 bundle of the architecture", func() {
+			bd := NewBundleDownloader(BundleTypeRKE2, "projects.registry.vmware.com/cluster_api_provider_bringyourownhost", "", logr.Discard())
+			Expect(bd.GetBundleAddr("x86-64", "v1.22.9", testTag)).Should(Equal(
+				"projects.registry.vmware.com/cluster_api_provider_bringyourownhost/byoh-bundle-x86-64_rke2:test-tag"))
+		})
+	})
+	Context("When the downloaded bundles are removed", func() {
+		It("Should remove the bundles of the type of the installer only", func() {
+			downloadPath, err := os.MkdirTemp("", "removeBundlesTest")
+			Expect(err).ShouldNot(HaveOccurred())
+			defer os.RemoveAll(downloadPath)
+			i, err := newUnchecked("Ubuntu_20.04.3_x86-64", BundleTypeK8s, downloadPath, logr.Discard(), &algo.OutputBuilderCounter{})
+			Expect(err).ShouldNot(HaveOccurred())
+			for _, dir := range []string{"repo.a/k8s-v1.22.3", "repo.a/rke2-v1.22.9+rke2r2", "repo.b/k8s-v1.23.5", "agentUpgrade123"} {
+				Expect(os.MkdirAll(filepath.Join(downloadPath, dir), 0755)).Should(Succeed())
+			}
+
+			Expect(i.RemoveBundles()).Should(Succeed())
+			Expect(filepath.Join(downloadPath, "repo.a", "k8s-v1.22.3")).ShouldNot(BeADirectory())
+			Expect(filepath.Join(downloadPath, "repo.a", "rke2-v1.22.9+rke2r2")).Should(BeADirectory())
+			Expect(filepath.Join(downloadPath, "repo.b")).ShouldNot(BeADirectory())
+			Expect(filepath.Join(downloadPath, "agentUpgrade123")).Should(BeADirectory())
+		})
+	})
+	Context("When the install steps are previewed for a dry run", func() {
+		It("Should resolve the bundle and output the steps run with it without running them", func() {
+			downloadPath, err := os.MkdirTemp("", "dryRunTest")
+			Expect(err).ShouldNot(HaveOccurred())
+			defer os.RemoveAll(downloadPath)
+			ob := algo.OutputBuilderCounter{}
+			i, err := newUnchecked("Ubuntu_20.04.3_x86-64", BundleTypeK8s, downloadPath, logr.Discard(), &ob)
+			Expect(err).ShouldNot(HaveOccurred())
+			i.SetProxy(ProxyConfig{HTTPSProxy: "http://proxy:3128"})
+
+			bundleAddr, steps, err := i.Preview("projects.registry.vmware.com/cluster_api_provider_bringyourownhost", "v1.22.3", testTag)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(bundleAddr).Should(Equal("projects.registry.vmware.com/cluster_api_provider_bringyourownhost/byoh-bundle-ubuntu_20.04.1_x86-64_k8s:test-tag"))
+			Expect(steps).Should(ContainSubstring("# Installing: kubelet"))
+			Expect(steps).Should(ContainSubstring(i.bundleDownloader.GetBundleDirPath("v1.22.3")))
+			Expect(steps).Should(ContainSubstring("/etc/systemd/system/containerd.service.d/http-proxy.conf"))
+			Expect(ob.LogCalledCnt).Should(Equal(0))
+			entries, err := os.ReadDir(downloadPath)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(entries).Should(BeEmpty())
+		})
+	})
 	Context("When installer is created", func() {
 		It("Should be possible to do so using host os or bundle os ", func() {
 			Expect(func() { NewPreviewInstaller("Ubuntu_20.04.1_x86-64", nil) }).NotTo(Panic())
 			Expect(func() { NewPreviewInstaller("Ubuntu_20.04.3_x86-64", nil) }).NotTo(Panic())
+			Expect(func() { NewPreviewInstaller("RHEL_8_x86-64", nil) }).NotTo(Panic())
+			Expect(func() { NewPreviewInstaller("Rocky_Linux_8.6_x86-64", nil) }).NotTo(Panic())
 		})
 	})
 })
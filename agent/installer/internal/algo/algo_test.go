@@ -16,7 +16,7 @@ var _ = Describe("Installer Algo Tests", func() {
 	)
 
 	const (
-		stepsNum = 22
+		stepsNum = 24
 	)
 
 	BeforeEach(func() {
@@ -37,6 +37,127 @@ var _ = Describe("Installer Algo Tests", func() {
 			Expect(outputBuilderCounter.LogCalledCnt).Should(Equal(stepsNum))
 		})
 	})
+	Context("When a proxy is configured", func() {
+		BeforeEach(func() {
+			installer.Proxy = ProxyConfig{HTTPProxy: "http://proxy:3128", NoProxy: "10.0.0.0/8"}
+		})
+		It("Should count the proxy step", func() {
+			err := installer.Install()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(outputBuilderCounter.LogCalledCnt).Should(Equal(stepsNum + 2))
+		})
+		It("Should write the proxy environment of the services", func() {
+			dropIn := installer.Proxy.systemdDropIn()
+			Expect(dropIn).Should(Equal("[Service]\n" +
+				"Environment=\"HTTP_PROXY=http://proxy:3128\"\n" +
+				"Environment=\"http_proxy=http://proxy:3128\"\n" +
+				"Environment=\"NO_PROXY=10.0.0.0/8\"\n" +
+				"Environment=\"no_proxy=10.0.0.0/8\"\n"))
+		})
+	})
+	Context("When the host runs with SELinux enforcing", func() {
+		It("Should label the directories of containerd and kubelet before starting them", func() {
+			step := securityModulesStep(installer).(*ShellStep)
+			Expect(step.DoCmd).Should(HavePrefix(`if [ "$(cat /sys/fs/selinux/enforce 2>/dev/null)" = 1 ]`))
+			Expect(step.DoCmd).Should(ContainSubstring("restorecon -R /etc/kubernetes /etc/cni/net.d /opt/cni/bin /var/lib/kubelet"))
+
+			steps := installer.getSteps(installer)
+			for i, s := range steps {
+				if s.(*ShellStep).Desc == "SECURITY MODULES" {
+					Expect(steps[i+1].(*ShellStep).Desc).Should(Equal(installer.containerdDaemonStep(installer).(*ShellStep).Desc))
+					return
+				}
+			}
+			Fail("no SECURITY MODULES step")
+		})
+	})
+	Context("When Installation is executed on RHEL", func() {
+		BeforeEach(func() {
+			rhel := Rhel8K8s1_22{}
+			rhel.OutputBuilder = &outputBuilderCounter
+			installer.K8sStepProvider = &rhel
+		})
+		It("Should count each step", func() {
+			err := installer.Install()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(outputBuilderCounter.LogCalledCnt).Should(Equal(stepsNum))
+		})
+		It("Should install the rpm packages with yum", func() {
+			step := installer.kubeletStep(installer).(*ShellStep)
+			Expect(step.DoCmd).Should(Equal("yum install -y 'kubelet.rpm'"))
+			Expect(step.UndoCmd).Should(Equal("yum remove -y kubelet"))
+		})
+		It("Should open the kubernetes ports on firewalld", func() {
+			step := installer.firewallStep(installer).(*ShellStep)
+			Expect(step.DoCmd).Should(ContainSubstring("firewall-cmd --permanent --add-port=6443/tcp"))
+			Expect(step.UndoCmd).Should(ContainSubstring("firewall-cmd --permanent --remove-port=6443/tcp"))
+		})
+	})
+	Context("When Installation is executed on SUSE", func() {
+		BeforeEach(func() {
+			suse := Suse15K8s1_22{}
+			suse.OutputBuilder = &outputBuilderCounter
+			installer.K8sStepProvider = &suse
+		})
+		It("Should count each step", func() {
+			err := installer.Install()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(outputBuilderCounter.LogCalledCnt).Should(Equal(stepsNum))
+		})
+		It("Should install and lock the rpm packages with zypper or transactional-update", func() {
+			step := installer.kubeletStep(installer).(*ShellStep)
+			Expect(step.DoCmd).Should(Equal("if command -v transactional-update > /dev/null; then " +
+				"transactional-update --non-interactive pkg install --allow-unsigned-rpm 'kubelet.rpm' && transactional-update apply; " +
+				"else zypper --non-interactive install --allow-unsigned-rpm 'kubelet.rpm'; fi && zypper addlock kubelet"))
+			Expect(step.UndoCmd).Should(Equal("zypper removelock kubelet && if command -v transactional-update > /dev/null; then " +
+				"transactional-update --non-interactive pkg remove kubelet && transactional-update apply; " +
+				"else zypper --non-interactive remove kubelet; fi"))
+		})
+	})
+	Context("When Installation is executed on an immutable host", func() {
+		BeforeEach(func() {
+			immutable := ImmutableK8s1_22{}
+			immutable.OutputBuilder = &outputBuilderCounter
+			installer.K8sStepProvider = &immutable
+		})
+		It("Should count each step", func() {
+			err := installer.Install()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(outputBuilderCounter.LogCalledCnt).Should(Equal(stepsNum))
+		})
+		It("Should install the binaries without a package manager", func() {
+			step := installer.kubeadmStep(installer).(*ShellStep)
+			Expect(step.DoCmd).Should(Equal("mkdir -p /opt/bin && install -m 0755 'kubeadm' /opt/bin/kubeadm"))
+			Expect(step.UndoCmd).Should(Equal("rm -f /opt/bin/kubeadm"))
+		})
+		It("Should run kubelet as a systemd service from the binary directory", func() {
+			step := installer.kubeletStep(installer).(*ShellStep)
+			Expect(step.DoCmd).Should(ContainSubstring("sed 's:/usr/bin:/opt/bin:g' 'kubelet.service' > /etc/systemd/system/kubelet.service"))
+			Expect(step.DoCmd).Should(HaveSuffix("systemctl enable kubelet"))
+		})
+	})
+	Context("When Installation is executed on Flatcar", func() {
+		BeforeEach(func() {
+			flatcar := FlatcarK8s1_22{}
+			flatcar.OutputBuilder = &outputBuilderCounter
+			installer.K8sStepProvider = &flatcar
+		})
+		It("Should count each step", func() {
+			err := installer.Install()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(outputBuilderCounter.LogCalledCnt).Should(Equal(stepsNum))
+		})
+		It("Should generate the kubelet systemd units outside of /usr", func() {
+			step := installer.kubeletStep(installer).(*ShellStep)
+			Expect(step.DoCmd).Should(ContainSubstring("ExecStart=/opt/bin/kubelet $KUBELET_KUBECONFIG_ARGS"))
+			Expect(step.DoCmd).Should(ContainSubstring("--volume-plugin-dir=/opt/libexec/kubernetes/kubelet-plugins/volume/exec/"))
+			Expect(step.DoCmd).ShouldNot(ContainSubstring("/usr/bin"))
+		})
+		It("Should point containerd to its configuration under /etc", func() {
+			step := installer.containerdStep(installer).(*ShellStep)
+			Expect(step.DoCmd).Should(ContainSubstring("/etc/systemd/system/containerd.service.d/10-byoh-config.conf"))
+		})
+	})
 	Context("When Uninstallation is executed", func() {
 		It("Should count each step", func() {
 			err := installer.Uninstall()
@@ -65,4 +186,47 @@ var _ = Describe("Installer Algo Tests", func() {
 			}
 		})
 	})
+	Context("When the commands are audited", func() {
+		var records []CommandRecord
+
+		BeforeEach(func() {
+			records = nil
+			installer.BundlePath = "/var/lib/byoh/bundles"
+			installer.Auditor = auditorFunc(func(record CommandRecord) { records = append(records, record) })
+		})
+		It("Should record the command with its exit code and output", func() {
+			step := &ShellStep{
+				Desc:             "audited step",
+				DoCmd:            "echo installed; echo failed >&2; exit 3",
+				BaseK8sInstaller: installer,
+			}
+			Expect(step.do()).Should(HaveOccurred())
+			Expect(records).Should(HaveLen(1))
+			Expect(records[0].Step).Should(Equal("audited step"))
+			Expect(records[0].Command).Should(Equal(step.DoCmd))
+			Expect(records[0].ExitCode).Should(Equal(3))
+			Expect(records[0].Stdout).Should(Equal("installed\n"))
+			Expect(records[0].Stderr).Should(Equal("failed\n"))
+			Expect(records[0].Time).ShouldNot(BeZero())
+		})
+		It("Should truncate the output of the command", func() {
+			step := &ShellStep{
+				DoCmd:            "head -c 5000 /dev/zero | tr '\\0' a",
+				BaseK8sInstaller: installer,
+			}
+			Expect(step.do()).ShouldNot(HaveOccurred())
+			Expect(records).Should(HaveLen(1))
+			Expect(records[0].ExitCode).Should(Equal(0))
+			Expect(records[0].Stdout).Should(HaveLen(MaxAuditOutput + len("...(truncated)")))
+		})
+		It("Should not record the commands in preview mode", func() {
+			installer.BundlePath = ""
+			Expect(installer.Install()).ShouldNot(HaveOccurred())
+			Expect(records).Should(BeEmpty())
+		})
+	})
 })
+
+type auditorFunc func(CommandRecord)
+
+func (f auditorFunc) Record(record CommandRecord) { f(record) }
@@ -0,0 +1,67 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package algo
+
+import (
+	"errors"
+	"os/exec"
+	"time"
+)
+
+// MaxAuditOutput is the number of bytes of the output of a command kept in its audit record
+const MaxAuditOutput = 4096
+
+// CommandRecord is the audit record of a command run by the installer
+type CommandRecord struct {
+	// Time is when the command started
+	Time time.Time `json:"time"`
+	// Step is the description of the installer step running the command
+	Step string `json:"step"`
+	// Command is the command line
+	Command  string        `json:"command"`
+	Duration time.Duration `json:"duration"`
+	// ExitCode is the exit code of the command, -1 when it could not be started
+	ExitCode int `json:"exitCode"`
+	// Stdout and Stderr are truncated to MaxAuditOutput bytes
+	Stdout string `json:"stdout,omitempty"`
+	Stderr string `json:"stderr,omitempty"`
+}
+
+// Auditor records the commands run by the installer
+type Auditor interface {
+	Record(CommandRecord)
+}
+
+// NewCommandRecord returns the audit record of a command started at start, from the error of its run and its output
+func NewCommandRecord(step, command string, start time.Time, err error, stdout, stderr string) CommandRecord {
+	return CommandRecord{
+		Time:     start,
+		Step:     step,
+		Command:  command,
+		Duration: time.Since(start),
+		ExitCode: exitCode(err),
+		Stdout:   truncateOutput(stdout),
+		Stderr:   truncateOutput(stderr),
+	}
+}
+
+// exitCode returns the exit code of a command from the error of its run
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// truncateOutput truncates the output of a command to MaxAuditOutput bytes
+func truncateOutput(out string) string {
+	if len(out) <= MaxAuditOutput {
+		return out
+	}
+	return out[:MaxAuditOutput] + "...(truncated)"
+}
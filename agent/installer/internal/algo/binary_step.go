@@ -0,0 +1,24 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package algo
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// BinDir is the writable directory of the PATH of the immutable hosts the binaries are installed to
+const BinDir = "/opt/bin"
+
+// NewBinaryStep returns a new step to install a binary of the bundle to BinDir
+func NewBinaryStep(k *BaseK8sInstaller, binary string) Step {
+	binAbsolutePath := filepath.Join(k.BundlePath, binary)
+	targetPath := filepath.Join(BinDir, binary)
+
+	return &ShellStep{
+		BaseK8sInstaller: k,
+		Desc:             binary,
+		DoCmd:            fmt.Sprintf("mkdir -p %s && install -m 0755 '%s' %s", BinDir, binAbsolutePath, targetPath),
+		UndoCmd:          fmt.Sprintf("rm -f %s", targetPath)}
+}
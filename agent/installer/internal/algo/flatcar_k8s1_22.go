@@ -0,0 +1,89 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package algo
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+const (
+	// flatcarVolumePluginDir replaces the kubelet default volume plugin dir, under the read-only /usr
+	flatcarVolumePluginDir = "/opt/libexec/kubernetes/kubelet-plugins/volume/exec/"
+
+	flatcarKubeletService = `[Unit]
+Description=kubelet: The Kubernetes Node Agent
+Documentation=https://kubernetes.io/docs/home/
+Wants=network-online.target
+After=network-online.target containerd.service
+
+[Service]
+ExecStart=` + BinDir + `/kubelet
+Restart=always
+StartLimitInterval=0
+RestartSec=10
+
+[Install]
+WantedBy=multi-user.target
+`
+
+	flatcarKubeadmDropIn = `[Service]
+Environment="KUBELET_KUBECONFIG_ARGS=--bootstrap-kubeconfig=/etc/kubernetes/bootstrap-kubelet.conf --kubeconfig=/etc/kubernetes/kubelet.conf"
+Environment="KUBELET_CONFIG_ARGS=--config=/var/lib/kubelet/config.yaml"
+EnvironmentFile=-/var/lib/kubelet/kubeadm-flags.env
+EnvironmentFile=-/etc/default/kubelet
+ExecStart=
+ExecStart=` + BinDir + `/kubelet $KUBELET_KUBECONFIG_ARGS $KUBELET_CONFIG_ARGS $KUBELET_KUBEADM_ARGS $KUBELET_EXTRA_ARGS --volume-plugin-dir=` + flatcarVolumePluginDir + `
+`
+
+	// flatcarContainerdDropIn points the containerd of Flatcar, shipped under /usr, to the writable configuration
+	flatcarContainerdDropIn = `[Service]
+Environment=CONTAINERD_CONFIG=/etc/containerd/config.toml
+`
+)
+
+// FlatcarK8s1_22 is the configuration for Flatcar Container Linux, K8s 1.22.X extending ImmutableK8s1_22.
+// Nothing is written under the read-only /usr, which the systemd-sysext images are merged into:
+// the binaries are installed to BinDir and the systemd units are generated under /etc.
+type FlatcarK8s1_22 struct {
+	ImmutableK8s1_22
+}
+
+// containerdStep writes the containerd configuration under /etc and points the containerd service to it
+func (f *FlatcarK8s1_22) containerdStep(bki *BaseK8sInstaller) Step {
+	dropInDir := "/etc/systemd/system/containerd.service.d"
+
+	doCmd := fmt.Sprintf("mkdir -p /etc/containerd '%[1]s' && "+
+		"containerd config default | sed 's/SystemdCgroup = false/SystemdCgroup = true/' > /etc/containerd/config.toml && "+
+		"printf '%%s' %[2]s > '%[1]s/10-byoh-config.conf'",
+		dropInDir, shellQuote(flatcarContainerdDropIn))
+	undoCmd := fmt.Sprintf("rm -f '%s/10-byoh-config.conf' /etc/containerd/config.toml", dropInDir)
+
+	return &ShellStep{
+		BaseK8sInstaller: bki,
+		Desc:             "CONTAINERD",
+		DoCmd:            doCmd,
+		UndoCmd:          undoCmd}
+}
+
+// kubeletStep installs the kubelet binary and generates its systemd units
+func (f *FlatcarK8s1_22) kubeletStep(bki *BaseK8sInstaller) Step {
+	kubeletAbsPath := filepath.Join(bki.BundlePath, "kubelet")
+
+	doCmd := fmt.Sprintf("mkdir -p %[1]s %[2]s /etc/systemd/system/kubelet.service.d && "+
+		"install -m 0755 '%[3]s' %[1]s/kubelet && "+
+		"printf '%%s' %[4]s > /etc/systemd/system/kubelet.service && "+
+		"printf '%%s' %[5]s > /etc/systemd/system/kubelet.service.d/10-kubeadm.conf && "+
+		"systemctl daemon-reload && systemctl enable kubelet",
+		BinDir, flatcarVolumePluginDir, kubeletAbsPath, shellQuote(flatcarKubeletService), shellQuote(flatcarKubeadmDropIn))
+	undoCmd := fmt.Sprintf("systemctl stop kubelet; systemctl disable kubelet; "+
+		"rm -f /etc/systemd/system/kubelet.service /etc/systemd/system/kubelet.service.d/10-kubeadm.conf %s/kubelet && "+
+		"systemctl daemon-reload", BinDir)
+
+	return &ShellStep{
+		BaseK8sInstaller: bki,
+		Desc:             "KUBELET",
+		DoCmd:            doCmd,
+		UndoCmd:          undoCmd}
+}
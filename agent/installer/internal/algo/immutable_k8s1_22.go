@@ -0,0 +1,125 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package algo
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// ImmutableK8s1_22 is the configuration for the hosts without a package manager, like Flatcar
+// Container Linux and Fedora CoreOS, K8s 1.22.X extending BaseK8sInstaller.
+// The bundle is pulled by containerd, which is part of the OS, and holds the plain binaries
+// of the kubernetes components, installed to BinDir. kubelet runs as a systemd service.
+type ImmutableK8s1_22 struct {
+	BaseK8sInstaller
+}
+
+func (m *ImmutableK8s1_22) swapStep(bki *BaseK8sInstaller) Step {
+	return &ShellStep{
+		BaseK8sInstaller: bki,
+		Desc:             "SWAP",
+		DoCmd:            `swapoff -a && sed -ri '/\sswap\s/s/^#?/#/' /etc/fstab`,
+		UndoCmd:          `swapon -a && sed -ri '/\sswap\s/s/^#?//' /etc/fstab`}
+}
+
+func (m *ImmutableK8s1_22) firewallStep(bki *BaseK8sInstaller) Step {
+	return &ShellStep{
+		BaseK8sInstaller: bki,
+		Desc:             "FIREWALL",
+		DoCmd:            firewalldPortsCmd("add"),
+		UndoCmd:          firewalldPortsCmd("remove")}
+}
+
+func (m *ImmutableK8s1_22) kernelModsLoadStep(bki *BaseK8sInstaller) Step {
+	return &ShellStep{
+		BaseK8sInstaller: bki,
+		Desc:             "KERNEL MODULES",
+		DoCmd:            "modprobe overlay && modprobe br_netfilter",
+		UndoCmd:          "modprobe -r overlay && modprobe -r br_netfilter"}
+}
+
+func (m *ImmutableK8s1_22) osWideCfgUpdateStep(bki *BaseK8sInstaller) Step {
+	confAbsolutePath := filepath.Join(bki.BundlePath, "conf.tar")
+
+	doCmd := fmt.Sprintf(
+		"tar -C / -xvf '%s' && sysctl --system",
+		confAbsolutePath)
+
+	undoCmd := fmt.Sprintf(
+		"tar tf '%s' | xargs -n 1 echo '/' | sed 's/ //g' | xargs rm -f",
+		confAbsolutePath)
+
+	return &ShellStep{
+		BaseK8sInstaller: bki,
+		Desc:             "OS CONFIGURATION",
+		DoCmd:            doCmd,
+		UndoCmd:          undoCmd}
+}
+
+func (m *ImmutableK8s1_22) criToolsStep(bki *BaseK8sInstaller) Step {
+	return NewBinaryStep(bki, "crictl")
+}
+
+// criKubernetesStep extracts the CNI plugins, the OS ones are not always available
+func (m *ImmutableK8s1_22) criKubernetesStep(bki *BaseK8sInstaller) Step {
+	cniAbsPath := filepath.Join(bki.BundlePath, "kubernetes-cni.tar")
+
+	return &ShellStep{
+		BaseK8sInstaller: bki,
+		Desc:             "KUBERNETES CNI",
+		DoCmd:            fmt.Sprintf("mkdir -p /opt/cni/bin && tar -C /opt/cni/bin -xvf '%s'", cniAbsPath),
+		UndoCmd:          "rm -rf /opt/cni/bin"}
+}
+
+// containerdStep configures the containerd of the OS with the systemd cgroup driver used by kubelet
+func (m *ImmutableK8s1_22) containerdStep(bki *BaseK8sInstaller) Step {
+	return &ShellStep{
+		BaseK8sInstaller: bki,
+		Desc:             "CONTAINERD",
+		DoCmd: "mkdir -p /etc/containerd && " +
+			"containerd config default | sed 's/SystemdCgroup = false/SystemdCgroup = true/' > /etc/containerd/config.toml",
+		UndoCmd: "rm -f /etc/containerd/config.toml"}
+}
+
+// containerdDaemonStep restarts containerd with the new configuration. containerd is part of
+// the OS, so it is only restarted with the OS configuration on uninstall.
+func (m *ImmutableK8s1_22) containerdDaemonStep(bki *BaseK8sInstaller) Step {
+	return &ShellStep{
+		BaseK8sInstaller: bki,
+		Desc:             "CONTAINERD SERVICE",
+		DoCmd:            "systemctl daemon-reload && systemctl enable containerd && systemctl restart containerd",
+		UndoCmd:          "systemctl daemon-reload && systemctl restart containerd"}
+}
+
+// kubeletStep installs the kubelet binary and its systemd units, pointed to BinDir
+func (m *ImmutableK8s1_22) kubeletStep(bki *BaseK8sInstaller) Step {
+	kubeletAbsPath := filepath.Join(bki.BundlePath, "kubelet")
+	serviceAbsPath := filepath.Join(bki.BundlePath, "kubelet.service")
+	dropInAbsPath := filepath.Join(bki.BundlePath, "10-kubeadm.conf")
+
+	doCmd := fmt.Sprintf("mkdir -p %[1]s /etc/systemd/system/kubelet.service.d && "+
+		"install -m 0755 '%[2]s' %[1]s/kubelet && "+
+		"sed 's:/usr/bin:%[1]s:g' '%[3]s' > /etc/systemd/system/kubelet.service && "+
+		"sed 's:/usr/bin:%[1]s:g' '%[4]s' > /etc/systemd/system/kubelet.service.d/10-kubeadm.conf && "+
+		"systemctl daemon-reload && systemctl enable kubelet",
+		BinDir, kubeletAbsPath, serviceAbsPath, dropInAbsPath)
+	undoCmd := fmt.Sprintf("systemctl stop kubelet; systemctl disable kubelet; "+
+		"rm -f /etc/systemd/system/kubelet.service /etc/systemd/system/kubelet.service.d/10-kubeadm.conf %s/kubelet && "+
+		"systemctl daemon-reload", BinDir)
+
+	return &ShellStep{
+		BaseK8sInstaller: bki,
+		Desc:             "KUBELET",
+		DoCmd:            doCmd,
+		UndoCmd:          undoCmd}
+}
+
+func (m *ImmutableK8s1_22) kubectlStep(bki *BaseK8sInstaller) Step {
+	return NewBinaryStep(bki, "kubectl")
+}
+
+func (m *ImmutableK8s1_22) kubeadmStep(bki *BaseK8sInstaller) Step {
+	return NewBinaryStep(bki, "kubeadm")
+}
@@ -3,6 +3,8 @@
 
 package algo
 
+import "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/privilege"
+
 // Installer generic installer interface
 type Installer interface {
 	Install() error
@@ -65,6 +67,13 @@ type K8sStepProvider interface {
 // BaseK8sInstaller is the default k8s installer implementation
 type BaseK8sInstaller struct {
 	BundlePath string
+	Proxy      ProxyConfig
+	// Auditor records the commands run, when set
+	Auditor Auditor
+	// Escalator runs the commands as root
+	Escalator privilege.Escalator
+	// DryRun outputs the commands, with the bundle path set, without running them
+	DryRun bool
 	Installer
 	K8sStepProvider
 	OutputBuilder
@@ -130,6 +139,10 @@ func (b *BaseK8sInstaller) getSteps(bki *BaseK8sInstaller) []Step {
 		ContainerD has to be loaded as a daemon first, in order
 		to let kubeadm detect that the default container
 		engine is not Docker.
+
+		The proxy settings have to be in place before
+		ContainerD is started, as well as the SELinux
+		file contexts of its directories.
 	*/
 
 	var steps = []Step{
@@ -139,11 +152,18 @@ func (b *BaseK8sInstaller) getSteps(bki *BaseK8sInstaller) []Step {
 		b.osWideCfgUpdateStep(bki),
 		b.criToolsStep(bki),
 		b.criKubernetesStep(bki),
-		b.containerdStep(bki),
+		b.containerdStep(bki)}
+
+	if !bki.Proxy.IsEmpty() {
+		steps = append(steps, proxyStep(bki))
+	}
+	steps = append(steps, securityModulesStep(bki))
+
+	steps = append(steps,
 		b.containerdDaemonStep(bki),
 		b.kubeletStep(bki),
 		b.kubectlStep(bki),
-		b.kubeadmStep(bki)}
+		b.kubeadmStep(bki))
 
 	return steps
 }
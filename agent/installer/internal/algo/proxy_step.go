@@ -0,0 +1,78 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package algo
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// proxyDropInDirs are the systemd drop-in directories of the services which need the proxy settings
+var proxyDropInDirs = []string{
+	"/etc/systemd/system/containerd.service.d",
+	"/etc/systemd/system/kubelet.service.d",
+}
+
+const proxyDropInFile = "http-proxy.conf"
+
+// ProxyConfig is the proxy configuration propagated to containerd and kubelet
+type ProxyConfig struct {
+	HTTPProxy  string
+	HTTPSProxy string
+	NoProxy    string
+}
+
+// IsEmpty returns true if no proxy is configured
+func (p ProxyConfig) IsEmpty() bool {
+	return p.HTTPProxy == "" && p.HTTPSProxy == "" && p.NoProxy == ""
+}
+
+// Env returns the proxy environment variables, in both the upper and lower case forms
+func (p ProxyConfig) Env() map[string]string {
+	env := map[string]string{}
+	for name, value := range map[string]string{"HTTP_PROXY": p.HTTPProxy, "HTTPS_PROXY": p.HTTPSProxy, "NO_PROXY": p.NoProxy} {
+		if value != "" {
+			env[name] = value
+			env[strings.ToLower(name)] = value
+		}
+	}
+	return env
+}
+
+// systemdDropIn returns the systemd drop-in setting the proxy environment of a service
+func (p ProxyConfig) systemdDropIn() string {
+	var sb strings.Builder
+	sb.WriteString("[Service]\n")
+	for _, name := range []string{"HTTP_PROXY", "http_proxy", "HTTPS_PROXY", "https_proxy", "NO_PROXY", "no_proxy"} {
+		if value, ok := p.Env()[name]; ok {
+			fmt.Fprintf(&sb, "Environment=\"%s=%s\"\n", name, value)
+		}
+	}
+	return sb.String()
+}
+
+// proxyStep writes the systemd drop-ins propagating the proxy settings to containerd and kubelet
+func proxyStep(bki *BaseK8sInstaller) Step {
+	dropIn := shellQuote(bki.Proxy.systemdDropIn())
+
+	doCmds := make([]string, 0, len(proxyDropInDirs))
+	undoCmds := make([]string, 0, len(proxyDropInDirs))
+	for _, dir := range proxyDropInDirs {
+		dropInPath := filepath.Join(dir, proxyDropInFile)
+		doCmds = append(doCmds, fmt.Sprintf("mkdir -p '%s' && printf '%%s' %s > '%s'", dir, dropIn, dropInPath))
+		undoCmds = append(undoCmds, fmt.Sprintf("rm -f '%s'", dropInPath))
+	}
+
+	return &ShellStep{
+		BaseK8sInstaller: bki,
+		Desc:             "PROXY",
+		DoCmd:            strings.Join(doCmds, " && "),
+		UndoCmd:          strings.Join(undoCmds, " && ")}
+}
+
+// shellQuote single quotes s for bash
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
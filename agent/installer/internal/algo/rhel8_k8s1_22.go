@@ -0,0 +1,127 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package algo
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// firewalldPorts are the ports of the control plane and node components opened on firewalld
+var firewalldPorts = []string{"6443/tcp", "2379-2380/tcp", "10250/tcp", "10257/tcp", "10259/tcp", "30000-32767/tcp"}
+
+// Rhel8K8s1_22 is the configuration for RHEL 8.X and its rebuilds (Rocky Linux, AlmaLinux),
+// K8s 1.22.X extending BaseK8sInstaller
+type Rhel8K8s1_22 struct {
+	BaseK8sInstaller
+}
+
+func (r *Rhel8K8s1_22) swapStep(bki *BaseK8sInstaller) Step {
+	return &ShellStep{
+		BaseK8sInstaller: bki,
+		Desc:             "SWAP",
+		DoCmd:            `swapoff -a && sed -ri '/\sswap\s/s/^#?/#/' /etc/fstab`,
+		UndoCmd:          `swapon -a && sed -ri '/\sswap\s/s/^#?//' /etc/fstab`}
+}
+
+// firewallStep opens the kubernetes ports when firewalld is running instead of disabling it
+func (r *Rhel8K8s1_22) firewallStep(bki *BaseK8sInstaller) Step {
+	return &ShellStep{
+		BaseK8sInstaller: bki,
+		Desc:             "FIREWALL",
+		DoCmd:            firewalldPortsCmd("add"),
+		UndoCmd:          firewalldPortsCmd("remove")}
+}
+
+// firewalldPortsCmd adds or removes the kubernetes ports, when firewalld is running
+func firewalldPortsCmd(action string) string {
+	args := make([]string, 0, len(firewalldPorts))
+	for _, port := range firewalldPorts {
+		args = append(args, fmt.Sprintf("--%s-port=%s", action, port))
+	}
+	return fmt.Sprintf("if systemctl is-active --quiet firewalld; then firewall-cmd --permanent %s && firewall-cmd --reload; fi",
+		strings.Join(args, " "))
+}
+
+func (r *Rhel8K8s1_22) kernelModsLoadStep(bki *BaseK8sInstaller) Step {
+	return &ShellStep{
+		BaseK8sInstaller: bki,
+		Desc:             "KERNEL MODULES",
+		DoCmd:            "modprobe overlay && modprobe br_netfilter",
+		UndoCmd:          "modprobe -r overlay && modprobe -r br_netfilter"}
+}
+
+func (r *Rhel8K8s1_22) osWideCfgUpdateStep(bki *BaseK8sInstaller) Step {
+	confAbsolutePath := filepath.Join(bki.BundlePath, "conf.tar")
+
+	doCmd := fmt.Sprintf(
+		"tar -C / -xvf '%s' && sysctl --system",
+		confAbsolutePath)
+
+	undoCmd := fmt.Sprintf(
+		"tar tf '%s' | xargs -n 1 echo '/' | sed 's/ //g' | xargs rm -f",
+		confAbsolutePath)
+
+	return &ShellStep{
+		BaseK8sInstaller: bki,
+		Desc:             "OS CONFIGURATION",
+		DoCmd:            doCmd,
+		UndoCmd:          undoCmd}
+}
+
+func (r *Rhel8K8s1_22) criToolsStep(bki *BaseK8sInstaller) Step {
+	// Not available upstream
+	return NewRpmStepOptional(bki, "cri-tools.rpm")
+}
+
+func (r *Rhel8K8s1_22) criKubernetesStep(bki *BaseK8sInstaller) Step {
+	// Not available upstream
+	return NewRpmStepOptional(bki, "kubernetes-cni.rpm")
+}
+
+func (r *Rhel8K8s1_22) kubectlStep(bki *BaseK8sInstaller) Step {
+	return NewRpmStep(bki, "kubectl.rpm")
+}
+
+func (r *Rhel8K8s1_22) kubeadmStep(bki *BaseK8sInstaller) Step {
+	return NewRpmStep(bki, "kubeadm.rpm")
+}
+
+func (r *Rhel8K8s1_22) kubeletStep(bki *BaseK8sInstaller) Step {
+	return NewRpmStep(bki, "kubelet.rpm")
+}
+
+// containerdStep extracts containerd and, when SELinux is enabled, relabels its files
+// and turns on the SELinux support of the CRI plugin
+func (r *Rhel8K8s1_22) containerdStep(bki *BaseK8sInstaller) Step {
+	containerdAbsPath := filepath.Join(bki.BundlePath, "containerd.tar")
+
+	cmdRmDirs := "rm -rf /opt/cni/ && rm -rf /opt/containerd/ && "
+	cmdListTar := fmt.Sprintf("tar tf '%s'", containerdAbsPath)
+	cmdConcatPathSlash := " | xargs -n 1 echo '/' | sed 's/ //g'"
+	cmdRmFilesOnly := " | grep -e '[^/]$' | xargs rm -f"
+
+	cmdSelinux := "if selinuxenabled; then " +
+		"restorecon -R /usr/local/bin /usr/local/sbin /opt/cni /etc/systemd/system && " +
+		"mkdir -p /etc/containerd && " +
+		"containerd config default | sed 's/enable_selinux = false/enable_selinux = true/' > /etc/containerd/config.toml; fi"
+
+	doCmd := fmt.Sprintf("tar -C / -xvf '%s' && %s", containerdAbsPath, cmdSelinux)
+	undoCmd := "rm -f /etc/containerd/config.toml && " + cmdRmDirs + cmdListTar + cmdConcatPathSlash + cmdRmFilesOnly
+
+	return &ShellStep{
+		BaseK8sInstaller: bki,
+		Desc:             "CONTAINERD",
+		DoCmd:            doCmd,
+		UndoCmd:          undoCmd}
+}
+
+func (r *Rhel8K8s1_22) containerdDaemonStep(bki *BaseK8sInstaller) Step {
+	return &ShellStep{
+		BaseK8sInstaller: bki,
+		Desc:             "CONTAINERD SERVICE",
+		DoCmd:            "systemctl daemon-reload && systemctl enable containerd && systemctl start containerd",
+		UndoCmd:          "systemctl stop containerd && systemctl disable containerd && systemctl daemon-reload"}
+}
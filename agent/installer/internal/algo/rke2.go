@@ -0,0 +1,126 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package algo
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// RKE2InstallDir is the prefix the rke2 tarball is extracted to, as with the install script of rke2
+	RKE2InstallDir = "/usr/local"
+
+	// rke2EnvFiles are sourced by the rke2 services, the embedded containerd inherits their environment
+	rke2ServerEnvFile = "/etc/default/rke2-server"
+	rke2AgentEnvFile  = "/etc/default/rke2-agent"
+)
+
+// RKE2 is the configuration of the hosts bootstrapped by the RKE2 bootstrap provider, for any OS,
+// extending BaseK8sInstaller. The bundle holds the rke2 release tarball, which ships the kubelet,
+// containerd, the CNI plugins and kubectl, so only the OS state and rke2 itself are installed.
+// The rke2-server or rke2-agent service is started by the bootstrap data.
+type RKE2 struct {
+	BaseK8sInstaller
+}
+
+// skipStep is a step rke2 takes care of itself
+type skipStep struct{}
+
+func (skipStep) do() error   { return nil }
+func (skipStep) undo() error { return nil }
+
+func (m *RKE2) swapStep(bki *BaseK8sInstaller) Step {
+	return &ShellStep{
+		BaseK8sInstaller: bki,
+		Desc:             "SWAP",
+		DoCmd:            `swapoff -a && sed -ri '/\sswap\s/s/^#?/#/' /etc/fstab`,
+		UndoCmd:          `swapon -a && sed -ri '/\sswap\s/s/^#?//' /etc/fstab`}
+}
+
+func (m *RKE2) firewallStep(bki *BaseK8sInstaller) Step {
+	return &ShellStep{
+		BaseK8sInstaller: bki,
+		Desc:             "FIREWALL",
+		DoCmd:            firewalldPortsCmd("add"),
+		UndoCmd:          firewalldPortsCmd("remove")}
+}
+
+func (m *RKE2) kernelModsLoadStep(bki *BaseK8sInstaller) Step {
+	return &ShellStep{
+		BaseK8sInstaller: bki,
+		Desc:             "KERNEL MODULES",
+		DoCmd:            "modprobe overlay && modprobe br_netfilter",
+		UndoCmd:          "modprobe -r overlay && modprobe -r br_netfilter"}
+}
+
+func (m *RKE2) osWideCfgUpdateStep(bki *BaseK8sInstaller) Step {
+	confAbsolutePath := filepath.Join(bki.BundlePath, "conf.tar")
+
+	return &ShellStep{
+		BaseK8sInstaller: bki,
+		Desc:             "OS CONFIGURATION",
+		DoCmd:            fmt.Sprintf("tar -C / -xvf '%s' && sysctl --system", confAbsolutePath),
+		UndoCmd:          fmt.Sprintf("tar tf '%s' | xargs -n 1 echo '/' | sed 's/ //g' | xargs rm -f", confAbsolutePath)}
+}
+
+func (m *RKE2) criToolsStep(bki *BaseK8sInstaller) Step {
+	return skipStep{}
+}
+
+func (m *RKE2) criKubernetesStep(bki *BaseK8sInstaller) Step {
+	return skipStep{}
+}
+
+// containerdStep writes the proxy environment of the rke2 services, the containerd of rke2
+// is embedded and configured by rke2 itself
+func (m *RKE2) containerdStep(bki *BaseK8sInstaller) Step {
+	if bki.Proxy.IsEmpty() {
+		return skipStep{}
+	}
+
+	env := bki.Proxy.Env()
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&sb, "%s=%s\n", name, env[name])
+	}
+
+	return &ShellStep{
+		BaseK8sInstaller: bki,
+		Desc:             "RKE2 PROXY",
+		DoCmd: fmt.Sprintf("printf '%%s' %[1]s > %[2]s && printf '%%s' %[1]s > %[3]s",
+			shellQuote(sb.String()), rke2ServerEnvFile, rke2AgentEnvFile),
+		UndoCmd: fmt.Sprintf("rm -f %s %s", rke2ServerEnvFile, rke2AgentEnvFile)}
+}
+
+func (m *RKE2) containerdDaemonStep(bki *BaseK8sInstaller) Step {
+	return skipStep{}
+}
+
+// kubeletStep extracts the rke2 tarball, holding the rke2 binary, its systemd units and its
+// killall and uninstall scripts. The uninstall script of rke2 removes its data as well.
+func (m *RKE2) kubeletStep(bki *BaseK8sInstaller) Step {
+	tarballAbsPath := filepath.Join(bki.BundlePath, "rke2.tar.gz")
+
+	return &ShellStep{
+		BaseK8sInstaller: bki,
+		Desc:             "RKE2",
+		DoCmd:            fmt.Sprintf("mkdir -p %[1]s && tar -C %[1]s -xzf '%[2]s' && systemctl daemon-reload", RKE2InstallDir, tarballAbsPath),
+		UndoCmd:          fmt.Sprintf("%s/bin/rke2-uninstall.sh", RKE2InstallDir)}
+}
+
+func (m *RKE2) kubectlStep(bki *BaseK8sInstaller) Step {
+	return skipStep{}
+}
+
+func (m *RKE2) kubeadmStep(bki *BaseK8sInstaller) Step {
+	return skipStep{}
+}
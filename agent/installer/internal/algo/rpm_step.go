@@ -0,0 +1,42 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package algo
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// NewRpmStep returns a new step to install rpm package
+func NewRpmStep(k *BaseK8sInstaller, rpmPkg string) Step {
+	return NewRpmStepEx(k, rpmPkg, false)
+}
+
+// NewRpmStepOptional optional step to install rpm package
+func NewRpmStepOptional(k *BaseK8sInstaller, rpmPkg string) Step {
+	return NewRpmStepEx(k, rpmPkg, true)
+}
+
+// NewRpmStepEx step to install rpm packages
+func NewRpmStepEx(k *BaseK8sInstaller, rpmPkg string, optional bool) Step {
+	pkgName := strings.Split(rpmPkg, ".")[0] // leave only pkg name, strip .rpm
+	pkgAbsolutePath := filepath.Join(k.BundlePath, rpmPkg)
+
+	condCmd := "%s"
+	if optional {
+		condCmd = fmt.Sprintf("if [ -f %s ]; then %%s; fi", pkgAbsolutePath)
+	}
+	// yum resolves the dependencies of the local package from the OS repositories.
+	// The bundle packages are not part of the OS repositories, so unlike apt-mark hold
+	// nothing is needed to prevent them from being upgraded unexpectedly.
+	doCmd := fmt.Sprintf("yum install -y '%s'", pkgAbsolutePath)
+	undoCmd := fmt.Sprintf("yum remove -y %s", pkgName)
+
+	return &ShellStep{
+		BaseK8sInstaller: k,
+		Desc:             pkgName,
+		DoCmd:            fmt.Sprintf(condCmd, doCmd),
+		UndoCmd:          fmt.Sprintf(condCmd, undoCmd)}
+}
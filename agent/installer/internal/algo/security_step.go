@@ -0,0 +1,34 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package algo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// selinuxLabeledDirs are the directories of containerd and kubelet which need the file contexts of the
+// container-selinux policy, e.g. container_file_t, on the hosts with SELinux enforcing
+var selinuxLabeledDirs = []string{
+	"/etc/kubernetes",
+	"/etc/cni/net.d",
+	"/opt/cni/bin",
+	"/var/lib/kubelet",
+	"/var/lib/containerd",
+	"/var/lib/etcd",
+}
+
+// securityModulesStep sets the SELinux file contexts of the directories of containerd and kubelet,
+// created beforehand so that they are labeled from the start, on the hosts with SELinux enforcing.
+// It does nothing on the other hosts, the AppArmor profile of the containers being loaded by containerd.
+func securityModulesStep(bki *BaseK8sInstaller) Step {
+	dirs := strings.Join(selinuxLabeledDirs, " ")
+	return &ShellStep{
+		BaseK8sInstaller: bki,
+		Desc:             "SECURITY MODULES",
+		DoCmd: fmt.Sprintf(`if [ "$(cat /sys/fs/selinux/enforce 2>/dev/null)" = 1 ]; then mkdir -p %s && restorecon -R %s; fi`,
+			dirs, dirs),
+		// the file contexts are those of the policy, they are left as is
+		UndoCmd: "true"}
+}
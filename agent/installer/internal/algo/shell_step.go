@@ -5,7 +5,7 @@ package algo
 
 import (
 	"bytes"
-	"os/exec"
+	"time"
 )
 
 // ShellStep step that executes a shell command
@@ -34,16 +34,20 @@ func (s *ShellStep) runStep(command string) error {
 	const defaultShell = "bash"
 
 	// TODO: check for exit(-1) or similar code
-	cmd := exec.Command(defaultShell, "-c", command)
+	cmd := s.Escalator.Command(defaultShell, "-c", command)
 	s.OutputBuilder.Cmd(cmd.String())
 
-	if s.BundlePath == "" {
+	if s.BundlePath == "" || s.DryRun {
 		return nil
 	}
 
 	cmd.Stdout = &stdOut
 	cmd.Stderr = &stdErr
+	start := time.Now()
 	err := cmd.Run()
+	if s.Auditor != nil {
+		s.Auditor.Record(NewCommandRecord(s.Desc, command, start, err, stdOut.String(), stdErr.String()))
+	}
 
 	if len(stdErr.String()) > 0 {
 		/*
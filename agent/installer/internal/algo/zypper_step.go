@@ -0,0 +1,52 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package algo
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// NewZypperStep returns a new step to install rpm package with zypper
+func NewZypperStep(k *BaseK8sInstaller, rpmPkg string) Step {
+	return NewZypperStepEx(k, rpmPkg, false)
+}
+
+// NewZypperStepOptional optional step to install rpm package with zypper
+func NewZypperStepOptional(k *BaseK8sInstaller, rpmPkg string) Step {
+	return NewZypperStepEx(k, rpmPkg, true)
+}
+
+// NewZypperStepEx step to install rpm packages with zypper.
+// On the hosts with a read-only root file system (SLE Micro, openSUSE Leap Micro and MicroOS)
+// the package is installed in a new snapshot with transactional-update, which is applied
+// right away so that the next steps see the package.
+func NewZypperStepEx(k *BaseK8sInstaller, rpmPkg string, optional bool) Step {
+	pkgName := strings.Split(rpmPkg, ".")[0] // leave only pkg name, strip .rpm
+	pkgAbsolutePath := filepath.Join(k.BundlePath, rpmPkg)
+
+	condCmd := "%s"
+	if optional {
+		condCmd = fmt.Sprintf("if [ -f %s ]; then %%s; fi", pkgAbsolutePath)
+	}
+	transactionalCmd := "if command -v transactional-update > /dev/null; then %s; else %s; fi"
+
+	// zypper addlock will prevent the package from being automatically upgraded or removed,
+	// the equivalent of apt-mark hold. If needed the lock can be manually removed.
+	doCmd := fmt.Sprintf(transactionalCmd,
+		fmt.Sprintf("transactional-update --non-interactive pkg install --allow-unsigned-rpm '%s' && transactional-update apply", pkgAbsolutePath),
+		fmt.Sprintf("zypper --non-interactive install --allow-unsigned-rpm '%s'", pkgAbsolutePath)) +
+		fmt.Sprintf(" && zypper addlock %s", pkgName)
+	undoCmd := fmt.Sprintf("zypper removelock %s && ", pkgName) +
+		fmt.Sprintf(transactionalCmd,
+			fmt.Sprintf("transactional-update --non-interactive pkg remove %s && transactional-update apply", pkgName),
+			fmt.Sprintf("zypper --non-interactive remove %s", pkgName))
+
+	return &ShellStep{
+		BaseK8sInstaller: k,
+		Desc:             pkgName,
+		DoCmd:            fmt.Sprintf(condCmd, doCmd),
+		UndoCmd:          fmt.Sprintf(condCmd, undoCmd)}
+}
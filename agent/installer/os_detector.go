@@ -13,8 +13,13 @@ import (
 
 const (
 	osNotDetected = "could not detect OS correctly"
+	// rollingReleaseVersion is the version of the rolling release OS, which have none
+	rollingReleaseVersion = "rolling"
 )
 
+// rollingReleaseOS are the OS without a version in the hostnamectl output
+var rollingReleaseOS = []string{"openSUSE MicroOS", "openSUSE Tumbleweed"}
+
 // oSDetector contains all the logic for detecting the OS version.
 type osDetector struct {
 	cachedNormalizedOS string
@@ -41,6 +46,9 @@ func (osd *osDetector) DetectByHostnamectl(f func() (string, error)) (string, er
 	os := osDetails[0]
 	ver := osDetails[1]
 	arch := osDetails[2]
+	if ver == "" && isRollingRelease(os) {
+		ver = rollingReleaseVersion
+	}
 	if os == "" || ver == "" || arch == "" {
 		return "", errors.New(osNotDetected)
 	}
@@ -49,6 +57,15 @@ func (osd *osDetector) DetectByHostnamectl(f func() (string, error)) (string, er
 	return osd.cachedNormalizedOS, nil
 }
 
+func isRollingRelease(os string) bool {
+	for _, rollingOS := range rollingReleaseOS {
+		if os == rollingOS {
+			return true
+		}
+	}
+	return false
+}
+
 // normalizeOsName normalizes given os, arch and k8s version to the correct format.
 // Takes as arguments os, ver and arch then returns string in the format <os>_<ver>_<arch>
 func normalizeOSName(os, ver, arch string) string {
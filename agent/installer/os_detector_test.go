@@ -71,6 +71,31 @@ var _ = Describe("Byohost Installer Tests", func() {
 			Expect(detectedOS).To(Equal("Red_Hat_Enterprise_Linux_8.1_x86-64"))
 		})
 
+		It("Should return string in normalized format for arm64 hosts", func() {
+			arch = "arm64"
+			detectedOS, err = d.DetectByHostnamectl(func() (string, error) { return mh.Get(os, ver, arch) })
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(detectedOS).To(Equal("Ubuntu_20.04.3_arm64"))
+		})
+
+		It("Should return string in normalized format for rolling release OS", func() {
+			detectedOS, err = d.DetectByHostnamectl(func() (string, error) {
+				return " Operating System: openSUSE MicroOS\n" +
+					"     Architecture: x86-64\n", nil
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(detectedOS).To(Equal("openSUSE_MicroOS_rolling_x86-64"))
+		})
+
+		It("Should return string in normalized format for immutable OS", func() {
+			detectedOS, err = d.DetectByHostnamectl(func() (string, error) {
+				return " Operating System: Flatcar Container Linux by Kinvolk 3139.2.0 (Oklo)\n" +
+					"     Architecture: x86-64\n", nil
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(detectedOS).To(Equal("Flatcar_Container_Linux_by_Kinvolk_3139.2.0_x86-64"))
+		})
+
 		It("Should not error with real hostnamectl", func() {
 			_, err = d.Detect()
 			Expect(err).ShouldNot(HaveOccurred())
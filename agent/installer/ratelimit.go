@@ -0,0 +1,57 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package installer
+
+import (
+	"context"
+	"io"
+
+	"golang.org/x/time/rate"
+)
+
+// maxRateLimitBurst is the largest read of a rate limited download, so that the bandwidth
+// stays close to the rate limit rather than coming in bursts
+const maxRateLimitBurst = 64 * 1024
+
+// newRateLimiter returns the limiter of the bundle downloads of bytesPerSecond, nil when
+// the downloads are not rate limited
+func newRateLimiter(bytesPerSecond int64) *rate.Limiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+	burst := bytesPerSecond
+	if burst > maxRateLimitBurst {
+		burst = maxRateLimitBurst
+	}
+	return rate.NewLimiter(rate.Limit(bytesPerSecond), int(burst))
+}
+
+// rateLimitedReader throttles the reads of a download with the limiter shared by the bundle downloads
+type rateLimitedReader struct {
+	ctx     context.Context
+	reader  io.Reader
+	limiter *rate.Limiter
+}
+
+// rateLimited returns reader throttled by limiter, or reader itself when limiter is nil
+func rateLimited(ctx context.Context, reader io.Reader, limiter *rate.Limiter) io.Reader {
+	if limiter == nil {
+		return reader
+	}
+	return &rateLimitedReader{ctx: ctx, reader: reader, limiter: limiter}
+}
+
+// Read implements io.Reader, waiting for the limiter to allow the bytes read
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	if len(p) > r.limiter.Burst() {
+		p = p[:r.limiter.Burst()]
+	}
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		if waitErr := r.limiter.WaitN(r.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
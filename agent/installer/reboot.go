@@ -0,0 +1,69 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package installer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// rebootRequiredFile is created by the packages, e.g. a kernel upgrade, requiring a reboot on Debian and Ubuntu
+	rebootRequiredFile = "/var/run/reboot-required"
+	// grubDefaultsFile holds the kernel command line of the next boots
+	grubDefaultsFile = "/etc/default/grub"
+	// cgroupV2KernelArg switches systemd to the unified cgroup hierarchy
+	cgroupV2KernelArg = "systemd.unified_cgroup_hierarchy=1"
+)
+
+// RebootRequiredError is returned by Install when the kubernetes components are installed, but the host
+// has to reboot before they can run, e.g. after a kernel upgrade or a switch to cgroup v2. Installing
+// them again after the reboot completes the installation.
+type RebootRequiredError struct {
+	// Reason tells why the host has to reboot
+	Reason string
+}
+
+func (e *RebootRequiredError) Error() string {
+	return "host reboot required: " + e.Reason
+}
+
+// rebootChecker detects the changes of the host which only take effect on reboot
+type rebootChecker struct {
+	// root is the root directory of the host, / when empty
+	root string
+}
+
+func (c rebootChecker) path(path string) string {
+	return filepath.Join(c.root, path)
+}
+
+// check returns why the host has to reboot, or an empty string when it does not
+func (c rebootChecker) check() (string, error) {
+	if _, err := os.Stat(c.path(rebootRequiredFile)); err == nil {
+		return "the packages installed on the host require a reboot", nil
+	}
+
+	release, err := os.ReadFile(c.path("/proc/sys/kernel/osrelease"))
+	if err != nil {
+		return "", err
+	}
+	kernel := strings.TrimSpace(string(release))
+	if _, err := os.Stat(c.path(filepath.Join("/lib/modules", kernel))); os.IsNotExist(err) {
+		return fmt.Sprintf("the kernel modules of the running kernel %s are not installed, the kernel was upgraded", kernel), nil
+	}
+
+	grub, err := os.ReadFile(c.path(grubDefaultsFile))
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	if strings.Contains(string(grub), cgroupV2KernelArg) {
+		if _, err := os.Stat(c.path("/sys/fs/cgroup/cgroup.controllers")); os.IsNotExist(err) {
+			return "cgroup v2 is enabled on the kernel command line of the next boot", nil
+		}
+	}
+	return "", nil
+}
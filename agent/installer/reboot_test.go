@@ -0,0 +1,79 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// nolint: testpackage
+package installer
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Reboot checker", func() {
+	var (
+		root    string
+		checker rebootChecker
+	)
+
+	writeFile := func(path, content string) {
+		path = filepath.Join(root, path)
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		root, err = os.MkdirTemp("", "reboot")
+		Expect(err).NotTo(HaveOccurred())
+		checker = rebootChecker{root: root}
+		writeFile("/proc/sys/kernel/osrelease", "5.4.0-107-generic\n")
+		Expect(os.MkdirAll(filepath.Join(root, "/lib/modules/5.4.0-107-generic"), 0755)).To(Succeed())
+		writeFile("/sys/fs/cgroup/cgroup.controllers", "cpu memory")
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(root)).To(Succeed())
+	})
+
+	It("should not require a reboot of an up to date host", func() {
+		reason, err := checker.check()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reason).To(BeEmpty())
+	})
+
+	It("should require a reboot when the packages request it", func() {
+		writeFile(rebootRequiredFile, "*** System restart required ***\n")
+
+		reason, err := checker.check()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reason).To(ContainSubstring("packages"))
+	})
+
+	It("should require a reboot when the modules of the running kernel are gone", func() {
+		Expect(os.RemoveAll(filepath.Join(root, "/lib/modules/5.4.0-107-generic"))).To(Succeed())
+
+		reason, err := checker.check()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reason).To(ContainSubstring("5.4.0-107-generic"))
+	})
+
+	It("should require a reboot to switch to cgroup v2", func() {
+		writeFile(grubDefaultsFile, `GRUB_CMDLINE_LINUX="`+cgroupV2KernelArg+`"`)
+		Expect(os.Remove(filepath.Join(root, "/sys/fs/cgroup/cgroup.controllers"))).To(Succeed())
+
+		reason, err := checker.check()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reason).To(ContainSubstring("cgroup v2"))
+	})
+
+	It("should not require a reboot once running cgroup v2", func() {
+		writeFile(grubDefaultsFile, `GRUB_CMDLINE_LINUX="`+cgroupV2KernelArg+`"`)
+
+		reason, err := checker.check()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reason).To(BeEmpty())
+	})
+})
@@ -116,4 +116,28 @@ var _ = Describe("Byohost Installer Tests", func() {
 			Expect(osBundle).To(Equal(""))
 		})
 	})
+
+	Context("When the bundle repositories are listed", func() {
+		It("Should list a repository for each OS bundle", func() {
+			repositories := BundleRepositories(BundleTypeK8s, "registry.example.com/byoh")
+			Expect(repositories).To(HaveKeyWithValue("Ubuntu_20.04.1_x86-64", "registry.example.com/byoh/byoh-bundle-ubuntu_20.04.1_x86-64_k8s"))
+			Expect(repositories).To(HaveKeyWithValue("Ubuntu_20.04.1_arm64", "registry.example.com/byoh/byoh-bundle-ubuntu_20.04.1_arm64_k8s"))
+		})
+		It("Should list a repository for each architecture of the rke2 bundles", func() {
+			repositories := BundleRepositories(BundleTypeRKE2, "registry.example.com/byoh")
+			Expect(repositories).To(HaveKeyWithValue("x86-64", "registry.example.com/byoh/byoh-bundle-x86-64_rke2"))
+			Expect(repositories).To(HaveLen(2))
+		})
+		It("Should only support the K8s versions of the installers", func() {
+			Expect(SupportsK8s(BundleTypeK8s, "Ubuntu_20.04.1_x86-64", "v1.22.3")).To(BeTrue())
+			Expect(SupportsK8s(BundleTypeK8s, "Ubuntu_20.04.1_x86-64", "v1.19.3")).To(BeFalse())
+			Expect(SupportsK8s(BundleTypeK8s, "Ubuntu_18.04.1_x86-64", "v1.22.3")).To(BeFalse())
+			Expect(SupportsK8s(BundleTypeRKE2, "arm64", "v1.23.6+rke2r2")).To(BeTrue())
+		})
+		It("Should resolve the OS bundle of a host", func() {
+			Expect(ResolveOsBundle(BundleTypeK8s, "Ubuntu_20.04.4_LTS_x86-64")).To(Equal("Ubuntu_20.04.1_x86-64"))
+			Expect(ResolveOsBundle(BundleTypeRKE2, "Ubuntu_20.04.4_LTS_x86-64")).To(Equal("x86-64"))
+			Expect(ResolveOsBundle(BundleTypeK8s, "Photon_4.0_x86-64")).To(Equal(""))
+		})
+	})
 })
@@ -0,0 +1,41 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// kubeletRootDirPrefix prefixes the kubelet data directory of an instance of the machine
+const kubeletRootDirPrefix = "/var/lib/kubelet-"
+
+// applyHostInstanceSuffix isolates an agent instance from the other instances of its machine: the
+// ByoHost, and the node registered by its kubelet, are named after the host name with the instance
+// suffix, and the bundles are downloaded into a subdirectory of the download path. It returns the
+// name of the ByoHost of the instance.
+func applyHostInstanceSuffix(hostName, suffix string) (string, error) {
+	if errs := validation.IsDNS1123Label(suffix); len(errs) > 0 {
+		return "", errors.New(strings.Join(errs, ", "))
+	}
+	hostName = hostName + "-" + suffix
+	if errs := validation.IsDNS1123Subdomain(hostName); len(errs) > 0 {
+		return "", errors.New(strings.Join(errs, ", "))
+	}
+	hostnameOverride = hostName
+	downloadpath = filepath.Join(downloadpath, suffix)
+	return hostName, nil
+}
+
+// instanceKubeletRootDir returns the kubelet data directory of the instance of the machine, empty
+// for the kubelet default when the agent is not an instance
+func instanceKubeletRootDir(suffix string) string {
+	if suffix == "" {
+		return ""
+	}
+	return kubeletRootDirPrefix + suffix
+}
@@ -0,0 +1,36 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// nolint: testpackage
+package main
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Host instances", func() {
+	BeforeEach(func() {
+		hostnameOverride = ""
+		downloadpath = "/var/lib/byoh/bundles"
+	})
+
+	It("should isolate the ByoHost and the downloads of the instance", func() {
+		hostName, err := applyHostInstanceSuffix("lab-server", "3")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hostName).To(Equal("lab-server-3"))
+		Expect(hostnameOverride).To(Equal("lab-server-3"))
+		Expect(downloadpath).To(Equal("/var/lib/byoh/bundles/3"))
+		Expect(instanceKubeletRootDir("3")).To(Equal("/var/lib/kubelet-3"))
+	})
+
+	It("should reject a suffix which is not a DNS label", func() {
+		_, err := applyHostInstanceSuffix("lab-server", "Instance_1")
+		Expect(err).To(HaveOccurred())
+		Expect(downloadpath).To(Equal("/var/lib/byoh/bundles"))
+	})
+
+	It("should keep the kubelet default outside of the instances", func() {
+		Expect(instanceKubeletRootDir("")).To(BeEmpty())
+	})
+})
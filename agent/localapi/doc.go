@@ -0,0 +1,7 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package localapi contains the local API of the host agent, served on a Unix socket so that
+// the node-local tools can query and operate the agent without parsing its logs or reaching
+// the management cluster.
+package localapi
@@ -0,0 +1,259 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package localapi
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-logr/logr"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+const (
+	// StatusPath returns the Status of the agent
+	StatusPath = "/status"
+	// ConditionsPath returns the conditions of the ByoHost
+	ConditionsPath = "/conditions"
+	// TriggerReconcilePath reconciles the ByoHost on POST
+	TriggerReconcilePath = "/trigger-reconcile"
+	// DrainPath takes the host out of the capacity pool on POST, and returns it on DELETE
+	DrainPath = "/drain"
+
+	// socketPermissions let the group of the agent use the API
+	socketPermissions = 0o660
+	shutdownTimeout   = 10 * time.Second
+)
+
+// Status is the status of the agent and its ByoHost
+type Status struct {
+	Host         string `json:"host"`
+	Namespace    string `json:"namespace"`
+	AgentVersion string `json:"agentVersion"`
+	// Offline is true while the management cluster is unreachable, the ByoHost is then the last one seen
+	Offline bool `json:"offline"`
+	// Unschedulable is true when the host is out of the capacity pool, e.g. drained with DrainPath
+	Unschedulable bool `json:"unschedulable"`
+	// Bootstrapped is the status of the K8sNodeBootstrapSucceeded condition of the ByoHost
+	Bootstrapped      corev1.ConditionStatus  `json:"bootstrapped"`
+	MachineRef        *corev1.ObjectReference `json:"machineRef,omitempty"`
+	LastHeartbeatTime *metav1.Time            `json:"lastHeartbeatTime,omitempty"`
+}
+
+// errorResponse is the body of the failed requests
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Server serves the local API of the agent on a Unix socket
+type Server struct {
+	// SocketPath is the path of the Unix socket, a stale socket left there is replaced
+	SocketPath string
+	// Client reads and patches the ByoHost of the agent
+	Client       client.Client
+	Host         types.NamespacedName
+	AgentVersion string
+	// Offline tells whether the management cluster is unreachable, it is never offline when nil
+	Offline func() bool
+	// Trigger receives the ByoHost to reconcile on TriggerReconcilePath
+	Trigger chan<- event.GenericEvent
+	Logger  logr.Logger
+}
+
+// Start implements manager.Runnable, serving the API until ctx is done
+func (s *Server) Start(ctx context.Context) error {
+	if err := os.MkdirAll(filepath.Dir(s.SocketPath), 0o755); err != nil {
+		return err
+	}
+	if err := os.Remove(s.SocketPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	listener, err := net.Listen("unix", s.SocketPath)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(s.SocketPath, socketPermissions); err != nil {
+		listener.Close()
+		return err
+	}
+	return s.serve(ctx, listener)
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, the API is served by every agent
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+func (s *Server) serve(ctx context.Context, listener net.Listener) error {
+	server := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: shutdownTimeout,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			s.Logger.Error(err, "error shutting down the local API")
+		}
+	}()
+
+	s.Logger.Info("Serving the local API", "socket", s.SocketPath)
+	if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// Handler returns the handler of the API endpoints
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(StatusPath, s.handleStatus)
+	mux.HandleFunc(ConditionsPath, s.handleConditions)
+	mux.HandleFunc(TriggerReconcilePath, s.handleTriggerReconcile)
+	mux.HandleFunc(DrainPath, s.handleDrain)
+	return mux
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, req *http.Request) {
+	if !allowMethods(w, req, http.MethodGet) {
+		return
+	}
+	byoHost, err := s.getByoHost(req.Context())
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.status(byoHost))
+}
+
+func (s *Server) handleConditions(w http.ResponseWriter, req *http.Request) {
+	if !allowMethods(w, req, http.MethodGet) {
+		return
+	}
+	byoHost, err := s.getByoHost(req.Context())
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	hostConditions := byoHost.Status.Conditions
+	if hostConditions == nil {
+		hostConditions = clusterv1.Conditions{}
+	}
+	writeJSON(w, http.StatusOK, hostConditions)
+}
+
+func (s *Server) handleTriggerReconcile(w http.ResponseWriter, req *http.Request) {
+	if !allowMethods(w, req, http.MethodPost) {
+		return
+	}
+	byoHost := &infrastructurev1beta1.ByoHost{
+		ObjectMeta: metav1.ObjectMeta{Namespace: s.Host.Namespace, Name: s.Host.Name},
+	}
+	select {
+	case s.Trigger <- event.GenericEvent{Object: byoHost}:
+		s.Logger.Info("Reconcile triggered through the local API")
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		// a reconcile is already pending
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+func (s *Server) handleDrain(w http.ResponseWriter, req *http.Request) {
+	if !allowMethods(w, req, http.MethodPost, http.MethodDelete) {
+		return
+	}
+	byoHost, err := s.getByoHost(req.Context())
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	// the host agent cannot change the spec of its ByoHost, it sets the UnschedulableAnnotation instead
+	unschedulable := req.Method == http.MethodPost
+	if hasUnschedulableAnnotation(byoHost) != unschedulable {
+		patch := client.MergeFrom(byoHost.DeepCopy())
+		if unschedulable {
+			if byoHost.Annotations == nil {
+				byoHost.Annotations = map[string]string{}
+			}
+			byoHost.Annotations[infrastructurev1beta1.UnschedulableAnnotation] = ""
+		} else {
+			delete(byoHost.Annotations, infrastructurev1beta1.UnschedulableAnnotation)
+		}
+		if err := s.Client.Patch(req.Context(), byoHost, patch); err != nil {
+			writeError(w, http.StatusServiceUnavailable, err)
+			return
+		}
+		s.Logger.Info("Set the host schedulability through the local API", "unschedulable", unschedulable)
+	}
+	writeJSON(w, http.StatusOK, s.status(byoHost))
+}
+
+func (s *Server) getByoHost(ctx context.Context) (*infrastructurev1beta1.ByoHost, error) {
+	byoHost := &infrastructurev1beta1.ByoHost{}
+	if err := s.Client.Get(ctx, s.Host, byoHost); err != nil {
+		return nil, err
+	}
+	return byoHost, nil
+}
+
+func (s *Server) status(byoHost *infrastructurev1beta1.ByoHost) *Status {
+	status := &Status{
+		Host:              s.Host.Name,
+		Namespace:         s.Host.Namespace,
+		AgentVersion:      s.AgentVersion,
+		Offline:           s.Offline != nil && s.Offline(),
+		Unschedulable:     byoHost.Spec.Unschedulable || hasUnschedulableAnnotation(byoHost),
+		MachineRef:        byoHost.Status.MachineRef,
+		LastHeartbeatTime: byoHost.Status.LastHeartbeatTime,
+		Bootstrapped:      corev1.ConditionUnknown,
+	}
+	if condition := conditions.Get(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded); condition != nil {
+		status.Bootstrapped = condition.Status
+	}
+	return status
+}
+
+func hasUnschedulableAnnotation(byoHost *infrastructurev1beta1.ByoHost) bool {
+	_, ok := byoHost.Annotations[infrastructurev1beta1.UnschedulableAnnotation]
+	return ok
+}
+
+// allowMethods writes a 405 response when the method of the request is not one of methods
+func allowMethods(w http.ResponseWriter, req *http.Request, methods ...string) bool {
+	for _, method := range methods {
+		if req.Method == method {
+			return true
+		}
+	}
+	for _, method := range methods {
+		w.Header().Add("Allow", method)
+	}
+	w.WriteHeader(http.StatusMethodNotAllowed)
+	return false
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, &errorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,214 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package localapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/localapi"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/test/builder"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+var _ = Describe("Local API", func() {
+	var (
+		ctx     context.Context
+		byoHost *infrastructurev1beta1.ByoHost
+		c       client.Client
+		trigger chan event.GenericEvent
+		offline bool
+		server  *localapi.Server
+	)
+
+	request := func(method, path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		server.Handler().ServeHTTP(recorder, httptest.NewRequest(method, path, http.NoBody))
+		return recorder
+	}
+
+	BeforeEach(func() {
+		ctx = context.TODO()
+		scheme := runtime.NewScheme()
+		Expect(infrastructurev1beta1.AddToScheme(scheme)).To(Succeed())
+		byoHost = builder.ByoHost("default", "edge-host").Build()
+		byoHost.Name = "edge-host"
+		byoHost.Status.MachineRef = &corev1.ObjectReference{Kind: "ByoMachine", Namespace: "default", Name: "edge-machine"}
+		conditions.MarkTrue(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded)
+		c = fake.NewClientBuilder().WithScheme(scheme).WithObjects(byoHost).Build()
+		trigger = make(chan event.GenericEvent, 1)
+		offline = false
+		server = &localapi.Server{
+			Client:       c,
+			Host:         client.ObjectKeyFromObject(byoHost),
+			AgentVersion: "v0.3.0",
+			Offline:      func() bool { return offline },
+			Trigger:      trigger,
+			Logger:       logr.Discard(),
+		}
+	})
+
+	It("should return the status of the agent and its ByoHost", func() {
+		offline = true
+		response := request(http.MethodGet, localapi.StatusPath)
+		Expect(response.Code).To(Equal(http.StatusOK))
+		var status localapi.Status
+		Expect(json.Unmarshal(response.Body.Bytes(), &status)).To(Succeed())
+		Expect(status).To(Equal(localapi.Status{
+			Host:         "edge-host",
+			Namespace:    "default",
+			AgentVersion: "v0.3.0",
+			Offline:      true,
+			Bootstrapped: corev1.ConditionTrue,
+			MachineRef:   byoHost.Status.MachineRef,
+		}))
+	})
+
+	It("should return the conditions of the ByoHost", func() {
+		response := request(http.MethodGet, localapi.ConditionsPath)
+		Expect(response.Code).To(Equal(http.StatusOK))
+		var hostConditions clusterv1.Conditions
+		Expect(json.Unmarshal(response.Body.Bytes(), &hostConditions)).To(Succeed())
+		Expect(hostConditions).To(HaveLen(1))
+		Expect(hostConditions[0].Type).To(Equal(infrastructurev1beta1.K8sNodeBootstrapSucceeded))
+	})
+
+	It("should trigger a reconcile of the ByoHost", func() {
+		Expect(request(http.MethodPost, localapi.TriggerReconcilePath).Code).To(Equal(http.StatusAccepted))
+		// a reconcile is already pending
+		Expect(request(http.MethodPost, localapi.TriggerReconcilePath).Code).To(Equal(http.StatusAccepted))
+
+		var triggered event.GenericEvent
+		Expect(trigger).To(Receive(&triggered))
+		Expect(client.ObjectKeyFromObject(triggered.Object)).To(Equal(client.ObjectKeyFromObject(byoHost)))
+		Expect(trigger).NotTo(Receive())
+	})
+
+	It("should take the host out of the capacity pool and return it", func() {
+		response := request(http.MethodPost, localapi.DrainPath)
+		Expect(response.Code).To(Equal(http.StatusOK))
+		var status localapi.Status
+		Expect(json.Unmarshal(response.Body.Bytes(), &status)).To(Succeed())
+		Expect(status.Unschedulable).To(BeTrue())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(byoHost), byoHost)).To(Succeed())
+		Expect(byoHost.Annotations).To(HaveKey(infrastructurev1beta1.UnschedulableAnnotation))
+		Expect(byoHost.Spec.Unschedulable).To(BeFalse())
+
+		response = request(http.MethodDelete, localapi.DrainPath)
+		Expect(response.Code).To(Equal(http.StatusOK))
+		Expect(json.Unmarshal(response.Body.Bytes(), &status)).To(Succeed())
+		Expect(status.Unschedulable).To(BeFalse())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(byoHost), byoHost)).To(Succeed())
+		Expect(byoHost.Annotations).NotTo(HaveKey(infrastructurev1beta1.UnschedulableAnnotation))
+	})
+
+	Context("When the requests of the host agent are validated by the ByoHost webhook", func() {
+		BeforeEach(func() {
+			byoHost = builder.ByoHost("default", agentHostName).Build()
+			byoHost.Name = agentHostName
+			Expect(k8sClient.Create(ctx, byoHost)).To(Succeed())
+			server.Client = hostAgentClient
+			server.Host = client.ObjectKeyFromObject(byoHost)
+		})
+
+		AfterEach(func() {
+			Expect(k8sClient.Delete(ctx, byoHost)).To(Succeed())
+		})
+
+		It("should take the host out of the capacity pool and return it", func() {
+			response := request(http.MethodPost, localapi.DrainPath)
+			Expect(response.Code).To(Equal(http.StatusOK), response.Body.String())
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(byoHost), byoHost)).To(Succeed())
+			Expect(byoHost.Annotations).To(HaveKey(infrastructurev1beta1.UnschedulableAnnotation))
+
+			response = request(http.MethodDelete, localapi.DrainPath)
+			Expect(response.Code).To(Equal(http.StatusOK), response.Body.String())
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(byoHost), byoHost)).To(Succeed())
+			Expect(byoHost.Annotations).NotTo(HaveKey(infrastructurev1beta1.UnschedulableAnnotation))
+		})
+
+		It("should not be allowed to set the spec of the host", func() {
+			patch := client.MergeFrom(byoHost.DeepCopy())
+			byoHost.Spec.Unschedulable = true
+			err := hostAgentClient.Patch(ctx, byoHost, patch)
+			Expect(err).To(MatchError(ContainSubstring("host agent can only remove the bootstrap secret from the spec")))
+		})
+	})
+
+	It("should reject the other methods", func() {
+		response := request(http.MethodDelete, localapi.StatusPath)
+		Expect(response.Code).To(Equal(http.StatusMethodNotAllowed))
+		Expect(response.Header().Get("Allow")).To(Equal(http.MethodGet))
+		Expect(request(http.MethodGet, localapi.TriggerReconcilePath).Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+
+	It("should return an error when the ByoHost cannot be read", func() {
+		server.Host.Name = "other-host"
+		response := request(http.MethodGet, localapi.StatusPath)
+		Expect(response.Code).To(Equal(http.StatusServiceUnavailable))
+		Expect(response.Body.String()).To(ContainSubstring(`"error"`))
+	})
+
+	Context("When the API is served on a Unix socket", func() {
+		var socketDir string
+
+		BeforeEach(func() {
+			var err error
+			// the Unix socket paths are limited to about 100 characters
+			socketDir, err = os.MkdirTemp("/tmp", "byoh")
+			Expect(err).NotTo(HaveOccurred())
+			server.SocketPath = filepath.Join(socketDir, "agent.sock")
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(socketDir)).To(Succeed())
+		})
+
+		It("should replace a stale socket and serve the API until stopped", func() {
+			Expect(os.WriteFile(server.SocketPath, nil, 0o600)).To(Succeed())
+			serverCtx, cancel := context.WithCancel(ctx)
+			done := make(chan error)
+			go func() {
+				done <- server.Start(serverCtx)
+			}()
+
+			httpClient := &http.Client{Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return (&net.Dialer{}).DialContext(ctx, "unix", server.SocketPath)
+				},
+			}}
+			Eventually(func() (int, error) {
+				response, err := httpClient.Get("http://localhost" + localapi.StatusPath)
+				if err != nil {
+					return 0, err
+				}
+				defer response.Body.Close()
+				return response.StatusCode, nil
+			}).Should(Equal(http.StatusOK))
+
+			info, err := os.Stat(server.SocketPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode()&os.ModeSocket).NotTo(BeZero())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0o660)))
+
+			cancel()
+			Eventually(done).Should(Receive(BeNil()))
+		})
+	})
+})
@@ -0,0 +1,126 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// jsonSink writes the entries as JSON lines, one object per entry with the ts, level, v, logger,
+// caller, msg and error fields, and a field per key and value
+type jsonSink struct {
+	out       *lockedWriter
+	verbosity *ModuleVerbosity
+	name      string
+	values    []interface{}
+	callDepth int
+}
+
+// lockedWriter serializes the writes of the entries of the loggers sharing it
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONLogger returns a logger writing its entries to out as JSON lines, with the verbosity of
+// its module, or the klog verbosity
+func NewJSONLogger(out io.Writer, verbosity *ModuleVerbosity) logr.Logger {
+	return logr.New(&jsonSink{out: &lockedWriter{w: out}, verbosity: verbosity})
+}
+
+// Init implements logr.LogSink
+func (s *jsonSink) Init(info logr.RuntimeInfo) {
+	s.callDepth += info.CallDepth
+}
+
+// Enabled implements logr.LogSink
+func (s *jsonSink) Enabled(level int) bool {
+	return s.verbosity.Enabled(s.name, level)
+}
+
+// Info implements logr.LogSink
+func (s *jsonSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	s.write(map[string]interface{}{"level": "info", "v": level, "msg": msg}, keysAndValues)
+}
+
+// Error implements logr.LogSink
+func (s *jsonSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	fields := map[string]interface{}{"level": "error", "msg": msg}
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+	s.write(fields, keysAndValues)
+}
+
+// WithValues implements logr.LogSink
+func (s *jsonSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	sink := *s
+	sink.values = append(append([]interface{}{}, s.values...), keysAndValues...)
+	return &sink
+}
+
+// WithName implements logr.LogSink
+func (s *jsonSink) WithName(name string) logr.LogSink {
+	sink := *s
+	sink.name = name
+	if s.name != "" {
+		sink.name = s.name + "/" + name
+	}
+	return &sink
+}
+
+// WithCallDepth implements logr.CallDepthLogSink
+func (s *jsonSink) WithCallDepth(depth int) logr.LogSink {
+	sink := *s
+	sink.callDepth += depth
+	return &sink
+}
+
+// write writes the entry with the fields, the values of the logger and the keys and values,
+// the fields of the entry taking precedence
+func (s *jsonSink) write(fields map[string]interface{}, keysAndValues []interface{}) {
+	entry := map[string]interface{}{}
+	values := append(append([]interface{}{}, s.values...), keysAndValues...)
+	for i := 0; i+1 < len(values); i += 2 {
+		entry[fmt.Sprint(values[i])] = jsonValue(values[i+1])
+	}
+	for key, value := range fields {
+		entry[key] = value
+	}
+	entry["ts"] = time.Now().UTC().Format(time.RFC3339Nano)
+	if s.name != "" {
+		entry["logger"] = s.name
+	}
+	// the frames of write, of Info or Error and of logr.Logger are skipped
+	if _, file, line, ok := runtime.Caller(s.callDepth + 2); ok { // nolint: gomnd
+		entry["caller"] = filepath.Base(file) + ":" + strconv.Itoa(line)
+	}
+	encoded, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	s.out.mu.Lock()
+	defer s.out.mu.Unlock()
+	_, _ = s.out.w.Write(append(encoded, '\n'))
+}
+
+// jsonValue returns the value when it can be encoded to JSON, its string form otherwise
+func jsonValue(value interface{}) interface{} {
+	if err, ok := value.(error); ok {
+		return err.Error()
+	}
+	if _, err := json.Marshal(value); err != nil {
+		return fmt.Sprintf("%+v", value)
+	}
+	return value
+}
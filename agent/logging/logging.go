@@ -0,0 +1,78 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package logging sets up the logs of the host agent: JSON lines by default, for the host logs to be
+// ingested by log shippers like Fluent Bit without parsing, or the klog text. The verbosity is set per
+// module, and the JSON logs are also written to a local log file rotated by size.
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/go-logr/logr"
+	"gopkg.in/natefinch/lumberjack.v2"
+	klog "k8s.io/klog/v2"
+	"k8s.io/klog/v2/klogr"
+)
+
+const (
+	// FormatJSON logs the entries as JSON lines
+	FormatJSON = "json"
+	// FormatText logs the entries as the klog text
+	FormatText = "text"
+)
+
+const (
+	// DefaultFileMaxSize is the default size in megabytes the log file is rotated at
+	DefaultFileMaxSize = 100
+	// DefaultFileMaxBackups is the default number of rotated log files kept
+	DefaultFileMaxBackups = 5
+)
+
+// FileOptions configures the local log file
+type FileOptions struct {
+	// Path of the log file, no log file is written when empty
+	Path string
+	// MaxSize is the size in megabytes the log file is rotated at
+	MaxSize int
+	// MaxBackups is the number of rotated log files kept, all are kept when 0
+	MaxBackups int
+	// MaxAge is the number of days the rotated log files are kept, they are kept whatever their age when 0
+	MaxAge int
+}
+
+// New returns the logger of the agent in the format, writing to stderr, and to the log file when
+// set. In the JSON format, the klog logs of the libraries are written by the logger too.
+// The returned func closes the log file.
+func New(format string, verbosity *ModuleVerbosity, file FileOptions) (logr.Logger, func() error, error) {
+	switch format {
+	case FormatText:
+		if file.Path != "" {
+			return logr.Logger{}, nil, errors.New("the log file is only written in the json log format")
+		}
+		return logr.New(newModuleSink(klogr.New().GetSink(), verbosity)), func() error { return nil }, nil
+	case FormatJSON:
+		var out io.Writer = os.Stderr
+		closeFile := func() error { return nil }
+		if file.Path != "" {
+			// the rotated log files are compressed, and named after the time they are rotated at
+			rotated := &lumberjack.Logger{
+				Filename:   file.Path,
+				MaxSize:    file.MaxSize,
+				MaxBackups: file.MaxBackups,
+				MaxAge:     file.MaxAge,
+				Compress:   true,
+			}
+			out = io.MultiWriter(os.Stderr, rotated)
+			closeFile = rotated.Close
+		}
+		logger := NewJSONLogger(out, verbosity)
+		klog.SetLogger(logger)
+		return logger, closeFile, nil
+	default:
+		return logr.Logger{}, nil, fmt.Errorf("unknown log format %q, expect %s or %s", format, FormatJSON, FormatText)
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/logging"
+)
+
+// entries decodes the JSON lines of the logs
+func entries(logs string) []map[string]interface{} {
+	var decoded []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSuffix(logs, "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		entry := map[string]interface{}{}
+		Expect(json.Unmarshal([]byte(line), &entry)).To(Succeed())
+		decoded = append(decoded, entry)
+	}
+	return decoded
+}
+
+var _ = Describe("Logging", func() {
+	var (
+		out       *bytes.Buffer
+		verbosity *logging.ModuleVerbosity
+		logger    logr.Logger
+	)
+
+	BeforeEach(func() {
+		out = &bytes.Buffer{}
+		verbosity = &logging.ModuleVerbosity{}
+		logger = logging.NewJSONLogger(out, verbosity)
+	})
+
+	Context("When the agent logs in the JSON format", func() {
+		It("should write an entry per line with the structured fields", func() {
+			logger.WithName("offline").WithValues("host", "edge-host").Info("Management cluster unreachable", "attempt", 2)
+			logger.Error(errors.New("connect: no route to host"), "failed to patch ByoHost", "byoHost", "edge-host")
+
+			decoded := entries(out.String())
+			Expect(decoded).To(HaveLen(2))
+			Expect(decoded[0]).To(HaveKeyWithValue("level", "info"))
+			Expect(decoded[0]).To(HaveKeyWithValue("v", BeNumerically("==", 0)))
+			Expect(decoded[0]).To(HaveKeyWithValue("logger", "offline"))
+			Expect(decoded[0]).To(HaveKeyWithValue("msg", "Management cluster unreachable"))
+			Expect(decoded[0]).To(HaveKeyWithValue("host", "edge-host"))
+			Expect(decoded[0]).To(HaveKeyWithValue("attempt", BeNumerically("==", 2)))
+			Expect(decoded[0]).To(HaveKeyWithValue("caller", HavePrefix("logging_test.go:")))
+			Expect(decoded[0]).To(HaveKey("ts"))
+			Expect(decoded[1]).To(HaveKeyWithValue("level", "error"))
+			Expect(decoded[1]).To(HaveKeyWithValue("error", "connect: no route to host"))
+			Expect(decoded[1]).To(HaveKeyWithValue("byoHost", "edge-host"))
+		})
+
+		It("should not let the keys and values override the fields of the entry", func() {
+			logger.Info("installing k8s components", "msg", "overridden", "level", "error")
+
+			decoded := entries(out.String())
+			Expect(decoded).To(HaveLen(1))
+			Expect(decoded[0]).To(HaveKeyWithValue("msg", "installing k8s components"))
+			Expect(decoded[0]).To(HaveKeyWithValue("level", "info"))
+		})
+
+		It("should log the entries with the verbosity of their module", func() {
+			Expect(verbosity.Set("installer=4, offline=0")).To(Succeed())
+
+			logger.WithName("installer").WithName("rke2").V(4).Info("downloading the bundle")
+			logger.WithName("installer").V(5).Info("unpacking the bundle")
+			logger.WithName("heartbeat").V(1).Info("heartbeat sent")
+			logger.V(0).Info("agent started")
+
+			decoded := entries(out.String())
+			Expect(decoded).To(HaveLen(2))
+			Expect(decoded[0]).To(HaveKeyWithValue("logger", "installer/rke2"))
+			Expect(decoded[0]).To(HaveKeyWithValue("v", BeNumerically("==", 4)))
+			Expect(decoded[1]).To(HaveKeyWithValue("msg", "agent started"))
+		})
+	})
+
+	Context("When the module verbosity is set", func() {
+		It("should parse the verbosity of the modules", func() {
+			Expect(verbosity.Set("installer=4,controller=2")).To(Succeed())
+			Expect(verbosity.String()).To(Equal("controller=2,installer=4"))
+
+			Expect(verbosity.Set("installer")).NotTo(Succeed())
+			Expect(verbosity.Set("installer=high")).NotTo(Succeed())
+			Expect(verbosity.Set("installer=-1")).NotTo(Succeed())
+		})
+
+		It("should replace the verbosity of the modules", func() {
+			Expect(verbosity.Set("installer=4")).To(Succeed())
+			verbosity.SetLevels(map[string]int{"controller": 2})
+
+			Expect(verbosity.Enabled("installer", 4)).To(BeFalse())
+			Expect(verbosity.Enabled("controller/byohost", 2)).To(BeTrue())
+		})
+	})
+
+	Context("When the logger of the agent is created", func() {
+		It("should write the JSON logs to the log file", func() {
+			dir, err := os.MkdirTemp("", "logs")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+			path := filepath.Join(dir, "agent.log")
+			logger, closeFile, err := logging.New(logging.FormatJSON, verbosity, logging.FileOptions{
+				Path:       path,
+				MaxSize:    logging.DefaultFileMaxSize,
+				MaxBackups: logging.DefaultFileMaxBackups,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			logger.Info("agent started")
+			Expect(closeFile()).To(Succeed())
+
+			logs, err := os.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			decoded := entries(string(logs))
+			Expect(decoded).To(HaveLen(1))
+			Expect(decoded[0]).To(HaveKeyWithValue("msg", "agent started"))
+		})
+
+		It("should only write the log file in the JSON format", func() {
+			_, _, err := logging.New(logging.FormatText, verbosity, logging.FileOptions{Path: "/var/log/byoh/agent.log"})
+			Expect(err).To(HaveOccurred())
+			_, _, err = logging.New(logging.FormatText, verbosity, logging.FileOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should refuse an unknown format", func() {
+			_, _, err := logging.New("logfmt", verbosity, logging.FileOptions{})
+			Expect(err).To(MatchError(ContainSubstring("unknown log format")))
+		})
+	})
+})
@@ -0,0 +1,69 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package logging
+
+import (
+	"github.com/go-logr/logr"
+)
+
+// moduleSink filters the entries logged to its delegate with the verbosity of the module of the logger
+type moduleSink struct {
+	delegate  logr.LogSink
+	verbosity *ModuleVerbosity
+	name      string
+}
+
+// newModuleSink returns a sink logging to delegate with the verbosity of the modules
+func newModuleSink(delegate logr.LogSink, verbosity *ModuleVerbosity) logr.LogSink {
+	// the caller of the logger is one frame further from the delegate
+	if callDepthSink, ok := delegate.(logr.CallDepthLogSink); ok {
+		delegate = callDepthSink.WithCallDepth(1)
+	}
+	return &moduleSink{delegate: delegate, verbosity: verbosity}
+}
+
+// Init implements logr.LogSink
+func (s *moduleSink) Init(info logr.RuntimeInfo) {
+	s.delegate.Init(info)
+}
+
+// Enabled implements logr.LogSink
+func (s *moduleSink) Enabled(level int) bool {
+	return s.verbosity.Enabled(s.name, level)
+}
+
+// Info implements logr.LogSink
+func (s *moduleSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	// the entry is enabled by the verbosity of the module, which may be above the klog verbosity
+	// the delegate checks the entries against
+	s.delegate.Info(0, msg, keysAndValues...)
+}
+
+// Error implements logr.LogSink
+func (s *moduleSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	s.delegate.Error(err, msg, keysAndValues...)
+}
+
+// WithValues implements logr.LogSink
+func (s *moduleSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	return &moduleSink{delegate: s.delegate.WithValues(keysAndValues...), verbosity: s.verbosity, name: s.name}
+}
+
+// WithName implements logr.LogSink
+func (s *moduleSink) WithName(name string) logr.LogSink {
+	fullName := name
+	if s.name != "" {
+		fullName = s.name + "/" + name
+	}
+	return &moduleSink{delegate: s.delegate.WithName(name), verbosity: s.verbosity, name: fullName}
+}
+
+// WithCallDepth implements logr.CallDepthLogSink
+func (s *moduleSink) WithCallDepth(depth int) logr.LogSink {
+	callDepthSink, ok := s.delegate.(logr.CallDepthLogSink)
+	if !ok {
+		return s
+	}
+	return &moduleSink{delegate: callDepthSink.WithCallDepth(depth), verbosity: s.verbosity, name: s.name}
+}
@@ -0,0 +1,85 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package logging
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	klog "k8s.io/klog/v2"
+)
+
+// ModuleVerbosity is the verbosity of the modules of the agent, overriding the klog verbosity for
+// their loggers. The module of a logger is its first name, e.g. installer for installer/rke2.
+// It is a flag in the form installer=4,controller=2, and can be changed while the agent logs.
+type ModuleVerbosity struct {
+	mu     sync.RWMutex
+	levels map[string]int
+}
+
+// String implements flag.Value interface
+func (m *ModuleVerbosity) String() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	result := make([]string, 0, len(m.levels))
+	for module, level := range m.levels {
+		result = append(result, fmt.Sprintf("%s=%d", module, level))
+	}
+	sort.Strings(result)
+	return strings.Join(result, ",")
+}
+
+// Set implements flag.Value interface, the verbosity of the modules not set is the klog verbosity
+func (m *ModuleVerbosity) Set(value string) error {
+	levels := make(map[string]int)
+	for _, s := range strings.Split(value, ",") {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		parts := strings.SplitN(s, "=", 2) // nolint: gomnd
+		if len(parts) < 2 {                // nolint: gomnd
+			return fmt.Errorf("invalid argument value. expect module=level, got %s", value)
+		}
+		level, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || level < 0 {
+			return fmt.Errorf("invalid verbosity %q of module %s", parts[1], parts[0])
+		}
+		levels[strings.TrimSpace(parts[0])] = level
+	}
+	m.SetLevels(levels)
+	return nil
+}
+
+// Type implements pflag.Value interface
+func (m *ModuleVerbosity) Type() string {
+	return "moduleVerbosity"
+}
+
+// SetLevels replaces the verbosity of the modules
+func (m *ModuleVerbosity) SetLevels(levels map[string]int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.levels = levels
+}
+
+// Enabled tells if the entries of the level are logged by the logger of the name
+func (m *ModuleVerbosity) Enabled(name string, level int) bool {
+	if m != nil {
+		m.mu.RLock()
+		moduleLevel, ok := m.levels[module(name)]
+		m.mu.RUnlock()
+		if ok {
+			return level <= moduleLevel
+		}
+	}
+	return klog.V(klog.Level(level)).Enabled()
+}
+
+// module returns the module of the logger of the name
+func module(name string) string {
+	return strings.SplitN(name, "/", 2)[0] // nolint: gomnd
+}
@@ -0,0 +1,105 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package logstream
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxSize is the default size cap of the streamed logs, well under the 1MiB object size
+// limit of etcd
+const DefaultMaxSize = 256 * 1024
+
+// Buffer keeps the last log entries of the agent as JSON lines, dropping the oldest entries
+// beyond MaxSize bytes
+type Buffer struct {
+	// MaxSize defaults to DefaultMaxSize
+	MaxSize int
+
+	mu      sync.Mutex
+	lines   []string
+	size    int
+	version uint64
+}
+
+// entry is a structured log entry of the agent
+type entry struct {
+	Time    time.Time
+	Level   string
+	V       int
+	Logger  string
+	Message string
+	Error   error
+	Values  []interface{}
+}
+
+// MarshalJSON flattens the key and values of the entry into the JSON object
+func (e entry) MarshalJSON() ([]byte, error) {
+	fields := map[string]interface{}{}
+	for i := 0; i+1 < len(e.Values); i += 2 {
+		fields[fmt.Sprint(e.Values[i])] = jsonValue(e.Values[i+1])
+	}
+	fields["ts"] = e.Time.UTC().Format(time.RFC3339Nano)
+	fields["level"] = e.Level
+	fields["msg"] = e.Message
+	if e.Level == "info" {
+		fields["v"] = e.V
+	}
+	if e.Logger != "" {
+		fields["logger"] = e.Logger
+	}
+	if e.Error != nil {
+		fields["error"] = e.Error.Error()
+	}
+	return json.Marshal(fields)
+}
+
+// jsonValue returns the value when it can be encoded to JSON, its string form otherwise
+func jsonValue(value interface{}) interface{} {
+	if err, ok := value.(error); ok {
+		return err.Error()
+	}
+	if _, err := json.Marshal(value); err != nil {
+		return fmt.Sprintf("%+v", value)
+	}
+	return value
+}
+
+// add appends the entry to the buffer
+func (b *Buffer) add(e entry) {
+	encoded, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	line := string(encoded) + "\n"
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	maxSize := b.MaxSize
+	if maxSize == 0 {
+		maxSize = DefaultMaxSize
+	}
+	if len(line) > maxSize {
+		return
+	}
+	b.lines = append(b.lines, line)
+	b.size += len(line)
+	for b.size > maxSize {
+		b.size -= len(b.lines[0])
+		b.lines = b.lines[1:]
+	}
+	b.version++
+}
+
+// Snapshot returns the buffered entries as JSON lines, and the version of the buffer which is
+// increased on every entry
+func (b *Buffer) Snapshot() (string, uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.Join(b.lines, ""), b.version
+}
@@ -0,0 +1,6 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package logstream streams the structured logs of the host agent to a ConfigMap of its host in the
+// management cluster, capped in size, so that the host-side errors can be diagnosed with kubectl.
+package logstream
@@ -0,0 +1,158 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package logstream_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/logstream"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/test/builder"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// entries decodes the JSON lines of the streamed logs
+func entries(logs string) []map[string]interface{} {
+	var decoded []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSuffix(logs, "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		entry := map[string]interface{}{}
+		Expect(json.Unmarshal([]byte(line), &entry)).To(Succeed())
+		decoded = append(decoded, entry)
+	}
+	return decoded
+}
+
+var _ = Describe("Log streaming", func() {
+	var (
+		buffer   *logstream.Buffer
+		logger   logr.Logger
+		delegate []string
+	)
+
+	BeforeEach(func() {
+		buffer = &logstream.Buffer{}
+		delegate = nil
+		logger = logstream.NewLogger(funcr.New(func(prefix, args string) {
+			delegate = append(delegate, args)
+		}, funcr.Options{Verbosity: 1}), buffer)
+	})
+
+	Context("When the agent logs", func() {
+		It("should buffer the structured entries along with logging them", func() {
+			logger.WithName("offline").WithValues("host", "edge-host").Info("Management cluster unreachable", "attempt", 2)
+			logger.Error(errors.New("connect: no route to host"), "failed to patch ByoHost")
+
+			Expect(delegate).To(HaveLen(2))
+			logs, _ := buffer.Snapshot()
+			decoded := entries(logs)
+			Expect(decoded).To(HaveLen(2))
+			Expect(decoded[0]).To(HaveKeyWithValue("level", "info"))
+			Expect(decoded[0]).To(HaveKeyWithValue("logger", "offline"))
+			Expect(decoded[0]).To(HaveKeyWithValue("msg", "Management cluster unreachable"))
+			Expect(decoded[0]).To(HaveKeyWithValue("host", "edge-host"))
+			Expect(decoded[0]).To(HaveKeyWithValue("attempt", BeNumerically("==", 2)))
+			Expect(decoded[0]).To(HaveKey("ts"))
+			Expect(decoded[1]).To(HaveKeyWithValue("level", "error"))
+			Expect(decoded[1]).To(HaveKeyWithValue("error", "connect: no route to host"))
+		})
+
+		It("should only buffer the entries enabled by the verbosity", func() {
+			logger.V(1).Info("installing k8s components")
+			logger.V(4).Info("probing the management cluster")
+
+			logs, _ := buffer.Snapshot()
+			decoded := entries(logs)
+			Expect(decoded).To(HaveLen(1))
+			Expect(decoded[0]).To(HaveKeyWithValue("msg", "installing k8s components"))
+			Expect(decoded[0]).To(HaveKeyWithValue("v", BeNumerically("==", 1)))
+		})
+
+		It("should drop the oldest entries beyond the size cap", func() {
+			buffer.MaxSize = 512
+			for i := 0; i < 20; i++ {
+				logger.Info("reconciling ByoHost", "attempt", i)
+			}
+
+			logs, version := buffer.Snapshot()
+			Expect(len(logs)).To(BeNumerically("<=", 512))
+			Expect(version).To(Equal(uint64(20)))
+			decoded := entries(logs)
+			Expect(decoded[len(decoded)-1]).To(HaveKeyWithValue("attempt", BeNumerically("==", 19)))
+			Expect(decoded[0]).NotTo(HaveKeyWithValue("attempt", BeNumerically("==", 0)))
+		})
+	})
+
+	Context("When the logs are streamed", func() {
+		var (
+			ctx      context.Context
+			c        client.Client
+			byoHost  *infrastructurev1beta1.ByoHost
+			streamer *logstream.Streamer
+		)
+
+		BeforeEach(func() {
+			ctx = context.TODO()
+			scheme := runtime.NewScheme()
+			Expect(infrastructurev1beta1.AddToScheme(scheme)).To(Succeed())
+			Expect(corev1.AddToScheme(scheme)).To(Succeed())
+			byoHost = builder.ByoHost("default", "edge-host").Build()
+			byoHost.Name = "edge-host"
+			c = fake.NewClientBuilder().WithScheme(scheme).WithObjects(byoHost).Build()
+			Expect(c.Get(ctx, client.ObjectKeyFromObject(byoHost), byoHost)).To(Succeed())
+			streamer = &logstream.Streamer{
+				Client: c,
+				Host:   client.ObjectKeyFromObject(byoHost),
+				Buffer: buffer,
+				Logger: logr.Discard(),
+			}
+		})
+
+		getConfigMap := func() (*corev1.ConfigMap, error) {
+			configMap := &corev1.ConfigMap{}
+			err := c.Get(ctx, types.NamespacedName{Name: logstream.ConfigMapName("edge-host"), Namespace: "default"}, configMap)
+			return configMap, err
+		}
+
+		It("should write the logs to the ConfigMap of the host", func() {
+			logger.Info("Registering host")
+			Expect(streamer.Stream(ctx)).To(Succeed())
+
+			configMap, err := getConfigMap()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(configMap.Name).To(Equal("edge-host-agent-logs"))
+			Expect(configMap.Labels).To(HaveKeyWithValue(logstream.HostLabel, "edge-host"))
+			Expect(configMap.OwnerReferences).To(HaveLen(1))
+			Expect(configMap.OwnerReferences[0].UID).To(Equal(byoHost.UID))
+			Expect(configMap.Data[logstream.LogsKey]).To(ContainSubstring(`"msg":"Registering host"`))
+
+			logger.Info("Installing k8s components")
+			Expect(streamer.Stream(ctx)).To(Succeed())
+			configMap, err = getConfigMap()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries(configMap.Data[logstream.LogsKey])).To(HaveLen(2))
+		})
+
+		It("should not write the ConfigMap when nothing was logged", func() {
+			Expect(streamer.Stream(ctx)).To(Succeed())
+
+			_, err := getConfigMap()
+			Expect(client.IgnoreNotFound(err)).To(Succeed())
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,103 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package logstream
+
+import (
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// sink tees the entries logged to its delegate into a Buffer
+type sink struct {
+	delegate logr.LogSink
+	buffer   *Buffer
+	name     string
+	values   []interface{}
+}
+
+// NewLogger returns a logger logging to the sink of the given logger, and into the buffer.
+// Only the entries enabled by the verbosity of the given logger are buffered.
+func NewLogger(logger logr.Logger, buffer *Buffer) logr.Logger {
+	delegate := logger.GetSink()
+	// the caller of the logger is one frame further from the delegate
+	if callDepthSink, ok := delegate.(logr.CallDepthLogSink); ok {
+		delegate = callDepthSink.WithCallDepth(1)
+	}
+	return logr.New(&sink{delegate: delegate, buffer: buffer})
+}
+
+// Init implements logr.LogSink
+func (s *sink) Init(info logr.RuntimeInfo) {
+	s.delegate.Init(info)
+}
+
+// Enabled implements logr.LogSink
+func (s *sink) Enabled(level int) bool {
+	return s.delegate.Enabled(level)
+}
+
+// Info implements logr.LogSink
+func (s *sink) Info(level int, msg string, keysAndValues ...interface{}) {
+	s.delegate.Info(level, msg, keysAndValues...)
+	s.buffer.add(entry{
+		Time:    time.Now(),
+		Level:   "info",
+		V:       level,
+		Logger:  s.name,
+		Message: msg,
+		Values:  append(append([]interface{}{}, s.values...), keysAndValues...),
+	})
+}
+
+// Error implements logr.LogSink
+func (s *sink) Error(err error, msg string, keysAndValues ...interface{}) {
+	s.delegate.Error(err, msg, keysAndValues...)
+	s.buffer.add(entry{
+		Time:    time.Now(),
+		Level:   "error",
+		Logger:  s.name,
+		Message: msg,
+		Error:   err,
+		Values:  append(append([]interface{}{}, s.values...), keysAndValues...),
+	})
+}
+
+// WithValues implements logr.LogSink
+func (s *sink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	return &sink{
+		delegate: s.delegate.WithValues(keysAndValues...),
+		buffer:   s.buffer,
+		name:     s.name,
+		values:   append(append([]interface{}{}, s.values...), keysAndValues...),
+	}
+}
+
+// WithName implements logr.LogSink
+func (s *sink) WithName(name string) logr.LogSink {
+	fullName := name
+	if s.name != "" {
+		fullName = s.name + "/" + name
+	}
+	return &sink{
+		delegate: s.delegate.WithName(name),
+		buffer:   s.buffer,
+		name:     fullName,
+		values:   s.values,
+	}
+}
+
+// WithCallDepth implements logr.CallDepthLogSink
+func (s *sink) WithCallDepth(depth int) logr.LogSink {
+	callDepthSink, ok := s.delegate.(logr.CallDepthLogSink)
+	if !ok {
+		return s
+	}
+	return &sink{
+		delegate: callDepthSink.WithCallDepth(depth),
+		buffer:   s.buffer,
+		name:     s.name,
+		values:   s.values,
+	}
+}
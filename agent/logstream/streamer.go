@@ -0,0 +1,109 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package logstream
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// LogsKey is the key of the logs in the ConfigMap they are streamed to
+	LogsKey = "agent.log"
+
+	// HostLabel records the name of the ByoHost on the ConfigMap its agent logs are streamed to
+	HostLabel = "byoh.infrastructure.cluster.x-k8s.io/agent-logs-host"
+
+	// DefaultInterval is how often the logs are streamed
+	DefaultInterval = 10 * time.Second
+
+	// configMapSuffix is appended to the name of the ByoHost to name the ConfigMap of its logs
+	configMapSuffix = "-agent-logs"
+)
+
+// ConfigMapName returns the name of the ConfigMap the logs of the host agent are streamed to
+func ConfigMapName(hostName string) string {
+	return hostName + configMapSuffix
+}
+
+// Streamer streams the buffered logs of the agent to the ConfigMap of its host, in the namespace
+// of the ByoHost and owned by it, whenever new entries were logged
+type Streamer struct {
+	Client client.Client
+	Host   types.NamespacedName
+	Buffer *Buffer
+	// Interval defaults to DefaultInterval
+	Interval time.Duration
+	Logger   logr.Logger
+
+	streamedVersion uint64
+}
+
+// Start implements manager.Runnable
+func (s *Streamer) Start(ctx context.Context) error {
+	interval := s.Interval
+	if interval == 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := s.Stream(ctx); err != nil {
+				// at a verbosity the failures of an unreachable management cluster do not fill the logs
+				s.Logger.V(2).Info("Failed to stream the agent logs", "error", err.Error())
+			}
+		}
+	}
+}
+
+// Stream writes the buffered logs to the ConfigMap, unless nothing was logged since the last time
+func (s *Streamer) Stream(ctx context.Context) error {
+	logs, version := s.Buffer.Snapshot()
+	if version == s.streamedVersion {
+		return nil
+	}
+
+	byoHost := &infrastructurev1beta1.ByoHost{}
+	if err := s.Client.Get(ctx, s.Host, byoHost); err != nil {
+		return err
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ConfigMapName(s.Host.Name),
+			Namespace: s.Host.Namespace,
+		},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, s.Client, configMap, func() error {
+		if configMap.Labels == nil {
+			configMap.Labels = map[string]string{}
+		}
+		configMap.Labels[HostLabel] = s.Host.Name
+		// the host agent is not allowed to block the deletion of its ByoHost
+		configMap.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: infrastructurev1beta1.GroupVersion.String(),
+			Kind:       "ByoHost",
+			Name:       byoHost.Name,
+			UID:        byoHost.UID,
+		}}
+		configMap.Data = map[string]string{LogsKey: logs}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.streamedVersion = version
+	return nil
+}
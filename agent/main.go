@@ -5,38 +5,59 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	pflag "github.com/spf13/pflag"
 	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/cloudinit"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/failover"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/faultinject"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/fips"
 	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/installer"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/localapi"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/logging"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/logstream"
+	agentmetrics "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/metrics"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/nodekeeper"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/offline"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/preflight"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/privilege"
 	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/reconciler"
 	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/registration"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/upgrader"
 	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/version"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/watchdog"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/agentconfig"
 	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/common/tracing"
 	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/feature"
 	certv1 "k8s.io/api/certificates/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	clientset "k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/util/certificate/csr"
+	"k8s.io/client-go/rest"
 	klog "k8s.io/klog/v2"
-	"k8s.io/klog/v2/klogr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 )
 
 // labelFlags is a flag that holds a map of label key values.
 // One or more key value pairs can be passed using the same flag
 // The following example sets labelFlags with two items:
-//     -label "key1=value1" -label "key2=value2"
+//
+//	-label "key1=value1" -label "key2=value2"
 type labelFlags map[string]string
 
 // String implements flag.Value interface
@@ -84,14 +105,79 @@ func setupflags() {
 	// clear any discard loggers set by dependecies
 	klog.ClearLogger()
 
+	flag.StringVar(&configFile, "config", "", "Path of the agent configuration file. The flags set on the command line take precedence over it. The labels and the log verbosity of the agent and of its modules are reloaded from it on SIGHUP")
 	flag.StringVar(&namespace, "namespace", "default", "Namespace in the management cluster where you would like to register this host")
+	flag.StringVar(&hostIdentity, "host-identity", string(registration.HostIdentityHostname), "Identity the host is registered under: \"hostname\", or \"machine-id\" and \"smbios-uuid\" to name the ByoHost after the hash of the machine identity, kept when the host is renamed")
+	flag.StringVar(&hostInstanceSuffix, "host-instance-suffix", "", "Suffix of the ByoHost of this agent instance, for several agent instances to run on one machine in dev mode, e.g. for the scale testing of the controllers. Every instance gets its own ByoHost, download path, agent state directory and kubelet data directory")
+	flag.StringVar(&hostnameOverride, "hostname-override", "", "Name the host is registered with, and its node is named after, instead of its hostname, e.g. for hosts sharing the same hostname")
+	flag.StringVar(&byohostNameTemplate, "byohost-name-template", "", "Template of the name the host is registered with, and its node is named after, e.g. \"{site}-node-{hostname}\". The variables are {hostname}, {serial} and the labels of the agent. The name is suffixed when taken by another host")
+	flag.StringVar(&byohostNamePrefix, "byohost-name-prefix", "", "Prefix of the name the host is registered with, and its node is named after, e.g. \"store42-\". The name is suffixed when taken by another host")
 	flag.Var(&labels, "label", "labels to attach to the ByoHost CR in the form labelname=labelVal for e.g. '--label site=apac --label cores=2'")
 	flag.StringVar(&metricsbindaddress, "metricsbindaddress", ":8080", "metricsbindaddress is the TCP address that the controller should bind to for serving prometheus metrics.It can be set to \"0\" to disable the metrics serving")
+	flag.StringVar(&metricsCertFile, "metrics-tls-cert-file", "", "Path to the certificate used to serve the metrics over TLS. The metrics are served over plain HTTP when not set")
+	flag.StringVar(&metricsKeyFile, "metrics-tls-key-file", "", "Path to the private key of the metrics serving certificate")
+	flag.StringVar(&metricsClientCAFile, "metrics-tls-client-ca-file", "", "Path to the CA bundle used to verify the scrapers client certificates. Enables mTLS on the metrics endpoint")
+	flag.StringVar(&localAPISocket, "local-api-socket", "", "Path of the Unix socket the local API of the agent is served on, for the node-local tools to query and operate the agent. The local API is disabled when not set")
+	flag.StringVar(&defaultNetworkInterface, "default-network-interface", "", "Name of the network interface reported as the default one, e.g. on dual-stack or bonded hosts. Defaults to the interface of the IPv4 default route, then of the IPv6 one")
+	flag.StringVar(&nodeIP, "node-ip", "", "IP address the kubelet registers the node with, overridden by the node-ip annotation of the ByoHost. Defaults to the address picked by the kubelet")
 	flag.StringVar(&downloadpath, "downloadpath", "/var/lib/byoh/bundles", "File System path to keep the downloads")
+	flag.StringVar(&stagedBundlePath, "staged-bundle-path", "", "Path the bundles are pre-staged under by the stage-bundle subcommand, e.g. the mount point of a removable drive. The staged bundles are verified and used instead of being downloaded")
+	flag.StringVar(&stageBundleRepo, "stage-bundle-repo", "projects.registry.vmware.com/cluster_api_provider_bringyourownhost", "Repository of the bundle staged by the stage-bundle subcommand")
+	flag.StringVar(&stageK8sVersion, "stage-k8s-version", "", "Kubernetes version of the bundle staged by the stage-bundle subcommand")
+	flag.StringVar(&stageBundleTag, "stage-bundle-tag", "", "Tag of the bundle staged by the stage-bundle subcommand")
+	flag.StringVar(&stageBundleOS, "stage-bundle-os", "", "OS of the bundle staged by the stage-bundle subcommand, as detected by the installer, e.g. Ubuntu_20.04.4_x86-64. Defaults to the OS of the current host")
+	flag.BoolVar(&stageRKE2Bundle, "stage-rke2-bundle", false, "Stage the rke2 bundle instead of the kubeadm bundle of the OS with the stage-bundle subcommand")
+	flag.StringVar(&installMode, "install-mode", string(installer.InstallModePackage), "How the kubernetes components are installed: \"package\" with the package manager of the OS, or \"containerd\" as plain binaries of a bundle pulled with containerd, for the hosts without a package manager")
+	flag.StringVar(&downloadRateLimit, "download-rate-limit", "", "Bandwidth the bundle downloads are throttled to, in bytes per second as a quantity, e.g. 1Mi, so that the hosts enrolled at once do not saturate the uplink of their site. The bundles are not throttled when not set, nor when they are pulled with containerd")
+	flag.StringVar(&tracingEndpoint, "tracing-endpoint", "", "OTLP/HTTP endpoint of the OpenTelemetry collector the spans of the bootstrap of the host are exported to, e.g. otel-collector:4318. The spans join the trace of the machine of the host. Nothing is traced when empty")
+	flag.BoolVar(&tracingInsecure, "tracing-insecure", false, "Export the spans to the tracing endpoint without TLS")
+	flag.StringVar(&installerAuditLog, "installer-audit-log", "/var/log/byoh/installer-audit.log", "Path of the local audit log of the commands run by the installer, as JSON lines. It can be set to \"\" to disable the audit log")
 	flag.BoolVar(&skipInstallation, "skip-installation", false, "If you want to skip installation of the kubernetes component binaries")
 	flag.BoolVar(&useInstallerController, "use-installer-controller", false, "If you want to skip the intree installer and use the default or your own installer controller")
+	flag.BoolVar(&skipPreflightChecks, "skip-preflight-checks", false, "If you want to skip the checks of the OS and kernel prerequisites run before installing the kubernetes components")
+	flag.BoolVar(&refuseInsecurePaths, "refuse-insecure-permissions", false, "Refuse to start on world-writable agent paths, i.e. the configuration, the kubeconfigs, the keys, the download path and the sudoers rules, rather than removing their write permission for the others")
+	flag.BoolVar(&escalateWithSudo, "escalate-with-sudo", false, "Run the installer steps, the bootstrap commands and the writes to the system directories with sudo, for an agent running as a dedicated user instead of root")
+	flag.BoolVar(&dryRunMode, "dry-run", false, "Register the host and print the steps installing the kubernetes components on it, without running them, then exit")
+	flag.StringVar(&dryRunK8sVersion, "dry-run-k8s-version", "", "Kubernetes version of the steps printed by the dry run, defaults to the version of the machine the host is attached to")
 	flag.BoolVar(&printVersion, "version", false, "Print the version of the agent")
 	flag.StringVar(&bootstrapKubeConfig, "bootstrap-kubeconfig", "", "Provide bootstrap kubeconfig for bootstrap token workflow")
+	flag.BoolVar(&fipsMode, "fips", false, "Enforce the FIPS 140-2 approved algorithms for the keys and the TLS settings of the agent. Always on for the agent built with BoringCrypto")
+	flag.StringVar(&csrSignerName, "csr-signer-name", certv1.KubeAPIServerClientSignerName, "Signer the host client certificate requested with secure access is requested from, e.g. the signer of an internal CA managed by cert-manager")
+	flag.DurationVar(&csrApprovalTimeout, "csr-approval-timeout", registration.CSRApprovalTimeout, "How long the host CSR is waited for to be approved with secure access before it is deleted and submitted again")
+	flag.IntVar(&csrMaxRequests, "csr-max-requests", 0, "Number of host CSRs not approved in time after which the agent gives up and exits, unlimited when 0")
+	flag.DurationVar(&csrRetryJitter, "csr-retry-jitter", 0, "Upper bound of the random delay before submitting again a host CSR not approved in time, so that the hosts enrolled at once do not submit them again at once")
+	flag.StringVar(&keyType, "key-type", string(registration.KeyTypeECDSAP256), "Type of the private key of the host client certificate requested with secure access: ECDSA-P256, ECDSA-P384, RSA-2048, RSA-3072 or RSA-4096")
+	flag.StringVar(&keyStore, "key-store", string(registration.KeyStoreFile), "Where the private key of the host client certificate is kept with secure access: \"file\" in the private key directory until it is written to the host kubeconfig, \"tpm\" in the TPM of the host or \"pkcs11\" in a PKCS#11 token. The tpm and pkcs11 keys never leave their store")
+	flag.StringVar(&privateKeyDir, "private-key-dir", "", "Directory the private key file, or the client certificate of the tpm and pkcs11 key stores, is kept in with 0700 permissions, defaults to $HOME/.byoh")
+	flag.StringVar(&tpmDevice, "tpm-device", "", "Path of the TPM device of the tpm key store, defaults to /dev/tpmrm0 then /dev/tpm0")
+	flag.StringVar(&pkcs11Module, "pkcs11-module", "", "Path of the PKCS#11 module of the pkcs11 key store. The user PIN of the token is read from the "+registration.PKCS11PinEnv+" env var")
+	flag.StringVar(&pkcs11TokenLabel, "pkcs11-token-label", "", "Label of the token of the pkcs11 key store")
+	flag.StringVar(&pkcs11KeyLabel, "pkcs11-key-label", "byoh-client", "Label of the key pair in the token of the pkcs11 key store, generated when missing")
+	flag.StringVar(&credentialEncryption, "credential-encryption", string(registration.CredentialEncryptionNone), "How the host kubeconfig and private key are encrypted at rest with secure access, they are only decrypted in memory: \"none\", \"key-file\" with the key of --credential-encryption-key-file or \"tpm\" with a key sealed by the TPM of the host")
+	flag.StringVar(&credentialKeyFile, "credential-encryption-key-file", "", "Path of the 32 bytes AES-256 key of the key-file credential encryption, with 0600 permissions, e.g. provisioned by a KMS on a separate volume")
+	flag.StringVar(&hostKubeConfig, "host-kubeconfig", "", "Path of the kubeconfig minted from the bootstrap kubeconfig with secure access, defaults to $HOME/.byoh/config")
+	flag.StringVar(&proxy.HTTPProxy, "http-proxy", os.Getenv("HTTP_PROXY"), "The proxy used for the HTTP requests, defaults to the HTTP_PROXY env var. Also set for containerd and kubelet")
+	flag.StringVar(&proxy.HTTPSProxy, "https-proxy", os.Getenv("HTTPS_PROXY"), "The proxy used for the HTTPS requests, defaults to the HTTPS_PROXY env var. Also set for containerd and kubelet")
+	flag.StringVar(&proxy.NoProxy, "no-proxy", os.Getenv("NO_PROXY"), "The hosts which are not accessed through the proxy, defaults to the NO_PROXY env var. Also set for containerd and kubelet")
+	flag.StringVar(&agentUpgradePublicKey, "agent-upgrade-public-key", "", "Path to the PEM encoded public key used to verify the signature of the agent binary on upgrade, required to upgrade the agent")
+	flag.BoolVar(&streamLogs, "stream-logs", false, "Stream the structured logs of the agent to the <host>-agent-logs ConfigMap in the namespace of the ByoHost")
+	flag.IntVar(&streamLogsMaxSize, "stream-logs-max-size", logstream.DefaultMaxSize, "Size cap in bytes of the streamed logs, the oldest entries are dropped beyond it")
+	flag.StringVar(&logFormat, "log-format", logging.FormatJSON, "Format of the agent logs: \"json\" lines with the ts, level, v, logger, caller, msg and error fields and a field per key and value, for the log shippers to ingest them without parsing, or the klog \"text\"")
+	flag.Var(&moduleVerbosity, "log-module-verbosity", "Verbosity of the modules of the agent overriding the -v verbosity for their logs, in the form module=level, e.g. 'installer=4,controller=2'. The module of a logger is its first name")
+	flag.StringVar(&logFile, "log-file", "", "Path of the local log file the JSON logs are also written to, rotated by size. No log file is written when empty")
+	flag.IntVar(&logFileMaxSize, "log-file-max-size", logging.DefaultFileMaxSize, "Size in megabytes the log file is rotated at. The rotated log files are compressed")
+	flag.IntVar(&logFileMaxBackups, "log-file-max-backups", logging.DefaultFileMaxBackups, "Number of rotated log files kept, all are kept when 0")
+	flag.IntVar(&logFileMaxAge, "log-file-max-age", 0, "Number of days the rotated log files are kept, they are kept whatever their age when 0")
+	flag.StringVar(&debugBundlePath, "debug-bundle-path", "", "Path of the tarball written by the collect-debug subcommand, defaults to byoh-debug-<host>-<time>.tar.gz in the working directory")
+	flag.BoolVar(&uploadDebugBundle, "upload-debug-bundle", false, "Upload the debug bundle collected by the collect-debug subcommand as a ConfigMap in the namespace of the ByoHost")
+	flag.StringVar(&failoverKubeconfigs, "failover-kubeconfigs", "", "Comma separated paths of the kubeconfigs of the standby management clusters the agent fails over to, in order, when the management cluster is unreachable")
+	flag.DurationVar(&failoverProbeInterval, "failover-probe-interval", failover.DefaultProbeInterval, "How often the management cluster is probed when failover kubeconfigs are set")
+	flag.IntVar(&failoverThreshold, "failover-threshold", failover.DefaultFailureThreshold, "Number of probes in a row the management cluster has to fail before the agent fails over to a standby management cluster")
+	flag.DurationVar(&reconcileHungTimeout, "watchdog-hung-timeout", watchdog.DefaultHungTimeout, "How long a reconcile may run before the agent restarts its subsystems")
+	flag.IntVar(&crashLoopThreshold, "watchdog-crash-loop-threshold", watchdog.DefaultCrashLoopThreshold, "Number of panics of the reconciles within the crash loop window after which the agent restarts its subsystems")
+	flag.DurationVar(&crashLoopWindow, "watchdog-crash-loop-window", watchdog.DefaultCrashLoopWindow, "Window the panics of the reconciles are counted in, the AgentDegraded condition of the host is removed once the agent ran that long without restarting its subsystems")
+	flag.StringVar(&nodeComponents, "node-components", strings.Join(nodekeeper.DefaultUnits, ","), "Comma separated systemd units of the node components restarted by the agent when they fail once the node is bootstrapped, e.g. containerd,kubelet. It can be set to \"\" to leave them to systemd")
+	flag.DurationVar(&nodeComponentsCheckInterval, "node-components-check-interval", nodekeeper.DefaultCheckInterval, "How often the systemd units of the node components are checked")
 
 	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
 	hiddenFlags := []string{"log-flush-frequency", "alsologtostderr", "log-backtrace-at", "log-dir", "logtostderr", "stderrthreshold", "vmodule", "azure-container-registry-config",
@@ -102,13 +188,35 @@ func setupflags() {
 	feature.MutableGates.AddFlag(pflag.CommandLine)
 }
 
+// resolveHostName returns the name the host is registered under, suffixed when the ByoHost of the
+// rendered name is owned by another host
+func resolveHostName(ctx context.Context, k8sClient client.Client, hostName string) (string, error) {
+	hostUIDPath, err := byohDirPath("host-uid")
+	if err != nil {
+		return "", err
+	}
+	hostUID, err := registration.LoadHostUID(hostUIDPath)
+	if err != nil {
+		return "", err
+	}
+	return registration.ResolveHostName(ctx, k8sClient, hostName, namespace, hostUID)
+}
+
 func handleHostRegistration(k8sClient client.Client, hostName string, logger logr.Logger) (err error) {
-	registration.LocalHostRegistrar = &registration.HostRegistrar{K8sClient: k8sClient}
+	registration.LocalHostRegistrar = &registration.HostRegistrar{K8sClient: k8sClient, DefaultNetworkInterface: defaultNetworkInterface, MachineID: machineID,
+		AgentInfo: agentInfo(pflag.CommandLine, feature.MutableGates)}
 	if feature.Gates.Enabled(feature.SecureAccess) {
 		logger.Info("secure access enabled, waiting for host to be registered by ByoAdmission Controller")
 	} else {
-		err := registration.LocalHostRegistrar.Register(hostName, namespace, labels)
-		return err
+		// the identity of the host keeps another host with the same name from taking its ByoHost over
+		hostUIDPath, err := byohDirPath("host-uid")
+		if err != nil {
+			return err
+		}
+		if registration.LocalHostRegistrar.HostUID, err = registration.LoadHostUID(hostUIDPath); err != nil {
+			return err
+		}
+		return registration.LocalHostRegistrar.Register(hostName, namespace, hostLabels())
 	}
 	return nil
 }
@@ -129,16 +237,83 @@ func setupTemplateParser() *cloudinit.TemplateParser {
 }
 
 var (
-	namespace              string
-	scheme                 *runtime.Scheme
-	labels                 = make(labelFlags)
-	metricsbindaddress     string
-	downloadpath           string
-	skipInstallation       bool
-	useInstallerController bool
-	printVersion           bool
-	bootstrapKubeConfig    string
-	k8sInstaller           reconciler.IK8sInstaller
+	configFile                  string
+	namespace                   string
+	hostnameOverride            string
+	hostInstanceSuffix          string
+	hostIdentity                string
+	byohostNameTemplate         string
+	byohostNamePrefix           string
+	machineID                   string
+	scheme                      *runtime.Scheme
+	labels                      = make(labelFlags)
+	metricsbindaddress          string
+	metricsCertFile             string
+	metricsKeyFile              string
+	metricsClientCAFile         string
+	localAPISocket              string
+	defaultNetworkInterface     string
+	nodeIP                      string
+	downloadpath                string
+	stagedBundlePath            string
+	stageBundleRepo             string
+	stageK8sVersion             string
+	stageBundleTag              string
+	stageBundleOS               string
+	stageRKE2Bundle             bool
+	installMode                 string
+	installerAuditLog           string
+	downloadRateLimit           string
+	skipInstallation            bool
+	useInstallerController      bool
+	skipPreflightChecks         bool
+	escalateWithSudo            bool
+	refuseInsecurePaths         bool
+	tracingEndpoint             string
+	tracingInsecure             bool
+	dryRunMode                  bool
+	dryRunK8sVersion            string
+	printVersion                bool
+	bootstrapKubeConfig         string
+	hostKubeConfig              string
+	fipsMode                    bool
+	keyType                     string
+	csrSignerName               string
+	keyStore                    string
+	csrApprovalTimeout          time.Duration
+	csrMaxRequests              int
+	csrRetryJitter              time.Duration
+	privateKeyDir               string
+	tpmDevice                   string
+	pkcs11Module                string
+	pkcs11TokenLabel            string
+	pkcs11KeyLabel              string
+	credentialEncryption        string
+	credentialKeyFile           string
+	agentUpgradePublicKey       string
+	streamLogs                  bool
+	streamLogsMaxSize           int
+	logBuffer                   *logstream.Buffer
+	logFormat                   string
+	moduleVerbosity             logging.ModuleVerbosity
+	logFile                     string
+	logFileMaxSize              int
+	logFileMaxBackups           int
+	logFileMaxAge               int
+	debugBundlePath             string
+	uploadDebugBundle           bool
+	failoverKubeconfigs         string
+	failoverProbeInterval       time.Duration
+	failoverThreshold           int
+	reconcileHungTimeout        time.Duration
+	crashLoopThreshold          int
+	crashLoopWindow             time.Duration
+	nodeComponents              string
+	nodeComponentsCheckInterval time.Duration
+	proxy                       installer.ProxyConfig
+	k8sInstaller                reconciler.IK8sInstaller
+	rke2Installer               reconciler.IK8sInstaller
+	agentUpgrader               reconciler.IAgentUpgrader
 )
 
 // TODO - fix logging
@@ -150,37 +325,296 @@ func main() {
 		fmt.Printf("byoh-hostagent version: %#v\n", info)
 		return
 	}
+	if configFile != "" {
+		config, err := agentconfig.Load(configFile)
+		if err == nil {
+			err = applyAgentConfig(config, pflag.CommandLine)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load the agent configuration %s: %v\n", configFile, err)
+			os.Exit(1)
+		}
+	}
+	if mode := installer.InstallMode(installMode); mode != installer.InstallModePackage && mode != installer.InstallModeContainerd {
+		fmt.Fprintf(os.Stderr, "invalid install mode %q\n", installMode)
+		os.Exit(1)
+	}
+	downloadRateLimitBytes, err := parseDownloadRateLimit(downloadRateLimit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid download rate limit %q: %v\n", downloadRateLimit, err)
+		os.Exit(1)
+	}
+	if _, err := registration.ParseKeyType(keyType); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fips.SetEnforced(fipsMode)
+	// the management cluster and the registries are reached through the proxy set in the environment
+	for name, value := range proxy.Env() {
+		if err := os.Setenv(name, value); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to set %s: %v\n", name, err)
+			os.Exit(1)
+		}
+	}
+
 	scheme = runtime.NewScheme()
 	_ = infrastructurev1beta1.AddToScheme(scheme)
 	_ = corev1.AddToScheme(scheme)
 	_ = clusterv1.AddToScheme(scheme)
 	_ = certv1.AddToScheme(scheme)
 
-	logger := klogr.New()
+	logger, closeLogFile, err := logging.New(logFormat, &moduleVerbosity, logging.FileOptions{
+		Path:       logFile,
+		MaxSize:    logFileMaxSize,
+		MaxBackups: logFileMaxBackups,
+		MaxAge:     logFileMaxAge,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to set up the logs: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() {
+		_ = closeLogFile()
+	}()
+	if streamLogs {
+		logBuffer = &logstream.Buffer{MaxSize: streamLogsMaxSize}
+		logger = logstream.NewLogger(logger, logBuffer)
+	}
 	ctrl.SetLogger(logger)
-	config, err := ctrl.GetConfig()
+	if fips.Enabled() && !fips.BoringCrypto {
+		logger.Info("FIPS mode enforced without BoringCrypto, only the algorithms are checked. Build the agent with BoringCrypto for a validated crypto module")
+	}
+	hostName, err := os.Hostname()
 	if err != nil {
-		logger.Error(err, "error getting kubeconfig")
+		logger.Error(err, "could not determine hostname")
 		return
 	}
+	if hostnameOverride != "" {
+		if errs := validation.IsDNS1123Subdomain(hostnameOverride); len(errs) > 0 {
+			fmt.Fprintf(os.Stderr, "invalid hostname override %q: %s\n", hostnameOverride, strings.Join(errs, ", "))
+			os.Exit(1)
+		}
+		hostName = hostnameOverride
+	}
+	if byohostNameTemplate != "" || byohostNamePrefix != "" {
+		if hostnameOverride != "" || registration.HostIdentity(hostIdentity) != registration.HostIdentityHostname {
+			fmt.Fprintln(os.Stderr, "the ByoHost name template and prefix cannot be set along with a hostname override or a machine identity")
+			os.Exit(1)
+		}
+		if hostName, err = registration.RenderHostName(byohostNameTemplate, byohostNamePrefix, registration.HostNameVariables{
+			Hostname: hostName,
+			Labels:   hostLabels(),
+			ReadFile: os.ReadFile,
+		}); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid ByoHost name template %q: %s\n", byohostNameTemplate, err)
+			os.Exit(1)
+		}
+		if errs := validation.IsDNS1123Subdomain(hostName); len(errs) > 0 {
+			fmt.Fprintf(os.Stderr, "invalid ByoHost name %q: %s\n", hostName, strings.Join(errs, ", "))
+			os.Exit(1)
+		}
+		// the node is named after the ByoHost as well
+		hostnameOverride = hostName
+	}
+	switch identity := registration.HostIdentity(hostIdentity); identity {
+	case registration.HostIdentityHostname:
+	case registration.HostIdentityMachineID, registration.HostIdentitySMBIOSUUID:
+		if hostnameOverride != "" {
+			fmt.Fprintln(os.Stderr, "the hostname override cannot be set along with a machine identity")
+			os.Exit(1)
+		}
+		if machineID, err = registration.MachineID(identity, os.ReadFile); err != nil {
+			logger.Error(err, "could not determine the machine identity", "hostIdentity", identity)
+			os.Exit(1)
+		}
+		// the node is named after the ByoHost as well
+		hostName = registration.MachineIDHostName(machineID)
+		hostnameOverride = hostName
+	default:
+		fmt.Fprintf(os.Stderr, "invalid host identity %q\n", hostIdentity)
+		os.Exit(1)
+	}
+	if hostInstanceSuffix != "" {
+		if hostName, err = applyHostInstanceSuffix(hostName, hostInstanceSuffix); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid host instance suffix %q: %s\n", hostInstanceSuffix, err)
+			os.Exit(1)
+		}
+		logger.Info("Running as an instance of the machine", "instance", hostInstanceSuffix, "byoHost", hostName, "downloadpath", downloadpath)
+	}
+
+	if pflag.Arg(0) == stageBundleCommand {
+		if err := stageBundle(os.Stdout, logger); err != nil {
+			logger.Error(err, "bundle staging failed")
+			os.Exit(1)
+		}
+		return
+	}
+
+	if pflag.Arg(0) == collectDebugCommand {
+		// the bundle is collected even when the management cluster is out of reach
+		var k8sClient client.Client
+		config, err := debugKubeConfig()
+		if err == nil {
+			k8sClient, err = client.New(config, client.Options{Scheme: scheme})
+		}
+		if err != nil {
+			logger.Error(err, "the ByoHost is not part of the debug bundle")
+			k8sClient = nil
+		}
+		if err := collectDebug(context.TODO(), k8sClient, hostName, privilege.Escalator{Sudo: escalateWithSudo}, os.Stdout, logger); err != nil {
+			logger.Error(err, "debug bundle collection failed")
+			os.Exit(1)
+		}
+		return
+	}
+
+	securityPosture := checkHostSecurity(logger)
+
+	config, err := loadHostKubeConfig(logger, hostName)
+	if err != nil {
+		logger.Error(err, "error getting kubeconfig")
+		os.Exit(1)
+	}
 
 	k8sClient, err := client.New(config, client.Options{Scheme: scheme})
 	if err != nil {
 		logger.Error(err, "error creating a new k8s client")
 		return
 	}
+	warnExcessPermissions(context.TODO(), config, logger)
 
-	hostName, err := os.Hostname()
-	if err != nil {
-		logger.Error(err, "could not determine hostname")
+	if (byohostNameTemplate != "" || byohostNamePrefix != "") && !feature.Gates.Enabled(feature.SecureAccess) {
+		if hostName, err = resolveHostName(context.TODO(), k8sClient, hostName); err != nil {
+			logger.Error(err, "could not resolve the name of the ByoHost", "name", hostName)
+			os.Exit(1)
+		}
+		hostnameOverride = hostName
+	}
+
+	if pflag.Arg(0) == decommissionCommand {
+		if err := decommission(context.TODO(), k8sClient, hostName, logger); err != nil {
+			logger.Error(err, "host decommission failed")
+			os.Exit(1)
+		}
+		return
+	}
+
+	if dryRunMode {
+		if err := dryRun(context.TODO(), k8sClient, hostName, os.Stdout, logger); err != nil {
+			logger.Error(err, "host dry run failed")
+			os.Exit(1)
+		}
 		return
 	}
 
-	err = handleHostRegistration(k8sClient, hostName, logger)
+	// only the operations needing root are escalated, the agent itself runs as a dedicated user
+	escalator := privilege.Escalator{Sudo: escalateWithSudo}
+	if os.Geteuid() != 0 && !escalateWithSudo {
+		logger.Info("the agent is not running as root, set the escalate-with-sudo flag for the host to be installed and bootstrapped")
+	}
+
+	if skipInstallation {
+		logger.Info("skip-installation flag set, skipping installer initialisation")
+	} else if useInstallerController {
+		logger.Info("use-installer-controller flag set, skipping intree installer")
+	} else {
+		// increasing installer log level to 1, so that it wont be logged by default
+		i, err := installer.New(downloadpath, installer.BundleTypeK8s, logger.WithName("installer").V(1))
+		if err != nil {
+			logger.Error(err, "failed to instantiate installer")
+		} else {
+			i.SetProxy(proxy)
+			i.SetInstallMode(installer.InstallMode(installMode))
+			i.SetStagedBundlePath(stagedBundlePath)
+			i.SetAuditLog(installerAuditLog)
+			i.SetDownloadRateLimit(downloadRateLimitBytes)
+			i.SetEscalator(escalator)
+			k8sInstaller = i
+		}
+		r, err := installer.New(downloadpath, installer.BundleTypeRKE2, logger.WithName("installer").V(1))
+		if err != nil {
+			logger.Error(err, "failed to instantiate rke2 installer")
+		} else {
+			r.SetProxy(proxy)
+			r.SetStagedBundlePath(stagedBundlePath)
+			r.SetAuditLog(installerAuditLog)
+			r.SetDownloadRateLimit(downloadRateLimitBytes)
+			r.SetEscalator(escalator)
+			rke2Installer = r
+		}
+	}
+
+	if feature.Gates.Enabled(feature.AgentAutoUpgrade) {
+		u, err := upgrader.New(downloadpath, agentUpgradePublicKey, logger.WithName("upgrader").V(1))
+		if err != nil {
+			logger.Error(err, "failed to instantiate agent upgrader")
+		} else {
+			agentUpgrader = u
+		}
+	}
+
+	var preflightChecker reconciler.IPreflightChecker
+	if !skipPreflightChecks {
+		// the in-tree installer and the installer controller configure the kernel themselves
+		preflightChecker = &preflight.Checker{CheckKernelConfig: skipInstallation}
+	}
+
+	// the watchdog outlives the restarts of the agent subsystems it triggers
+	agentWatchdog := &watchdog.Watchdog{
+		HungTimeout:        reconcileHungTimeout,
+		CrashLoopThreshold: crashLoopThreshold,
+		CrashLoopWindow:    crashLoopWindow,
+	}
+
+	shutdownTracing, err := tracing.Setup(context.TODO(), "byoh-hostagent", tracingEndpoint, tracingInsecure)
+	if err != nil {
+		logger.Error(err, "failed to set up tracing")
+		os.Exit(1)
+	}
+	defer func() {
+		if err := shutdownTracing(context.TODO()); err != nil {
+			logger.Error(err, "failed to export the pending spans")
+		}
+	}()
+
+	run := func(ctx context.Context, config *rest.Config) error {
+		for {
+			err := runAgent(ctx, config, hostName, escalator, preflightChecker, agentWatchdog, securityPosture, logger)
+			if !errors.Is(err, watchdog.ErrRestart) || ctx.Err() != nil {
+				return err
+			}
+			logger.Info("Restarted the agent subsystems")
+		}
+	}
+	if failoverKubeconfigs == "" {
+		err = run(ctrl.SetupSignalHandler(), config)
+	} else {
+		err = runWithFailover(ctrl.SetupSignalHandler(), config, run, logger)
+	}
 	if err != nil {
-		logger.Error(err, "error registering host %s registration in namespace %s", hostName, namespace)
+		logger.Error(err, "problem running manager")
 		return
 	}
+}
+
+// runAgent registers the host in the management cluster and runs the host reconciler until
+// the context is done
+func runAgent(ctx context.Context, config *rest.Config, hostName string, escalator privilege.Escalator,
+	preflightChecker reconciler.IPreflightChecker, agentWatchdog *watchdog.Watchdog, securityPosture *clusterv1.Condition, logger logr.Logger) error {
+	k8sClient, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return fmt.Errorf("error creating a new k8s client: %w", err)
+	}
+
+	if err := handleHostRegistration(k8sClient, hostName, logger); err != nil {
+		return fmt.Errorf("error registering host %s in namespace %s: %w", hostName, namespace, err)
+	}
+
+	// the manager only serves plain HTTP, serve the metrics ourselves when TLS is requested
+	managerMetricsBindAddress := metricsbindaddress
+	if metricsCertFile != "" {
+		managerMetricsBindAddress = "0"
+	}
 
 	mgr, err := ctrl.NewManager(config, ctrl.Options{
 		Scheme:    scheme,
@@ -195,62 +629,292 @@ func main() {
 			},
 		},
 		),
-		MetricsBindAddress: metricsbindaddress,
+		// the bootstrap secret lives in the namespace of the ByoMachine, which is not
+		// the namespace of the host when it is claimed from a host pool namespace
+		ClientDisableCacheFor: []client.Object{&corev1.Secret{}},
+		MetricsBindAddress:    managerMetricsBindAddress,
 	})
 	if err != nil {
-		logger.Error(err, "unable to start manager")
-		return
+		return fmt.Errorf("unable to start manager: %w", err)
 	}
 
-	if skipInstallation {
-		logger.Info("skip-installation flag set, skipping installer initialisation")
-	} else if useInstallerController {
-		logger.Info("use-installer-controller flag set, skipping intree installer")
-	} else {
-		// increasing installer log level to 1, so that it wont be logged by default
-		k8sInstaller, err = installer.New(downloadpath, installer.BundleTypeK8s, logger.V(1))
+	if err := mgr.Add(&registration.Heartbeat{
+		K8sClient: mgr.GetClient(),
+		Host:      types.NamespacedName{Namespace: namespace, Name: hostName},
+		Logger:    logger.WithName("heartbeat"),
+	}); err != nil {
+		return fmt.Errorf("unable to add the heartbeat to the manager: %w", err)
+	}
+	if err := mgr.Add(&watchdog.Supervisor{
+		Watchdog:  agentWatchdog,
+		K8sClient: mgr.GetClient(),
+		Host:      types.NamespacedName{Namespace: namespace, Name: hostName},
+		Logger:    logger.WithName("watchdog"),
+	}); err != nil {
+		return fmt.Errorf("unable to add the watchdog to the manager: %w", err)
+	}
+	if nodeComponents != "" {
+		if err := mgr.Add(&nodekeeper.Keeper{
+			K8sClient: mgr.GetClient(),
+			Host:      types.NamespacedName{Namespace: namespace, Name: hostName},
+			Systemd:   nodekeeper.Systemctl{Escalator: escalator},
+			Units:     strings.Split(nodeComponents, ","),
+			Interval:  nodeComponentsCheckInterval,
+			Logger:    logger.WithName("nodekeeper"),
+		}); err != nil {
+			return fmt.Errorf("unable to add the node keeper to the manager: %w", err)
+		}
+	}
+	if configFile != "" {
+		if err := mgr.Add(newConfigReloader(configFile, hostName, pflag.CommandLine, logger.WithName("config"))); err != nil {
+			return fmt.Errorf("unable to set up the configuration reload: %w", err)
+		}
+	}
+
+	if logBuffer != nil {
+		if err := mgr.Add(&logstream.Streamer{
+			Client: k8sClient,
+			Host:   types.NamespacedName{Namespace: namespace, Name: hostName},
+			Buffer: logBuffer,
+			Logger: logger.WithName("logstream"),
+		}); err != nil {
+			return fmt.Errorf("unable to set up the log streaming: %w", err)
+		}
+	}
+
+	if metricsCertFile != "" && metricsbindaddress != "0" {
+		err = mgr.Add(&agentmetrics.Server{
+			BindAddress:  metricsbindaddress,
+			CertFile:     metricsCertFile,
+			KeyFile:      metricsKeyFile,
+			ClientCAFile: metricsClientCAFile,
+			Logger:       logger.WithName("metrics"),
+		})
 		if err != nil {
-			logger.Error(err, "failed to instantiate installer")
+			return fmt.Errorf("unable to set up metrics server: %w", err)
+		}
+	}
+
+	// the events and the ByoHost updates are buffered while the management cluster is unreachable
+	offlineQueue := &offline.Queue{
+		Client:   k8sClient,
+		Recorder: mgr.GetEventRecorderFor("hostagent-controller"),
+		Host:     types.NamespacedName{Namespace: namespace, Name: hostName},
+		Logger:   logger.WithName("offline"),
+	}
+
+	var reconcileTrigger chan event.GenericEvent
+	if localAPISocket != "" {
+		reconcileTrigger = make(chan event.GenericEvent, 1)
+		err = mgr.Add(&localapi.Server{
+			SocketPath:   localAPISocket,
+			Client:       mgr.GetClient(),
+			Host:         types.NamespacedName{Namespace: namespace, Name: hostName},
+			AgentVersion: version.Get().GitVersion,
+			Offline:      offlineQueue.Offline,
+			Trigger:      reconcileTrigger,
+			Logger:       logger.WithName("localapi"),
+		})
+		if err != nil {
+			return fmt.Errorf("unable to set up the local API: %w", err)
 		}
 	}
 
 	hostReconciler := &reconciler.HostReconciler{
 		Client:                 k8sClient,
-		CmdRunner:              cloudinit.CmdRunner{},
-		FileWriter:             cloudinit.FileWriter{},
+		CmdRunner:              cloudinit.CmdRunner{Escalator: escalator},
+		FileWriter:             cloudinit.FileWriter{Escalator: escalator},
 		TemplateParser:         setupTemplateParser(),
-		Recorder:               mgr.GetEventRecorderFor("hostagent-controller"),
+		Recorder:               offlineQueue,
 		K8sInstaller:           k8sInstaller,
 		SkipK8sInstallation:    skipInstallation,
 		UseInstallerController: useInstallerController,
+		AgentUpgrader:          agentUpgrader,
+		AgentVersion:           version.Get().GitVersion,
+		NodeIP:                 nodeIP,
+		HostnameOverride:       hostnameOverride,
+		HostLabels:             hostLabels,
+		PreflightChecker:       preflightChecker,
+		RKE2Installer:          rke2Installer,
+		OfflineQueue:           offlineQueue,
+		Escalator:              escalator,
+		ReconcileTrigger:       reconcileTrigger,
+		Watchdog:               agentWatchdog,
+		KubeletRootDir:         instanceKubeletRootDir(hostInstanceSuffix),
+		MachineID:              installedMachineID(logger),
+		// the agent instances sharing the machine share its /etc/kubernetes
+		CheckInstallArtifacts: hostInstanceSuffix == "",
+		SecurityPosture:       securityPosture,
 	}
 
-	if err = hostReconciler.SetupWithManager(context.TODO(), mgr); err != nil {
-		logger.Error(err, "unable to create controller")
-		return
+	if err = hostReconciler.SetupWithManager(ctx, mgr); err != nil {
+		return fmt.Errorf("unable to create controller: %w", err)
 	}
 
-	// if secure-access is enabled
-	if feature.Gates.Enabled(feature.SecureAccess) {
-		err := generateKubeConfig(logger, hostName, bootstrapKubeConfig)
+	return mgr.Start(ctx)
+}
+
+// runWithFailover runs the agent against the primary management cluster, failing over to the
+// standby management clusters of the failover kubeconfigs when it becomes unreachable
+func runWithFailover(ctx context.Context, primary *rest.Config, run func(ctx context.Context, config *rest.Config) error, logger logr.Logger) error {
+	endpoints := []failover.Endpoint{{Name: "primary", Config: primary}}
+	for _, path := range strings.Split(failoverKubeconfigs, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		config, err := registration.LoadRESTClientConfig(path)
 		if err != nil {
-			logger.Error(err, "kubeconfig creation failed")
-			os.Exit(1)
+			return fmt.Errorf("failed to load the failover kubeconfig %s: %w", path, err)
 		}
+		endpoints = append(endpoints, failover.Endpoint{Name: path, Config: config})
+	}
+	f := &failover.Failover{
+		Endpoints:        endpoints,
+		ProbeInterval:    failoverProbeInterval,
+		FailureThreshold: failoverThreshold,
+		Logger:           logger.WithName("failover"),
 	}
+	return f.Run(ctx, func(ctx context.Context, endpoint failover.Endpoint) error {
+		return run(ctx, endpoint.Config)
+	})
+}
 
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
-		logger.Error(err, "problem running manager")
-		return
+// loadHostKubeConfig returns the config used to talk to the management cluster.
+// With secure access the host kubeconfig is generated from the bootstrap kubeconfig
+// on the first start, so the short-lived bootstrap credentials are not needed afterwards.
+func loadHostKubeConfig(logger logr.Logger, hostName string) (*rest.Config, error) {
+	if !feature.Gates.Enabled(feature.SecureAccess) {
+		return ctrl.GetConfig()
 	}
+
+	kubeConfigPath, err := hostKubeConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	sealer, err := newSealer()
+	if err != nil {
+		return nil, err
+	}
+	store, err := newKeyStore(sealer)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(kubeConfigPath); os.IsNotExist(err) {
+		if bootstrapKubeConfig == "" {
+			return nil, fmt.Errorf("no kubeconfig found at %s and no bootstrap kubeconfig provided", kubeConfigPath)
+		}
+		if err := generateKubeConfig(logger, hostName, bootstrapKubeConfig, kubeConfigPath, store, sealer); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	} else if sealer != nil {
+		// the kubeconfig was written before the credential encryption was set
+		sealed, err := registration.SealKubeconfig(kubeConfigPath, sealer)
+		if err != nil {
+			return nil, err
+		}
+		if sealed {
+			logger.Info("Encrypted the host kubeconfig", "path", kubeConfigPath, "encryption", credentialEncryption)
+		}
+	}
+	return hostRESTConfig(kubeConfigPath, store, sealer)
+}
+
+// hostRESTConfig loads the host kubeconfig, decrypted in memory by the sealer. When the private key
+// cannot leave the key store, the kubeconfig holds no client credentials and the client certificate
+// is added with the key of the store.
+func hostRESTConfig(kubeConfigPath string, store registration.KeyStore, sealer registration.Sealer) (*rest.Config, error) {
+	config, err := registration.LoadSealedRESTClientConfig(kubeConfigPath, sealer)
+	if err != nil || store.Exportable() {
+		return config, err
+	}
+	// the key type is validated on startup
+	parsedKeyType, _ := registration.ParseKeyType(keyType)
+	signer, err := store.Signer(parsedKeyType)
+	if err != nil {
+		return nil, err
+	}
+	certPath, err := privateKeyDirPath(registration.ClientCertificateFile)
+	if err != nil {
+		return nil, err
+	}
+	return registration.WithClientCertificate(config, certPath, signer)
+}
+
+// newSealer returns the sealer encrypting the host credentials at rest, nil when they are not encrypted
+func newSealer() (registration.Sealer, error) {
+	return registration.NewSealer(&registration.SealerConfig{
+		Type:      registration.CredentialEncryption(credentialEncryption),
+		KeyFile:   credentialKeyFile,
+		TPMDevice: tpmDevice,
+	})
+}
+
+// newKeyStore returns the key store of the private key of the host client certificate
+func newKeyStore(sealer registration.Sealer) (registration.KeyStore, error) {
+	dir, err := privateKeyDirPath("")
+	if err != nil {
+		return nil, err
+	}
+	return registration.NewKeyStore(&registration.KeyStoreConfig{
+		Type:             registration.KeyStoreType(keyStore),
+		PrivateKeyDir:    dir,
+		TPMDevice:        tpmDevice,
+		PKCS11Module:     pkcs11Module,
+		PKCS11TokenLabel: pkcs11TokenLabel,
+		PKCS11KeyLabel:   pkcs11KeyLabel,
+		PKCS11Pin:        os.Getenv(registration.PKCS11PinEnv),
+		Sealer:           sealer,
+	})
+}
+
+// privateKeyDirPath returns the path of a file of the private key directory, $HOME/.byoh by default
+func privateKeyDirPath(name string) (string, error) {
+	if privateKeyDir != "" {
+		return filepath.Join(privateKeyDir, name), nil
+	}
+	return byohDirPath(name)
+}
+
+// hostKubeConfigPath returns the path of the kubeconfig generated from the bootstrap kubeconfig
+func hostKubeConfigPath() (string, error) {
+	if hostKubeConfig != "" {
+		return hostKubeConfig, nil
+	}
+	return byohDirPath("config")
+}
+
+// byohDirPath returns the path of a file of the agent in the $HOME/.byoh directory, in its
+// $HOME/.byoh/<suffix> subdirectory for an instance of the machine
+func byohDirPath(name string) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".byoh", hostInstanceSuffix, name), nil
+}
+
+// installedMachineID returns the hashed machine-id of the host, recorded once its node is bootstrapped to
+// detect the host being reimaged, or an empty string when the host has no machine-id
+func installedMachineID(logger logr.Logger) string {
+	id, err := registration.MachineID(registration.HostIdentityMachineID, os.ReadFile)
+	if err != nil {
+		logger.Info("could not determine the machine-id, the host is not checked for reimages", "error", err.Error())
+		return ""
+	}
+	return id
 }
 
 // generateKubeConfig will create a CertificateSigningRequest for the host
 // its running on and once the CSR is approved it will fetch the Certificate
 // and create a kubeconfig which will be used then by the host reconciler
-func generateKubeConfig(logger logr.Logger, hostName, boostrapKubeConfigPath string) error {
+func generateKubeConfig(logger logr.Logger, hostName, boostrapKubeConfigPath, kubeConfigPath string, store registration.KeyStore, sealer registration.Sealer) error {
 	logger.Info("creating host csr", "name", fmt.Sprintf(registration.ByohCSRNameFormat, hostName))
-	bootstrapClientConfig, err := registration.LoadRESTClientConfig(bootstrapKubeConfig)
+	bootstrapClientConfig, err := registration.LoadRESTClientConfig(boostrapKubeConfigPath)
 	if err != nil {
 		return err
 	}
@@ -258,25 +922,48 @@ func generateKubeConfig(logger logr.Logger, hostName, boostrapKubeConfigPath str
 	if err != nil {
 		return err
 	}
-	byohCSR := registration.ByohCSR{BootstrapClient: bootstrapClient}
-	reqName, reqUID, err := byohCSR.RequestBYOHClientCert(hostName)
+	// the key type is validated on startup
+	parsedKeyType, _ := registration.ParseKeyType(keyType)
+	byohCSR := registration.ByohCSR{
+		BootstrapClient: bootstrapClient,
+		KeyType:         parsedKeyType,
+		KeyStore:        store,
+		SignerName:      csrSignerName,
+		ApprovalTimeout: csrApprovalTimeout,
+		MaxRequests:     csrMaxRequests,
+		RetryJitter:     csrRetryJitter,
+	}
+	if delay := faultinject.Delay(faultinject.DelayCSRIssuance, nil); delay > 0 {
+		logger.Info("Delaying the client certificate issuance", "fault", faultinject.DelayCSRIssuance, "delay", delay)
+		time.Sleep(delay)
+	}
+	// wait for certificate to be issued, requesting it again when not approved in time
+	logger.Info("Waiting for client certificate to be issued", "timeout", csrApprovalTimeout)
+	certData, err := byohCSR.WaitForBYOHClientCert(context.TODO(), hostName)
 	if err != nil {
 		return err
 	}
-	// wait for certificate to be issued
-	ctx, cancel := context.WithTimeout(context.TODO(), registration.CSRApprovalTimeout)
-	defer cancel()
-	logger.Info("Waiting for client certificate to be issued")
-	certData, err := csr.WaitForCertificate(ctx, bootstrapClient, reqName, reqUID)
+	if !store.Exportable() {
+		// the private key never leaves the key store, so the kubeconfig holds no client credentials
+		certPath, err := privateKeyDirPath(registration.ClientCertificateFile)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(certPath, certData, 0o600); err != nil {
+			return err
+		}
+		certData = nil
+	}
+	err = registration.WriteSealedKubeconfigFromBootstrapping(bootstrapClientConfig, kubeConfigPath, string(certData), string(byohCSR.PrivateKey), sealer)
 	if err != nil {
 		return err
 	}
-	err = registration.WriteKubeconfigFromBootstrapping(bootstrapClientConfig, "~/.byoh/config", string(certData), string(byohCSR.PrivateKey))
+	logger.Info("kubeconfig created")
+	keyPath, err := privateKeyDirPath(registration.TmpPrivateKey)
 	if err != nil {
 		return err
 	}
-	logger.Info("kubeconfig created")
-	if err := os.Remove(registration.TmpPrivateKey); err != nil && !os.IsNotExist(err) {
+	if err := os.Remove(keyPath); err != nil && !os.IsNotExist(err) {
 		logger.Error(err, "Failed cleaning up private key file")
 	}
 	return nil
@@ -0,0 +1,7 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package metrics contains the prometheus metrics reported by the host agent.
+// The metrics are registered with the controller-runtime registry, so they are
+// served along with the controller-runtime reconcile metrics.
+package metrics
@@ -0,0 +1,98 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	namespace = "byoh_agent"
+
+	// PhaseDownload is the bundle download phase
+	PhaseDownload = "download"
+	// PhaseInstall is the k8s components installation phase
+	PhaseInstall = "install"
+	// PhaseUninstall is the k8s components uninstallation phase
+	PhaseUninstall = "uninstall"
+	// PhaseBootstrap is the k8s node bootstrap phase
+	PhaseBootstrap = "bootstrap"
+	// PhaseReset is the k8s node reset phase
+	PhaseReset = "reset"
+)
+
+var (
+	// InstallPhaseDuration is the time taken by each of the phases of bringing up a k8s node
+	InstallPhaseDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "install_phase_duration_seconds",
+		Help:      "Duration of the k8s node install phases in seconds",
+		Buckets:   []float64{1, 5, 10, 30, 60, 120, 300, 600, 1200},
+	}, []string{"phase"})
+
+	// BundleDownloadBytes is the total size of the downloaded bundles
+	BundleDownloadBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "bundle_download_bytes_total",
+		Help:      "Total number of bytes of the downloaded bundles",
+	})
+
+	// Errors counts the errors hit by the agent, by reason
+	Errors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "errors_total",
+		Help:      "Total number of errors hit by the agent",
+	}, []string{"reason"})
+
+	heartbeatMutex sync.RWMutex
+	lastHeartbeat  time.Time
+	now            = time.Now
+
+	// HeartbeatAge is the time since the agent last synced with the management cluster
+	HeartbeatAge = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "heartbeat_age_seconds",
+		Help:      "Seconds since the agent last synced its ByoHost with the management cluster",
+	}, heartbeatAge)
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		InstallPhaseDuration,
+		BundleDownloadBytes,
+		Errors,
+		HeartbeatAge,
+	)
+}
+
+// RecordHeartbeat marks a successful sync with the management cluster
+func RecordHeartbeat() {
+	heartbeatMutex.Lock()
+	defer heartbeatMutex.Unlock()
+	lastHeartbeat = now()
+}
+
+// ObservePhase records the duration of an install phase started at start
+func ObservePhase(phase string, start time.Time) {
+	InstallPhaseDuration.WithLabelValues(phase).Observe(now().Sub(start).Seconds())
+}
+
+// RecordError increments the error counter for the given reason
+func RecordError(reason string) {
+	Errors.WithLabelValues(reason).Inc()
+}
+
+// heartbeatAge returns the seconds since the last heartbeat, or 0 if there was none yet
+func heartbeatAge() float64 {
+	heartbeatMutex.RLock()
+	defer heartbeatMutex.RUnlock()
+	if lastHeartbeat.IsZero() {
+		return 0
+	}
+	return now().Sub(lastHeartbeat).Seconds()
+}
@@ -0,0 +1,194 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// nolint: testpackage
+package metrics
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var _ = Describe("Agent metrics", func() {
+	Context("Heartbeat", func() {
+		var fakeNow time.Time
+
+		BeforeEach(func() {
+			fakeNow = time.Now()
+			now = func() time.Time { return fakeNow }
+		})
+
+		AfterEach(func() {
+			now = time.Now
+		})
+
+		It("should report the seconds since the last heartbeat", func() {
+			RecordHeartbeat()
+			fakeNow = fakeNow.Add(42 * time.Second)
+			Expect(testutil.ToFloat64(HeartbeatAge)).To(Equal(float64(42)))
+		})
+	})
+
+	It("should count the errors by reason", func() {
+		before := testutil.ToFloat64(Errors.WithLabelValues("TestFailed"))
+		RecordError("TestFailed")
+		Expect(testutil.ToFloat64(Errors.WithLabelValues("TestFailed"))).To(Equal(before + 1))
+	})
+
+	It("should observe the phase durations", func() {
+		before := testutil.CollectAndCount(InstallPhaseDuration)
+		ObservePhase("test-phase", time.Now())
+		Expect(testutil.CollectAndCount(InstallPhaseDuration)).To(Equal(before + 1))
+	})
+
+	Context("Server", func() {
+		var (
+			tmpDir     string
+			server     *Server
+			clientCert tls.Certificate
+			caPool     *x509.CertPool
+			cancel     context.CancelFunc
+			address    string
+		)
+
+		BeforeEach(func() {
+			var err error
+			tmpDir, err = os.MkdirTemp("", "metrics")
+			Expect(err).NotTo(HaveOccurred())
+
+			caCert, caKey := newCert(nil, nil, "test-ca")
+			caPool = x509.NewCertPool()
+			caPool.AddCert(caCert)
+			writePEM(filepath.Join(tmpDir, "ca.crt"), "CERTIFICATE", caCert.Raw)
+
+			cert, key := newCert(caCert, caKey, "127.0.0.1")
+			writePEM(filepath.Join(tmpDir, "tls.crt"), "CERTIFICATE", cert.Raw)
+			keyBytes, err := x509.MarshalECPrivateKey(key)
+			Expect(err).NotTo(HaveOccurred())
+			writePEM(filepath.Join(tmpDir, "tls.key"), "EC PRIVATE KEY", keyBytes)
+
+			cCert, cKey := newCert(caCert, caKey, "scraper")
+			clientCert = tls.Certificate{Certificate: [][]byte{cCert.Raw}, PrivateKey: cKey}
+
+			server = &Server{
+				CertFile:     filepath.Join(tmpDir, "tls.crt"),
+				KeyFile:      filepath.Join(tmpDir, "tls.key"),
+				ClientCAFile: filepath.Join(tmpDir, "ca.crt"),
+				Logger:       logr.Discard(),
+			}
+		})
+
+		JustBeforeEach(func() {
+			config, err := server.tlsConfig()
+			Expect(err).NotTo(HaveOccurred())
+			listener, err := tls.Listen("tcp", "127.0.0.1:0", config)
+			Expect(err).NotTo(HaveOccurred())
+			address = listener.Addr().(*net.TCPAddr).String()
+
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			go func() {
+				defer GinkgoRecover()
+				Expect(server.serve(ctx, listener)).To(Succeed())
+			}()
+		})
+
+		AfterEach(func() {
+			cancel()
+			Expect(os.RemoveAll(tmpDir)).To(Succeed())
+		})
+
+		scrape := func(certs ...tls.Certificate) (string, error) {
+			client := &http.Client{Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{RootCAs: caPool, Certificates: certs, MinVersion: tls.VersionTLS12},
+			}}
+			resp, err := client.Get(fmt.Sprintf("https://%s%s", address, DefaultMetricsEndpoint))
+			if err != nil {
+				return "", err
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			return string(body), err
+		}
+
+		It("should serve the metrics to clients with a valid certificate", func() {
+			RecordError("ScrapeTest")
+			body, err := scrape(clientCert)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(body).To(ContainSubstring(`byoh_agent_errors_total{reason="ScrapeTest"}`))
+		})
+
+		It("should reject clients without a certificate", func() {
+			_, err := scrape()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should reject clients with a certificate not signed by the client CA", func() {
+			otherCA, otherKey := newCert(nil, nil, "other-ca")
+			cert, key := newCert(otherCA, otherKey, "scraper")
+			_, err := scrape(tls.Certificate{Certificate: [][]byte{cert.Raw}, PrivateKey: key})
+			Expect(err).To(HaveOccurred())
+		})
+
+		Context("When no client CA is set", func() {
+			BeforeEach(func() {
+				server.ClientCAFile = ""
+			})
+
+			It("should serve the metrics to clients without a certificate", func() {
+				_, err := scrape()
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
+})
+
+// newCert returns a certificate for name signed by parent, or a self-signed CA if parent is nil
+func newCert(parent *x509.Certificate, parentKey *ecdsa.PrivateKey, name string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if ip := net.ParseIP(name); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	Expect(err).NotTo(HaveOccurred())
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).NotTo(HaveOccurred())
+	return cert, key
+}
+
+func writePEM(path, blockType string, data []byte) {
+	Expect(os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data}), 0600)).To(Succeed())
+}
@@ -0,0 +1,106 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/fips"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// DefaultMetricsEndpoint is the path the metrics are served on
+	DefaultMetricsEndpoint = "/metrics"
+
+	shutdownTimeout = 10 * time.Second
+)
+
+// Server serves the agent metrics over TLS, optionally verifying client
+// certificates against a CA bundle (mTLS)
+type Server struct {
+	BindAddress  string
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string
+	Logger       logr.Logger
+}
+
+// tlsConfig returns the TLS config of the metrics server
+func (s *Server) tlsConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(s.CertFile, s.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load metrics serving certificate: %w", err)
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if fips.Enabled() {
+		fips.ConfigureTLS(config)
+	}
+	if s.ClientCAFile == "" {
+		return config, nil
+	}
+
+	caBytes, err := os.ReadFile(s.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metrics client CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caBytes) {
+		return nil, fmt.Errorf("no certificates found in %s", s.ClientCAFile)
+	}
+	config.ClientCAs = pool
+	config.ClientAuth = tls.RequireAndVerifyClientCert
+	return config, nil
+}
+
+// Start implements manager.Runnable, serving the metrics until ctx is done
+func (s *Server) Start(ctx context.Context) error {
+	config, err := s.tlsConfig()
+	if err != nil {
+		return err
+	}
+	listener, err := tls.Listen("tcp", s.BindAddress, config)
+	if err != nil {
+		return err
+	}
+	return s.serve(ctx, listener)
+}
+
+func (s *Server) serve(ctx context.Context, listener net.Listener) error {
+	mux := http.NewServeMux()
+	mux.Handle(DefaultMetricsEndpoint, promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{
+		ErrorHandling: promhttp.HTTPErrorOnError,
+	}))
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: shutdownTimeout,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			s.Logger.Error(err, "error shutting down the metrics server")
+		}
+	}()
+
+	s.Logger.Info("Serving metrics", "address", listener.Addr().String(), "mTLS", s.ClientCAFile != "")
+	if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
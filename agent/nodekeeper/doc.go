@@ -0,0 +1,7 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package nodekeeper keeps the node components of a bootstrapped host running. It restarts the failed
+// systemd units of containerd and the kubelet with a backoff, rather than leaving the node NotReady until
+// someone logs in the host, and reports their health through the NodeComponentsHealthy condition of the ByoHost.
+package nodekeeper
@@ -0,0 +1,214 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nodekeeper
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/registration"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultCheckInterval is how often the units of the node components are checked
+	DefaultCheckInterval = 15 * time.Second
+
+	// DefaultInitialBackoff is how long the keeper waits before restarting a unit again after its first restart
+	DefaultInitialBackoff = 10 * time.Second
+
+	// DefaultMaxBackoff caps the backoff of the restarts of a unit. The restarts of a unit are counted
+	// again from zero once it ran that long.
+	DefaultMaxBackoff = 5 * time.Minute
+)
+
+// DefaultUnits are the systemd units of the node components kept running
+var DefaultUnits = []string{"containerd", "kubelet"}
+
+// Keeper checks the systemd units of the node components every interval once the node of the host is
+// bootstrapped, i.e. its K8sNodeBootstrapSucceeded condition is true, until it is reset. The failed or stopped units are
+// restarted, the backoff between the restarts of a unit doubling up to the max backoff. The health of
+// the units is reported in the NodeComponentsHealthy condition of the host, removed before the bootstrap.
+type Keeper struct {
+	K8sClient client.Client
+	Host      types.NamespacedName
+	Systemd   Systemd
+	// Units defaults to DefaultUnits, the units not installed on the host are ignored
+	Units []string
+	// Interval defaults to DefaultCheckInterval
+	Interval time.Duration
+	// InitialBackoff defaults to DefaultInitialBackoff
+	InitialBackoff time.Duration
+	// MaxBackoff defaults to DefaultMaxBackoff
+	MaxBackoff time.Duration
+	Logger     logr.Logger
+
+	// restarts are the restarts of the failed units, by unit
+	restarts map[string]*restarts
+	// reported is the NodeComponentsHealthy condition last reported, nil once it is removed
+	reported *clusterv1.Condition
+	synced   bool
+	now      func() time.Time
+}
+
+// restarts are the restarts of a failed unit
+type restarts struct {
+	count int
+	last  time.Time
+	next  time.Time
+}
+
+// Start keeps the node components running until the context is done. The failed checks are
+// logged and run again on the next interval.
+func (k *Keeper) Start(ctx context.Context) error {
+	interval := k.Interval
+	if interval == 0 {
+		interval = DefaultCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := k.Keep(ctx); err != nil {
+			k.Logger.Error(err, "failed to keep the node components running")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Keep checks the units of the node components of the bootstrapped host, restarts the failed ones whose
+// backoff elapsed and reports their health
+func (k *Keeper) Keep(ctx context.Context) error {
+	byoHost := &infrastructurev1beta1.ByoHost{}
+	if err := k.K8sClient.Get(ctx, k.Host, byoHost); err != nil {
+		return err
+	}
+	if !conditions.IsTrue(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded) {
+		k.restarts = nil
+		return k.report(ctx, byoHost, nil)
+	}
+	if beingReset(byoHost) {
+		return nil
+	}
+	return k.report(ctx, byoHost, k.check())
+}
+
+// beingReset tells whether the node of the host is about to be reset by the host agent, which stops its components
+func beingReset(byoHost *infrastructurev1beta1.ByoHost) bool {
+	_, cleanup := byoHost.Annotations[infrastructurev1beta1.HostCleanupAnnotation]
+	return cleanup || byoHost.Status.MachineRef == nil || !byoHost.DeletionTimestamp.IsZero()
+}
+
+// check restarts the failed units whose backoff elapsed, and returns the NodeComponentsHealthy condition
+func (k *Keeper) check() *clusterv1.Condition {
+	now := k.clock()
+	if k.restarts == nil {
+		k.restarts = map[string]*restarts{}
+	}
+	units := k.Units
+	if units == nil {
+		units = DefaultUnits
+	}
+
+	var failed []string
+	for _, unit := range units {
+		state, err := k.Systemd.ActiveState(unit)
+		if err != nil {
+			return conditions.FalseCondition(infrastructurev1beta1.NodeComponentsHealthy, infrastructurev1beta1.NodeComponentsCheckErrorReason,
+				clusterv1.ConditionSeverityWarning, "%s", err.Error())
+		}
+		unitRestarts := k.restarts[unit]
+		if state != "failed" && state != "inactive" {
+			// the unit not installed, running or being started by systemd
+			if unitRestarts != nil && now.Sub(unitRestarts.last) >= k.maxBackoff() {
+				delete(k.restarts, unit)
+			}
+			continue
+		}
+
+		if unitRestarts == nil {
+			unitRestarts = &restarts{}
+			k.restarts[unit] = unitRestarts
+		}
+		if !now.Before(unitRestarts.next) {
+			k.Logger.Info("Restarting the node component", "unit", unit, "state", state, "restarts", unitRestarts.count)
+			if err := k.Systemd.Restart(unit); err != nil {
+				k.Logger.Error(err, "failed to restart the node component", "unit", unit)
+			}
+			unitRestarts.count++
+			unitRestarts.last = now
+			unitRestarts.next = now.Add(k.backoff(unitRestarts.count))
+		}
+		failed = append(failed, fmt.Sprintf("%s is %s, restarted %d times", unit, state, unitRestarts.count))
+	}
+	if len(failed) > 0 {
+		return conditions.FalseCondition(infrastructurev1beta1.NodeComponentsHealthy, infrastructurev1beta1.NodeComponentsFailedReason,
+			clusterv1.ConditionSeverityWarning, "%s", strings.Join(failed, "; "))
+	}
+	return conditions.TrueCondition(infrastructurev1beta1.NodeComponentsHealthy)
+}
+
+// report applies the NodeComponentsHealthy condition to the host when it changed, or removes it when nil
+func (k *Keeper) report(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost, condition *clusterv1.Condition) error {
+	if k.synced && sameState(k.reported, condition) {
+		return nil
+	}
+	status := &infrastructurev1beta1.ByoHostStatus{}
+	if condition != nil {
+		conditions.Set(byoHost, condition)
+		status.Conditions = clusterv1.Conditions{*conditions.Get(byoHost, infrastructurev1beta1.NodeComponentsHealthy)}
+	}
+	if err := registration.ApplyStatus(ctx, k.K8sClient, byoHost, status, registration.NodeKeeperFieldOwner); err != nil {
+		return err
+	}
+	k.reported, k.synced = condition, true
+	return nil
+}
+
+// backoff returns how long to wait after the restart of a unit before restarting it again
+func (k *Keeper) backoff(restarts int) time.Duration {
+	backoff := k.InitialBackoff
+	if backoff == 0 {
+		backoff = DefaultInitialBackoff
+	}
+	for i := 1; i < restarts && backoff < k.maxBackoff(); i++ {
+		backoff *= 2
+	}
+	if backoff > k.maxBackoff() {
+		return k.maxBackoff()
+	}
+	return backoff
+}
+
+func (k *Keeper) maxBackoff() time.Duration {
+	if k.MaxBackoff == 0 {
+		return DefaultMaxBackoff
+	}
+	return k.MaxBackoff
+}
+
+func (k *Keeper) clock() time.Time {
+	if k.now == nil {
+		return time.Now()
+	}
+	return k.now()
+}
+
+// sameState tells whether the conditions are both nil or have the same status, reason, severity and message
+func sameState(a, b *clusterv1.Condition) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Status == b.Status && a.Reason == b.Reason && a.Severity == b.Severity && a.Message == b.Message
+}
@@ -0,0 +1,169 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// nolint: testpackage
+package nodekeeper
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+)
+
+// fakeSystemd reports the states of the units, the restarted units being active
+type fakeSystemd struct {
+	states   map[string]string
+	restarts []string
+	err      error
+}
+
+func (f *fakeSystemd) ActiveState(unit string) (string, error) {
+	return f.states[unit], f.err
+}
+
+func (f *fakeSystemd) Restart(unit string) error {
+	f.restarts = append(f.restarts, unit)
+	return nil
+}
+
+var _ = Describe("Keeper", func() {
+	var (
+		keeper  *Keeper
+		systemd *fakeSystemd
+		now     time.Time
+		hostKey = types.NamespacedName{Name: "host", Namespace: "default"}
+	)
+
+	getHost := func() *infrastructurev1beta1.ByoHost {
+		byoHost := &infrastructurev1beta1.ByoHost{}
+		Expect(k8sClient.Get(context.TODO(), hostKey, byoHost)).To(Succeed())
+		return byoHost
+	}
+
+	bootstrap := func() {
+		byoHost := getHost()
+		byoHost.Status.MachineRef = &corev1.ObjectReference{Kind: "ByoMachine", Name: "machine", Namespace: hostKey.Namespace}
+		conditions.MarkTrue(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded)
+		Expect(k8sClient.Status().Update(context.TODO(), byoHost)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		byoHost := &infrastructurev1beta1.ByoHost{
+			ObjectMeta: metav1.ObjectMeta{Name: hostKey.Name, Namespace: hostKey.Namespace},
+		}
+		Expect(k8sClient.Create(context.TODO(), byoHost)).To(Succeed())
+		now = time.Now()
+		systemd = &fakeSystemd{states: map[string]string{"containerd": "active", "kubelet": "active"}}
+		keeper = &Keeper{
+			K8sClient: k8sClient,
+			Host:      hostKey,
+			Systemd:   systemd,
+			Logger:    logr.Discard(),
+			now:       func() time.Time { return now },
+		}
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.Delete(context.TODO(), &infrastructurev1beta1.ByoHost{
+			ObjectMeta: metav1.ObjectMeta{Name: hostKey.Name, Namespace: hostKey.Namespace},
+		})).To(Succeed())
+	})
+
+	It("should leave the node components alone before the bootstrap", func() {
+		systemd.states["kubelet"] = "inactive"
+		Expect(keeper.Keep(context.TODO())).To(Succeed())
+		Expect(systemd.restarts).To(BeEmpty())
+		Expect(conditions.Has(getHost(), infrastructurev1beta1.NodeComponentsHealthy)).To(BeFalse())
+	})
+
+	It("should report the healthy node components", func() {
+		bootstrap()
+		Expect(keeper.Keep(context.TODO())).To(Succeed())
+		Expect(systemd.restarts).To(BeEmpty())
+		Expect(conditions.IsTrue(getHost(), infrastructurev1beta1.NodeComponentsHealthy)).To(BeTrue())
+	})
+
+	It("should restart the failed node components with backoff", func() {
+		bootstrap()
+		systemd.states["kubelet"] = "failed"
+		Expect(keeper.Keep(context.TODO())).To(Succeed())
+		Expect(systemd.restarts).To(Equal([]string{"kubelet"}))
+		Expect(*conditions.Get(getHost(), infrastructurev1beta1.NodeComponentsHealthy)).To(conditions.MatchCondition(clusterv1.Condition{
+			Type:     infrastructurev1beta1.NodeComponentsHealthy,
+			Status:   corev1.ConditionFalse,
+			Severity: clusterv1.ConditionSeverityWarning,
+			Reason:   infrastructurev1beta1.NodeComponentsFailedReason,
+			Message:  "kubelet is failed, restarted 1 times",
+		}))
+
+		By("waiting for the backoff before restarting it again")
+		now = now.Add(5 * time.Second)
+		Expect(keeper.Keep(context.TODO())).To(Succeed())
+		Expect(systemd.restarts).To(HaveLen(1))
+		now = now.Add(5 * time.Second)
+		Expect(keeper.Keep(context.TODO())).To(Succeed())
+		Expect(systemd.restarts).To(HaveLen(2))
+		Expect(keeper.backoff(2)).To(Equal(20 * time.Second))
+		Expect(keeper.backoff(10)).To(Equal(DefaultMaxBackoff))
+
+		By("reporting the node components healthy once restarted")
+		systemd.states["kubelet"] = "active"
+		Expect(keeper.Keep(context.TODO())).To(Succeed())
+		Expect(conditions.IsTrue(getHost(), infrastructurev1beta1.NodeComponentsHealthy)).To(BeTrue())
+		Expect(keeper.restarts).To(HaveKey("kubelet"))
+
+		By("forgetting the restarts once the unit ran the max backoff")
+		now = now.Add(DefaultMaxBackoff)
+		Expect(keeper.Keep(context.TODO())).To(Succeed())
+		Expect(keeper.restarts).To(BeEmpty())
+	})
+
+	It("should ignore the units not installed on the host", func() {
+		bootstrap()
+		keeper.Units = []string{"docker", "kubelet"}
+		Expect(keeper.Keep(context.TODO())).To(Succeed())
+		Expect(systemd.restarts).To(BeEmpty())
+		Expect(conditions.IsTrue(getHost(), infrastructurev1beta1.NodeComponentsHealthy)).To(BeTrue())
+	})
+
+	It("should report the failed checks", func() {
+		bootstrap()
+		systemd.err = errors.New("failed to connect to bus")
+		Expect(keeper.Keep(context.TODO())).To(Succeed())
+		condition := conditions.Get(getHost(), infrastructurev1beta1.NodeComponentsHealthy)
+		Expect(condition.Reason).To(Equal(infrastructurev1beta1.NodeComponentsCheckErrorReason))
+		Expect(condition.Message).To(Equal("failed to connect to bus"))
+	})
+
+	It("should not restart the node components of the host being reset", func() {
+		bootstrap()
+		byoHost := getHost()
+		byoHost.Annotations = map[string]string{infrastructurev1beta1.HostCleanupAnnotation: ""}
+		Expect(k8sClient.Update(context.TODO(), byoHost)).To(Succeed())
+		systemd.states["kubelet"] = "inactive"
+		Expect(keeper.Keep(context.TODO())).To(Succeed())
+		Expect(systemd.restarts).To(BeEmpty())
+	})
+
+	It("should remove the condition once the node is reset", func() {
+		bootstrap()
+		Expect(keeper.Keep(context.TODO())).To(Succeed())
+		Expect(conditions.Has(getHost(), infrastructurev1beta1.NodeComponentsHealthy)).To(BeTrue())
+
+		byoHost := getHost()
+		conditions.MarkFalse(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded, infrastructurev1beta1.WaitingForMachineRefReason, clusterv1.ConditionSeverityInfo, "")
+		Expect(k8sClient.Status().Update(context.TODO(), byoHost)).To(Succeed())
+		Expect(keeper.Keep(context.TODO())).To(Succeed())
+		Expect(conditions.Has(getHost(), infrastructurev1beta1.NodeComponentsHealthy)).To(BeFalse())
+	})
+})
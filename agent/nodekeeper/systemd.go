@@ -0,0 +1,57 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nodekeeper
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/privilege"
+)
+
+// Systemd checks and restarts the systemd units of the host
+type Systemd interface {
+	// ActiveState returns the active state of the unit, e.g. active or failed, empty when the unit is not installed
+	ActiveState(unit string) (string, error)
+	// Restart restarts the unit
+	Restart(unit string) error
+}
+
+// Systemctl is the Systemd of the host, run through systemctl
+type Systemctl struct {
+	// Escalator runs systemctl as root
+	Escalator privilege.Escalator
+}
+
+// ActiveState returns the active state of the unit, from systemctl show
+func (s Systemctl) ActiveState(unit string) (string, error) {
+	output, err := s.Escalator.Command("systemctl", "show", "--property=LoadState,ActiveState", unit).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to show the state of %s: %w", unit, err)
+	}
+	properties := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		if property := strings.SplitN(scanner.Text(), "=", 2); len(property) == 2 {
+			properties[property[0]] = property[1]
+		}
+	}
+	if properties["LoadState"] == "not-found" {
+		return "", nil
+	}
+	return properties["ActiveState"], nil
+}
+
+// Restart restarts the unit, resetting its failed state first so that its start rate limit does not prevent it
+func (s Systemctl) Restart(unit string) error {
+	if output, err := s.Escalator.Command("systemctl", "reset-failed", unit).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to reset the failed state of %s: %w: %s", unit, err, bytes.TrimSpace(output))
+	}
+	if output, err := s.Escalator.Command("systemctl", "restart", unit).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to restart %s: %w: %s", unit, err, bytes.TrimSpace(output))
+	}
+	return nil
+}
@@ -0,0 +1,7 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package offline contains the queue of the host agent buffering the ByoHost updates
+// and the events while the management cluster is unreachable, e.g. when an edge site
+// loses its WAN link. The buffered updates are replayed once the cluster is reachable again.
+package offline
@@ -0,0 +1,200 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package offline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/registration"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultMaxEvents is the number of events buffered while offline, the oldest ones are dropped beyond it
+const DefaultMaxEvents = 1000
+
+// Queue buffers the updates of the ByoHost of the agent and its events while the management
+// cluster is unreachable, and replays them on Flush once it is reachable again.
+// It is the event recorder of the agent, the events being forwarded to Recorder while online.
+// A nil Queue buffers nothing.
+type Queue struct {
+	// Client is the client of the management cluster
+	Client client.Client
+	// Recorder records the events while the management cluster is reachable
+	Recorder record.EventRecorder
+	// Host is the ByoHost of the agent, read to probe the management cluster
+	Host types.NamespacedName
+	// MaxEvents is the number of buffered events, defaults to DefaultMaxEvents
+	MaxEvents int
+	Logger    logr.Logger
+
+	mu      sync.Mutex
+	offline bool
+	// original and modified are the ByoHost before and after the updates which could not be patched
+	original *infrastructurev1beta1.ByoHost
+	modified *infrastructurev1beta1.ByoHost
+	events   []event
+	dropped  int
+}
+
+type event struct {
+	object      runtime.Object
+	annotations map[string]string
+	eventType   string
+	reason      string
+	message     string
+}
+
+// IsUnreachable tells if the error of a request means that the management cluster could not be
+// reached, rather than the request being rejected
+func IsUnreachable(err error) bool {
+	if err == nil {
+		return false
+	}
+	// the patch helper aggregates the errors of the patches of the object and of its status
+	var aggregate kerrors.Aggregate
+	if errors.As(err, &aggregate) {
+		for _, e := range aggregate.Errors() {
+			if IsUnreachable(e) {
+				return true
+			}
+		}
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return apierrors.IsServiceUnavailable(err) || apierrors.IsTimeout(err) || apierrors.IsServerTimeout(err)
+}
+
+// Observe tells if err means that the management cluster is unreachable, in which case the queue
+// goes offline and buffers the events until the next successful Flush
+func (q *Queue) Observe(err error) bool {
+	if q == nil || !IsUnreachable(err) {
+		return false
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.offline {
+		q.Logger.Info("Management cluster unreachable, buffering the updates until it is back", "error", err.Error())
+		q.offline = true
+	}
+	return true
+}
+
+// Offline tells if the management cluster was found unreachable and not reached since
+func (q *Queue) Offline() bool {
+	if q == nil {
+		return false
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.offline
+}
+
+// BufferPatch keeps the update of the ByoHost, from original to modified, which could not be patched.
+// The updates of consecutive reconciles are merged, from the first original to the last modified.
+func (q *Queue) BufferPatch(original, modified *infrastructurev1beta1.ByoHost) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.original == nil {
+		q.original = original.DeepCopy()
+	}
+	q.modified = modified.DeepCopy()
+}
+
+// Flush replays the buffered update of the ByoHost and the buffered events, once the management
+// cluster is reachable again, and brings the queue back online. It returns the error of the
+// management cluster when it is still unreachable.
+func (q *Queue) Flush(ctx context.Context) error {
+	if q == nil {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.offline && q.modified == nil {
+		return nil
+	}
+
+	if q.modified != nil {
+		helper, err := patch.NewHelper(q.original, q.Client)
+		if err != nil {
+			return err
+		}
+		if err = registration.PatchHost(ctx, q.Client, helper, q.original, q.modified); err != nil {
+			if IsUnreachable(err) {
+				return err
+			}
+			// the ByoHost was deleted, or updated meanwhile in a conflicting way
+			q.Logger.Error(err, "failed to replay the buffered ByoHost update, dropping it")
+		}
+	} else if err := q.Client.Get(ctx, q.Host, &infrastructurev1beta1.ByoHost{}); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	for _, e := range q.events {
+		q.Recorder.AnnotatedEventf(e.object, e.annotations, e.eventType, e.reason, "%s", e.message)
+	}
+	q.Logger.Info("Management cluster reachable again, replayed the buffered updates",
+		"byohostUpdated", q.modified != nil, "events", len(q.events), "droppedEvents", q.dropped)
+	q.offline = false
+	q.original, q.modified = nil, nil
+	q.events, q.dropped = nil, 0
+	return nil
+}
+
+// Event implements record.EventRecorder
+func (q *Queue) Event(object runtime.Object, eventtype, reason, message string) {
+	q.record(object, nil, eventtype, reason, message)
+}
+
+// Eventf implements record.EventRecorder
+func (q *Queue) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	q.record(object, nil, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+// AnnotatedEventf implements record.EventRecorder
+func (q *Queue) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	q.record(object, annotations, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func (q *Queue) record(object runtime.Object, annotations map[string]string, eventType, reason, message string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.offline {
+		q.Recorder.AnnotatedEventf(object, annotations, eventType, reason, "%s", message)
+		return
+	}
+
+	maxEvents := q.MaxEvents
+	if maxEvents <= 0 {
+		maxEvents = DefaultMaxEvents
+	}
+	if len(q.events) >= maxEvents {
+		q.events = q.events[1:]
+		q.dropped++
+	}
+	q.events = append(q.events, event{
+		object:      object.DeepCopyObject(),
+		annotations: annotations,
+		eventType:   eventType,
+		reason:      reason,
+		message:     message,
+	})
+}
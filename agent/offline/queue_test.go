@@ -0,0 +1,167 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package offline_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/offline"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/test/builder"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// unreachableClient fails all the requests while down, as a client-go transport error
+type unreachableClient struct {
+	client.Client
+	down bool
+}
+
+var errUnreachable = &url.Error{Op: "Get", URL: "https://management:6443", Err: errors.New("connect: no route to host")}
+
+func (c *unreachableClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if c.down {
+		return errUnreachable
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+func (c *unreachableClient) Patch(ctx context.Context, obj client.Object, p client.Patch, opts ...client.PatchOption) error {
+	if c.down {
+		return errUnreachable
+	}
+	return c.Client.Patch(ctx, obj, p, opts...)
+}
+
+func (c *unreachableClient) Status() client.StatusWriter {
+	return &unreachableStatusWriter{StatusWriter: c.Client.Status(), c: c}
+}
+
+type unreachableStatusWriter struct {
+	client.StatusWriter
+	c *unreachableClient
+}
+
+func (w *unreachableStatusWriter) Patch(ctx context.Context, obj client.Object, p client.Patch, opts ...client.PatchOption) error {
+	if w.c.down {
+		return errUnreachable
+	}
+	return w.StatusWriter.Patch(ctx, obj, p, opts...)
+}
+
+var _ = Describe("Offline queue", func() {
+	var (
+		ctx      context.Context
+		byoHost  *infrastructurev1beta1.ByoHost
+		c        *unreachableClient
+		recorder *record.FakeRecorder
+		queue    *offline.Queue
+	)
+
+	BeforeEach(func() {
+		ctx = context.TODO()
+		byoHost = builder.ByoHost("default", "edge-host").Build()
+		byoHost.Name = "edge-host"
+		Expect(k8sClient.Create(ctx, byoHost)).To(Succeed())
+		c = &unreachableClient{Client: k8sClient}
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(byoHost), byoHost)).To(Succeed())
+		recorder = record.NewFakeRecorder(10)
+		queue = &offline.Queue{
+			Client:    c,
+			Recorder:  recorder,
+			Host:      client.ObjectKeyFromObject(byoHost),
+			MaxEvents: 2,
+			Logger:    logr.Discard(),
+		}
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.Delete(ctx, byoHost)).To(Succeed())
+	})
+
+	It("should tell the errors of an unreachable management cluster apart", func() {
+		Expect(offline.IsUnreachable(errUnreachable)).To(BeTrue())
+		Expect(offline.IsUnreachable(apierrors.NewServiceUnavailable("down"))).To(BeTrue())
+		Expect(offline.IsUnreachable(apierrors.NewNotFound(schema.GroupResource{Resource: "byohosts"}, "edge-host"))).To(BeFalse())
+		Expect(offline.IsUnreachable(nil)).To(BeFalse())
+		Expect(queue.Observe(errors.New("bootstrap failed"))).To(BeFalse())
+		Expect(queue.Offline()).To(BeFalse())
+	})
+
+	It("should forward the events while online", func() {
+		queue.Eventf(byoHost, corev1.EventTypeNormal, "BootstrapK8sNodeSucceeded", "k8s Node Bootstrap %s", "succeeded")
+		Expect(recorder.Events).To(Receive(Equal("Normal BootstrapK8sNodeSucceeded k8s Node Bootstrap succeeded")))
+	})
+
+	It("should do nothing when nil", func() {
+		var nilQueue *offline.Queue
+		Expect(nilQueue.Observe(errUnreachable)).To(BeFalse())
+		nilQueue.BufferPatch(byoHost, byoHost)
+		Expect(nilQueue.Flush(ctx)).To(Succeed())
+	})
+
+	Context("When the management cluster is unreachable", func() {
+		var original *infrastructurev1beta1.ByoHost
+
+		BeforeEach(func() {
+			c.down = true
+			original = byoHost.DeepCopy()
+			conditions.MarkTrue(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded)
+			Expect(queue.Observe(c.Status().Patch(ctx, byoHost, client.MergeFrom(original)))).To(BeTrue())
+			queue.BufferPatch(original, byoHost)
+		})
+
+		It("should buffer the events and keep the latest ones", func() {
+			Expect(queue.Offline()).To(BeTrue())
+			queue.Event(byoHost, corev1.EventTypeNormal, "First", "dropped")
+			queue.Event(byoHost, corev1.EventTypeNormal, "Second", "kept")
+			queue.Event(byoHost, corev1.EventTypeNormal, "Third", "kept")
+			Expect(recorder.Events).NotTo(Receive())
+
+			c.down = false
+			Expect(queue.Flush(ctx)).To(Succeed())
+			Expect(queue.Offline()).To(BeFalse())
+			Expect(recorder.Events).To(Receive(Equal("Normal Second kept")))
+			Expect(recorder.Events).To(Receive(Equal("Normal Third kept")))
+			Expect(recorder.Events).NotTo(Receive())
+		})
+
+		It("should keep the buffered update until the management cluster is back", func() {
+			Expect(queue.Flush(ctx)).To(MatchError(errUnreachable))
+			Expect(queue.Offline()).To(BeTrue())
+
+			c.down = false
+			Expect(queue.Flush(ctx)).To(Succeed())
+			updatedByoHost := &infrastructurev1beta1.ByoHost{}
+			Expect(c.Get(ctx, client.ObjectKeyFromObject(byoHost), updatedByoHost)).To(Succeed())
+			Expect(conditions.IsTrue(updatedByoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded)).To(BeTrue())
+		})
+
+		It("should merge the updates of consecutive reconciles", func() {
+			next := byoHost.DeepCopy()
+			conditions.MarkFalse(next, infrastructurev1beta1.K8sComponentsInstallationSucceeded,
+				infrastructurev1beta1.K8sComponentsInstallationFailedReason, clusterv1.ConditionSeverityError, "")
+			queue.BufferPatch(byoHost, next)
+
+			c.down = false
+			Expect(queue.Flush(ctx)).To(Succeed())
+			updatedByoHost := &infrastructurev1beta1.ByoHost{}
+			Expect(c.Get(ctx, client.ObjectKeyFromObject(byoHost), updatedByoHost)).To(Succeed())
+			Expect(conditions.IsTrue(updatedByoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded)).To(BeTrue())
+			Expect(conditions.GetReason(updatedByoHost, infrastructurev1beta1.K8sComponentsInstallationSucceeded)).
+				To(Equal(infrastructurev1beta1.K8sComponentsInstallationFailedReason))
+		})
+	})
+})
@@ -0,0 +1,47 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/common/agentrbac"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	authorizationclient "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	"k8s.io/client-go/rest"
+)
+
+// warnExcessPermissions warns when the credentials of the agent grant more than the least privileges
+// of a host agent in its namespace, e.g. when the kubeconfig of a cluster admin is copied to the host
+func warnExcessPermissions(ctx context.Context, config *rest.Config, logger logr.Logger) {
+	client, err := clientset.NewForConfig(config)
+	if err != nil {
+		logger.V(1).Info("Could not review the permissions of the agent", "error", err.Error())
+		return
+	}
+	excess, err := excessPermissions(ctx, client.AuthorizationV1().SelfSubjectRulesReviews(), namespace)
+	if err != nil {
+		logger.V(1).Info("Could not review the permissions of the agent", "error", err.Error())
+		return
+	}
+	if len(excess) > 0 {
+		logger.Info("WARNING: the agent is granted more than the privileges of a host agent, consider a namespace-scoped agent kubeconfig",
+			"namespace", namespace, "excessPermissions", excess)
+	}
+}
+
+// excessPermissions returns the permissions of the agent in the namespace beyond the least
+// privileges of a host agent
+func excessPermissions(ctx context.Context, reviews authorizationclient.SelfSubjectRulesReviewInterface, namespace string) ([]string, error) {
+	review, err := reviews.Create(ctx, &authorizationv1.SelfSubjectRulesReview{
+		Spec: authorizationv1.SelfSubjectRulesReviewSpec{Namespace: namespace},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return agentrbac.ExcessPermissions(review.Status.ResourceRules), nil
+}
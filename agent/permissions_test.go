@@ -0,0 +1,51 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// nolint: testpackage
+package main
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/common/agentrbac"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("Agent permissions", func() {
+	var (
+		clientSet *fakeclientset.Clientset
+		granted   []authorizationv1.ResourceRule
+	)
+
+	BeforeEach(func() {
+		granted = nil
+		for _, rule := range agentrbac.NamespaceRules() {
+			granted = append(granted, authorizationv1.ResourceRule{Verbs: rule.Verbs, APIGroups: rule.APIGroups, Resources: rule.Resources})
+		}
+		clientSet = fakeclientset.NewSimpleClientset()
+		clientSet.PrependReactor("create", "selfsubjectrulesreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectRulesReview)
+			Expect(review.Spec.Namespace).To(Equal("byoh-hosts"))
+			review.Status.ResourceRules = granted
+			return true, review, nil
+		})
+	})
+
+	It("should not report the least privileges of a host agent", func() {
+		excess, err := excessPermissions(context.TODO(), clientSet.AuthorizationV1().SelfSubjectRulesReviews(), "byoh-hosts")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(excess).To(BeEmpty())
+	})
+
+	It("should report the permissions beyond the least privileges of a host agent", func() {
+		granted = append(granted, authorizationv1.ResourceRule{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}})
+		excess, err := excessPermissions(context.TODO(), clientSet.AuthorizationV1().SelfSubjectRulesReviews(), "byoh-hosts")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(excess).To(Equal([]string{"* *.*"}))
+	})
+})
@@ -0,0 +1,8 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package preflight contains the checks of the OS and kernel prerequisites of a
+// Kubernetes node, run by the host agent before installing the k8s components.
+// The checks read the kernel state from /proc and /sys, so that a host which would
+// only fail later on, when the kubelet starts, is reported up front.
+package preflight
@@ -0,0 +1,343 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package preflight
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+)
+
+const (
+	// DefaultMinCPUs is the number of CPUs kubeadm requires on a control plane node
+	DefaultMinCPUs = 2
+	// DefaultMinMemoryMiB is the memory kubeadm requires on a control plane node
+	DefaultMinMemoryMiB = 1700
+
+	// KubeletPort is the port of the kubelet API
+	KubeletPort = 10250
+)
+
+var (
+	// requiredKernelModules are loaded by the installer, or else by the host admin
+	requiredKernelModules = []string{"br_netfilter", "overlay"}
+
+	// requiredSysctls are set to 1 by the installer, or else by the host admin
+	requiredSysctls = []string{"net.bridge.bridge-nf-call-iptables", "net.ipv4.ip_forward"}
+
+	// requiredCgroupControllers are the controllers the kubelet enforces the pod resources with
+	requiredCgroupControllers = []string{"cpu", "memory"}
+
+	// selinuxContainerPolicyModules are the modules of the container-selinux policy, under the module
+	// store of each SELinux policy type, defining the file contexts of containerd and kubelet
+	selinuxContainerPolicyModules = "/etc/selinux/*/active/modules/*/container"
+
+	// apparmorParserPaths are where containerd looks for apparmor_parser to load the profile of the containers
+	apparmorParserPaths = []string{"/sbin/apparmor_parser", "/usr/sbin/apparmor_parser", "/usr/bin/apparmor_parser"}
+)
+
+// Failure is a failed preflight check
+type Failure struct {
+	// Reason is the reason of the HostPreflightSucceeded condition reporting the failure
+	Reason string
+	// Message tells what is wrong with the host and how to fix it
+	Message string
+}
+
+// Checker checks the prerequisites of a Kubernetes node on the host
+type Checker struct {
+	// CheckKernelConfig checks the kernel modules, the sysctls and the swap, which are
+	// otherwise configured by the installer
+	CheckKernelConfig bool
+	// Ports are the ports which must be free, defaults to the kubelet port
+	Ports []int
+	// MinCPUs is the minimum number of CPUs, defaults to DefaultMinCPUs
+	MinCPUs int
+	// MinMemoryMiB is the minimum memory in MiB, defaults to DefaultMinMemoryMiB
+	MinMemoryMiB uint64
+
+	// root is prepended to the /proc and /sys paths
+	root string
+	// numCPU returns the number of CPUs of the host
+	numCPU func() int
+}
+
+// Check runs the preflight checks, returning the failed ones
+func (c *Checker) Check() ([]Failure, error) {
+	checks := []func() (*Failure, error){c.checkCgroups, c.checkSELinux, c.checkAppArmor, c.checkPorts, c.checkResources}
+	if c.CheckKernelConfig {
+		checks = append([]func() (*Failure, error){c.checkKernelModules, c.checkSysctls, c.checkSwap}, checks...)
+	}
+
+	failures := []Failure{}
+	for _, check := range checks {
+		failure, err := check()
+		if err != nil {
+			return nil, err
+		}
+		if failure != nil {
+			failures = append(failures, *failure)
+		}
+	}
+	return failures, nil
+}
+
+func (c *Checker) path(path string) string {
+	return filepath.Join(c.root, path)
+}
+
+func (c *Checker) checkKernelModules() (*Failure, error) {
+	missing := []string{}
+	for _, module := range requiredKernelModules {
+		// loaded modules, as well as the ones built into the kernel, are listed under /sys/module
+		if _, err := os.Stat(c.path(filepath.Join("/sys/module", module))); err != nil {
+			if !os.IsNotExist(err) {
+				return nil, err
+			}
+			missing = append(missing, module)
+		}
+	}
+	if len(missing) == 0 {
+		return nil, nil
+	}
+	return &Failure{
+		Reason: infrav1.KernelModulesMissingReason,
+		Message: fmt.Sprintf("kernel modules %s are not loaded, load them with modprobe and list them in /etc/modules-load.d",
+			strings.Join(missing, ", ")),
+	}, nil
+}
+
+func (c *Checker) checkSysctls() (*Failure, error) {
+	unset := []string{}
+	for _, sysctl := range requiredSysctls {
+		value, err := os.ReadFile(c.path(filepath.Join("/proc/sys", strings.ReplaceAll(sysctl, ".", "/"))))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		// bridge-nf-call-iptables does not exist until br_netfilter is loaded
+		if strings.TrimSpace(string(value)) != "1" {
+			unset = append(unset, sysctl)
+		}
+	}
+	if len(unset) == 0 {
+		return nil, nil
+	}
+	return &Failure{
+		Reason: infrav1.SysctlsNotSetReason,
+		Message: fmt.Sprintf("sysctls %s are not set to 1, set them in /etc/sysctl.d and run sysctl --system",
+			strings.Join(unset, ", ")),
+	}, nil
+}
+
+func (c *Checker) checkSwap() (*Failure, error) {
+	swaps, err := os.ReadFile(c.path("/proc/swaps"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	// the first line is the header of the table
+	lines := strings.Split(strings.TrimSpace(string(swaps)), "\n")
+	if len(lines) <= 1 {
+		return nil, nil
+	}
+	return &Failure{
+		Reason:  infrav1.SwapEnabledReason,
+		Message: "swap is enabled, the kubelet does not start with swap on, disable it with swapoff -a and remove the swap entries of /etc/fstab",
+	}, nil
+}
+
+func (c *Checker) checkCgroups() (*Failure, error) {
+	enabled, err := c.cgroupControllers()
+	if err != nil {
+		return nil, err
+	}
+	missing := []string{}
+	for _, controller := range requiredCgroupControllers {
+		if !enabled[controller] {
+			missing = append(missing, controller)
+		}
+	}
+	if len(missing) == 0 {
+		return nil, nil
+	}
+	return &Failure{
+		Reason: infrav1.CgroupControllersMissingReason,
+		Message: fmt.Sprintf("cgroup controllers %s are not enabled, enable them on the kernel command line, e.g. with cgroup_enable=memory",
+			strings.Join(missing, ", ")),
+	}, nil
+}
+
+// cgroupControllers returns the enabled cgroup controllers, of the unified hierarchy
+// with cgroup v2 or of /proc/cgroups with cgroup v1
+func (c *Checker) cgroupControllers() (map[string]bool, error) {
+	enabled := map[string]bool{}
+	controllers, err := os.ReadFile(c.path("/sys/fs/cgroup/cgroup.controllers"))
+	if err == nil {
+		for _, controller := range strings.Fields(string(controllers)) {
+			enabled[controller] = true
+		}
+		return enabled, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	cgroups, err := os.ReadFile(c.path("/proc/cgroups"))
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(cgroups))
+	for scanner.Scan() {
+		// #subsys_name hierarchy num_cgroups enabled
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 4 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		enabled[fields[0]] = fields[3] == "1"
+	}
+	return enabled, scanner.Err()
+}
+
+// checkSELinux checks that the container-selinux policy is installed when SELinux is enforcing, the
+// containers being denied the access to their files otherwise
+func (c *Checker) checkSELinux() (*Failure, error) {
+	enforce, err := os.ReadFile(c.path("/sys/fs/selinux/enforce"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if strings.TrimSpace(string(enforce)) != "1" {
+		return nil, nil
+	}
+	modules, err := filepath.Glob(c.path(selinuxContainerPolicyModules))
+	if err != nil {
+		return nil, err
+	}
+	if len(modules) > 0 {
+		return nil, nil
+	}
+	return &Failure{
+		Reason:  infrav1.SELinuxPolicyMissingReason,
+		Message: "SELinux is enforcing and the container-selinux policy is not installed, install the container-selinux package or set SELinux to permissive",
+	}, nil
+}
+
+// checkAppArmor checks that containerd can load the AppArmor profile of the containers when AppArmor is
+// enabled, the containers failing to start otherwise
+func (c *Checker) checkAppArmor() (*Failure, error) {
+	enabled, err := os.ReadFile(c.path("/sys/module/apparmor/parameters/enabled"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if strings.TrimSpace(string(enabled)) != "Y" {
+		return nil, nil
+	}
+	for _, path := range apparmorParserPaths {
+		if _, err := os.Stat(c.path(path)); err == nil {
+			return nil, nil
+		}
+	}
+	return &Failure{
+		Reason:  infrav1.AppArmorParserMissingReason,
+		Message: "AppArmor is enabled and apparmor_parser is not installed, containerd cannot load the profile of the containers, install the apparmor package",
+	}, nil
+}
+
+func (c *Checker) checkPorts() (*Failure, error) {
+	ports := c.Ports
+	if ports == nil {
+		ports = []int{KubeletPort}
+	}
+	inUse := []string{}
+	for _, port := range ports {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+		if err != nil {
+			inUse = append(inUse, strconv.Itoa(port))
+			continue
+		}
+		_ = listener.Close()
+	}
+	if len(inUse) == 0 {
+		return nil, nil
+	}
+	return &Failure{
+		Reason: infrav1.PortsInUseReason,
+		Message: fmt.Sprintf("ports %s are in use, stop the processes listening on them, e.g. a kubelet left over by a previous installation",
+			strings.Join(inUse, ", ")),
+	}, nil
+}
+
+func (c *Checker) checkResources() (*Failure, error) {
+	minCPUs := c.MinCPUs
+	if minCPUs == 0 {
+		minCPUs = DefaultMinCPUs
+	}
+	minMemoryMiB := c.MinMemoryMiB
+	if minMemoryMiB == 0 {
+		minMemoryMiB = DefaultMinMemoryMiB
+	}
+	numCPU := runtime.NumCPU
+	if c.numCPU != nil {
+		numCPU = c.numCPU
+	}
+	memoryMiB, err := c.memoryMiB()
+	if err != nil {
+		return nil, err
+	}
+
+	insufficient := []string{}
+	if cpus := numCPU(); cpus < minCPUs {
+		insufficient = append(insufficient, fmt.Sprintf("%d CPUs while %d are required", cpus, minCPUs))
+	}
+	if memoryMiB < minMemoryMiB {
+		insufficient = append(insufficient, fmt.Sprintf("%dMiB of memory while %dMiB are required", memoryMiB, minMemoryMiB))
+	}
+	if len(insufficient) == 0 {
+		return nil, nil
+	}
+	return &Failure{
+		Reason:  infrav1.InsufficientResourcesReason,
+		Message: fmt.Sprintf("the host has %s", strings.Join(insufficient, " and ")),
+	}, nil
+}
+
+// memoryMiB returns the MemTotal of /proc/meminfo
+func (c *Checker) memoryMiB() (uint64, error) {
+	meminfo, err := os.ReadFile(c.path("/proc/meminfo"))
+	if err != nil {
+		return 0, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(meminfo))
+	for scanner.Scan() {
+		// MemTotal:        8039852 kB
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "MemTotal:" {
+			continue
+		}
+		kiB, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return kiB / 1024, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("MemTotal not found in /proc/meminfo")
+}
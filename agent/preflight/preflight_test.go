@@ -0,0 +1,215 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// nolint: testpackage
+package preflight
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+)
+
+var _ = Describe("Preflight checks", func() {
+	var (
+		root    string
+		checker *Checker
+	)
+
+	writeFile := func(path, content string) {
+		path = filepath.Join(root, path)
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+	}
+
+	reasons := func(failures []Failure) []string {
+		result := []string{}
+		for _, failure := range failures {
+			result = append(result, failure.Reason)
+		}
+		return result
+	}
+
+	BeforeEach(func() {
+		var err error
+		root, err = os.MkdirTemp("", "preflight")
+		Expect(err).NotTo(HaveOccurred())
+
+		// a host meeting all the prerequisites
+		Expect(os.MkdirAll(filepath.Join(root, "/sys/module/br_netfilter"), 0755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(root, "/sys/module/overlay"), 0755)).To(Succeed())
+		writeFile("/proc/sys/net/bridge/bridge-nf-call-iptables", "1\n")
+		writeFile("/proc/sys/net/ipv4/ip_forward", "1\n")
+		writeFile("/proc/swaps", "Filename\t\t\t\tType\t\tSize\t\tUsed\t\tPriority\n")
+		writeFile("/sys/fs/cgroup/cgroup.controllers", "cpuset cpu io memory hugetlb pids rdma misc\n")
+		writeFile("/proc/meminfo", "MemTotal:        8039852 kB\nMemFree:         1402716 kB\n")
+
+		checker = &Checker{
+			CheckKernelConfig: true,
+			Ports:             []int{},
+			root:              root,
+			numCPU:            func() int { return 4 },
+		}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(root)).To(Succeed())
+	})
+
+	It("should pass on a host meeting the prerequisites", func() {
+		failures, err := checker.Check()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(failures).To(BeEmpty())
+	})
+
+	It("should report the missing kernel modules", func() {
+		Expect(os.RemoveAll(filepath.Join(root, "/sys/module/br_netfilter"))).To(Succeed())
+
+		failures, err := checker.Check()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(failures).To(ConsistOf(Failure{
+			Reason:  infrav1.KernelModulesMissingReason,
+			Message: "kernel modules br_netfilter are not loaded, load them with modprobe and list them in /etc/modules-load.d",
+		}))
+	})
+
+	It("should report the sysctls which are not set", func() {
+		writeFile("/proc/sys/net/ipv4/ip_forward", "0\n")
+		Expect(os.Remove(filepath.Join(root, "/proc/sys/net/bridge/bridge-nf-call-iptables"))).To(Succeed())
+
+		failures, err := checker.Check()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(failures).To(ConsistOf(Failure{
+			Reason:  infrav1.SysctlsNotSetReason,
+			Message: "sysctls net.bridge.bridge-nf-call-iptables, net.ipv4.ip_forward are not set to 1, set them in /etc/sysctl.d and run sysctl --system",
+		}))
+	})
+
+	It("should report the swap when it is on", func() {
+		writeFile("/proc/swaps", "Filename\t\t\t\tType\t\tSize\t\tUsed\t\tPriority\n/swap.img\tfile\t\t2097148\t\t0\t\t-2\n")
+
+		failures, err := checker.Check()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reasons(failures)).To(ConsistOf(infrav1.SwapEnabledReason))
+	})
+
+	It("should not check the kernel configuration when it is left to the installer", func() {
+		checker.CheckKernelConfig = false
+		Expect(os.RemoveAll(filepath.Join(root, "/sys/module"))).To(Succeed())
+		writeFile("/proc/sys/net/ipv4/ip_forward", "0\n")
+		writeFile("/proc/swaps", "Filename\t\t\t\tType\t\tSize\t\tUsed\t\tPriority\n/swap.img\tfile\t\t2097148\t\t0\t\t-2\n")
+
+		failures, err := checker.Check()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(failures).To(BeEmpty())
+	})
+
+	Context("When checking the cgroup controllers", func() {
+		It("should report the controllers missing from the cgroup v2 hierarchy", func() {
+			writeFile("/sys/fs/cgroup/cgroup.controllers", "cpuset cpu io pids\n")
+
+			failures, err := checker.Check()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(failures).To(ConsistOf(Failure{
+				Reason:  infrav1.CgroupControllersMissingReason,
+				Message: "cgroup controllers memory are not enabled, enable them on the kernel command line, e.g. with cgroup_enable=memory",
+			}))
+		})
+
+		It("should read the controllers of /proc/cgroups with cgroup v1", func() {
+			Expect(os.Remove(filepath.Join(root, "/sys/fs/cgroup/cgroup.controllers"))).To(Succeed())
+			writeFile("/proc/cgroups", "#subsys_name\thierarchy\tnum_cgroups\tenabled\ncpu\t3\t64\t1\nmemory\t0\t1\t0\n")
+
+			failures, err := checker.Check()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reasons(failures)).To(ConsistOf(infrav1.CgroupControllersMissingReason))
+
+			writeFile("/proc/cgroups", "#subsys_name\thierarchy\tnum_cgroups\tenabled\ncpu\t3\t64\t1\nmemory\t5\t90\t1\n")
+			failures, err = checker.Check()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(failures).To(BeEmpty())
+		})
+	})
+
+	Context("When checking the security modules", func() {
+		It("should report the missing container-selinux policy when SELinux is enforcing", func() {
+			writeFile("/sys/fs/selinux/enforce", "1")
+
+			failures, err := checker.Check()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(failures).To(ConsistOf(Failure{
+				Reason:  infrav1.SELinuxPolicyMissingReason,
+				Message: "SELinux is enforcing and the container-selinux policy is not installed, install the container-selinux package or set SELinux to permissive",
+			}))
+
+			Expect(os.MkdirAll(filepath.Join(root, "/etc/selinux/targeted/active/modules/200/container"), 0755)).To(Succeed())
+			failures, err = checker.Check()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(failures).To(BeEmpty())
+		})
+
+		It("should not check the SELinux policy when SELinux is permissive", func() {
+			writeFile("/sys/fs/selinux/enforce", "0")
+
+			failures, err := checker.Check()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(failures).To(BeEmpty())
+		})
+
+		It("should report the missing apparmor_parser when AppArmor is enabled", func() {
+			writeFile("/sys/module/apparmor/parameters/enabled", "Y\n")
+
+			failures, err := checker.Check()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reasons(failures)).To(ConsistOf(infrav1.AppArmorParserMissingReason))
+
+			writeFile("/usr/sbin/apparmor_parser", "")
+			failures, err = checker.Check()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(failures).To(BeEmpty())
+		})
+	})
+
+	It("should report the ports in use", func() {
+		listener, err := net.Listen("tcp", ":0")
+		Expect(err).NotTo(HaveOccurred())
+		defer listener.Close()
+		port := listener.Addr().(*net.TCPAddr).Port
+		checker.Ports = []int{port}
+
+		failures, err := checker.Check()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reasons(failures)).To(ConsistOf(infrav1.PortsInUseReason))
+		Expect(failures[0].Message).To(ContainSubstring("ports " + strconv.Itoa(port) + " are in use"))
+	})
+
+	It("should report the insufficient resources", func() {
+		checker.numCPU = func() int { return 1 }
+		writeFile("/proc/meminfo", "MemTotal:        1015852 kB\n")
+
+		failures, err := checker.Check()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(failures).To(ConsistOf(Failure{
+			Reason:  infrav1.InsufficientResourcesReason,
+			Message: "the host has 1 CPUs while 2 are required and 992MiB of memory while 1700MiB are required",
+		}))
+
+		checker.MinCPUs = 1
+		checker.MinMemoryMiB = 512
+		failures, err = checker.Check()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(failures).To(BeEmpty())
+	})
+
+	It("should error when the memory of the host cannot be read", func() {
+		Expect(os.Remove(filepath.Join(root, "/proc/meminfo"))).To(Succeed())
+
+		_, err := checker.Check()
+		Expect(err).To(HaveOccurred())
+	})
+})
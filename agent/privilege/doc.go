@@ -0,0 +1,8 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package privilege runs the operations of the host agent which need root, i.e. the
+// installer steps, the bootstrap commands and the writes to the system directories.
+// They are run directly when the agent runs as root, or through sudo when it runs as a
+// dedicated user, so that only these operations are escalated.
+package privilege
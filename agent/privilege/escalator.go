@@ -0,0 +1,152 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package privilege
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"strings"
+)
+
+// sudoCommand is the command the operations are escalated with, it fails rather than
+// prompting for a password when the sudo rules do not allow the operation
+var sudoCommand = []string{"sudo", "-n", "--"}
+
+// Escalator runs the operations needing root. The zero Escalator runs them directly,
+// for an agent running as root.
+type Escalator struct {
+	// Sudo runs the operations through sudo, for an agent running as a dedicated user
+	Sudo bool
+}
+
+// Command returns the command running name with args as root
+func (e Escalator) Command(name string, args ...string) *exec.Cmd {
+	if !e.Sudo {
+		return exec.Command(name, args...)
+	}
+	sudoArgs := make([]string, 0, len(sudoCommand)+len(args))
+	sudoArgs = append(sudoArgs, sudoCommand[1:]...)
+	sudoArgs = append(sudoArgs, name)
+	return exec.Command(sudoCommand[0], append(sudoArgs, args...)...)
+}
+
+// MkdirAll creates the directory and its parents as root
+func (e Escalator) MkdirAll(path string, perm fs.FileMode) error {
+	if !e.Sudo {
+		return os.MkdirAll(path, perm)
+	}
+	return e.run(nil, "mkdir", "-p", "-m", fmt.Sprintf("%o", perm), path)
+}
+
+// ReadFile reads the file as root
+func (e Escalator) ReadFile(path string) ([]byte, error) {
+	if !e.Sudo {
+		return os.ReadFile(path)
+	}
+	cmd := e.Command("cat", "--", path)
+	var stdErr bytes.Buffer
+	cmd.Stderr = &stdErr
+	content, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("cat -- %s: %w: %s", path, err, strings.TrimSpace(stdErr.String()))
+	}
+	return content, nil
+}
+
+// WriteFile writes content to the file as root, creating it with perm if it does not exist
+// and appending to it when append is true
+func (e Escalator) WriteFile(path string, content []byte, perm fs.FileMode, append bool) error {
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if append {
+		flag = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	if !e.Sudo {
+		f, err := os.OpenFile(path, flag, perm)
+		if err != nil {
+			return err
+		}
+		if _, err = f.Write(content); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+
+	_, statErr := os.Stat(path)
+	args := []string{path}
+	if append {
+		args = []string{"-a", path}
+	}
+	if err := e.run(content, "tee", args...); err != nil {
+		return err
+	}
+	// tee creates the file with the umask of root
+	if os.IsNotExist(statErr) {
+		return e.Chmod(path, perm)
+	}
+	return nil
+}
+
+// Chmod changes the mode of the file as root
+func (e Escalator) Chmod(path string, mode fs.FileMode) error {
+	if !e.Sudo {
+		return os.Chmod(path, mode)
+	}
+	return e.run(nil, "chmod", fmt.Sprintf("%o", mode), path)
+}
+
+// Chown changes the owner of the file as root, owner being "user:group"
+func (e Escalator) Chown(path, owner string) error {
+	if !e.Sudo {
+		names := strings.SplitN(owner, ":", 2)
+		if len(names) != 2 {
+			return fmt.Errorf("invalid owner format '%s'", owner)
+		}
+		u, err := user.Lookup(names[0])
+		if err != nil {
+			return err
+		}
+		g, err := user.LookupGroup(names[1])
+		if err != nil {
+			return err
+		}
+		uid, err := strconv.Atoi(u.Uid)
+		if err != nil {
+			return err
+		}
+		gid, err := strconv.Atoi(g.Gid)
+		if err != nil {
+			return err
+		}
+		return os.Chown(path, uid, gid)
+	}
+	return e.run(nil, "chown", owner, path)
+}
+
+// RemoveAll removes the path and its children as root
+func (e Escalator) RemoveAll(path string) error {
+	if !e.Sudo {
+		return os.RemoveAll(path)
+	}
+	return e.run(nil, "rm", "-rf", "--", path)
+}
+
+// run runs the command as root, with stdin as its input
+func (e Escalator) run(stdin []byte, name string, args ...string) error {
+	cmd := e.Command(name, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stdErr bytes.Buffer
+	cmd.Stderr = &stdErr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, strings.TrimSpace(stdErr.String()))
+	}
+	return nil
+}
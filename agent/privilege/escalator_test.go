@@ -0,0 +1,104 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// nolint: testpackage
+package privilege
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Escalator", func() {
+	Context("When the agent runs as root", func() {
+		It("should run the commands directly", func() {
+			cmd := Escalator{}.Command("kubeadm", "reset", "--force")
+			Expect(cmd.Args).To(Equal([]string{"kubeadm", "reset", "--force"}))
+		})
+	})
+
+	Context("When the agent escalates with sudo", func() {
+		var (
+			escalator Escalator
+			dir       string
+		)
+
+		BeforeEach(func() {
+			escalator = Escalator{Sudo: true}
+			var err error
+			dir, err = os.MkdirTemp("", "privilegeTest")
+			Expect(err).NotTo(HaveOccurred())
+		})
+		AfterEach(func() {
+			sudoCommand = []string{"sudo", "-n", "--"}
+			Expect(os.RemoveAll(dir)).To(Succeed())
+		})
+
+		It("should run the commands through sudo without prompting", func() {
+			cmd := escalator.Command("kubeadm", "reset", "--force")
+			Expect(cmd.Args).To(Equal([]string{"sudo", "-n", "--", "kubeadm", "reset", "--force"}))
+		})
+
+		Context("with the sudo rules allowing the operations", func() {
+			BeforeEach(func() {
+				// runs the escalated operations as the user running the tests
+				sudoCommand = []string{"env", "--"}
+			})
+
+			It("should write the files", func() {
+				path := filepath.Join(dir, "etc", "kubernetes", "kubelet.conf")
+				Expect(escalator.MkdirAll(filepath.Dir(path), 0750)).To(Succeed())
+				Expect(escalator.WriteFile(path, []byte("first\n"), 0600, false)).To(Succeed())
+				Expect(escalator.WriteFile(path, []byte("second\n"), 0644, true)).To(Succeed())
+
+				content, err := os.ReadFile(path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(content)).To(Equal("first\nsecond\n"))
+				info, err := os.Stat(path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+				Expect(escalator.Chmod(path, 0640)).To(Succeed())
+				info, err = os.Stat(path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(info.Mode().Perm()).To(Equal(os.FileMode(0640)))
+			})
+
+			It("should truncate the files which are not appended to", func() {
+				path := filepath.Join(dir, "kubelet")
+				Expect(escalator.WriteFile(path, []byte("KUBELET_EXTRA_ARGS=--node-ip=10.0.0.5\n"), 0644, false)).To(Succeed())
+				Expect(escalator.WriteFile(path, []byte("KUBELET_EXTRA_ARGS=\n"), 0644, false)).To(Succeed())
+				content, err := os.ReadFile(path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(content)).To(Equal("KUBELET_EXTRA_ARGS=\n"))
+			})
+
+			It("should read the files", func() {
+				path := filepath.Join(dir, "config.yaml")
+				Expect(os.WriteFile(path, []byte("kind: KubeletConfiguration\n"), 0600)).To(Succeed())
+				content, err := escalator.ReadFile(path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(content)).To(Equal("kind: KubeletConfiguration\n"))
+
+				_, err = escalator.ReadFile(filepath.Join(dir, "missing"))
+				Expect(err).To(MatchError(ContainSubstring("No such file or directory")))
+			})
+
+			It("should remove the files", func() {
+				path := filepath.Join(dir, "net.d", "10-flannel.conflist")
+				Expect(os.MkdirAll(filepath.Dir(path), 0750)).To(Succeed())
+				Expect(os.WriteFile(path, []byte("{}"), 0600)).To(Succeed())
+				Expect(escalator.RemoveAll(filepath.Dir(path))).To(Succeed())
+				Expect(filepath.Dir(path)).NotTo(BeADirectory())
+			})
+
+			It("should return the error output of the failed operations", func() {
+				err := escalator.Chmod(filepath.Join(dir, "missing"), 0600)
+				Expect(err).To(MatchError(ContainSubstring("No such file or directory")))
+			})
+		})
+	})
+})
@@ -0,0 +1,132 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package reconciler
+
+import (
+	"time"
+
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+)
+
+// The classes of the errors failing the reconciles of the agent, recorded in ByoHost.Status.Backoff
+const (
+	bootstrapSecretErrorClass   = "BootstrapSecret"
+	installationErrorClass      = "Installation"
+	hostConfigurationErrorClass = "HostConfiguration"
+	bootstrapErrorClass         = "Bootstrap"
+	cleanupErrorClass           = "Cleanup"
+)
+
+// backoffPolicy is the exponential backoff of the retries of a class of errors
+type backoffPolicy struct {
+	initial time.Duration
+	max     time.Duration
+	// maxFailures stops the retries after as many consecutive failures, 0 retries forever
+	maxFailures int32
+}
+
+var backoffPolicies = map[string]backoffPolicy{
+	// the secret is read from the management cluster, its errors are transient
+	bootstrapSecretErrorClass: {initial: 5 * time.Second, max: 5 * time.Minute},
+	// a bundle which fails to install keeps failing, retrying it only downloads it over and over
+	installationErrorClass:      {initial: 30 * time.Second, max: 30 * time.Minute, maxFailures: 5},
+	hostConfigurationErrorClass: {initial: 10 * time.Second, max: 10 * time.Minute},
+	bootstrapErrorClass:         {initial: 30 * time.Second, max: 30 * time.Minute},
+	cleanupErrorClass:           {initial: 10 * time.Second, max: 10 * time.Minute},
+}
+
+// bootstrapErrorClasses are the classes of the steps run until the node is bootstrapped
+var bootstrapErrorClasses = []string{bootstrapSecretErrorClass, installationErrorClass, hostConfigurationErrorClass, bootstrapErrorClass}
+
+// retryDelay returns how long the steps of the classes wait for their next retry, and whether
+// their retries are stopped
+func retryDelay(byoHost *infrastructurev1beta1.ByoHost, classes ...string) (time.Duration, bool) {
+	var delay time.Duration
+	for _, class := range classes {
+		backoff := getBackoff(byoHost, class)
+		if backoff == nil {
+			continue
+		}
+		if backoff.RetriesStopped {
+			return 0, true
+		}
+		if backoff.NextRetryTime != nil {
+			if wait := time.Until(backoff.NextRetryTime.Time); wait > delay {
+				delay = wait
+			}
+		}
+	}
+	return delay, false
+}
+
+// recordFailure records a failure of the class in the backoff of the ByoHost, stopping the
+// retries once the class reaches its maximum number of failures
+func (r *HostReconciler) recordFailure(byoHost *infrastructurev1beta1.ByoHost, class string, err error) {
+	policy := backoffPolicies[class]
+	backoff := getBackoff(byoHost, class)
+	if backoff == nil {
+		byoHost.Status.Backoff = append(byoHost.Status.Backoff, infrastructurev1beta1.ErrorBackoff{Class: class})
+		backoff = &byoHost.Status.Backoff[len(byoHost.Status.Backoff)-1]
+	}
+	backoff.Failures++
+	backoff.LastError = err.Error()
+
+	if policy.maxFailures > 0 && backoff.Failures >= policy.maxFailures {
+		backoff.RetriesStopped = true
+		backoff.NextRetryTime = nil
+		r.Recorder.Eventf(byoHost, corev1.EventTypeWarning, "RetriesStopped", "%s retries stopped after %d failures, set the %s annotation to retry",
+			class, backoff.Failures, infrastructurev1beta1.RetryAnnotation)
+		if class == installationErrorClass {
+			conditions.MarkFalse(byoHost, infrastructurev1beta1.K8sComponentsInstallationSucceeded, infrastructurev1beta1.K8sComponentsInstallationRetriesStoppedReason,
+				clusterv1.ConditionSeverityError, "installation failed %d times: %s", backoff.Failures, backoff.LastError)
+		}
+		return
+	}
+
+	delay := policy.initial
+	for i := int32(1); i < backoff.Failures && delay < policy.max; i++ {
+		delay *= 2
+	}
+	if delay > policy.max {
+		delay = policy.max
+	}
+	nextRetryTime := metav1.NewTime(time.Now().Add(delay))
+	backoff.NextRetryTime = &nextRetryTime
+}
+
+// recordSuccess removes the backoff of the classes from the ByoHost
+func recordSuccess(byoHost *infrastructurev1beta1.ByoHost, classes ...string) {
+	backoffs := byoHost.Status.Backoff[:0]
+	for _, backoff := range byoHost.Status.Backoff {
+		if !containsString(classes, backoff.Class) {
+			backoffs = append(backoffs, backoff)
+		}
+	}
+	if len(backoffs) == 0 {
+		backoffs = nil
+	}
+	byoHost.Status.Backoff = backoffs
+}
+
+func getBackoff(byoHost *infrastructurev1beta1.ByoHost, class string) *infrastructurev1beta1.ErrorBackoff {
+	for i := range byoHost.Status.Backoff {
+		if byoHost.Status.Backoff[i].Class == class {
+			return &byoHost.Status.Backoff[i]
+		}
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
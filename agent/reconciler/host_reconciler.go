@@ -6,22 +6,45 @@ package reconciler
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/cloudinit"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/faultinject"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/ignition"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/installer"
+	agentmetrics "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/metrics"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/offline"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/preflight"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/privilege"
 	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/registration"
-	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/common"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/rke2"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/watchdog"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/common/maintenance"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/common/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/annotations"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/cluster-api/util/predicates"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/kube-vip/kube-vip/pkg/vip"
 	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
@@ -35,6 +58,64 @@ type IK8sInstaller interface {
 	Uninstall(string, string, string) error
 }
 
+// IEventEmitter is implemented by the installers reporting their lifecycle
+// transitions, which are recorded as events on the ByoHost
+type IEventEmitter interface {
+	SetEventFunc(func(eventType, reason, message string))
+}
+
+// ICommandAuditor is implemented by the installers auditing the commands they run,
+// which are summarized in the status of the ByoHost
+type ICommandAuditor interface {
+	SetAuditFunc(func(installer.AuditRecord))
+}
+
+// ITraceable is implemented by the installers tracing the downloads and the installations
+// of the bundles, in the trace of the machine of the ByoHost
+type ITraceable interface {
+	SetTraceContext(context.Context)
+}
+
+// IProgressReporter is implemented by the installers reporting the phases of the installation,
+// which are published in the InstallationProgress of the ByoHost
+type IProgressReporter interface {
+	SetProgressFunc(func(installer.InstallPhase))
+}
+
+// IKubeletEnvironmentFiler is implemented by the installers knowing the environment file of the kubelet
+// service of the OS, e.g. /etc/sysconfig/kubelet for the RPM packages
+type IKubeletEnvironmentFiler interface {
+	KubeletEnvironmentFile() string
+}
+
+// IBundleRemover is implemented by the installers removing the bundles they
+// downloaded, once the host is uninstalled before its ByoHost is deleted
+type IBundleRemover interface {
+	RemoveBundles() error
+}
+
+//counterfeiter:generate . IAgentUpgrader
+type IAgentUpgrader interface {
+	Upgrade(string, string) error
+}
+
+//counterfeiter:generate . IPreflightChecker
+type IPreflightChecker interface {
+	Check() ([]preflight.Failure, error)
+}
+
+//counterfeiter:generate . BootstrapExecutor
+type BootstrapExecutor interface {
+	// Execute runs the bootstrap data of a format on the host
+	Execute(string) error
+}
+
+// TracedBootstrapExecutor is implemented by the bootstrap executors tracing the steps
+// of the bootstrap data, e.g. the kubeadm join of the node
+type TracedBootstrapExecutor interface {
+	ExecuteContext(context.Context, string) error
+}
+
 // HostReconciler encapsulates the data/logic needed to reconcile a ByoHost
 type HostReconciler struct {
 	Client                 client.Client
@@ -45,54 +126,265 @@ type HostReconciler struct {
 	K8sInstaller           IK8sInstaller
 	SkipK8sInstallation    bool
 	UseInstallerController bool
+	AgentUpgrader          IAgentUpgrader
+	AgentVersion           string
+	// NodeIP is the IP address the kubelet registers the node with, the
+	// NodeIPAnnotation of the ByoHost takes precedence over it
+	NodeIP string
+	// HostnameOverride is set when the host is registered under another name than its hostname,
+	// the kubelet then registers the node with the name of the ByoHost
+	HostnameOverride string
+	// KubeletConfigFile is the kubelet configuration written by kubeadm, the KubeletConfigurationAnnotation of
+	// the ByoHost is merged into. /var/lib/kubelet/config.yaml when not set
+	KubeletConfigFile string
+	// KubeletRootDir is the directory the kubelet keeps its pods and volumes in, when the kubelets of
+	// several agent instances share the machine. The kubelet default is used when not set
+	KubeletRootDir string
+	// HostLabels returns the labels of the agent, synced to the ByoHost on every reconcile
+	HostLabels func() map[string]string
+	// PreflightChecker checks the prerequisites of the host before installing the k8s components,
+	// the checks are skipped when not set
+	PreflightChecker IPreflightChecker
+	// BootstrapExecutors are the executors of the bootstrap data formats, keyed by the format
+	// of the bootstrap secret. They take precedence over the built-in cloud-config and ignition executors
+	BootstrapExecutors map[string]BootstrapExecutor
+	// RKE2Installer installs the rke2 bundle on the hosts attached to the machines of the rke2 distribution
+	RKE2Installer IK8sInstaller
+	// OfflineQueue buffers the ByoHost updates while the management cluster is unreachable, they are
+	// replayed before the next reconcile once it is back. The updates are lost when not set
+	OfflineQueue *offline.Queue
+	// Escalator removes the kubernetes files of the host as root, e.g. through sudo for an agent
+	// running as a dedicated user
+	Escalator privilege.Escalator
+	// ReconcileTrigger receives the ByoHost to reconcile on demand, e.g. from the local API of the agent
+	ReconcileTrigger <-chan event.GenericEvent
+	// Watchdog tracks the reconciles to restart the agent subsystems when they hang or keep panicking,
+	// the reconciles are not supervised when not set
+	Watchdog *watchdog.Watchdog
+	// MachineID is the hashed machine-id of the host, recorded in the ByoHost once the node is bootstrapped
+	// to detect the host being reimaged. The machine-id is not checked when not set
+	MachineID string
+	// CheckInstallArtifacts considers the host reimaged when the kubeconfig of its kubelet is missing
+	// while its ByoHost says the node is bootstrapped
+	CheckInstallArtifacts bool
+	// SecurityPosture is the HostSecurityPosture condition of the security self-check of the agent, set on
+	// the ByoHost on every reconcile. It is not set when nil
+	SecurityPosture *clusterv1.Condition
+
+	// rebooting is set once the host reboot is started, the installation resumes after the reboot
+	rebooting bool
 }
 
 const (
 	bootstrapSentinelFile = "/run/cluster-api/bootstrap-success.complete"
+	// cloudConfigFormat is the bootstrap data format of kubeadm, the default when the bootstrap secret has no format
+	cloudConfigFormat = "cloud-config"
+	// ignitionFormat is the bootstrap data format of the ignition config
+	ignitionFormat = "ignition"
+	// kubeletExtraArgsFile is sourced by the kubeadm drop-in of the kubelet service of the deb packages,
+	// its KUBELET_EXTRA_ARGS take precedence over the flags written by kubeadm. The installers of the other
+	// packages tell the file of their drop-in, see IKubeletEnvironmentFiler
+	kubeletExtraArgsFile = "/etc/default/kubelet"
+	// kubeletConfigFile is the kubelet configuration written by kubeadm when the node joins
+	kubeletConfigFile = "/var/lib/kubelet/config.yaml"
+	// kubeletRestartCommand restarts the kubelet for it to read its configuration again
+	kubeletRestartCommand = "systemctl restart kubelet"
+	// kubeVIPManifestFile is the static pod manifest of kube-vip, started by the kubelet along with the control plane
+	kubeVIPManifestFile = "/etc/kubernetes/manifests/kube-vip.yaml"
 	// KubeadmResetCommand is the command to run to force reset/remove nodes' local file system of the files created by kubeadm
 	KubeadmResetCommand = "kubeadm reset --force"
+	// RebootCommand is the command to run to reboot the host when the installation of the k8s components requires it
+	RebootCommand = "systemctl reboot"
+	// preflightRequeueInterval is how often failed preflight checks are run again, as the host
+	// admin fixes the host without the ByoHost being updated
+	preflightRequeueInterval = time.Minute
+	// offlineRequeueInterval is how often the management cluster is probed while it is unreachable
+	offlineRequeueInterval = 30 * time.Second
+	// installOperation and uninstallOperation are the installer operations summarized in ByoHost.Status.InstallerAudit
+	installOperation   = "Install"
+	uninstallOperation = "Uninstall"
+	// maxAuditedCommandLength is the length the failed command is truncated to in ByoHost.Status.InstallerAudit
+	maxAuditedCommandLength = 256
 )
 
 // Reconcile handles events for the ByoHost that is registered by this agent process
-func (r *HostReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+func (r *HostReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, reterr error) {
 	logger := ctrl.LoggerFrom(ctx)
 	logger.Info("Reconcile request received")
 
+	// The updates buffered while the management cluster was unreachable are sent first,
+	// so that the host is not reconciled again from a stale status
+	if err := r.OfflineQueue.Flush(ctx); err != nil {
+		if r.OfflineQueue.Observe(err) {
+			return ctrl.Result{RequeueAfter: offlineRequeueInterval}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
 	// Fetch the ByoHost instance
 	byoHost := &infrastructurev1beta1.ByoHost{}
 	err := r.Client.Get(ctx, req.NamespacedName, byoHost)
 	if err != nil {
+		if r.OfflineQueue.Observe(err) {
+			return ctrl.Result{RequeueAfter: offlineRequeueInterval}, nil
+		}
 		logger.Error(err, "error getting ByoHost")
 		return ctrl.Result{}, err
 	}
+	agentmetrics.RecordHeartbeat()
+	// The predicate of the controller only filters the events, the requeued requests of a
+	// host paused in between, e.g. while its cluster is moved by clusterctl, are dropped here
+	if annotations.HasPaused(byoHost) {
+		logger.Info("The ByoHost or its cluster is paused, not reconciling")
+		return ctrl.Result{}, nil
+	}
+	// The quarantined host is left as it is for forensics, its pending cleanup runs once the quarantine is lifted
+	if _, ok := byoHost.GetAnnotations()[infrastructurev1beta1.QuarantineAnnotation]; ok {
+		logger.Info("The ByoHost is quarantined, not reconciling")
+		return ctrl.Result{}, nil
+	}
+	original := byoHost.DeepCopy()
 	helper, _ := patch.NewHelper(byoHost, r.Client)
 	defer func() {
-		err = helper.Patch(ctx, byoHost)
+		err = r.patchByoHost(ctx, helper, original, byoHost)
+		if r.OfflineQueue.Observe(err) {
+			r.OfflineQueue.BufferPatch(original, byoHost)
+			err = nil
+		}
 		if err != nil && reterr == nil {
 			logger.Error(err, "failed to patch byohost")
 			reterr = err
 		}
+		// the management cluster going away is reported once by the queue, rather than
+		// retried with the error backoff of the controller
+		if r.OfflineQueue.Observe(reterr) {
+			res, reterr = ctrl.Result{RequeueAfter: offlineRequeueInterval}, nil
+		}
 	}()
 
+	if r.HostLabels != nil {
+		if conflicts := registration.SyncLabels(byoHost, r.HostLabels()); len(conflicts) > 0 {
+			logger.Info("Labels already set with other values, not overriding them", "labels", conflicts)
+		}
+	}
+
+	if r.SecurityPosture != nil {
+		conditions.Set(byoHost, r.SecurityPosture.DeepCopy())
+	}
+
+	// Check for a requested retry, after the retries were stopped or to skip the backoff
+	if _, ok := byoHost.GetAnnotations()[infrastructurev1beta1.RetryAnnotation]; ok {
+		logger.Info("Retry requested, resetting the backoff")
+		byoHost.Status.Backoff = nil
+		delete(byoHost.Annotations, infrastructurev1beta1.RetryAnnotation)
+	}
+
+	// Check for a requested agent upgrade, deferred until the maintenance window of the host opens
+	// without holding the other operations of the host
+	var deferredUntil time.Duration
+	deferred := false
+	if r.AgentUpgrader != nil {
+		desiredVersion := byoHost.GetAnnotations()[infrastructurev1beta1.DesiredAgentVersionAnnotation]
+		if desiredVersion != "" && desiredVersion != r.AgentVersion {
+			var res ctrl.Result
+			if deferred, res = r.deferToMaintenanceWindow(ctx, byoHost, "host agent upgrade to "+desiredVersion); !deferred {
+				return ctrl.Result{}, r.upgradeAgent(ctx, byoHost, desiredVersion)
+			}
+			deferredUntil = res.RequeueAfter
+			conditions.MarkFalse(byoHost, infrastructurev1beta1.AgentUpgradeSucceeded, infrastructurev1beta1.WaitingForMaintenanceWindowReason,
+				clusterv1.ConditionSeverityInfo, "host agent upgrade to %s is deferred until the maintenance window opens", desiredVersion)
+		} else if desiredVersion != "" {
+			conditions.MarkTrue(byoHost, infrastructurev1beta1.AgentUpgradeSucceeded)
+		}
+	}
+
+	// The deletion of the ByoHost is held by the agent to uninstall the host, when requested by its deletion policy
+	if byoHost.ObjectMeta.DeletionTimestamp.IsZero() {
+		if uninstallOnDeletion(byoHost) {
+			controllerutil.AddFinalizer(byoHost, infrastructurev1beta1.HostUninstallFinalizer)
+		} else {
+			controllerutil.RemoveFinalizer(byoHost, infrastructurev1beta1.HostUninstallFinalizer)
+		}
+	}
+
 	// Check for host cleanup annotation
 	hostAnnotations := byoHost.GetAnnotations()
 	_, ok := hostAnnotations[infrastructurev1beta1.HostCleanupAnnotation]
 	if ok {
+		if delay, _ := retryDelay(byoHost, cleanupErrorClass); delay > 0 {
+			return ctrl.Result{RequeueAfter: delay}, nil
+		}
+		if deferCleanup, res := r.deferToMaintenanceWindow(ctx, byoHost, "host cleanup"); deferCleanup {
+			return requeueBefore(res, deferredUntil), nil
+		}
 		err = r.hostCleanUp(ctx, byoHost)
 		if err != nil {
+			r.recordFailure(byoHost, cleanupErrorClass, err)
 			return ctrl.Result{}, err
 		}
+		// the host is back in the capacity pool, the retries of its next machine start over
+		byoHost.Status.Backoff = nil
 		return ctrl.Result{}, nil
 	}
 
+	if !deferred {
+		conditions.Delete(byoHost, infrastructurev1beta1.MaintenanceWindowOpen)
+	}
+
 	// Handle deleted machines
 	if !byoHost.ObjectMeta.DeletionTimestamp.IsZero() {
-		return r.reconcileDelete(ctx, byoHost)
+		res, err = r.reconcileDelete(ctx, byoHost)
+		return requeueBefore(res, deferredUntil), err
 	}
-	return r.reconcileNormal(ctx, byoHost)
+	res, err = r.reconcileNormal(ctx, byoHost)
+	return requeueBefore(res, deferredUntil), err
+}
+
+// patchByoHost patches the changes of the reconcile to the host, its status being applied under the field
+// manager of the agent so that it does not conflict with the controller, see registration.PatchHost
+func (r *HostReconciler) patchByoHost(ctx context.Context, helper *patch.Helper, original, byoHost *infrastructurev1beta1.ByoHost) error {
+	return registration.PatchHost(ctx, r.Client, helper, original, byoHost)
 }
 
-func (r *HostReconciler) reconcileNormal(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost) (ctrl.Result, error) {
+// deferToMaintenanceWindow tells whether the disruptive operation is deferred until the next maintenance
+// window of the host, which is reported in the MaintenanceWindowOpen condition. The returned result
+// requeues the host when the window opens.
+func (r *HostReconciler) deferToMaintenanceWindow(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost, operation string) (bool, ctrl.Result) {
+	logger := ctrl.LoggerFrom(ctx)
+	open, next, err := maintenance.Check(byoHost.GetAnnotations()[infrastructurev1beta1.MaintenanceWindowAnnotation], time.Now())
+	switch {
+	case err != nil:
+		logger.Info("Invalid maintenance window, deferring the operation", "operation", operation, "error", err.Error())
+		conditions.MarkFalse(byoHost, infrastructurev1beta1.MaintenanceWindowOpen, infrastructurev1beta1.MaintenanceWindowInvalidReason,
+			clusterv1.ConditionSeverityError, "%s is deferred: %v", operation, err)
+		return true, ctrl.Result{}
+	case open:
+		conditions.Delete(byoHost, infrastructurev1beta1.MaintenanceWindowOpen)
+		return false, ctrl.Result{}
+	case next.IsZero():
+		logger.Info("The maintenance window never opens, deferring the operation", "operation", operation)
+		conditions.MarkFalse(byoHost, infrastructurev1beta1.MaintenanceWindowOpen, infrastructurev1beta1.WaitingForMaintenanceWindowReason,
+			clusterv1.ConditionSeverityWarning, "%s is deferred, the maintenance window never opens", operation)
+		return true, ctrl.Result{}
+	}
+	logger.Info("Deferring the operation until the maintenance window opens", "operation", operation, "opensAt", next)
+	conditions.MarkFalse(byoHost, infrastructurev1beta1.MaintenanceWindowOpen, infrastructurev1beta1.WaitingForMaintenanceWindowReason,
+		clusterv1.ConditionSeverityInfo, "%s is deferred until the maintenance window opens at %s", operation, next.Format(time.RFC3339))
+	return true, ctrl.Result{RequeueAfter: time.Until(next)}
+}
+
+// requeueBefore returns the result requeued after the delay at the latest, when there is one
+func requeueBefore(res ctrl.Result, delay time.Duration) ctrl.Result {
+	if delay <= 0 || (res.Requeue && res.RequeueAfter == 0) {
+		return res
+	}
+	if res.RequeueAfter == 0 || delay < res.RequeueAfter {
+		res.RequeueAfter = delay
+	}
+	return res
+}
+
+func (r *HostReconciler) reconcileNormal(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost) (_ ctrl.Result, reterr error) {
 	logger := ctrl.LoggerFrom(ctx)
 	if byoHost.Status.MachineRef == nil {
 		logger.Info("Machine ref not yet set")
@@ -106,14 +398,61 @@ func (r *HostReconciler) reconcileNormal(ctx context.Context, byoHost *infrastru
 		return ctrl.Result{}, nil
 	}
 
+	r.reconcileReimage(ctx, byoHost)
+
+	if !conditions.IsTrue(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded) && r.bootstrapSucceeded() {
+		// the status of the ByoHost does not survive its move by clusterctl, the node bootstrapped
+		// before is not bootstrapped again
+		logger.Info("Bootstrap sentinel file found, the k8s node is already bootstrapped")
+		conditions.MarkTrue(byoHost, infrastructurev1beta1.K8sComponentsInstallationSucceeded)
+		conditions.MarkTrue(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded)
+		byoHost.Status.InstalledMachineID = r.MachineID
+		r.reportProgress(ctx, byoHost, infrastructurev1beta1.InstallationPhaseCompleted)
+	}
+
 	if !conditions.IsTrue(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded) {
-		bootstrapScript, err := r.getBootstrapScript(ctx, byoHost.Spec.BootstrapSecret.Name, byoHost.Spec.BootstrapSecret.Namespace)
+		// the failed steps are retried with an exponential backoff, rather than on every update of the ByoHost
+		if delay, stopped := retryDelay(byoHost, bootstrapErrorClasses...); stopped {
+			logger.Info("Retries stopped after repeated failures, waiting for the retry annotation", "annotation", infrastructurev1beta1.RetryAnnotation)
+			return ctrl.Result{}, nil
+		} else if delay > 0 {
+			logger.Info("Backing off after a failure", "retryAfter", delay)
+			return ctrl.Result{RequeueAfter: delay}, nil
+		}
+
+		// the bootstrap of the host joins the trace of its machine, see TraceParentAnnotation
+		var span trace.Span
+		ctx, span = tracing.Continue(ctx, byoHost, "HostAgent.Bootstrap", attribute.String("byohost", byoHost.Name))
+		defer func() { tracing.End(span, reterr) }()
+
+		bootstrapScript, format, err := r.getBootstrapScript(ctx, byoHost.Spec.BootstrapSecret.Name, byoHost.Spec.BootstrapSecret.Namespace)
 		if err != nil {
 			logger.Error(err, "error getting bootstrap script")
 			r.Recorder.Eventf(byoHost, corev1.EventTypeWarning, "ReadBootstrapSecretFailed", "bootstrap secret %s not found", byoHost.Spec.BootstrapSecret.Name)
+			agentmetrics.RecordError("ReadBootstrapSecretFailed")
+			r.recordFailure(byoHost, bootstrapSecretErrorClass, err)
 			return ctrl.Result{}, err
 		}
 
+		// the format is checked before installing anything on the host, it does not change
+		// until the bootstrap provider regenerates the secret
+		executor, ok := r.bootstrapExecutor(byoHost, format)
+		if !ok {
+			logger.Info("Unsupported bootstrap data format", "format", format)
+			r.Recorder.Eventf(byoHost, corev1.EventTypeWarning, "BootstrapFormatUnsupported", "bootstrap data format %q is not supported", format)
+			agentmetrics.RecordError("BootstrapFormatUnsupported")
+			conditions.MarkFalse(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded, infrastructurev1beta1.BootstrapFormatUnsupportedReason, clusterv1.ConditionSeverityError,
+				"bootstrap data format %q is not supported", format)
+			return ctrl.Result{}, nil
+		}
+
+		if r.PreflightChecker != nil && !conditions.IsTrue(byoHost, infrastructurev1beta1.HostPreflightSucceeded) {
+			passed, err := r.runPreflightChecks(ctx, byoHost)
+			if err != nil || !passed {
+				return ctrl.Result{RequeueAfter: preflightRequeueInterval}, err
+			}
+		}
+
 		if r.SkipK8sInstallation {
 			logger.Info("Skipping installation of k8s components")
 		} else if r.UseInstallerController {
@@ -122,61 +461,278 @@ func (r *HostReconciler) reconcileNormal(ctx context.Context, byoHost *infrastru
 				conditions.MarkFalse(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded, infrastructurev1beta1.K8sInstallationSecretUnavailableReason, clusterv1.ConditionSeverityInfo, "")
 				return ctrl.Result{}, nil
 			}
+		} else if r.rebooting {
+			logger.Info("Waiting for the host to reboot")
+			return ctrl.Result{}, nil
 		} else {
 			err = r.installK8sComponents(ctx, byoHost)
+			var rebootRequired *installer.RebootRequiredError
+			if errors.As(err, &rebootRequired) {
+				return r.reconcileReboot(ctx, byoHost, rebootRequired.Reason)
+			}
 			if err != nil {
 				logger.Error(err, "error in installing k8s components")
-				r.Recorder.Event(byoHost, corev1.EventTypeWarning, "InstallK8sComponentFailed", "k8s component installation failed")
+				r.Recorder.Eventf(byoHost, corev1.EventTypeWarning, "InstallK8sComponentFailed", "k8s component installation failed: %v", err)
+				agentmetrics.RecordError("InstallK8sComponentFailed")
 				conditions.MarkFalse(byoHost, infrastructurev1beta1.K8sComponentsInstallationSucceeded, infrastructurev1beta1.K8sComponentsInstallationFailedReason, clusterv1.ConditionSeverityInfo, "")
+				if conditions.GetReason(byoHost, infrastructurev1beta1.K8sBundleDownloadSucceeded) == infrastructurev1beta1.BundleDownloadingReason {
+					conditions.MarkFalse(byoHost, infrastructurev1beta1.K8sBundleDownloadSucceeded, infrastructurev1beta1.BundleDownloadFailedReason, clusterv1.ConditionSeverityInfo, "")
+				}
+				r.recordFailure(byoHost, installationErrorClass, err)
 				return ctrl.Result{}, err
 			}
+			recordSuccess(byoHost, installationErrorClass)
 		}
 
+		r.reportProgress(ctx, byoHost, infrastructurev1beta1.InstallationPhaseConfiguring)
 		err = r.cleank8sdirectories(ctx)
 		if err != nil {
 			logger.Error(err, "error cleaning up k8s directories, please delete it manually for reconcile to proceed.")
-			r.Recorder.Event(byoHost, corev1.EventTypeWarning, "CleanK8sDirectoriesFailed", "clean k8s directories failed")
+			r.Recorder.Eventf(byoHost, corev1.EventTypeWarning, "CleanK8sDirectoriesFailed", "clean k8s directories failed: %v", err)
+			agentmetrics.RecordError("CleanK8sDirectoriesFailed")
 			conditions.MarkFalse(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded, infrastructurev1beta1.CleanK8sDirectoriesFailedReason, clusterv1.ConditionSeverityError, "")
+			r.recordFailure(byoHost, hostConfigurationErrorClass, err)
+			return ctrl.Result{}, err
+		}
+
+		bootstrapScript, err = r.configureKubelet(ctx, byoHost, format, bootstrapScript)
+		if err != nil {
+			logger.Error(err, "error configuring the kubelet")
+			r.Recorder.Eventf(byoHost, corev1.EventTypeWarning, "ConfigureKubeletFailed", "configuring the kubelet failed: %v", err)
+			agentmetrics.RecordError("ConfigureKubeletFailed")
+			r.recordFailure(byoHost, hostConfigurationErrorClass, err)
 			return ctrl.Result{}, err
 		}
 
-		err = r.bootstrapK8sNode(ctx, bootstrapScript, byoHost)
+		err = r.writeKubeVIPManifest(ctx, byoHost)
+		if err != nil {
+			logger.Error(err, "error writing the kube-vip manifest")
+			r.Recorder.Eventf(byoHost, corev1.EventTypeWarning, "WriteKubeVIPManifestFailed", "writing the kube-vip manifest failed: %v", err)
+			agentmetrics.RecordError("WriteKubeVIPManifestFailed")
+			r.recordFailure(byoHost, hostConfigurationErrorClass, err)
+			return ctrl.Result{}, err
+		}
+
+		err = r.bootstrapK8sNode(ctx, executor, bootstrapScript, byoHost)
 		if err != nil {
 			logger.Error(err, "error in bootstrapping k8s node")
 			r.Recorder.Event(byoHost, corev1.EventTypeWarning, "BootstrapK8sNodeFailed", "k8s Node Bootstrap failed")
+			agentmetrics.RecordError("BootstrapK8sNodeFailed")
 			_ = r.resetNode(ctx, byoHost)
-			conditions.MarkFalse(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded, infrastructurev1beta1.CloudInitExecutionFailedReason, clusterv1.ConditionSeverityError, "")
+			if isBootstrapTokenExpired(err) {
+				// the ByoMachine controller has the bootstrap data regenerated, rather than retrying the dead token
+				r.Recorder.Event(byoHost, corev1.EventTypeWarning, "BootstrapTokenExpired", "the bootstrap token of the bootstrap data expired")
+				conditions.MarkFalse(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded, infrastructurev1beta1.BootstrapTokenExpiredReason, clusterv1.ConditionSeverityWarning,
+					"the bootstrap token of the bootstrap data expired")
+			} else {
+				conditions.MarkFalse(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded, infrastructurev1beta1.CloudInitExecutionFailedReason, clusterv1.ConditionSeverityError, "")
+			}
+			r.recordFailure(byoHost, bootstrapErrorClass, err)
 			return ctrl.Result{}, err
 		}
+		recordSuccess(byoHost, bootstrapErrorClasses...)
 		logger.Info("k8s node successfully bootstrapped")
 		r.Recorder.Event(byoHost, corev1.EventTypeNormal, "BootstrapK8sNodeSucceeded", "k8s Node Bootstraped")
 		conditions.MarkTrue(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded)
+		byoHost.Status.InstalledMachineID = r.MachineID
+		r.reportProgress(ctx, byoHost, infrastructurev1beta1.InstallationPhaseCompleted)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// reconcileReboot reboots the host the installation of the k8s components requires to reboot, once the reboot is
+// approved with the RebootApprovedAnnotation and the maintenance window of the host is open. The approval is removed
+// before rebooting, and the installation resumes when the agent starts again after the reboot.
+func (r *HostReconciler) reconcileReboot(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost, reason string) (ctrl.Result, error) {
+	logger := ctrl.LoggerFrom(ctx)
+	if _, ok := byoHost.Annotations[infrastructurev1beta1.RebootApprovedAnnotation]; !ok {
+		if conditions.GetReason(byoHost, infrastructurev1beta1.K8sComponentsInstallationSucceeded) != infrastructurev1beta1.RebootRequiredReason {
+			logger.Info("Host reboot required, waiting for its approval", "reason", reason, "annotation", infrastructurev1beta1.RebootApprovedAnnotation)
+			r.Recorder.Eventf(byoHost, corev1.EventTypeNormal, "RebootRequired", "host reboot required: %s", reason)
+		}
+		conditions.MarkFalse(byoHost, infrastructurev1beta1.K8sComponentsInstallationSucceeded, infrastructurev1beta1.RebootRequiredReason, clusterv1.ConditionSeverityWarning,
+			"%s, waiting for the %s annotation", reason, infrastructurev1beta1.RebootApprovedAnnotation)
+		return ctrl.Result{}, nil
+	}
+	if deferred, res := r.deferToMaintenanceWindow(ctx, byoHost, "host reboot"); deferred {
+		return res, nil
+	}
+
+	// the approval is removed before the host goes down, so that the host is not rebooted again without a
+	// new approval. A copy is patched to keep the status updates of the reconcile.
+	approved := byoHost.DeepCopy()
+	delete(byoHost.Annotations, infrastructurev1beta1.RebootApprovedAnnotation)
+	unapproved := approved.DeepCopy()
+	delete(unapproved.Annotations, infrastructurev1beta1.RebootApprovedAnnotation)
+	if err := r.Client.Patch(ctx, unapproved, client.MergeFrom(approved)); err != nil {
+		return ctrl.Result{}, err
 	}
 
+	logger.Info("Rebooting the host", "reason", reason)
+	r.Recorder.Eventf(byoHost, corev1.EventTypeNormal, "RebootingHost", "rebooting the host: %s", reason)
+	conditions.MarkFalse(byoHost, infrastructurev1beta1.K8sComponentsInstallationSucceeded, infrastructurev1beta1.RebootingReason, clusterv1.ConditionSeverityInfo, "%s", reason)
+	if err := r.CmdRunner.RunCmd(RebootCommand); err != nil {
+		r.Recorder.Eventf(byoHost, corev1.EventTypeWarning, "RebootFailed", "host reboot failed: %v", err)
+		agentmetrics.RecordError("RebootFailed")
+		conditions.MarkFalse(byoHost, infrastructurev1beta1.K8sComponentsInstallationSucceeded, infrastructurev1beta1.RebootRequiredReason, clusterv1.ConditionSeverityWarning,
+			"%s, the reboot failed: %v", reason, err)
+		return ctrl.Result{}, errors.Wrap(err, "failed to reboot the host")
+	}
+	r.rebooting = true
 	return ctrl.Result{}, nil
 }
 
+// runPreflightChecks reports the failed preflight checks in the HostPreflightSucceeded condition,
+// returning whether the host passed them
+func (r *HostReconciler) runPreflightChecks(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost) (bool, error) {
+	logger := ctrl.LoggerFrom(ctx)
+	logger.Info("Running the host preflight checks")
+
+	failures, err := r.PreflightChecker.Check()
+	if err != nil {
+		logger.Error(err, "error running the host preflight checks")
+		agentmetrics.RecordError("HostPreflightFailed")
+		conditions.MarkFalse(byoHost, infrastructurev1beta1.HostPreflightSucceeded, infrastructurev1beta1.PreflightCheckErrorReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return false, err
+	}
+	if len(failures) > 0 {
+		messages := make([]string, 0, len(failures))
+		for _, failure := range failures {
+			messages = append(messages, failure.Message)
+		}
+		message := strings.Join(messages, "; ")
+		logger.Info("Host preflight checks failed", "failures", messages)
+		r.Recorder.Eventf(byoHost, corev1.EventTypeWarning, "HostPreflightFailed", "host preflight checks failed: %s", message)
+		agentmetrics.RecordError("HostPreflightFailed")
+		conditions.MarkFalse(byoHost, infrastructurev1beta1.HostPreflightSucceeded, failures[0].Reason, clusterv1.ConditionSeverityWarning, message)
+		return false, nil
+	}
+	conditions.MarkTrue(byoHost, infrastructurev1beta1.HostPreflightSucceeded)
+	return true, nil
+}
+
+// reconcileDelete uninstalls the host before its ByoHost is deleted, when requested by its deletion
+// policy. A host still attached to a machine is cleaned up first, its k8s components being uninstalled
+// unless the installation is skipped, then the downloaded bundles are removed and the deletion released.
 func (r *HostReconciler) reconcileDelete(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost) (ctrl.Result, error) {
+	logger := ctrl.LoggerFrom(ctx)
+	if !controllerutil.ContainsFinalizer(byoHost, infrastructurev1beta1.HostUninstallFinalizer) {
+		return ctrl.Result{}, nil
+	}
+	if !uninstallOnDeletion(byoHost) {
+		logger.Info("Deletion policy changed, leaving the host as it is")
+		controllerutil.RemoveFinalizer(byoHost, infrastructurev1beta1.HostUninstallFinalizer)
+		return ctrl.Result{}, nil
+	}
+
+	if deferUninstall, res := r.deferToMaintenanceWindow(ctx, byoHost, "host uninstall"); deferUninstall {
+		return res, nil
+	}
+
+	if byoHost.Status.MachineRef != nil {
+		if delay, _ := retryDelay(byoHost, cleanupErrorClass); delay > 0 {
+			return ctrl.Result{RequeueAfter: delay}, nil
+		}
+		logger.Info("ByoHost deleted while attached to a machine, cleaning up host")
+		if err := r.hostCleanUp(ctx, byoHost); err != nil {
+			r.recordFailure(byoHost, cleanupErrorClass, err)
+			return ctrl.Result{}, err
+		}
+		// the status of the cleaned up host is patched before its deletion is released
+		byoHost.Status.Backoff = nil
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	if err := r.removeBundles(ctx); err != nil {
+		logger.Error(err, "error removing the downloaded bundles")
+		r.Recorder.Eventf(byoHost, corev1.EventTypeWarning, "RemoveBundlesFailed", "removing the downloaded bundles failed: %v", err)
+		agentmetrics.RecordError("RemoveBundlesFailed")
+		return ctrl.Result{}, err
+	}
+	r.Recorder.Event(byoHost, corev1.EventTypeNormal, "HostUninstallSucceeded", "host uninstalled before the ByoHost deletion")
+	controllerutil.RemoveFinalizer(byoHost, infrastructurev1beta1.HostUninstallFinalizer)
 	return ctrl.Result{}, nil
 }
 
-func (r *HostReconciler) getBootstrapScript(ctx context.Context, dataSecretName, namespace string) (string, error) {
+// uninstallOnDeletion returns true if the host is uninstalled before its ByoHost is deleted
+func uninstallOnDeletion(byoHost *infrastructurev1beta1.ByoHost) bool {
+	return byoHost.GetAnnotations()[infrastructurev1beta1.DeletionPolicyAnnotation] == infrastructurev1beta1.DeletionPolicyUninstall
+}
+
+// removeBundles removes the bundles downloaded by the installers of the host
+func (r *HostReconciler) removeBundles(ctx context.Context) error {
+	logger := ctrl.LoggerFrom(ctx)
+	if r.SkipK8sInstallation {
+		logger.Info("Skipping removal of the downloaded bundles")
+		return nil
+	}
+	for _, k8sInstaller := range []IK8sInstaller{r.K8sInstaller, r.RKE2Installer} {
+		if remover, ok := k8sInstaller.(IBundleRemover); ok {
+			if err := remover.RemoveBundles(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// getBootstrapScript returns the bootstrap data of the secret, along with its format
+func (r *HostReconciler) getBootstrapScript(ctx context.Context, dataSecretName, namespace string) (string, string, error) {
 	secret := &corev1.Secret{}
+	_, span := tracing.Start(ctx, "FetchBootstrapSecret", attribute.String("secret", namespace+"/"+dataSecretName))
 	err := r.Client.Get(ctx, types.NamespacedName{Name: dataSecretName, Namespace: namespace}, secret)
+	tracing.End(span, err)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
 	bootstrapSecret := string(secret.Data["value"])
-	return bootstrapSecret, nil
+	format := string(secret.Data["format"])
+	if format == "" {
+		format = cloudConfigFormat
+	}
+	return bootstrapSecret, format, nil
+}
+
+// bootstrapExecutor returns the executor of the bootstrap data format
+func (r *HostReconciler) bootstrapExecutor(byoHost *infrastructurev1beta1.ByoHost, format string) (BootstrapExecutor, bool) {
+	if executor, ok := r.BootstrapExecutors[format]; ok {
+		return executor, true
+	}
+	if isRKE2(byoHost) {
+		if format != cloudConfigFormat {
+			return nil, false
+		}
+		return rke2.Executor{
+			WriteFilesExecutor:    r.FileWriter,
+			RunCmdExecutor:        r.CmdRunner,
+			ParseTemplateExecutor: r.TemplateParser}, true
+	}
+	switch format {
+	case cloudConfigFormat:
+		return cloudinit.ScriptExecutor{
+			WriteFilesExecutor:    r.FileWriter,
+			RunCmdExecutor:        r.CmdRunner,
+			ParseTemplateExecutor: r.TemplateParser}, true
+	case ignitionFormat:
+		return ignition.Executor{
+			WriteFilesExecutor: r.FileWriter,
+			RunCmdExecutor:     r.CmdRunner}, true
+	}
+	return nil, false
 }
 
 // SetupWithManager sets up the controller with the manager
 func (r *HostReconciler) SetupWithManager(ctx context.Context, mgr manager.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&infrastructurev1beta1.ByoHost{}).
 		WithEventFilter(predicates.ResourceNotPaused(ctrl.LoggerFrom(ctx))).
-		Complete(r)
+		WithEventFilter(registration.IgnoreHeartbeats())
+	if r.ReconcileTrigger != nil {
+		b = b.Watches(&source.Channel{Source: r.ReconcileTrigger}, &handler.EnqueueRequestForObject{})
+	}
+	return b.Complete(r.Watchdog.Wrap("host-reconciler", r))
 }
 
 // cleanup /run/kubeadm, /etc/cni/net.d dirs to remove any stale config on the host
@@ -191,7 +747,7 @@ func (r *HostReconciler) cleank8sdirectories(ctx context.Context) error {
 	errList := make([]error, 0)
 	for _, dir := range dirs {
 		logger.Info(fmt.Sprintf("cleaning up directory %s", dir))
-		if err := common.RemoveGlob(dir); err != nil {
+		if err := r.removeGlob(dir); err != nil {
 			logger.Error(err, fmt.Sprintf("failed to clean up directory %s", dir))
 			errList = append(errList, err)
 		}
@@ -203,6 +759,20 @@ func (r *HostReconciler) cleank8sdirectories(ctx context.Context) error {
 	return nil
 }
 
+// removeGlob removes the files matching the pattern as root
+func (r *HostReconciler) removeGlob(pattern string) error {
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if err = r.Escalator.RemoveAll(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *HostReconciler) hostCleanUp(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost) error {
 	logger := ctrl.LoggerFrom(ctx)
 	logger.Info("cleaning up host")
@@ -237,34 +807,283 @@ func (r *HostReconciler) hostCleanUp(ctx context.Context, byoHost *infrastructur
 	}
 
 	r.removeAnnotations(ctx, byoHost)
+	// the host is checked again before its next installation
+	conditions.Delete(byoHost, infrastructurev1beta1.HostPreflightSucceeded)
+	byoHost.Status.InstalledMachineID = ""
+	resetProgress(byoHost)
 	conditions.MarkFalse(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded, infrastructurev1beta1.K8sNodeAbsentReason, clusterv1.ConditionSeverityInfo, "")
+	r.Recorder.Event(byoHost, corev1.EventTypeNormal, "HostCleanupSucceeded", "host cleanup completed")
 	return nil
 }
 
 func (r *HostReconciler) resetNode(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost) error {
 	logger := ctrl.LoggerFrom(ctx)
-	logger.Info("Running kubeadm reset")
+	resetName, resetCommand := "kubeadm reset", KubeadmResetCommand
+	if isRKE2(byoHost) {
+		resetName, resetCommand = "rke2 killall", rke2.KillAllCommand
+	}
+	logger.Info("Running " + resetName)
+	defer agentmetrics.ObservePhase(agentmetrics.PhaseReset, time.Now())
 
-	err := r.CmdRunner.RunCmd(KubeadmResetCommand)
+	err := r.CmdRunner.RunCmd(resetCommand)
 	if err != nil {
 		r.Recorder.Event(byoHost, corev1.EventTypeWarning, "ResetK8sNodeFailed", "k8s Node Reset failed")
-		return errors.Wrapf(err, "failed to exec kubeadm reset")
+		agentmetrics.RecordError("ResetK8sNodeFailed")
+		return errors.Wrapf(err, "failed to exec %s", resetName)
 	}
 	logger.Info("Kubernetes Node reset completed")
 	r.Recorder.Event(byoHost, corev1.EventTypeNormal, "ResetK8sNodeSucceeded", "k8s Node Reset completed")
 	return nil
 }
 
-func (r *HostReconciler) bootstrapK8sNode(ctx context.Context, bootstrapScript string, byoHost *infrastructurev1beta1.ByoHost) error {
+func (r *HostReconciler) bootstrapK8sNode(ctx context.Context, executor BootstrapExecutor, bootstrapScript string, byoHost *infrastructurev1beta1.ByoHost) error {
 	logger := ctrl.LoggerFrom(ctx)
 	logger.Info("Bootstraping k8s Node")
-	return cloudinit.ScriptExecutor{
-		WriteFilesExecutor:    r.FileWriter,
-		RunCmdExecutor:        r.CmdRunner,
-		ParseTemplateExecutor: r.TemplateParser}.Execute(bootstrapScript)
+	defer agentmetrics.ObservePhase(agentmetrics.PhaseBootstrap, time.Now())
+	r.Recorder.Event(byoHost, corev1.EventTypeNormal, "BootstrapK8sNodeStarted", "k8s Node Bootstrap started")
+	bootstrapScript = hostnameReplacer(byoHost.Name).Replace(bootstrapScript)
+	ctx, span := tracing.Start(ctx, "BootstrapK8sNode")
+	var err error
+	if traced, ok := executor.(TracedBootstrapExecutor); ok {
+		err = traced.ExecuteContext(ctx, bootstrapScript)
+	} else {
+		err = executor.Execute(bootstrapScript)
+	}
+	if err == nil && !isRKE2(byoHost) {
+		err = r.applyKubeletConfiguration(ctx, byoHost)
+	}
+	tracing.End(span, err)
+	return err
 }
 
-func (r *HostReconciler) installK8sComponents(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost) error {
+// applyKubeletConfiguration merges the KubeletConfigurationAnnotation of the host into the kubelet configuration
+// written by kubeadm, and restarts the kubelet to apply it. kubeadm only patches the kubelet configuration
+// from Kubernetes v1.25, the configuration of the cluster being downloaded and written as the node joins.
+func (r *HostReconciler) applyKubeletConfiguration(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost) error {
+	patch := byoHost.GetAnnotations()[infrastructurev1beta1.KubeletConfigurationAnnotation]
+	if patch == "" {
+		return nil
+	}
+	configFile := r.KubeletConfigFile
+	if configFile == "" {
+		configFile = kubeletConfigFile
+	}
+	config, err := r.Escalator.ReadFile(configFile)
+	if err != nil {
+		return errors.Wrap(err, "error reading the kubelet configuration")
+	}
+	merged, err := mergeKubeletConfiguration(config, []byte(patch))
+	if err != nil {
+		return err
+	}
+
+	ctrl.LoggerFrom(ctx).Info("Applying the kubelet configuration of the machine", "file", configFile)
+	if err = r.FileWriter.WriteToFile(&cloudinit.Files{Path: configFile, Content: merged, Permissions: "0644"}); err != nil {
+		return err
+	}
+	return r.CmdRunner.RunCmd(kubeletRestartCommand)
+}
+
+// bootstrapTokenExpiredErrors are the errors of kubeadm failing to join the node with an expired bootstrap
+// token, its secret being deleted from the workload cluster once expired
+var bootstrapTokenExpiredErrors = []string{
+	"is invalid for this cluster or it has expired",
+	"could not find a JWS signature in the cluster-info ConfigMap",
+}
+
+// isBootstrapTokenExpired returns whether the bootstrap failed as its bootstrap token expired
+func isBootstrapTokenExpired(err error) bool {
+	for _, expired := range bootstrapTokenExpiredErrors {
+		if strings.Contains(err.Error(), expired) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostnameReplacer replaces the hostname variables of the cloud-init instance data in the bootstrap
+// data, e.g. the kubeadm nodeRegistration.name set to '{{ ds.meta_data.hostname }}', with the name of the ByoHost
+func hostnameReplacer(hostName string) *strings.Replacer {
+	return strings.NewReplacer("{{ ds.meta_data.hostname }}", hostName, "{{ ds.meta_data.local_hostname }}", hostName,
+		"{{ v1.local_hostname }}", hostName)
+}
+
+// configureKubelet pins the IP address and the name the kubelet registers the node with, when set,
+// and adds the kubelet flags of the attached machine. The pinned IP address is also patched into the
+// kubeadm configuration of the cloud-config bootstrap data, which is returned
+func (r *HostReconciler) configureKubelet(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost, format, bootstrapScript string) (string, error) {
+	args := []string{}
+	nodeIP, err := r.nodeIP(byoHost)
+	if err != nil {
+		return "", err
+	}
+	if nodeIP != "" {
+		args = append(args, "--node-ip="+nodeIP)
+		if format == cloudConfigFormat && !isRKE2(byoHost) {
+			if bootstrapScript, err = pinKubeadmNodeIP(bootstrapScript, nodeIP); err != nil {
+				return "", err
+			}
+		}
+	}
+	if r.HostnameOverride != "" {
+		args = append(args, "--hostname-override="+byoHost.Name)
+	}
+	if r.KubeletRootDir != "" {
+		args = append(args, "--root-dir="+r.KubeletRootDir)
+	}
+	if extraArgs := byoHost.GetAnnotations()[infrastructurev1beta1.KubeletExtraArgsAnnotation]; extraArgs != "" {
+		args = append(args, extraArgs)
+	}
+	if len(args) == 0 {
+		return bootstrapScript, nil
+	}
+
+	environmentFile := kubeletExtraArgsFile
+	if filer, ok := r.K8sInstaller.(IKubeletEnvironmentFiler); ok {
+		environmentFile = filer.KubeletEnvironmentFile()
+	}
+	logger := ctrl.LoggerFrom(ctx)
+	logger.Info("Configuring the kubelet", "nodeIP", nodeIP, "args", strings.Join(args, " "), "file", environmentFile)
+	return bootstrapScript, r.FileWriter.WriteToFile(&cloudinit.Files{
+		Path:        environmentFile,
+		Content:     fmt.Sprintf("KUBELET_EXTRA_ARGS=%s\n", strings.Join(args, " ")),
+		Permissions: "0644",
+	})
+}
+
+// nodeIP returns the IP addresses the node is pinned to, comma separated: the node IP annotation of
+// the ByoHost, the agent flag, or the addresses of the IP families of the cluster, in that order
+func (r *HostReconciler) nodeIP(byoHost *infrastructurev1beta1.ByoHost) (string, error) {
+	nodeIP := r.NodeIP
+	if annotatedIP, ok := byoHost.GetAnnotations()[infrastructurev1beta1.NodeIPAnnotation]; ok {
+		nodeIP = annotatedIP
+	}
+	if nodeIP == "" {
+		return clusterNodeIP(byoHost)
+	}
+	for _, ip := range strings.Split(nodeIP, ",") {
+		if net.ParseIP(ip) == nil {
+			return "", errors.Errorf("invalid node IP %q", nodeIP)
+		}
+	}
+	return nodeIP, nil
+}
+
+// clusterNodeIP returns the addresses of the default network interfaces of the host in the IP families
+// of the cluster, primary family first, for the nodes of the IPv6-only and dual-stack clusters.
+// It returns an empty string when the cluster is IPv4-only.
+func clusterNodeIP(byoHost *infrastructurev1beta1.ByoHost) (string, error) {
+	addresses := []string{}
+	for _, family := range infrastructurev1beta1.ParseIPFamilies(byoHost.Annotations[infrastructurev1beta1.IPFamiliesAnnotation]) {
+		address := infrastructurev1beta1.DefaultAddress(byoHost.Status.Network, family)
+		if address == "" {
+			return "", errors.Errorf("host has no %s address on its default network interface, set the node-ip annotation", family)
+		}
+		addresses = append(addresses, address)
+	}
+	return strings.Join(addresses, ","), nil
+}
+
+// kubeVIPManifest is the kube-vip static pod serving the control plane endpoint as a virtual IP,
+// elected among the control plane hosts with the lease of the workload cluster
+var kubeVIPManifest = template.Must(template.New("kube-vip").Parse(`apiVersion: v1
+kind: Pod
+metadata:
+  name: kube-vip
+  namespace: kube-system
+spec:
+  containers:
+  - args:
+    - manager
+    env:
+    - name: cp_enable
+      value: "true"
+    - name: vip_arp
+      value: "true"
+    - name: vip_leaderelection
+      value: "true"
+    - name: vip_address
+      value: "{{ .Address }}"
+    - name: port
+      value: "{{ .Port }}"
+    - name: vip_interface
+      value: "{{ .Interface }}"
+    - name: vip_leaseduration
+      value: "15"
+    - name: vip_renewdeadline
+      value: "10"
+    - name: vip_retryperiod
+      value: "2"
+    image: {{ .Image }}
+    imagePullPolicy: IfNotPresent
+    name: kube-vip
+    securityContext:
+      capabilities:
+        add:
+        - NET_ADMIN
+        - NET_RAW
+    volumeMounts:
+    - mountPath: /etc/kubernetes/admin.conf
+      name: kubeconfig
+  hostAliases:
+  - hostnames:
+    - kubernetes
+    ip: 127.0.0.1
+  hostNetwork: true
+  volumes:
+  - hostPath:
+      path: /etc/kubernetes/admin.conf
+      type: FileOrCreate
+    name: kubeconfig
+`))
+
+// writeKubeVIPManifest writes the kube-vip static pod manifest on the control plane hosts of
+// the clusters which have the provider manage their control plane endpoint
+func (r *HostReconciler) writeKubeVIPManifest(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost) error {
+	image, ok := byoHost.GetAnnotations()[infrastructurev1beta1.KubeVIPImageAnnotation]
+	if !ok {
+		return nil
+	}
+	vipInterface := byoHost.Annotations[infrastructurev1beta1.KubeVIPInterfaceAnnotation]
+	if vip := net.ParseIP(byoHost.Annotations[infrastructurev1beta1.EndPointIPAnnotation]); vipInterface == "" && vip != nil && vip.To4() == nil {
+		// an IPv6 virtual IP is advertised on the interface of the IPv6 default route
+		for _, network := range byoHost.Status.Network {
+			if network.IsDefaultIPv6 {
+				vipInterface = network.NetworkInterfaceName
+			}
+		}
+	}
+	if vipInterface == "" {
+		vipInterface = registration.LocalHostRegistrar.ByoHostInfo.DefaultNetworkInterfaceName
+	}
+	port := byoHost.Annotations[infrastructurev1beta1.EndPointPortAnnotation]
+	if port == "" {
+		port = fmt.Sprint(infrastructurev1beta1.DefaultAPIEndpointPort)
+	}
+
+	var manifest strings.Builder
+	if err := kubeVIPManifest.Execute(&manifest, map[string]string{
+		"Address":   byoHost.Annotations[infrastructurev1beta1.EndPointIPAnnotation],
+		"Port":      port,
+		"Interface": vipInterface,
+		"Image":     image,
+	}); err != nil {
+		return err
+	}
+
+	logger := ctrl.LoggerFrom(ctx)
+	logger.Info("Writing the kube-vip manifest", "image", image, "interface", vipInterface)
+	if err := r.FileWriter.MkdirIfNotExists(filepath.Dir(kubeVIPManifestFile)); err != nil {
+		return err
+	}
+	return r.FileWriter.WriteToFile(&cloudinit.Files{
+		Path:        kubeVIPManifestFile,
+		Content:     manifest.String(),
+		Permissions: "0600",
+	})
+}
+
+func (r *HostReconciler) installK8sComponents(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost) (err error) {
 	logger := ctrl.LoggerFrom(ctx)
 	logger.Info("Installing K8s")
 
@@ -272,12 +1091,41 @@ func (r *HostReconciler) installK8sComponents(ctx context.Context, byoHost *infr
 	k8sVersion := byoHost.GetAnnotations()[infrastructurev1beta1.K8sVersionAnnotation]
 	byohBundleTag := byoHost.GetAnnotations()[infrastructurev1beta1.BundleLookupTagAnnotation]
 
-	err := r.K8sInstaller.Install(bundleRegistry, k8sVersion, byohBundleTag)
+	ctx, span := tracing.Start(ctx, "InstallK8sComponents", attribute.String("k8sVersion", k8sVersion))
+	defer func() { tracing.End(span, err) }()
+
+	k8sInstaller, err := r.k8sInstaller(byoHost)
+	if err != nil {
+		return err
+	}
+	r.reportProgress(ctx, byoHost, infrastructurev1beta1.InstallationPhaseDownloading)
+	if reporter, ok := k8sInstaller.(IProgressReporter); ok {
+		reporter.SetProgressFunc(func(phase installer.InstallPhase) {
+			r.reportProgress(ctx, byoHost, installationPhases[phase])
+		})
+	}
+	if emitter, ok := k8sInstaller.(IEventEmitter); ok {
+		emitter.SetEventFunc(func(eventType, reason, message string) {
+			r.Recorder.Event(byoHost, eventType, reason, message)
+		})
+	}
+	if traceable, ok := k8sInstaller.(ITraceable); ok {
+		traceable.SetTraceContext(ctx)
+	}
+	auditInstaller(k8sInstaller, byoHost, installOperation)
+
+	r.Recorder.Eventf(byoHost, corev1.EventTypeNormal, "InstallK8sComponentsStarted", "Installing k8s %s components", k8sVersion)
+	if err = faultinject.Error(faultinject.FailBundleDownload, byoHost.Annotations); err != nil {
+		r.Recorder.Eventf(byoHost, corev1.EventTypeWarning, "BundleDownloadFailed", "Downloading bundle failed: %v", err)
+		return fmt.Errorf("%s: %w", installer.ErrBundleDownload, err)
+	}
+	err = k8sInstaller.Install(bundleRegistry, k8sVersion, byohBundleTag)
 	if err != nil {
 		return err
 	}
 
 	r.Recorder.Event(byoHost, corev1.EventTypeNormal, "k8sComponentInstalled", "Successfully Installed K8s components")
+	conditions.MarkTrue(byoHost, infrastructurev1beta1.K8sBundleDownloadSucceeded)
 	conditions.MarkTrue(byoHost, infrastructurev1beta1.K8sComponentsInstallationSucceeded)
 	return nil
 }
@@ -286,18 +1134,88 @@ func (r *HostReconciler) uninstallk8sComponents(ctx context.Context, byoHost *in
 	bundleRegistry := byoHost.GetAnnotations()[infrastructurev1beta1.BundleLookupBaseRegistryAnnotation]
 	k8sVersion := byoHost.GetAnnotations()[infrastructurev1beta1.K8sVersionAnnotation]
 	byohBundleTag := byoHost.GetAnnotations()[infrastructurev1beta1.BundleLookupTagAnnotation]
-	err := r.K8sInstaller.Uninstall(bundleRegistry, k8sVersion, byohBundleTag)
+	k8sInstaller, err := r.k8sInstaller(byoHost)
+	if err == nil {
+		auditInstaller(k8sInstaller, byoHost, uninstallOperation)
+		err = k8sInstaller.Uninstall(bundleRegistry, k8sVersion, byohBundleTag)
+	}
 	if err != nil {
+		r.Recorder.Eventf(byoHost, corev1.EventTypeWarning, "UninstallK8sComponentFailed", "k8s component uninstallation failed: %v", err)
+		agentmetrics.RecordError("UninstallK8sComponentFailed")
 		return err
 	}
+	r.Recorder.Event(byoHost, corev1.EventTypeNormal, "K8sComponentsUninstalled", "Successfully Uninstalled K8s components")
 	return nil
 }
 
+// auditInstaller summarizes the commands run by the installer for the operation in the
+// status of the ByoHost, replacing the summary of the previous operation
+func auditInstaller(k8sInstaller IK8sInstaller, byoHost *infrastructurev1beta1.ByoHost, operation string) {
+	auditor, ok := k8sInstaller.(ICommandAuditor)
+	if !ok {
+		return
+	}
+	summary := &infrastructurev1beta1.InstallerAuditSummary{Operation: operation, StartTime: metav1.Now()}
+	byoHost.Status.InstallerAudit = summary
+	auditor.SetAuditFunc(func(record installer.AuditRecord) {
+		summary.Commands++
+		if record.ExitCode != 0 {
+			summary.FailedCommands++
+			summary.LastFailedCommand = record.Command
+			if len(summary.LastFailedCommand) > maxAuditedCommandLength {
+				summary.LastFailedCommand = summary.LastFailedCommand[:maxAuditedCommandLength] + "..."
+			}
+			summary.LastFailedExitCode = int32(record.ExitCode)
+		}
+	})
+}
+
+// k8sInstaller returns the installer of the Kubernetes distribution of the host
+func (r *HostReconciler) k8sInstaller(byoHost *infrastructurev1beta1.ByoHost) (IK8sInstaller, error) {
+	if !isRKE2(byoHost) {
+		return r.K8sInstaller, nil
+	}
+	if r.RKE2Installer == nil {
+		return nil, errors.New("the rke2 installer is not available")
+	}
+	return r.RKE2Installer, nil
+}
+
+// isRKE2 returns true if the host is attached to a machine of the rke2 distribution
+func isRKE2(byoHost *infrastructurev1beta1.ByoHost) bool {
+	return byoHost.GetAnnotations()[infrastructurev1beta1.K8sDistributionAnnotation] == string(infrastructurev1beta1.KubernetesDistributionRKE2)
+}
+
+// upgradeAgent replaces the running agent with the desired version.
+// On success the agent process is re-executed and this function does not return.
+func (r *HostReconciler) upgradeAgent(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost, desiredVersion string) error {
+	logger := ctrl.LoggerFrom(ctx)
+	logger.Info("Upgrading host agent", "from", r.AgentVersion, "to", desiredVersion)
+
+	agentBinaryRepo := byoHost.GetAnnotations()[infrastructurev1beta1.AgentBinaryRepoAnnotation]
+	err := r.AgentUpgrader.Upgrade(agentBinaryRepo, desiredVersion)
+	if err != nil {
+		logger.Error(err, "error upgrading host agent")
+		r.Recorder.Eventf(byoHost, corev1.EventTypeWarning, "AgentUpgradeFailed", "host agent upgrade to %s failed", desiredVersion)
+		agentmetrics.RecordError("AgentUpgradeFailed")
+		conditions.MarkFalse(byoHost, infrastructurev1beta1.AgentUpgradeSucceeded, infrastructurev1beta1.AgentUpgradeFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return err
+	}
+	return nil
+}
+
+// bootstrapSucceeded tells if the bootstrap of the k8s node wrote its sentinel file, which is
+// removed when the host is cleaned up
+func (r *HostReconciler) bootstrapSucceeded() bool {
+	_, err := os.Stat(bootstrapSentinelFile)
+	return err == nil
+}
+
 func (r *HostReconciler) removeSentinelFile(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost) error {
 	logger := ctrl.LoggerFrom(ctx)
 	logger.Info("Removing the bootstrap sentinel file")
 	if _, err := os.Stat(bootstrapSentinelFile); !os.IsNotExist(err) {
-		err := os.Remove(bootstrapSentinelFile)
+		err := r.Escalator.RemoveAll(bootstrapSentinelFile)
 		if err != nil {
 			return errors.Wrapf(err, "failed to delete sentinel file %s", bootstrapSentinelFile)
 		}
@@ -335,9 +1253,27 @@ func (r *HostReconciler) removeAnnotations(ctx context.Context, byoHost *infrast
 	// Remove Byomachine-name label
 	delete(byoHost.Labels, infrastructurev1beta1.AttachedByoMachineLabel)
 
+	// Remove Byomachinepool-name label
+	delete(byoHost.Labels, infrastructurev1beta1.AttachedByoMachinePoolLabel)
+
 	// Remove the EndPointIP annotation
 	delete(byoHost.Annotations, infrastructurev1beta1.EndPointIPAnnotation)
 
+	// Remove the kube-vip annotations
+	delete(byoHost.Annotations, infrastructurev1beta1.EndPointPortAnnotation)
+	delete(byoHost.Annotations, infrastructurev1beta1.KubeVIPImageAnnotation)
+	delete(byoHost.Annotations, infrastructurev1beta1.KubeVIPInterfaceAnnotation)
+
+	// Remove the kubelet flags and configuration of the machine
+	delete(byoHost.Annotations, infrastructurev1beta1.KubeletExtraArgsAnnotation)
+	delete(byoHost.Annotations, infrastructurev1beta1.KubeletConfigurationAnnotation)
+
+	// Remove the IP families of the cluster
+	delete(byoHost.Annotations, infrastructurev1beta1.IPFamiliesAnnotation)
+
+	// Remove the Kubernetes distribution of the machine
+	delete(byoHost.Annotations, infrastructurev1beta1.K8sDistributionAnnotation)
+
 	// Remove the cleanup annotation
 	delete(byoHost.Annotations, infrastructurev1beta1.HostCleanupAnnotation)
 
@@ -349,4 +1285,7 @@ func (r *HostReconciler) removeAnnotations(ctx context.Context, byoHost *infrast
 
 	// Remove the bundle tag annotation
 	delete(byoHost.Annotations, infrastructurev1beta1.BundleLookupTagAnnotation)
+
+	// Remove the trace context of the machine
+	delete(byoHost.Annotations, infrastructurev1beta1.TraceParentAnnotation)
 }
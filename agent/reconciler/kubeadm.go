@@ -0,0 +1,143 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package reconciler
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// yamlDocumentSeparator separates the documents of the kubeadm configuration, e.g. the ClusterConfiguration
+// and the InitConfiguration of the first control plane node
+var yamlDocumentSeparator = regexp.MustCompile(`(?m)^---[ \t]*\n`)
+
+// pinKubeadmNodeIP patches the kubeadm configurations written by the cloud-config bootstrap data for kubeadm
+// to register the node with the pinned IP addresses: the node-ip of nodeRegistration.kubeletExtraArgs, written by
+// kubeadm to the kubeadm-flags.env of the kubelet, and the advertise address of the API server of the control plane
+// nodes, when not set. The bootstrap data is returned unchanged when it writes no kubeadm configuration.
+func pinKubeadmNodeIP(bootstrapScript, nodeIP string) (string, error) {
+	cloudConfig := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(bootstrapScript), &cloudConfig); err != nil {
+		return "", errors.Wrap(err, "error parsing the bootstrap data")
+	}
+
+	files, _ := cloudConfig["write_files"].([]interface{})
+	patched := false
+	for _, f := range files {
+		file, ok := f.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		// the encoded files are not kubeadm configurations written by the bootstrap provider
+		if encoding, _ := file["encoding"].(string); encoding != "" {
+			continue
+		}
+		content, _ := file["content"].(string)
+		patchedContent, ok, err := pinKubeadmConfigNodeIP(content, nodeIP)
+		if err != nil {
+			return "", errors.Wrapf(err, "error patching the kubeadm configuration %v", file["path"])
+		}
+		if ok {
+			file["content"] = patchedContent
+			patched = true
+		}
+	}
+	if !patched {
+		return bootstrapScript, nil
+	}
+
+	out, err := yaml.Marshal(cloudConfig)
+	if err != nil {
+		return "", errors.Wrap(err, "error writing the bootstrap data")
+	}
+	return string(out), nil
+}
+
+// pinKubeadmConfigNodeIP patches the InitConfiguration or JoinConfiguration of the content of a file with
+// the node IP, and returns whether the content has any
+func pinKubeadmConfigNodeIP(content, nodeIP string) (string, bool, error) {
+	docs := yamlDocumentSeparator.Split(content, -1)
+	patched := false
+	for i, doc := range docs {
+		config := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(doc), &config); err != nil {
+			// not a kubeadm configuration, e.g. a script
+			continue
+		}
+		apiVersion, _ := config["apiVersion"].(string)
+		if !strings.HasPrefix(apiVersion, "kubeadm.k8s.io/") {
+			continue
+		}
+
+		var advertiseAddressPath []string
+		switch config["kind"] {
+		case "InitConfiguration":
+			advertiseAddressPath = []string{"localAPIEndpoint", "advertiseAddress"}
+		case "JoinConfiguration":
+			if controlPlane, found, _ := unstructured.NestedFieldNoCopy(config, "controlPlane"); found && controlPlane != nil {
+				advertiseAddressPath = []string{"controlPlane", "localAPIEndpoint", "advertiseAddress"}
+			}
+		default:
+			continue
+		}
+
+		if err := unstructured.SetNestedField(config, nodeIP, "nodeRegistration", "kubeletExtraArgs", "node-ip"); err != nil {
+			return "", false, err
+		}
+		if advertiseAddressPath != nil {
+			// the API server advertises a single address, of the primary IP family
+			if address, _, _ := unstructured.NestedString(config, advertiseAddressPath...); address == "" {
+				if err := unstructured.SetNestedField(config, strings.Split(nodeIP, ",")[0], advertiseAddressPath...); err != nil {
+					return "", false, err
+				}
+			}
+		}
+
+		out, err := yaml.Marshal(config)
+		if err != nil {
+			return "", false, err
+		}
+		docs[i] = string(out)
+		patched = true
+	}
+	return strings.Join(docs, "---\n"), patched, nil
+}
+
+// mergeKubeletConfiguration merges the fields of the KubeletConfiguration patch into the kubelet configuration,
+// the keys of the map fields, e.g. the eviction thresholds, being merged into the ones of the configuration
+func mergeKubeletConfiguration(config, patch []byte) (string, error) {
+	merged := map[string]interface{}{}
+	if err := yaml.Unmarshal(config, &merged); err != nil {
+		return "", errors.Wrap(err, "error parsing the kubelet configuration")
+	}
+	fields := map[string]interface{}{}
+	if err := yaml.Unmarshal(patch, &fields); err != nil {
+		return "", errors.Wrap(err, "error parsing the kubelet configuration of the machine")
+	}
+
+	for name, value := range fields {
+		if name == "apiVersion" || name == "kind" {
+			continue
+		}
+		values, ok := value.(map[string]interface{})
+		mergedValues, merge := merged[name].(map[string]interface{})
+		if !ok || !merge {
+			merged[name] = value
+			continue
+		}
+		for key, value := range values {
+			mergedValues[key] = value
+		}
+	}
+
+	out, err := yaml.Marshal(merged)
+	if err != nil {
+		return "", errors.Wrap(err, "error writing the kubelet configuration")
+	}
+	return string(out), nil
+}
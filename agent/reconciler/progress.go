@@ -0,0 +1,61 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package reconciler
+
+import (
+	"context"
+
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/installer"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/registration"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// installationPhases maps the phases reported by the installers to the phases of the installation of the host
+var installationPhases = map[installer.InstallPhase]infrastructurev1beta1.InstallationPhase{
+	installer.InstallPhaseDownload: infrastructurev1beta1.InstallationPhaseDownloading,
+	installer.InstallPhaseInstall:  infrastructurev1beta1.InstallationPhaseInstalling,
+}
+
+// reportProgress records the phase the installation of the host enters in its InstallationProgress, along
+// with the conditions of the phase. The phases taking minutes, the progress is published right away rather
+// than once the reconcile ends, while the conditions are published with the rest of the status.
+func (r *HostReconciler) reportProgress(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost, phase infrastructurev1beta1.InstallationPhase) {
+	switch phase {
+	case infrastructurev1beta1.InstallationPhaseDownloading:
+		conditions.MarkFalse(byoHost, infrastructurev1beta1.K8sBundleDownloadSucceeded, infrastructurev1beta1.BundleDownloadingReason, clusterv1.ConditionSeverityInfo, "")
+		conditions.MarkFalse(byoHost, infrastructurev1beta1.K8sComponentsInstallationSucceeded, infrastructurev1beta1.K8sComponentsInstallingReason, clusterv1.ConditionSeverityInfo, "")
+	case infrastructurev1beta1.InstallationPhaseInstalling:
+		conditions.MarkTrue(byoHost, infrastructurev1beta1.K8sBundleDownloadSucceeded)
+	}
+	if byoHost.Status.InstallationProgress != nil && byoHost.Status.InstallationProgress.Phase == phase {
+		return
+	}
+
+	before := byoHost.DeepCopy()
+	byoHost.Status.InstallationProgress = &infrastructurev1beta1.InstallationProgress{
+		Phase:              phase,
+		Percentage:         phase.Percentage(),
+		LastTransitionTime: metav1.Now(),
+	}
+	// the completion is published with the conditions of the bootstrap
+	if phase == infrastructurev1beta1.InstallationPhaseCompleted {
+		return
+	}
+	// only the progress is published, the rest of the status applied being the one the reconcile started from
+	progress := before.DeepCopy()
+	progress.Status.InstallationProgress = byoHost.Status.InstallationProgress
+	if err := registration.ApplyStatus(ctx, r.Client, progress, registration.AgentStatus(progress), ""); err != nil {
+		ctrl.LoggerFrom(ctx).Info("Could not report the installation progress", "phase", phase, "error", err.Error())
+	}
+}
+
+// resetProgress clears the progress of the installation of the host, e.g. once the host is cleaned up
+func resetProgress(byoHost *infrastructurev1beta1.ByoHost) {
+	byoHost.Status.InstallationProgress = nil
+	conditions.Delete(byoHost, infrastructurev1beta1.K8sBundleDownloadSucceeded)
+}
@@ -7,22 +7,33 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/cloudinit/cloudinitfakes"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/installer"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/preflight"
 	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/reconciler"
 	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/reconciler/reconcilerfakes"
 	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
 	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/test/builder"
 	eventutils "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/test/utils/events"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
 	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
 var _ = Describe("Byohost Agent Tests", func() {
@@ -96,6 +107,54 @@ var _ = Describe("Byohost Agent Tests", func() {
 			}))
 		})
 
+		It("should not reconcile the ByoHost while it is paused", func() {
+			byoHost.Annotations = map[string]string{clusterv1.PausedAnnotation: ""}
+			Expect(patchHelper.Patch(ctx, byoHost)).NotTo(HaveOccurred())
+
+			result, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+				NamespacedName: byoHostLookupKey,
+			})
+			Expect(result).To(Equal(controllerruntime.Result{}))
+			Expect(reconcilerErr).ToNot(HaveOccurred())
+
+			updatedByoHost := &infrastructurev1beta1.ByoHost{}
+			Expect(k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)).To(Succeed())
+			Expect(conditions.Get(updatedByoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded)).To(BeNil())
+		})
+
+		It("should sync the labels of the agent to the ByoHost", func() {
+			hostReconciler.HostLabels = func() map[string]string { return map[string]string{"site": "apac"} }
+			defer func() { hostReconciler.HostLabels = nil }()
+
+			_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+				NamespacedName: byoHostLookupKey,
+			})
+			Expect(reconcilerErr).ToNot(HaveOccurred())
+
+			updatedByoHost := &infrastructurev1beta1.ByoHost{}
+			Expect(k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)).To(Succeed())
+			Expect(updatedByoHost.Labels).To(HaveKeyWithValue("site", "apac"))
+			Expect(updatedByoHost.Annotations).To(HaveKeyWithValue(infrastructurev1beta1.AgentLabelsAnnotation, "site"))
+		})
+
+		It("should report the security posture of the agent on the ByoHost", func() {
+			hostReconciler.SecurityPosture = conditions.FalseCondition(infrastructurev1beta1.HostSecurityPosture,
+				infrastructurev1beta1.InsecurePermissionsReason, clusterv1.ConditionSeverityWarning, "/etc/byoh/config.yaml is owned by uid 1001")
+
+			_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+				NamespacedName: byoHostLookupKey,
+			})
+			Expect(reconcilerErr).ToNot(HaveOccurred())
+
+			updatedByoHost := &infrastructurev1beta1.ByoHost{}
+			Expect(k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)).To(Succeed())
+			condition := conditions.Get(updatedByoHost, infrastructurev1beta1.HostSecurityPosture)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(infrastructurev1beta1.InsecurePermissionsReason))
+			Expect(condition.Message).To(Equal("/etc/byoh/config.yaml is owned by uid 1001"))
+		})
+
 		Context("When MachineRef is set", func() {
 			BeforeEach(func() {
 				byoMachine = builder.ByoMachine(ns, "test-byomachine").Build()
@@ -232,6 +291,114 @@ runCmd:
 					}))
 				})
 
+				Context("When the installation requires a host reboot", func() {
+					BeforeEach(func() {
+						hostReconciler.K8sInstaller = fakeInstaller
+						fakeInstaller.InstallReturns(&installer.RebootRequiredError{Reason: "the kernel was upgraded"})
+					})
+
+					It("should wait for the approval of the reboot", func() {
+						result, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+							NamespacedName: byoHostLookupKey,
+						})
+						Expect(result).To(Equal(controllerruntime.Result{}))
+						Expect(reconcilerErr).ToNot(HaveOccurred())
+						Expect(fakeCommandRunner.RunCmdCallCount()).To(Equal(0))
+
+						updatedByoHost := &infrastructurev1beta1.ByoHost{}
+						err := k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(conditions.GetReason(updatedByoHost, infrastructurev1beta1.K8sComponentsInstallationSucceeded)).
+							To(Equal(infrastructurev1beta1.RebootRequiredReason))
+						Expect(updatedByoHost.Status.Backoff).To(BeEmpty())
+					})
+
+					It("should reboot the host once the reboot is approved", func() {
+						byoHost.Annotations[infrastructurev1beta1.RebootApprovedAnnotation] = ""
+						Expect(patchHelper.Patch(ctx, byoHost)).NotTo(HaveOccurred())
+
+						_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+							NamespacedName: byoHostLookupKey,
+						})
+						Expect(reconcilerErr).ToNot(HaveOccurred())
+						Expect(fakeCommandRunner.RunCmdCallCount()).To(Equal(1))
+						Expect(fakeCommandRunner.RunCmdArgsForCall(0)).To(Equal(reconciler.RebootCommand))
+
+						updatedByoHost := &infrastructurev1beta1.ByoHost{}
+						err := k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(updatedByoHost.Annotations).NotTo(HaveKey(infrastructurev1beta1.RebootApprovedAnnotation))
+						Expect(conditions.GetReason(updatedByoHost, infrastructurev1beta1.K8sComponentsInstallationSucceeded)).
+							To(Equal(infrastructurev1beta1.RebootingReason))
+					})
+				})
+
+				It("should back off before retrying a failed installation", func() {
+					hostReconciler.K8sInstaller = fakeInstaller
+					fakeInstaller.InstallReturns(errors.New("k8s components install failed"))
+
+					_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+						NamespacedName: byoHostLookupKey,
+					})
+					Expect(reconcilerErr).To(HaveOccurred())
+
+					updatedByoHost := &infrastructurev1beta1.ByoHost{}
+					Expect(k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)).To(Succeed())
+					Expect(updatedByoHost.Status.Backoff).To(HaveLen(1))
+					Expect(updatedByoHost.Status.Backoff[0].Class).To(Equal("Installation"))
+					Expect(updatedByoHost.Status.Backoff[0].Failures).To(Equal(int32(1)))
+					Expect(updatedByoHost.Status.Backoff[0].LastError).To(Equal("k8s components install failed"))
+					Expect(updatedByoHost.Status.Backoff[0].NextRetryTime).NotTo(BeNil())
+
+					result, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+						NamespacedName: byoHostLookupKey,
+					})
+					Expect(reconcilerErr).NotTo(HaveOccurred())
+					Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+					Expect(fakeInstaller.InstallCallCount()).To(Equal(1))
+				})
+
+				It("should stop retrying the installation after repeated failures until the retry annotation is set", func() {
+					hostReconciler.K8sInstaller = fakeInstaller
+					fakeInstaller.InstallReturns(errors.New("k8s components install failed"))
+					byoHost.Status.Backoff = []infrastructurev1beta1.ErrorBackoff{{Class: "Installation", Failures: 4}}
+					Expect(patchHelper.Patch(ctx, byoHost, patch.WithStatusObservedGeneration{})).NotTo(HaveOccurred())
+
+					_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+						NamespacedName: byoHostLookupKey,
+					})
+					Expect(reconcilerErr).To(HaveOccurred())
+
+					updatedByoHost := &infrastructurev1beta1.ByoHost{}
+					Expect(k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)).To(Succeed())
+					Expect(updatedByoHost.Status.Backoff[0].RetriesStopped).To(BeTrue())
+					Expect(updatedByoHost.Status.Backoff[0].NextRetryTime).To(BeNil())
+					Expect(conditions.GetReason(updatedByoHost, infrastructurev1beta1.K8sComponentsInstallationSucceeded)).
+						To(Equal(infrastructurev1beta1.K8sComponentsInstallationRetriesStoppedReason))
+
+					result, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+						NamespacedName: byoHostLookupKey,
+					})
+					Expect(reconcilerErr).NotTo(HaveOccurred())
+					Expect(result).To(Equal(controllerruntime.Result{}))
+					Expect(fakeInstaller.InstallCallCount()).To(Equal(1))
+
+					retryHelper, err := patch.NewHelper(updatedByoHost, k8sClient)
+					Expect(err).NotTo(HaveOccurred())
+					updatedByoHost.Annotations[infrastructurev1beta1.RetryAnnotation] = ""
+					Expect(retryHelper.Patch(ctx, updatedByoHost)).To(Succeed())
+					fakeInstaller.InstallReturns(nil)
+
+					_, reconcilerErr = hostReconciler.Reconcile(ctx, controllerruntime.Request{
+						NamespacedName: byoHostLookupKey,
+					})
+					Expect(reconcilerErr).NotTo(HaveOccurred())
+					Expect(fakeInstaller.InstallCallCount()).To(Equal(2))
+					Expect(k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)).To(Succeed())
+					Expect(updatedByoHost.Status.Backoff).To(BeEmpty())
+					Expect(updatedByoHost.Annotations).NotTo(HaveKey(infrastructurev1beta1.RetryAnnotation))
+				})
+
 				It("should set K8sNodeBootstrapSucceeded to false with Reason CloudInitExecutionFailedReason if the bootstrap execution fails", func() {
 					hostReconciler.K8sInstaller = fakeInstaller
 					fakeCommandRunner.RunCmdReturns(errors.New("I failed"))
@@ -258,13 +425,38 @@ runCmd:
 					// assert events
 					events := eventutils.CollectEvents(recorder.Events)
 					Expect(events).Should(ConsistOf([]string{
+						"Normal InstallK8sComponentsStarted Installing k8s 1.22 components",
 						"Normal k8sComponentInstalled Successfully Installed K8s components",
+						"Normal BootstrapK8sNodeStarted k8s Node Bootstrap started",
 						"Warning BootstrapK8sNodeFailed k8s Node Bootstrap failed",
 						// TODO: improve test to remove this event
 						"Warning ResetK8sNodeFailed k8s Node Reset failed",
 					}))
 				})
 
+				It("should set K8sNodeBootstrapSucceeded to false with Reason BootstrapTokenExpiredReason if kubeadm fails on an expired token", func() {
+					hostReconciler.K8sInstaller = fakeInstaller
+					fakeCommandRunner.RunCmdReturns(errors.New(`exit status 1: error execution phase preflight: couldn't validate the identity of the API Server: ` +
+						`could not find a JWS signature in the cluster-info ConfigMap for token ID "abcdef"`))
+
+					_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+						NamespacedName: byoHostLookupKey,
+					})
+					Expect(reconcilerErr).To(HaveOccurred())
+
+					updatedByoHost := &infrastructurev1beta1.ByoHost{}
+					Expect(k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)).To(Succeed())
+					k8sNodeBootstrapSucceeded := conditions.Get(updatedByoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded)
+					Expect(*k8sNodeBootstrapSucceeded).To(conditions.MatchCondition(clusterv1.Condition{
+						Type:     infrastructurev1beta1.K8sNodeBootstrapSucceeded,
+						Status:   corev1.ConditionFalse,
+						Reason:   infrastructurev1beta1.BootstrapTokenExpiredReason,
+						Severity: clusterv1.ConditionSeverityWarning,
+						Message:  "the bootstrap token of the bootstrap data expired",
+					}))
+					Expect(eventutils.CollectEvents(recorder.Events)).Should(ContainElement("Warning BootstrapTokenExpired the bootstrap token of the bootstrap data expired"))
+				})
+
 				It("should set K8sNodeBootstrapSucceeded to True if the boostrap execution succeeds", func() {
 					hostReconciler.K8sInstaller = fakeInstaller
 					result, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
@@ -289,11 +481,35 @@ runCmd:
 					// assert events
 					events := eventutils.CollectEvents(recorder.Events)
 					Expect(events).Should(ConsistOf([]string{
+						"Normal InstallK8sComponentsStarted Installing k8s 1.22 components",
 						"Normal k8sComponentInstalled Successfully Installed K8s components",
+						"Normal BootstrapK8sNodeStarted k8s Node Bootstrap started",
 						"Normal BootstrapK8sNodeSucceeded k8s Node Bootstraped",
 					}))
 				})
 
+				It("should trace the bootstrap of the host in the trace of its machine", func() {
+					spanRecorder := tracetest.NewSpanRecorder()
+					otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+					defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+					traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+					byoHost.Annotations[infrastructurev1beta1.TraceParentAnnotation] = "00-" + traceID + "-00f067aa0ba902b7-01"
+					Expect(patchHelper.Patch(ctx, byoHost)).NotTo(HaveOccurred())
+
+					hostReconciler.K8sInstaller = fakeInstaller
+					_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+						NamespacedName: byoHostLookupKey,
+					})
+					Expect(reconcilerErr).ToNot(HaveOccurred())
+
+					names := []string{}
+					for _, span := range spanRecorder.Ended() {
+						Expect(span.SpanContext().TraceID().String()).To(Equal(traceID))
+						names = append(names, span.Name())
+					}
+					Expect(names).To(ContainElements("HostAgent.Bootstrap", "FetchBootstrapSecret", "InstallK8sComponents", "BootstrapK8sNode", "echo"))
+				})
+
 				It("should skip k8s installation if skip-installation is set", func() {
 					hostReconciler.SkipK8sInstallation = true
 					result, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
@@ -335,6 +551,570 @@ runCmd:
 					Expect(fakeFileWriter.WriteToFileCallCount()).To(Equal(1))
 				})
 
+				It("should record the installer events on the ByoHost", func() {
+					emittingInstaller := &eventEmittingInstaller{FakeIK8sInstaller: fakeInstaller}
+					fakeInstaller.InstallStub = func(_, _, _ string) error {
+						emittingInstaller.eventFunc(corev1.EventTypeNormal, "BundleDownloadStarted", "Downloading bundle")
+						return nil
+					}
+					hostReconciler.K8sInstaller = emittingInstaller
+					_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+						NamespacedName: byoHostLookupKey,
+					})
+					Expect(reconcilerErr).ToNot(HaveOccurred())
+
+					// assert events
+					events := eventutils.CollectEvents(recorder.Events)
+					Expect(events).Should(ContainElement("Normal BundleDownloadStarted Downloading bundle"))
+				})
+
+				It("should summarize the commands run by the installer in the ByoHost status", func() {
+					auditingInstaller := &commandAuditingInstaller{FakeIK8sInstaller: fakeInstaller}
+					fakeInstaller.InstallStub = func(_, _, _ string) error {
+						auditingInstaller.auditFunc(installer.AuditRecord{Command: "swapoff -a", ExitCode: 0})
+						auditingInstaller.auditFunc(installer.AuditRecord{Command: "ufw disable", ExitCode: 127})
+						auditingInstaller.auditFunc(installer.AuditRecord{Command: "apt-get install -y kubelet", ExitCode: 0})
+						return nil
+					}
+					hostReconciler.K8sInstaller = auditingInstaller
+					_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+						NamespacedName: byoHostLookupKey,
+					})
+					Expect(reconcilerErr).ToNot(HaveOccurred())
+
+					updatedByoHost := &infrastructurev1beta1.ByoHost{}
+					err := k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(updatedByoHost.Status.InstallerAudit).ToNot(BeNil())
+					Expect(updatedByoHost.Status.InstallerAudit.Operation).To(Equal("Install"))
+					Expect(updatedByoHost.Status.InstallerAudit.Commands).To(Equal(int32(3)))
+					Expect(updatedByoHost.Status.InstallerAudit.FailedCommands).To(Equal(int32(1)))
+					Expect(updatedByoHost.Status.InstallerAudit.LastFailedCommand).To(Equal("ufw disable"))
+					Expect(updatedByoHost.Status.InstallerAudit.LastFailedExitCode).To(Equal(int32(127)))
+				})
+
+				It("should report the progress of the installation in the ByoHost status as it goes", func() {
+					reportingInstaller := &progressReportingInstaller{FakeIK8sInstaller: fakeInstaller}
+					var progress []infrastructurev1beta1.InstallationProgress
+					fakeInstaller.InstallStub = func(_, _, _ string) error {
+						for _, phase := range []installer.InstallPhase{installer.InstallPhaseDownload, installer.InstallPhaseInstall} {
+							reportingInstaller.progressFunc(phase)
+							reportedByoHost := &infrastructurev1beta1.ByoHost{}
+							Expect(k8sClient.Get(ctx, byoHostLookupKey, reportedByoHost)).To(Succeed())
+							progress = append(progress, *reportedByoHost.Status.InstallationProgress)
+						}
+						return nil
+					}
+					hostReconciler.K8sInstaller = reportingInstaller
+					_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+						NamespacedName: byoHostLookupKey,
+					})
+					Expect(reconcilerErr).ToNot(HaveOccurred())
+
+					Expect(progress).To(HaveLen(2))
+					Expect(progress[0].Phase).To(Equal(infrastructurev1beta1.InstallationPhaseDownloading))
+					Expect(progress[0].Percentage).To(Equal(int32(0)))
+					Expect(progress[1].Phase).To(Equal(infrastructurev1beta1.InstallationPhaseInstalling))
+					Expect(progress[1].Percentage).To(Equal(int32(40)))
+
+					updatedByoHost := &infrastructurev1beta1.ByoHost{}
+					err := k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(updatedByoHost.Status.InstallationProgress.Phase).To(Equal(infrastructurev1beta1.InstallationPhaseCompleted))
+					Expect(updatedByoHost.Status.InstallationProgress.Percentage).To(Equal(int32(100)))
+					Expect(conditions.IsTrue(updatedByoHost, infrastructurev1beta1.K8sBundleDownloadSucceeded)).To(BeTrue())
+					Expect(conditions.IsTrue(updatedByoHost, infrastructurev1beta1.K8sComponentsInstallationSucceeded)).To(BeTrue())
+				})
+
+				It("should report the failed download of the bundle", func() {
+					reportingInstaller := &progressReportingInstaller{FakeIK8sInstaller: fakeInstaller}
+					fakeInstaller.InstallStub = func(_, _, _ string) error {
+						reportingInstaller.progressFunc(installer.InstallPhaseDownload)
+						return installer.ErrBundleDownload
+					}
+					hostReconciler.K8sInstaller = reportingInstaller
+					_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+						NamespacedName: byoHostLookupKey,
+					})
+					Expect(reconcilerErr).To(HaveOccurred())
+
+					updatedByoHost := &infrastructurev1beta1.ByoHost{}
+					err := k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(updatedByoHost.Status.InstallationProgress.Phase).To(Equal(infrastructurev1beta1.InstallationPhaseDownloading))
+					Expect(conditions.GetReason(updatedByoHost, infrastructurev1beta1.K8sBundleDownloadSucceeded)).
+						To(Equal(infrastructurev1beta1.BundleDownloadFailedReason))
+				})
+
+				It("should pin the kubelet node IP set with the agent flag", func() {
+					hostReconciler.K8sInstaller = fakeInstaller
+					hostReconciler.NodeIP = "10.0.0.5"
+					_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+						NamespacedName: byoHostLookupKey,
+					})
+					Expect(reconcilerErr).ToNot(HaveOccurred())
+
+					Expect(fakeFileWriter.WriteToFileCallCount()).To(Equal(2))
+					kubeletArgs := fakeFileWriter.WriteToFileArgsForCall(0)
+					Expect(kubeletArgs.Path).To(Equal("/etc/default/kubelet"))
+					Expect(kubeletArgs.Content).To(Equal("KUBELET_EXTRA_ARGS=--node-ip=10.0.0.5\n"))
+				})
+
+				It("should prefer the node IP annotation over the agent flag", func() {
+					hostReconciler.K8sInstaller = fakeInstaller
+					hostReconciler.NodeIP = "10.0.0.5"
+					byoHost.Annotations[infrastructurev1beta1.NodeIPAnnotation] = "fd00::5"
+					Expect(patchHelper.Patch(ctx, byoHost)).NotTo(HaveOccurred())
+
+					_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+						NamespacedName: byoHostLookupKey,
+					})
+					Expect(reconcilerErr).ToNot(HaveOccurred())
+
+					Expect(fakeFileWriter.WriteToFileCallCount()).To(Equal(2))
+					Expect(fakeFileWriter.WriteToFileArgsForCall(0).Content).To(Equal("KUBELET_EXTRA_ARGS=--node-ip=fd00::5\n"))
+				})
+
+				It("should pin the addresses of the IP families of a dual-stack cluster", func() {
+					hostReconciler.K8sInstaller = fakeInstaller
+					byoHost.Annotations[infrastructurev1beta1.IPFamiliesAnnotation] = "IPv6,IPv4"
+					byoHost.Status.Network = []infrastructurev1beta1.NetworkStatus{
+						{NetworkInterfaceName: "eth0", IsDefault: true, IsDefaultIPv4: true, IPv4Addrs: []string{"10.0.0.5/24"}},
+						{NetworkInterfaceName: "eth1", IsDefaultIPv6: true, IPv6Addrs: []string{"fe80::1/64", "fd00::5/64"}},
+					}
+					Expect(patchHelper.Patch(ctx, byoHost)).NotTo(HaveOccurred())
+
+					_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+						NamespacedName: byoHostLookupKey,
+					})
+					Expect(reconcilerErr).ToNot(HaveOccurred())
+
+					Expect(fakeFileWriter.WriteToFileArgsForCall(0).Content).To(Equal("KUBELET_EXTRA_ARGS=--node-ip=fd00::5,10.0.0.5\n"))
+				})
+
+				It("should fail to bootstrap the host without an address of the IP family of the cluster", func() {
+					hostReconciler.K8sInstaller = fakeInstaller
+					byoHost.Annotations[infrastructurev1beta1.IPFamiliesAnnotation] = "IPv6"
+					byoHost.Status.Network = []infrastructurev1beta1.NetworkStatus{
+						{NetworkInterfaceName: "eth0", IsDefault: true, IsDefaultIPv4: true, IPv4Addrs: []string{"10.0.0.5/24"}},
+					}
+					Expect(patchHelper.Patch(ctx, byoHost)).NotTo(HaveOccurred())
+
+					_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+						NamespacedName: byoHostLookupKey,
+					})
+					Expect(reconcilerErr).To(MatchError("host has no IPv6 address on its default network interface, set the node-ip annotation"))
+				})
+
+				It("should add the kubelet flags of the machine to the node IP", func() {
+					hostReconciler.K8sInstaller = fakeInstaller
+					hostReconciler.NodeIP = "10.0.0.5"
+					byoHost.Annotations[infrastructurev1beta1.KubeletExtraArgsAnnotation] = "--max-pods=200 --system-reserved=cpu=500m,memory=1Gi"
+					Expect(patchHelper.Patch(ctx, byoHost)).NotTo(HaveOccurred())
+
+					_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+						NamespacedName: byoHostLookupKey,
+					})
+					Expect(reconcilerErr).ToNot(HaveOccurred())
+
+					Expect(fakeFileWriter.WriteToFileArgsForCall(0).Content).To(Equal("KUBELET_EXTRA_ARGS=--node-ip=10.0.0.5 --max-pods=200 --system-reserved=cpu=500m,memory=1Gi\n"))
+				})
+
+				It("should register the node with the name of the byohost when the hostname is overridden", func() {
+					hostReconciler.K8sInstaller = fakeInstaller
+					hostReconciler.HostnameOverride = byoHost.Name
+					_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+						NamespacedName: byoHostLookupKey,
+					})
+					Expect(reconcilerErr).ToNot(HaveOccurred())
+
+					Expect(fakeFileWriter.WriteToFileArgsForCall(0).Content).To(Equal("KUBELET_EXTRA_ARGS=--hostname-override=" + byoHost.Name + "\n"))
+				})
+
+				It("should isolate the kubelet data of an agent instance of the machine", func() {
+					hostReconciler.K8sInstaller = fakeInstaller
+					hostReconciler.HostnameOverride = byoHost.Name
+					hostReconciler.KubeletRootDir = "/var/lib/kubelet-3"
+					_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+						NamespacedName: byoHostLookupKey,
+					})
+					Expect(reconcilerErr).ToNot(HaveOccurred())
+
+					Expect(fakeFileWriter.WriteToFileArgsForCall(0).Content).To(Equal(
+						"KUBELET_EXTRA_ARGS=--hostname-override=" + byoHost.Name + " --root-dir=/var/lib/kubelet-3\n"))
+				})
+
+				It("should write the kubelet flags to the environment file of the kubelet service of the OS", func() {
+					hostReconciler.K8sInstaller = &kubeletEnvironmentFilingInstaller{FakeIK8sInstaller: fakeInstaller, environmentFile: "/etc/sysconfig/kubelet"}
+					hostReconciler.NodeIP = "10.0.0.5"
+					_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+						NamespacedName: byoHostLookupKey,
+					})
+					Expect(reconcilerErr).ToNot(HaveOccurred())
+
+					kubeletArgs := fakeFileWriter.WriteToFileArgsForCall(0)
+					Expect(kubeletArgs.Path).To(Equal("/etc/sysconfig/kubelet"))
+					Expect(kubeletArgs.Content).To(Equal("KUBELET_EXTRA_ARGS=--node-ip=10.0.0.5\n"))
+				})
+
+				It("should pin the node IP in the kubeadm configuration of the bootstrap data", func() {
+					secret := &corev1.Secret{}
+					Expect(k8sClient.Get(ctx, types.NamespacedName{Name: bootstrapSecret.Name, Namespace: ns}, secret)).To(Succeed())
+					secret.Data["value"] = []byte(`write_files:
+- path: /run/kubeadm/kubeadm-join-config.yaml
+  owner: root:root
+  permissions: '0640'
+  content: |
+    apiVersion: kubeadm.k8s.io/v1beta2
+    kind: JoinConfiguration
+    controlPlane:
+      localAPIEndpoint: {}
+    nodeRegistration:
+      kubeletExtraArgs:
+        cgroup-driver: systemd
+      name: '{{ ds.meta_data.hostname }}'
+runCmd:
+- kubeadm join --config /run/kubeadm/kubeadm-join-config.yaml`)
+					Expect(k8sClient.Update(ctx, secret)).To(Succeed())
+
+					fakeTemplateParser.ParseTemplateStub = func(content string) (string, error) { return content, nil }
+					hostReconciler.K8sInstaller = fakeInstaller
+					hostReconciler.NodeIP = "fd00::5,10.0.0.5"
+					_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+						NamespacedName: byoHostLookupKey,
+					})
+					Expect(reconcilerErr).ToNot(HaveOccurred())
+
+					Expect(fakeFileWriter.WriteToFileCallCount()).To(Equal(2))
+					kubeadmConfig := fakeFileWriter.WriteToFileArgsForCall(1)
+					Expect(kubeadmConfig.Path).To(Equal("/run/kubeadm/kubeadm-join-config.yaml"))
+					Expect(kubeadmConfig.Content).To(ContainSubstring("node-ip: fd00::5,10.0.0.5"))
+					Expect(kubeadmConfig.Content).To(ContainSubstring("cgroup-driver: systemd"))
+					Expect(kubeadmConfig.Content).To(ContainSubstring("advertiseAddress: fd00::5"))
+					Expect(kubeadmConfig.Content).To(ContainSubstring(byoHost.Name))
+				})
+
+				It("should merge the kubelet configuration of the machine into the one written by kubeadm", func() {
+					dir, err := os.MkdirTemp("", "kubelet")
+					Expect(err).NotTo(HaveOccurred())
+					defer os.RemoveAll(dir)
+					hostReconciler.KubeletConfigFile = filepath.Join(dir, "config.yaml")
+					Expect(os.WriteFile(hostReconciler.KubeletConfigFile, []byte(`apiVersion: kubelet.config.k8s.io/v1beta1
+kind: KubeletConfiguration
+cgroupDriver: systemd
+evictionHard:
+  imagefs.available: 15%
+`), 0600)).To(Succeed())
+
+					hostReconciler.K8sInstaller = fakeInstaller
+					byoHost.Annotations[infrastructurev1beta1.KubeletConfigurationAnnotation] = `apiVersion: kubelet.config.k8s.io/v1beta1
+kind: KubeletConfiguration
+evictionHard:
+  memory.available: 500Mi
+maxPods: 200
+`
+					Expect(patchHelper.Patch(ctx, byoHost)).NotTo(HaveOccurred())
+
+					_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+						NamespacedName: byoHostLookupKey,
+					})
+					Expect(reconcilerErr).ToNot(HaveOccurred())
+
+					Expect(fakeFileWriter.WriteToFileCallCount()).To(Equal(2))
+					kubeletConfig := fakeFileWriter.WriteToFileArgsForCall(1)
+					Expect(kubeletConfig.Path).To(Equal(hostReconciler.KubeletConfigFile))
+					Expect(kubeletConfig.Content).To(Equal(`apiVersion: kubelet.config.k8s.io/v1beta1
+cgroupDriver: systemd
+evictionHard:
+  imagefs.available: 15%
+  memory.available: 500Mi
+kind: KubeletConfiguration
+maxPods: 200
+`))
+					Expect(fakeCommandRunner.RunCmdArgsForCall(fakeCommandRunner.RunCmdCallCount() - 1)).To(Equal("systemctl restart kubelet"))
+				})
+
+				It("should write the kube-vip static pod manifest on control plane hosts", func() {
+					hostReconciler.K8sInstaller = fakeInstaller
+					byoHost.Annotations[infrastructurev1beta1.EndPointIPAnnotation] = "10.0.0.100"
+					byoHost.Annotations[infrastructurev1beta1.EndPointPortAnnotation] = "6443"
+					byoHost.Annotations[infrastructurev1beta1.KubeVIPImageAnnotation] = "ghcr.io/kube-vip/kube-vip:test"
+					byoHost.Annotations[infrastructurev1beta1.KubeVIPInterfaceAnnotation] = "eth1"
+					Expect(patchHelper.Patch(ctx, byoHost)).NotTo(HaveOccurred())
+
+					_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+						NamespacedName: byoHostLookupKey,
+					})
+					Expect(reconcilerErr).ToNot(HaveOccurred())
+
+					Expect(fakeFileWriter.WriteToFileCallCount()).To(Equal(2))
+					manifest := fakeFileWriter.WriteToFileArgsForCall(0)
+					Expect(manifest.Path).To(Equal("/etc/kubernetes/manifests/kube-vip.yaml"))
+					Expect(manifest.Content).To(ContainSubstring("image: ghcr.io/kube-vip/kube-vip:test"))
+					Expect(manifest.Content).To(ContainSubstring(`value: "10.0.0.100"`))
+					Expect(manifest.Content).To(ContainSubstring(`value: "eth1"`))
+				})
+
+				It("should not bootstrap the node if the node IP is invalid", func() {
+					hostReconciler.K8sInstaller = fakeInstaller
+					hostReconciler.NodeIP = "not-an-ip"
+					_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+						NamespacedName: byoHostLookupKey,
+					})
+					Expect(reconcilerErr).To(MatchError(`invalid node IP "not-an-ip"`))
+					Expect(fakeCommandRunner.RunCmdCallCount()).To(Equal(0))
+
+					// assert events
+					events := eventutils.CollectEvents(recorder.Events)
+					Expect(events).Should(ContainElement(`Warning ConfigureKubeletFailed configuring the kubelet failed: invalid node IP "not-an-ip"`))
+				})
+
+				Context("When the host was reimaged since its node was bootstrapped", func() {
+					BeforeEach(func() {
+						hostReconciler.K8sInstaller = fakeInstaller
+						hostReconciler.MachineID = "new-machine-id"
+						conditions.MarkTrue(byoHost, infrastructurev1beta1.K8sComponentsInstallationSucceeded)
+						conditions.MarkTrue(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded)
+						byoHost.Status.InstalledMachineID = "old-machine-id"
+						Expect(patchHelper.Patch(ctx, byoHost, patch.WithStatusObservedGeneration{})).NotTo(HaveOccurred())
+					})
+
+					It("should install the k8s components and bootstrap the node again when the machine-id changed", func() {
+						_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+							NamespacedName: byoHostLookupKey,
+						})
+						Expect(reconcilerErr).ToNot(HaveOccurred())
+						Expect(fakeInstaller.InstallCallCount()).To(Equal(1))
+
+						updatedByoHost := &infrastructurev1beta1.ByoHost{}
+						Expect(k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)).To(Succeed())
+						Expect(conditions.IsTrue(updatedByoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded)).To(BeTrue())
+						Expect(updatedByoHost.Status.InstalledMachineID).To(Equal("new-machine-id"))
+						Expect(eventutils.CollectEvents(recorder.Events)).To(ContainElement(
+							"Warning HostReimaged the machine-id of the host changed, installing the k8s components and bootstrapping the node again"))
+					})
+
+					It("should install the k8s components and bootstrap the node again when the kubelet kubeconfig is missing", func() {
+						hostReconciler.MachineID = "old-machine-id"
+						hostReconciler.CheckInstallArtifacts = true
+						_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+							NamespacedName: byoHostLookupKey,
+						})
+						Expect(reconcilerErr).ToNot(HaveOccurred())
+						Expect(fakeInstaller.InstallCallCount()).To(Equal(1))
+						Expect(eventutils.CollectEvents(recorder.Events)).To(ContainElement(
+							"Warning HostReimaged the kubelet kubeconfig /etc/kubernetes/kubelet.conf is missing, installing the k8s components and bootstrapping the node again"))
+					})
+
+					It("should trust the status of the host which was not reimaged", func() {
+						hostReconciler.MachineID = "old-machine-id"
+						_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+							NamespacedName: byoHostLookupKey,
+						})
+						Expect(reconcilerErr).ToNot(HaveOccurred())
+						Expect(fakeInstaller.InstallCallCount()).To(Equal(0))
+						Expect(fakeCommandRunner.RunCmdCallCount()).To(Equal(0))
+					})
+
+					It("should record the machine-id of the host bootstrapped before it was", func() {
+						byoHost.Status.InstalledMachineID = ""
+						Expect(patchHelper.Patch(ctx, byoHost, patch.WithStatusObservedGeneration{})).NotTo(HaveOccurred())
+
+						_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+							NamespacedName: byoHostLookupKey,
+						})
+						Expect(reconcilerErr).ToNot(HaveOccurred())
+						Expect(fakeInstaller.InstallCallCount()).To(Equal(0))
+
+						updatedByoHost := &infrastructurev1beta1.ByoHost{}
+						Expect(k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)).To(Succeed())
+						Expect(updatedByoHost.Status.InstalledMachineID).To(Equal("new-machine-id"))
+					})
+				})
+
+				Context("When the bootstrap secret has a format", func() {
+					setFormat := func(format string) {
+						secret := &corev1.Secret{}
+						Expect(k8sClient.Get(ctx, types.NamespacedName{Name: bootstrapSecret.Name, Namespace: ns}, secret)).To(Succeed())
+						secret.Data["format"] = []byte(format)
+						Expect(k8sClient.Update(ctx, secret)).To(Succeed())
+					}
+
+					BeforeEach(func() {
+						hostReconciler.K8sInstaller = fakeInstaller
+					})
+
+					It("should bootstrap the node with the executor registered for the format", func() {
+						fakeExecutor := &reconcilerfakes.FakeBootstrapExecutor{}
+						hostReconciler.BootstrapExecutors = map[string]reconciler.BootstrapExecutor{"k3s": fakeExecutor}
+						defer func() { hostReconciler.BootstrapExecutors = nil }()
+						setFormat("k3s")
+
+						_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+							NamespacedName: byoHostLookupKey,
+						})
+						Expect(reconcilerErr).ToNot(HaveOccurred())
+						Expect(fakeExecutor.ExecuteCallCount()).To(Equal(1))
+						Expect(fakeExecutor.ExecuteArgsForCall(0)).To(ContainSubstring("echo 'some run command'"))
+						Expect(fakeCommandRunner.RunCmdCallCount()).To(Equal(0))
+
+						updatedByoHost := &infrastructurev1beta1.ByoHost{}
+						Expect(k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)).To(Succeed())
+						Expect(conditions.IsTrue(updatedByoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded)).To(BeTrue())
+					})
+
+					It("should not install the k8s components if the format is not supported", func() {
+						setFormat("talos")
+
+						result, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+							NamespacedName: byoHostLookupKey,
+						})
+						Expect(reconcilerErr).ToNot(HaveOccurred())
+						Expect(result).To(Equal(controllerruntime.Result{}))
+						Expect(fakeInstaller.InstallCallCount()).To(Equal(0))
+
+						updatedByoHost := &infrastructurev1beta1.ByoHost{}
+						Expect(k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)).To(Succeed())
+						Expect(*conditions.Get(updatedByoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded)).To(conditions.MatchCondition(clusterv1.Condition{
+							Type:     infrastructurev1beta1.K8sNodeBootstrapSucceeded,
+							Status:   corev1.ConditionFalse,
+							Reason:   infrastructurev1beta1.BootstrapFormatUnsupportedReason,
+							Severity: clusterv1.ConditionSeverityError,
+							Message:  `bootstrap data format "talos" is not supported`,
+						}))
+
+						// assert events
+						events := eventutils.CollectEvents(recorder.Events)
+						Expect(events).Should(ConsistOf([]string{
+							`Warning BootstrapFormatUnsupported bootstrap data format "talos" is not supported`,
+						}))
+					})
+
+					It("should bootstrap the node with the ignition config", func() {
+						setFormat("ignition")
+						secret := &corev1.Secret{}
+						Expect(k8sClient.Get(ctx, types.NamespacedName{Name: bootstrapSecret.Name, Namespace: ns}, secret)).To(Succeed())
+						secret.Data["value"] = []byte(`{"ignition":{"version":"3.2.0"},"storage":{"files":[{"path":"/etc/kubeadm.yml","contents":{"source":"data:,kind%3A%20InitConfiguration"}}]}}`)
+						Expect(k8sClient.Update(ctx, secret)).To(Succeed())
+
+						_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+							NamespacedName: byoHostLookupKey,
+						})
+						Expect(reconcilerErr).ToNot(HaveOccurred())
+						Expect(fakeFileWriter.WriteToFileCallCount()).To(Equal(1))
+						Expect(fakeFileWriter.WriteToFileArgsForCall(0).Content).To(Equal("kind: InitConfiguration"))
+					})
+				})
+
+				Context("When the host is attached to a machine of the rke2 distribution", func() {
+					var fakeRKE2Installer *reconcilerfakes.FakeIK8sInstaller
+
+					BeforeEach(func() {
+						fakeRKE2Installer = &reconcilerfakes.FakeIK8sInstaller{}
+						hostReconciler.K8sInstaller = fakeInstaller
+						hostReconciler.RKE2Installer = fakeRKE2Installer
+						byoHost.Annotations[infrastructurev1beta1.K8sDistributionAnnotation] = string(infrastructurev1beta1.KubernetesDistributionRKE2)
+						Expect(patchHelper.Patch(ctx, byoHost, patch.WithStatusObservedGeneration{})).NotTo(HaveOccurred())
+					})
+
+					AfterEach(func() {
+						hostReconciler.RKE2Installer = nil
+					})
+
+					It("should install the rke2 bundle and start the rke2 service", func() {
+						_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+							NamespacedName: byoHostLookupKey,
+						})
+						Expect(reconcilerErr).ToNot(HaveOccurred())
+						Expect(fakeInstaller.InstallCallCount()).To(Equal(0))
+						Expect(fakeRKE2Installer.InstallCallCount()).To(Equal(1))
+
+						// the runCmd of the bootstrap data, then the start of the rke2 server
+						Expect(fakeCommandRunner.RunCmdCallCount()).To(Equal(2))
+						Expect(fakeCommandRunner.RunCmdArgsForCall(1)).To(Equal("systemctl enable --now rke2-server.service"))
+					})
+
+					It("should fail if the rke2 installer is not available", func() {
+						hostReconciler.RKE2Installer = nil
+
+						_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+							NamespacedName: byoHostLookupKey,
+						})
+						Expect(reconcilerErr).To(MatchError("the rke2 installer is not available"))
+						Expect(fakeInstaller.InstallCallCount()).To(Equal(0))
+					})
+				})
+
+				Context("When the preflight checks are enabled", func() {
+					var fakePreflightChecker *reconcilerfakes.FakeIPreflightChecker
+
+					BeforeEach(func() {
+						fakePreflightChecker = &reconcilerfakes.FakeIPreflightChecker{}
+						hostReconciler.PreflightChecker = fakePreflightChecker
+						hostReconciler.K8sInstaller = fakeInstaller
+					})
+
+					It("should not install the k8s components if the host fails the preflight checks", func() {
+						fakePreflightChecker.CheckReturns([]preflight.Failure{
+							{Reason: infrastructurev1beta1.SwapEnabledReason, Message: "swap is enabled"},
+							{Reason: infrastructurev1beta1.PortsInUseReason, Message: "ports 10250 are in use"},
+						}, nil)
+
+						result, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+							NamespacedName: byoHostLookupKey,
+						})
+						Expect(reconcilerErr).ToNot(HaveOccurred())
+						Expect(result.RequeueAfter).NotTo(BeZero())
+						Expect(fakeInstaller.InstallCallCount()).To(Equal(0))
+						Expect(fakeCommandRunner.RunCmdCallCount()).To(Equal(0))
+
+						updatedByoHost := &infrastructurev1beta1.ByoHost{}
+						Expect(k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)).To(Succeed())
+						Expect(*conditions.Get(updatedByoHost, infrastructurev1beta1.HostPreflightSucceeded)).To(conditions.MatchCondition(clusterv1.Condition{
+							Type:     infrastructurev1beta1.HostPreflightSucceeded,
+							Status:   corev1.ConditionFalse,
+							Reason:   infrastructurev1beta1.SwapEnabledReason,
+							Severity: clusterv1.ConditionSeverityWarning,
+							Message:  "swap is enabled; ports 10250 are in use",
+						}))
+
+						// assert events
+						events := eventutils.CollectEvents(recorder.Events)
+						Expect(events).Should(ContainElement("Warning HostPreflightFailed host preflight checks failed: swap is enabled; ports 10250 are in use"))
+					})
+
+					It("should install the k8s components once the host passes the preflight checks", func() {
+						result, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+							NamespacedName: byoHostLookupKey,
+						})
+						Expect(reconcilerErr).ToNot(HaveOccurred())
+						Expect(result).To(Equal(controllerruntime.Result{}))
+						Expect(fakeInstaller.InstallCallCount()).To(Equal(1))
+
+						updatedByoHost := &infrastructurev1beta1.ByoHost{}
+						Expect(k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)).To(Succeed())
+						Expect(conditions.IsTrue(updatedByoHost, infrastructurev1beta1.HostPreflightSucceeded)).To(BeTrue())
+					})
+
+					It("should report the errors of the preflight checks", func() {
+						fakePreflightChecker.CheckReturns(nil, errors.New("MemTotal not found in /proc/meminfo"))
+
+						_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+							NamespacedName: byoHostLookupKey,
+						})
+						Expect(reconcilerErr).To(MatchError("MemTotal not found in /proc/meminfo"))
+						Expect(fakeInstaller.InstallCallCount()).To(Equal(0))
+
+						updatedByoHost := &infrastructurev1beta1.ByoHost{}
+						Expect(k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)).To(Succeed())
+						Expect(conditions.GetReason(updatedByoHost, infrastructurev1beta1.HostPreflightSucceeded)).To(Equal(infrastructurev1beta1.PreflightCheckErrorReason))
+					})
+
+					AfterEach(func() {
+						hostReconciler.PreflightChecker = nil
+					})
+				})
+
 				AfterEach(func() {
 					Expect(k8sClient.Delete(ctx, bootstrapSecret)).NotTo(HaveOccurred())
 					hostReconciler.SkipK8sInstallation = false
@@ -422,6 +1202,8 @@ runCmd:
 				events := eventutils.CollectEvents(recorder.Events)
 				Expect(events).Should(ConsistOf([]string{
 					"Normal ResetK8sNodeSucceeded k8s Node Reset completed",
+					"Normal K8sComponentsUninstalled Successfully Uninstalled K8s components",
+					"Normal HostCleanupSucceeded host cleanup completed",
 				}))
 			})
 
@@ -494,9 +1276,307 @@ runCmd:
 			})
 		})
 
+		Context("When an agent upgrade is requested", func() {
+			var fakeUpgrader *reconcilerfakes.FakeIAgentUpgrader
+
+			BeforeEach(func() {
+				fakeUpgrader = &reconcilerfakes.FakeIAgentUpgrader{}
+				hostReconciler.AgentUpgrader = fakeUpgrader
+				hostReconciler.AgentVersion = "v0.1.0"
+				byoHost.Annotations = map[string]string{
+					infrastructurev1beta1.DesiredAgentVersionAnnotation: "v0.2.0",
+					infrastructurev1beta1.AgentBinaryRepoAnnotation:     "projects.blah.com",
+				}
+				Expect(patchHelper.Patch(ctx, byoHost)).NotTo(HaveOccurred())
+			})
+
+			It("should upgrade the agent to the desired version", func() {
+				result, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+					NamespacedName: byoHostLookupKey,
+				})
+				Expect(result).To(Equal(controllerruntime.Result{}))
+				Expect(reconcilerErr).ToNot(HaveOccurred())
+
+				Expect(fakeUpgrader.UpgradeCallCount()).To(Equal(1))
+				repo, version := fakeUpgrader.UpgradeArgsForCall(0)
+				Expect(repo).To(Equal("projects.blah.com"))
+				Expect(version).To(Equal("v0.2.0"))
+			})
+
+			It("should set the Reason to AgentUpgradeFailedReason if the upgrade fails", func() {
+				fakeUpgrader.UpgradeReturns(errors.New("download failed"))
+				result, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+					NamespacedName: byoHostLookupKey,
+				})
+				Expect(result).To(Equal(controllerruntime.Result{}))
+				Expect(reconcilerErr).To(MatchError("download failed"))
+
+				updatedByoHost := &infrastructurev1beta1.ByoHost{}
+				err := k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)
+				Expect(err).ToNot(HaveOccurred())
+				agentUpgradeSucceeded := conditions.Get(updatedByoHost, infrastructurev1beta1.AgentUpgradeSucceeded)
+				Expect(*agentUpgradeSucceeded).To(conditions.MatchCondition(clusterv1.Condition{
+					Type:     infrastructurev1beta1.AgentUpgradeSucceeded,
+					Status:   corev1.ConditionFalse,
+					Reason:   infrastructurev1beta1.AgentUpgradeFailedReason,
+					Severity: clusterv1.ConditionSeverityWarning,
+					Message:  "download failed",
+				}))
+
+				// assert events
+				events := eventutils.CollectEvents(recorder.Events)
+				Expect(events).Should(ConsistOf([]string{
+					"Warning AgentUpgradeFailed host agent upgrade to v0.2.0 failed",
+				}))
+			})
+
+			It("should mark AgentUpgradeSucceeded when already running the desired version", func() {
+				hostReconciler.AgentVersion = "v0.2.0"
+				result, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+					NamespacedName: byoHostLookupKey,
+				})
+				Expect(result).To(Equal(controllerruntime.Result{}))
+				Expect(reconcilerErr).ToNot(HaveOccurred())
+				Expect(fakeUpgrader.UpgradeCallCount()).To(Equal(0))
+
+				updatedByoHost := &infrastructurev1beta1.ByoHost{}
+				err := k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(conditions.IsTrue(updatedByoHost, infrastructurev1beta1.AgentUpgradeSucceeded)).To(BeTrue())
+			})
+
+			It("should defer the upgrade until the maintenance window opens", func() {
+				byoHost.Annotations[infrastructurev1beta1.MaintenanceWindowAnnotation] = "0 0 1 1 * 1m"
+				Expect(patchHelper.Patch(ctx, byoHost)).NotTo(HaveOccurred())
+
+				result, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+					NamespacedName: byoHostLookupKey,
+				})
+				Expect(reconcilerErr).ToNot(HaveOccurred())
+				Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+				Expect(fakeUpgrader.UpgradeCallCount()).To(Equal(0))
+
+				updatedByoHost := &infrastructurev1beta1.ByoHost{}
+				err := k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)
+				Expect(err).ToNot(HaveOccurred())
+				maintenanceWindowOpen := conditions.Get(updatedByoHost, infrastructurev1beta1.MaintenanceWindowOpen)
+				Expect(maintenanceWindowOpen).NotTo(BeNil())
+				Expect(maintenanceWindowOpen.Status).To(Equal(corev1.ConditionFalse))
+				Expect(maintenanceWindowOpen.Reason).To(Equal(infrastructurev1beta1.WaitingForMaintenanceWindowReason))
+			})
+
+			It("should not mark AgentUpgradeSucceeded while the upgrade is deferred", func() {
+				byoHost.Annotations[infrastructurev1beta1.MaintenanceWindowAnnotation] = "0 0 1 1 * 1m"
+				Expect(patchHelper.Patch(ctx, byoHost)).NotTo(HaveOccurred())
+
+				_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+					NamespacedName: byoHostLookupKey,
+				})
+				Expect(reconcilerErr).ToNot(HaveOccurred())
+				Expect(fakeUpgrader.UpgradeCallCount()).To(Equal(0))
+
+				updatedByoHost := &infrastructurev1beta1.ByoHost{}
+				err := k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)
+				Expect(err).ToNot(HaveOccurred())
+				agentUpgradeSucceeded := conditions.Get(updatedByoHost, infrastructurev1beta1.AgentUpgradeSucceeded)
+				Expect(*agentUpgradeSucceeded).To(conditions.MatchCondition(clusterv1.Condition{
+					Type:     infrastructurev1beta1.AgentUpgradeSucceeded,
+					Status:   corev1.ConditionFalse,
+					Reason:   infrastructurev1beta1.WaitingForMaintenanceWindowReason,
+					Severity: clusterv1.ConditionSeverityInfo,
+					Message:  "host agent upgrade to v0.2.0 is deferred until the maintenance window opens",
+				}))
+			})
+
+			It("should upgrade the agent while the maintenance window is open", func() {
+				byoHost.Annotations[infrastructurev1beta1.MaintenanceWindowAnnotation] = "* * * * * 1h"
+				Expect(patchHelper.Patch(ctx, byoHost)).NotTo(HaveOccurred())
+
+				_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+					NamespacedName: byoHostLookupKey,
+				})
+				Expect(reconcilerErr).ToNot(HaveOccurred())
+				Expect(fakeUpgrader.UpgradeCallCount()).To(Equal(1))
+			})
+
+			It("should not upgrade the agent when the maintenance window is invalid", func() {
+				byoHost.Annotations[infrastructurev1beta1.MaintenanceWindowAnnotation] = "not a window"
+				Expect(patchHelper.Patch(ctx, byoHost)).NotTo(HaveOccurred())
+
+				_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{
+					NamespacedName: byoHostLookupKey,
+				})
+				Expect(reconcilerErr).ToNot(HaveOccurred())
+				Expect(fakeUpgrader.UpgradeCallCount()).To(Equal(0))
+
+				updatedByoHost := &infrastructurev1beta1.ByoHost{}
+				err := k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(conditions.GetReason(updatedByoHost, infrastructurev1beta1.MaintenanceWindowOpen)).To(Equal(infrastructurev1beta1.MaintenanceWindowInvalidReason))
+			})
+		})
+
 		AfterEach(func() {
 			Expect(k8sClient.Delete(ctx, byoHost)).NotTo(HaveOccurred())
 			hostReconciler.SkipK8sInstallation = false
 		})
 	})
+
+	Context("When the ByoHost has the Uninstall deletion policy", func() {
+		var bundleRemover *bundleRemovingInstaller
+
+		BeforeEach(func() {
+			bundleRemover = &bundleRemovingInstaller{FakeIK8sInstaller: fakeInstaller}
+			hostReconciler.K8sInstaller = bundleRemover
+			byoHost = builder.ByoHost(ns, "uninstalled-host").Build()
+			byoHost.Annotations = map[string]string{
+				infrastructurev1beta1.DeletionPolicyAnnotation: infrastructurev1beta1.DeletionPolicyUninstall,
+			}
+			Expect(k8sClient.Create(ctx, byoHost)).NotTo(HaveOccurred(), "failed to create byohost")
+			byoHostLookupKey = types.NamespacedName{Name: byoHost.Name, Namespace: ns}
+
+			_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{NamespacedName: byoHostLookupKey})
+			Expect(reconcilerErr).ToNot(HaveOccurred())
+			Expect(k8sClient.Get(ctx, byoHostLookupKey, byoHost)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			if err := k8sClient.Get(ctx, byoHostLookupKey, byoHost); err == nil {
+				controllerutil.RemoveFinalizer(byoHost, infrastructurev1beta1.HostUninstallFinalizer)
+				Expect(k8sClient.Update(ctx, byoHost)).NotTo(HaveOccurred())
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, byoHost))).NotTo(HaveOccurred())
+			}
+		})
+
+		It("should hold the deletion of the ByoHost with the uninstall finalizer", func() {
+			Expect(byoHost.Finalizers).To(ContainElement(infrastructurev1beta1.HostUninstallFinalizer))
+		})
+
+		It("should uninstall the attached host and remove the bundles before it is deleted", func() {
+			byoMachine = builder.ByoMachine(ns, "uninstalled-byomachine").Build()
+			Expect(k8sClient.Create(ctx, byoMachine)).NotTo(HaveOccurred(), "failed to create byomachine")
+			defer func() { Expect(k8sClient.Delete(ctx, byoMachine)).NotTo(HaveOccurred()) }()
+			helper, err := patch.NewHelper(byoHost, k8sClient)
+			Expect(err).ShouldNot(HaveOccurred())
+			byoHost.Status.MachineRef = &corev1.ObjectReference{
+				Kind:       "ByoMachine",
+				Namespace:  byoMachine.Namespace,
+				Name:       byoMachine.Name,
+				UID:        byoMachine.UID,
+				APIVersion: byoHost.APIVersion,
+			}
+			byoHost.Annotations[infrastructurev1beta1.BundleLookupBaseRegistryAnnotation] = "projects.blah.com"
+			byoHost.Annotations[infrastructurev1beta1.K8sVersionAnnotation] = "1.22"
+			byoHost.Annotations[infrastructurev1beta1.BundleLookupTagAnnotation] = "byoh-bundle-tag"
+			conditions.MarkTrue(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded)
+			conditions.MarkTrue(byoHost, infrastructurev1beta1.K8sComponentsInstallationSucceeded)
+			Expect(helper.Patch(ctx, byoHost, patch.WithStatusObservedGeneration{})).NotTo(HaveOccurred())
+			Expect(k8sClient.Delete(ctx, byoHost)).NotTo(HaveOccurred())
+
+			result, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{NamespacedName: byoHostLookupKey})
+			Expect(reconcilerErr).ToNot(HaveOccurred())
+			Expect(result).To(Equal(controllerruntime.Result{Requeue: true}))
+			Expect(fakeCommandRunner.RunCmdArgsForCall(0)).To(Equal(reconciler.KubeadmResetCommand))
+			Expect(fakeInstaller.UninstallCallCount()).To(Equal(1))
+			Expect(bundleRemover.removeBundlesCallCount).To(Equal(0))
+
+			updatedByoHost := &infrastructurev1beta1.ByoHost{}
+			Expect(k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost)).To(Succeed())
+			Expect(updatedByoHost.Status.MachineRef).To(BeNil())
+
+			result, reconcilerErr = hostReconciler.Reconcile(ctx, controllerruntime.Request{NamespacedName: byoHostLookupKey})
+			Expect(reconcilerErr).ToNot(HaveOccurred())
+			Expect(result).To(Equal(controllerruntime.Result{}))
+			Expect(bundleRemover.removeBundlesCallCount).To(Equal(1))
+			Expect(apierrors.IsNotFound(k8sClient.Get(ctx, byoHostLookupKey, updatedByoHost))).To(BeTrue())
+
+			events := eventutils.CollectEvents(recorder.Events)
+			Expect(events).Should(ContainElements(
+				"Normal K8sComponentsUninstalled Successfully Uninstalled K8s components",
+				"Normal HostUninstallSucceeded host uninstalled before the ByoHost deletion",
+			))
+		})
+
+		It("should keep holding the deletion of the ByoHost if the bundles cannot be removed", func() {
+			bundleRemover.removeBundlesErr = errors.New("permission denied")
+			Expect(k8sClient.Delete(ctx, byoHost)).NotTo(HaveOccurred())
+
+			_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{NamespacedName: byoHostLookupKey})
+			Expect(reconcilerErr).To(MatchError("permission denied"))
+			Expect(k8sClient.Get(ctx, byoHostLookupKey, byoHost)).To(Succeed())
+			Expect(byoHost.Finalizers).To(ContainElement(infrastructurev1beta1.HostUninstallFinalizer))
+
+			bundleRemover.removeBundlesErr = nil
+			_, reconcilerErr = hostReconciler.Reconcile(ctx, controllerruntime.Request{NamespacedName: byoHostLookupKey})
+			Expect(reconcilerErr).ToNot(HaveOccurred())
+		})
+
+		It("should release the deletion of the ByoHost without uninstalling it once the policy is removed", func() {
+			helper, err := patch.NewHelper(byoHost, k8sClient)
+			Expect(err).ShouldNot(HaveOccurred())
+			delete(byoHost.Annotations, infrastructurev1beta1.DeletionPolicyAnnotation)
+			Expect(helper.Patch(ctx, byoHost)).NotTo(HaveOccurred())
+
+			_, reconcilerErr := hostReconciler.Reconcile(ctx, controllerruntime.Request{NamespacedName: byoHostLookupKey})
+			Expect(reconcilerErr).ToNot(HaveOccurred())
+			Expect(k8sClient.Get(ctx, byoHostLookupKey, byoHost)).To(Succeed())
+			Expect(byoHost.Finalizers).NotTo(ContainElement(infrastructurev1beta1.HostUninstallFinalizer))
+
+			Expect(k8sClient.Delete(ctx, byoHost)).NotTo(HaveOccurred())
+			Expect(apierrors.IsNotFound(k8sClient.Get(ctx, byoHostLookupKey, byoHost))).To(BeTrue())
+			Expect(bundleRemover.removeBundlesCallCount).To(Equal(0))
+		})
+	})
 })
+
+// eventEmittingInstaller is a fake installer reporting its lifecycle transitions
+type eventEmittingInstaller struct {
+	*reconcilerfakes.FakeIK8sInstaller
+	eventFunc func(eventType, reason, message string)
+}
+
+func (e *eventEmittingInstaller) SetEventFunc(eventFunc func(eventType, reason, message string)) {
+	e.eventFunc = eventFunc
+}
+
+// commandAuditingInstaller is a fake installer auditing the commands it runs
+type commandAuditingInstaller struct {
+	*reconcilerfakes.FakeIK8sInstaller
+	auditFunc func(installer.AuditRecord)
+}
+
+func (a *commandAuditingInstaller) SetAuditFunc(auditFunc func(installer.AuditRecord)) {
+	a.auditFunc = auditFunc
+}
+
+// progressReportingInstaller is a fake installer reporting the phases of the installation
+type progressReportingInstaller struct {
+	*reconcilerfakes.FakeIK8sInstaller
+	progressFunc func(installer.InstallPhase)
+}
+
+func (p *progressReportingInstaller) SetProgressFunc(progressFunc func(installer.InstallPhase)) {
+	p.progressFunc = progressFunc
+}
+
+// kubeletEnvironmentFilingInstaller is a fake installer of an OS whose kubelet service reads another environment file
+type kubeletEnvironmentFilingInstaller struct {
+	*reconcilerfakes.FakeIK8sInstaller
+	environmentFile string
+}
+
+func (k *kubeletEnvironmentFilingInstaller) KubeletEnvironmentFile() string {
+	return k.environmentFile
+}
+
+// bundleRemovingInstaller is a fake installer removing the bundles it downloaded
+type bundleRemovingInstaller struct {
+	*reconcilerfakes.FakeIK8sInstaller
+	removeBundlesCallCount int
+	removeBundlesErr       error
+}
+
+func (b *bundleRemovingInstaller) RemoveBundles() error {
+	b.removeBundlesCallCount++
+	return b.removeBundlesErr
+}
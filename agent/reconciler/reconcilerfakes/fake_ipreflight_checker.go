@@ -0,0 +1,108 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package reconcilerfakes
+
+import (
+	"sync"
+
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/preflight"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/reconciler"
+)
+
+type FakeIPreflightChecker struct {
+	CheckStub        func() ([]preflight.Failure, error)
+	checkMutex       sync.RWMutex
+	checkArgsForCall []struct {
+	}
+	checkReturns struct {
+		result1 []preflight.Failure
+		result2 error
+	}
+	checkReturnsOnCall map[int]struct {
+		result1 []preflight.Failure
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeIPreflightChecker) Check() ([]preflight.Failure, error) {
+	fake.checkMutex.Lock()
+	ret, specificReturn := fake.checkReturnsOnCall[len(fake.checkArgsForCall)]
+	fake.checkArgsForCall = append(fake.checkArgsForCall, struct {
+	}{})
+	stub := fake.CheckStub
+	fakeReturns := fake.checkReturns
+	fake.recordInvocation("Check", []interface{}{})
+	fake.checkMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeIPreflightChecker) CheckCallCount() int {
+	fake.checkMutex.RLock()
+	defer fake.checkMutex.RUnlock()
+	return len(fake.checkArgsForCall)
+}
+
+func (fake *FakeIPreflightChecker) CheckCalls(stub func() ([]preflight.Failure, error)) {
+	fake.checkMutex.Lock()
+	defer fake.checkMutex.Unlock()
+	fake.CheckStub = stub
+}
+
+func (fake *FakeIPreflightChecker) CheckReturns(result1 []preflight.Failure, result2 error) {
+	fake.checkMutex.Lock()
+	defer fake.checkMutex.Unlock()
+	fake.CheckStub = nil
+	fake.checkReturns = struct {
+		result1 []preflight.Failure
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIPreflightChecker) CheckReturnsOnCall(i int, result1 []preflight.Failure, result2 error) {
+	fake.checkMutex.Lock()
+	defer fake.checkMutex.Unlock()
+	fake.CheckStub = nil
+	if fake.checkReturnsOnCall == nil {
+		fake.checkReturnsOnCall = make(map[int]struct {
+			result1 []preflight.Failure
+			result2 error
+		})
+	}
+	fake.checkReturnsOnCall[i] = struct {
+		result1 []preflight.Failure
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIPreflightChecker) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.checkMutex.RLock()
+	defer fake.checkMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeIPreflightChecker) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ reconciler.IPreflightChecker = new(FakeIPreflightChecker)
@@ -0,0 +1,71 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package reconciler
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// kubeadmKubeletKubeconfig is the kubeconfig kubeadm writes for the kubelet when it joins the node
+	kubeadmKubeletKubeconfig = "/etc/kubernetes/kubelet.conf"
+	// rke2KubeletKubeconfig is the kubeconfig rke2 writes for the kubelet when it joins the node
+	rke2KubeletKubeconfig = "/var/lib/rancher/rke2/agent/kubelet.kubeconfig"
+)
+
+// reconcileReimage resets the stale status of a host reimaged since its node was bootstrapped, so that
+// the k8s components are installed and the node bootstrapped again rather than trusting the conditions
+// of the former image. The current machine-id is recorded for the hosts bootstrapped before it was.
+func (r *HostReconciler) reconcileReimage(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost) {
+	if !conditions.IsTrue(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded) &&
+		!conditions.IsTrue(byoHost, infrastructurev1beta1.K8sComponentsInstallationSucceeded) {
+		return
+	}
+	reason := r.reimageReason(byoHost)
+	if reason == "" {
+		if byoHost.Status.InstalledMachineID == "" && conditions.IsTrue(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded) {
+			byoHost.Status.InstalledMachineID = r.MachineID
+		}
+		return
+	}
+
+	logger := ctrl.LoggerFrom(ctx)
+	logger.Info("The host was reimaged, installing the k8s components and bootstrapping the node again", "reason", reason)
+	r.Recorder.Eventf(byoHost, corev1.EventTypeWarning, "HostReimaged", "%s, installing the k8s components and bootstrapping the node again", reason)
+
+	conditions.Delete(byoHost, infrastructurev1beta1.K8sComponentsInstallationSucceeded)
+	conditions.Delete(byoHost, infrastructurev1beta1.HostPreflightSucceeded)
+	conditions.MarkFalse(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded, infrastructurev1beta1.HostReimagedReason, clusterv1.ConditionSeverityWarning, reason)
+	byoHost.Status.Backoff = nil
+	byoHost.Status.InstallerAudit = nil
+	byoHost.Status.InstalledMachineID = ""
+	resetProgress(byoHost)
+}
+
+// reimageReason returns why the host is considered reimaged since its node was bootstrapped: its
+// machine-id changed, or the kubeconfig of its kubelet is missing. It is empty when it is not.
+func (r *HostReconciler) reimageReason(byoHost *infrastructurev1beta1.ByoHost) string {
+	if r.MachineID != "" && byoHost.Status.InstalledMachineID != "" && r.MachineID != byoHost.Status.InstalledMachineID {
+		return "the machine-id of the host changed"
+	}
+	if !r.CheckInstallArtifacts || !conditions.IsTrue(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded) {
+		return ""
+	}
+	kubeconfig := kubeadmKubeletKubeconfig
+	if isRKE2(byoHost) {
+		kubeconfig = rke2KubeletKubeconfig
+	}
+	if _, err := os.Stat(kubeconfig); os.IsNotExist(err) {
+		return fmt.Sprintf("the kubelet kubeconfig %s is missing", kubeconfig)
+	}
+	return ""
+}
@@ -4,15 +4,23 @@
 package registration
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"time"
 
 	certv1 "k8s.io/api/certificates/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/wait"
 	clientset "k8s.io/client-go/kubernetes"
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -31,8 +39,8 @@ const (
 	ByohCSROrg        = "byoh:hosts"
 	ByohCSRCNFormat   = "byoh:host:%s"
 	ByohCSRNameFormat = "byoh-csr-%s"
-	// CSRApprovalTimeout defines the time to wait for certificate to
-	// be issued. Currently set to 1 hour.
+	// CSRApprovalTimeout defines the default time to wait for certificate to
+	// be issued before requesting it again. Currently set to 1 hour.
 	CSRApprovalTimeout = 3600 * time.Second
 	TmpPrivateKey      = "byoh-client.key.tmp"
 )
@@ -40,33 +48,57 @@ const (
 type ByohCSR struct {
 	BootstrapClient clientset.Interface
 	PrivateKey      []byte
+	// KeyType is the type of the private key generated for the certificate, KeyTypeECDSAP256 when empty
+	KeyType KeyType
+	// KeyStore keeps the private key, TmpPrivateKey of the working directory when nil. PrivateKey
+	// is left empty when the key cannot leave the store.
+	KeyStore KeyStore
+	// SignerName is the signer the certificate is requested from, kubernetes.io/kube-apiserver-client
+	// when empty. The CA of a custom signer has to be trusted by the API server for client authentication.
+	SignerName string
+	// ApprovalTimeout is how long the certificate is waited for before its request is deleted and
+	// submitted again, CSRApprovalTimeout when zero
+	ApprovalTimeout time.Duration
+	// MaxRequests is the number of requests of the certificate timing out before giving up, unlimited when zero
+	MaxRequests int
+	// RetryJitter is the upper bound of the random delay before submitting the request again, so that
+	// the hosts enrolled at once do not request their certificates again at once
+	RetryJitter time.Duration
 }
 
-// RequestBYOHClientCert will generate Private Key and then will create a
-// CertificateSigningRequest in K8s
+// RequestBYOHClientCert will generate Private Key of the KeyType in the KeyStore and then will
+// create a CertificateSigningRequest in K8s
 func (bcsr *ByohCSR) RequestBYOHClientCert(hostname string) (string, types.UID, error) {
 	if hostname == "" {
 		return "", "", fmt.Errorf("hostname is not valid")
 	}
-	keyData, _, err := keyutil.LoadOrGenerateKeyFile(TmpPrivateKey)
+	store := bcsr.KeyStore
+	if store == nil {
+		store = &fileKeyStore{keyPath: TmpPrivateKey}
+	}
+	privateKey, err := store.Signer(bcsr.KeyType)
 	if err != nil {
 		return "", "", err
 	}
-	privateKey, err := keyutil.ParsePrivateKeyPEM(keyData)
-	if err != nil {
-		return "", "", fmt.Errorf("invalid private key for certificate request: %v", err)
+	if store.Exportable() {
+		if bcsr.PrivateKey, err = keyutil.MarshalPrivateKeyToPEM(privateKey); err != nil {
+			return "", "", err
+		}
 	}
-	bcsr.PrivateKey = keyData
 	csrData, err := generateCSR(hostname, privateKey)
 	if err != nil {
 		klog.Errorf("error generating csr %s, err=%v", hostname, err)
 		return "", "", err
 	}
+	signerName := bcsr.SignerName
+	if signerName == "" {
+		signerName = certv1.KubeAPIServerClientSignerName
+	}
 	certTimeToExpire := time.Duration(ExpirationSeconds) * time.Second
 	reqName, reqUID, err := csr.RequestCertificate(bcsr.BootstrapClient,
 		csrData,
 		fmt.Sprintf(ByohCSRNameFormat, hostname),
-		certv1.KubeAPIServerClientSignerName,
+		signerName,
 		&certTimeToExpire,
 		[]certv1.KeyUsage{certv1.UsageClientAuth},
 		privateKey)
@@ -76,6 +108,51 @@ func (bcsr *ByohCSR) RequestBYOHClientCert(hostname string) (string, types.UID,
 	return reqName, reqUID, nil
 }
 
+// WaitForBYOHClientCert requests the client certificate of the host and waits for it to be issued. A request
+// not approved within the approval timeout is deleted and submitted again, until the certificate is issued,
+// the request is denied or the max requests timed out.
+func (bcsr *ByohCSR) WaitForBYOHClientCert(ctx context.Context, hostname string) ([]byte, error) {
+	timeout := bcsr.ApprovalTimeout
+	if timeout == 0 {
+		timeout = CSRApprovalTimeout
+	}
+	for requests := 1; ; requests++ {
+		reqName, reqUID, err := bcsr.RequestBYOHClientCert(hostname)
+		if err != nil {
+			return nil, err
+		}
+		waitCtx, cancel := context.WithTimeout(ctx, timeout)
+		certData, err := csr.WaitForCertificate(waitCtx, bcsr.BootstrapClient, reqName, reqUID)
+		cancel()
+		if !errors.Is(err, wait.ErrWaitTimeout) || ctx.Err() != nil || (bcsr.MaxRequests > 0 && requests >= bcsr.MaxRequests) {
+			return certData, err
+		}
+
+		klog.Infof("csr %s not approved within %s, submitting it again", reqName, timeout)
+		if err := bcsr.DeleteBYOHClientCertRequest(ctx, reqName, reqUID); err != nil {
+			return nil, err
+		}
+		if bcsr.RetryJitter > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Duration(utilrand.Int63nRange(0, int64(bcsr.RetryJitter)))):
+			}
+		}
+	}
+}
+
+// DeleteBYOHClientCertRequest deletes the pending request of the client certificate of the host, unless it
+// was already replaced by another request
+func (bcsr *ByohCSR) DeleteBYOHClientCertRequest(ctx context.Context, reqName string, reqUID types.UID) error {
+	err := bcsr.BootstrapClient.CertificatesV1().CertificateSigningRequests().Delete(ctx, reqName,
+		metav1.DeleteOptions{Preconditions: metav1.NewUIDPreconditions(string(reqUID))})
+	if apierrors.IsNotFound(err) || apierrors.IsConflict(err) {
+		return nil
+	}
+	return err
+}
+
 func generateCSR(hostname string, privKey interface{}) ([]byte, error) {
 	// Generate a new *x509.CertificateRequest template
 	csrTemplate := x509.CertificateRequest{
@@ -114,9 +191,83 @@ func LoadRESTClientConfig(bootstrapKubeconfig string) (*restclient.Config, error
 	).ClientConfig()
 }
 
+// LoadSealedRESTClientConfig is LoadRESTClientConfig for a kubeconfig encrypted by the sealer,
+// decrypted in memory only. A kubeconfig in plain text is loaded as is.
+func LoadSealedRESTClientConfig(kubeconfigPath string, sealer Sealer) (*restclient.Config, error) {
+	if sealer == nil {
+		return LoadRESTClientConfig(kubeconfigPath)
+	}
+	data, err := os.ReadFile(kubeconfigPath)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = unsealFile(kubeconfigPath, data, sealer); err != nil {
+		return nil, err
+	}
+	loadedConfig, err := clientcmd.Load(data)
+	if err != nil {
+		return nil, err
+	}
+	return clientcmd.NewNonInteractiveClientConfig(
+		*loadedConfig,
+		loadedConfig.CurrentContext,
+		&clientcmd.ConfigOverrides{},
+		nil,
+	).ClientConfig()
+}
+
+// SealKubeconfig encrypts the kubeconfig in place with the sealer, e.g. a kubeconfig written before
+// the credential encryption was set. It returns false when the kubeconfig is already encrypted.
+func SealKubeconfig(kubeconfigPath string, sealer Sealer) (bool, error) {
+	data, err := os.ReadFile(kubeconfigPath)
+	if err != nil || IsSealed(data) {
+		return false, err
+	}
+	if data, err = sealer.Seal(data); err != nil {
+		return false, fmt.Errorf("error encrypting kubeconfig %s: %v", kubeconfigPath, err)
+	}
+	return true, writeFileAtomic(kubeconfigPath, data)
+}
+
+// writeFileAtomic replaces the file with the data with 0600 permissions, so the file is never
+// left half written
+func writeFileAtomic(path string, data []byte) error {
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
 // WriteKubeconfigFromBootstrapping will write the new kubeconfig fetching
 // some details from bootstrap client config and using key/cert details
 func WriteKubeconfigFromBootstrapping(bootstrapClientConfig *restclient.Config, kubeconfigPath, certData, keyData string) error {
+	// Marshal to disk
+	return clientcmd.WriteToFile(kubeconfigFromBootstrapping(bootstrapClientConfig, certData, keyData), kubeconfigPath)
+}
+
+// WriteSealedKubeconfigFromBootstrapping is WriteKubeconfigFromBootstrapping with the kubeconfig
+// encrypted by the sealer, or in plain text when it is nil
+func WriteSealedKubeconfigFromBootstrapping(bootstrapClientConfig *restclient.Config, kubeconfigPath, certData, keyData string, sealer Sealer) error {
+	if sealer == nil {
+		return WriteKubeconfigFromBootstrapping(bootstrapClientConfig, kubeconfigPath, certData, keyData)
+	}
+	data, err := clientcmd.Write(kubeconfigFromBootstrapping(bootstrapClientConfig, certData, keyData))
+	if err != nil {
+		return err
+	}
+	if data, err = sealer.Seal(data); err != nil {
+		return fmt.Errorf("error encrypting kubeconfig: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(kubeconfigPath), 0o755); err != nil {
+		return err
+	}
+	return writeFileAtomic(kubeconfigPath, data)
+}
+
+// kubeconfigFromBootstrapping returns the kubeconfig of the cluster of the bootstrap client config
+// authenticating with the key/cert details
+func kubeconfigFromBootstrapping(bootstrapClientConfig *restclient.Config, certData, keyData string) clientcmdapi.Config {
 	// Get the CA data from the bootstrap client config.
 	caFile, caData := bootstrapClientConfig.CAFile, []byte{}
 	if caFile == "" {
@@ -124,7 +275,7 @@ func WriteKubeconfigFromBootstrapping(bootstrapClientConfig *restclient.Config,
 	}
 
 	// Build resulting kubeconfig.
-	kubeconfigData := clientcmdapi.Config{
+	return clientcmdapi.Config{
 		// Define a cluster stanza based on the bootstrap kubeconfig.
 		Clusters: map[string]*clientcmdapi.Cluster{"default-cluster": {
 			Server:                   bootstrapClientConfig.Host,
@@ -134,8 +285,8 @@ func WriteKubeconfigFromBootstrapping(bootstrapClientConfig *restclient.Config,
 		}},
 		// Define auth based on the obtained client cert.
 		AuthInfos: map[string]*clientcmdapi.AuthInfo{"default-auth": {
-			ClientCertificate: certData,
-			ClientKey:         keyData,
+			ClientCertificateData: []byte(certData),
+			ClientKeyData:         []byte(keyData),
 		}},
 		// Define a context that connects the auth info and cluster, and set it as the default
 		Contexts: map[string]*clientcmdapi.Context{"default-context": {
@@ -145,7 +296,4 @@ func WriteKubeconfigFromBootstrapping(bootstrapClientConfig *restclient.Config,
 		}},
 		CurrentContext: "default-context",
 	}
-
-	// Marshal to disk
-	return clientcmd.WriteToFile(kubeconfigData, kubeconfigPath)
 }
@@ -4,11 +4,17 @@
 package registration
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"os"
+	"path/filepath"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/fips"
+	"k8s.io/client-go/util/keyutil"
 )
 
 var _ = Describe("Registration", func() {
@@ -29,4 +35,63 @@ var _ = Describe("Registration", func() {
 			Expect(certData).ToNot(BeNil())
 		})
 	})
+
+	Context("When the private key is loaded or generated", func() {
+		var keyDir, keyPath string
+
+		BeforeEach(func() {
+			var err error
+			keyDir, err = os.MkdirTemp("", "keys")
+			Expect(err).NotTo(HaveOccurred())
+			keyPath = filepath.Join(keyDir, "client.key")
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(keyDir)).To(Succeed())
+		})
+
+		It("should generate a key of the key type and load it afterwards", func() {
+			keyData, key, err := loadOrGenerateKeyFile(keyPath, KeyTypeECDSAP384, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(key.(*ecdsa.PrivateKey).Curve).To(Equal(elliptic.P384()))
+
+			loadedData, _, err := loadOrGenerateKeyFile(keyPath, KeyTypeRSA2048, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loadedData).To(Equal(keyData))
+		})
+
+		It("should generate an ECDSA P-256 key by default", func() {
+			_, key, err := loadOrGenerateKeyFile(keyPath, "", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(key.(*ecdsa.PrivateKey).Curve).To(Equal(elliptic.P256()))
+		})
+
+		It("should reject a loaded key which is not approved in FIPS mode", func() {
+			key, err := rsa.GenerateKey(rand.Reader, 1024)
+			Expect(err).NotTo(HaveOccurred())
+			keyData, err := keyutil.MarshalPrivateKeyToPEM(key)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(keyutil.WriteKey(keyPath, keyData)).To(Succeed())
+
+			_, _, err = loadOrGenerateKeyFile(keyPath, KeyTypeECDSAP256, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			fips.SetEnforced(true)
+			defer fips.SetEnforced(false)
+			_, _, err = loadOrGenerateKeyFile(keyPath, KeyTypeECDSAP256, nil)
+			Expect(err).To(MatchError(ContainSubstring("rejected in FIPS mode: RSA key of 1024 bits is not FIPS approved")))
+		})
+	})
+
+	Context("When the key type is parsed", func() {
+		It("should return the key type of its name, case insensitively", func() {
+			Expect(ParseKeyType("rsa-3072")).To(Equal(KeyTypeRSA3072))
+			Expect(ParseKeyType("")).To(Equal(KeyTypeECDSAP256))
+		})
+
+		It("should return an error for an unsupported key type", func() {
+			_, err := ParseKeyType("Ed25519")
+			Expect(err).To(MatchError(`unsupported key type "Ed25519", must be one of ECDSA-P256, ECDSA-P384, RSA-2048, RSA-3072, RSA-4096`))
+		})
+	})
 })
@@ -10,13 +10,17 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/registration"
 	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/test/builder"
 	certv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
 )
 
 var _ = Describe("CSR Registration", func() {
@@ -120,6 +124,16 @@ users:
 
 			Expect(os.Remove(registration.TmpPrivateKey)).ShouldNot(HaveOccurred())
 		})
+		It("should request the certificate from the custom signer", func() {
+			CSRRegistrar := registration.ByohCSR{BootstrapClient: clientSetFake, SignerName: "clusterissuers.cert-manager.io/byoh-ca"}
+			_, _, err := CSRRegistrar.RequestBYOHClientCert(hostName)
+			Expect(err).NotTo(HaveOccurred())
+			ByohCSR, err := clientSetFake.CertificatesV1().CertificateSigningRequests().Get(ctx, fmt.Sprintf(registration.ByohCSRNameFormat, hostName), v1.GetOptions{})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ByohCSR.Spec.SignerName).Should(Equal("clusterissuers.cert-manager.io/byoh-ca"))
+
+			Expect(os.Remove(registration.TmpPrivateKey)).ShouldNot(HaveOccurred())
+		})
 		It("should write kubeconfig if bootstrap kubeconfig is valid", func() {
 			fileboot, err := ioutil.TempFile(fileDir, "boostrapkubeconfig")
 			Expect(err).ShouldNot(HaveOccurred())
@@ -149,6 +163,56 @@ users:
 			Expect(err).Should(HaveOccurred())
 			Expect(err.Error()).Should(ContainSubstring("retrieved csr is not compatible"))
 
+			Expect(os.Remove(registration.TmpPrivateKey)).ShouldNot(HaveOccurred())
+		})
+		It("should return the certificate once the CSR is approved", func() {
+			clientSet := fakeclientset.NewSimpleClientset()
+			go func() {
+				defer GinkgoRecover()
+				Eventually(func() error {
+					byohCSR, err := clientSet.CertificatesV1().CertificateSigningRequests().Get(ctx, fmt.Sprintf(registration.ByohCSRNameFormat, hostName), v1.GetOptions{})
+					if err != nil {
+						return err
+					}
+					byohCSR.Status.Conditions = append(byohCSR.Status.Conditions, certv1.CertificateSigningRequestCondition{
+						Type:   certv1.CertificateApproved,
+						Status: corev1.ConditionTrue,
+					})
+					byohCSR.Status.Certificate = []byte("cert-data")
+					_, err = clientSet.CertificatesV1().CertificateSigningRequests().UpdateStatus(ctx, byohCSR, v1.UpdateOptions{})
+					return err
+				}).Should(Succeed())
+			}()
+			CSRRegistrar := registration.ByohCSR{BootstrapClient: clientSet}
+			certData, err := CSRRegistrar.WaitForBYOHClientCert(ctx, hostName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(certData).To(Equal([]byte("cert-data")))
+
+			Expect(os.Remove(registration.TmpPrivateKey)).ShouldNot(HaveOccurred())
+		})
+		It("should submit the CSR again when it is not approved in time", func() {
+			clientSet := fakeclientset.NewSimpleClientset()
+			CSRRegistrar := registration.ByohCSR{
+				BootstrapClient: clientSet,
+				ApprovalTimeout: 100 * time.Millisecond,
+				MaxRequests:     3,
+				RetryJitter:     10 * time.Millisecond,
+			}
+			_, err := CSRRegistrar.WaitForBYOHClientCert(ctx, hostName)
+			Expect(err).To(MatchError(wait.ErrWaitTimeout))
+
+			verbs := map[string]int{}
+			for _, action := range clientSet.Actions() {
+				if action.GetResource().Resource == "certificatesigningrequests" {
+					verbs[action.GetVerb()]++
+				}
+			}
+			Expect(verbs["create"]).To(Equal(3))
+			// the last request is left pending, to be picked up once approved
+			Expect(verbs["delete"]).To(Equal(2))
+			_, err = clientSet.CertificatesV1().CertificateSigningRequests().Get(ctx, fmt.Sprintf(registration.ByohCSRNameFormat, hostName), v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
 			Expect(os.Remove(registration.TmpPrivateKey)).ShouldNot(HaveOccurred())
 		})
 	})
@@ -0,0 +1,90 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package registration
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/faultinject"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// DefaultHeartbeatInterval is how often the agent reports that it is running
+const DefaultHeartbeatInterval = time.Minute
+
+// Heartbeat periodically records in ByoHost.Status.LastHeartbeatTime that the agent is running,
+// so that the registrations of the hosts gone for good are garbage collected by the controller
+type Heartbeat struct {
+	K8sClient client.Client
+	Host      types.NamespacedName
+	// Interval defaults to DefaultHeartbeatInterval
+	Interval time.Duration
+	Logger   logr.Logger
+}
+
+// Start sends a heartbeat every interval until the context is done. The failed heartbeats are
+// logged and sent again on the next interval.
+func (h *Heartbeat) Start(ctx context.Context) error {
+	interval := h.Interval
+	if interval == 0 {
+		interval = DefaultHeartbeatInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := h.Beat(ctx); err != nil {
+			h.Logger.Error(err, "failed to send the heartbeat")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Beat records the current time as the last heartbeat of the host
+func (h *Heartbeat) Beat(ctx context.Context) error {
+	byoHost := &infrastructurev1beta1.ByoHost{}
+	if err := h.K8sClient.Get(ctx, h.Host, byoHost); err != nil {
+		return err
+	}
+	if faultinject.Active(faultinject.DropHeartbeats, byoHost.Annotations) {
+		return nil
+	}
+	now := metav1.Now()
+	return ApplyStatus(ctx, h.K8sClient, byoHost, &infrastructurev1beta1.ByoHostStatus{LastHeartbeatTime: &now}, HeartbeatFieldOwner)
+}
+
+// IgnoreHeartbeats filters out the updates of a ByoHost which only record a heartbeat, so that
+// the host is not reconciled on every heartbeat
+func IgnoreHeartbeats() predicate.Funcs {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldHost, ok := e.ObjectOld.(*infrastructurev1beta1.ByoHost)
+			if !ok {
+				return true
+			}
+			newHost, ok := e.ObjectNew.(*infrastructurev1beta1.ByoHost)
+			if !ok {
+				return true
+			}
+			oldHost, newHost = oldHost.DeepCopy(), newHost.DeepCopy()
+			for _, host := range []*infrastructurev1beta1.ByoHost{oldHost, newHost} {
+				host.ResourceVersion = ""
+				host.ManagedFields = nil
+				host.Status.LastHeartbeatTime = nil
+			}
+			return !equality.Semantic.DeepEqual(oldHost, newHost)
+		},
+	}
+}
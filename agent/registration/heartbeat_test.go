@@ -0,0 +1,100 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package registration_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/registration"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+var _ = Describe("Heartbeat Tests", func() {
+	var (
+		heartbeat *registration.Heartbeat
+		hostKey   = types.NamespacedName{Name: "heartbeat-host", Namespace: "default"}
+	)
+
+	BeforeEach(func() {
+		byoHost := &infrastructurev1beta1.ByoHost{
+			ObjectMeta: metav1.ObjectMeta{Name: hostKey.Name, Namespace: hostKey.Namespace},
+		}
+		Expect(k8sClient.Create(context.TODO(), byoHost)).To(Succeed())
+		byoHost.Status.AgentVersion = "v0.2.0"
+		Expect(k8sClient.Status().Update(context.TODO(), byoHost)).To(Succeed())
+		heartbeat = &registration.Heartbeat{
+			K8sClient: k8sClient,
+			Host:      hostKey,
+			Interval:  10 * time.Millisecond,
+			Logger:    logr.Discard(),
+		}
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.Delete(context.TODO(), &infrastructurev1beta1.ByoHost{
+			ObjectMeta: metav1.ObjectMeta{Name: hostKey.Name, Namespace: hostKey.Namespace},
+		})).To(Succeed())
+	})
+
+	It("Should record the last heartbeat of the host", func() {
+		before := time.Now().Add(-time.Second)
+		Expect(heartbeat.Beat(context.TODO())).To(Succeed())
+
+		byoHost := &infrastructurev1beta1.ByoHost{}
+		Expect(heartbeat.K8sClient.Get(context.TODO(), hostKey, byoHost)).To(Succeed())
+		Expect(byoHost.Status.LastHeartbeatTime).NotTo(BeNil())
+		Expect(byoHost.Status.LastHeartbeatTime.Time).To(BeTemporally(">", before))
+		Expect(byoHost.Status.AgentVersion).To(Equal("v0.2.0"))
+	})
+
+	It("Should apply the heartbeat under the field manager of the agent", func() {
+		Expect(heartbeat.Beat(context.TODO())).To(Succeed())
+
+		byoHost := &infrastructurev1beta1.ByoHost{}
+		Expect(k8sClient.Get(context.TODO(), hostKey, byoHost)).To(Succeed())
+		Expect(byoHost.ManagedFields).To(ContainElement(And(
+			HaveField("Manager", registration.FieldManager+"-"+registration.HeartbeatFieldOwner),
+			HaveField("Operation", metav1.ManagedFieldsOperationApply),
+			HaveField("Subresource", "status"),
+		)))
+	})
+
+	It("Should fail the heartbeat of an unregistered host", func() {
+		heartbeat.Host.Name = "unregistered-host"
+		Expect(heartbeat.Beat(context.TODO())).NotTo(Succeed())
+	})
+
+	It("Should send the heartbeats until it is stopped", func() {
+		ctx, cancel := context.WithCancel(context.TODO())
+		done := make(chan error)
+		go func() { done <- heartbeat.Start(ctx) }()
+
+		Eventually(func() *metav1.Time {
+			byoHost := &infrastructurev1beta1.ByoHost{}
+			Expect(heartbeat.K8sClient.Get(context.TODO(), hostKey, byoHost)).To(Succeed())
+			return byoHost.Status.LastHeartbeatTime
+		}).ShouldNot(BeNil())
+		cancel()
+		Eventually(done).Should(Receive(BeNil()))
+	})
+
+	It("Should filter out the updates only recording a heartbeat", func() {
+		oldHost := &infrastructurev1beta1.ByoHost{ObjectMeta: metav1.ObjectMeta{Name: hostKey.Name, ResourceVersion: "1"}}
+		newHost := oldHost.DeepCopy()
+		now := metav1.Now()
+		newHost.ResourceVersion = "2"
+		newHost.Status.LastHeartbeatTime = &now
+		Expect(registration.IgnoreHeartbeats().Update(event.UpdateEvent{ObjectOld: oldHost, ObjectNew: newHost})).To(BeFalse())
+
+		newHost.Annotations = map[string]string{infrastructurev1beta1.RetryAnnotation: ""}
+		Expect(registration.IgnoreHeartbeats().Update(event.UpdateEvent{ObjectOld: oldHost, ObjectNew: newHost})).To(BeTrue())
+	})
+})
@@ -0,0 +1,207 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package registration
+
+import (
+	"bufio"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// pciDevicesDir lists the PCI devices of the host, relative to the root of the host file system
+	pciDevicesDir = "sys/bus/pci/devices"
+	// hugePagesDir has a directory for each huge page size, e.g. hugepages-2048kB
+	hugePagesDir = "sys/kernel/mm/hugepages"
+	// netClassDir lists the network interfaces of the host
+	netClassDir = "sys/class/net"
+	// cpuDir lists the logical CPUs of the host, e.g. cpu0, along with other attributes
+	cpuDir = "sys/devices/system/cpu"
+	// nodeDir lists the NUMA nodes of the host, e.g. node0
+	nodeDir = "sys/devices/system/node"
+	// displayControllerClass is the prefix of the PCI classes of the display controllers, GPUs included
+	displayControllerClass = "0x03"
+)
+
+var (
+	// pciIDsFiles are the locations of the PCI ID database, which names the vendors and models
+	pciIDsFiles = []string{"usr/share/hwdata/pci.ids", "usr/share/misc/pci.ids"}
+
+	// gpuVendors are the short names of the GPU vendors, the other vendors are named after the PCI ID database
+	gpuVendors = map[string]string{"10de": "NVIDIA", "1002": "AMD", "8086": "Intel"}
+
+	// bmcDisplayVendors are the vendors of the display controllers of the BMCs, which are not GPUs, i.e. ASPEED and Matrox
+	bmcDisplayVendors = map[string]bool{"1a03": true, "102b": true}
+)
+
+// getGPUs scans the PCI devices of the host for display controllers, grouped by vendor and model
+func getGPUs(fsys fs.FS) ([]infrastructurev1beta1.GPUInfo, error) {
+	entries, err := fs.ReadDir(fsys, pciDevicesDir)
+	if err != nil {
+		return nil, err
+	}
+	groups := map[string]*infrastructurev1beta1.GPUInfo{}
+	for _, entry := range entries {
+		device := path.Join(pciDevicesDir, entry.Name())
+		if !strings.HasPrefix(readSysfsValue(fsys, device, "class"), displayControllerClass) {
+			continue
+		}
+		vendorID := strings.TrimPrefix(readSysfsValue(fsys, device, "vendor"), "0x")
+		deviceID := strings.TrimPrefix(readSysfsValue(fsys, device, "device"), "0x")
+		if vendorID == "" || bmcDisplayVendors[vendorID] {
+			continue
+		}
+		key := vendorID + ":" + deviceID
+		if groups[key] == nil {
+			groups[key] = &infrastructurev1beta1.GPUInfo{VendorID: vendorID, DeviceID: deviceID}
+		}
+		groups[key].Count++
+	}
+
+	gpus := make([]infrastructurev1beta1.GPUInfo, 0, len(groups))
+	for _, gpu := range groups {
+		vendor, model := lookupPCINames(fsys, gpu.VendorID, gpu.DeviceID)
+		gpu.Vendor, gpu.Model = gpu.VendorID, gpu.DeviceID
+		if shortName, ok := gpuVendors[gpu.VendorID]; ok {
+			gpu.Vendor = shortName
+		} else if vendor != "" {
+			gpu.Vendor = vendor
+		}
+		if model != "" {
+			gpu.Model = model
+		}
+		gpus = append(gpus, *gpu)
+	}
+	sort.Slice(gpus, func(i, j int) bool {
+		return gpus[i].VendorID+":"+gpus[i].DeviceID < gpus[j].VendorID+":"+gpus[j].DeviceID
+	})
+	return gpus, nil
+}
+
+// lookupPCINames returns the names of the vendor and of the device from the PCI ID database of the host,
+// empty when the database or the IDs are not found
+func lookupPCINames(fsys fs.FS, vendorID, deviceID string) (vendor, model string) {
+	for _, pciIDsFile := range pciIDsFiles {
+		file, err := fsys.Open(pciIDsFile)
+		if err != nil {
+			continue
+		}
+		defer file.Close()
+
+		// vendors start the lines, their devices are indented with a tab, and the subsystems with two tabs
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "#") || strings.HasPrefix(line, "\t\t"):
+			case strings.HasPrefix(line, "\t"):
+				if vendor != "" && strings.HasPrefix(line[1:], deviceID+" ") {
+					return vendor, strings.TrimSpace(line[1+len(deviceID):])
+				}
+			case vendor != "":
+				return vendor, ""
+			case strings.HasPrefix(line, vendorID+" "):
+				vendor = strings.TrimSpace(line[len(vendorID):])
+			}
+		}
+		return vendor, ""
+	}
+	return "", ""
+}
+
+// getHugePages returns the huge pages preallocated on the host, for each page size
+func getHugePages(fsys fs.FS) ([]infrastructurev1beta1.HugePagesInfo, error) {
+	entries, err := fs.ReadDir(fsys, hugePagesDir)
+	if err != nil {
+		return nil, err
+	}
+	hugePages := make([]infrastructurev1beta1.HugePagesInfo, 0, len(entries))
+	for _, entry := range entries {
+		sizeKB, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(entry.Name(), "hugepages-"), "kB"), 10, 64)
+		if err != nil {
+			continue
+		}
+		count, err := strconv.ParseInt(readSysfsValue(fsys, path.Join(hugePagesDir, entry.Name()), "nr_hugepages"), 10, 64)
+		if err != nil || count == 0 {
+			continue
+		}
+		hugePages = append(hugePages, infrastructurev1beta1.HugePagesInfo{
+			PageSize: *resource.NewQuantity(sizeKB*1024, resource.BinarySI),
+			Count:    count,
+		})
+	}
+	sort.Slice(hugePages, func(i, j int) bool { return hugePages[i].PageSize.Cmp(hugePages[j].PageSize) < 0 })
+	return hugePages, nil
+}
+
+// getSRIOVNetworkInterfaces returns the network interfaces of the host supporting SR-IOV virtual functions
+func getSRIOVNetworkInterfaces(fsys fs.FS) ([]infrastructurev1beta1.SRIOVNetworkInterface, error) {
+	entries, err := fs.ReadDir(fsys, netClassDir)
+	if err != nil {
+		return nil, err
+	}
+	ifaces := make([]infrastructurev1beta1.SRIOVNetworkInterface, 0)
+	for _, entry := range entries {
+		totalVFs, err := strconv.ParseInt(readSysfsValue(fsys, path.Join(netClassDir, entry.Name(), "device"), "sriov_totalvfs"), 10, 32)
+		if err != nil || totalVFs == 0 {
+			continue
+		}
+		ifaces = append(ifaces, infrastructurev1beta1.SRIOVNetworkInterface{Name: entry.Name(), TotalVFs: int32(totalVFs)})
+	}
+	return ifaces, nil
+}
+
+// getCPUTopology counts the sockets, cores and logical CPUs of the host, and lists its NUMA nodes.
+// The offline CPUs, which have no topology, are left out.
+func getCPUTopology(fsys fs.FS) (*infrastructurev1beta1.CPUTopology, error) {
+	entries, err := fs.ReadDir(fsys, cpuDir)
+	if err != nil {
+		return nil, err
+	}
+	topology := &infrastructurev1beta1.CPUTopology{}
+	sockets, cores := map[string]bool{}, map[string]bool{}
+	for _, entry := range entries {
+		if _, err := strconv.Atoi(strings.TrimPrefix(entry.Name(), "cpu")); err != nil || !strings.HasPrefix(entry.Name(), "cpu") {
+			continue
+		}
+		cpuTopology := path.Join(cpuDir, entry.Name(), "topology")
+		socket := readSysfsValue(fsys, cpuTopology, "physical_package_id")
+		if socket == "" {
+			continue
+		}
+		topology.CPUs++
+		sockets[socket] = true
+		cores[socket+":"+readSysfsValue(fsys, cpuTopology, "core_id")] = true
+	}
+	topology.Sockets, topology.Cores = int32(len(sockets)), int32(len(cores))
+
+	// the hosts without NUMA support have no node directory
+	nodes, _ := fs.ReadDir(fsys, nodeDir)
+	for _, node := range nodes {
+		id, err := strconv.ParseInt(strings.TrimPrefix(node.Name(), "node"), 10, 32)
+		if err != nil || !strings.HasPrefix(node.Name(), "node") {
+			continue
+		}
+		if cpus := readSysfsValue(fsys, path.Join(nodeDir, node.Name()), "cpulist"); cpus != "" {
+			topology.NUMANodes = append(topology.NUMANodes, infrastructurev1beta1.NUMANode{ID: int32(id), CPUs: cpus})
+		}
+	}
+	sort.Slice(topology.NUMANodes, func(i, j int) bool { return topology.NUMANodes[i].ID < topology.NUMANodes[j].ID })
+	return topology, nil
+}
+
+// readSysfsValue returns the trimmed content of a sysfs attribute, empty when it cannot be read
+func readSysfsValue(fsys fs.FS, dir, attribute string) string {
+	content, err := fs.ReadFile(fsys, path.Join(dir, attribute))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
@@ -0,0 +1,105 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// nolint: testpackage
+package registration
+
+import (
+	"testing/fstest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+var _ = Describe("Host devices", func() {
+	var hostFS fstest.MapFS
+
+	pciDevice := func(address, class, vendor, device string) {
+		hostFS[pciDevicesDir+"/"+address+"/class"] = &fstest.MapFile{Data: []byte(class + "\n")}
+		hostFS[pciDevicesDir+"/"+address+"/vendor"] = &fstest.MapFile{Data: []byte(vendor + "\n")}
+		hostFS[pciDevicesDir+"/"+address+"/device"] = &fstest.MapFile{Data: []byte(device + "\n")}
+	}
+
+	BeforeEach(func() {
+		hostFS = fstest.MapFS{}
+	})
+
+	Context("When the host has GPUs", func() {
+		BeforeEach(func() {
+			pciDevice("0000:03:00.0", "0x030000", "0x1a03", "0x2000")
+			pciDevice("0000:17:00.0", "0x030200", "0x10de", "0x20b5")
+			pciDevice("0000:65:00.0", "0x030200", "0x10de", "0x20b5")
+			pciDevice("0000:b1:00.0", "0x030000", "0x1af4", "0x1050")
+			pciDevice("0000:18:00.0", "0x020000", "0x15b3", "0x101d")
+		})
+
+		It("Should group the GPUs by vendor and model, leaving out the BMC and the other devices", func() {
+			Expect(getGPUs(hostFS)).To(Equal([]infrastructurev1beta1.GPUInfo{
+				{VendorID: "10de", DeviceID: "20b5", Vendor: "NVIDIA", Model: "20b5", Count: 2},
+				{VendorID: "1af4", DeviceID: "1050", Vendor: "1af4", Model: "1050", Count: 1},
+			}))
+		})
+
+		It("Should name the vendors and the models after the PCI ID database", func() {
+			hostFS["usr/share/misc/pci.ids"] = &fstest.MapFile{Data: []byte("# PCI IDs\n" +
+				"10de  NVIDIA Corporation\n\t20b0  GA100 [A100 SXM4 40GB]\n\t20b5  GA100 [A100 PCIe 80GB]\n\t\t10de 1533  A100 80GB\n" +
+				"1af4  Red Hat, Inc.\n\t1050  Virtio GPU\n")}
+
+			Expect(getGPUs(hostFS)).To(Equal([]infrastructurev1beta1.GPUInfo{
+				{VendorID: "10de", DeviceID: "20b5", Vendor: "NVIDIA", Model: "GA100 [A100 PCIe 80GB]", Count: 2},
+				{VendorID: "1af4", DeviceID: "1050", Vendor: "Red Hat, Inc.", Model: "Virtio GPU", Count: 1},
+			}))
+		})
+	})
+
+	It("Should report the preallocated huge pages of each size", func() {
+		hostFS[hugePagesDir+"/hugepages-2048kB/nr_hugepages"] = &fstest.MapFile{Data: []byte("1024\n")}
+		hostFS[hugePagesDir+"/hugepages-1048576kB/nr_hugepages"] = &fstest.MapFile{Data: []byte("8\n")}
+		hostFS[hugePagesDir+"/hugepages-32768kB/nr_hugepages"] = &fstest.MapFile{Data: []byte("0\n")}
+
+		Expect(getHugePages(hostFS)).To(Equal([]infrastructurev1beta1.HugePagesInfo{
+			{PageSize: *resource.NewQuantity(2*1024*1024, resource.BinarySI), Count: 1024},
+			{PageSize: *resource.NewQuantity(1024*1024*1024, resource.BinarySI), Count: 8},
+		}))
+	})
+
+	It("Should report the network interfaces capable of SR-IOV", func() {
+		hostFS[netClassDir+"/lo/mtu"] = &fstest.MapFile{Data: []byte("65536\n")}
+		hostFS[netClassDir+"/eno1/device/sriov_totalvfs"] = &fstest.MapFile{Data: []byte("0\n")}
+		hostFS[netClassDir+"/ens1f0/device/sriov_totalvfs"] = &fstest.MapFile{Data: []byte("64\n")}
+
+		Expect(getSRIOVNetworkInterfaces(hostFS)).To(Equal([]infrastructurev1beta1.SRIOVNetworkInterface{
+			{Name: "ens1f0", TotalVFs: 64},
+		}))
+	})
+
+	It("Should report the CPU topology with the NUMA nodes", func() {
+		for cpu, topology := range map[string][2]string{"cpu0": {"0", "0"}, "cpu1": {"0", "1"}, "cpu2": {"1", "0"}, "cpu3": {"1", "1"},
+			"cpu4": {"0", "0"}, "cpu5": {"0", "1"}, "cpu6": {"1", "0"}, "cpu7": {"1", "1"}} {
+			hostFS[cpuDir+"/"+cpu+"/topology/physical_package_id"] = &fstest.MapFile{Data: []byte(topology[0] + "\n")}
+			hostFS[cpuDir+"/"+cpu+"/topology/core_id"] = &fstest.MapFile{Data: []byte(topology[1] + "\n")}
+		}
+		hostFS[cpuDir+"/cpu8/online"] = &fstest.MapFile{Data: []byte("0\n")}
+		hostFS[cpuDir+"/cpufreq/boost"] = &fstest.MapFile{Data: []byte("1\n")}
+		hostFS[nodeDir+"/node1/cpulist"] = &fstest.MapFile{Data: []byte("2-3,6-7\n")}
+		hostFS[nodeDir+"/node0/cpulist"] = &fstest.MapFile{Data: []byte("0-1,4-5\n")}
+		hostFS[nodeDir+"/possible"] = &fstest.MapFile{Data: []byte("0-1\n")}
+
+		Expect(getCPUTopology(hostFS)).To(Equal(&infrastructurev1beta1.CPUTopology{
+			Sockets: 2,
+			Cores:   4,
+			CPUs:    8,
+			NUMANodes: []infrastructurev1beta1.NUMANode{
+				{ID: 0, CPUs: "0-1,4-5"},
+				{ID: 1, CPUs: "2-3,6-7"},
+			},
+		}))
+	})
+
+	It("Should fail without sysfs", func() {
+		_, err := getGPUs(hostFS)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,65 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package registration
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// HostIdentity is the source of the identity the host is registered with
+type HostIdentity string
+
+const (
+	// HostIdentityHostname registers the host under its hostname
+	HostIdentityHostname HostIdentity = "hostname"
+	// HostIdentityMachineID registers the host under the hash of its /etc/machine-id
+	HostIdentityMachineID HostIdentity = "machine-id"
+	// HostIdentitySMBIOSUUID registers the host under the hash of its SMBIOS system UUID, which
+	// differs on the virtual machines cloned without resetting their machine-id
+	HostIdentitySMBIOSUUID HostIdentity = "smbios-uuid"
+
+	// machineIDNamePrefix prefixes the names of the ByoHosts registered under their machine identity
+	machineIDNamePrefix = "byoh-"
+	// machineIDNameLength is the number of hex digits of the machine identity in the name of the ByoHost
+	machineIDNameLength = 16
+)
+
+// hostIdentityFiles are the files holding the machine identity of each source
+var hostIdentityFiles = map[HostIdentity]string{
+	HostIdentityMachineID:  "/etc/machine-id",
+	HostIdentitySMBIOSUUID: "/sys/class/dmi/id/product_uuid",
+}
+
+// machineIDKey keys the hash of the machine identity, so that the ByoHosts do not disclose the
+// machine-id of the hosts, as recommended by machine-id(5)
+var machineIDKey = []byte("cluster-api-provider-bringyourownhost")
+
+// MachineID returns the hashed machine identity of the host from the source, read with readFile
+func MachineID(source HostIdentity, readFile func(string) ([]byte, error)) (string, error) {
+	path, ok := hostIdentityFiles[source]
+	if !ok {
+		return "", fmt.Errorf("host identity %q has no machine identity", source)
+	}
+	content, err := readFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the machine identity: %w", err)
+	}
+	id := strings.ToLower(strings.TrimSpace(string(content)))
+	if id == "" {
+		return "", fmt.Errorf("the machine identity in %s is empty", path)
+	}
+	mac := hmac.New(sha256.New, machineIDKey)
+	mac.Write([]byte(id))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// MachineIDHostName returns the name of the ByoHost of a host registered under its machine identity,
+// kept when the host is renamed
+func MachineIDHostName(machineID string) string {
+	return machineIDNamePrefix + machineID[:machineIDNameLength]
+}
@@ -0,0 +1,141 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package registration
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// HostNameVariableHostname is replaced by the short hostname of the host
+	HostNameVariableHostname = "hostname"
+	// HostNameVariableSerial is replaced by the SMBIOS serial number of the host, reading it needs root
+	HostNameVariableSerial = "serial"
+
+	// maxHostNameSuffix is the highest suffix tried when the name of the ByoHost is taken by other hosts
+	maxHostNameSuffix = 10
+
+	// serialNumberFile holds the SMBIOS serial number of the host
+	serialNumberFile = "/sys/class/dmi/id/product_serial"
+)
+
+var (
+	// hostNameVariable matches the variables of the name templates, e.g. {hostname}
+	hostNameVariable = regexp.MustCompile(`\{([^{}]*)\}`)
+	// invalidHostNameChars matches the characters not allowed in the name of a ByoHost
+	invalidHostNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+)
+
+// HostNameVariables are the values of the variables of the name templates
+type HostNameVariables struct {
+	// Hostname is the hostname of the host, shortened to its first label
+	Hostname string
+	// Labels are the labels of the agent, each is a variable named after the label, or after
+	// its name without the prefix, e.g. {site} for topology.byoh/site
+	Labels map[string]string
+	// ReadFile reads the serial number of the host
+	ReadFile func(string) ([]byte, error)
+}
+
+// lookup returns the value of the variable
+func (v HostNameVariables) lookup(variable string) (string, error) {
+	switch variable {
+	case HostNameVariableHostname:
+		return strings.SplitN(v.Hostname, ".", 2)[0], nil
+	case HostNameVariableSerial:
+		content, err := v.ReadFile(serialNumberFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the serial number: %w", err)
+		}
+		serial := strings.TrimSpace(string(content))
+		if serial == "" {
+			return "", fmt.Errorf("the serial number in %s is empty", serialNumberFile)
+		}
+		return serial, nil
+	}
+	if value, ok := v.Labels[variable]; ok {
+		return value, nil
+	}
+	for key, value := range v.Labels {
+		if i := strings.LastIndex(key, "/"); i >= 0 && key[i+1:] == variable {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("unknown variable {%s}, neither hostname, serial nor a label of the agent", variable)
+}
+
+// RenderHostName returns the name of the ByoHost from the template, e.g. "{site}-node-{hostname}", with the
+// variables replaced by their value, and the prefix prepended. The name is lowercased, and the characters
+// not allowed in the name of a ByoHost are replaced with dashes.
+func RenderHostName(template, prefix string, variables HostNameVariables) (string, error) {
+	if template == "" {
+		template = "{" + HostNameVariableHostname + "}"
+	}
+	var err error
+	name := hostNameVariable.ReplaceAllStringFunc(template, func(match string) string {
+		value, lookupErr := variables.lookup(strings.TrimSpace(match[1 : len(match)-1]))
+		if lookupErr != nil && err == nil {
+			err = lookupErr
+		}
+		return value
+	})
+	if err != nil {
+		return "", err
+	}
+	name = invalidHostNameChars.ReplaceAllString(strings.ToLower(prefix+name), "-")
+	name = strings.Trim(name, "-.")
+	if name == "" {
+		return "", fmt.Errorf("the name template %q renders an empty name", template)
+	}
+	return name, nil
+}
+
+// ResolveHostName returns the name the host is registered under: the given name, unless its ByoHost is
+// owned by another host, in which case the name is suffixed with -2, -3, ... The suffixed ByoHost of the
+// host is found again on its next starts.
+func ResolveHostName(ctx context.Context, k8sClient client.Client, hostName, namespace, hostUID string) (string, error) {
+	owner, found, err := hostNameOwner(ctx, k8sClient, hostName, namespace)
+	if err != nil || !found || owner == "" || owner == hostUID {
+		return hostName, err
+	}
+
+	freeName := ""
+	for suffix := 2; suffix <= maxHostNameSuffix; suffix++ {
+		name := fmt.Sprintf("%s-%d", hostName, suffix)
+		owner, found, err := hostNameOwner(ctx, k8sClient, name, namespace)
+		switch {
+		case err != nil:
+			return "", err
+		case found && owner == hostUID:
+			return name, nil
+		case !found && freeName == "":
+			freeName = name
+		}
+	}
+	if freeName == "" {
+		return "", fmt.Errorf("the names %s to %s-%d are owned by other hosts", hostName, hostName, maxHostNameSuffix)
+	}
+	return freeName, nil
+}
+
+// hostNameOwner returns the identity of the host owning the ByoHost of the name, empty when the ByoHost
+// can be claimed, and whether the ByoHost was found
+func hostNameOwner(ctx context.Context, k8sClient client.Client, name, namespace string) (string, bool, error) {
+	byoHost := &infrastructurev1beta1.ByoHost{}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, byoHost); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return byoHost.Annotations[infrastructurev1beta1.HostUIDAnnotation], true, nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// nolint: testpackage
+package registration
+
+import (
+	"context"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Host name", func() {
+	Context("When the name is rendered from a template", func() {
+		var variables HostNameVariables
+
+		BeforeEach(func() {
+			variables = HostNameVariables{
+				Hostname: "Node-ABC.store.example.com",
+				Labels:   map[string]string{"site": "store42", "topology.byoh/rack": "r1"},
+				ReadFile: func(path string) ([]byte, error) {
+					if path != serialNumberFile {
+						return nil, os.ErrNotExist
+					}
+					return []byte("CZ 1234 XY\n"), nil
+				},
+			}
+		})
+
+		It("Should replace the variables with the hostname, the serial and the labels", func() {
+			Expect(RenderHostName("{site}-node-{hostname}", "", variables)).To(Equal("store42-node-node-abc"))
+			Expect(RenderHostName("{rack}-{serial}", "", variables)).To(Equal("r1-cz-1234-xy"))
+			Expect(RenderHostName("{topology.byoh/rack}-{hostname}", "", variables)).To(Equal("r1-node-abc"))
+		})
+
+		It("Should prepend the prefix to the template or the hostname", func() {
+			Expect(RenderHostName("{hostname}", "edge-", variables)).To(Equal("edge-node-abc"))
+			Expect(RenderHostName("", "Store42_", variables)).To(Equal("store42-node-abc"))
+		})
+
+		It("Should fail with an unknown variable", func() {
+			_, err := RenderHostName("{region}-{hostname}", "", variables)
+			Expect(err).To(MatchError("unknown variable {region}, neither hostname, serial nor a label of the agent"))
+		})
+
+		It("Should fail without a serial number", func() {
+			variables.ReadFile = func(string) ([]byte, error) { return []byte(" \n"), nil }
+			_, err := RenderHostName("{serial}", "", variables)
+			Expect(err).To(MatchError("the serial number in /sys/class/dmi/id/product_serial is empty"))
+		})
+	})
+
+	Context("When the name is taken by another host", func() {
+		var k8sClient client.Client
+
+		byoHost := func(name, hostUID string) client.Object {
+			return &infrastructurev1beta1.ByoHost{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default",
+				Annotations: map[string]string{infrastructurev1beta1.HostUIDAnnotation: hostUID}}}
+		}
+
+		BeforeEach(func() {
+			scheme := runtime.NewScheme()
+			Expect(infrastructurev1beta1.AddToScheme(scheme)).To(Succeed())
+			k8sClient = fake.NewClientBuilder().WithScheme(scheme).
+				WithObjects(byoHost("store42-node", "uid-1"), byoHost("store42-node-2", "uid-2")).Build()
+		})
+
+		It("Should keep the name of a new host or of its own ByoHost", func() {
+			Expect(ResolveHostName(context.TODO(), k8sClient, "store43-node", "default", "uid-3")).To(Equal("store43-node"))
+			Expect(ResolveHostName(context.TODO(), k8sClient, "store42-node", "default", "uid-1")).To(Equal("store42-node"))
+		})
+
+		It("Should suffix the name with the first free suffix", func() {
+			Expect(ResolveHostName(context.TODO(), k8sClient, "store42-node", "default", "uid-3")).To(Equal("store42-node-3"))
+		})
+
+		It("Should find the suffixed ByoHost of the host again", func() {
+			Expect(ResolveHostName(context.TODO(), k8sClient, "store42-node", "default", "uid-2")).To(Equal("store42-node-2"))
+		})
+	})
+})
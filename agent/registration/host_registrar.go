@@ -11,10 +11,11 @@ import (
 	"os"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 
-	"github.com/jackpal/gateway"
 	"github.com/pkg/errors"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/version"
 	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -29,6 +30,9 @@ var (
 	LocalHostRegistrar *HostRegistrar
 )
 
+// ipv6DefaultDestination is the destination of the IPv6 default route in /proc/net/ipv6_route
+var ipv6DefaultDestination = strings.Repeat("0", 32)
+
 // HostInfo contains information about the host network interface.
 type HostInfo struct {
 	DefaultNetworkInterfaceName string
@@ -38,6 +42,15 @@ type HostInfo struct {
 type HostRegistrar struct {
 	K8sClient   client.Client
 	ByoHostInfo HostInfo
+	// DefaultNetworkInterface overrides the interface of the default route as the default network interface
+	DefaultNetworkInterface string
+	// HostUID is the unique identity of the host, recorded in the HostUIDAnnotation of its ByoHost so that
+	// another host with the same name cannot take it over. The ByoHost is not claimed when empty.
+	HostUID string
+	// MachineID is the hashed machine identity the ByoHost is named after, empty when it is named after the hostname
+	MachineID string
+	// AgentInfo is the build and the configuration of the agent reported in the ByoHost status, kept when not set
+	AgentInfo *infrastructurev1beta1.AgentInfo
 }
 
 // Register is called on agent startup
@@ -61,11 +74,14 @@ func (hr *HostRegistrar) Register(hostName, namespace string, hostLabels map[str
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      hostName,
 				Namespace: namespace,
-				Labels:    hostLabels,
 			},
 			Spec:   infrastructurev1beta1.ByoHostSpec{},
 			Status: infrastructurev1beta1.ByoHostStatus{},
 		}
+		SyncLabels(byoHost, hostLabels)
+		if hr.HostUID != "" {
+			metav1.SetMetaDataAnnotation(&byoHost.ObjectMeta, infrastructurev1beta1.HostUIDAnnotation, hr.HostUID)
+		}
 		err = hr.K8sClient.Create(ctx, byoHost)
 		if err != nil {
 			klog.Errorf("error creating host %s in namespace %s, err=%v", hostName, namespace, err)
@@ -73,8 +89,119 @@ func (hr *HostRegistrar) Register(hostName, namespace string, hostLabels map[str
 		}
 	}
 
+	if err := hr.claimHost(ctx, byoHost); err != nil {
+		return err
+	}
+	if err := hr.bindExpectedHost(ctx, byoHost); err != nil {
+		return err
+	}
+
 	// run it at startup or reboot
-	return hr.UpdateHost(ctx, byoHost)
+	if err := hr.UpdateHost(ctx, byoHost); err != nil {
+		return err
+	}
+	// the labels of the agent may have changed since the host was registered
+	return hr.UpdateLabels(ctx, hostName, namespace, hostLabels)
+}
+
+// claimHost records the identity of the host on its ByoHost, registered before the host had one,
+// and fails when the ByoHost is owned by another host with the same name
+func (hr *HostRegistrar) claimHost(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost) error {
+	if hr.HostUID == "" {
+		return nil
+	}
+	switch hostUID := byoHost.Annotations[infrastructurev1beta1.HostUIDAnnotation]; hostUID {
+	case hr.HostUID:
+		return nil
+	case "":
+	default:
+		return fmt.Errorf("ByoHost %s in namespace %s is owned by another host with the same name, set --hostname-override to register this host under another name",
+			byoHost.Name, byoHost.Namespace)
+	}
+
+	helper, err := patch.NewHelper(byoHost, hr.K8sClient)
+	if err != nil {
+		return err
+	}
+	metav1.SetMetaDataAnnotation(&byoHost.ObjectMeta, infrastructurev1beta1.HostUIDAnnotation, hr.HostUID)
+	return helper.Patch(ctx, byoHost)
+}
+
+// bindExpectedHost binds the ByoHost imported from a host inventory to the host, removing its
+// ExpectedHostLabel. The labels and annotations of the inventory are kept.
+func (hr *HostRegistrar) bindExpectedHost(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost) error {
+	if _, ok := byoHost.Labels[infrastructurev1beta1.ExpectedHostLabel]; !ok {
+		return nil
+	}
+	helper, err := patch.NewHelper(byoHost, hr.K8sClient)
+	if err != nil {
+		return err
+	}
+	delete(byoHost.Labels, infrastructurev1beta1.ExpectedHostLabel)
+	if err := helper.Patch(ctx, byoHost); err != nil {
+		return err
+	}
+	klog.Infof("Bound the host to the ByoHost %s expected from the host inventory", byoHost.Name)
+	return nil
+}
+
+// UpdateLabels syncs the labels of the agent to its ByoHost, see SyncLabels
+func (hr *HostRegistrar) UpdateLabels(ctx context.Context, hostName, namespace string, hostLabels map[string]string) error {
+	byoHost := &infrastructurev1beta1.ByoHost{}
+	if err := hr.K8sClient.Get(ctx, types.NamespacedName{Name: hostName, Namespace: namespace}, byoHost); err != nil {
+		return err
+	}
+	helper, err := patch.NewHelper(byoHost, hr.K8sClient)
+	if err != nil {
+		return err
+	}
+	if conflicts := SyncLabels(byoHost, hostLabels); len(conflicts) > 0 {
+		klog.Infof("labels %v are already set on ByoHost %s with other values, not overriding them", conflicts, hostName)
+	}
+	return helper.Patch(ctx, byoHost)
+}
+
+// SyncLabels applies the labels of the agent to the ByoHost, and removes the labels the agent set
+// before which are not desired anymore. The keys of the labels owned by the agent are recorded in the
+// AgentLabelsAnnotation, so that the labels applied by the operators are never removed. A label
+// already set by someone else with another value is not overridden, its key is returned as a conflict.
+func SyncLabels(byoHost *infrastructurev1beta1.ByoHost, hostLabels map[string]string) (conflicts []string) {
+	owned := map[string]bool{}
+	for _, key := range strings.Split(byoHost.Annotations[infrastructurev1beta1.AgentLabelsAnnotation], ",") {
+		if key != "" {
+			owned[key] = true
+		}
+	}
+
+	if byoHost.Labels == nil {
+		byoHost.Labels = map[string]string{}
+	}
+	for key := range owned {
+		if _, ok := hostLabels[key]; !ok {
+			delete(byoHost.Labels, key)
+		}
+	}
+	ownedKeys := make([]string, 0, len(hostLabels))
+	for key, value := range hostLabels {
+		if current, ok := byoHost.Labels[key]; ok && !owned[key] && current != value {
+			conflicts = append(conflicts, key)
+			continue
+		}
+		byoHost.Labels[key] = value
+		ownedKeys = append(ownedKeys, key)
+	}
+	sort.Strings(ownedKeys)
+	sort.Strings(conflicts)
+
+	if len(ownedKeys) == 0 {
+		delete(byoHost.Annotations, infrastructurev1beta1.AgentLabelsAnnotation)
+		return conflicts
+	}
+	if byoHost.Annotations == nil {
+		byoHost.Annotations = map[string]string{}
+	}
+	byoHost.Annotations[infrastructurev1beta1.AgentLabelsAnnotation] = strings.Join(ownedKeys, ",")
+	return conflicts
 }
 
 // UpdateHost updates the network interface and host platform details status for the host
@@ -92,6 +219,15 @@ func (hr *HostRegistrar) UpdateHost(ctx context.Context, byoHost *infrastructure
 		return err
 	}
 
+	byoHost.Status.AgentVersion = version.Get().GitVersion
+	if hr.AgentInfo != nil {
+		byoHost.Status.AgentInfo = hr.AgentInfo
+	}
+	byoHost.Status.MachineID = hr.MachineID
+	if hostName, err := os.Hostname(); err == nil {
+		byoHost.Status.Hostname = hostName
+	}
+
 	return helper.Patch(ctx, byoHost)
 }
 
@@ -99,14 +235,18 @@ func (hr *HostRegistrar) UpdateHost(ctx context.Context, byoHost *infrastructure
 func (hr *HostRegistrar) GetNetworkStatus() []infrastructurev1beta1.NetworkStatus {
 	Network := make([]infrastructurev1beta1.NetworkStatus, 0)
 
-	defaultIP, err := gateway.DiscoverInterface()
+	ifaces, err := net.Interfaces()
 	if err != nil {
 		return Network
 	}
 
-	ifaces, err := net.Interfaces()
-	if err != nil {
-		return Network
+	defaultIPv4Iface, defaultIPv6Iface := getDefaultRouteInterfaces(ioutil.ReadFile)
+	defaultIface := hr.DefaultNetworkInterface
+	if defaultIface == "" {
+		defaultIface = defaultIPv4Iface
+	}
+	if defaultIface == "" {
+		defaultIface = defaultIPv6Iface
 	}
 
 	for _, iface := range ifaces {
@@ -117,12 +257,19 @@ func (hr *HostRegistrar) GetNetworkStatus() []infrastructurev1beta1.NetworkStatu
 		}
 
 		netStatus.MACAddr = iface.HardwareAddr.String()
+		netStatus.MTU = iface.MTU
 		addrs, err := iface.Addrs()
 		if err != nil {
 			continue
 		}
 
 		netStatus.NetworkInterfaceName = iface.Name
+		netStatus.IsDefaultIPv4 = iface.Name == defaultIPv4Iface
+		netStatus.IsDefaultIPv6 = iface.Name == defaultIPv6Iface
+		if iface.Name == defaultIface {
+			netStatus.IsDefault = true
+			hr.ByoHostInfo.DefaultNetworkInterfaceName = netStatus.NetworkInterfaceName
+		}
 		for _, addr := range addrs {
 			var ip net.IP
 			switch v := addr.(type) {
@@ -131,17 +278,48 @@ func (hr *HostRegistrar) GetNetworkStatus() []infrastructurev1beta1.NetworkStatu
 			case *net.IPAddr:
 				ip = v.IP
 			}
-			if ip.String() == defaultIP.String() {
-				netStatus.IsDefault = true
-				hr.ByoHostInfo.DefaultNetworkInterfaceName = netStatus.NetworkInterfaceName
+			if ip.To4() != nil {
+				netStatus.IPv4Addrs = append(netStatus.IPv4Addrs, addr.String())
+			} else {
+				netStatus.IPv6Addrs = append(netStatus.IPv6Addrs, addr.String())
 			}
 			netStatus.IPAddrs = append(netStatus.IPAddrs, addr.String())
 		}
 		Network = append(Network, netStatus)
 	}
+
+	if hr.DefaultNetworkInterface != "" && hr.ByoHostInfo.DefaultNetworkInterfaceName != hr.DefaultNetworkInterface {
+		klog.Warningf("default network interface %s not found", hr.DefaultNetworkInterface)
+	}
 	return Network
 }
 
+// getDefaultRouteInterfaces returns the names of the interfaces the IPv4 and IPv6 default routes go through
+func getDefaultRouteInterfaces(f func(string) ([]byte, error)) (ipv4Iface, ipv6Iface string) {
+	// Iface Destination Gateway Flags RefCnt Use Metric Mask MTU Window IRTT
+	if bytes, err := f("/proc/net/route"); err == nil {
+		for _, line := range strings.Split(string(bytes), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) >= 8 && fields[1] == "00000000" && fields[7] == "00000000" {
+				ipv4Iface = fields[0]
+				break
+			}
+		}
+	}
+
+	// Destination DestPrefixLen Source SourcePrefixLen NextHop Metric RefCnt Use Flags Iface
+	if bytes, err := f("/proc/net/ipv6_route"); err == nil {
+		for _, line := range strings.Split(string(bytes), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) >= 10 && fields[0] == ipv6DefaultDestination && fields[1] == "00" && fields[9] != "lo" {
+				ipv6Iface = fields[9]
+				break
+			}
+		}
+	}
+	return ipv4Iface, ipv6Iface
+}
+
 // getHostInfo gets the host platform details.
 func (hr *HostRegistrar) getHostInfo() (infrastructurev1beta1.HostInfo, error) {
 	hostInfo := infrastructurev1beta1.HostInfo{}
@@ -154,6 +332,22 @@ func (hr *HostRegistrar) getHostInfo() (infrastructurev1beta1.HostInfo, error) {
 	} else {
 		hostInfo.OSImage = distribution
 	}
+
+	// the devices are reported on a best effort basis, e.g. sysfs is only mounted on Linux
+	hostFS := os.DirFS("/")
+	var err error
+	if hostInfo.GPUs, err = getGPUs(hostFS); err != nil {
+		klog.Warningf("failed to scan the PCI devices for GPUs: %v", err)
+	}
+	if hostInfo.HugePages, err = getHugePages(hostFS); err != nil {
+		klog.Warningf("failed to get the huge pages: %v", err)
+	}
+	if hostInfo.SRIOVNetworkInterfaces, err = getSRIOVNetworkInterfaces(hostFS); err != nil {
+		klog.Warningf("failed to get the SR-IOV network interfaces: %v", err)
+	}
+	if hostInfo.CPUTopology, err = getCPUTopology(hostFS); err != nil {
+		klog.Warningf("failed to get the CPU topology: %v", err)
+	}
 	return hostInfo, nil
 }
 
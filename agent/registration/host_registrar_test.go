@@ -5,11 +5,19 @@
 package registration
 
 import (
+	"context"
 	"fmt"
-	. "github.com/onsi/ginkgo"
-	. "github.com/onsi/gomega"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func getMockFile(targetOs string) ([]byte, error) {
@@ -71,4 +79,168 @@ var _ = Describe("Host Registrar Tests", func() {
 			Expect(detectedOS).To(Equal("Unknown"))
 		})
 	})
+
+	Context("When the default routes are detected", func() {
+		var routes = map[string]string{
+			"/proc/net/route": `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+bond0	0000A8C0	00000000	0001	0	0	0	00FFFFFF	0	0	0
+bond0	00000000	0100A8C0	0003	0	0	100	00000000	0	0	0`,
+			"/proc/net/ipv6_route": `fd000000000000000000000000000000 40 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000001 00000000 00000001     eth1
+00000000000000000000000000000000 00 00000000000000000000000000000000 00 fe800000000000000000000000000001 00000400 00000001 00000000 00000003     eth1
+00000000000000000000000000000000 00 00000000000000000000000000000000 00 00000000000000000000000000000000 ffffffff 00000001 00000000 00200200       lo`,
+		}
+
+		It("Should return the interface of the default route per IP family", func() {
+			ipv4Iface, ipv6Iface := getDefaultRouteInterfaces(func(path string) ([]byte, error) {
+				return []byte(routes[path]), nil
+			})
+			Expect(ipv4Iface).To(Equal("bond0"))
+			Expect(ipv6Iface).To(Equal("eth1"))
+		})
+
+		It("Should return no interface if the routes are not readable", func() {
+			ipv4Iface, ipv6Iface := getDefaultRouteInterfaces(func(string) ([]byte, error) {
+				return nil, os.ErrNotExist
+			})
+			Expect(ipv4Iface).To(BeEmpty())
+			Expect(ipv6Iface).To(BeEmpty())
+		})
+	})
+
+	Context("When the labels of the agent are synced", func() {
+		var byoHost *infrastructurev1beta1.ByoHost
+
+		BeforeEach(func() {
+			byoHost = &infrastructurev1beta1.ByoHost{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "host",
+					Namespace:   "default",
+					Labels:      map[string]string{"site": "apac", "rack": "r1", "owner": "ops"},
+					Annotations: map[string]string{infrastructurev1beta1.AgentLabelsAnnotation: "rack,site"},
+				},
+			}
+		})
+
+		It("Should replace the labels previously set by the agent", func() {
+			scheme := runtime.NewScheme()
+			Expect(infrastructurev1beta1.AddToScheme(scheme)).To(Succeed())
+			hr := &HostRegistrar{K8sClient: fake.NewClientBuilder().WithScheme(scheme).WithObjects(byoHost).Build()}
+
+			Expect(hr.UpdateLabels(context.TODO(), "host", "default", map[string]string{"site": "emea", "zone": "z1"})).To(Succeed())
+
+			updatedByoHost := &infrastructurev1beta1.ByoHost{}
+			Expect(hr.K8sClient.Get(context.TODO(), types.NamespacedName{Name: "host", Namespace: "default"}, updatedByoHost)).To(Succeed())
+			Expect(updatedByoHost.Labels).To(Equal(map[string]string{"site": "emea", "zone": "z1", "owner": "ops"}))
+			Expect(updatedByoHost.Annotations).To(HaveKeyWithValue(infrastructurev1beta1.AgentLabelsAnnotation, "site,zone"))
+		})
+
+		It("Should not override the labels set by the operators", func() {
+			conflicts := SyncLabels(byoHost, map[string]string{"site": "apac", "owner": "dev"})
+			Expect(conflicts).To(Equal([]string{"owner"}))
+			Expect(byoHost.Labels).To(Equal(map[string]string{"site": "apac", "owner": "ops"}))
+			Expect(byoHost.Annotations).To(HaveKeyWithValue(infrastructurev1beta1.AgentLabelsAnnotation, "site"))
+		})
+
+		It("Should take the ownership of the labels set with the same value", func() {
+			Expect(SyncLabels(byoHost, map[string]string{"owner": "ops"})).To(BeEmpty())
+			Expect(byoHost.Labels).To(Equal(map[string]string{"owner": "ops"}))
+			Expect(byoHost.Annotations).To(HaveKeyWithValue(infrastructurev1beta1.AgentLabelsAnnotation, "owner"))
+		})
+
+		It("Should remove the ownership annotation once the agent has no label", func() {
+			Expect(SyncLabels(byoHost, nil)).To(BeEmpty())
+			Expect(byoHost.Labels).To(Equal(map[string]string{"owner": "ops"}))
+			Expect(byoHost.Annotations).NotTo(HaveKey(infrastructurev1beta1.AgentLabelsAnnotation))
+		})
+	})
+
+	Context("When the host has an identity", func() {
+		var (
+			hr      *HostRegistrar
+			byoHost *infrastructurev1beta1.ByoHost
+		)
+
+		BeforeEach(func() {
+			byoHost = &infrastructurev1beta1.ByoHost{ObjectMeta: metav1.ObjectMeta{Name: "host", Namespace: "default"}}
+			scheme := runtime.NewScheme()
+			Expect(infrastructurev1beta1.AddToScheme(scheme)).To(Succeed())
+			hr = &HostRegistrar{K8sClient: fake.NewClientBuilder().WithScheme(scheme).WithObjects(byoHost).Build(), HostUID: "uid-1"}
+		})
+
+		It("Should claim the ByoHost registered before the host had an identity", func() {
+			Expect(hr.claimHost(context.TODO(), byoHost)).To(Succeed())
+
+			updatedByoHost := &infrastructurev1beta1.ByoHost{}
+			Expect(hr.K8sClient.Get(context.TODO(), types.NamespacedName{Name: "host", Namespace: "default"}, updatedByoHost)).To(Succeed())
+			Expect(updatedByoHost.Annotations).To(HaveKeyWithValue(infrastructurev1beta1.HostUIDAnnotation, "uid-1"))
+			Expect(hr.claimHost(context.TODO(), updatedByoHost)).To(Succeed())
+		})
+
+		It("Should fail to claim the ByoHost of another host with the same name", func() {
+			byoHost.Annotations = map[string]string{infrastructurev1beta1.HostUIDAnnotation: "uid-2"}
+			Expect(hr.claimHost(context.TODO(), byoHost)).To(MatchError(
+				"ByoHost host in namespace default is owned by another host with the same name, set --hostname-override to register this host under another name"))
+		})
+
+		It("Should bind the ByoHost expected from the host inventory", func() {
+			byoHost.Labels = map[string]string{infrastructurev1beta1.ExpectedHostLabel: "", "site": "edge-1"}
+			Expect(hr.K8sClient.Update(context.TODO(), byoHost)).To(Succeed())
+			Expect(hr.claimHost(context.TODO(), byoHost)).To(Succeed())
+			Expect(hr.bindExpectedHost(context.TODO(), byoHost)).To(Succeed())
+
+			updatedByoHost := &infrastructurev1beta1.ByoHost{}
+			Expect(hr.K8sClient.Get(context.TODO(), types.NamespacedName{Name: "host", Namespace: "default"}, updatedByoHost)).To(Succeed())
+			Expect(updatedByoHost.Labels).To(Equal(map[string]string{"site": "edge-1"}))
+			Expect(updatedByoHost.Annotations).To(HaveKeyWithValue(infrastructurev1beta1.HostUIDAnnotation, "uid-1"))
+		})
+
+		It("Should keep the identity of the host across the restarts of the agent", func() {
+			dir, err := os.MkdirTemp("", "hostUID")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+			path := filepath.Join(dir, ".byoh", "host-uid")
+
+			hostUID, err := LoadHostUID(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hostUID).NotTo(BeEmpty())
+			info, err := os.Stat(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+			Expect(LoadHostUID(path)).To(Equal(hostUID))
+		})
+	})
+
+	Context("When the host is registered under its machine identity", func() {
+		readIdentity := func(content string) func(string) ([]byte, error) {
+			return func(path string) ([]byte, error) {
+				if path != hostIdentityFiles[HostIdentityMachineID] {
+					return nil, os.ErrNotExist
+				}
+				return []byte(content), nil
+			}
+		}
+
+		It("Should return the hashed machine identity", func() {
+			machineID, err := MachineID(HostIdentityMachineID, readIdentity("0123456789abcdef0123456789abcdef\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(machineID).To(HaveLen(64))
+			Expect(machineID).NotTo(ContainSubstring("0123456789abcdef"))
+
+			sameMachineID, err := MachineID(HostIdentityMachineID, readIdentity("0123456789ABCDEF0123456789ABCDEF"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sameMachineID).To(Equal(machineID))
+
+			Expect(MachineIDHostName(machineID)).To(Equal("byoh-" + machineID[:16]))
+		})
+
+		It("Should fail without a machine identity", func() {
+			_, err := MachineID(HostIdentityMachineID, readIdentity(" \n"))
+			Expect(err).To(MatchError("the machine identity in /etc/machine-id is empty"))
+			_, err = MachineID(HostIdentitySMBIOSUUID, readIdentity("uuid"))
+			Expect(err).To(HaveOccurred())
+			_, err = MachineID(HostIdentityHostname, readIdentity("uuid"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
 })
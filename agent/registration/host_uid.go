@@ -0,0 +1,35 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package registration
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/uuid"
+)
+
+// LoadHostUID returns the unique identity of the host stored in the file, generating it on the
+// first start of the agent. The identity is kept across the restarts of the agent and the
+// renames of the host, a reimaged host gets a new one.
+func LoadHostUID(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err == nil {
+		if hostUID := strings.TrimSpace(string(content)); hostUID != "" {
+			return hostUID, nil
+		}
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	hostUID := string(uuid.NewUUID())
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(hostUID+"\n"), 0600); err != nil {
+		return "", err
+	}
+	return hostUID, nil
+}
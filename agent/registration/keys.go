@@ -0,0 +1,122 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package registration
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/fips"
+	"k8s.io/client-go/util/keyutil"
+)
+
+// KeyType is the algorithm and size of the private key of the host client certificate
+type KeyType string
+
+const (
+	// KeyTypeECDSAP256 is an ECDSA key on the P-256 curve, the default key type
+	KeyTypeECDSAP256 KeyType = "ECDSA-P256"
+	// KeyTypeECDSAP384 is an ECDSA key on the P-384 curve
+	KeyTypeECDSAP384 KeyType = "ECDSA-P384"
+	// KeyTypeRSA2048 is an RSA key of 2048 bits
+	KeyTypeRSA2048 KeyType = "RSA-2048"
+	// KeyTypeRSA3072 is an RSA key of 3072 bits
+	KeyTypeRSA3072 KeyType = "RSA-3072"
+	// KeyTypeRSA4096 is an RSA key of 4096 bits
+	KeyTypeRSA4096 KeyType = "RSA-4096"
+)
+
+// KeyTypes are the supported key types, all of them are FIPS approved
+var KeyTypes = []KeyType{KeyTypeECDSAP256, KeyTypeECDSAP384, KeyTypeRSA2048, KeyTypeRSA3072, KeyTypeRSA4096}
+
+// ParseKeyType returns the key type of its name, KeyTypeECDSAP256 when empty
+func ParseKeyType(name string) (KeyType, error) {
+	if name == "" {
+		return KeyTypeECDSAP256, nil
+	}
+	for _, keyType := range KeyTypes {
+		if strings.EqualFold(name, string(keyType)) {
+			return keyType, nil
+		}
+	}
+	names := make([]string, 0, len(KeyTypes))
+	for _, keyType := range KeyTypes {
+		names = append(names, string(keyType))
+	}
+	return "", fmt.Errorf("unsupported key type %q, must be one of %s", name, strings.Join(names, ", "))
+}
+
+// generateKey generates a private key of the key type
+func generateKey(keyType KeyType) (crypto.PrivateKey, error) {
+	switch keyType {
+	case KeyTypeECDSAP256, "":
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case KeyTypeECDSAP384:
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case KeyTypeRSA2048:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case KeyTypeRSA3072:
+		return rsa.GenerateKey(rand.Reader, 3072)
+	case KeyTypeRSA4096:
+		return rsa.GenerateKey(rand.Reader, 4096)
+	}
+	return nil, fmt.Errorf("unsupported key type %q", keyType)
+}
+
+// loadOrGenerateKeyFile loads the PEM encoded private key at keyPath, or generates a key of the
+// key type and writes it there with 0600 permissions, encrypted by the sealer unless it is nil.
+// A loaded key readable by the group or the others is rejected, and so is a key which is not
+// approved in FIPS mode.
+func loadOrGenerateKeyFile(keyPath string, keyType KeyType, sealer Sealer) ([]byte, crypto.PrivateKey, error) {
+	keyData, err := os.ReadFile(keyPath)
+	switch {
+	case err == nil:
+		info, err := os.Stat(keyPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error loading key from %s: %v", keyPath, err)
+		}
+		if perm := info.Mode().Perm(); perm&0o077 != 0 {
+			return nil, nil, fmt.Errorf("private key %s is accessible by other users, its permissions %#o must be 0600", keyPath, perm)
+		}
+		if keyData, err = unsealFile(keyPath, keyData, sealer); err != nil {
+			return nil, nil, err
+		}
+		key, err := keyutil.ParsePrivateKeyPEM(keyData)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid private key for certificate request: %v", err)
+		}
+		if fips.Enabled() {
+			if err := fips.CheckKey(key); err != nil {
+				return nil, nil, fmt.Errorf("private key %s rejected in FIPS mode: %w", keyPath, err)
+			}
+		}
+		return keyData, key, nil
+	case !os.IsNotExist(err):
+		return nil, nil, fmt.Errorf("error loading key from %s: %v", keyPath, err)
+	}
+
+	key, err := generateKey(keyType)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error generating key: %v", err)
+	}
+	if keyData, err = keyutil.MarshalPrivateKeyToPEM(key); err != nil {
+		return nil, nil, err
+	}
+	fileData := keyData
+	if sealer != nil {
+		if fileData, err = sealer.Seal(keyData); err != nil {
+			return nil, nil, fmt.Errorf("error encrypting key: %v", err)
+		}
+	}
+	if err := keyutil.WriteKey(keyPath, fileData); err != nil {
+		return nil, nil, fmt.Errorf("error writing key to %s: %v", keyPath, err)
+	}
+	return keyData, key, nil
+}
@@ -0,0 +1,150 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package registration
+
+import (
+	"crypto"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/fips"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/util/cert"
+)
+
+// KeyStoreType is where the private key of the host client certificate is kept
+type KeyStoreType string
+
+const (
+	// KeyStoreFile keeps the private key in a file of the private key directory, the default
+	KeyStoreFile KeyStoreType = "file"
+	// KeyStoreTPM keeps the private key in the TPM of the host
+	KeyStoreTPM KeyStoreType = "tpm"
+	// KeyStorePKCS11 keeps the private key in a PKCS#11 token, e.g. an HSM or a smart card
+	KeyStorePKCS11 KeyStoreType = "pkcs11"
+
+	// ClientCertificateFile is the file of the private key directory the host client certificate
+	// is written to when its private key cannot leave the key store
+	ClientCertificateFile = "byoh-client.crt"
+	// PKCS11PinEnv is the environment variable holding the user PIN of the PKCS#11 token
+	PKCS11PinEnv = "BYOH_PKCS11_PIN"
+)
+
+// KeyStoreConfig configures the key store of the private key of the host client certificate
+type KeyStoreConfig struct {
+	// Type is the key store type, KeyStoreFile when empty
+	Type KeyStoreType
+	// PrivateKeyDir is the directory the private key file and the client certificate are kept in,
+	// created with 0700 permissions
+	PrivateKeyDir string
+	// TPMDevice is the path of the TPM device, /dev/tpmrm0 then /dev/tpm0 when empty
+	TPMDevice string
+	// PKCS11Module is the path of the PKCS#11 module of the token
+	PKCS11Module string
+	// PKCS11TokenLabel is the label of the PKCS#11 token
+	PKCS11TokenLabel string
+	// PKCS11KeyLabel is the label of the key pair in the PKCS#11 token
+	PKCS11KeyLabel string
+	// PKCS11Pin is the user PIN of the PKCS#11 token
+	PKCS11Pin string
+	// Sealer encrypts the private key file at rest, it is kept in plain text when nil
+	Sealer Sealer
+}
+
+// KeyStore keeps the private key of the host client certificate
+type KeyStore interface {
+	// Signer returns the private key, a key of the key type is generated when there is none yet
+	Signer(keyType KeyType) (crypto.Signer, error)
+	// Exportable tells whether the private key can leave the store to be written to the host
+	// kubeconfig. Otherwise, the kubeconfig holds no client credentials, see WithClientCertificate.
+	Exportable() bool
+}
+
+// NewKeyStore returns the key store of the config, its private key directory is created with 0700
+// permissions
+func NewKeyStore(config *KeyStoreConfig) (KeyStore, error) {
+	if config.PrivateKeyDir == "" {
+		return nil, fmt.Errorf("private key directory is not set")
+	}
+	if err := os.MkdirAll(config.PrivateKeyDir, 0o700); err != nil {
+		return nil, fmt.Errorf("error creating private key directory %s: %v", config.PrivateKeyDir, err)
+	}
+	// MkdirAll keeps the permissions of an existing directory
+	if err := os.Chmod(config.PrivateKeyDir, 0o700); err != nil {
+		return nil, err
+	}
+
+	switch config.Type {
+	case KeyStoreFile, "":
+		return &fileKeyStore{keyPath: filepath.Join(config.PrivateKeyDir, TmpPrivateKey), sealer: config.Sealer}, nil
+	case KeyStoreTPM:
+		return &tpmKeyStore{device: config.TPMDevice}, nil
+	case KeyStorePKCS11:
+		return newPKCS11KeyStore(config)
+	}
+	return nil, fmt.Errorf("unsupported key store %q, must be one of %s, %s, %s", config.Type, KeyStoreFile, KeyStoreTPM, KeyStorePKCS11)
+}
+
+// fileKeyStore keeps the private key in a PEM encoded file with 0600 permissions
+type fileKeyStore struct {
+	keyPath string
+	sealer  Sealer
+}
+
+func (s *fileKeyStore) Signer(keyType KeyType) (crypto.Signer, error) {
+	_, key, err := loadOrGenerateKeyFile(s.keyPath, keyType, s.sealer)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("private key %s of type %T cannot sign", s.keyPath, key)
+	}
+	return signer, nil
+}
+
+func (s *fileKeyStore) Exportable() bool {
+	return true
+}
+
+// checkSigner rejects the private key of a hardware-backed store which is not approved in FIPS mode
+func checkSigner(signer crypto.Signer) (crypto.Signer, error) {
+	if fips.Enabled() {
+		if err := fips.CheckPublicKey(signer.Public()); err != nil {
+			return nil, fmt.Errorf("private key rejected in FIPS mode: %w", err)
+		}
+	}
+	return signer, nil
+}
+
+// WithClientCertificate returns a copy of the config authenticating with the client certificate
+// at certPath and its private key held by the signer, e.g. in a TPM or a PKCS#11 token
+func WithClientCertificate(config *restclient.Config, certPath string, signer crypto.Signer) (*restclient.Config, error) {
+	certs, err := cert.CertsFromFile(certPath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading client certificate: %v", err)
+	}
+	tlsConfig, err := restclient.TLSConfigFor(config)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig == nil {
+		return nil, fmt.Errorf("client certificate requires a TLS connection to %s", config.Host)
+	}
+	clientCert := tls.Certificate{PrivateKey: signer, Leaf: certs[0]}
+	for _, c := range certs {
+		clientCert.Certificate = append(clientCert.Certificate, c.Raw)
+	}
+	tlsConfig.Certificates = []tls.Certificate{clientCert}
+
+	// a custom transport cannot be combined with the TLS options, which it now holds
+	config = restclient.CopyConfig(config)
+	config.TLSClientConfig = restclient.TLSClientConfig{}
+	config.Transport = utilnet.SetTransportDefaults(&http.Transport{TLSClientConfig: tlsConfig})
+	return config, nil
+}
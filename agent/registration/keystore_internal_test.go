@@ -0,0 +1,127 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package registration
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	restclient "k8s.io/client-go/rest"
+)
+
+var _ = Describe("Key store", func() {
+	var keyDir string
+
+	BeforeEach(func() {
+		var err error
+		keyDir, err = os.MkdirTemp("", "keys")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(keyDir)).To(Succeed())
+	})
+
+	Context("When the file key store is created", func() {
+		It("should create the private key directory with 0700 permissions", func() {
+			privateKeyDir := filepath.Join(keyDir, "byoh")
+			Expect(os.Mkdir(privateKeyDir, 0o755)).To(Succeed())
+
+			store, err := NewKeyStore(&KeyStoreConfig{PrivateKeyDir: privateKeyDir})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(store.Exportable()).To(BeTrue())
+			info, err := os.Stat(privateKeyDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0o700)))
+		})
+
+		It("should write the private key with 0600 permissions", func() {
+			store, err := NewKeyStore(&KeyStoreConfig{Type: KeyStoreFile, PrivateKeyDir: keyDir})
+			Expect(err).NotTo(HaveOccurred())
+			signer, err := store.Signer(KeyTypeECDSAP384)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(signer.Public().(*ecdsa.PublicKey).Curve).To(Equal(elliptic.P384()))
+
+			info, err := os.Stat(filepath.Join(keyDir, TmpPrivateKey))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0o600)))
+		})
+
+		It("should reject a private key accessible by other users", func() {
+			store, err := NewKeyStore(&KeyStoreConfig{PrivateKeyDir: keyDir})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = store.Signer(KeyTypeECDSAP256)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.Chmod(filepath.Join(keyDir, TmpPrivateKey), 0o644)).To(Succeed())
+
+			_, err = store.Signer(KeyTypeECDSAP256)
+			Expect(err).To(MatchError(ContainSubstring("is accessible by other users, its permissions 0644 must be 0600")))
+		})
+	})
+
+	Context("When another key store is created", func() {
+		It("should return an error for an unsupported key store", func() {
+			_, err := NewKeyStore(&KeyStoreConfig{Type: "vault", PrivateKeyDir: keyDir})
+			Expect(err).To(MatchError(`unsupported key store "vault", must be one of file, tpm, pkcs11`))
+		})
+
+		It("should not be able to export the TPM key", func() {
+			store, err := NewKeyStore(&KeyStoreConfig{Type: KeyStoreTPM, PrivateKeyDir: keyDir})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(store.Exportable()).To(BeFalse())
+		})
+
+		It("should not support the key types of the TPM key store beyond RSA-2048", func() {
+			_, err := tpmKeyTemplate(KeyTypeRSA4096)
+			Expect(err).To(MatchError(`key type "RSA-4096" is not supported by the TPM key store`))
+		})
+	})
+
+	Context("When the client certificate is added to a config", func() {
+		It("should authenticate with the certificate and the signer through the transport", func() {
+			key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).NotTo(HaveOccurred())
+			template := &x509.Certificate{
+				SerialNumber: big.NewInt(1),
+				Subject:      pkix.Name{CommonName: "byoh:host:test-host"},
+				NotBefore:    time.Now(),
+				NotAfter:     time.Now().Add(time.Hour),
+				ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+			}
+			der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+			Expect(err).NotTo(HaveOccurred())
+			certPath := filepath.Join(keyDir, ClientCertificateFile)
+			Expect(os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)).To(Succeed())
+
+			config := &restclient.Config{
+				Host:            "https://127.0.0.1:6443",
+				TLSClientConfig: restclient.TLSClientConfig{Insecure: true},
+			}
+			withCert, err := WithClientCertificate(config, certPath, key)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Transport).To(BeNil())
+			Expect(withCert.TLSClientConfig).To(Equal(restclient.TLSClientConfig{}))
+
+			tlsConfig := withCert.Transport.(*http.Transport).TLSClientConfig
+			Expect(tlsConfig.InsecureSkipVerify).To(BeTrue())
+			Expect(tlsConfig.Certificates).To(HaveLen(1))
+			Expect(tlsConfig.Certificates[0].Certificate).To(Equal([][]byte{der}))
+			Expect(tlsConfig.Certificates[0].PrivateKey).To(Equal(key))
+
+			_, err = restclient.HTTPClientFor(withCert)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
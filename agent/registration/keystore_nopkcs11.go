@@ -0,0 +1,14 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !pkcs11
+// +build !pkcs11
+
+package registration
+
+import "fmt"
+
+// newPKCS11KeyStore fails, the PKCS#11 key store requires cgo and the pkcs11 build tag
+func newPKCS11KeyStore(*KeyStoreConfig) (KeyStore, error) {
+	return nil, fmt.Errorf("PKCS#11 key store is not supported by this agent, it must be built with the pkcs11 tag")
+}
@@ -0,0 +1,68 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build pkcs11
+// +build pkcs11
+
+package registration
+
+import (
+	"crypto"
+	"crypto/elliptic"
+	"fmt"
+
+	"github.com/ThalesIgnite/crypto11"
+)
+
+// pkcs11KeyStore keeps the private key in a PKCS#11 token, found by its label
+type pkcs11KeyStore struct {
+	ctx   *crypto11.Context
+	label string
+}
+
+func newPKCS11KeyStore(config *KeyStoreConfig) (KeyStore, error) {
+	if config.PKCS11Module == "" || config.PKCS11TokenLabel == "" || config.PKCS11KeyLabel == "" {
+		return nil, fmt.Errorf("PKCS#11 key store requires the module, the token label and the key label")
+	}
+	ctx, err := crypto11.Configure(&crypto11.Config{
+		Path:       config.PKCS11Module,
+		TokenLabel: config.PKCS11TokenLabel,
+		Pin:        config.PKCS11Pin,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error opening PKCS#11 token %s: %v", config.PKCS11TokenLabel, err)
+	}
+	return &pkcs11KeyStore{ctx: ctx, label: config.PKCS11KeyLabel}, nil
+}
+
+func (s *pkcs11KeyStore) Signer(keyType KeyType) (crypto.Signer, error) {
+	id, label := []byte(s.label), []byte(s.label)
+	signer, err := s.ctx.FindKeyPair(id, label)
+	if err != nil {
+		return nil, fmt.Errorf("error finding PKCS#11 key %s: %v", s.label, err)
+	}
+	if signer == nil {
+		switch keyType {
+		case KeyTypeECDSAP256, "":
+			signer, err = s.ctx.GenerateECDSAKeyPairWithLabel(id, label, elliptic.P256())
+		case KeyTypeECDSAP384:
+			signer, err = s.ctx.GenerateECDSAKeyPairWithLabel(id, label, elliptic.P384())
+		case KeyTypeRSA2048:
+			signer, err = s.ctx.GenerateRSAKeyPairWithLabel(id, label, 2048)
+		case KeyTypeRSA3072:
+			signer, err = s.ctx.GenerateRSAKeyPairWithLabel(id, label, 3072)
+		case KeyTypeRSA4096:
+			signer, err = s.ctx.GenerateRSAKeyPairWithLabel(id, label, 4096)
+		default:
+			return nil, fmt.Errorf("unsupported key type %q", keyType)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error generating PKCS#11 key %s: %v", s.label, err)
+		}
+	}
+	return checkSigner(signer)
+}
+
+func (s *pkcs11KeyStore) Exportable() bool {
+	return false
+}
@@ -0,0 +1,139 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package registration
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"encoding/asn1"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+
+	"github.com/google/go-tpm/tpm2"
+	"github.com/google/go-tpm/tpmutil"
+)
+
+// tpmKeyStore keeps the private key in the TPM of the host. The key is a primary key of the owner
+// hierarchy, derived by the TPM from its seed and the key template on every agent start, so it is
+// never stored outside of the TPM.
+type tpmKeyStore struct {
+	device string
+
+	mu     sync.Mutex
+	rw     io.ReadWriteCloser
+	handle tpmutil.Handle
+	public crypto.PublicKey
+}
+
+// tpmKeyTemplate returns the template of the signing key of the key type
+func tpmKeyTemplate(keyType KeyType) (tpm2.Public, error) {
+	template := tpm2.Public{
+		NameAlg: tpm2.AlgSHA256,
+		Attributes: tpm2.FlagSign | tpm2.FlagFixedTPM | tpm2.FlagFixedParent |
+			tpm2.FlagSensitiveDataOrigin | tpm2.FlagUserWithAuth,
+	}
+	switch keyType {
+	case KeyTypeECDSAP256, "":
+		template.Type = tpm2.AlgECC
+		template.ECCParameters = &tpm2.ECCParams{CurveID: tpm2.CurveNISTP256}
+	case KeyTypeECDSAP384:
+		template.Type = tpm2.AlgECC
+		template.ECCParameters = &tpm2.ECCParams{CurveID: tpm2.CurveNISTP384}
+	case KeyTypeRSA2048:
+		template.Type = tpm2.AlgRSA
+		template.RSAParameters = &tpm2.RSAParams{KeyBits: 2048}
+	default:
+		return tpm2.Public{}, fmt.Errorf("key type %q is not supported by the TPM key store", keyType)
+	}
+	return template, nil
+}
+
+func (s *tpmKeyStore) Signer(keyType KeyType) (crypto.Signer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.rw == nil {
+		template, err := tpmKeyTemplate(keyType)
+		if err != nil {
+			return nil, err
+		}
+		rw, err := openTPM(s.device)
+		if err != nil {
+			return nil, err
+		}
+		handle, public, err := tpm2.CreatePrimary(rw, tpm2.HandleOwner, tpm2.PCRSelection{}, "", "", template)
+		if err != nil {
+			rw.Close()
+			return nil, fmt.Errorf("error creating TPM key: %v", err)
+		}
+		s.rw, s.handle, s.public = rw, handle, public
+	}
+	return checkSigner(&tpmSigner{store: s})
+}
+
+func (s *tpmKeyStore) Exportable() bool {
+	return false
+}
+
+// tpmSigner signs with the key of the TPM key store
+type tpmSigner struct {
+	store *tpmKeyStore
+}
+
+func (t *tpmSigner) Public() crypto.PublicKey {
+	return t.store.public
+}
+
+func (t *tpmSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	hash, err := tpmHashAlgorithm(opts.HashFunc())
+	if err != nil {
+		return nil, err
+	}
+	scheme := &tpm2.SigScheme{Alg: tpm2.AlgECDSA, Hash: hash}
+	if _, ok := t.store.public.(*rsa.PublicKey); ok {
+		scheme.Alg = tpm2.AlgRSASSA
+		if _, ok := opts.(*rsa.PSSOptions); ok {
+			scheme.Alg = tpm2.AlgRSAPSS
+		}
+	}
+
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+	signature, err := tpm2.Sign(t.store.rw, t.store.handle, "", digest, nil, scheme)
+	if err != nil {
+		return nil, fmt.Errorf("error signing with TPM key: %v", err)
+	}
+	if signature.RSA != nil {
+		return signature.RSA.Signature, nil
+	}
+	return asn1.Marshal(struct{ R, S *big.Int }{signature.ECC.R, signature.ECC.S})
+}
+
+// openTPM opens the TPM device, /dev/tpmrm0 then /dev/tpm0 when empty
+func openTPM(device string) (io.ReadWriteCloser, error) {
+	var paths []string
+	if device != "" {
+		paths = append(paths, device)
+	}
+	rw, err := tpm2.OpenTPM(paths...)
+	if err != nil {
+		return nil, fmt.Errorf("error opening TPM: %v", err)
+	}
+	return rw, nil
+}
+
+// tpmHashAlgorithm returns the TPM algorithm of the hash function
+func tpmHashAlgorithm(hash crypto.Hash) (tpm2.Algorithm, error) {
+	switch hash {
+	case crypto.SHA256:
+		return tpm2.AlgSHA256, nil
+	case crypto.SHA384:
+		return tpm2.AlgSHA384, nil
+	case crypto.SHA512:
+		return tpm2.AlgSHA512, nil
+	}
+	return tpm2.AlgNull, fmt.Errorf("hash function %v is not supported by the TPM key store", hash)
+}
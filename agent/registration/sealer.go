@@ -0,0 +1,259 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package registration
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/google/go-tpm/tpm2"
+	"github.com/google/go-tpm/tpmutil"
+)
+
+// CredentialEncryption is how the host credentials are encrypted at rest
+type CredentialEncryption string
+
+const (
+	// CredentialEncryptionNone keeps the host credentials in plain text, the default
+	CredentialEncryptionNone CredentialEncryption = "none"
+	// CredentialEncryptionKeyFile encrypts the host credentials with a host-local key, e.g. provisioned
+	// by a KMS on a separate volume
+	CredentialEncryptionKeyFile CredentialEncryption = "key-file"
+	// CredentialEncryptionTPM encrypts the host credentials with a key sealed by the TPM of the host
+	CredentialEncryptionTPM CredentialEncryption = "tpm"
+
+	// credentialKeySize is the size of the AES-256 keys encrypting the credentials
+	credentialKeySize = 32
+)
+
+// SealerConfig configures the encryption of the host credentials at rest
+type SealerConfig struct {
+	// Type is the credential encryption, CredentialEncryptionNone when empty
+	Type CredentialEncryption
+	// KeyFile is the path of the 32 bytes key of the key-file encryption
+	KeyFile string
+	// TPMDevice is the path of the TPM device, /dev/tpmrm0 then /dev/tpm0 when empty
+	TPMDevice string
+}
+
+// Sealer encrypts the host credentials at rest, they are only decrypted in memory
+type Sealer interface {
+	// Seal returns the encrypted data
+	Seal(data []byte) ([]byte, error)
+	// Unseal returns the data decrypted from what Seal returned
+	Unseal(sealed []byte) ([]byte, error)
+}
+
+// NewSealer returns the sealer of the config, nil for CredentialEncryptionNone
+func NewSealer(config *SealerConfig) (Sealer, error) {
+	switch config.Type {
+	case CredentialEncryptionNone, "":
+		return nil, nil
+	case CredentialEncryptionKeyFile:
+		return newKeyFileSealer(config.KeyFile)
+	case CredentialEncryptionTPM:
+		return &tpmSealer{device: config.TPMDevice}, nil
+	}
+	return nil, fmt.Errorf("unsupported credential encryption %q, must be one of %s, %s, %s", config.Type,
+		CredentialEncryptionNone, CredentialEncryptionKeyFile, CredentialEncryptionTPM)
+}
+
+// sealedData is the JSON envelope of the sealed credentials
+type sealedData struct {
+	Encryption CredentialEncryption `json:"encryption"`
+	// EncryptedKey is the data key encrypted by the TPM, absent for the key-file encryption
+	EncryptedKey []byte `json:"encryptedKey,omitempty"`
+	Nonce        []byte `json:"nonce"`
+	Ciphertext   []byte `json:"ciphertext"`
+}
+
+// parseSealedData returns the envelope of the sealed data, or nil when the data is not sealed
+func parseSealedData(data []byte) *sealedData {
+	var sealed sealedData
+	if err := json.Unmarshal(data, &sealed); err != nil || sealed.Encryption == "" {
+		return nil
+	}
+	return &sealed
+}
+
+// IsSealed tells whether the data was returned by a Sealer
+func IsSealed(data []byte) bool {
+	return parseSealedData(data) != nil
+}
+
+// unsealFile returns the data of the file decrypted by the sealer, as is when it is not sealed
+func unsealFile(path string, data []byte, sealer Sealer) ([]byte, error) {
+	if !IsSealed(data) {
+		return data, nil
+	}
+	if sealer == nil {
+		return nil, fmt.Errorf("%s is encrypted, the credential encryption has to be set", path)
+	}
+	data, err := sealer.Unseal(data)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// unsealEnvelope returns the envelope of the sealed data of the encryption
+func unsealEnvelope(data []byte, encryption CredentialEncryption) (*sealedData, error) {
+	sealed := parseSealedData(data)
+	if sealed == nil {
+		return nil, fmt.Errorf("data is not sealed")
+	}
+	if sealed.Encryption != encryption {
+		return nil, fmt.Errorf("data is sealed with the %s encryption, not %s", sealed.Encryption, encryption)
+	}
+	return sealed, nil
+}
+
+// encryptAESGCM encrypts the data with the AES-256 key into the envelope
+func encryptAESGCM(key, data []byte, sealed *sealedData) ([]byte, error) {
+	aead, err := newAESGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	sealed.Nonce = make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, sealed.Nonce); err != nil {
+		return nil, err
+	}
+	sealed.Ciphertext = aead.Seal(nil, sealed.Nonce, data, []byte(sealed.Encryption))
+	return json.Marshal(sealed)
+}
+
+// decryptAESGCM decrypts the data of the envelope with the AES-256 key
+func decryptAESGCM(key []byte, sealed *sealedData) ([]byte, error) {
+	aead, err := newAESGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := aead.Open(nil, sealed.Nonce, sealed.Ciphertext, []byte(sealed.Encryption))
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting sealed data: %v", err)
+	}
+	return data, nil
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// keyFileSealer encrypts with the AES-256 key of a host-local file
+type keyFileSealer struct {
+	key []byte
+}
+
+func newKeyFileSealer(keyFile string) (*keyFileSealer, error) {
+	if keyFile == "" {
+		return nil, fmt.Errorf("key-file credential encryption requires a key file")
+	}
+	info, err := os.Stat(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error loading credential encryption key: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm&0o077 != 0 {
+		return nil, fmt.Errorf("credential encryption key %s is accessible by other users, its permissions %#o must be 0600", keyFile, perm)
+	}
+	key, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error loading credential encryption key: %v", err)
+	}
+	if len(key) != credentialKeySize {
+		return nil, fmt.Errorf("credential encryption key %s must be %d bytes long, not %d", keyFile, credentialKeySize, len(key))
+	}
+	return &keyFileSealer{key: key}, nil
+}
+
+func (s *keyFileSealer) Seal(data []byte) ([]byte, error) {
+	return encryptAESGCM(s.key, data, &sealedData{Encryption: CredentialEncryptionKeyFile})
+}
+
+func (s *keyFileSealer) Unseal(data []byte) ([]byte, error) {
+	sealed, err := unsealEnvelope(data, CredentialEncryptionKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return decryptAESGCM(s.key, sealed)
+}
+
+// tpmSealer encrypts with a random data key, itself encrypted with RSA-OAEP by a primary key of
+// the TPM. The TPM key is derived from its seed on every use, so the data key can only be
+// decrypted on the host, by the TPM it was sealed with.
+type tpmSealer struct {
+	device string
+}
+
+// tpmSealerTemplate is the template of the primary decryption key of the TPM sealer
+var tpmSealerTemplate = tpm2.Public{
+	Type:    tpm2.AlgRSA,
+	NameAlg: tpm2.AlgSHA256,
+	Attributes: tpm2.FlagDecrypt | tpm2.FlagFixedTPM | tpm2.FlagFixedParent |
+		tpm2.FlagSensitiveDataOrigin | tpm2.FlagUserWithAuth | tpm2.FlagNoDA,
+	RSAParameters: &tpm2.RSAParams{KeyBits: 2048},
+}
+
+// withKey runs f with the primary decryption key of the TPM
+func (s *tpmSealer) withKey(f func(rw io.ReadWriter, handle tpmutil.Handle, publicKey *rsa.PublicKey) error) error {
+	rw, err := openTPM(s.device)
+	if err != nil {
+		return err
+	}
+	defer rw.Close()
+	handle, public, err := tpm2.CreatePrimary(rw, tpm2.HandleOwner, tpm2.PCRSelection{}, "", "", tpmSealerTemplate)
+	if err != nil {
+		return fmt.Errorf("error creating TPM key: %v", err)
+	}
+	defer tpm2.FlushContext(rw, handle) // nolint: errcheck
+	publicKey, ok := public.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("unexpected TPM key of type %T", public)
+	}
+	return f(rw, handle, publicKey)
+}
+
+func (s *tpmSealer) Seal(data []byte) ([]byte, error) {
+	key := make([]byte, credentialKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	sealed := &sealedData{Encryption: CredentialEncryptionTPM}
+	err := s.withKey(func(_ io.ReadWriter, _ tpmutil.Handle, publicKey *rsa.PublicKey) error {
+		var err error
+		sealed.EncryptedKey, err = rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey, key, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return encryptAESGCM(key, data, sealed)
+}
+
+func (s *tpmSealer) Unseal(data []byte) ([]byte, error) {
+	sealed, err := unsealEnvelope(data, CredentialEncryptionTPM)
+	if err != nil {
+		return nil, err
+	}
+	var key []byte
+	err = s.withKey(func(rw io.ReadWriter, handle tpmutil.Handle, _ *rsa.PublicKey) error {
+		var err error
+		key, err = tpm2.RSADecrypt(rw, handle, "", sealed.EncryptedKey, &tpm2.AsymScheme{Alg: tpm2.AlgOAEP, Hash: tpm2.AlgSHA256}, "")
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting the data key with the TPM: %v", err)
+	}
+	return decryptAESGCM(key, sealed)
+}
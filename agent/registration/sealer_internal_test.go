@@ -0,0 +1,145 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package registration
+
+import (
+	"bytes"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	restclient "k8s.io/client-go/rest"
+)
+
+var _ = Describe("Credential encryption", func() {
+	var (
+		dir     string
+		keyFile string
+		sealer  Sealer
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "credentials")
+		Expect(err).NotTo(HaveOccurred())
+		key := make([]byte, credentialKeySize)
+		_, err = rand.Read(key)
+		Expect(err).NotTo(HaveOccurred())
+		keyFile = filepath.Join(dir, "credentials.key")
+		Expect(os.WriteFile(keyFile, key, 0o600)).To(Succeed())
+
+		sealer, err = NewSealer(&SealerConfig{Type: CredentialEncryptionKeyFile, KeyFile: keyFile})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	Context("When the sealer is created", func() {
+		It("should not encrypt with the none encryption", func() {
+			Expect(NewSealer(&SealerConfig{Type: CredentialEncryptionNone})).To(BeNil())
+		})
+
+		It("should reject a key which is not 32 bytes long", func() {
+			Expect(os.WriteFile(keyFile, []byte("short"), 0o600)).To(Succeed())
+			_, err := NewSealer(&SealerConfig{Type: CredentialEncryptionKeyFile, KeyFile: keyFile})
+			Expect(err).To(MatchError(ContainSubstring("must be 32 bytes long, not 5")))
+		})
+
+		It("should reject a key accessible by other users", func() {
+			Expect(os.Chmod(keyFile, 0o640)).To(Succeed())
+			_, err := NewSealer(&SealerConfig{Type: CredentialEncryptionKeyFile, KeyFile: keyFile})
+			Expect(err).To(MatchError(ContainSubstring("is accessible by other users, its permissions 0640 must be 0600")))
+		})
+
+		It("should return an error for an unsupported encryption", func() {
+			_, err := NewSealer(&SealerConfig{Type: "kms"})
+			Expect(err).To(MatchError(`unsupported credential encryption "kms", must be one of none, key-file, tpm`))
+		})
+	})
+
+	Context("When data is sealed with the key-file encryption", func() {
+		It("should only be unsealed with the same key", func() {
+			sealed, err := sealer.Seal([]byte("credentials"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(IsSealed(sealed)).To(BeTrue())
+			Expect(bytes.Contains(sealed, []byte("credentials"))).To(BeFalse())
+			Expect(sealer.Unseal(sealed)).To(Equal([]byte("credentials")))
+
+			otherKey := filepath.Join(dir, "other.key")
+			Expect(os.WriteFile(otherKey, bytes.Repeat([]byte{1}, credentialKeySize), 0o600)).To(Succeed())
+			otherSealer, err := NewSealer(&SealerConfig{Type: CredentialEncryptionKeyFile, KeyFile: otherKey})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = otherSealer.Unseal(sealed)
+			Expect(err).To(MatchError(ContainSubstring("error decrypting sealed data")))
+		})
+	})
+
+	Context("When the host kubeconfig is encrypted", func() {
+		var (
+			kubeconfigPath  string
+			bootstrapConfig *restclient.Config
+		)
+
+		BeforeEach(func() {
+			kubeconfigPath = filepath.Join(dir, "config")
+			bootstrapConfig = &restclient.Config{
+				Host:            "https://127.0.0.1:6443",
+				TLSClientConfig: restclient.TLSClientConfig{CAData: []byte("ca-data")},
+			}
+		})
+
+		It("should be decrypted in memory only", func() {
+			Expect(WriteSealedKubeconfigFromBootstrapping(bootstrapConfig, kubeconfigPath, "cert-data", "key-data", sealer)).To(Succeed())
+			data, err := os.ReadFile(kubeconfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(IsSealed(data)).To(BeTrue())
+
+			config, err := LoadSealedRESTClientConfig(kubeconfigPath, sealer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Host).To(Equal("https://127.0.0.1:6443"))
+			Expect(config.CertData).To(Equal([]byte("cert-data")))
+			Expect(config.KeyData).To(Equal([]byte("key-data")))
+
+			_, err = LoadRESTClientConfig(kubeconfigPath)
+			Expect(err).To(HaveOccurred())
+			_, err = LoadSealedRESTClientConfig(kubeconfigPath, nil)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should encrypt a kubeconfig written in plain text", func() {
+			Expect(WriteKubeconfigFromBootstrapping(bootstrapConfig, kubeconfigPath, "cert-data", "key-data")).To(Succeed())
+			Expect(SealKubeconfig(kubeconfigPath, sealer)).To(BeTrue())
+			Expect(SealKubeconfig(kubeconfigPath, sealer)).To(BeFalse())
+
+			info, err := os.Stat(kubeconfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0o600)))
+			config, err := LoadSealedRESTClientConfig(kubeconfigPath, sealer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.KeyData).To(Equal([]byte("key-data")))
+		})
+	})
+
+	Context("When the private key file is encrypted", func() {
+		It("should be written encrypted and loaded", func() {
+			keyPath := filepath.Join(dir, TmpPrivateKey)
+			keyData, _, err := loadOrGenerateKeyFile(keyPath, KeyTypeECDSAP256, sealer)
+			Expect(err).NotTo(HaveOccurred())
+			fileData, err := os.ReadFile(keyPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(IsSealed(fileData)).To(BeTrue())
+
+			loadedData, _, err := loadOrGenerateKeyFile(keyPath, KeyTypeECDSAP256, sealer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loadedData).To(Equal(keyData))
+
+			_, _, err = loadOrGenerateKeyFile(keyPath, KeyTypeECDSAP256, nil)
+			Expect(err).To(MatchError(ContainSubstring("is encrypted, the credential encryption has to be set")))
+		})
+	})
+})
@@ -0,0 +1,181 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package registration
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// FieldManager is the field manager the host agent applies the status of its ByoHost with. Being distinct
+// from the one of the controller, their concurrent updates of the status do not conflict.
+const FieldManager = "byoh-agent"
+
+const (
+	// HeartbeatFieldOwner owns the LastHeartbeatTime of the status
+	HeartbeatFieldOwner = "heartbeat"
+
+	// WatchdogFieldOwner owns the AgentDegraded condition
+	WatchdogFieldOwner = "watchdog"
+
+	// NodeKeeperFieldOwner owns the NodeComponentsHealthy condition
+	NodeKeeperFieldOwner = "nodekeeper"
+)
+
+// controllerConditions are the conditions of the host set by the controller, every other one is set by the agent
+var controllerConditions = map[clusterv1.ConditionType]bool{
+	clusterv1.ReadyCondition:              true,
+	infrastructurev1beta1.HostReserved:    true,
+	infrastructurev1beta1.HostSchedulable: true,
+}
+
+// notAppliedConditions are the conditions not applied with the rest of the status of the agent: those of
+// the controller and those applied by another owner
+var notAppliedConditions = map[clusterv1.ConditionType]bool{
+	clusterv1.ReadyCondition:                    true,
+	infrastructurev1beta1.HostReserved:          true,
+	infrastructurev1beta1.HostSchedulable:       true,
+	infrastructurev1beta1.AgentDegraded:         true,
+	infrastructurev1beta1.NodeComponentsHealthy: true,
+}
+
+// AgentStatus returns the fields of the status of the host the agent applies, i.e. all of them but
+// those of the controller, e.g. the MachineRef and the Ready condition, and those applied by another owner
+func AgentStatus(byoHost *infrastructurev1beta1.ByoHost) *infrastructurev1beta1.ByoHostStatus {
+	status := byoHost.Status.DeepCopy()
+	status.MachineRef = nil
+	status.Node = nil
+	status.ObservedGeneration = 0
+	status.LastHeartbeatTime = nil
+	status.Conditions = nil
+	for i := range byoHost.Status.Conditions {
+		if !notAppliedConditions[byoHost.Status.Conditions[i].Type] {
+			status.Conditions = append(status.Conditions, byoHost.Status.Conditions[i])
+		}
+	}
+	return status
+}
+
+// ApplyStatus applies the status to the host with server-side apply, under the FieldManager suffixed by
+// the owner of the fields when not empty. The status holds all the fields of the owner: those it applied
+// before and are left out are removed, unless another field manager set them too.
+// The conditions of the agent are released by the other field managers first, see releaseAgentConditions.
+func ApplyStatus(ctx context.Context, c client.Client, byoHost *infrastructurev1beta1.ByoHost, status *infrastructurev1beta1.ByoHostStatus, owner string) error {
+	if err := releaseAgentConditions(ctx, c, byoHost); err != nil {
+		return err
+	}
+	fieldManager := FieldManager
+	if owner != "" {
+		fieldManager += "-" + owner
+	}
+	applied := &infrastructurev1beta1.ByoHost{}
+	applied.APIVersion = infrastructurev1beta1.GroupVersion.String()
+	applied.Kind = "ByoHost"
+	applied.Name = byoHost.Name
+	applied.Namespace = byoHost.Namespace
+	applied.Status = *status
+	return c.Status().Patch(ctx, applied, client.Apply, client.FieldOwner(fieldManager), client.ForceOwnership)
+}
+
+// PatchHost patches the changes of the reconcile of the host, from original to byoHost. The status of the agent,
+// if changed, is applied first, then the metadata, the spec and the fields of the status of the controller the
+// reconcile changed, e.g. the MachineRef cleared once the host is cleaned up, are patched with the helper of original.
+// The controller reacting to the patch, e.g. to a removed annotation, thereby sees the status it goes along with.
+func PatchHost(ctx context.Context, c client.Client, helper *patch.Helper, original, byoHost *infrastructurev1beta1.ByoHost) error {
+	if status := AgentStatus(byoHost); !equality.Semantic.DeepEqual(status, AgentStatus(original)) {
+		if err := ApplyStatus(ctx, c, original, status, ""); err != nil {
+			return err
+		}
+	}
+	patched := byoHost.DeepCopy()
+	patched.Status = *original.Status.DeepCopy()
+	patched.Status.MachineRef = byoHost.Status.MachineRef
+	patched.Status.Node = byoHost.Status.Node
+	return helper.Patch(ctx, patched)
+}
+
+// releaseAgentConditions removes the conditions of the agent from the fields of the other field managers of
+// the host, e.g. those merge patched by an agent predating server-side apply or by the controller, so that the
+// agent is their only field manager and a condition it leaves out of its applied status is removed.
+// The managed fields of the host are only patched when another field manager owns such a condition.
+func releaseAgentConditions(ctx context.Context, c client.Client, byoHost *infrastructurev1beta1.ByoHost) error {
+	if _, released, err := agentConditionsReleased(byoHost.ManagedFields); err != nil || !released {
+		return err
+	}
+	// the managed fields of the host of a reconcile are stale once it is patched
+	latest := &infrastructurev1beta1.ByoHost{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(byoHost), latest); err != nil {
+		return err
+	}
+	managedFields, released, err := agentConditionsReleased(latest.ManagedFields)
+	if err != nil || !released {
+		return err
+	}
+	updated := latest.DeepCopy()
+	updated.ManagedFields = managedFields
+	return c.Patch(ctx, updated, client.MergeFromWithOptions(latest, client.MergeFromWithOptimisticLock{}))
+}
+
+// agentConditionsReleased returns the managed fields without the conditions of the agent owned by the field
+// managers other than the ones of the agent, and whether any was removed
+func agentConditionsReleased(managedFields []metav1.ManagedFieldsEntry) ([]metav1.ManagedFieldsEntry, bool, error) {
+	entries := make([]metav1.ManagedFieldsEntry, 0, len(managedFields))
+	released := false
+	for _, entry := range managedFields {
+		if strings.HasPrefix(entry.Manager, FieldManager) || entry.FieldsV1 == nil {
+			entries = append(entries, entry)
+			continue
+		}
+		fields := map[string]interface{}{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			return nil, false, err
+		}
+		status, _ := fields["f:status"].(map[string]interface{})
+		conditionFields, _ := status["f:conditions"].(map[string]interface{})
+		removed := false
+		for key := range conditionFields {
+			// the conditions are keyed by their type, e.g. k:{"type":"K8sNodeBootstrapSucceeded"}
+			var condition struct {
+				Type clusterv1.ConditionType `json:"type"`
+			}
+			if !strings.HasPrefix(key, "k:") || json.Unmarshal([]byte(strings.TrimPrefix(key, "k:")), &condition) != nil ||
+				controllerConditions[condition.Type] {
+				continue
+			}
+			delete(conditionFields, key)
+			removed = true
+		}
+		if !removed {
+			entries = append(entries, entry)
+			continue
+		}
+		released = true
+
+		if len(conditionFields) == 0 {
+			delete(status, "f:conditions")
+		}
+		if len(status) == 0 {
+			delete(fields, "f:status")
+		}
+		// the entry of a field manager owning nothing else is dropped
+		if len(fields) == 0 {
+			continue
+		}
+		raw, err := json.Marshal(fields)
+		if err != nil {
+			return nil, false, err
+		}
+		entry.FieldsV1 = &metav1.FieldsV1{Raw: raw}
+		entries = append(entries, entry)
+	}
+	return entries, released, nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package registration_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/registration"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+)
+
+var _ = Describe("Status Tests", func() {
+	var (
+		ctx     = context.TODO()
+		hostKey = types.NamespacedName{Name: "status-host", Namespace: "default"}
+		byoHost *infrastructurev1beta1.ByoHost
+	)
+
+	// applyConditions applies the status of the agent with the conditions, from the latest host
+	applyConditions := func(agentConditions ...clusterv1.Condition) {
+		Expect(k8sClient.Get(ctx, hostKey, byoHost)).To(Succeed())
+		status := registration.AgentStatus(byoHost)
+		status.Conditions = agentConditions
+		Expect(registration.ApplyStatus(ctx, k8sClient, byoHost, status, "")).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		byoHost = &infrastructurev1beta1.ByoHost{
+			ObjectMeta: metav1.ObjectMeta{Name: hostKey.Name, Namespace: hostKey.Namespace},
+		}
+		Expect(k8sClient.Create(ctx, byoHost)).To(Succeed())
+
+		applyConditions(
+			*conditions.TrueCondition(infrastructurev1beta1.K8sComponentsInstallationSucceeded),
+			*conditions.FalseCondition(infrastructurev1beta1.K8sNodeBootstrapSucceeded, infrastructurev1beta1.CloudInitExecutionFailedReason,
+				clusterv1.ConditionSeverityError, ""),
+		)
+
+		// the controller merge patches the conditions of the host with the patch helper
+		Expect(k8sClient.Get(ctx, hostKey, byoHost)).To(Succeed())
+		helper, err := patch.NewHelper(byoHost, k8sClient)
+		Expect(err).NotTo(HaveOccurred())
+		conditions.MarkTrue(byoHost, infrastructurev1beta1.HostReserved)
+		conditions.MarkFalse(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded, infrastructurev1beta1.BootstrapTokenExpiredReason,
+			clusterv1.ConditionSeverityWarning, "")
+		Expect(helper.Patch(ctx, byoHost)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.Delete(ctx, &infrastructurev1beta1.ByoHost{
+			ObjectMeta: metav1.ObjectMeta{Name: hostKey.Name, Namespace: hostKey.Namespace},
+		})).To(Succeed())
+	})
+
+	It("Should remove the condition the agent leaves out of its status, even when merge patched by another field manager", func() {
+		applyConditions(*conditions.TrueCondition(infrastructurev1beta1.K8sComponentsInstallationSucceeded))
+
+		updatedByoHost := &infrastructurev1beta1.ByoHost{}
+		Expect(k8sClient.Get(ctx, hostKey, updatedByoHost)).To(Succeed())
+		Expect(conditions.Has(updatedByoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded)).To(BeFalse())
+		Expect(conditions.IsTrue(updatedByoHost, infrastructurev1beta1.K8sComponentsInstallationSucceeded)).To(BeTrue())
+		// the conditions of the controller are kept
+		Expect(conditions.IsTrue(updatedByoHost, infrastructurev1beta1.HostReserved)).To(BeTrue())
+	})
+
+	It("Should leave the agent the only field manager of its conditions", func() {
+		applyConditions(*conditions.TrueCondition(infrastructurev1beta1.K8sComponentsInstallationSucceeded))
+
+		updatedByoHost := &infrastructurev1beta1.ByoHost{}
+		Expect(k8sClient.Get(ctx, hostKey, updatedByoHost)).To(Succeed())
+		for _, entry := range updatedByoHost.ManagedFields {
+			if entry.Manager == registration.FieldManager {
+				Expect(string(entry.FieldsV1.Raw)).To(ContainSubstring(`k:{\"type\":\"K8sComponentsInstallationSucceeded\"}`))
+				continue
+			}
+			Expect(string(entry.FieldsV1.Raw)).NotTo(ContainSubstring("K8sComponentsInstallationSucceeded"))
+			Expect(string(entry.FieldsV1.Raw)).NotTo(ContainSubstring("K8sNodeBootstrapSucceeded"))
+		}
+	})
+})
@@ -0,0 +1,7 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package rke2 executes the bootstrap data of the RKE2 bootstrap provider on a host where the
+// host agent installed rke2 from the rke2 bundle. The cloud-config writes the rke2 server or
+// agent configuration, then the rke2 service of the role of the machine is started.
+package rke2
@@ -0,0 +1,93 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package rke2
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/cloudinit"
+)
+
+const (
+	// ServerRole is the role of the control plane machines
+	ServerRole = "server"
+	// AgentRole is the role of the worker machines
+	AgentRole = "agent"
+
+	// KillAllCommand stops rke2 and the containers it started, it is the kubeadm reset of rke2
+	KillAllCommand = "/usr/local/bin/rke2-killall.sh"
+)
+
+var (
+	// installCommand matches the commands of the bootstrap data installing rke2, with the install
+	// script of get.rke2.io or its airgap artifacts, e.g.
+	// curl -sfL https://get.rke2.io | INSTALL_RKE2_VERSION=v1.23.5+rke2r1 sh -s - server
+	installCommand = regexp.MustCompile(`get\.rke2\.io|INSTALL_RKE2_`)
+	// roleArgument matches the role passed to the install script
+	roleArgument = regexp.MustCompile(`sh -s - (server|agent)\b`)
+	// serviceCommand matches the commands of the bootstrap data starting the rke2 service
+	serviceCommand = regexp.MustCompile(`systemctl (start|restart|enable --now) rke2-(server|agent)`)
+)
+
+// Executor executes the cloud-config bootstrap data of the RKE2 bootstrap provider
+type Executor struct {
+	WriteFilesExecutor    cloudinit.IFileWriter
+	RunCmdExecutor        cloudinit.ICmdRunner
+	ParseTemplateExecutor cloudinit.ITemplateParser
+}
+
+// cmdRunner skips the commands installing rke2, which is installed from the bundle, and
+// records the role of the machine and whether its service was started
+type cmdRunner struct {
+	cloudinit.ICmdRunner
+	role    string
+	started bool
+}
+
+func (r *cmdRunner) RunCmd(cmd string) error {
+	if installCommand.MatchString(cmd) {
+		if match := roleArgument.FindStringSubmatch(cmd); match != nil {
+			r.role = match[1]
+		}
+		return nil
+	}
+	if serviceCommand.MatchString(cmd) {
+		r.started = true
+	}
+	return r.ICmdRunner.RunCmd(cmd)
+}
+
+// Execute writes the files and runs the commands of the bootstrap data, then starts the rke2
+// service of the role of the machine unless the bootstrap data started it
+func (e Executor) Execute(bootstrapScript string) error {
+	return e.ExecuteContext(context.Background(), bootstrapScript)
+}
+
+// ExecuteContext executes the bootstrap data like Execute, tracing its steps in the trace of ctx
+func (e Executor) ExecuteContext(ctx context.Context, bootstrapScript string) error {
+	runner := &cmdRunner{ICmdRunner: e.RunCmdExecutor, role: role(bootstrapScript)}
+	err := cloudinit.ScriptExecutor{
+		WriteFilesExecutor:    e.WriteFilesExecutor,
+		RunCmdExecutor:        runner,
+		ParseTemplateExecutor: e.ParseTemplateExecutor}.ExecuteContext(ctx, bootstrapScript)
+	if err != nil {
+		return err
+	}
+	if runner.started {
+		return nil
+	}
+	return e.RunCmdExecutor.RunCmd(fmt.Sprintf("systemctl enable --now rke2-%s.service", runner.role))
+}
+
+// role returns the role of the machine, which is an agent if the bootstrap data mentions the
+// rke2-agent service, a server otherwise
+func role(bootstrapScript string) string {
+	if strings.Contains(bootstrapScript, "rke2-agent") {
+		return AgentRole
+	}
+	return ServerRole
+}
@@ -0,0 +1,77 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package rke2_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/cloudinit/cloudinitfakes"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/rke2"
+)
+
+var _ = Describe("RKE2", func() {
+	var (
+		fakeFileWriter  *cloudinitfakes.FakeIFileWriter
+		fakeCmdExecutor *cloudinitfakes.FakeICmdRunner
+		executor        rke2.Executor
+	)
+
+	BeforeEach(func() {
+		fakeFileWriter = &cloudinitfakes.FakeIFileWriter{}
+		fakeCmdExecutor = &cloudinitfakes.FakeICmdRunner{}
+		executor = rke2.Executor{
+			WriteFilesExecutor:    fakeFileWriter,
+			RunCmdExecutor:        fakeCmdExecutor,
+			ParseTemplateExecutor: &cloudinitfakes.FakeITemplateParser{},
+		}
+	})
+
+	It("should skip the installation of rke2 and start the service of the bootstrap data", func() {
+		err := executor.Execute(`write_files:
+- path: /etc/rancher/rke2/config.yaml
+  content: "token: secret"
+runcmd:
+- 'curl -sfL https://get.rke2.io | INSTALL_RKE2_VERSION=v1.23.5+rke2r1 sh -s - server'
+- 'systemctl enable rke2-server.service'
+- 'systemctl start rke2-server.service'`)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fakeFileWriter.WriteToFileCallCount()).To(Equal(1))
+		Expect(fakeFileWriter.WriteToFileArgsForCall(0).Path).To(Equal("/etc/rancher/rke2/config.yaml"))
+		Expect(fakeCmdExecutor.RunCmdCallCount()).To(Equal(2))
+		Expect(fakeCmdExecutor.RunCmdArgsForCall(0)).To(Equal("systemctl enable rke2-server.service"))
+		Expect(fakeCmdExecutor.RunCmdArgsForCall(1)).To(Equal("systemctl start rke2-server.service"))
+	})
+
+	It("should start the service of the role of the install script", func() {
+		err := executor.Execute(`write_files:
+- path: /etc/rancher/rke2/config.yaml
+  content: "server: https://10.0.0.1:9345"
+runcmd:
+- 'INSTALL_RKE2_ARTIFACT_PATH=/opt/rke2-artifacts sh /opt/install.sh -s - agent'`)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fakeCmdExecutor.RunCmdCallCount()).To(Equal(1))
+		Expect(fakeCmdExecutor.RunCmdArgsForCall(0)).To(Equal("systemctl enable --now rke2-agent.service"))
+	})
+
+	It("should start the rke2 server when the role is unknown", func() {
+		err := executor.Execute(`write_files:
+- path: /etc/rancher/rke2/config.yaml
+  content: "token: secret"`)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fakeCmdExecutor.RunCmdCallCount()).To(Equal(1))
+		Expect(fakeCmdExecutor.RunCmdArgsForCall(0)).To(Equal("systemctl enable --now rke2-server.service"))
+	})
+
+	It("should return the error of the commands", func() {
+		fakeCmdExecutor.RunCmdReturns(errors.New("rke2-server.service not found"))
+		err := executor.Execute(`runcmd:
+- 'systemctl start rke2-server.service'`)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,77 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/hostsecurity"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/feature"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+// checkHostSecurity runs the security self-check of the agent paths, returning the HostSecurityPosture
+// condition of the host. It exits when world-writable paths are left, as the configuration and the
+// credentials of the agent could have been tampered with.
+func checkHostSecurity(logger logr.Logger) *clusterv1.Condition {
+	checker := &hostsecurity.Checker{
+		Paths:  hostSecurityPaths(),
+		Refuse: refuseInsecurePaths,
+	}
+	posture, err := checker.Check()
+	if err != nil {
+		logger.Error(err, "the security self-check failed")
+		return hostsecurity.ErrorCondition(err)
+	}
+	for _, fixed := range posture.Fixed {
+		logger.Info("Fixed the permissions of an agent path", "path", fixed)
+	}
+	if len(posture.Findings) > 0 {
+		logger.Info("WARNING: the agent paths are not protected from the other users of the host", "findings", posture.Findings)
+	}
+	if posture.Refused() {
+		logger.Info("Refusing to start on world-writable agent paths, remove their write permission for the others", "violations", posture.Violations)
+		os.Exit(1)
+	}
+	return posture.Condition()
+}
+
+// hostSecurityPaths returns the paths of the agent checked by the security self-check
+func hostSecurityPaths() []hostsecurity.Path {
+	paths := []hostsecurity.Path{
+		{Path: configFile},
+		{Path: downloadpath},
+		{Path: stagedBundlePath},
+		{Path: installerAuditLog},
+		{Path: agentUpgradePublicKey},
+		{Path: bootstrapKubeConfig, Secret: true},
+		{Path: credentialKeyFile, Secret: true},
+		{Path: metricsKeyFile, Secret: true},
+	}
+	if kubeconfig := flag.Lookup("kubeconfig"); kubeconfig != nil && kubeconfig.Value.String() != "" {
+		paths = append(paths, hostsecurity.Path{Path: kubeconfig.Value.String(), Secret: true})
+	}
+	for _, kubeconfig := range filepath.SplitList(os.Getenv("KUBECONFIG")) {
+		paths = append(paths, hostsecurity.Path{Path: kubeconfig, Secret: true})
+	}
+	for _, kubeconfig := range strings.Split(failoverKubeconfigs, ",") {
+		paths = append(paths, hostsecurity.Path{Path: strings.TrimSpace(kubeconfig), Secret: true})
+	}
+	if feature.Gates.Enabled(feature.SecureAccess) {
+		if path, err := hostKubeConfigPath(); err == nil {
+			paths = append(paths, hostsecurity.Path{Path: path, Secret: true})
+		}
+		if dir, err := privateKeyDirPath(""); err == nil {
+			paths = append(paths, hostsecurity.Path{Path: dir, Secret: true})
+		}
+	}
+	if escalateWithSudo {
+		paths = append(paths, hostsecurity.Path{Path: hostsecurity.SudoersFile, RootOwned: true})
+	}
+	return paths
+}
@@ -0,0 +1,40 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/go-logr/logr"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/installer"
+)
+
+// stageBundleCommand is the agent subcommand pre-staging the bundle of a k8s version, for the
+// hosts connected to the bundle registry during maintenance windows only
+const stageBundleCommand = "stage-bundle"
+
+// stageBundle downloads and verifies the bundle of the k8s version under the staged bundle path,
+// the download path of the agent unless set, e.g. to stage the bundle onto a removable drive.
+// The management cluster is not needed.
+func stageBundle(out io.Writer, logger logr.Logger) error {
+	if stageK8sVersion == "" || stageBundleTag == "" {
+		return fmt.Errorf("the stage-k8s-version and stage-bundle-tag flags are required")
+	}
+	stagingPath := stagedBundlePath
+	if stagingPath == "" {
+		stagingPath = downloadpath
+	}
+	bundleType := installer.BundleTypeK8s
+	if stageRKE2Bundle {
+		bundleType = installer.BundleTypeRKE2
+	}
+	path, err := installer.Stage(stagingPath, stageBundleOS, bundleType, installer.InstallMode(installMode),
+		stageBundleRepo, stageK8sVersion, stageBundleTag, logger)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Bundle staged and verified at %s\n", path)
+	return nil
+}
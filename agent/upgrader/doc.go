@@ -0,0 +1,7 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package upgrader contains the implementation of the host agent self-upgrade.
+// The agent binary is published as an OCI artifact, which is downloaded, verified
+// and then exec'ed into in place of the running agent process.
+package upgrader
@@ -0,0 +1,278 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrader
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	"github.com/go-logr/logr"
+	"github.com/k14s/imgpkg/pkg/imgpkg/cmd"
+)
+
+// Error string wrapper for errors returned by the upgrader
+type Error string
+
+func (e Error) Error() string { return string(e) }
+
+const (
+	// ErrAgentDownload error type when the agent artifact download fails
+	ErrAgentDownload = Error("Error downloading agent binary")
+	// ErrChecksumMismatch error type when the agent binary does not match its checksum
+	ErrChecksumMismatch = Error("Agent binary checksum mismatch")
+	// ErrSignatureInvalid error type when the agent binary signature cannot be verified
+	ErrSignatureInvalid = Error("Agent binary signature verification failed")
+	// ErrPublicKeyMissing error type when no public key is configured to verify the agent binary signature
+	ErrPublicKeyMissing = Error("Agent binary public key not configured, set --agent-upgrade-public-key to upgrade the agent")
+)
+
+const (
+	// ChecksumFileSuffix is the suffix of the file, shipped along with the agent binary,
+	// that holds its sha256 checksum in the sha256sum output format
+	ChecksumFileSuffix = ".sha256"
+	// SignatureFileSuffix is the suffix of the file, shipped along with the agent binary,
+	// that holds the base64 encoded signature of its sha256 digest
+	SignatureFileSuffix = ".sig"
+)
+
+var (
+	// BinaryPermissions file mode permissions for the agent binary
+	BinaryPermissions fs.FileMode = 0755
+)
+
+// Upgrader replaces the running host agent binary with the one
+// published for the requested version and restarts the agent.
+type Upgrader struct {
+	binaryPath     string
+	downloadPath   string
+	publicKeyPath  string
+	logger         logr.Logger
+	downloadByTool func(string, string) error
+	execFn         func(string, []string, []string) error
+}
+
+// New returns an Upgrader that replaces the currently running executable.
+// Artifacts are downloaded under downloadPath. The signature of the downloaded binary is verified
+// against the public key of publicKeyPath, the agent is not upgraded without one.
+func New(downloadPath, publicKeyPath string, logger logr.Logger) (*Upgrader, error) {
+	if downloadPath == "" {
+		return nil, fmt.Errorf("empty download path")
+	}
+	binaryPath, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	binaryPath, err = filepath.EvalSymlinks(binaryPath)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &Upgrader{
+		binaryPath:    binaryPath,
+		downloadPath:  downloadPath,
+		publicKeyPath: publicKeyPath,
+		logger:        logger,
+		execFn:        syscall.Exec,
+	}
+	u.downloadByTool = u.downloadByImgpkg
+	return u, nil
+}
+
+// GetAgentArtifactName returns the name of the agent artifact for the current platform.
+func GetAgentArtifactName() string {
+	return fmt.Sprintf("byoh-hostagent-%s-%s", runtime.GOOS, runtime.GOARCH)
+}
+
+// GetAgentArtifactAddr returns the exact address of the agent artifact in the repo.
+func GetAgentArtifactAddr(repoAddr, version string) string {
+	return fmt.Sprintf("%s/%s:%s", repoAddr, GetAgentArtifactName(), version)
+}
+
+// Upgrade downloads the agent binary of the given version from repoAddr, verifies it,
+// swaps it with the running binary and re-executes the agent with the same arguments.
+// The checksum shipped along with the binary only detects its corruption, the binary is
+// only run once its signature is verified with the public key of the upgrader.
+// On success, Upgrade does not return.
+func (u *Upgrader) Upgrade(repoAddr, version string) error {
+	if repoAddr == "" {
+		return fmt.Errorf("agent binary repository is not set")
+	}
+	if u.publicKeyPath == "" {
+		return ErrPublicKeyMissing
+	}
+	if err := os.MkdirAll(u.downloadPath, BinaryPermissions); err != nil {
+		return err
+	}
+	dir, err := os.MkdirTemp(u.downloadPath, "agentUpgrade")
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			u.logger.Error(err, "Failed to remove temp agent dir", "path", dir)
+		}
+	}()
+	if err != nil {
+		return err
+	}
+
+	artifactAddr := GetAgentArtifactAddr(repoAddr, version)
+	u.logger.Info("Downloading agent binary", "from", artifactAddr)
+	if err = u.downloadByTool(artifactAddr, dir); err != nil {
+		u.logger.Error(err, "Failed to download agent binary", "from", artifactAddr)
+		return ErrAgentDownload
+	}
+
+	downloadedBinary := filepath.Join(dir, GetAgentArtifactName())
+	if err = verifyChecksum(downloadedBinary, downloadedBinary+ChecksumFileSuffix); err != nil {
+		return err
+	}
+	if err = verifySignature(downloadedBinary, downloadedBinary+SignatureFileSuffix, u.publicKeyPath); err != nil {
+		return err
+	}
+
+	if err = u.replaceBinary(downloadedBinary); err != nil {
+		return err
+	}
+
+	u.logger.Info("Restarting host agent", "version", version)
+	return u.execFn(u.binaryPath, os.Args, os.Environ())
+}
+
+// downloadByImgpkg downloads the agent artifact from the given address using imgpkg.
+func (u *Upgrader) downloadByImgpkg(artifactAddr, dir string) error {
+	var confUI = ui.NewConfUI(ui.NewNoopLogger())
+	defer confUI.Flush()
+
+	imgpkgCmd := cmd.NewDefaultImgpkgCmd(confUI)
+	imgpkgCmd.SetArgs([]string{"pull", "-i", artifactAddr, "-o", dir})
+	return imgpkgCmd.Execute()
+}
+
+// replaceBinary atomically swaps the running agent binary with newBinary.
+// The new binary is first copied next to the running one, so that the
+// final rename does not cross file systems.
+func (u *Upgrader) replaceBinary(newBinary string) error {
+	stagedBinary := u.binaryPath + ".new"
+	if err := copyFile(newBinary, stagedBinary); err != nil {
+		return err
+	}
+	if err := os.Chmod(stagedBinary, BinaryPermissions); err != nil {
+		return err
+	}
+	return os.Rename(stagedBinary, u.binaryPath)
+}
+
+// digest returns the sha256 digest of the file at path.
+func digest(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// verifyChecksum compares the sha256 digest of binaryPath with the one in checksumPath.
+// The checksum file is expected to follow the sha256sum output format.
+func verifyChecksum(binaryPath, checksumPath string) error {
+	checksumData, err := os.ReadFile(checksumPath)
+	if err != nil {
+		return err
+	}
+	fields := strings.Fields(string(checksumData))
+	if len(fields) == 0 {
+		return ErrChecksumMismatch
+	}
+	sum, err := digest(binaryPath)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(fields[0], hex.EncodeToString(sum)) {
+		return ErrChecksumMismatch
+	}
+	return nil
+}
+
+// verifySignature verifies the signature in signaturePath of the sha256 digest
+// of binaryPath with the PEM encoded public key in publicKeyPath.
+func verifySignature(binaryPath, signaturePath, publicKeyPath string) error {
+	keyData, err := os.ReadFile(publicKeyPath)
+	if err != nil {
+		return err
+	}
+	block, _ := pem.Decode(keyData)
+	if block == nil {
+		return fmt.Errorf("invalid public key %s", publicKeyPath)
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return err
+	}
+
+	signatureData, err := os.ReadFile(signaturePath)
+	if err != nil {
+		return err
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signatureData)))
+	if err != nil {
+		return ErrSignatureInvalid
+	}
+	sum, err := digest(binaryPath)
+	if err != nil {
+		return err
+	}
+
+	var valid bool
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(key, sum, signature)
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, sum, signature) == nil
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(key, sum, signature)
+	default:
+		return fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+	if !valid {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, BinaryPermissions)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
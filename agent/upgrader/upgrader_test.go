@@ -0,0 +1,157 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// nolint: testpackage
+package upgrader
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Agent Upgrader Tests", func() {
+	var (
+		u             *Upgrader
+		workDir       string
+		binaryPath    string
+		newBinary     = []byte("new agent binary")
+		signingKey    *ecdsa.PrivateKey
+		publicKeyPath string
+		execCalled    bool
+		execPath      string
+		pulledAddr    string
+	)
+
+	writeArtifact := func(dir string, checksum string, sign bool) {
+		name := filepath.Join(dir, GetAgentArtifactName())
+		Expect(os.WriteFile(name, newBinary, 0600)).To(Succeed())
+		Expect(os.WriteFile(name+ChecksumFileSuffix, []byte(fmt.Sprintf("%s  %s\n", checksum, GetAgentArtifactName())), 0600)).To(Succeed())
+		if sign {
+			sum := sha256.Sum256(newBinary)
+			signature, err := ecdsa.SignASN1(rand.Reader, signingKey, sum[:])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.WriteFile(name+SignatureFileSuffix, []byte(base64.StdEncoding.EncodeToString(signature)), 0600)).To(Succeed())
+		}
+	}
+
+	validChecksum := func() string {
+		sum := sha256.Sum256(newBinary)
+		return hex.EncodeToString(sum[:])
+	}
+
+	BeforeEach(func() {
+		var err error
+		workDir, err = os.MkdirTemp("", "upgraderTest")
+		Expect(err).NotTo(HaveOccurred())
+		binaryPath = filepath.Join(workDir, "byoh-hostagent")
+		Expect(os.WriteFile(binaryPath, []byte("old agent binary"), 0600)).To(Succeed())
+
+		signingKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		publicKeyDER, err := x509.MarshalPKIXPublicKey(&signingKey.PublicKey)
+		Expect(err).NotTo(HaveOccurred())
+		publicKeyPath = filepath.Join(workDir, "agent.pub")
+		Expect(os.WriteFile(publicKeyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER}), 0600)).To(Succeed())
+
+		execCalled = false
+		u = &Upgrader{
+			binaryPath:    binaryPath,
+			downloadPath:  filepath.Join(workDir, "downloads"),
+			publicKeyPath: publicKeyPath,
+			logger:        logr.Discard(),
+			execFn: func(path string, _, _ []string) error {
+				execCalled = true
+				execPath = path
+				return nil
+			},
+		}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(workDir)).To(Succeed())
+	})
+
+	It("Should replace the binary and re-exec the agent", func() {
+		u.downloadByTool = func(addr, dir string) error {
+			pulledAddr = addr
+			writeArtifact(dir, validChecksum(), true)
+			return nil
+		}
+		Expect(u.Upgrade("example.com/byoh", "v0.3.0")).To(Succeed())
+		Expect(pulledAddr).To(Equal(fmt.Sprintf("example.com/byoh/%s:v0.3.0", GetAgentArtifactName())))
+		Expect(os.ReadFile(binaryPath)).To(Equal(newBinary))
+		Expect(execCalled).To(BeTrue())
+		Expect(execPath).To(Equal(binaryPath))
+	})
+
+	It("Should fail when the repository is not set", func() {
+		Expect(u.Upgrade("", "v0.3.0")).To(MatchError("agent binary repository is not set"))
+		Expect(execCalled).To(BeFalse())
+	})
+
+	It("Should fail when the download fails", func() {
+		u.downloadByTool = func(_, _ string) error { return errors.New("no such host") }
+		Expect(u.Upgrade("example.com/byoh", "v0.3.0")).To(MatchError(ErrAgentDownload))
+		Expect(execCalled).To(BeFalse())
+	})
+
+	It("Should not replace the binary when the checksum does not match", func() {
+		u.downloadByTool = func(_, dir string) error {
+			writeArtifact(dir, "deadbeef", true)
+			return nil
+		}
+		Expect(u.Upgrade("example.com/byoh", "v0.3.0")).To(MatchError(ErrChecksumMismatch))
+		Expect(os.ReadFile(binaryPath)).To(Equal([]byte("old agent binary")))
+		Expect(execCalled).To(BeFalse())
+	})
+
+	It("Should fail when the signature does not match the public key", func() {
+		var err error
+		signingKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		u.downloadByTool = func(_, dir string) error {
+			writeArtifact(dir, validChecksum(), true)
+			return nil
+		}
+		Expect(u.Upgrade("example.com/byoh", "v0.3.0")).To(MatchError(ErrSignatureInvalid))
+		Expect(execCalled).To(BeFalse())
+	})
+
+	It("Should fail when the signature is missing", func() {
+		u.downloadByTool = func(_, dir string) error {
+			writeArtifact(dir, validChecksum(), false)
+			return nil
+		}
+		Expect(u.Upgrade("example.com/byoh", "v0.3.0")).NotTo(Succeed())
+		Expect(os.ReadFile(binaryPath)).To(Equal([]byte("old agent binary")))
+		Expect(execCalled).To(BeFalse())
+	})
+
+	It("Should not upgrade without a public key, even to a binary matching its checksum", func() {
+		u.publicKeyPath = ""
+		downloaded := false
+		u.downloadByTool = func(_, dir string) error {
+			downloaded = true
+			writeArtifact(dir, validChecksum(), false)
+			return nil
+		}
+		Expect(u.Upgrade("example.com/byoh", "v0.3.0")).To(MatchError(ErrPublicKeyMissing))
+		Expect(downloaded).To(BeFalse())
+		Expect(os.ReadFile(binaryPath)).To(Equal([]byte("old agent binary")))
+		Expect(execCalled).To(BeFalse())
+	})
+})
@@ -0,0 +1,7 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package watchdog supervises the reconciles of the host agent. It restarts the agent subsystems
+// when a reconcile hangs or keeps panicking, reports the crash reason through the AgentDegraded
+// condition of the ByoHost, and pings the systemd watchdog of the agent service while healthy.
+package watchdog
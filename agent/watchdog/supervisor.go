@@ -0,0 +1,111 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package watchdog
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/registration"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	byohconditions "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/pkg/conditions"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultCheckInterval is how often the supervisor checks the reconciles of the agent
+const DefaultCheckInterval = 10 * time.Second
+
+// Supervisor checks the watchdog every interval while the agent subsystems run. It stops them
+// with ErrRestart when they are degraded, after raising the AgentDegraded condition of the host,
+// and removes the condition once they recovered. The systemd watchdog of the agent service is
+// pinged while the subsystems are healthy.
+type Supervisor struct {
+	Watchdog  *Watchdog
+	K8sClient client.Client
+	Host      types.NamespacedName
+	// Interval defaults to DefaultCheckInterval, or half of the systemd watchdog timeout when shorter
+	Interval time.Duration
+	Logger   logr.Logger
+}
+
+// Start supervises the agent subsystems until the context is done, or they have to be restarted
+func (s *Supervisor) Start(ctx context.Context) error {
+	interval := s.Interval
+	if interval == 0 {
+		interval = DefaultCheckInterval
+	}
+	systemdInterval := WatchdogInterval()
+	if systemdInterval > 0 && systemdInterval < interval {
+		interval = systemdInterval
+	}
+	if err := Notify("READY=1"); err != nil {
+		s.Logger.Error(err, "failed to notify systemd that the agent is ready")
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.check(ctx, systemdInterval > 0); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// check restarts the degraded subsystems, or pings the systemd watchdog and clears the
+// AgentDegraded condition of the host once they recovered
+func (s *Supervisor) check(ctx context.Context, pingSystemd bool) error {
+	if degradation := s.Watchdog.Check(); degradation != nil {
+		s.Logger.Info("Restarting the agent subsystems", "reason", degradation.Reason, "message", degradation.Message)
+		if err := s.markDegraded(ctx, degradation); err != nil {
+			s.Logger.Error(err, "failed to raise the AgentDegraded condition of the host")
+		}
+		s.Watchdog.Restarted()
+		return ErrRestart
+	}
+
+	if pingSystemd {
+		if err := Notify("WATCHDOG=1"); err != nil {
+			s.Logger.Error(err, "failed to ping the systemd watchdog")
+		}
+	}
+	if s.Watchdog.Recovered() {
+		if err := s.clearDegraded(ctx); err != nil {
+			s.Logger.Error(err, "failed to remove the AgentDegraded condition of the host")
+		}
+	}
+	return nil
+}
+
+func (s *Supervisor) markDegraded(ctx context.Context, degradation *Degradation) error {
+	byoHost := &infrastructurev1beta1.ByoHost{}
+	if err := s.K8sClient.Get(ctx, s.Host, byoHost); err != nil {
+		return err
+	}
+	byohconditions.MarkNegative(byoHost, infrastructurev1beta1.AgentDegraded, degradation.Reason, clusterv1.ConditionSeverityWarning, "%s", degradation.Message)
+	status := &infrastructurev1beta1.ByoHostStatus{
+		Conditions: clusterv1.Conditions{*conditions.Get(byoHost, infrastructurev1beta1.AgentDegraded)},
+	}
+	return registration.ApplyStatus(ctx, s.K8sClient, byoHost, status, registration.WatchdogFieldOwner)
+}
+
+func (s *Supervisor) clearDegraded(ctx context.Context) error {
+	byoHost := &infrastructurev1beta1.ByoHost{}
+	if err := s.K8sClient.Get(ctx, s.Host, byoHost); err != nil {
+		return err
+	}
+	if !conditions.Has(byoHost, infrastructurev1beta1.AgentDegraded) {
+		return nil
+	}
+	// the negative polarity condition is removed rather than set to false, by applying none
+	return registration.ApplyStatus(ctx, s.K8sClient, byoHost, &infrastructurev1beta1.ByoHostStatus{}, registration.WatchdogFieldOwner)
+}
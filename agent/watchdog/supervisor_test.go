@@ -0,0 +1,158 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// nolint: testpackage
+package watchdog
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("Supervisor", func() {
+	var (
+		supervisor *Supervisor
+		now        time.Time
+		hostKey    = types.NamespacedName{Name: "host", Namespace: "default"}
+	)
+
+	BeforeEach(func() {
+		byoHost := &infrastructurev1beta1.ByoHost{
+			ObjectMeta: metav1.ObjectMeta{Name: hostKey.Name, Namespace: hostKey.Namespace},
+		}
+		Expect(k8sClient.Create(context.TODO(), byoHost)).To(Succeed())
+		now = time.Now()
+		supervisor = &Supervisor{
+			Watchdog: &Watchdog{
+				CrashLoopThreshold: 1,
+				now:                func() time.Time { return now },
+			},
+			K8sClient: k8sClient,
+			Host:      hostKey,
+			Interval:  10 * time.Millisecond,
+			Logger:    logr.Discard(),
+		}
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.Delete(context.TODO(), &infrastructurev1beta1.ByoHost{
+			ObjectMeta: metav1.ObjectMeta{Name: hostKey.Name, Namespace: hostKey.Namespace},
+		})).To(Succeed())
+	})
+
+	getHost := func() *infrastructurev1beta1.ByoHost {
+		byoHost := &infrastructurev1beta1.ByoHost{}
+		Expect(supervisor.K8sClient.Get(context.TODO(), hostKey, byoHost)).To(Succeed())
+		return byoHost
+	}
+
+	crash := func() {
+		_, _ = supervisor.Watchdog.Wrap("host-reconciler", reconcile.Func(func(context.Context, reconcile.Request) (reconcile.Result, error) {
+			panic("nil pointer dereference")
+		})).Reconcile(context.TODO(), reconcile.Request{NamespacedName: hostKey})
+	}
+
+	It("should raise the AgentDegraded condition and restart the crash looping subsystems", func() {
+		crash()
+		Expect(supervisor.Start(context.TODO())).To(MatchError(ErrRestart))
+
+		Expect(*conditions.Get(getHost(), infrastructurev1beta1.AgentDegraded)).To(conditions.MatchCondition(clusterv1.Condition{
+			Type:     infrastructurev1beta1.AgentDegraded,
+			Status:   corev1.ConditionTrue,
+			Severity: clusterv1.ConditionSeverityWarning,
+			Reason:   infrastructurev1beta1.CrashLoopReason,
+			Message:  "1 panics within 10m0s, the last one in the host-reconciler reconcile: nil pointer dereference",
+		}))
+		Expect(supervisor.Watchdog.Check()).To(BeNil())
+	})
+
+	It("should remove the AgentDegraded condition once the subsystems recovered", func() {
+		crash()
+		Expect(supervisor.check(context.TODO(), false)).To(MatchError(ErrRestart))
+
+		Expect(supervisor.check(context.TODO(), false)).To(Succeed())
+		Expect(conditions.Has(getHost(), infrastructurev1beta1.AgentDegraded)).To(BeTrue())
+
+		now = now.Add(DefaultCrashLoopWindow)
+		Expect(supervisor.check(context.TODO(), false)).To(Succeed())
+		Expect(conditions.Has(getHost(), infrastructurev1beta1.AgentDegraded)).To(BeFalse())
+	})
+
+	It("should supervise the subsystems until it is stopped", func() {
+		ctx, cancel := context.WithCancel(context.TODO())
+		done := make(chan error)
+		go func() {
+			done <- supervisor.Start(ctx)
+		}()
+		Consistently(done, 50*time.Millisecond).ShouldNot(Receive())
+		cancel()
+		Eventually(done).Should(Receive(BeNil()))
+	})
+
+	Context("when the agent runs as a systemd service", func() {
+		var (
+			notifications *net.UnixConn
+			socketDir     string
+		)
+
+		BeforeEach(func() {
+			var err error
+			socketDir, err = os.MkdirTemp("", "notify")
+			Expect(err).NotTo(HaveOccurred())
+			socket := filepath.Join(socketDir, "notify.sock")
+			notifications, err = net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.Setenv("NOTIFY_SOCKET", socket)).To(Succeed())
+			Expect(os.Setenv("WATCHDOG_USEC", "20000")).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(os.Unsetenv("NOTIFY_SOCKET")).To(Succeed())
+			Expect(os.Unsetenv("WATCHDOG_USEC")).To(Succeed())
+			notifications.Close()
+			Expect(os.RemoveAll(socketDir)).To(Succeed())
+		})
+
+		readNotification := func() string {
+			buf := make([]byte, 64)
+			Expect(notifications.SetReadDeadline(time.Now().Add(time.Second))).To(Succeed())
+			n, err := notifications.Read(buf)
+			Expect(err).NotTo(HaveOccurred())
+			return string(buf[:n])
+		}
+
+		It("should notify systemd that the agent is ready and ping its watchdog", func() {
+			Expect(WatchdogInterval()).To(Equal(10 * time.Millisecond))
+
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			go func() {
+				defer GinkgoRecover()
+				Expect(supervisor.Start(ctx)).To(Succeed())
+			}()
+			Expect(readNotification()).To(Equal("READY=1"))
+			Expect(readNotification()).To(Equal("WATCHDOG=1"))
+			Expect(readNotification()).To(Equal("WATCHDOG=1"))
+		})
+
+		It("should not ping the watchdog of another process", func() {
+			Expect(os.Setenv("WATCHDOG_PID", "1")).To(Succeed())
+			defer os.Unsetenv("WATCHDOG_PID")
+			Expect(WatchdogInterval()).To(BeZero())
+		})
+	})
+})
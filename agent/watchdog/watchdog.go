@@ -0,0 +1,200 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package watchdog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// DefaultHungTimeout is how long a reconcile may run before it is considered hung. It leaves
+	// room for the download and the installation of the k8s bundle.
+	DefaultHungTimeout = 30 * time.Minute
+
+	// DefaultCrashLoopThreshold is the number of panics within the crash loop window after which
+	// the agent subsystems are restarted
+	DefaultCrashLoopThreshold = 3
+
+	// DefaultCrashLoopWindow is the window the panics are counted in, the agent is considered
+	// healthy again once it ran that long without restarting its subsystems
+	DefaultCrashLoopWindow = 10 * time.Minute
+)
+
+// ErrRestart stops the agent for its subsystems to be restarted
+var ErrRestart = errors.New("restarting the agent subsystems")
+
+// Degradation is why the agent subsystems are restarted
+type Degradation struct {
+	// Reason is the reason of the AgentDegraded condition, HungReconcileReason or CrashLoopReason
+	Reason  string
+	Message string
+}
+
+// Watchdog tracks the reconciles of the agent subsystems it wraps. It outlives the restarts of
+// the subsystems, so that the panics leading to a restart are not forgotten.
+type Watchdog struct {
+	// HungTimeout defaults to DefaultHungTimeout
+	HungTimeout time.Duration
+	// CrashLoopThreshold defaults to DefaultCrashLoopThreshold
+	CrashLoopThreshold int
+	// CrashLoopWindow defaults to DefaultCrashLoopWindow
+	CrashLoopWindow time.Duration
+
+	// now defaults to time.Now, overridden in the tests
+	now func() time.Time
+
+	mu           sync.Mutex
+	nextRun      uint64
+	running      map[uint64]reconcileRun
+	panics       []panicRecord
+	healthySince time.Time
+}
+
+type reconcileRun struct {
+	subsystem string
+	request   reconcile.Request
+	started   time.Time
+}
+
+type panicRecord struct {
+	subsystem string
+	reason    string
+	at        time.Time
+}
+
+// Wrap tracks the reconciles of the subsystem, and recovers their panics into errors. The
+// reconciler is returned as is by a nil watchdog.
+func (w *Watchdog) Wrap(subsystem string, r reconcile.Reconciler) reconcile.Reconciler {
+	if w == nil {
+		return r
+	}
+	return reconcile.Func(func(ctx context.Context, req reconcile.Request) (result reconcile.Result, err error) {
+		id := w.begin(subsystem, req)
+		defer func() {
+			w.end(id)
+			if p := recover(); p != nil {
+				w.recordPanic(subsystem, fmt.Sprint(p))
+				err = fmt.Errorf("panic in the %s reconcile of %s: %v", subsystem, req, p)
+			}
+		}()
+		return r.Reconcile(ctx, req)
+	})
+}
+
+// Check returns why the agent subsystems have to be restarted, or nil while they are healthy
+func (w *Watchdog) Check() *Degradation {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	now := w.clock()
+	if w.healthySince.IsZero() {
+		w.healthySince = now
+	}
+
+	for _, run := range w.running {
+		if elapsed := now.Sub(run.started); elapsed > w.hungTimeout() {
+			return &Degradation{
+				Reason: infrastructurev1beta1.HungReconcileReason,
+				Message: fmt.Sprintf("the %s reconcile of %s has been running for %s",
+					run.subsystem, run.request, elapsed.Round(time.Second)),
+			}
+		}
+	}
+
+	w.prunePanics(now)
+	if threshold := w.crashLoopThreshold(); len(w.panics) >= threshold {
+		last := w.panics[len(w.panics)-1]
+		return &Degradation{
+			Reason: infrastructurev1beta1.CrashLoopReason,
+			Message: fmt.Sprintf("%d panics within %s, the last one in the %s reconcile: %s",
+				len(w.panics), w.crashLoopWindow(), last.subsystem, last.reason),
+		}
+	}
+	return nil
+}
+
+// Restarted forgets the reconciles and the panics of the subsystems being restarted. The
+// reconciles still hanging in the stopped subsystems are not tracked anymore.
+func (w *Watchdog) Restarted() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.running = nil
+	w.panics = nil
+	w.healthySince = w.clock()
+}
+
+// Recovered reports if the agent ran the crash loop window without restarting its subsystems
+func (w *Watchdog) Recovered() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return !w.healthySince.IsZero() && w.clock().Sub(w.healthySince) >= w.crashLoopWindow()
+}
+
+func (w *Watchdog) begin(subsystem string, req reconcile.Request) uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.running == nil {
+		w.running = map[uint64]reconcileRun{}
+	}
+	w.nextRun++
+	w.running[w.nextRun] = reconcileRun{subsystem: subsystem, request: req, started: w.clock()}
+	return w.nextRun
+}
+
+func (w *Watchdog) end(id uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.running, id)
+}
+
+func (w *Watchdog) recordPanic(subsystem, reason string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.panics = append(w.panics, panicRecord{subsystem: subsystem, reason: reason, at: w.clock()})
+}
+
+// prunePanics drops the panics older than the crash loop window
+func (w *Watchdog) prunePanics(now time.Time) {
+	recent := w.panics[:0]
+	for _, p := range w.panics {
+		if now.Sub(p.at) < w.crashLoopWindow() {
+			recent = append(recent, p)
+		}
+	}
+	w.panics = recent
+}
+
+func (w *Watchdog) clock() time.Time {
+	if w.now != nil {
+		return w.now()
+	}
+	return time.Now()
+}
+
+func (w *Watchdog) hungTimeout() time.Duration {
+	if w.HungTimeout > 0 {
+		return w.HungTimeout
+	}
+	return DefaultHungTimeout
+}
+
+func (w *Watchdog) crashLoopThreshold() int {
+	if w.CrashLoopThreshold > 0 {
+		return w.CrashLoopThreshold
+	}
+	return DefaultCrashLoopThreshold
+}
+
+func (w *Watchdog) crashLoopWindow() time.Duration {
+	if w.CrashLoopWindow > 0 {
+		return w.CrashLoopWindow
+	}
+	return DefaultCrashLoopWindow
+}
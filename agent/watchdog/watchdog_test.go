@@ -0,0 +1,119 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// nolint: testpackage
+package watchdog
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("Watchdog", func() {
+	var (
+		wd      *Watchdog
+		now     time.Time
+		request = reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "host"}}
+	)
+
+	BeforeEach(func() {
+		now = time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+		wd = &Watchdog{
+			HungTimeout:        time.Minute,
+			CrashLoopThreshold: 2,
+			CrashLoopWindow:    10 * time.Minute,
+			now:                func() time.Time { return now },
+		}
+	})
+
+	panicking := reconcile.Func(func(context.Context, reconcile.Request) (reconcile.Result, error) {
+		panic("nil pointer dereference")
+	})
+
+	It("should pass the result of the reconciles through", func() {
+		r := wd.Wrap("host-reconciler", reconcile.Func(func(context.Context, reconcile.Request) (reconcile.Result, error) {
+			return reconcile.Result{RequeueAfter: time.Second}, errors.New("requeue")
+		}))
+		result, err := r.Reconcile(context.TODO(), request)
+		Expect(err).To(MatchError("requeue"))
+		Expect(result.RequeueAfter).To(Equal(time.Second))
+		Expect(wd.Check()).To(BeNil())
+	})
+
+	It("should recover the panics of the reconciles", func() {
+		_, err := wd.Wrap("host-reconciler", panicking).Reconcile(context.TODO(), request)
+		Expect(err).To(MatchError("panic in the host-reconciler reconcile of default/host: nil pointer dereference"))
+		Expect(wd.Check()).To(BeNil())
+	})
+
+	It("should report a crash loop once the panics reach the threshold within the window", func() {
+		r := wd.Wrap("host-reconciler", panicking)
+		_, _ = r.Reconcile(context.TODO(), request)
+		now = now.Add(11 * time.Minute)
+		_, _ = r.Reconcile(context.TODO(), request)
+		Expect(wd.Check()).To(BeNil())
+
+		now = now.Add(time.Minute)
+		_, _ = r.Reconcile(context.TODO(), request)
+		Expect(wd.Check()).To(Equal(&Degradation{
+			Reason:  infrastructurev1beta1.CrashLoopReason,
+			Message: "2 panics within 10m0s, the last one in the host-reconciler reconcile: nil pointer dereference",
+		}))
+	})
+
+	It("should report a reconcile running longer than the hung timeout", func() {
+		started := make(chan struct{})
+		release := make(chan struct{})
+		defer close(release)
+		r := wd.Wrap("host-reconciler", reconcile.Func(func(context.Context, reconcile.Request) (reconcile.Result, error) {
+			close(started)
+			<-release
+			return reconcile.Result{}, nil
+		}))
+		go func() {
+			defer GinkgoRecover()
+			_, _ = r.Reconcile(context.TODO(), request)
+		}()
+		<-started
+		Expect(wd.Check()).To(BeNil())
+
+		now = now.Add(2 * time.Minute)
+		Expect(wd.Check()).To(Equal(&Degradation{
+			Reason:  infrastructurev1beta1.HungReconcileReason,
+			Message: "the host-reconciler reconcile of default/host has been running for 2m0s",
+		}))
+	})
+
+	It("should forget the panics and the hung reconciles of the restarted subsystems", func() {
+		r := wd.Wrap("host-reconciler", panicking)
+		_, _ = r.Reconcile(context.TODO(), request)
+		_, _ = r.Reconcile(context.TODO(), request)
+		Expect(wd.Check()).NotTo(BeNil())
+
+		wd.Restarted()
+		Expect(wd.Check()).To(BeNil())
+	})
+
+	It("should be recovered once it ran the crash loop window without restarting", func() {
+		Expect(wd.Check()).To(BeNil())
+		Expect(wd.Recovered()).To(BeFalse())
+
+		now = now.Add(10 * time.Minute)
+		Expect(wd.Recovered()).To(BeTrue())
+
+		wd.Restarted()
+		Expect(wd.Recovered()).To(BeFalse())
+	})
+
+	It("should not wrap the reconciler without a watchdog", func() {
+		var nilWatchdog *Watchdog
+		Expect(nilWatchdog.Wrap("host-reconciler", panicking)).To(BeAssignableToTypeOf(panicking))
+	})
+})
@@ -0,0 +1,46 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package agentconfig loads the host agent configuration file
+package agentconfig
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/agentconfig/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Load reads the host agent configuration file. Unknown fields are rejected,
+// so that a typo does not silently leave a setting to its default.
+func Load(path string) (*v1alpha1.AgentConfiguration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(data)
+}
+
+// Decode decodes a host agent configuration of a supported schema version
+func Decode(data []byte) (*v1alpha1.AgentConfiguration, error) {
+	typeMeta := metav1.TypeMeta{}
+	if err := yaml.Unmarshal(data, &typeMeta); err != nil {
+		return nil, err
+	}
+	if typeMeta.Kind != v1alpha1.Kind {
+		return nil, fmt.Errorf("unsupported kind %q, expected %s", typeMeta.Kind, v1alpha1.Kind)
+	}
+
+	switch typeMeta.APIVersion {
+	case v1alpha1.GroupVersion.String():
+		config := &v1alpha1.AgentConfiguration{}
+		if err := yaml.UnmarshalStrict(data, config); err != nil {
+			return nil, err
+		}
+		return config, nil
+	default:
+		return nil, fmt.Errorf("unsupported apiVersion %q, expected %s", typeMeta.APIVersion, v1alpha1.GroupVersion)
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package agentconfig_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/agentconfig"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/agentconfig/v1alpha1"
+)
+
+var _ = Describe("Agent configuration file", func() {
+	It("should load a v1alpha1 configuration", func() {
+		dir, err := os.MkdirTemp("", "agentconfig")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "config.yaml")
+		Expect(os.WriteFile(path, []byte(`apiVersion: agent.byoh.infrastructure.cluster.x-k8s.io/v1alpha1
+kind: AgentConfiguration
+namespace: byoh-hosts
+labels:
+  site: apac
+metrics:
+  bindAddress: ":9090"
+logging:
+  verbosity: 4
+failover:
+  kubeconfigs:
+  - /etc/byoh/standby.conf
+  probeInterval: 30s
+watchdog:
+  hungTimeout: 1h
+featureGates:
+  SecureAccess: true
+`), 0600)).To(Succeed())
+
+		config, err := agentconfig.Load(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.Namespace).To(Equal("byoh-hosts"))
+		Expect(config.Labels).To(Equal(map[string]string{"site": "apac"}))
+		Expect(config.Metrics.BindAddress).To(Equal(":9090"))
+		Expect(*config.Logging.Verbosity).To(Equal(int32(4)))
+		Expect(config.Failover.Kubeconfigs).To(Equal([]string{"/etc/byoh/standby.conf"}))
+		Expect(config.Failover.ProbeInterval.Duration).To(Equal(30 * time.Second))
+		Expect(config.Watchdog.HungTimeout.Duration).To(Equal(time.Hour))
+		Expect(config.FeatureGates).To(HaveKeyWithValue("SecureAccess", true))
+	})
+
+	It("should reject the unknown fields", func() {
+		_, err := agentconfig.Decode([]byte(`apiVersion: agent.byoh.infrastructure.cluster.x-k8s.io/v1alpha1
+kind: AgentConfiguration
+namesapce: byoh-hosts
+`))
+		Expect(err).To(MatchError(ContainSubstring(`unknown field "namesapce"`)))
+	})
+
+	It("should reject an unsupported schema version", func() {
+		_, err := agentconfig.Decode([]byte(`apiVersion: agent.byoh.infrastructure.cluster.x-k8s.io/v1
+kind: AgentConfiguration
+`))
+		Expect(err).To(MatchError(`unsupported apiVersion "agent.byoh.infrastructure.cluster.x-k8s.io/v1", expected ` + v1alpha1.GroupVersion.String()))
+	})
+
+	It("should reject another kind", func() {
+		_, err := agentconfig.Decode([]byte(`apiVersion: agent.byoh.infrastructure.cluster.x-k8s.io/v1alpha1
+kind: ByoHost
+`))
+		Expect(err).To(MatchError(`unsupported kind "ByoHost", expected AgentConfiguration`))
+	})
+})
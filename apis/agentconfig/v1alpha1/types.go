@@ -0,0 +1,362 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package v1alpha1 contains the v1alpha1 schema of the host agent configuration file
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// GroupName is the group of the host agent configuration file schema
+	GroupName = "agent.byoh.infrastructure.cluster.x-k8s.io"
+
+	// Kind is the kind of the host agent configuration file
+	Kind = "AgentConfiguration"
+)
+
+// GroupVersion is the group version of this schema
+var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha1"}
+
+// AgentConfiguration configures the host agent, as an alternative to its flags.
+// The flags set on the command line take precedence over the configuration file.
+type AgentConfiguration struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Namespace in the management cluster the host is registered in
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// HostnameOverride is the name the host is registered with instead of its hostname
+	// +optional
+	HostnameOverride string `json:"hostnameOverride,omitempty"`
+
+	// HostInstanceSuffix runs the agent as one of several instances of the machine, in dev mode
+	// +optional
+	HostInstanceSuffix string `json:"hostInstanceSuffix,omitempty"`
+
+	// HostIdentity is the identity the host is registered under: hostname, machine-id or smbios-uuid
+	// +optional
+	HostIdentity string `json:"hostIdentity,omitempty"`
+
+	// ByoHostNameTemplate is the template of the name the host is registered with, e.g. "{site}-node-{hostname}"
+	// +optional
+	ByoHostNameTemplate string `json:"byoHostNameTemplate,omitempty"`
+
+	// ByoHostNamePrefix is prepended to the name the host is registered with
+	// +optional
+	ByoHostNamePrefix string `json:"byoHostNamePrefix,omitempty"`
+
+	// Labels are attached to the ByoHost. They are reloaded on SIGHUP.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// DefaultNetworkInterface is the network interface reported as the default one
+	// +optional
+	DefaultNetworkInterface string `json:"defaultNetworkInterface,omitempty"`
+
+	// NodeIP is the IP address the kubelet registers the node with
+	// +optional
+	NodeIP string `json:"nodeIP,omitempty"`
+
+	// DownloadPath is the file system path the bundles are downloaded to
+	// +optional
+	DownloadPath string `json:"downloadPath,omitempty"`
+
+	// StagedBundlePath is the file system path the bundles are pre-staged under
+	// +optional
+	StagedBundlePath string `json:"stagedBundlePath,omitempty"`
+
+	// InstallMode is how the kubernetes components are installed, "package" or "containerd"
+	// +optional
+	InstallMode string `json:"installMode,omitempty"`
+
+	// InstallerAuditLog is the path of the local audit log of the commands run by the installer
+	// +optional
+	InstallerAuditLog string `json:"installerAuditLog,omitempty"`
+
+	// DownloadRateLimit is the bandwidth the bundle downloads are throttled to, in bytes per second as a
+	// quantity, e.g. 1Mi
+	// +optional
+	DownloadRateLimit string `json:"downloadRateLimit,omitempty"`
+
+	// SkipInstallation skips the installation of the kubernetes components
+	// +optional
+	SkipInstallation bool `json:"skipInstallation,omitempty"`
+
+	// UseInstallerController skips the intree installer in favour of an installer controller
+	// +optional
+	UseInstallerController bool `json:"useInstallerController,omitempty"`
+
+	// SkipPreflightChecks skips the checks of the OS and kernel prerequisites
+	// +optional
+	SkipPreflightChecks bool `json:"skipPreflightChecks,omitempty"`
+
+	// EscalateWithSudo runs the operations needing root with sudo, for an agent running as a dedicated user
+	// +optional
+	EscalateWithSudo bool `json:"escalateWithSudo,omitempty"`
+
+	// RefuseInsecurePermissions refuses to start on world-writable agent paths rather than fixing their permissions
+	// +optional
+	RefuseInsecurePermissions bool `json:"refuseInsecurePermissions,omitempty"`
+
+	// BootstrapKubeconfig is the path of the bootstrap kubeconfig of the bootstrap token workflow
+	// +optional
+	BootstrapKubeconfig string `json:"bootstrapKubeconfig,omitempty"`
+
+	// HostKubeconfig is the path of the kubeconfig minted from the bootstrap kubeconfig
+	// +optional
+	HostKubeconfig string `json:"hostKubeconfig,omitempty"`
+
+	// FIPS enforces the FIPS 140-2 approved algorithms for the keys and the TLS settings of the agent
+	// +optional
+	FIPS bool `json:"fips,omitempty"`
+
+	// KeyType is the type of the private key of the host client certificate: ECDSA-P256, ECDSA-P384,
+	// RSA-2048, RSA-3072 or RSA-4096
+	// +optional
+	KeyType string `json:"keyType,omitempty"`
+
+	// CSRSignerName is the signer the host client certificate is requested from, e.g. the signer of
+	// an internal CA, kubernetes.io/kube-apiserver-client by default
+	// +optional
+	CSRSignerName string `json:"csrSignerName,omitempty"`
+
+	// CSRApprovalTimeout is how long the host CSR is waited for to be approved before it is deleted
+	// and submitted again, 1h by default
+	// +optional
+	CSRApprovalTimeout *metav1.Duration `json:"csrApprovalTimeout,omitempty"`
+
+	// CSRMaxRequests is the number of host CSRs not approved in time after which the agent gives up,
+	// unlimited by default
+	// +optional
+	CSRMaxRequests int `json:"csrMaxRequests,omitempty"`
+
+	// CSRRetryJitter is the upper bound of the random delay before submitting a host CSR again
+	// +optional
+	CSRRetryJitter *metav1.Duration `json:"csrRetryJitter,omitempty"`
+
+	// KeyStore configures where the private key of the host client certificate is kept
+	// +optional
+	KeyStore KeyStoreConfiguration `json:"keyStore,omitempty"`
+
+	// CredentialEncryption configures how the host kubeconfig and private key are encrypted at rest
+	// +optional
+	CredentialEncryption CredentialEncryptionConfiguration `json:"credentialEncryption,omitempty"`
+
+	// AgentUpgradePublicKey is the path of the public key verifying the agent binary on upgrade
+	// +optional
+	AgentUpgradePublicKey string `json:"agentUpgradePublicKey,omitempty"`
+
+	// Metrics configures the metrics endpoint
+	// +optional
+	Metrics MetricsConfiguration `json:"metrics,omitempty"`
+
+	// LocalAPISocket is the path of the Unix socket the local API of the agent is served on,
+	// the local API is disabled when empty
+	// +optional
+	LocalAPISocket string `json:"localAPISocket,omitempty"`
+
+	// Proxy configures the proxy of the agent, containerd and the kubelet
+	// +optional
+	Proxy ProxyConfiguration `json:"proxy,omitempty"`
+
+	// Logging configures the logs of the agent
+	// +optional
+	Logging LoggingConfiguration `json:"logging,omitempty"`
+
+	// Failover configures the standby management clusters the agent fails over to
+	// +optional
+	Failover FailoverConfiguration `json:"failover,omitempty"`
+
+	// Watchdog configures when the agent restarts its hung or crash looping subsystems
+	// +optional
+	Watchdog WatchdogConfiguration `json:"watchdog,omitempty"`
+
+	// NodeComponents configures the node components the agent keeps running once the node is bootstrapped
+	// +optional
+	NodeComponents NodeComponentsConfiguration `json:"nodeComponents,omitempty"`
+
+	// Tracing configures the export of the spans of the bootstrap of the host
+	// +optional
+	Tracing TracingConfiguration `json:"tracing,omitempty"`
+
+	// FeatureGates enables or disables the features of the agent
+	// +optional
+	FeatureGates map[string]bool `json:"featureGates,omitempty"`
+}
+
+// MetricsConfiguration configures the metrics endpoint of the agent
+type MetricsConfiguration struct {
+	// BindAddress is the TCP address the metrics are served on, "0" disables them
+	// +optional
+	BindAddress string `json:"bindAddress,omitempty"`
+
+	// TLSCertFile is the certificate the metrics are served with over TLS
+	// +optional
+	TLSCertFile string `json:"tlsCertFile,omitempty"`
+
+	// TLSKeyFile is the private key of the metrics serving certificate
+	// +optional
+	TLSKeyFile string `json:"tlsKeyFile,omitempty"`
+
+	// TLSClientCAFile is the CA bundle verifying the client certificates of the scrapers
+	// +optional
+	TLSClientCAFile string `json:"tlsClientCAFile,omitempty"`
+}
+
+// ProxyConfiguration configures the proxy the agent, containerd and the kubelet go through
+type ProxyConfiguration struct {
+	// +optional
+	HTTPProxy string `json:"httpProxy,omitempty"`
+
+	// +optional
+	HTTPSProxy string `json:"httpsProxy,omitempty"`
+
+	// +optional
+	NoProxy string `json:"noProxy,omitempty"`
+}
+
+// KeyStoreConfiguration configures the key store of the private key of the host client certificate
+type KeyStoreConfiguration struct {
+	// Type is the key store: file, tpm or pkcs11
+	// +optional
+	Type string `json:"type,omitempty"`
+
+	// PrivateKeyDir is the directory the private key file, or the client certificate of the tpm
+	// and pkcs11 key stores, is kept in
+	// +optional
+	PrivateKeyDir string `json:"privateKeyDir,omitempty"`
+
+	// TPMDevice is the path of the TPM device of the tpm key store
+	// +optional
+	TPMDevice string `json:"tpmDevice,omitempty"`
+
+	// PKCS11Module is the path of the PKCS#11 module of the pkcs11 key store
+	// +optional
+	PKCS11Module string `json:"pkcs11Module,omitempty"`
+
+	// PKCS11TokenLabel is the label of the token of the pkcs11 key store
+	// +optional
+	PKCS11TokenLabel string `json:"pkcs11TokenLabel,omitempty"`
+
+	// PKCS11KeyLabel is the label of the key pair in the token of the pkcs11 key store
+	// +optional
+	PKCS11KeyLabel string `json:"pkcs11KeyLabel,omitempty"`
+}
+
+// CredentialEncryptionConfiguration configures the encryption at rest of the host credentials
+type CredentialEncryptionConfiguration struct {
+	// Type is the credential encryption: none, key-file or tpm
+	// +optional
+	Type string `json:"type,omitempty"`
+
+	// KeyFile is the path of the 32 bytes AES-256 key of the key-file encryption
+	// +optional
+	KeyFile string `json:"keyFile,omitempty"`
+}
+
+// LoggingConfiguration configures the logs of the agent
+type LoggingConfiguration struct {
+	// Verbosity is the klog verbosity of the agent logs. It is reloaded on SIGHUP.
+	// +optional
+	Verbosity *int32 `json:"verbosity,omitempty"`
+
+	// ModuleVerbosity is the verbosity of the modules of the agent, e.g. installer or controller,
+	// overriding Verbosity for their logs. It is reloaded on SIGHUP.
+	// +optional
+	ModuleVerbosity map[string]int32 `json:"moduleVerbosity,omitempty"`
+
+	// Format is the format of the agent logs, json or text
+	// +optional
+	Format string `json:"format,omitempty"`
+
+	// File configures the local log file the JSON logs are also written to
+	// +optional
+	File LogFileConfiguration `json:"file,omitempty"`
+
+	// Stream streams the structured logs of the agent to a ConfigMap of the host in the
+	// management cluster
+	// +optional
+	Stream bool `json:"stream,omitempty"`
+
+	// StreamMaxSize is the size cap in bytes of the streamed logs
+	// +optional
+	StreamMaxSize int `json:"streamMaxSize,omitempty"`
+}
+
+// LogFileConfiguration configures the local log file of the agent, rotated by size
+type LogFileConfiguration struct {
+	// Path is the path of the log file
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// MaxSize is the size in megabytes the log file is rotated at
+	// +optional
+	MaxSize int `json:"maxSize,omitempty"`
+
+	// MaxBackups is the number of rotated log files kept
+	// +optional
+	MaxBackups int `json:"maxBackups,omitempty"`
+
+	// MaxAge is the number of days the rotated log files are kept
+	// +optional
+	MaxAge int `json:"maxAge,omitempty"`
+}
+
+// FailoverConfiguration configures the failover of the agent to the standby management clusters
+type FailoverConfiguration struct {
+	// Kubeconfigs are the paths of the kubeconfigs of the standby management clusters, in the
+	// order the agent fails over to them
+	// +optional
+	Kubeconfigs []string `json:"kubeconfigs,omitempty"`
+
+	// ProbeInterval is how often the management cluster is probed
+	// +optional
+	ProbeInterval *metav1.Duration `json:"probeInterval,omitempty"`
+
+	// FailureThreshold is the number of probes in a row the management cluster has to fail
+	// before the agent fails over
+	// +optional
+	FailureThreshold int `json:"failureThreshold,omitempty"`
+}
+
+// WatchdogConfiguration configures the supervision of the reconciles of the agent
+type WatchdogConfiguration struct {
+	// HungTimeout is how long a reconcile may run before the agent restarts its subsystems
+	// +optional
+	HungTimeout *metav1.Duration `json:"hungTimeout,omitempty"`
+
+	// CrashLoopThreshold is the number of panics within the crash loop window after which the
+	// agent restarts its subsystems
+	// +optional
+	CrashLoopThreshold int `json:"crashLoopThreshold,omitempty"`
+
+	// CrashLoopWindow is the window the panics are counted in
+	// +optional
+	CrashLoopWindow *metav1.Duration `json:"crashLoopWindow,omitempty"`
+}
+
+// NodeComponentsConfiguration configures the restarts of the failed node components
+type NodeComponentsConfiguration struct {
+	// Units are the systemd units of the node components, an empty list leaves them to systemd
+	// +optional
+	Units []string `json:"units,omitempty"`
+
+	// CheckInterval is how often the units are checked
+	// +optional
+	CheckInterval *metav1.Duration `json:"checkInterval,omitempty"`
+}
+
+// TracingConfiguration configures the OpenTelemetry collector the spans of the agent are exported to
+type TracingConfiguration struct {
+	// Endpoint is the OTLP/HTTP endpoint of the collector, e.g. otel-collector:4318, nothing is traced when empty
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Insecure exports the spans without TLS
+	// +optional
+	Insecure bool `json:"insecure,omitempty"`
+}
@@ -0,0 +1,83 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BootstrapKubeconfigSpec defines the desired state of BootstrapKubeconfig
+type BootstrapKubeconfigSpec struct {
+	// APIServer is the address of the management cluster (https://hostname:port)
+	// +kubebuilder:validation:Pattern=`^https://`
+	APIServer string `json:"apiserver"`
+
+	// CertificateAuthorityData contains the PEM-encoded certificate authority
+	// certificates of the management cluster
+	// +optional
+	CertificateAuthorityData string `json:"certificateAuthorityData,omitempty"`
+
+	// InsecureSkipTLSVerify skips the validity check for the server's certificate
+	// +optional
+	InsecureSkipTLSVerify bool `json:"insecureSkipTLSVerify,omitempty"`
+
+	// TTL is how long the bootstrap credentials are valid for, defaults to 30 minutes
+	// +optional
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+
+	// NamespaceScopedAgent mints, instead of a bootstrap kubeconfig, a kubeconfig the host agents run
+	// with through --kubeconfig, which only grants the least privileges of a host agent in the namespace
+	// of the BootstrapKubeconfig. Its credentials do not expire, they are revoked by deleting the
+	// BootstrapKubeconfig.
+	// +optional
+	NamespaceScopedAgent bool `json:"namespaceScopedAgent,omitempty"`
+}
+
+// BootstrapKubeconfigStatus defines the observed state of BootstrapKubeconfig
+type BootstrapKubeconfigStatus struct {
+	// BootstrapKubeconfigData is the kubeconfig to pass to the host agent
+	// with --bootstrap-kubeconfig. It only allows requesting the host certificate.
+	// +optional
+	BootstrapKubeconfigData *string `json:"bootstrapKubeconfigData,omitempty"`
+
+	// ExpirationTime is when the credentials of the bootstrap kubeconfig expire
+	// +optional
+	ExpirationTime *metav1.Time `json:"expirationTime,omitempty"`
+
+	// AgentKubeconfigData is the namespace-scoped kubeconfig to pass to the host agents with
+	// --kubeconfig, minted when NamespaceScopedAgent is set
+	// +optional
+	AgentKubeconfigData *string `json:"agentKubeconfigData,omitempty"`
+
+	// ServiceAccountName is the ServiceAccount the namespace-scoped agent kubeconfig authenticates as
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:path=bootstrapkubeconfigs,scope=Namespaced,shortName=bkc
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Expiration",type="string",JSONPath=".status.expirationTime"
+
+// BootstrapKubeconfig is the Schema for the bootstrapkubeconfigs API
+type BootstrapKubeconfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BootstrapKubeconfigSpec   `json:"spec,omitempty"`
+	Status BootstrapKubeconfigStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// BootstrapKubeconfigList contains a list of BootstrapKubeconfig
+type BootstrapKubeconfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BootstrapKubeconfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BootstrapKubeconfig{}, &BootstrapKubeconfigList{})
+}
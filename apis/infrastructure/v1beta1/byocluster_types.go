@@ -13,6 +13,13 @@ const (
 	// resources associated with ByoCluster before removing it from the
 	// API server.
 	ClusterFinalizer = "byocluster.infrastructure.cluster.x-k8s.io"
+
+	// DefaultAPIEndpointPort is the port the control plane endpoint defaults to
+	// when only the host is set.
+	DefaultAPIEndpointPort = 6443
+
+	// DefaultKubeVIPImage is the kube-vip image deployed on the control plane hosts when none is set
+	DefaultKubeVIPImage = "ghcr.io/kube-vip/kube-vip:v0.4.1"
 )
 
 // ByoClusterSpec defines the desired state of ByoCluster
@@ -28,6 +35,64 @@ type ByoClusterSpec struct {
 
 	// BundleLookupTag is the tag of the BYOH bundle to be used
 	BundleLookupTag string `json:"bundleLookupTag,omitempty"`
+
+	// KubeVIP, when set, has kube-vip deployed as a static pod on the control plane hosts,
+	// serving the ControlPlaneEndpoint host as a virtual IP
+	// +optional
+	KubeVIP *KubeVIPSpec `json:"kubeVIP,omitempty"`
+
+	// LoadBalancer, when set, has the control plane machines reconciled as the backends of
+	// an external load balancer serving the ControlPlaneEndpoint
+	// +optional
+	LoadBalancer *LoadBalancerSpec `json:"loadBalancer,omitempty"`
+
+	// FailureDomainLabel is the label of the ByoHosts whose values are the failure domains of the
+	// cluster, e.g. a rack or site label. The failure domains are reported in the status, for the
+	// control plane and the MachineDeployments to spread their machines across them, and the
+	// ByoMachines are attached to the hosts of the failure domain of their Machine.
+	// +optional
+	FailureDomainLabel string `json:"failureDomainLabel,omitempty"`
+}
+
+// KubeVIPSpec configures the kube-vip static pods serving the control plane endpoint
+type KubeVIPSpec struct {
+	// Image is the kube-vip image, defaults to DefaultKubeVIPImage
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Interface is the network interface the virtual IP is advertised on,
+	// defaults to the default network interface of every control plane host
+	// +optional
+	Interface string `json:"interface,omitempty"`
+}
+
+// LoadBalancerType is the kind of external load balancer serving the control plane endpoint
+type LoadBalancerType string
+
+const (
+	// HAProxyLoadBalancer has the load balancer configuration generated as a haproxy.cfg
+	HAProxyLoadBalancer LoadBalancerType = "HAProxy"
+	// NGINXLoadBalancer has the load balancer configuration generated as an nginx.conf stream block
+	NGINXLoadBalancer LoadBalancerType = "NGINX"
+)
+
+// LoadBalancerSpec configures the external load balancer serving the control plane endpoint
+type LoadBalancerSpec struct {
+	// Type is the kind of load balancer the configuration is generated for
+	// +kubebuilder:validation:Enum=HAProxy;NGINX
+	// +kubebuilder:default=HAProxy
+	// +optional
+	Type LoadBalancerType `json:"type,omitempty"`
+
+	// ConfigMapName is the ConfigMap, in the namespace of the ByoCluster, the load balancer
+	// configuration is written to. Defaults to "<byocluster name>-control-plane-lb"
+	// +optional
+	ConfigMapName string `json:"configMapName,omitempty"`
+
+	// BackendPort is the port the API servers of the control plane machines serve on,
+	// defaults to DefaultAPIEndpointPort
+	// +optional
+	BackendPort int32 `json:"backendPort,omitempty"`
 }
 
 // ByoClusterStatus defines the observed state of ByoCluster
@@ -40,7 +105,26 @@ type ByoClusterStatus struct {
 	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
 
 	// FailureDomains is a list of failure domain objects synced from the infrastructure provider.
+	// They are the values of the FailureDomainLabel of the ByoHosts the cluster can use.
 	FailureDomains clusterv1.FailureDomains `json:"failureDomains,omitempty"`
+
+	// ReadyHosts is the number of ByoHosts attached to the cluster whose node is bootstrapped
+	// and which report no error
+	// +optional
+	ReadyHosts int32 `json:"readyHosts"`
+
+	// ProvisioningMachines is the number of ByoMachines of the cluster which are not ready yet
+	// +optional
+	ProvisioningMachines int32 `json:"provisioningMachines"`
+
+	// FailedMachines is the number of ByoMachines of the cluster which failed to provision,
+	// or whose ByoHost reports an error
+	// +optional
+	FailedMachines int32 `json:"failedMachines"`
+
+	// ObservedGeneration is the latest generation of the ByoCluster reconciled by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
 
 // APIEndpoint represents a reachable Kubernetes API endpoint.
@@ -55,6 +139,12 @@ type APIEndpoint struct {
 //+kubebuilder:object:root=true
 //+kubebuilder:resource:path=byoclusters,scope=Namespaced,shortName=byoc
 //+kubebuilder:subresource:status
+//+kubebuilder:storageversion
+//+kubebuilder:printcolumn:name="Ready",type="boolean",JSONPath=".status.ready"
+//+kubebuilder:printcolumn:name="ReadyHosts",type="integer",JSONPath=".status.readyHosts"
+//+kubebuilder:printcolumn:name="Provisioning",type="integer",JSONPath=".status.provisioningMachines"
+//+kubebuilder:printcolumn:name="Failed",type="integer",JSONPath=".status.failedMachines"
+//+kubebuilder:printcolumn:name="FleetHealthy",type="string",JSONPath=".status.conditions[?(@.type=='FleetHealthy')].status",priority=1
 
 // ByoCluster is the Schema for the byoclusters API
 type ByoCluster struct {
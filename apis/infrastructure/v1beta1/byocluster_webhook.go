@@ -5,6 +5,7 @@ package v1beta1
 
 import (
 	"errors"
+	"net"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -30,6 +31,40 @@ var _ webhook.Defaulter = &ByoCluster{}
 
 // Default implements webhook.Defaulter so a webhook will be registered for the type
 func (byoCluster *ByoCluster) Default() {
+	defaultByoClusterSpec(&byoCluster.Spec)
+}
+
+// defaultByoClusterSpec sets the defaults shared by ByoCluster and ByoClusterTemplate,
+// so that a ClusterClass only needs to patch the control plane endpoint host
+func defaultByoClusterSpec(spec *ByoClusterSpec) {
+	if spec.ControlPlaneEndpoint.Host != "" && spec.ControlPlaneEndpoint.Port == 0 {
+		spec.ControlPlaneEndpoint.Port = DefaultAPIEndpointPort
+	}
+	if spec.KubeVIP != nil && spec.KubeVIP.Image == "" {
+		spec.KubeVIP.Image = DefaultKubeVIPImage
+	}
+	if spec.LoadBalancer != nil && spec.LoadBalancer.Type == "" {
+		spec.LoadBalancer.Type = HAProxyLoadBalancer
+	}
+}
+
+// validateControlPlaneEndpoint checks the control plane endpoint is managed by a single provider,
+// and that its host is a virtual IP kube-vip can serve
+func validateControlPlaneEndpoint(spec *ByoClusterSpec, fldPath *field.Path) field.ErrorList {
+	if spec.KubeVIP == nil {
+		return nil
+	}
+	if spec.LoadBalancer != nil {
+		return field.ErrorList{field.Forbidden(fldPath.Child("loadBalancer"), "cannot be set along with kubeVIP")}
+	}
+	if spec.ControlPlaneEndpoint.Host == "" {
+		return nil
+	}
+	if net.ParseIP(spec.ControlPlaneEndpoint.Host) == nil {
+		return field.ErrorList{field.Invalid(fldPath.Child("controlPlaneEndpoint", "host"), spec.ControlPlaneEndpoint.Host,
+			"must be an IP address to be served by kube-vip")}
+	}
+	return nil
 }
 
 // TODO(user): change verbs to "verbs=create;update;delete" if you want to enable deletion validation.
@@ -45,6 +80,9 @@ func (byoCluster *ByoCluster) ValidateCreate() error {
 			field.InternalError(nil, errors.New("cannot create ByoCluster without Spec.BundleLookupTag")),
 		})
 	}
+	if errs := validateControlPlaneEndpoint(&byoCluster.Spec, field.NewPath("spec")); len(errs) > 0 {
+		return apierrors.NewInvalid(byoCluster.GroupVersionKind().GroupKind(), byoCluster.Name, errs)
+	}
 
 	return nil
 }
@@ -57,6 +95,9 @@ func (byoCluster *ByoCluster) ValidateUpdate(old runtime.Object) error {
 			field.InternalError(nil, errors.New("cannot update ByoCluster with empty Spec.BundleLookupTag")),
 		})
 	}
+	if errs := validateControlPlaneEndpoint(&byoCluster.Spec, field.NewPath("spec")); len(errs) > 0 {
+		return apierrors.NewInvalid(byoCluster.GroupVersionKind().GroupKind(), byoCluster.Name, errs)
+	}
 	return nil
 }
 
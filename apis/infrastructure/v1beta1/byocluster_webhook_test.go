@@ -54,6 +54,40 @@ var _ = Describe("ByoclusterWebhook", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 
+		It("should default the control plane endpoint port when only the host is set", func() {
+			byoCluster.Name = "byocluster-default-port"
+			byoCluster.Spec.BundleLookupTag = "v0.1.0_alpha.2"
+			byoCluster.Spec.ControlPlaneEndpoint.Host = "10.10.10.10"
+			Expect(k8sClientUncached.Create(ctx, byoCluster)).Should(Succeed())
+			Expect(byoCluster.Spec.ControlPlaneEndpoint.Port).To(Equal(int32(byohv1beta1.DefaultAPIEndpointPort)))
+		})
+
+		It("should default the kube-vip image when kube-vip is enabled", func() {
+			byoCluster.Name = "byocluster-kube-vip"
+			byoCluster.Spec.BundleLookupTag = "v0.1.0_alpha.2"
+			byoCluster.Spec.ControlPlaneEndpoint.Host = "10.10.10.10"
+			byoCluster.Spec.KubeVIP = &byohv1beta1.KubeVIPSpec{}
+			Expect(k8sClientUncached.Create(ctx, byoCluster)).Should(Succeed())
+			Expect(byoCluster.Spec.KubeVIP.Image).To(Equal(byohv1beta1.DefaultKubeVIPImage))
+		})
+
+		It("should reject the request when kube-vip is enabled for a control plane endpoint hostname", func() {
+			byoCluster.Spec.BundleLookupTag = "v0.1.0_alpha.2"
+			byoCluster.Spec.ControlPlaneEndpoint.Host = "api.example.com"
+			byoCluster.Spec.KubeVIP = &byohv1beta1.KubeVIPSpec{}
+			err := k8sClientUncached.Create(ctx, byoCluster)
+			Expect(err).To(MatchError(ContainSubstring("spec.controlPlaneEndpoint.host: Invalid value: \"api.example.com\": must be an IP address to be served by kube-vip")))
+		})
+
+		It("should reject the request when both kube-vip and a load balancer manage the control plane endpoint", func() {
+			byoCluster.Spec.BundleLookupTag = "v0.1.0_alpha.2"
+			byoCluster.Spec.ControlPlaneEndpoint.Host = "10.10.10.10"
+			byoCluster.Spec.KubeVIP = &byohv1beta1.KubeVIPSpec{}
+			byoCluster.Spec.LoadBalancer = &byohv1beta1.LoadBalancerSpec{}
+			err := k8sClientUncached.Create(ctx, byoCluster)
+			Expect(err).To(MatchError(ContainSubstring("spec.loadBalancer: Forbidden: cannot be set along with kubeVIP")))
+		})
+
 	})
 
 	Context("When ByoCluster gets an update request", func() {
@@ -0,0 +1,70 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1beta1
+
+import (
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var byoclustertemplatelog = logf.Log.WithName("byoclustertemplate-resource")
+
+// SetupWebhookWithManager sets up the webhook for the byoclustertemplate resource
+func (byoClusterTemplate *ByoClusterTemplate) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(byoClusterTemplate).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-infrastructure-cluster-x-k8s-io-v1beta1-byoclustertemplate,mutating=true,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=byoclustertemplates,verbs=create;update,versions=v1beta1,name=mbyoclustertemplate.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Defaulter = &ByoClusterTemplate{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (byoClusterTemplate *ByoClusterTemplate) Default() {
+	defaultByoClusterSpec(&byoClusterTemplate.Spec.Template.Spec)
+}
+
+//+kubebuilder:webhook:path=/validate-infrastructure-cluster-x-k8s-io-v1beta1-byoclustertemplate,mutating=false,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=byoclustertemplates,verbs=create;update,versions=v1beta1,name=vbyoclustertemplate.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &ByoClusterTemplate{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (byoClusterTemplate *ByoClusterTemplate) ValidateCreate() error {
+	if errs := validateControlPlaneEndpoint(&byoClusterTemplate.Spec.Template.Spec, field.NewPath("spec", "template", "spec")); len(errs) > 0 {
+		return apierrors.NewInvalid(byoClusterTemplate.GroupVersionKind().GroupKind(), byoClusterTemplate.Name, errs)
+	}
+	return nil
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+// Templates referenced by a ClusterClass are rebuilt rather than mutated, so the
+// template spec is immutable.
+func (byoClusterTemplate *ByoClusterTemplate) ValidateUpdate(old runtime.Object) error {
+	byoclustertemplatelog.Info("validate update", "name", byoClusterTemplate.Name)
+	oldTemplate, ok := old.(*ByoClusterTemplate)
+	if !ok {
+		return apierrors.NewBadRequest("expected a ByoClusterTemplate")
+	}
+
+	if !reflect.DeepEqual(byoClusterTemplate.Spec.Template.Spec, oldTemplate.Spec.Template.Spec) {
+		return apierrors.NewInvalid(byoClusterTemplate.GroupVersionKind().GroupKind(), byoClusterTemplate.Name, field.ErrorList{
+			field.Invalid(field.NewPath("spec", "template", "spec"), byoClusterTemplate.Spec.Template.Spec,
+				"ByoClusterTemplate spec.template.spec field is immutable. Please create a new resource instead."),
+		})
+	}
+	return nil
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (byoClusterTemplate *ByoClusterTemplate) ValidateDelete() error {
+	return nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1beta1_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	byohv1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("ByoClusterTemplateWebhook", func() {
+	var (
+		byoClusterTemplate *byohv1beta1.ByoClusterTemplate
+		ctx                context.Context
+		k8sClientUncached  client.Client
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		var clientErr error
+		k8sClientUncached, clientErr = client.New(cfg, client.Options{Scheme: scheme.Scheme})
+		Expect(clientErr).NotTo(HaveOccurred())
+
+		byoClusterTemplate = &byohv1beta1.ByoClusterTemplate{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "byoclustertemplate-",
+				Namespace:    "default",
+			},
+			Spec: byohv1beta1.ByoClusterTemplateSpec{
+				Template: byohv1beta1.ByoClusterTemplateResource{
+					Spec: byohv1beta1.ByoClusterSpec{
+						BundleLookupTag: "v0.1.0_alpha.2",
+						ControlPlaneEndpoint: byohv1beta1.APIEndpoint{
+							Host: "10.10.10.10",
+						},
+					},
+				},
+			},
+		}
+		Expect(k8sClientUncached.Create(ctx, byoClusterTemplate)).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(k8sClientUncached.Delete(ctx, byoClusterTemplate)).Should(Succeed())
+	})
+
+	It("should default the control plane endpoint port", func() {
+		Expect(byoClusterTemplate.Spec.Template.Spec.ControlPlaneEndpoint.Port).To(Equal(int32(byohv1beta1.DefaultAPIEndpointPort)))
+	})
+
+	It("should reject changes to the template spec", func() {
+		byoClusterTemplate.Spec.Template.Spec.BundleLookupTag = "new_tag"
+		err := k8sClientUncached.Update(ctx, byoClusterTemplate)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("ByoClusterTemplate spec.template.spec field is immutable. Please create a new resource instead."))
+	})
+
+	It("should allow changes to the template metadata", func() {
+		byoClusterTemplate.Labels = map[string]string{"topology.cluster.x-k8s.io/owned": ""}
+		Expect(k8sClientUncached.Update(ctx, byoClusterTemplate)).Should(Succeed())
+	})
+})
@@ -4,7 +4,9 @@
 package v1beta1
 
 import (
+	certv1 "k8s.io/api/certificates/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
@@ -14,14 +16,103 @@ const (
 	HostCleanupAnnotation = "byoh.infrastructure.cluster.x-k8s.io/unregistering"
 	// EndPointIPAnnotation annotation used to store the IP address of the endpoint
 	EndPointIPAnnotation = "byoh.infrastructure.cluster.x-k8s.io/endpointip"
+	// EndPointPortAnnotation annotation used to store the port of the endpoint
+	EndPointPortAnnotation = "byoh.infrastructure.cluster.x-k8s.io/endpointport"
+	// KubeVIPImageAnnotation annotation used to store the kube-vip image deployed on a control plane host
+	KubeVIPImageAnnotation = "byoh.infrastructure.cluster.x-k8s.io/kube-vip-image"
+	// KubeVIPInterfaceAnnotation annotation used to store the network interface kube-vip advertises the endpoint on
+	KubeVIPInterfaceAnnotation = "byoh.infrastructure.cluster.x-k8s.io/kube-vip-interface"
 	// K8sVersionAnnotation annotation used to store the k8s version
 	K8sVersionAnnotation = "byoh.infrastructure.cluster.x-k8s.io/k8sversion"
 	// AttachedByoMachineLabel label used to mark a node name attached to a byo host
 	AttachedByoMachineLabel = "byoh.infrastructure.cluster.x-k8s.io/byomachine-name"
+	// AttachedByoMachinePoolLabel label used to mark the byo machine pool a byo host is attached to
+	AttachedByoMachinePoolLabel = "byoh.infrastructure.cluster.x-k8s.io/byomachinepool-name"
 	// BundleLookupBaseRegistryAnnotation annotation used to store the base registry for the bundle lookup
 	BundleLookupBaseRegistryAnnotation = "byoh.infrastructure.cluster.x-k8s.io/bundle-registry"
 	// BundleLookupTagAnnotation annotation used to store the bundle tag
 	BundleLookupTagAnnotation = "byoh.infrastructure.cluster.x-k8s.io/bundle-tag"
+	// DesiredAgentVersionAnnotation annotation used to store the host agent version the host should be running
+	DesiredAgentVersionAnnotation = "byoh.infrastructure.cluster.x-k8s.io/desired-agent-version"
+	// AgentBinaryRepoAnnotation annotation used to store the OCI repository the host agent binary is pulled from
+	AgentBinaryRepoAnnotation = "byoh.infrastructure.cluster.x-k8s.io/agent-binary-repo"
+	// UnschedulableAnnotation annotation used to keep a host in the capacity pool from being attached to new machines,
+	// e.g. with kubectl annotate byohost <host> byoh.infrastructure.cluster.x-k8s.io/unschedulable=
+	UnschedulableAnnotation = "byoh.infrastructure.cluster.x-k8s.io/unschedulable"
+	// AttestedAnnotation annotation formerly set on a host CSR by an attestation service once the host has been verified.
+	//
+	// Deprecated: the annotation is ignored, the requester of the CSR being able to set it. The attestation service
+	// adds the HostAttestedCondition to the status of the CSR instead.
+	AttestedAnnotation = "byoh.infrastructure.cluster.x-k8s.io/attested"
+	// ApproveAnnotation annotation formerly used to approve a pending host CSR regardless of the approval policy.
+	//
+	// Deprecated: the annotation is ignored, the requester of the CSR being able to set it. The pending host
+	// CSRs are approved with kubectl certificate approve <csr>.
+	ApproveAnnotation = "byoh.infrastructure.cluster.x-k8s.io/approve"
+	// DenyAnnotation annotation used to deny a pending host CSR, its value is recorded as the denial message
+	DenyAnnotation = "byoh.infrastructure.cluster.x-k8s.io/deny"
+	// HostRegisteredAnnotation annotation set on an approved host CSR once the ByoHost of the host is created,
+	// the ByoHost is not created again after it is deleted
+	HostRegisteredAnnotation = "byoh.infrastructure.cluster.x-k8s.io/host-registered"
+	// DecommissionAnnotation annotation used to request the host to be detached before it is removed
+	DecommissionAnnotation = "byoh.infrastructure.cluster.x-k8s.io/decommission"
+	// NodeIPAnnotation annotation used to pin the IP address the kubelet registers the node with, or
+	// the comma separated IPv4 and IPv6 addresses of a dual-stack node
+	NodeIPAnnotation = "byoh.infrastructure.cluster.x-k8s.io/node-ip"
+	// IPFamiliesAnnotation annotation used to store the comma separated IP families of the cluster of the attached
+	// machine, primary family first, when the cluster is IPv6-only or dual-stack
+	IPFamiliesAnnotation = "byoh.infrastructure.cluster.x-k8s.io/ip-families"
+	// AgentLabelsAnnotation annotation used to record the comma separated keys of the labels owned by the host agent
+	AgentLabelsAnnotation = "byoh.infrastructure.cluster.x-k8s.io/agent-labels"
+	// KubeletExtraArgsAnnotation annotation used to store the space separated kubelet flags of the attached machine
+	KubeletExtraArgsAnnotation = "byoh.infrastructure.cluster.x-k8s.io/kubelet-extra-args"
+	// KubeletConfigurationAnnotation annotation used to store the KubeletConfiguration patch of the attached machine,
+	// merged into the kubelet configuration written by kubeadm
+	KubeletConfigurationAnnotation = "byoh.infrastructure.cluster.x-k8s.io/kubelet-configuration"
+	// K8sDistributionAnnotation annotation used to store the Kubernetes distribution of the attached machine, unset for kubeadm
+	K8sDistributionAnnotation = "byoh.infrastructure.cluster.x-k8s.io/k8s-distribution"
+	// RetryAnnotation annotation used to reset the retry backoff of the host agent, and resume the retries stopped
+	// after repeated installation failures, e.g. with kubectl annotate byohost <host> byoh.infrastructure.cluster.x-k8s.io/retry=
+	RetryAnnotation = "byoh.infrastructure.cluster.x-k8s.io/retry"
+	// SkipGarbageCollectionAnnotation annotation used to keep a stale host from being deleted by the garbage collection
+	// of the registrations, e.g. a host powered off for a long maintenance
+	SkipGarbageCollectionAnnotation = "byoh.infrastructure.cluster.x-k8s.io/skip-garbage-collection"
+	// HostUIDAnnotation annotation used to store the unique identity of the host registering the ByoHost, another
+	// host with the same name cannot take the ByoHost over
+	HostUIDAnnotation = "byoh.infrastructure.cluster.x-k8s.io/host-uid"
+	// DeletionPolicyAnnotation annotation used to set what the host agent does with the host when its ByoHost is deleted,
+	// e.g. with kubectl annotate byohost <host> byoh.infrastructure.cluster.x-k8s.io/deletion-policy=Uninstall
+	DeletionPolicyAnnotation = "byoh.infrastructure.cluster.x-k8s.io/deletion-policy"
+	// ExpectedHostLabel label used to mark the ByoHosts imported from a host inventory before their host
+	// registers, e.g. listed with kubectl get byohosts -l byoh.infrastructure.cluster.x-k8s.io/expected.
+	// The host agent removes it when the host registers, the expected hosts are not attached to machines.
+	ExpectedHostLabel = "byoh.infrastructure.cluster.x-k8s.io/expected"
+	// MaintenanceWindowAnnotation annotation used to restrict the disruptive operations of the host, the agent upgrades,
+	// the host cleanups and uninstalls and the decommissioning, to maintenance windows, e.g. "0 2 * * SAT 4h" for four
+	// hours from 2am on Saturdays. Several windows are separated by semicolons.
+	MaintenanceWindowAnnotation = "byoh.infrastructure.cluster.x-k8s.io/maintenance-window"
+	// RebootApprovedAnnotation annotation used to approve the reboot the installation of the k8s components is waiting
+	// for, see RebootRequiredReason. The host agent removes it before rebooting the host.
+	RebootApprovedAnnotation = "byoh.infrastructure.cluster.x-k8s.io/reboot-approved"
+	// QuarantineAnnotation annotation used to quarantine a host for forensics, e.g. with kubectl annotate byohost <host>
+	// byoh.infrastructure.cluster.x-k8s.io/quarantine="<reason>". The host is detached from its machine, which is replaced,
+	// without being reset, and the host agent leaves the host as it is until the annotation is removed.
+	QuarantineAnnotation = "byoh.infrastructure.cluster.x-k8s.io/quarantine"
+)
+
+// HostAttestedCondition condition added with status True to a host CSR by an attestation service once the host
+// has been verified, through the status subresource of the CSR which its requester cannot update
+const HostAttestedCondition certv1.RequestConditionType = "HostAttested"
+
+const (
+	// DeletionPolicyRetain leaves the host as it is when its ByoHost is deleted, the default deletion policy
+	DeletionPolicyRetain = "Retain"
+	// DeletionPolicyUninstall has the host agent reset the node, uninstall the k8s components and remove
+	// the downloaded bundles before its ByoHost is deleted
+	DeletionPolicyUninstall = "Uninstall"
+	// HostUninstallFinalizer allows the host agent to uninstall the host before its ByoHost is deleted,
+	// it is set on the ByoHosts with the Uninstall deletion policy
+	HostUninstallFinalizer = "byohost.infrastructure.cluster.x-k8s.io/uninstall"
 )
 
 // ByoHostSpec defines the desired state of ByoHost
@@ -35,6 +126,29 @@ type ByoHostSpec struct {
 	// generated by InstallerController for K8s installation
 	// +optional
 	InstallationSecret *corev1.ObjectReference `json:"installationSecret,omitempty"`
+
+	// AllowedNamespaces are the namespaces, besides the one of the host, whose
+	// ByoMachines are allowed to claim the host. "*" allows every namespace.
+	// The ByoMachine namespace also has to be granted the "use" verb on the
+	// byohosts of the host namespace.
+	// +optional
+	AllowedNamespaces []string `json:"allowedNamespaces,omitempty"`
+
+	// Reserved keeps the host out of the capacity pool, it is only attached
+	// to the ByoMachines pinning it through their HostRef.
+	// +optional
+	Reserved bool `json:"reserved,omitempty"`
+
+	// ReservedFor is the Cluster the host is dedicated to, only the ByoMachines
+	// of this Cluster can claim the host. The namespace defaults to the one of the host.
+	// +optional
+	ReservedFor *corev1.ObjectReference `json:"reservedFor,omitempty"`
+
+	// Unschedulable takes the host out of the capacity pool for maintenance, it is not attached
+	// to new machines while the machine it is attached to, if any, keeps running.
+	// The UnschedulableAnnotation has the same effect.
+	// +optional
+	Unschedulable bool `json:"unschedulable,omitempty"`
 }
 
 // HostInfo is a set of details about the host platform.
@@ -47,6 +161,87 @@ type HostInfo struct {
 
 	// The Architecture reported by the host.
 	Architecture string `json:"architecture,omitempty"`
+
+	// GPUs are the GPUs found by a PCI scan of the host, grouped by vendor and model.
+	// The display controllers of the BMCs are left out.
+	// +optional
+	GPUs []GPUInfo `json:"gpus,omitempty"`
+
+	// HugePages are the huge pages preallocated on the host, for each page size.
+	// +optional
+	HugePages []HugePagesInfo `json:"hugePages,omitempty"`
+
+	// SRIOVNetworkInterfaces are the network interfaces of the host capable of SR-IOV.
+	// +optional
+	SRIOVNetworkInterfaces []SRIOVNetworkInterface `json:"sriovNetworkInterfaces,omitempty"`
+
+	// CPUTopology is the topology of the CPUs of the host, with its NUMA nodes.
+	// +optional
+	CPUTopology *CPUTopology `json:"cpuTopology,omitempty"`
+}
+
+// GPUInfo is a group of identical GPUs of a host
+type GPUInfo struct {
+	// VendorID is the PCI vendor ID of the GPUs, e.g. 10de.
+	VendorID string `json:"vendorID"`
+
+	// DeviceID is the PCI device ID of the GPUs, e.g. 20b5.
+	DeviceID string `json:"deviceID"`
+
+	// Vendor is the name of the vendor, e.g. NVIDIA, or the vendor ID when it is unknown.
+	// +optional
+	Vendor string `json:"vendor,omitempty"`
+
+	// Model is the name of the model from the PCI ID database of the host, or the device ID
+	// when it is unknown.
+	// +optional
+	Model string `json:"model,omitempty"`
+
+	// Count is the number of GPUs of the vendor and model.
+	Count int32 `json:"count"`
+}
+
+// HugePagesInfo is the number of huge pages of a page size
+type HugePagesInfo struct {
+	// PageSize is the size of the huge pages, e.g. 2Mi or 1Gi.
+	PageSize resource.Quantity `json:"pageSize"`
+
+	// Count is the number of huge pages of the size.
+	Count int64 `json:"count"`
+}
+
+// SRIOVNetworkInterface is a network interface capable of SR-IOV
+type SRIOVNetworkInterface struct {
+	// Name is the name of the network interface.
+	Name string `json:"name"`
+
+	// TotalVFs is the number of virtual functions the network interface supports.
+	TotalVFs int32 `json:"totalVFs"`
+}
+
+// CPUTopology is the topology of the CPUs of a host
+type CPUTopology struct {
+	// Sockets is the number of CPU sockets.
+	Sockets int32 `json:"sockets"`
+
+	// Cores is the number of physical cores, across the sockets.
+	Cores int32 `json:"cores"`
+
+	// CPUs is the number of logical CPUs, i.e. the hardware threads of the cores.
+	CPUs int32 `json:"cpus"`
+
+	// NUMANodes are the NUMA nodes of the host, with their CPUs.
+	// +optional
+	NUMANodes []NUMANode `json:"numaNodes,omitempty"`
+}
+
+// NUMANode is a NUMA node of a host
+type NUMANode struct {
+	// ID is the number of the node.
+	ID int32 `json:"id"`
+
+	// CPUs is the list of the logical CPUs of the node, in the format of the kernel, e.g. 0-15,32-47.
+	CPUs string `json:"cpus"`
 }
 
 // ByoHostStatus defines the observed state of ByoHost
@@ -57,6 +252,8 @@ type ByoHostStatus struct {
 	MachineRef *corev1.ObjectReference `json:"machineRef,omitempty"`
 
 	// Conditions defines current service state of the BYOMachine.
+	// +listType=map
+	// +listMapKey=type
 	// +optional
 	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
 
@@ -68,14 +265,202 @@ type ByoHostStatus struct {
 	// network interfaces.
 	// +optional
 	Network []NetworkStatus `json:"network,omitempty"`
+
+	// AgentVersion is the version of the host agent running on the host.
+	// +optional
+	AgentVersion string `json:"agentVersion,omitempty"`
+
+	// AgentInfo is the build and the configuration of the host agent running on the host, to audit
+	// the configuration drift across the hosts.
+	// +optional
+	AgentInfo *AgentInfo `json:"agentInfo,omitempty"`
+
+	// Backoff is the state of the retries of the host agent, for each class of errors failing
+	// its reconciles. The class is removed once the failing step succeeds.
+	// +listType=map
+	// +listMapKey=class
+	// +optional
+	Backoff []ErrorBackoff `json:"backoff,omitempty"`
+
+	// InstallerAudit summarizes the commands run by the in-tree installer during its last
+	// install or uninstall. Each command is recorded in the audit log of the host agent.
+	// +optional
+	InstallerAudit *InstallerAuditSummary `json:"installerAudit,omitempty"`
+
+	// LastHeartbeatTime is when the host agent last reported that it is running. The hosts
+	// without a MachineRef are deleted once their heartbeat is older than the stale host
+	// timeout of the controller, when set.
+	// +optional
+	LastHeartbeatTime *metav1.Time `json:"lastHeartbeatTime,omitempty"`
+
+	// Node mirrors the health and the kubelet version of the node of the host in the workload
+	// cluster of its ByoMachine.
+	// +optional
+	Node *NodeStatus `json:"node,omitempty"`
+
+	// Hostname is the hostname of the host, which may differ from the name of the ByoHost
+	// +optional
+	Hostname string `json:"hostname,omitempty"`
+
+	// MachineID is the hashed machine identity the host is registered under, set when the ByoHost
+	// is named after it rather than after the hostname
+	// +optional
+	MachineID string `json:"machineID,omitempty"`
+
+	// InstalledMachineID is the hashed machine-id of the host when its node was bootstrapped. The
+	// host agent considers the host reimaged when the machine-id changes, and installs it again.
+	// +optional
+	InstalledMachineID string `json:"installedMachineID,omitempty"`
+
+	// InstallationProgress is the progress of the installation of the k8s components and of the
+	// bootstrap of the node of the host, reported by the host agent as it goes through the phases.
+	// +optional
+	InstallationProgress *InstallationProgress `json:"installationProgress,omitempty"`
+
+	// ObservedGeneration is the latest generation of the ByoHost its Ready condition was summarized for
+	// by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// InstallationPhase is a phase of the installation of a host
+type InstallationPhase string
+
+const (
+	// InstallationPhaseDownloading is the download of the bundle of the k8s components
+	InstallationPhaseDownloading InstallationPhase = "Downloading"
+
+	// InstallationPhaseInstalling is the installation of the k8s components of the bundle
+	InstallationPhaseInstalling InstallationPhase = "Installing"
+
+	// InstallationPhaseConfiguring is the configuration of the host and the bootstrap of its node
+	InstallationPhaseConfiguring InstallationPhase = "Configuring"
+
+	// InstallationPhaseCompleted is reached once the node of the host is bootstrapped
+	InstallationPhaseCompleted InstallationPhase = "Completed"
+)
+
+// Percentage returns the percentage of the installation completed when it enters the phase:
+// the download is 40% of the installation, the installation of the k8s components 40% more,
+// and the configuration of the host the last 20%
+func (p InstallationPhase) Percentage() int32 {
+	switch p {
+	case InstallationPhaseInstalling:
+		return 40 // nolint: gomnd
+	case InstallationPhaseConfiguring:
+		return 80 // nolint: gomnd
+	case InstallationPhaseCompleted:
+		return 100 // nolint: gomnd
+	default:
+		return 0
+	}
+}
+
+// InstallationProgress is the progress of the installation of a host
+type InstallationProgress struct {
+	// Phase is the phase the installation is in
+	// +kubebuilder:validation:Enum=Downloading;Installing;Configuring;Completed
+	Phase InstallationPhase `json:"phase"`
+
+	// Percentage is the percentage of the installation completed: 40 once the bundle is downloaded,
+	// 80 once the k8s components are installed and 100 once the node is bootstrapped
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	Percentage int32 `json:"percentage"`
+
+	// LastTransitionTime is when the installation entered the phase
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// AgentInfo is the build and the configuration of a host agent
+type AgentInfo struct {
+	// Version is the version of the host agent
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// GitCommit is the commit the host agent was built from
+	// +optional
+	GitCommit string `json:"gitCommit,omitempty"`
+
+	// GoVersion is the version of Go the host agent was built with
+	// +optional
+	GoVersion string `json:"goVersion,omitempty"`
+
+	// Platform is the OS and the architecture of the host agent, e.g. linux/amd64
+	// +optional
+	Platform string `json:"platform,omitempty"`
+
+	// FeatureGates are the feature gates of the host agent, with whether they are enabled
+	// +optional
+	FeatureGates map[string]bool `json:"featureGates,omitempty"`
+
+	// Flags are the flags of the host agent set to another value than their default, on its
+	// command line or in its configuration file. The credentials of the URLs are redacted.
+	// +optional
+	Flags map[string]string `json:"flags,omitempty"`
+}
+
+// InstallerAuditSummary summarizes the commands run by an installer operation
+type InstallerAuditSummary struct {
+	// Operation is the installer operation, Install or Uninstall
+	// +kubebuilder:validation:Enum=Install;Uninstall
+	Operation string `json:"operation"`
+
+	// StartTime is when the operation started
+	StartTime metav1.Time `json:"startTime"`
+
+	// Commands is the number of commands run
+	Commands int32 `json:"commands"`
+
+	// FailedCommands is the number of commands exiting with a non zero code
+	// +optional
+	FailedCommands int32 `json:"failedCommands,omitempty"`
+
+	// LastFailedCommand is the last command exiting with a non zero code, truncated
+	// +optional
+	LastFailedCommand string `json:"lastFailedCommand,omitempty"`
+
+	// LastFailedExitCode is the exit code of the LastFailedCommand
+	// +optional
+	LastFailedExitCode int32 `json:"lastFailedExitCode,omitempty"`
+}
+
+// ErrorBackoff is the exponential backoff of the retries of a class of errors
+type ErrorBackoff struct {
+	// Class is the class of the errors, one of BootstrapSecret, Installation, HostConfiguration,
+	// Bootstrap and Cleanup
+	Class string `json:"class"`
+
+	// Failures is the number of consecutive failures
+	Failures int32 `json:"failures"`
+
+	// LastError is the message of the last error
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+
+	// NextRetryTime is when the failed step is retried, unset once the retries are stopped
+	// +optional
+	NextRetryTime *metav1.Time `json:"nextRetryTime,omitempty"`
+
+	// RetriesStopped tells that the retries are stopped after too many consecutive failures,
+	// until the RetryAnnotation is set on the ByoHost
+	// +optional
+	RetriesStopped bool `json:"retriesStopped,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:resource:path=byohosts,scope=Namespaced,shortName=byoh
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Hostname",type="string",JSONPath=`.status.hostname`
 //+kubebuilder:printcolumn:name="OSName",type="string",JSONPath=`.status.hostinfo.osname`
 //+kubebuilder:printcolumn:name="OSImage",type="string",JSONPath=`.status.hostinfo.osimage`
 //+kubebuilder:printcolumn:name="Arch",type="string",JSONPath=`.status.hostinfo.architecture`
+//+kubebuilder:printcolumn:name="Progress",type="integer",JSONPath=`.status.installationProgress.percentage`,priority=1
+//+kubebuilder:printcolumn:name="NodeReady",type="string",JSONPath=`.status.node.ready`,priority=1
+//+kubebuilder:printcolumn:name="AgentVersion",type="string",JSONPath=`.status.agentVersion`,priority=1
+//+kubebuilder:printcolumn:name="Unschedulable",type="boolean",JSONPath=`.spec.unschedulable`,priority=1
+//+kubebuilder:printcolumn:name="LastHeartbeat",type="date",JSONPath=`.status.lastHeartbeatTime`,priority=1
 
 // ByoHost is the Schema for the byohosts API
 type ByoHost struct {
@@ -5,18 +5,35 @@ package v1beta1
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"reflect"
+	"strings"
 
 	v1 "k8s.io/api/admission/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
-//+kubebuilder:webhook:path=/validate-infrastructure-cluster-x-k8s-io-v1beta1-byohost,mutating=false,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=byohosts,verbs=create;update;delete,versions=v1beta1,name=vbyohost.kb.io,admissionReviewVersions={v1,v1beta1}
+//+kubebuilder:webhook:path=/validate-infrastructure-cluster-x-k8s-io-v1beta1-byohost,mutating=false,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=byohosts;byohosts/status,verbs=create;update;delete,versions=v1beta1,name=vbyohost.kb.io,admissionReviewVersions={v1,v1beta1}
+
+// HostAgentUsernamePrefix prefixes the username of the host agents, followed by the host name
+const HostAgentUsernamePrefix = "byoh:host:"
+
+var (
+	// hostAgentReleasedLabels are the labels set by the controller manager, a host agent
+	// can only remove them when the host is released
+	hostAgentReleasedLabels = []string{clusterv1.ClusterLabelName, AttachedByoMachineLabel, AttachedByoMachinePoolLabel}
+	// hostAgentAnnotations are the annotations a host agent can set on its ByoHost
+	hostAgentAnnotations = []string{UnschedulableAnnotation, DecommissionAnnotation, AgentLabelsAnnotation, HostUIDAnnotation}
+)
 
 // +k8s:deepcopy-gen=false
 // ByoHostValidator validates ByoHosts
 type ByoHostValidator struct {
-	//	Client  client.Client
+	// Client reads the ByoHostQuotas the registrations are checked against, no quota is enforced when nil
+	Client  client.Reader
 	decoder *admission.Decoder
 }
 
@@ -35,11 +52,162 @@ func (v *ByoHostValidator) Handle(ctx context.Context, req admission.Request) ad
 		}
 	}
 
-	// TODO: verify if req.UserInfo.Username has rbac permission to update the byohost
+	if req.Operation == v1.Create && req.SubResource == "" && v.Client != nil {
+		byoHost := &ByoHost{}
+		if err := v.decoder.DecodeRaw(req.Object, byoHost); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		reason, err := CheckByoHostQuota(ctx, v.Client, req.Namespace, byoHost.Labels[BootstrapTokenLabel])
+		if err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		if reason != "" {
+			return admission.Denied(reason)
+		}
+	}
+
+	if req.Operation == v1.Update && req.SubResource == "" {
+		reason, err := v.validateHostUID(req)
+		if err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if reason != "" {
+			return admission.Denied(reason)
+		}
+	}
+
+	if hostName := strings.TrimPrefix(req.UserInfo.Username, HostAgentUsernamePrefix); hostName != req.UserInfo.Username {
+		reason, err := v.validateHostAgentRequest(hostName, req)
+		if err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if reason != "" {
+			return admission.Denied(reason)
+		}
+	}
 
 	return admission.Allowed("")
 }
 
+// validateHostAgentRequest returns why the request of the host agent is denied, or an empty
+// string when it is allowed. A host agent can only register, update and delete its own ByoHost.
+// Besides the status, it can only change its labels, release the host, and set the annotations
+// requesting to unschedule and decommission the host. It cannot lift the quarantine of the host.
+// nolint: gocritic
+func (v *ByoHostValidator) validateHostAgentRequest(hostName string, req admission.Request) (string, error) {
+	if req.Name != hostName {
+		return fmt.Sprintf("host agent %s cannot modify ByoHost %s", hostName, req.Name), nil
+	}
+	if req.SubResource == "status" || req.Operation == v1.Delete {
+		return "", nil
+	}
+
+	byoHost := &ByoHost{}
+	if err := v.decoder.DecodeRaw(req.Object, byoHost); err != nil {
+		return "", err
+	}
+	oldByoHost := &ByoHost{}
+	if req.Operation == v1.Update {
+		if err := v.decoder.DecodeRaw(req.OldObject, oldByoHost); err != nil {
+			return "", err
+		}
+	}
+
+	spec := byoHost.Spec
+	if spec.BootstrapSecret == nil {
+		spec.BootstrapSecret = oldByoHost.Spec.BootstrapSecret
+	}
+	if !reflect.DeepEqual(spec, oldByoHost.Spec) {
+		return "host agent can only remove the bootstrap secret from the spec", nil
+	}
+	for _, label := range hostAgentReleasedLabels {
+		if value, ok := byoHost.Labels[label]; ok && value != oldByoHost.Labels[label] {
+			return fmt.Sprintf("host agent cannot set the %s label", label), nil
+		}
+	}
+	if byoHost.Labels[BootstrapTokenLabel] != oldByoHost.Labels[BootstrapTokenLabel] {
+		return fmt.Sprintf("host agent cannot change the %s label", BootstrapTokenLabel), nil
+	}
+	if oldValue, ok := oldByoHost.Annotations[QuarantineAnnotation]; ok && byoHost.Annotations[QuarantineAnnotation] != oldValue {
+		return fmt.Sprintf("host agent cannot lift the quarantine of its host, the %s annotation is kept", QuarantineAnnotation), nil
+	}
+	for annotation, value := range byoHost.Annotations {
+		if oldValue, ok := oldByoHost.Annotations[annotation]; ok && value == oldValue {
+			continue
+		}
+		if !containsAnnotation(hostAgentAnnotations, annotation) {
+			return fmt.Sprintf("host agent cannot set the %s annotation", annotation), nil
+		}
+	}
+	return "", nil
+}
+
+// validateHostUID returns why the update is denied when it changes the host owning the ByoHost,
+// i.e. another host registering with the same name, or an empty string when it is allowed.
+// The identity of the host can be removed, e.g. to let a reimaged host take its ByoHost over.
+// nolint: gocritic
+func (v *ByoHostValidator) validateHostUID(req admission.Request) (string, error) {
+	byoHost := &ByoHost{}
+	if err := v.decoder.DecodeRaw(req.Object, byoHost); err != nil {
+		return "", err
+	}
+	oldByoHost := &ByoHost{}
+	if err := v.decoder.DecodeRaw(req.OldObject, oldByoHost); err != nil {
+		return "", err
+	}
+	oldUID, newUID := oldByoHost.Annotations[HostUIDAnnotation], byoHost.Annotations[HostUIDAnnotation]
+	if oldUID != "" && newUID != "" && oldUID != newUID {
+		return fmt.Sprintf("ByoHost %s is owned by another host with the same name, register the host under another name", req.Name), nil
+	}
+	return "", nil
+}
+
+// CheckByoHostQuota returns why registering one more ByoHost in the namespace exceeds its
+// ByoHostQuotas, or an empty string when it does not. The bootstrap token ID is empty for the hosts
+// not registered with a bootstrap kubeconfig.
+func CheckByoHostQuota(ctx context.Context, c client.Reader, namespace, bootstrapTokenID string) (string, error) {
+	quotas := &ByoHostQuotaList{}
+	if err := c.List(ctx, quotas, client.InNamespace(namespace)); err != nil {
+		return "", err
+	}
+	if len(quotas.Items) == 0 {
+		return "", nil
+	}
+
+	hosts := &ByoHostList{}
+	if err := c.List(ctx, hosts, client.InNamespace(namespace)); err != nil {
+		return "", err
+	}
+	tokenHosts := 0
+	for i := range hosts.Items {
+		if bootstrapTokenID != "" && hosts.Items[i].Labels[BootstrapTokenLabel] == bootstrapTokenID {
+			tokenHosts++
+		}
+	}
+
+	for i := range quotas.Items {
+		spec := quotas.Items[i].Spec
+		if spec.MaxHosts != nil && len(hosts.Items) >= int(*spec.MaxHosts) {
+			return fmt.Sprintf("namespace %s has reached the quota of %d ByoHosts of ByoHostQuota %s",
+				namespace, *spec.MaxHosts, quotas.Items[i].Name), nil
+		}
+		if bootstrapTokenID != "" && spec.MaxHostsPerBootstrapToken != nil && tokenHosts >= int(*spec.MaxHostsPerBootstrapToken) {
+			return fmt.Sprintf("bootstrap token %s has reached the quota of %d ByoHosts of ByoHostQuota %s",
+				bootstrapTokenID, *spec.MaxHostsPerBootstrapToken, quotas.Items[i].Name), nil
+		}
+	}
+	return "", nil
+}
+
+func containsAnnotation(annotations []string, annotation string) bool {
+	for _, a := range annotations {
+		if a == annotation {
+			return true
+		}
+	}
+	return false
+}
+
 // InjectDecoder injects the decoder.
 func (v *ByoHostValidator) InjectDecoder(d *admission.Decoder) error {
 	v.decoder = d
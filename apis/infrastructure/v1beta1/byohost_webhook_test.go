@@ -5,15 +5,22 @@ package v1beta1_test
 
 import (
 	"context"
+	"encoding/json"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	byohv1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/kubectl/pkg/scheme"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
 var _ = Describe("ByohostWebhook", func() {
@@ -91,4 +98,240 @@ var _ = Describe("ByohostWebhook", func() {
 		})
 	})
 
+	Context("When a host agent sends a request", func() {
+		var (
+			validator *byohv1beta1.ByoHostValidator
+			byoHost   *byohv1beta1.ByoHost
+			hostName  = "host1"
+		)
+
+		BeforeEach(func() {
+			testScheme := runtime.NewScheme()
+			Expect(byohv1beta1.AddToScheme(testScheme)).Should(Succeed())
+			decoder, err := admission.NewDecoder(testScheme)
+			Expect(err).NotTo(HaveOccurred())
+			validator = &byohv1beta1.ByoHostValidator{}
+			Expect(validator.InjectDecoder(decoder)).Should(Succeed())
+
+			byoHost = &byohv1beta1.ByoHost{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "ByoHost",
+					APIVersion: byohv1beta1.GroupVersion.String(),
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      hostName,
+					Namespace: "default",
+					Labels:    map[string]string{"site": "edge"},
+				},
+			}
+		})
+
+		request := func(operation admissionv1.Operation, name string, oldObj, obj *byohv1beta1.ByoHost) admission.Request {
+			req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: operation,
+				Name:      name,
+				Namespace: "default",
+				UserInfo:  authenticationv1.UserInfo{Username: byohv1beta1.HostAgentUsernamePrefix + hostName},
+			}}
+			if oldObj != nil {
+				raw, err := json.Marshal(oldObj)
+				Expect(err).NotTo(HaveOccurred())
+				req.OldObject = runtime.RawExtension{Raw: raw}
+			}
+			if obj != nil {
+				raw, err := json.Marshal(obj)
+				Expect(err).NotTo(HaveOccurred())
+				req.Object = runtime.RawExtension{Raw: raw}
+			}
+			return req
+		}
+
+		It("should allow the host agent to register its own host", func() {
+			resp := validator.Handle(context.Background(), request(admissionv1.Create, hostName, nil, byoHost))
+			Expect(resp.Allowed).To(BeTrue())
+		})
+
+		It("should reject a request on another host", func() {
+			resp := validator.Handle(context.Background(), request(admissionv1.Update, "host2", byoHost, byoHost))
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(string(resp.Result.Reason)).To(Equal("host agent host1 cannot modify ByoHost host2"))
+
+			resp = validator.Handle(context.Background(), request(admissionv1.Delete, "host2", byoHost, nil))
+			Expect(resp.Allowed).To(BeFalse())
+		})
+
+		It("should reject a request on the status of another host", func() {
+			req := request(admissionv1.Update, "host2", byoHost, byoHost)
+			req.SubResource = "status"
+			resp := validator.Handle(context.Background(), req)
+			Expect(resp.Allowed).To(BeFalse())
+		})
+
+		It("should reject the host agent changing the spec", func() {
+			updated := byoHost.DeepCopy()
+			updated.Spec.AllowedNamespaces = []string{"*"}
+			resp := validator.Handle(context.Background(), request(admissionv1.Update, hostName, byoHost, updated))
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(string(resp.Result.Reason)).To(Equal("host agent can only remove the bootstrap secret from the spec"))
+		})
+
+		It("should reject the host agent attaching its host to a cluster", func() {
+			updated := byoHost.DeepCopy()
+			updated.Labels[clusterv1.ClusterLabelName] = "my-cluster"
+			resp := validator.Handle(context.Background(), request(admissionv1.Update, hostName, byoHost, updated))
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(string(resp.Result.Reason)).To(Equal("host agent cannot set the " + clusterv1.ClusterLabelName + " label"))
+		})
+
+		It("should reject the host agent setting the annotations of the controller manager", func() {
+			updated := byoHost.DeepCopy()
+			updated.Annotations = map[string]string{byohv1beta1.DesiredAgentVersionAnnotation: "v0.0.1"}
+			resp := validator.Handle(context.Background(), request(admissionv1.Update, hostName, byoHost, updated))
+			Expect(resp.Allowed).To(BeFalse())
+		})
+
+		It("should allow the host agent to change its own labels", func() {
+			updated := byoHost.DeepCopy()
+			updated.Labels["site"] = "apac"
+			updated.Annotations = map[string]string{byohv1beta1.AgentLabelsAnnotation: "site"}
+			resp := validator.Handle(context.Background(), request(admissionv1.Update, hostName, byoHost, updated))
+			Expect(resp.Allowed).To(BeTrue())
+		})
+
+		It("should reject the host agent changing the bootstrap token of its host", func() {
+			registered := byoHost.DeepCopy()
+			registered.Labels[byohv1beta1.BootstrapTokenLabel] = "abcdef"
+			resp := validator.Handle(context.Background(), request(admissionv1.Create, hostName, nil, registered))
+			Expect(resp.Allowed).To(BeFalse())
+
+			updated := registered.DeepCopy()
+			delete(updated.Labels, byohv1beta1.BootstrapTokenLabel)
+			resp = validator.Handle(context.Background(), request(admissionv1.Update, hostName, registered, updated))
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(string(resp.Result.Reason)).To(Equal("host agent cannot change the " + byohv1beta1.BootstrapTokenLabel + " label"))
+		})
+
+		It("should allow the host agent to release and decommission its host", func() {
+			attached := byoHost.DeepCopy()
+			attached.Labels[clusterv1.ClusterLabelName] = "my-cluster"
+			attached.Annotations = map[string]string{byohv1beta1.HostCleanupAnnotation: ""}
+			attached.Spec.BootstrapSecret = &corev1.ObjectReference{Name: "bootstrap-secret"}
+
+			released := byoHost.DeepCopy()
+			released.Annotations = map[string]string{
+				byohv1beta1.UnschedulableAnnotation: "",
+				byohv1beta1.DecommissionAnnotation:  "",
+			}
+			resp := validator.Handle(context.Background(), request(admissionv1.Update, hostName, attached, released))
+			Expect(resp.Allowed).To(BeTrue())
+		})
+
+		It("should reject the host agent lifting the quarantine of its host", func() {
+			quarantined := byoHost.DeepCopy()
+			quarantined.Annotations = map[string]string{byohv1beta1.QuarantineAnnotation: "incident-42"}
+			resp := validator.Handle(context.Background(), request(admissionv1.Update, hostName, quarantined, byoHost))
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(string(resp.Result.Reason)).To(Equal("host agent cannot lift the quarantine of its host, the " +
+				byohv1beta1.QuarantineAnnotation + " annotation is kept"))
+
+			resp = validator.Handle(context.Background(), request(admissionv1.Update, hostName, byoHost, quarantined))
+			Expect(resp.Allowed).To(BeFalse())
+		})
+
+		It("should allow the host agent to set the identity of its host", func() {
+			registered := byoHost.DeepCopy()
+			registered.Annotations = map[string]string{byohv1beta1.HostUIDAnnotation: "uid-1"}
+			resp := validator.Handle(context.Background(), request(admissionv1.Create, hostName, nil, registered))
+			Expect(resp.Allowed).To(BeTrue())
+
+			resp = validator.Handle(context.Background(), request(admissionv1.Update, hostName, byoHost, registered))
+			Expect(resp.Allowed).To(BeTrue())
+		})
+
+		It("should reject another host with the same name taking the host over", func() {
+			registered := byoHost.DeepCopy()
+			registered.Annotations = map[string]string{byohv1beta1.HostUIDAnnotation: "uid-1"}
+			takenOver := byoHost.DeepCopy()
+			takenOver.Annotations = map[string]string{byohv1beta1.HostUIDAnnotation: "uid-2"}
+			resp := validator.Handle(context.Background(), request(admissionv1.Update, hostName, registered, takenOver))
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(string(resp.Result.Reason)).To(Equal("ByoHost host1 is owned by another host with the same name, register the host under another name"))
+		})
+	})
+
+	Context("When ByoHostQuotas apply to the namespace", func() {
+		var (
+			validator   *byohv1beta1.ByoHostValidator
+			testScheme  *runtime.Scheme
+			quota       *byohv1beta1.ByoHostQuota
+			maxHosts    int32
+			maxPerToken int32
+		)
+
+		BeforeEach(func() {
+			testScheme = runtime.NewScheme()
+			Expect(byohv1beta1.AddToScheme(testScheme)).Should(Succeed())
+			maxHosts, maxPerToken = 2, 1
+			quota = &byohv1beta1.ByoHostQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "edge-quota", Namespace: "default"},
+				Spec: byohv1beta1.ByoHostQuotaSpec{
+					MaxHosts:                  &maxHosts,
+					MaxHostsPerBootstrapToken: &maxPerToken,
+				},
+			}
+		})
+
+		newHost := func(name, tokenID string) *byohv1beta1.ByoHost {
+			byoHost := &byohv1beta1.ByoHost{
+				TypeMeta:   metav1.TypeMeta{Kind: "ByoHost", APIVersion: byohv1beta1.GroupVersion.String()},
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			}
+			if tokenID != "" {
+				byoHost.Labels = map[string]string{byohv1beta1.BootstrapTokenLabel: tokenID}
+			}
+			return byoHost
+		}
+
+		createRequest := func(byoHost *byohv1beta1.ByoHost, existing ...client.Object) admission.Response {
+			decoder, err := admission.NewDecoder(testScheme)
+			Expect(err).NotTo(HaveOccurred())
+			validator = &byohv1beta1.ByoHostValidator{
+				Client: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(existing...).Build(),
+			}
+			Expect(validator.InjectDecoder(decoder)).Should(Succeed())
+			raw, err := json.Marshal(byoHost)
+			Expect(err).NotTo(HaveOccurred())
+			return validator.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: admissionv1.Create,
+				Name:      byoHost.Name,
+				Namespace: byoHost.Namespace,
+				Object:    runtime.RawExtension{Raw: raw},
+			}})
+		}
+
+		It("should allow the registrations under the quota", func() {
+			resp := createRequest(newHost("host2", "abcdef"), quota, newHost("host1", ""))
+			Expect(resp.Allowed).To(BeTrue())
+		})
+
+		It("should reject the registrations beyond the quota of the namespace", func() {
+			resp := createRequest(newHost("host3", ""), quota, newHost("host1", ""), newHost("host2", ""))
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(string(resp.Result.Reason)).To(Equal("namespace default has reached the quota of 2 ByoHosts of ByoHostQuota edge-quota"))
+		})
+
+		It("should reject the registrations beyond the quota of the bootstrap token", func() {
+			resp := createRequest(newHost("host2", "abcdef"), quota, newHost("host1", "abcdef"))
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(string(resp.Result.Reason)).To(Equal("bootstrap token abcdef has reached the quota of 1 ByoHosts of ByoHostQuota edge-quota"))
+
+			resp = createRequest(newHost("host2", "ghijkl"), quota, newHost("host1", "abcdef"))
+			Expect(resp.Allowed).To(BeTrue())
+		})
+
+		It("should allow any registration without a quota", func() {
+			resp := createRequest(newHost("host3", ""), newHost("host1", ""), newHost("host2", ""))
+			Expect(resp.Allowed).To(BeTrue())
+		})
+	})
 })
@@ -0,0 +1,109 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+const (
+	// ByoHostClaimFinalizer allows the binder to release the ByoHost bound to
+	// the ByoHostClaim before removing it from the API Server.
+	ByoHostClaimFinalizer = "byohostclaim.infrastructure.cluster.x-k8s.io"
+
+	// ByoHostClaimLabel is set on the ByoHost bound to a ByoHostClaim, to the
+	// namespace and name of the claim. The bound hosts are only attached to the
+	// machines referencing the claim.
+	ByoHostClaimLabel = "byoh.infrastructure.cluster.x-k8s.io/byohostclaim-name"
+)
+
+// ByoHostClaimPhase is the binding phase of a ByoHostClaim
+type ByoHostClaimPhase string
+
+const (
+	// ByoHostClaimPending is the phase of the claims waiting for a host
+	ByoHostClaimPending ByoHostClaimPhase = "Pending"
+	// ByoHostClaimBound is the phase of the claims bound to a host
+	ByoHostClaimBound ByoHostClaimPhase = "Bound"
+	// ByoHostClaimLost is the phase of the claims whose bound host was deleted
+	ByoHostClaimLost ByoHostClaimPhase = "Lost"
+)
+
+// ByoHostClaimSpec defines the requirements of the host requested by a ByoHostClaim
+type ByoHostClaimSpec struct {
+	// Selector is the label selector the claimed host has to match
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// Architecture is the architecture the claimed host has to report, e.g. amd64
+	// +optional
+	Architecture string `json:"architecture,omitempty"`
+
+	// OSName is the operating system the claimed host has to report, e.g. linux
+	// +optional
+	OSName string `json:"osName,omitempty"`
+
+	// HostRef pre-binds the claim to the host, the claim is bound to it once it
+	// is available. The namespace of the claim is used when the namespace is empty.
+	// +optional
+	HostRef *corev1.ObjectReference `json:"hostRef,omitempty"`
+}
+
+// ByoHostClaimStatus defines the observed state of ByoHostClaim
+type ByoHostClaimStatus struct {
+	// Phase is the binding phase of the claim
+	// +optional
+	Phase ByoHostClaimPhase `json:"phase,omitempty"`
+
+	// HostRef is the ByoHost the claim is bound to
+	// +optional
+	HostRef *corev1.ObjectReference `json:"hostRef,omitempty"`
+
+	// Conditions defines current service state of the ByoHostClaim.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:path=byohostclaims,scope=Namespaced,shortName=byohc
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+//+kubebuilder:printcolumn:name="Host",type="string",JSONPath=".status.hostRef.name"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// ByoHostClaim is the Schema for the byohostclaims API. A claim requests a ByoHost
+// matching its requirements, and is bound to one by the binder of the controller
+// manager, in the order the claims were created.
+type ByoHostClaim struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ByoHostClaimSpec   `json:"spec,omitempty"`
+	Status ByoHostClaimStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ByoHostClaimList contains a list of ByoHostClaim
+type ByoHostClaimList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ByoHostClaim `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ByoHostClaim{}, &ByoHostClaimList{})
+}
+
+// GetConditions returns the conditions of ByoHostClaim status
+func (byoHostClaim *ByoHostClaim) GetConditions() clusterv1.Conditions {
+	return byoHostClaim.Status.Conditions
+}
+
+// SetConditions sets the conditions of ByoHostClaim status
+func (byoHostClaim *ByoHostClaim) SetConditions(conditions clusterv1.Conditions) {
+	byoHostClaim.Status.Conditions = conditions
+}
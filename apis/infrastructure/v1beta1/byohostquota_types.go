@@ -0,0 +1,56 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BootstrapTokenLabel is set on the ByoHosts registered with a bootstrap kubeconfig, to the ID of
+// its bootstrap token. The hosts registered with the same bootstrap token are capped by the
+// MaxHostsPerBootstrapToken of the ByoHostQuotas.
+const BootstrapTokenLabel = "byoh.infrastructure.cluster.x-k8s.io/bootstrap-token-id"
+
+// ByoHostQuotaSpec defines the number of ByoHosts which may be registered in the namespace
+type ByoHostQuotaSpec struct {
+	// MaxHosts caps the number of ByoHosts of the namespace
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxHosts *int32 `json:"maxHosts,omitempty"`
+
+	// MaxHostsPerBootstrapToken caps the number of ByoHosts of the namespace registered with the
+	// same bootstrap token, i.e. the same BootstrapKubeconfig
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxHostsPerBootstrapToken *int32 `json:"maxHostsPerBootstrapToken,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:path=byohostquotas,scope=Namespaced,shortName=byohq
+//+kubebuilder:printcolumn:name="MaxHosts",type="integer",JSONPath=".spec.maxHosts"
+//+kubebuilder:printcolumn:name="MaxHostsPerBootstrapToken",type="integer",JSONPath=".spec.maxHostsPerBootstrapToken"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// ByoHostQuota is the Schema for the byohostquotas API. The registration of the ByoHosts of its
+// namespace is denied beyond the quota, by the ByoHost webhook and by the approval of the host CSRs.
+// All the quotas of a namespace apply.
+type ByoHostQuota struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ByoHostQuotaSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ByoHostQuotaList contains a list of ByoHostQuota
+type ByoHostQuotaList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ByoHostQuota `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ByoHostQuota{}, &ByoHostQuotaList{})
+}
@@ -5,6 +5,7 @@ package v1beta1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
@@ -14,6 +15,16 @@ const (
 	// resources associated with ByoMachine before removing it from the
 	// API Server.
 	MachineFinalizer = "byomachine.infrastructure.cluster.x-k8s.io"
+
+	// InstallerConfigTemplateAnnotation on a Machine names the K8sInstallerConfigTemplate, in the namespace
+	// of the Machine, which installs its host. It is set through the metadata of the machine template of
+	// a MachineDeployment or a KubeadmControlPlane, to select an installer per MachineDeployment or
+	// control plane while they share a ByoMachineTemplate.
+	InstallerConfigTemplateAnnotation = "byoh.infrastructure.cluster.x-k8s.io/installer-config-template"
+
+	// TraceParentAnnotation stores the W3C trace context of the provisioning of a ByoMachine, copied to the ByoHost
+	// attached to it, so that the spans of the controllers and of the host agent make a single trace
+	TraceParentAnnotation = "byoh.infrastructure.cluster.x-k8s.io/traceparent"
 )
 
 // ByoMachineSpec defines the desired state of ByoMachine
@@ -24,9 +35,202 @@ type ByoMachineSpec struct {
 	ProviderID string `json:"providerID,omitempty"`
 
 	// InstallerRef is an optional reference to a installer-specific resource that holds
-	// the details of InstallationSecret to be used to install BYOH Bundle.
+	// the details of InstallationSecret to be used to install BYOH Bundle. It is set from the
+	// installer-config-template annotation of the Machine, when the Machine has one.
 	// +optional
 	InstallerRef *corev1.ObjectReference `json:"installerRef,omitempty"`
+
+	// AntiAffinity spreads the ByoMachines of the same control plane or
+	// MachineDeployment across ByoHosts with different values of a topology label.
+	// +optional
+	AntiAffinity *AntiAffinity `json:"antiAffinity,omitempty"`
+
+	// HostRef pins the ByoMachine to a specific ByoHost, which can be reserved.
+	// The namespace defaults to the one of the ByoMachine.
+	// +optional
+	HostRef *corev1.ObjectReference `json:"hostRef,omitempty"`
+
+	// ClaimRef attaches the ByoMachine to the ByoHost bound to the ByoHostClaim of the
+	// namespace of the ByoMachine, instead of selecting a host.
+	// +optional
+	ClaimRef *corev1.LocalObjectReference `json:"claimRef,omitempty"`
+
+	// Kubelet customizes the kubelet of the host, on top of the kubelet configuration of the cluster.
+	// It is applied by the host agent before the host joins the cluster.
+	// +optional
+	Kubelet *KubeletSpec `json:"kubelet,omitempty"`
+
+	// Distribution is the Kubernetes distribution installed and bootstrapped on the host,
+	// it has to match the bootstrap provider of the machine. Defaults to kubeadm.
+	// +kubebuilder:validation:Enum=kubeadm;rke2
+	// +optional
+	Distribution KubernetesDistribution `json:"distribution,omitempty"`
+
+	// NodeDrain configures the drain of the node when the ByoMachine is deleted, which is
+	// cordoned and has its pods deleted before the host is reset.
+	// +optional
+	NodeDrain *NodeDrainSpec `json:"nodeDrain,omitempty"`
+
+	// Devices restricts the selection of the ByoMachine to the ByoHosts reporting the devices,
+	// e.g. GPUs, on top of the selector.
+	// +optional
+	Devices *DeviceRequirements `json:"devices,omitempty"`
+
+	// NodeLabels are the labels the kubelet registers the Node of the host with. The kubelet can only
+	// set the labels of the kubernetes.io and k8s.io namespaces allowed by the NodeRestriction admission
+	// plugin, i.e. the kubelet.kubernetes.io and node.kubernetes.io ones, and the topology zone and region.
+	// +optional
+	NodeLabels map[string]string `json:"nodeLabels,omitempty"`
+
+	// NodeTaints are the taints the kubelet registers the Node of the host with.
+	// +optional
+	NodeTaints []corev1.Taint `json:"nodeTaints,omitempty"`
+}
+
+// DeviceRequirements are the devices a ByoHost needs to report to be selected by a ByoMachine
+type DeviceRequirements struct {
+	// GPUs is the minimum number of GPUs of the host, of a vendor and model when set.
+	// +optional
+	GPUs *GPURequirement `json:"gpus,omitempty"`
+
+	// HugePages are the minimum numbers of huge pages preallocated on the host, for each page size.
+	// +listType=map
+	// +listMapKey=pageSize
+	// +optional
+	HugePages []HugePagesRequirement `json:"hugePages,omitempty"`
+
+	// SRIOVVirtualFunctions is the minimum number of virtual functions supported by one of the
+	// network interfaces of the host capable of SR-IOV.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	SRIOVVirtualFunctions *int32 `json:"sriovVirtualFunctions,omitempty"`
+
+	// CPUs is the minimum number of logical CPUs of the host.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	CPUs *int32 `json:"cpus,omitempty"`
+
+	// NUMANodes is the minimum number of NUMA nodes of the host.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	NUMANodes *int32 `json:"numaNodes,omitempty"`
+}
+
+// GPURequirement is the minimum number of GPUs of a host
+type GPURequirement struct {
+	// Count is the minimum number of GPUs.
+	// +kubebuilder:validation:Minimum=1
+	Count int32 `json:"count"`
+
+	// Vendor restricts the GPUs to a vendor, matched against the vendor ID or name, e.g. 10de or NVIDIA.
+	// +optional
+	Vendor string `json:"vendor,omitempty"`
+
+	// Model restricts the GPUs to a model, matched against the device ID, or contained in
+	// the model name, e.g. 20b5 or A100.
+	// +optional
+	Model string `json:"model,omitempty"`
+}
+
+// HugePagesRequirement is the minimum number of huge pages of a page size
+type HugePagesRequirement struct {
+	// PageSize is the size of the huge pages, e.g. 2Mi or 1Gi.
+	PageSize resource.Quantity `json:"pageSize"`
+
+	// Count is the minimum number of huge pages of the size.
+	// +kubebuilder:validation:Minimum=1
+	Count int64 `json:"count"`
+}
+
+// NodeDrainSpec configures the drain of the node of a deleted ByoMachine
+type NodeDrainSpec struct {
+	// GracePeriodSeconds overrides the termination grace period of the drained pods.
+	// Defaults to the grace period of each pod.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	GracePeriodSeconds *int64 `json:"gracePeriodSeconds,omitempty"`
+
+	// Timeout is how long the pods are waited for before the host is reset anyway.
+	// Defaults to 5m, 0s skips the drain.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// KubernetesDistribution is a Kubernetes distribution the host agent installs and bootstraps
+type KubernetesDistribution string
+
+const (
+	// KubernetesDistributionKubeadm installs the kubeadm bundle and bootstraps the node with kubeadm
+	KubernetesDistributionKubeadm KubernetesDistribution = "kubeadm"
+	// KubernetesDistributionRKE2 installs the rke2 bundle and starts the rke2 server or agent
+	// configured by the RKE2 bootstrap provider
+	KubernetesDistributionRKE2 KubernetesDistribution = "rke2"
+)
+
+// KubeletSpec customizes the kubelet of a host, e.g. with the reserved resources of a host class
+type KubeletSpec struct {
+	// Configuration overrides the fields of the kubelet configuration of the cluster
+	// +optional
+	Configuration *KubeletConfiguration `json:"configuration,omitempty"`
+
+	// ExtraArgs are the extra flags of the kubelet, without the leading dashes, e.g. {"max-pods": "200"}.
+	// They take precedence over Configuration.
+	// +optional
+	ExtraArgs map[string]string `json:"extraArgs,omitempty"`
+}
+
+// KubeletConfiguration are the fields of the kubelet configuration which are set per host
+type KubeletConfiguration struct {
+	// MaxPods is the number of pods the kubelet can run
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxPods *int32 `json:"maxPods,omitempty"`
+
+	// SystemReserved are the resources reserved for the system daemons, e.g. {"cpu": "500m", "memory": "1Gi"}
+	// +optional
+	SystemReserved map[string]string `json:"systemReserved,omitempty"`
+
+	// KubeReserved are the resources reserved for the kubernetes daemons, e.g. {"cpu": "500m", "memory": "1Gi"}
+	// +optional
+	KubeReserved map[string]string `json:"kubeReserved,omitempty"`
+
+	// EvictionHard are the thresholds evicting the pods right away, e.g. {"memory.available": "500Mi"}
+	// +optional
+	EvictionHard map[string]string `json:"evictionHard,omitempty"`
+
+	// EvictionSoft are the thresholds evicting the pods after their grace period, e.g. {"memory.available": "1Gi"}
+	// +optional
+	EvictionSoft map[string]string `json:"evictionSoft,omitempty"`
+
+	// EvictionSoftGracePeriod are the grace periods of the soft eviction thresholds, e.g. {"memory.available": "1m30s"}
+	// +optional
+	EvictionSoftGracePeriod map[string]string `json:"evictionSoftGracePeriod,omitempty"`
+}
+
+// AntiAffinityPolicy defines how strictly the anti-affinity is enforced
+type AntiAffinityPolicy string
+
+const (
+	// AntiAffinityPolicyRequired only attaches a ByoHost in a topology domain
+	// not used by the peer ByoMachines, and waits otherwise
+	AntiAffinityPolicyRequired AntiAffinityPolicy = "Required"
+
+	// AntiAffinityPolicyPreferred falls back to any available ByoHost when
+	// every topology domain is already used by the peer ByoMachines
+	AntiAffinityPolicyPreferred AntiAffinityPolicy = "Preferred"
+)
+
+// AntiAffinity defines the topology the ByoMachines are spread across
+type AntiAffinity struct {
+	// TopologyKey is the ByoHost label whose values define the topology
+	// domains, e.g. topology.byoh/rack
+	TopologyKey string `json:"topologyKey"`
+
+	// Policy is either Required or Preferred
+	// +kubebuilder:validation:Enum=Required;Preferred
+	// +kubebuilder:default=Preferred
+	// +optional
+	Policy AntiAffinityPolicy `json:"policy,omitempty"`
 }
 
 // NetworkStatus provides information about one of a VM's networks.
@@ -39,9 +243,21 @@ type NetworkStatus struct {
 	// +optional
 	IPAddrs []string `json:"ipAddrs,omitempty"`
 
+	// IPv4Addrs are the IPv4 addresses of the network interface.
+	// +optional
+	IPv4Addrs []string `json:"ipv4Addrs,omitempty"`
+
+	// IPv6Addrs are the IPv6 addresses of the network interface.
+	// +optional
+	IPv6Addrs []string `json:"ipv6Addrs,omitempty"`
+
 	// MACAddr is the MAC address of the network device.
 	MACAddr string `json:"macAddr"`
 
+	// MTU is the maximum transmission unit of the network interface.
+	// +optional
+	MTU int `json:"mtu,omitempty"`
+
 	// NetworkInterfaceName is the name of the network interface.
 	// +optional
 	NetworkInterfaceName string `json:"networkInterfaceName,omitempty"`
@@ -49,6 +265,16 @@ type NetworkStatus struct {
 	// IsDefault is a flag that indicates whether this interface name is where
 	// the default gateway sit on.
 	IsDefault bool `json:"isDefault,omitempty"`
+
+	// IsDefaultIPv4 is a flag that indicates whether the IPv4 default route
+	// goes through this interface.
+	// +optional
+	IsDefaultIPv4 bool `json:"isDefaultIPv4,omitempty"`
+
+	// IsDefaultIPv6 is a flag that indicates whether the IPv6 default route
+	// goes through this interface.
+	// +optional
+	IsDefaultIPv6 bool `json:"isDefaultIPv6,omitempty"`
 }
 
 // ByoMachineStatus defines the observed state of ByoMachine
@@ -66,11 +292,99 @@ type ByoMachineStatus struct {
 	// Conditions defines current service state of the BYOMachine.
 	// +optional
 	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+
+	// Timeline records when the ByoMachine reached each phase of its provisioning.
+	// +optional
+	Timeline ProvisioningTimeline `json:"timeline,omitempty"`
+
+	// ProvisioningDuration is the time from the creation of the ByoMachine to its node joining the cluster.
+	// +optional
+	ProvisioningDuration *metav1.Duration `json:"provisioningDuration,omitempty"`
+
+	// FailureDomain is the failure domain of the attached ByoHost, the value of its label
+	// set as the FailureDomainLabel of the ByoCluster.
+	// +optional
+	FailureDomain string `json:"failureDomain,omitempty"`
+
+	// Node mirrors the health and the kubelet version of the node of the attached ByoHost
+	// in the workload cluster.
+	// +optional
+	Node *NodeStatus `json:"node,omitempty"`
+
+	// ObservedGeneration is the latest generation of the ByoMachine reconciled by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// ProvisioningTimeline records when the phases of the provisioning of a ByoMachine were reached.
+// A time is only recorded once, the first time the phase is reached.
+type ProvisioningTimeline struct {
+	// BootstrapSecretReadyTime is when the bootstrap provider provided the bootstrap data secret.
+	// +optional
+	BootstrapSecretReadyTime *metav1.Time `json:"bootstrapSecretReadyTime,omitempty"`
+
+	// HostSelectedTime is when a ByoHost was attached to the ByoMachine.
+	// +optional
+	HostSelectedTime *metav1.Time `json:"hostSelectedTime,omitempty"`
+
+	// K8sInstalledTime is when the host agent installed the k8s components. It is not recorded
+	// when the installation is skipped, or left to an installer.
+	// +optional
+	K8sInstalledTime *metav1.Time `json:"k8sInstalledTime,omitempty"`
+
+	// NodeJoinedTime is when the node of the host joined the cluster and got the provider ID.
+	// +optional
+	NodeJoinedTime *metav1.Time `json:"nodeJoinedTime,omitempty"`
+}
+
+// NodeStatus mirrors the node of a host in the workload cluster.
+type NodeStatus struct {
+	// Name is the name of the node.
+	Name string `json:"name"`
+
+	// KubeletVersion is the version of the kubelet reported by the node.
+	// +optional
+	KubeletVersion string `json:"kubeletVersion,omitempty"`
+
+	// Ready is the status of the Ready condition of the node, True, False or Unknown.
+	// +optional
+	Ready corev1.ConditionStatus `json:"ready,omitempty"`
+
+	// Conditions are the Ready and the pressure conditions of the node.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []NodeCondition `json:"conditions,omitempty"`
+}
+
+// NodeCondition is a condition of a node, without its heartbeat, so that it only changes
+// when the condition does.
+type NodeCondition struct {
+	// Type is the type of the condition, e.g. Ready or DiskPressure.
+	Type corev1.NodeConditionType `json:"type"`
+
+	// Status is the status of the condition, True, False or Unknown.
+	Status corev1.ConditionStatus `json:"status"`
+
+	// Reason is the reason of the last transition of the condition.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Message is the human readable details of the last transition of the condition.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// LastTransitionTime is when the condition last changed its status.
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:resource:path=byomachines,scope=Namespaced,shortName=byom
 //+kubebuilder:subresource:status
+//+kubebuilder:storageversion
+//+kubebuilder:printcolumn:name="NodeReady",type="string",JSONPath=`.status.node.ready`
+//+kubebuilder:printcolumn:name="Kubelet",type="string",JSONPath=`.status.node.kubeletVersion`
 
 // ByoMachine is the Schema for the byomachines API
 type ByoMachine struct {
@@ -0,0 +1,189 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1beta1
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var byomachinelog = logf.Log.WithName("byomachine-resource")
+
+// SetupWebhookWithManager sets up the webhook for the byomachine resource
+func (byoMachine *ByoMachine) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(byoMachine).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-infrastructure-cluster-x-k8s-io-v1beta1-byomachine,mutating=false,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=byomachines,verbs=create;update,versions=v1beta1,name=vbyomachine.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &ByoMachine{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+// The node labels and taints have to be accepted by the kubelet, see validateNodeRegistration,
+// and the extra args written as kubelet flags, see validateKubeletExtraArgs.
+func (byoMachine *ByoMachine) ValidateCreate() error {
+	specPath := field.NewPath("spec")
+	allErrs := validateNodeRegistration(&byoMachine.Spec, specPath)
+	allErrs = append(allErrs, validateKubeletExtraArgs(&byoMachine.Spec, specPath)...)
+	if len(allErrs) > 0 {
+		return apierrors.NewInvalid(byoMachine.GroupVersionKind().GroupKind(), byoMachine.Name, allErrs)
+	}
+	return nil
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+// The provider ID cannot change once set. The fields selecting, installing and configuring the
+// host are immutable once a host is attached, the controller would not apply their changes to it.
+func (byoMachine *ByoMachine) ValidateUpdate(old runtime.Object) error {
+	byomachinelog.Info("validate update", "name", byoMachine.Name)
+	oldMachine, ok := old.(*ByoMachine)
+	if !ok {
+		return apierrors.NewBadRequest("expected a ByoMachine")
+	}
+
+	specPath := field.NewPath("spec")
+	allErrs := validateNodeRegistration(&byoMachine.Spec, specPath)
+	allErrs = append(allErrs, validateKubeletExtraArgs(&byoMachine.Spec, specPath)...)
+	if oldMachine.Spec.ProviderID != "" && byoMachine.Spec.ProviderID != oldMachine.Spec.ProviderID {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("providerID"), byoMachine.Spec.ProviderID, "field is immutable once set"))
+	}
+	if oldMachine.hostAttached() {
+		immutableFields := []struct {
+			name     string
+			old, new interface{}
+		}{
+			{"selector", oldMachine.Spec.Selector, byoMachine.Spec.Selector},
+			{"installerRef", oldMachine.Spec.InstallerRef, byoMachine.Spec.InstallerRef},
+			{"antiAffinity", oldMachine.Spec.AntiAffinity, byoMachine.Spec.AntiAffinity},
+			{"hostRef", oldMachine.Spec.HostRef, byoMachine.Spec.HostRef},
+			{"claimRef", oldMachine.Spec.ClaimRef, byoMachine.Spec.ClaimRef},
+			{"kubelet", oldMachine.Spec.Kubelet, byoMachine.Spec.Kubelet},
+			{"distribution", oldMachine.Spec.Distribution, byoMachine.Spec.Distribution},
+			{"devices", oldMachine.Spec.Devices, byoMachine.Spec.Devices},
+			{"nodeLabels", oldMachine.Spec.NodeLabels, byoMachine.Spec.NodeLabels},
+			{"nodeTaints", oldMachine.Spec.NodeTaints, byoMachine.Spec.NodeTaints},
+		}
+		for _, immutableField := range immutableFields {
+			if !reflect.DeepEqual(immutableField.old, immutableField.new) {
+				allErrs = append(allErrs, field.Invalid(specPath.Child(immutableField.name), immutableField.new,
+					"field is immutable once a ByoHost is attached, delete the ByoMachine or its Machine to select another host"))
+			}
+		}
+	}
+	if len(allErrs) > 0 {
+		return apierrors.NewInvalid(byoMachine.GroupVersionKind().GroupKind(), byoMachine.Name, allErrs)
+	}
+	return nil
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (byoMachine *ByoMachine) ValidateDelete() error {
+	return nil
+}
+
+// nodeLabelNamespaces are the namespaces of the kubernetes.io and k8s.io labels the kubelet can set on its Node
+var nodeLabelNamespaces = []string{"kubelet.kubernetes.io", "node.kubernetes.io"}
+
+// nodeLabels are the kubernetes.io and k8s.io labels outside of the nodeLabelNamespaces the kubelet can set on its Node
+var nodeLabels = []string{corev1.LabelTopologyRegion, corev1.LabelTopologyZone}
+
+// validateNodeRegistration checks that the kubelet can register the Node of the host with the node labels
+// and taints of the ByoMachine. The labels of the kubernetes.io and k8s.io namespaces are restricted by
+// the NodeRestriction admission plugin, and would have the kubelet fail to start.
+func validateNodeRegistration(spec *ByoMachineSpec, specPath *field.Path) field.ErrorList {
+	labelsPath := specPath.Child("nodeLabels")
+	allErrs := metav1validation.ValidateLabels(spec.NodeLabels, labelsPath)
+	for key := range spec.NodeLabels {
+		if !isRestrictedNodeLabel(key) {
+			continue
+		}
+		allowed := false
+		for _, label := range nodeLabels {
+			allowed = allowed || key == label
+		}
+		for _, namespace := range nodeLabelNamespaces {
+			allowed = allowed || isLabelOfNamespace(key, namespace)
+		}
+		if !allowed {
+			allErrs = append(allErrs, field.Forbidden(labelsPath.Key(key),
+				"the kubelet can only set the labels of the kubernetes.io and k8s.io namespaces under kubelet.kubernetes.io and node.kubernetes.io, and the topology zone and region"))
+		}
+	}
+
+	for i, taint := range spec.NodeTaints {
+		taintPath := specPath.Child("nodeTaints").Index(i)
+		for _, msg := range validation.IsQualifiedName(taint.Key) {
+			allErrs = append(allErrs, field.Invalid(taintPath.Child("key"), taint.Key, msg))
+		}
+		for _, msg := range validation.IsValidLabelValue(taint.Value) {
+			allErrs = append(allErrs, field.Invalid(taintPath.Child("value"), taint.Value, msg))
+		}
+		switch taint.Effect {
+		case corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		default:
+			allErrs = append(allErrs, field.NotSupported(taintPath.Child("effect"), taint.Effect, []string{
+				string(corev1.TaintEffectNoSchedule), string(corev1.TaintEffectPreferNoSchedule), string(corev1.TaintEffectNoExecute)}))
+		}
+	}
+	return allErrs
+}
+
+// kubeletFlagName is the name of a kubelet flag, without the leading dashes
+var kubeletFlagName = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// validateKubeletExtraArgs checks that the extra args of the kubelet can be written as flags to the environment
+// file of the kubelet service, which splits its KUBELET_EXTRA_ARGS on the whitespaces without unquoting them
+func validateKubeletExtraArgs(spec *ByoMachineSpec, specPath *field.Path) field.ErrorList {
+	if spec.Kubelet == nil {
+		return nil
+	}
+	allErrs := field.ErrorList{}
+	argsPath := specPath.Child("kubelet", "extraArgs")
+	for name, value := range spec.Kubelet.ExtraArgs {
+		if !kubeletFlagName.MatchString(name) {
+			allErrs = append(allErrs, field.Invalid(argsPath.Key(name), name,
+				"must be the name of a kubelet flag without the leading dashes, e.g. max-pods"))
+		}
+		if strings.IndexFunc(value, unicode.IsSpace) >= 0 || strings.ContainsAny(value, "\"'`\\$") {
+			allErrs = append(allErrs, field.Invalid(argsPath.Key(name), value,
+				"must not contain whitespaces, newlines, quotes, backslashes or dollar signs"))
+		}
+	}
+	return allErrs
+}
+
+// isRestrictedNodeLabel tells if the label is of the kubernetes.io or k8s.io namespaces, or of their subdomains
+func isRestrictedNodeLabel(key string) bool {
+	return isLabelOfNamespace(key, "kubernetes.io") || isLabelOfNamespace(key, "k8s.io")
+}
+
+// isLabelOfNamespace tells if the prefix of the label is the namespace or one of its subdomains
+func isLabelOfNamespace(key, namespace string) bool {
+	i := strings.Index(key, "/")
+	if i < 0 {
+		return false
+	}
+	prefix := key[:i]
+	return prefix == namespace || strings.HasSuffix(prefix, "."+namespace)
+}
+
+// hostAttached tells if a ByoHost was attached to the ByoMachine
+func (byoMachine *ByoMachine) hostAttached() bool {
+	return byoMachine.Spec.ProviderID != "" || byoMachine.Status.Timeline.HostSelectedTime != nil
+}
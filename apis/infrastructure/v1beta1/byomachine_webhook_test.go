@@ -0,0 +1,123 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1beta1_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	byohv1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("ByoMachineWebhook", func() {
+	var (
+		byoMachine        *byohv1beta1.ByoMachine
+		ctx               context.Context
+		k8sClientUncached client.Client
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		var clientErr error
+		k8sClientUncached, clientErr = client.New(cfg, client.Options{Scheme: scheme.Scheme})
+		Expect(clientErr).NotTo(HaveOccurred())
+
+		byoMachine = &byohv1beta1.ByoMachine{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "byomachine-",
+				Namespace:    "default",
+			},
+			Spec: byohv1beta1.ByoMachineSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"site": "apac"}},
+			},
+		}
+		Expect(k8sClientUncached.Create(ctx, byoMachine)).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(k8sClientUncached.Delete(ctx, byoMachine)).Should(Succeed())
+	})
+
+	It("should allow changes to the host selection before a host is attached", func() {
+		byoMachine.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"site": "emea"}}
+		byoMachine.Spec.InstallerRef = &corev1.ObjectReference{Kind: "K8sInstallerConfig", Name: "installer"}
+		Expect(k8sClientUncached.Update(ctx, byoMachine)).Should(Succeed())
+	})
+
+	It("should reject changes to the provider ID once set", func() {
+		byoMachine.Spec.ProviderID = "byoh://host1/abcdef"
+		Expect(k8sClientUncached.Update(ctx, byoMachine)).Should(Succeed())
+
+		byoMachine.Spec.ProviderID = "byoh://host2/abcdef"
+		err := k8sClientUncached.Update(ctx, byoMachine)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("spec.providerID: Invalid value: \"byoh://host2/abcdef\": field is immutable once set"))
+	})
+
+	It("should reject changes to the host selection once a host is attached", func() {
+		now := metav1.Now()
+		attached := byoMachine.DeepCopy()
+		attached.Status.Timeline.HostSelectedTime = &now
+
+		updated := attached.DeepCopy()
+		updated.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"site": "emea"}}
+		updated.Spec.Kubelet = &byohv1beta1.KubeletSpec{ExtraArgs: map[string]string{"max-pods": "200"}}
+		err := updated.ValidateUpdate(attached)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("spec.selector"))
+		Expect(err.Error()).To(ContainSubstring("spec.kubelet"))
+		Expect(err.Error()).To(ContainSubstring("field is immutable once a ByoHost is attached"))
+	})
+
+	It("should allow changes to the node drain once a host is attached", func() {
+		now := metav1.Now()
+		attached := byoMachine.DeepCopy()
+		attached.Status.Timeline.HostSelectedTime = &now
+
+		updated := attached.DeepCopy()
+		updated.Spec.NodeDrain = &byohv1beta1.NodeDrainSpec{Timeout: &metav1.Duration{}}
+		Expect(updated.ValidateUpdate(attached)).To(Succeed())
+	})
+
+	It("should accept the node labels and taints the kubelet can register the node with", func() {
+		byoMachine.Spec.NodeLabels = map[string]string{"site": "store42", "node.kubernetes.io/pool": "gpu", "topology.kubernetes.io/zone": "z1"}
+		byoMachine.Spec.NodeTaints = []corev1.Taint{{Key: "nvidia.com/gpu", Value: "present", Effect: corev1.TaintEffectNoSchedule}}
+		Expect(byoMachine.ValidateCreate()).To(Succeed())
+	})
+
+	It("should reject the node labels restricted by the NodeRestriction admission plugin and the invalid taints", func() {
+		byoMachine.Spec.NodeLabels = map[string]string{"node-role.kubernetes.io/worker": ""}
+		byoMachine.Spec.NodeTaints = []corev1.Taint{{Key: "dedicated", Effect: "NoRun"}}
+		err := byoMachine.ValidateCreate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("spec.nodeLabels[node-role.kubernetes.io/worker]: Forbidden"))
+		Expect(err.Error()).To(ContainSubstring("spec.nodeTaints[0].effect: Unsupported value: \"NoRun\""))
+	})
+
+	It("should accept the kubelet extra args written as kubelet flags", func() {
+		byoMachine.Spec.Kubelet = &byohv1beta1.KubeletSpec{ExtraArgs: map[string]string{
+			"image-gc-high-threshold": "80", "feature-gates": "GracefulNodeShutdown=true,CPUManager=true"}}
+		Expect(byoMachine.ValidateCreate()).To(Succeed())
+	})
+
+	It("should reject the kubelet extra args breaking the kubelet flags", func() {
+		byoMachine.Spec.Kubelet = &byohv1beta1.KubeletSpec{ExtraArgs: map[string]string{
+			"--max-pods":      "200",
+			"node-labels":     "site=a b",
+			"system-reserved": "\"cpu=1\"",
+			"v":               "2\n--config=/tmp/kubelet.yaml",
+		}}
+		err := byoMachine.ValidateCreate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("spec.kubelet.extraArgs[--max-pods]: Invalid value: \"--max-pods\": must be the name of a kubelet flag"))
+		Expect(err.Error()).To(ContainSubstring("spec.kubelet.extraArgs[node-labels]: Invalid value: \"site=a b\": must not contain whitespaces"))
+		Expect(err.Error()).To(ContainSubstring("spec.kubelet.extraArgs[system-reserved]"))
+		Expect(err.Error()).To(ContainSubstring("spec.kubelet.extraArgs[v]"))
+	})
+})
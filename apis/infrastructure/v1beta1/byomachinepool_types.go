@@ -0,0 +1,83 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+const (
+	// MachinePoolFinalizer allows ReconcileByoMachinePool to release the
+	// ByoHosts attached to the ByoMachinePool before removing it from the
+	// API Server.
+	MachinePoolFinalizer = "byomachinepool.infrastructure.cluster.x-k8s.io"
+)
+
+// ByoMachinePoolSpec defines the desired state of ByoMachinePool
+type ByoMachinePoolSpec struct {
+	// Label Selector to choose the byohosts backing the pool
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// ProviderIDList are the identification IDs of the nodes of the ByoHosts
+	// attached to the pool
+	// +optional
+	ProviderIDList []string `json:"providerIDList,omitempty"`
+}
+
+// ByoMachinePoolStatus defines the observed state of ByoMachinePool
+type ByoMachinePoolStatus struct {
+	// Ready is true when the number of provisioned ByoHosts matches the
+	// desired replicas of the MachinePool
+	// +optional
+	Ready bool `json:"ready"`
+
+	// Replicas is the number of ByoHosts attached to the pool that are
+	// provisioned as nodes
+	// +optional
+	Replicas int32 `json:"replicas"`
+
+	// Conditions defines current service state of the ByoMachinePool.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:path=byomachinepools,scope=Namespaced,shortName=byomp
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Replicas",type="integer",JSONPath=".status.replicas"
+//+kubebuilder:printcolumn:name="Ready",type="boolean",JSONPath=".status.ready"
+
+// ByoMachinePool is the Schema for the byomachinepools API
+type ByoMachinePool struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ByoMachinePoolSpec   `json:"spec,omitempty"`
+	Status ByoMachinePoolStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ByoMachinePoolList contains a list of ByoMachinePool
+type ByoMachinePoolList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ByoMachinePool `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ByoMachinePool{}, &ByoMachinePoolList{})
+}
+
+// GetConditions returns the conditions of ByoMachinePool status
+func (byoMachinePool *ByoMachinePool) GetConditions() clusterv1.Conditions {
+	return byoMachinePool.Status.Conditions
+}
+
+// SetConditions sets the conditions of ByoMachinePool status
+func (byoMachinePool *ByoMachinePool) SetConditions(conditions clusterv1.Conditions) {
+	byoMachinePool.Status.Conditions = conditions
+}
@@ -5,6 +5,7 @@ package v1beta1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
 
 // ByoMachineTemplateSpec defines the desired state of ByoMachineTemplate
@@ -42,6 +43,11 @@ type ByoMachineTemplateList struct {
 
 // ByoMachineTemplateResource defines the desired state of ByoMachineTemplateResource
 type ByoMachineTemplateResource struct {
+	// Standard object's metadata.
+	// More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#metadata
+	// +optional
+	ObjectMeta clusterv1.ObjectMeta `json:"metadata,omitempty"`
+
 	// Spec is the specification of the desired behavior of the machine.
 	Spec ByoMachineSpec `json:"spec"`
 }
@@ -0,0 +1,71 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1beta1
+
+import (
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var byomachinetemplatelog = logf.Log.WithName("byomachinetemplate-resource")
+
+// SetupWebhookWithManager sets up the webhook for the byomachinetemplate resource
+func (byoMachineTemplate *ByoMachineTemplate) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(byoMachineTemplate).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-infrastructure-cluster-x-k8s-io-v1beta1-byomachinetemplate,mutating=false,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=byomachinetemplates,verbs=create;update,versions=v1beta1,name=vbyomachinetemplate.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &ByoMachineTemplate{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+// A template is shared by several machines, so it cannot pin them to a single host. Its node
+// labels and taints, and its kubelet extra args, are validated like the ones of a ByoMachine.
+func (byoMachineTemplate *ByoMachineTemplate) ValidateCreate() error {
+	byomachinetemplatelog.Info("validate create", "name", byoMachineTemplate.Name)
+	specPath := field.NewPath("spec", "template", "spec")
+	allErrs := validateNodeRegistration(&byoMachineTemplate.Spec.Template.Spec, specPath)
+	allErrs = append(allErrs, validateKubeletExtraArgs(&byoMachineTemplate.Spec.Template.Spec, specPath)...)
+	if byoMachineTemplate.Spec.Template.Spec.HostRef != nil {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("hostRef"),
+			"ByoMachineTemplate cannot pin its machines to a single ByoHost"))
+	}
+	if len(allErrs) > 0 {
+		return apierrors.NewInvalid(byoMachineTemplate.GroupVersionKind().GroupKind(), byoMachineTemplate.Name, allErrs)
+	}
+	return nil
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+// Machines are rolled out by pointing to a new template, both for MachineDeployments
+// and managed topologies, so the template spec is immutable.
+func (byoMachineTemplate *ByoMachineTemplate) ValidateUpdate(old runtime.Object) error {
+	byomachinetemplatelog.Info("validate update", "name", byoMachineTemplate.Name)
+	oldTemplate, ok := old.(*ByoMachineTemplate)
+	if !ok {
+		return apierrors.NewBadRequest("expected a ByoMachineTemplate")
+	}
+
+	if !reflect.DeepEqual(byoMachineTemplate.Spec.Template.Spec, oldTemplate.Spec.Template.Spec) {
+		return apierrors.NewInvalid(byoMachineTemplate.GroupVersionKind().GroupKind(), byoMachineTemplate.Name, field.ErrorList{
+			field.Invalid(field.NewPath("spec", "template", "spec"), byoMachineTemplate.Spec.Template.Spec,
+				"ByoMachineTemplate spec.template.spec field is immutable. Please create a new resource instead."),
+		})
+	}
+	return nil
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (byoMachineTemplate *ByoMachineTemplate) ValidateDelete() error {
+	return nil
+}
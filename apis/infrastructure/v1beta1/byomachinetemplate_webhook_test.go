@@ -0,0 +1,68 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1beta1_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	byohv1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("ByoMachineTemplateWebhook", func() {
+	var (
+		byoMachineTemplate *byohv1beta1.ByoMachineTemplate
+		ctx                context.Context
+		k8sClientUncached  client.Client
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		var clientErr error
+		k8sClientUncached, clientErr = client.New(cfg, client.Options{Scheme: scheme.Scheme})
+		Expect(clientErr).NotTo(HaveOccurred())
+
+		byoMachineTemplate = &byohv1beta1.ByoMachineTemplate{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "byomachinetemplate-",
+				Namespace:    "default",
+			},
+		}
+		Expect(k8sClientUncached.Create(ctx, byoMachineTemplate)).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(k8sClientUncached.Delete(ctx, byoMachineTemplate)).Should(Succeed())
+	})
+
+	It("should reject changes to the template spec", func() {
+		byoMachineTemplate.Spec.Template.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"rack": "r1"}}
+		err := k8sClientUncached.Update(ctx, byoMachineTemplate)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("ByoMachineTemplate spec.template.spec field is immutable. Please create a new resource instead."))
+	})
+
+	It("should reject a template pinning its machines to a host", func() {
+		pinnedTemplate := &byohv1beta1.ByoMachineTemplate{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "byomachinetemplate-",
+				Namespace:    "default",
+			},
+		}
+		pinnedTemplate.Spec.Template.Spec.HostRef = &corev1.ObjectReference{Name: "host1"}
+		err := k8sClientUncached.Create(ctx, pinnedTemplate)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("ByoMachineTemplate cannot pin its machines to a single ByoHost"))
+	})
+
+	It("should allow changes to the template metadata", func() {
+		byoMachineTemplate.Spec.Template.ObjectMeta.Labels = map[string]string{"nodepool": "pool1"}
+		Expect(k8sClientUncached.Update(ctx, byoMachineTemplate)).Should(Succeed())
+	})
+})
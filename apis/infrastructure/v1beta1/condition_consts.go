@@ -17,6 +17,17 @@ const (
 	// components are currently installed on the node.
 	K8sComponentsInstallationSucceeded clusterv1.ConditionType = "K8sComponentsInstallationSucceeded"
 
+	// K8sBundleDownloadSucceeded documents if the bundle of the Kubernetes components is downloaded
+	// on the host, the first phase of their installation. It is false with the BundleDownloadingReason
+	// while the bundle is downloaded.
+	K8sBundleDownloadSucceeded clusterv1.ConditionType = "K8sBundleDownloadSucceeded"
+
+	// BundleDownloadingReason indicates that the bundle of the k8s components is being downloaded
+	BundleDownloadingReason = "BundleDownloading"
+
+	// BundleDownloadFailedReason indicates that the bundle of the k8s components could not be downloaded
+	BundleDownloadFailedReason = "BundleDownloadFailed"
+
 	// WaitingForMachineRefReason indicates when a ByoHost is registered into a capacity pool and
 	// waiting for a byohost.Status.MachineRef to be assigned
 	WaitingForMachineRefReason = "WaitingForMachineRefToBeAssigned"
@@ -37,21 +48,159 @@ const (
 	CleanK8sDirectoriesFailedReason = "CleanK8sDirectoriesFailed"
 
 	// CloudInitExecutionFailedReason indicates that cloudinit failed to parse and execute the directives
-	// that are part of the cloud-config file
+	// that are part of the cloud-config file, or the executor of another format failed to execute the bootstrap data
 	CloudInitExecutionFailedReason = "CloudInitExecutionFailed"
 
+	// BootstrapTokenExpiredReason indicates that kubeadm failed to join the node as the bootstrap token of
+	// the bootstrap data expired. The ByoMachine controller has the bootstrap provider regenerate the bootstrap
+	// data with a fresh token, which the host agent retries with
+	BootstrapTokenExpiredReason = "BootstrapTokenExpired"
+
+	// BootstrapFormatUnsupportedReason indicates that the host agent has no executor for the format
+	// of the bootstrap data, set in the "format" key of the bootstrap secret
+	BootstrapFormatUnsupportedReason = "BootstrapFormatUnsupported"
+
 	// K8sNodeAbsentReason indicates that the node is not a Kubernetes node
 	// This is usually set after executing kubeadm reset on the node
 	K8sNodeAbsentReason = "K8sNodeAbsent"
 
+	// HostReimagedReason indicates that the host was reimaged since its node was bootstrapped, e.g. its
+	// machine-id changed, the host agent installs the k8s components and bootstraps the node again
+	HostReimagedReason = "HostReimaged"
+
 	// K8sComponentsInstallingReason indicates that the k8s components are being
 	// downloaded and installed
-	// TODO unused, remove it
 	K8sComponentsInstallingReason = "K8sComponentsInstalling"
 
 	// K8sComponentsInstallationFailedReason indicates that the installer failed to install all the
 	// k8s components on this host
 	K8sComponentsInstallationFailedReason = "K8sComponentsInstallationFailed"
+
+	// K8sComponentsInstallationRetriesStoppedReason indicates that the host agent stopped retrying the
+	// installation of the k8s components after repeated failures, until the RetryAnnotation is set
+	K8sComponentsInstallationRetriesStoppedReason = "K8sComponentsInstallationRetriesStopped"
+
+	// RebootRequiredReason indicates that the k8s components are installed but the host has to reboot
+	// before they can run, the reboot waits for the RebootApprovedAnnotation
+	RebootRequiredReason = "RebootRequired"
+
+	// RebootingReason indicates that the host agent rebooted the host, the installation of the
+	// k8s components resumes once it is back
+	RebootingReason = "Rebooting"
+
+	// AgentUpgradeSucceeded documents if the host agent is running the version
+	// requested through the DesiredAgentVersionAnnotation.
+	AgentUpgradeSucceeded clusterv1.ConditionType = "AgentUpgradeSucceeded"
+
+	// AgentUpgradeFailedReason indicates that the host agent failed to download, verify
+	// or switch to the desired agent binary
+	AgentUpgradeFailedReason = "AgentUpgradeFailed"
+
+	// HostReserved documents if the host is reserved, either for the ByoMachines
+	// pinning it or for the Cluster of ByoHost.Spec.ReservedFor.
+	HostReserved clusterv1.ConditionType = "HostReserved"
+
+	// ReservedClusterNotFoundReason indicates that the Cluster the host is reserved for does not exist
+	ReservedClusterNotFoundReason = "ReservedClusterNotFound"
+
+	// HostSchedulable documents if the host can be attached to new machines. It is set to false while
+	// the host is under maintenance, through ByoHost.Spec.Unschedulable or the UnschedulableAnnotation,
+	// and removed once the host is back in the capacity pool.
+	HostSchedulable clusterv1.ConditionType = "HostSchedulable"
+
+	// HostUnschedulableReason indicates that the host is marked unschedulable, it is not attached
+	// to new machines
+	HostUnschedulableReason = "HostUnschedulable"
+
+	// HostNotEnrolledReason indicates that the host is expected from a host inventory, its host agent
+	// has not registered it yet
+	HostNotEnrolledReason = "HostNotEnrolled"
+
+	// HostQuarantinedReason indicates that the host is quarantined for forensics, see QuarantineAnnotation
+	HostQuarantinedReason = "HostQuarantined"
+
+	// MaintenanceWindowOpen documents if the disruptive operations of the host can run. It is set to false
+	// by the host agent while an operation is deferred until the next maintenance window of the host,
+	// see MaintenanceWindowAnnotation, and removed once the operation ran.
+	MaintenanceWindowOpen clusterv1.ConditionType = "MaintenanceWindowOpen"
+
+	// WaitingForMaintenanceWindowReason indicates that a disruptive operation of the host is deferred
+	// until its next maintenance window opens
+	WaitingForMaintenanceWindowReason = "WaitingForMaintenanceWindow"
+
+	// MaintenanceWindowInvalidReason indicates that the maintenance windows of the host cannot be parsed,
+	// the disruptive operations are deferred until they are fixed
+	MaintenanceWindowInvalidReason = "MaintenanceWindowInvalid"
+
+	// HostPreflightSucceeded documents if the host meets the OS and kernel prerequisites
+	// of a Kubernetes node. The checks are run by the host agent before installing the
+	// k8s components, the reason of the first failed check is reported.
+	HostPreflightSucceeded clusterv1.ConditionType = "HostPreflightSucceeded"
+
+	// KernelModulesMissingReason indicates that the br_netfilter or overlay kernel modules are not loaded
+	KernelModulesMissingReason = "KernelModulesMissing"
+
+	// SysctlsNotSetReason indicates that the bridged traffic is not seen by iptables or
+	// that the IP forwarding is disabled
+	SysctlsNotSetReason = "SysctlsNotSet"
+
+	// SwapEnabledReason indicates that swap is on, which the kubelet fails to start with
+	SwapEnabledReason = "SwapEnabled"
+
+	// CgroupControllersMissingReason indicates that the cpu or memory cgroup controllers are not enabled
+	CgroupControllersMissingReason = "CgroupControllersMissing"
+
+	// SELinuxPolicyMissingReason indicates that SELinux is enforcing and the container-selinux policy,
+	// defining the file contexts of containerd and kubelet, is not installed
+	SELinuxPolicyMissingReason = "SELinuxPolicyMissing"
+
+	// AppArmorParserMissingReason indicates that AppArmor is enabled and containerd cannot load the
+	// AppArmor profile of the containers, apparmor_parser not being installed
+	AppArmorParserMissingReason = "AppArmorParserMissing"
+
+	// PortsInUseReason indicates that the ports of the Kubernetes components are in use by other processes
+	PortsInUseReason = "PortsInUse"
+
+	// InsufficientResourcesReason indicates that the host has less CPUs or memory than a Kubernetes node requires
+	InsufficientResourcesReason = "InsufficientResources"
+
+	// PreflightCheckErrorReason indicates that the preflight checks could not be run
+	PreflightCheckErrorReason = "PreflightCheckError"
+
+	// AgentDegraded documents that the host agent restarted its subsystems as a reconcile hung or
+	// kept panicking. Unlike the other conditions it is true while the agent is degraded, with the
+	// crash reason as message, and removed once the agent ran healthy for its crash loop window.
+	AgentDegraded clusterv1.ConditionType = "AgentDegraded"
+
+	// HungReconcileReason indicates that a reconcile of the host agent did not return within its hung timeout
+	HungReconcileReason = "HungReconcile"
+
+	// CrashLoopReason indicates that the reconciles of the host agent panicked repeatedly
+	CrashLoopReason = "CrashLoop"
+
+	// HostSecurityPosture documents if the configuration, the credentials, the downloads and the sudoers
+	// rules of the host agent are protected from the other users of the host, as checked by the agent on startup
+	HostSecurityPosture clusterv1.ConditionType = "HostSecurityPosture"
+
+	// InsecurePermissionsReason indicates that files of the host agent are owned or writable by other users,
+	// or that its credentials are readable by them, and the agent could not fix their permissions
+	InsecurePermissionsReason = "InsecurePermissions"
+
+	// SecurityCheckErrorReason indicates that the security self-check of the host agent could not be run
+	SecurityCheckErrorReason = "SecurityCheckError"
+
+	// NodeComponentsHealthy documents if the systemd units of the node components, containerd and the kubelet,
+	// are running on the host once its node is bootstrapped. The host agent restarts the failed units, with a
+	// backoff, and removes the condition once the node is reset.
+	NodeComponentsHealthy clusterv1.ConditionType = "NodeComponentsHealthy"
+
+	// NodeComponentsFailedReason indicates that systemd units of the node components failed or stopped,
+	// the message tells how many times the host agent restarted them
+	NodeComponentsFailedReason = "NodeComponentsFailed"
+
+	// NodeComponentsCheckErrorReason indicates that the state of the systemd units of the node components
+	// could not be checked
+	NodeComponentsCheckErrorReason = "NodeComponentsCheckError"
 )
 
 // Conditions and Reasons defined on BYOMachine
@@ -69,12 +218,132 @@ const (
 	// This secret is available on Machine.Spec.Bootstrap.DataSecretName
 	WaitingForBootstrapDataSecretReason = "WaitingForBootstrapDataSecret"
 
+	// BootstrapDataInvalidReason indicates that the data of the bootstrap secret is not in a format
+	// the host agent runs, e.g. a bootstrap provider of another infrastructure is configured
+	BootstrapDataInvalidReason = "BootstrapDataInvalid"
+
 	// BYOHostsUnavailableReason indicates that no byohosts are available in the capacity pool
 	BYOHostsUnavailableReason = "BYOHostsUnavailable"
 
 	// InstallationSecretNotAvailableReason indicates that the installation secret is not yet
 	// generated for a given BYOMachine
 	InstallationSecretNotAvailableReason = "InstallationSecretNotAvailable"
+
+	// ReservedHostUnavailableReason indicates that the ByoHost pinned by ByoMachine.Spec.HostRef
+	// does not exist, is attached to another machine or is reserved for another cluster
+	ReservedHostUnavailableReason = "ReservedHostUnavailable"
+
+	// WaitingForAvailableHostReason indicates that none of the ByoHosts matches the ByoMachine.
+	// The message counts the hosts filtered out by the selector, by capacity and by taints.
+	WaitingForAvailableHostReason = "WaitingForAvailableHost"
+
+	// WaitingForClaimReason indicates that the ByoHostClaim referenced by ByoMachine.Spec.ClaimRef
+	// is not bound to a host yet
+	WaitingForClaimReason = "WaitingForClaim"
+
+	// NodeDrainSucceeded documents the drain of the node of a deleted ByoMachine, which is
+	// cordoned and has its pods deleted before the host is released and reset
+	NodeDrainSucceeded clusterv1.ConditionType = "NodeDrainSucceeded"
+
+	// NodeDrainingReason indicates that the node is cordoned and its pods are being deleted
+	NodeDrainingReason = "NodeDraining"
+
+	// NodeDrainTimeoutReason indicates that pods were still running on the node when the drain
+	// timed out, the host is released anyway
+	NodeDrainTimeoutReason = "NodeDrainTimeout"
+)
+
+// Conditions and Reasons defined on ByoMachinePool
+const (
+
+	// ReplicasReady documents all the ByoHosts backing the pool are provisioned as nodes
+	ReplicasReady clusterv1.ConditionType = "ReplicasReady"
+
+	// WaitingForNodesReason indicates that some of the ByoHosts attached to the pool
+	// are not yet registered as nodes in the workload cluster
+	WaitingForNodesReason = "WaitingForNodes"
+)
+
+// Conditions and Reasons defined on ByoHostClaim
+const (
+
+	// HostBound documents the ByoHostClaim is bound to a ByoHost
+	HostBound clusterv1.ConditionType = "HostBound"
+
+	// ClaimedHostLostReason indicates that the ByoHost the claim was bound to was deleted
+	ClaimedHostLostReason = "ClaimedHostLost"
+)
+
+// Conditions and Reasons defined on ByoCluster
+const (
+
+	// ControlPlaneEndpointVIPReady documents the kube-vip lease of the control plane endpoint
+	// is held and renewed by a control plane host, when ByoCluster.Spec.KubeVIP is set
+	ControlPlaneEndpointVIPReady clusterv1.ConditionType = "ControlPlaneEndpointVIPReady"
+
+	// WaitingForControlPlaneInitializedReason indicates that the control plane of the cluster
+	// is not yet initialized, so kube-vip is not running yet
+	WaitingForControlPlaneInitializedReason = "WaitingForControlPlaneInitialized"
+
+	// VIPLeaseNotHeldReason indicates that no kube-vip instance holds the lease of the control plane endpoint
+	VIPLeaseNotHeldReason = "VIPLeaseNotHeld"
+
+	// VIPLeaseExpiredReason indicates that the kube-vip instance holding the lease of the control plane
+	// endpoint stopped renewing it
+	VIPLeaseExpiredReason = "VIPLeaseExpired"
+
+	// VIPLeaseCheckFailedReason indicates that the lease of the control plane endpoint
+	// could not be read from the workload cluster
+	VIPLeaseCheckFailedReason = "VIPLeaseCheckFailed"
+
+	// ControlPlaneEndpointLoadBalancerReady documents the external load balancer configuration
+	// lists the control plane machines as backends, when ByoCluster.Spec.LoadBalancer is set
+	ControlPlaneEndpointLoadBalancerReady clusterv1.ConditionType = "ControlPlaneEndpointLoadBalancerReady"
+
+	// WaitingForControlPlaneBackendsReason indicates that no control plane machine is attached
+	// to a host with a known address yet
+	WaitingForControlPlaneBackendsReason = "WaitingForControlPlaneBackends"
+
+	// LoadBalancerConfigFailedReason indicates that the load balancer configuration could not be written
+	LoadBalancerConfigFailedReason = "LoadBalancerConfigFailed"
+
+	// FleetHealthy documents no ByoMachine of the cluster failed and no ByoHost attached to the
+	// cluster reports an error. It is not summarized into the Ready condition of the ByoCluster.
+	FleetHealthy clusterv1.ConditionType = "FleetHealthy"
+
+	// MachinesFailedReason indicates that ByoMachines of the cluster failed to provision,
+	// or that their ByoHost reports an error
+	MachinesFailedReason = "MachinesFailed"
+
+	// HostsUnhealthyReason indicates that ByoHosts attached to the cluster, not to a ByoMachine
+	// of the cluster, report an error
+	HostsUnhealthyReason = "HostsUnhealthy"
+)
+
+// Conditions and Reasons defined on K8sInstallerConfig
+const (
+	// InstallationSecretAvailableCondition documents the installation secret of the K8sInstallerConfig
+	// was generated for the OS, the architecture and the Kubernetes version of its host
+	InstallationSecretAvailableCondition clusterv1.ConditionType = "InstallationSecretAvailable"
+
+	// BundleUnsupportedReason indicates that the bundle of the K8sInstallerConfig has no installer
+	// for the OS and the architecture reported by the host, or for the Kubernetes version of the machine
+	BundleUnsupportedReason = "BundleUnsupported"
+
+	// WaitingForRolloutReason indicates that the installation secret is held as the hosts of the site
+	// of the host installing at once reached the MaxConcurrentInstalls of the rollout policy
+	WaitingForRolloutReason = "WaitingForRollout"
+)
+
+// Conditions and Reasons defined on K8sBundleCatalog
+const (
+	// CatalogRefreshedCondition documents the bundles of the K8sBundleCatalog were listed from the bundle
+	// repository on the last refresh
+	CatalogRefreshedCondition clusterv1.ConditionType = "CatalogRefreshed"
+
+	// CatalogRefreshFailedReason indicates that the bundle repository could not be queried, the bundles
+	// of the last successful refresh are kept
+	CatalogRefreshFailedReason = "CatalogRefreshFailed"
 )
 
 // Reasons common to all Byo Resources
@@ -0,0 +1,18 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1beta1
+
+// v1beta1 is the storage version of ByoCluster and ByoMachine, the hub the other versions are converted to and from.
+
+// Hub marks ByoCluster as a conversion hub.
+func (*ByoCluster) Hub() {}
+
+// Hub marks ByoClusterList as a conversion hub.
+func (*ByoClusterList) Hub() {}
+
+// Hub marks ByoMachine as a conversion hub.
+func (*ByoMachine) Hub() {}
+
+// Hub marks ByoMachineList as a conversion hub.
+func (*ByoMachineList) Hub() {}
@@ -0,0 +1,111 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+// K8sBundleCatalogSpec defines the bundle repository queried by the K8sBundleCatalog
+type K8sBundleCatalogSpec struct {
+	// BundleRepo is the OCI registry from which the carvel imgpkg bundles are downloaded,
+	// as in the K8sInstallerConfigs
+	BundleRepo string `json:"bundleRepo"`
+
+	// BundleType is the type of bundle (e.g. k8s) that is listed
+	BundleType string `json:"bundleType"`
+
+	// RefreshInterval is how often the bundle repository is queried. Defaults to 1h.
+	// +optional
+	RefreshInterval *metav1.Duration `json:"refreshInterval,omitempty"`
+}
+
+// CatalogBundle is a bundle published in the bundle repository
+type CatalogBundle struct {
+	// K8sVersion is the Kubernetes version of the bundle, e.g. v1.22.3
+	K8sVersion string `json:"k8sVersion"`
+
+	// OS is the OS the bundle is built for, e.g. Ubuntu_20.04.1, which installs any patch release
+	// of the OS. It is not set for the bundles installing any OS, e.g. the rke2 ones.
+	// +optional
+	OS string `json:"os,omitempty"`
+
+	// Arch is the architecture of the hosts, as reported by them, e.g. amd64
+	Arch string `json:"arch"`
+
+	// Image is the address of the bundle in the bundle repository
+	Image string `json:"image"`
+}
+
+// K8sBundleCatalogStatus defines the bundles found in the bundle repository
+type K8sBundleCatalogStatus struct {
+	// Bundles are the bundles of the bundle repository the host agent installs
+	// +optional
+	Bundles []CatalogBundle `json:"bundles,omitempty"`
+
+	// LastRefreshTime is when the bundle repository was last queried successfully
+	// +optional
+	LastRefreshTime *metav1.Time `json:"lastRefreshTime,omitempty"`
+
+	// ObservedGeneration is the generation of the spec the bundles were listed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions defines current service state of the K8sBundleCatalog.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:path=k8sbundlecatalogs,scope=Namespaced,shortName=k8sbc
+//+kubebuilder:printcolumn:name="BundleRepo",type="string",JSONPath=".spec.bundleRepo"
+//+kubebuilder:printcolumn:name="Refreshed",type="string",JSONPath=".status.conditions[?(@.type=='CatalogRefreshed')].status"
+//+kubebuilder:printcolumn:name="LastRefresh",type="date",JSONPath=".status.lastRefreshTime"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// K8sBundleCatalog is the Schema for the k8sbundlecatalogs API. It publishes the (Kubernetes version,
+// OS, architecture) of the bundles of a bundle repository, which the K8sInstallerConfigs of the
+// namespace installing from the same repository are validated against.
+type K8sBundleCatalog struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   K8sBundleCatalogSpec   `json:"spec,omitempty"`
+	Status K8sBundleCatalogStatus `json:"status,omitempty"`
+}
+
+// GetConditions gets the K8sBundleCatalog status conditions
+func (catalog *K8sBundleCatalog) GetConditions() clusterv1.Conditions {
+	return catalog.Status.Conditions
+}
+
+// SetConditions sets the K8sBundleCatalog status conditions
+func (catalog *K8sBundleCatalog) SetConditions(conditions clusterv1.Conditions) {
+	catalog.Status.Conditions = conditions
+}
+
+// HasBundle returns whether the bundle repository publishes the bundle image
+func (catalog *K8sBundleCatalog) HasBundle(image string) bool {
+	for _, bundle := range catalog.Status.Bundles {
+		if bundle.Image == image {
+			return true
+		}
+	}
+	return false
+}
+
+//+kubebuilder:object:root=true
+
+// K8sBundleCatalogList contains a list of K8sBundleCatalog
+type K8sBundleCatalogList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []K8sBundleCatalog `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&K8sBundleCatalog{}, &K8sBundleCatalogList{})
+}
@@ -5,7 +5,9 @@ package v1beta1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
 
 const (
@@ -22,6 +24,200 @@ type K8sInstallerConfigSpec struct {
 
 	// BundleType is the type of bundle (e.g. k8s) that needs to be downloaded
 	BundleType string `json:"bundleType"`
+
+	// HostConfiguration is how the installation script prepares the OS of the host for the kubelet.
+	// The host is fully configured when not set.
+	// +optional
+	HostConfiguration *HostConfigurationPolicy `json:"hostConfiguration,omitempty"`
+
+	// Containerd patches the containerd configuration of the bundle. The patch is imported
+	// by /etc/containerd/config.toml before containerd is started.
+	// +optional
+	Containerd *ContainerdConfigPatch `json:"containerd,omitempty"`
+
+	// Components selects how the installation script handles the components of the bundle which
+	// may be preinstalled on the host image, e.g. containerd. They are all installed when not set.
+	// +optional
+	Components *ComponentsPolicy `json:"components,omitempty"`
+
+	// CPUManagement renders the kubelet CPU manager and topology manager settings of the host,
+	// e.g. to pin the CPUs of the guaranteed pods. The kubelet defaults are kept when not set.
+	// +optional
+	CPUManagement *CPUManagementPolicy `json:"cpuManagement,omitempty"`
+
+	// DownloadRateLimit is the bandwidth the installation script downloads the bundle with, in bytes
+	// per second, e.g. 1Mi, so that the hosts enrolled at once do not saturate the uplink of their site.
+	// The bundle is pulled through trickle when the host has it. The download is not throttled when not set.
+	// +optional
+	DownloadRateLimit *resource.Quantity `json:"downloadRateLimit,omitempty"`
+
+	// Rollout staggers the installations of the hosts, so that only a few hosts of a site download the
+	// bundle and install at once. The hosts are all installed at once when not set.
+	// +optional
+	Rollout *RolloutPolicy `json:"rollout,omitempty"`
+}
+
+// RolloutPolicy limits the hosts of a site installing at once. A host is installing from the generation
+// of its installation secret until its node is bootstrapped, a failed installation keeps holding its slot
+// so that a bad bundle does not fail the whole site.
+type RolloutPolicy struct {
+	// MaxConcurrentInstalls is the number of hosts of a site installing at once, among the hosts
+	// installed by the K8sInstallerConfigs of the namespace
+	// +kubebuilder:validation:Minimum=1
+	MaxConcurrentInstalls int32 `json:"maxConcurrentInstalls"`
+
+	// SiteLabel is the label of the ByoHosts naming their site, e.g. topology.kubernetes.io/zone, the
+	// installations are counted per value of the label. The hosts of the namespace are a single site
+	// when not set.
+	// +optional
+	SiteLabel string `json:"siteLabel,omitempty"`
+}
+
+// CPUManagementPolicy configures how the kubelet assigns the CPUs of the host to the containers
+type CPUManagementPolicy struct {
+	// CPUManagerPolicy is the policy of the kubelet CPU manager, static grants exclusive CPUs
+	// to the containers of the guaranteed pods requesting whole CPUs. Defaults to static.
+	// +kubebuilder:validation:Enum=none;static
+	// +optional
+	CPUManagerPolicy string `json:"cpuManagerPolicy,omitempty"`
+
+	// TopologyManagerPolicy is how the kubelet topology manager aligns the CPUs and the devices
+	// of the containers on the NUMA nodes of the host. The alignment is left out when not set.
+	// +kubebuilder:validation:Enum=none;best-effort;restricted;single-numa-node
+	// +optional
+	TopologyManagerPolicy string `json:"topologyManagerPolicy,omitempty"`
+
+	// TopologyManagerScope is whether the resources are aligned per container or per pod.
+	// Defaults to the kubelet default, container.
+	// +kubebuilder:validation:Enum=container;pod
+	// +optional
+	TopologyManagerScope string `json:"topologyManagerScope,omitempty"`
+
+	// ReservedCPUsPerNUMANode is the number of CPUs of each NUMA node reserved for the system and
+	// the Kubernetes daemons, which are never pinned. The first CPUs of each node are reserved,
+	// according to the CPU topology reported by the host. Defaults to 1 with the static policy.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ReservedCPUsPerNUMANode *int32 `json:"reservedCPUsPerNUMANode,omitempty"`
+}
+
+// ComponentInstallPolicy is when the installation script installs a component of the bundle
+// +kubebuilder:validation:Enum=Always;IfNotPresent;Never
+type ComponentInstallPolicy string
+
+const (
+	// ComponentInstallAlways installs the component of the bundle, whatever the host has
+	ComponentInstallAlways ComponentInstallPolicy = "Always"
+	// ComponentInstallIfNotPresent installs the component of the bundle unless the host has it.
+	// The installation fails when the version the host has is not compatible.
+	ComponentInstallIfNotPresent ComponentInstallPolicy = "IfNotPresent"
+	// ComponentInstallNever never installs the component of the bundle. The installation fails
+	// unless the host has a compatible version.
+	ComponentInstallNever ComponentInstallPolicy = "Never"
+)
+
+// ComponentPolicy is how the installation script handles a component of the bundle. The
+// components it does not install are left in place by the uninstallation script.
+type ComponentPolicy struct {
+	// Install is when the component of the bundle is installed. Defaults to Always.
+	// +optional
+	Install ComponentInstallPolicy `json:"install,omitempty"`
+
+	// MinVersion is the oldest version of the component the host may have, e.g. 1.6.0.
+	// Any version is compatible when not set.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^v?[0-9]+(\.[0-9]+)*$`
+	MinVersion string `json:"minVersion,omitempty"`
+}
+
+// InstallPolicy returns when the component of the bundle is installed
+func (p *ComponentPolicy) InstallPolicy() ComponentInstallPolicy {
+	if p == nil || p.Install == "" {
+		return ComponentInstallAlways
+	}
+	return p.Install
+}
+
+// ComponentsPolicy selects how the components of the bundle are installed
+type ComponentsPolicy struct {
+	// Containerd is the container runtime, along with the CNI plugins of its release
+	// +optional
+	Containerd *ComponentPolicy `json:"containerd,omitempty"`
+
+	// CRITools is crictl
+	// +optional
+	CRITools *ComponentPolicy `json:"criTools,omitempty"`
+
+	// Kubernetes are kubelet, kubeadm, kubectl and the kubernetes CNI plugins. Their version
+	// must be the Kubernetes version of the machine, the MinVersion is ignored.
+	// +optional
+	Kubernetes *ComponentPolicy `json:"kubernetes,omitempty"`
+}
+
+// ContainerdConfigPatch is the site specific containerd configuration, e.g. the registry mirrors
+type ContainerdConfigPatch struct {
+	// RegistryMirrors are the endpoints of the mirrors of the registries, keyed by registry host,
+	// e.g. docker.io. The registry itself is used when none of its mirrors is reachable.
+	// +optional
+	RegistryMirrors map[string][]string `json:"registryMirrors,omitempty"`
+
+	// InsecureRegistries are the registries, or mirrors, whose TLS certificate is not verified
+	// +optional
+	InsecureRegistries []string `json:"insecureRegistries,omitempty"`
+
+	// SandboxImage is the image of the pause container of the pods
+	// +optional
+	SandboxImage string `json:"sandboxImage,omitempty"`
+
+	// Proxy is the proxy containerd pulls the images through
+	// +optional
+	Proxy *ContainerdProxy `json:"proxy,omitempty"`
+}
+
+// ContainerdProxy is the proxy containerd goes through, set in the environment of its systemd service
+type ContainerdProxy struct {
+	// +optional
+	HTTPProxy string `json:"httpProxy,omitempty"`
+
+	// +optional
+	HTTPSProxy string `json:"httpsProxy,omitempty"`
+
+	// +optional
+	NoProxy string `json:"noProxy,omitempty"`
+}
+
+// HostConfigurationPolicy selects the OS settings changed by the installation script, and
+// reverted by the uninstallation one. The settings left out are expected to be managed by
+// the host admin, e.g. when they are baked into the host image.
+type HostConfigurationPolicy struct {
+	// DisableSwap turns swap off, comments the swap entries of /etc/fstab out and masks the
+	// swap units of systemd, so that swap stays off across reboots. Defaults to true.
+	// +optional
+	DisableSwap *bool `json:"disableSwap,omitempty"`
+
+	// ConfigureSysctls applies the sysctls of the bundle, which have iptables see the bridged
+	// traffic and enable the IP forwarding. Defaults to true.
+	// +optional
+	ConfigureSysctls *bool `json:"configureSysctls,omitempty"`
+
+	// LoadKernelModules loads the overlay and br_netfilter kernel modules. Defaults to true.
+	// +optional
+	LoadKernelModules *bool `json:"loadKernelModules,omitempty"`
+}
+
+// SwapDisabled returns whether the installation script disables swap
+func (p *HostConfigurationPolicy) SwapDisabled() bool {
+	return p == nil || p.DisableSwap == nil || *p.DisableSwap
+}
+
+// SysctlsConfigured returns whether the installation script applies the sysctls
+func (p *HostConfigurationPolicy) SysctlsConfigured() bool {
+	return p == nil || p.ConfigureSysctls == nil || *p.ConfigureSysctls
+}
+
+// KernelModulesLoaded returns whether the installation script loads the kernel modules
+func (p *HostConfigurationPolicy) KernelModulesLoaded() bool {
+	return p == nil || p.LoadKernelModules == nil || *p.LoadKernelModules
 }
 
 // K8sInstallerConfigStatus defines the observed state of K8sInstallerConfig
@@ -33,6 +229,15 @@ type K8sInstallerConfigStatus struct {
 	// InstallationSecret is an optional reference to a generated installation secret by K8sInstallerConfig controller
 	// +optional
 	InstallationSecret *corev1.ObjectReference `json:"installationSecret,omitempty"`
+
+	// KubeletExtraArgs are the kubelet flags rendered for the host from the CPUManagement policy,
+	// which are added to the kubelet flags of the host before the ones of its ByoMachine.
+	// +optional
+	KubeletExtraArgs string `json:"kubeletExtraArgs,omitempty"`
+
+	// Conditions defines current service state of the K8sInstallerConfig.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -47,6 +252,16 @@ type K8sInstallerConfig struct {
 	Status K8sInstallerConfigStatus `json:"status,omitempty"`
 }
 
+// GetConditions gets the K8sInstallerConfig status conditions
+func (k8sInstallerConfig *K8sInstallerConfig) GetConditions() clusterv1.Conditions {
+	return k8sInstallerConfig.Status.Conditions
+}
+
+// SetConditions sets the K8sInstallerConfig status conditions
+func (k8sInstallerConfig *K8sInstallerConfig) SetConditions(conditions clusterv1.Conditions) {
+	k8sInstallerConfig.Status.Conditions = conditions
+}
+
 //+kubebuilder:object:root=true
 
 // K8sInstallerConfigList contains a list of K8sInstallerConfig
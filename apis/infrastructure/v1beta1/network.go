@@ -0,0 +1,62 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1beta1
+
+import (
+	"net"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ParseIPFamilies parses the comma separated IP families of the IPFamiliesAnnotation,
+// ignoring the unknown ones
+func ParseIPFamilies(value string) []corev1.IPFamily {
+	families := []corev1.IPFamily{}
+	for _, family := range strings.Split(value, ",") {
+		switch corev1.IPFamily(strings.TrimSpace(family)) {
+		case corev1.IPv4Protocol:
+			families = append(families, corev1.IPv4Protocol)
+		case corev1.IPv6Protocol:
+			families = append(families, corev1.IPv6Protocol)
+		}
+	}
+	return families
+}
+
+// DefaultAddress returns the first global unicast address of the family on the network interface
+// the default route of the family goes through, or else on the default network interface.
+// It returns an empty string when the host has no such address.
+func DefaultAddress(networks []NetworkStatus, family corev1.IPFamily) string {
+	for _, isDefault := range []func(NetworkStatus) bool{
+		func(network NetworkStatus) bool {
+			if family == corev1.IPv6Protocol {
+				return network.IsDefaultIPv6
+			}
+			return network.IsDefaultIPv4
+		},
+		func(network NetworkStatus) bool { return network.IsDefault },
+	} {
+		for _, network := range networks {
+			if !isDefault(network) {
+				continue
+			}
+			addrs := network.IPv4Addrs
+			if family == corev1.IPv6Protocol {
+				addrs = network.IPv6Addrs
+			}
+			for _, addr := range addrs {
+				// the agent reports the addresses in CIDR notation
+				ip, _, err := net.ParseCIDR(addr)
+				if err != nil {
+					ip = net.ParseIP(addr)
+				}
+				if ip != nil && ip.IsGlobalUnicast() {
+					return ip.String()
+				}
+			}
+		}
+	}
+	return ""
+}
@@ -9,8 +9,8 @@
 package v1beta1
 
 import (
-	"k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	apiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
@@ -30,12 +30,164 @@ func (in *APIEndpoint) DeepCopy() *APIEndpoint {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AgentInfo) DeepCopyInto(out *AgentInfo) {
+	*out = *in
+	if in.FeatureGates != nil {
+		in, out := &in.FeatureGates, &out.FeatureGates
+		*out = make(map[string]bool, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Flags != nil {
+		in, out := &in.Flags, &out.Flags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AgentInfo.
+func (in *AgentInfo) DeepCopy() *AgentInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(AgentInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AntiAffinity) DeepCopyInto(out *AntiAffinity) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AntiAffinity.
+func (in *AntiAffinity) DeepCopy() *AntiAffinity {
+	if in == nil {
+		return nil
+	}
+	out := new(AntiAffinity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BootstrapKubeconfig) DeepCopyInto(out *BootstrapKubeconfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootstrapKubeconfig.
+func (in *BootstrapKubeconfig) DeepCopy() *BootstrapKubeconfig {
+	if in == nil {
+		return nil
+	}
+	out := new(BootstrapKubeconfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BootstrapKubeconfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BootstrapKubeconfigList) DeepCopyInto(out *BootstrapKubeconfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]BootstrapKubeconfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootstrapKubeconfigList.
+func (in *BootstrapKubeconfigList) DeepCopy() *BootstrapKubeconfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(BootstrapKubeconfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BootstrapKubeconfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BootstrapKubeconfigSpec) DeepCopyInto(out *BootstrapKubeconfigSpec) {
+	*out = *in
+	if in.TTL != nil {
+		in, out := &in.TTL, &out.TTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootstrapKubeconfigSpec.
+func (in *BootstrapKubeconfigSpec) DeepCopy() *BootstrapKubeconfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BootstrapKubeconfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BootstrapKubeconfigStatus) DeepCopyInto(out *BootstrapKubeconfigStatus) {
+	*out = *in
+	if in.BootstrapKubeconfigData != nil {
+		in, out := &in.BootstrapKubeconfigData, &out.BootstrapKubeconfigData
+		*out = new(string)
+		**out = **in
+	}
+	if in.ExpirationTime != nil {
+		in, out := &in.ExpirationTime, &out.ExpirationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.AgentKubeconfigData != nil {
+		in, out := &in.AgentKubeconfigData, &out.AgentKubeconfigData
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootstrapKubeconfigStatus.
+func (in *BootstrapKubeconfigStatus) DeepCopy() *BootstrapKubeconfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BootstrapKubeconfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ByoCluster) DeepCopyInto(out *ByoCluster) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -93,6 +245,16 @@ func (in *ByoClusterList) DeepCopyObject() runtime.Object {
 func (in *ByoClusterSpec) DeepCopyInto(out *ByoClusterSpec) {
 	*out = *in
 	out.ControlPlaneEndpoint = in.ControlPlaneEndpoint
+	if in.KubeVIP != nil {
+		in, out := &in.KubeVIP, &out.KubeVIP
+		*out = new(KubeVIPSpec)
+		**out = **in
+	}
+	if in.LoadBalancer != nil {
+		in, out := &in.LoadBalancer, &out.LoadBalancer
+		*out = new(LoadBalancerSpec)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoClusterSpec.
@@ -196,7 +358,7 @@ func (in *ByoClusterTemplateList) DeepCopyObject() runtime.Object {
 func (in *ByoClusterTemplateResource) DeepCopyInto(out *ByoClusterTemplateResource) {
 	*out = *in
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoClusterTemplateResource.
@@ -253,31 +415,58 @@ func (in *ByoHost) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ByoHostList) DeepCopyInto(out *ByoHostList) {
+func (in *ByoHostClaim) DeepCopyInto(out *ByoHostClaim) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoHostClaim.
+func (in *ByoHostClaim) DeepCopy() *ByoHostClaim {
+	if in == nil {
+		return nil
+	}
+	out := new(ByoHostClaim)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ByoHostClaim) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ByoHostClaimList) DeepCopyInto(out *ByoHostClaimList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]ByoHost, len(*in))
+		*out = make([]ByoHostClaim, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoHostList.
-func (in *ByoHostList) DeepCopy() *ByoHostList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoHostClaimList.
+func (in *ByoHostClaimList) DeepCopy() *ByoHostClaimList {
 	if in == nil {
 		return nil
 	}
-	out := new(ByoHostList)
+	out := new(ByoHostClaimList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ByoHostList) DeepCopyObject() runtime.Object {
+func (in *ByoHostClaimList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -285,36 +474,36 @@ func (in *ByoHostList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ByoHostSpec) DeepCopyInto(out *ByoHostSpec) {
+func (in *ByoHostClaimSpec) DeepCopyInto(out *ByoHostClaimSpec) {
 	*out = *in
-	if in.BootstrapSecret != nil {
-		in, out := &in.BootstrapSecret, &out.BootstrapSecret
-		*out = new(v1.ObjectReference)
-		**out = **in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
 	}
-	if in.InstallationSecret != nil {
-		in, out := &in.InstallationSecret, &out.InstallationSecret
-		*out = new(v1.ObjectReference)
+	if in.HostRef != nil {
+		in, out := &in.HostRef, &out.HostRef
+		*out = new(corev1.ObjectReference)
 		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoHostSpec.
-func (in *ByoHostSpec) DeepCopy() *ByoHostSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoHostClaimSpec.
+func (in *ByoHostClaimSpec) DeepCopy() *ByoHostClaimSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ByoHostSpec)
+	out := new(ByoHostClaimSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ByoHostStatus) DeepCopyInto(out *ByoHostStatus) {
+func (in *ByoHostClaimStatus) DeepCopyInto(out *ByoHostClaimStatus) {
 	*out = *in
-	if in.MachineRef != nil {
-		in, out := &in.MachineRef, &out.MachineRef
-		*out = new(v1.ObjectReference)
+	if in.HostRef != nil {
+		in, out := &in.HostRef, &out.HostRef
+		*out = new(corev1.ObjectReference)
 		**out = **in
 	}
 	if in.Conditions != nil {
@@ -324,47 +513,70 @@ func (in *ByoHostStatus) DeepCopyInto(out *ByoHostStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	out.HostDetails = in.HostDetails
-	if in.Network != nil {
-		in, out := &in.Network, &out.Network
-		*out = make([]NetworkStatus, len(*in))
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoHostClaimStatus.
+func (in *ByoHostClaimStatus) DeepCopy() *ByoHostClaimStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ByoHostClaimStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ByoHostList) DeepCopyInto(out *ByoHostList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ByoHost, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoHostStatus.
-func (in *ByoHostStatus) DeepCopy() *ByoHostStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoHostList.
+func (in *ByoHostList) DeepCopy() *ByoHostList {
 	if in == nil {
 		return nil
 	}
-	out := new(ByoHostStatus)
+	out := new(ByoHostList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ByoHostList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ByoMachine) DeepCopyInto(out *ByoMachine) {
+func (in *ByoHostQuota) DeepCopyInto(out *ByoHostQuota) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoMachine.
-func (in *ByoMachine) DeepCopy() *ByoMachine {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoHostQuota.
+func (in *ByoHostQuota) DeepCopy() *ByoHostQuota {
 	if in == nil {
 		return nil
 	}
-	out := new(ByoMachine)
+	out := new(ByoHostQuota)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ByoMachine) DeepCopyObject() runtime.Object {
+func (in *ByoHostQuota) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -372,31 +584,31 @@ func (in *ByoMachine) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ByoMachineList) DeepCopyInto(out *ByoMachineList) {
+func (in *ByoHostQuotaList) DeepCopyInto(out *ByoHostQuotaList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]ByoMachine, len(*in))
+		*out = make([]ByoHostQuota, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoMachineList.
-func (in *ByoMachineList) DeepCopy() *ByoMachineList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoHostQuotaList.
+func (in *ByoHostQuotaList) DeepCopy() *ByoHostQuotaList {
 	if in == nil {
 		return nil
 	}
-	out := new(ByoMachineList)
+	out := new(ByoHostQuotaList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ByoMachineList) DeepCopyObject() runtime.Object {
+func (in *ByoHostQuotaList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -404,106 +616,904 @@ func (in *ByoMachineList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ByoMachineSpec) DeepCopyInto(out *ByoMachineSpec) {
+func (in *ByoHostQuotaSpec) DeepCopyInto(out *ByoHostQuotaSpec) {
 	*out = *in
-	if in.Selector != nil {
-		in, out := &in.Selector, &out.Selector
-		*out = new(metav1.LabelSelector)
-		(*in).DeepCopyInto(*out)
+	if in.MaxHosts != nil {
+		in, out := &in.MaxHosts, &out.MaxHosts
+		*out = new(int32)
+		**out = **in
 	}
-	if in.InstallerRef != nil {
-		in, out := &in.InstallerRef, &out.InstallerRef
-		*out = new(v1.ObjectReference)
+	if in.MaxHostsPerBootstrapToken != nil {
+		in, out := &in.MaxHostsPerBootstrapToken, &out.MaxHostsPerBootstrapToken
+		*out = new(int32)
 		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoMachineSpec.
-func (in *ByoMachineSpec) DeepCopy() *ByoMachineSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoHostQuotaSpec.
+func (in *ByoHostQuotaSpec) DeepCopy() *ByoHostQuotaSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ByoMachineSpec)
+	out := new(ByoHostQuotaSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ByoMachineStatus) DeepCopyInto(out *ByoMachineStatus) {
+func (in *ByoHostSpec) DeepCopyInto(out *ByoHostSpec) {
 	*out = *in
-	out.HostInfo = in.HostInfo
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make(apiv1beta1.Conditions, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.BootstrapSecret != nil {
+		in, out := &in.BootstrapSecret, &out.BootstrapSecret
+		*out = new(corev1.ObjectReference)
+		**out = **in
+	}
+	if in.InstallationSecret != nil {
+		in, out := &in.InstallationSecret, &out.InstallationSecret
+		*out = new(corev1.ObjectReference)
+		**out = **in
+	}
+	if in.AllowedNamespaces != nil {
+		in, out := &in.AllowedNamespaces, &out.AllowedNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ReservedFor != nil {
+		in, out := &in.ReservedFor, &out.ReservedFor
+		*out = new(corev1.ObjectReference)
+		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoMachineStatus.
-func (in *ByoMachineStatus) DeepCopy() *ByoMachineStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoHostSpec.
+func (in *ByoHostSpec) DeepCopy() *ByoHostSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ByoMachineStatus)
+	out := new(ByoHostSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ByoMachineTemplate) DeepCopyInto(out *ByoMachineTemplate) {
+func (in *ByoHostStatus) DeepCopyInto(out *ByoHostStatus) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoMachineTemplate.
-func (in *ByoMachineTemplate) DeepCopy() *ByoMachineTemplate {
-	if in == nil {
-		return nil
+	if in.MachineRef != nil {
+		in, out := &in.MachineRef, &out.MachineRef
+		*out = new(corev1.ObjectReference)
+		**out = **in
 	}
-	out := new(ByoMachineTemplate)
-	in.DeepCopyInto(out)
-	return out
-}
-
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(apiv1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.HostDetails.DeepCopyInto(&out.HostDetails)
+	if in.Network != nil {
+		in, out := &in.Network, &out.Network
+		*out = make([]NetworkStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AgentInfo != nil {
+		in, out := &in.AgentInfo, &out.AgentInfo
+		*out = new(AgentInfo)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Backoff != nil {
+		in, out := &in.Backoff, &out.Backoff
+		*out = make([]ErrorBackoff, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.InstallerAudit != nil {
+		in, out := &in.InstallerAudit, &out.InstallerAudit
+		*out = new(InstallerAuditSummary)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LastHeartbeatTime != nil {
+		in, out := &in.LastHeartbeatTime, &out.LastHeartbeatTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Node != nil {
+		in, out := &in.Node, &out.Node
+		*out = new(NodeStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.InstallationProgress != nil {
+		in, out := &in.InstallationProgress, &out.InstallationProgress
+		*out = new(InstallationProgress)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoHostStatus.
+func (in *ByoHostStatus) DeepCopy() *ByoHostStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ByoHostStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ByoMachine) DeepCopyInto(out *ByoMachine) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoMachine.
+func (in *ByoMachine) DeepCopy() *ByoMachine {
+	if in == nil {
+		return nil
+	}
+	out := new(ByoMachine)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ByoMachineTemplate) DeepCopyObject() runtime.Object {
+func (in *ByoMachine) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ByoMachineList) DeepCopyInto(out *ByoMachineList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ByoMachine, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoMachineList.
+func (in *ByoMachineList) DeepCopy() *ByoMachineList {
+	if in == nil {
+		return nil
+	}
+	out := new(ByoMachineList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ByoMachineList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ByoMachinePool) DeepCopyInto(out *ByoMachinePool) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoMachinePool.
+func (in *ByoMachinePool) DeepCopy() *ByoMachinePool {
+	if in == nil {
+		return nil
+	}
+	out := new(ByoMachinePool)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ByoMachinePool) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ByoMachinePoolList) DeepCopyInto(out *ByoMachinePoolList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ByoMachinePool, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoMachinePoolList.
+func (in *ByoMachinePoolList) DeepCopy() *ByoMachinePoolList {
+	if in == nil {
+		return nil
+	}
+	out := new(ByoMachinePoolList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ByoMachinePoolList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
-	return nil
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ByoMachinePoolSpec) DeepCopyInto(out *ByoMachinePoolSpec) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ProviderIDList != nil {
+		in, out := &in.ProviderIDList, &out.ProviderIDList
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoMachinePoolSpec.
+func (in *ByoMachinePoolSpec) DeepCopy() *ByoMachinePoolSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ByoMachinePoolSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ByoMachinePoolStatus) DeepCopyInto(out *ByoMachinePoolStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(apiv1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoMachinePoolStatus.
+func (in *ByoMachinePoolStatus) DeepCopy() *ByoMachinePoolStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ByoMachinePoolStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ByoMachineSpec) DeepCopyInto(out *ByoMachineSpec) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.InstallerRef != nil {
+		in, out := &in.InstallerRef, &out.InstallerRef
+		*out = new(corev1.ObjectReference)
+		**out = **in
+	}
+	if in.AntiAffinity != nil {
+		in, out := &in.AntiAffinity, &out.AntiAffinity
+		*out = new(AntiAffinity)
+		**out = **in
+	}
+	if in.HostRef != nil {
+		in, out := &in.HostRef, &out.HostRef
+		*out = new(corev1.ObjectReference)
+		**out = **in
+	}
+	if in.ClaimRef != nil {
+		in, out := &in.ClaimRef, &out.ClaimRef
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+	if in.Kubelet != nil {
+		in, out := &in.Kubelet, &out.Kubelet
+		*out = new(KubeletSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodeDrain != nil {
+		in, out := &in.NodeDrain, &out.NodeDrain
+		*out = new(NodeDrainSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Devices != nil {
+		in, out := &in.Devices, &out.Devices
+		*out = new(DeviceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodeLabels != nil {
+		in, out := &in.NodeLabels, &out.NodeLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.NodeTaints != nil {
+		in, out := &in.NodeTaints, &out.NodeTaints
+		*out = make([]corev1.Taint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoMachineSpec.
+func (in *ByoMachineSpec) DeepCopy() *ByoMachineSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ByoMachineSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ByoMachineStatus) DeepCopyInto(out *ByoMachineStatus) {
+	*out = *in
+	in.HostInfo.DeepCopyInto(&out.HostInfo)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(apiv1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.Timeline.DeepCopyInto(&out.Timeline)
+	if in.ProvisioningDuration != nil {
+		in, out := &in.ProvisioningDuration, &out.ProvisioningDuration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Node != nil {
+		in, out := &in.Node, &out.Node
+		*out = new(NodeStatus)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoMachineStatus.
+func (in *ByoMachineStatus) DeepCopy() *ByoMachineStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ByoMachineStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ByoMachineTemplate) DeepCopyInto(out *ByoMachineTemplate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoMachineTemplate.
+func (in *ByoMachineTemplate) DeepCopy() *ByoMachineTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(ByoMachineTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ByoMachineTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ByoMachineTemplateList) DeepCopyInto(out *ByoMachineTemplateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ByoMachineTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoMachineTemplateList.
+func (in *ByoMachineTemplateList) DeepCopy() *ByoMachineTemplateList {
+	if in == nil {
+		return nil
+	}
+	out := new(ByoMachineTemplateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ByoMachineTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ByoMachineTemplateResource) DeepCopyInto(out *ByoMachineTemplateResource) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoMachineTemplateResource.
+func (in *ByoMachineTemplateResource) DeepCopy() *ByoMachineTemplateResource {
+	if in == nil {
+		return nil
+	}
+	out := new(ByoMachineTemplateResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ByoMachineTemplateSpec) DeepCopyInto(out *ByoMachineTemplateSpec) {
+	*out = *in
+	in.Template.DeepCopyInto(&out.Template)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoMachineTemplateSpec.
+func (in *ByoMachineTemplateSpec) DeepCopy() *ByoMachineTemplateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ByoMachineTemplateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ByoMachineTemplateStatus) DeepCopyInto(out *ByoMachineTemplateStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoMachineTemplateStatus.
+func (in *ByoMachineTemplateStatus) DeepCopy() *ByoMachineTemplateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ByoMachineTemplateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CPUManagementPolicy) DeepCopyInto(out *CPUManagementPolicy) {
+	*out = *in
+	if in.ReservedCPUsPerNUMANode != nil {
+		in, out := &in.ReservedCPUsPerNUMANode, &out.ReservedCPUsPerNUMANode
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CPUManagementPolicy.
+func (in *CPUManagementPolicy) DeepCopy() *CPUManagementPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(CPUManagementPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CPUTopology) DeepCopyInto(out *CPUTopology) {
+	*out = *in
+	if in.NUMANodes != nil {
+		in, out := &in.NUMANodes, &out.NUMANodes
+		*out = make([]NUMANode, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CPUTopology.
+func (in *CPUTopology) DeepCopy() *CPUTopology {
+	if in == nil {
+		return nil
+	}
+	out := new(CPUTopology)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogBundle) DeepCopyInto(out *CatalogBundle) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogBundle.
+func (in *CatalogBundle) DeepCopy() *CatalogBundle {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogBundle)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComponentPolicy) DeepCopyInto(out *ComponentPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComponentPolicy.
+func (in *ComponentPolicy) DeepCopy() *ComponentPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ComponentPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComponentsPolicy) DeepCopyInto(out *ComponentsPolicy) {
+	*out = *in
+	if in.Containerd != nil {
+		in, out := &in.Containerd, &out.Containerd
+		*out = new(ComponentPolicy)
+		**out = **in
+	}
+	if in.CRITools != nil {
+		in, out := &in.CRITools, &out.CRITools
+		*out = new(ComponentPolicy)
+		**out = **in
+	}
+	if in.Kubernetes != nil {
+		in, out := &in.Kubernetes, &out.Kubernetes
+		*out = new(ComponentPolicy)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComponentsPolicy.
+func (in *ComponentsPolicy) DeepCopy() *ComponentsPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ComponentsPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContainerdConfigPatch) DeepCopyInto(out *ContainerdConfigPatch) {
+	*out = *in
+	if in.RegistryMirrors != nil {
+		in, out := &in.RegistryMirrors, &out.RegistryMirrors
+		*out = make(map[string][]string, len(*in))
+		for key, val := range *in {
+			var outVal []string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make([]string, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.InsecureRegistries != nil {
+		in, out := &in.InsecureRegistries, &out.InsecureRegistries
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Proxy != nil {
+		in, out := &in.Proxy, &out.Proxy
+		*out = new(ContainerdProxy)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerdConfigPatch.
+func (in *ContainerdConfigPatch) DeepCopy() *ContainerdConfigPatch {
+	if in == nil {
+		return nil
+	}
+	out := new(ContainerdConfigPatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContainerdProxy) DeepCopyInto(out *ContainerdProxy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerdProxy.
+func (in *ContainerdProxy) DeepCopy() *ContainerdProxy {
+	if in == nil {
+		return nil
+	}
+	out := new(ContainerdProxy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeviceRequirements) DeepCopyInto(out *DeviceRequirements) {
+	*out = *in
+	if in.GPUs != nil {
+		in, out := &in.GPUs, &out.GPUs
+		*out = new(GPURequirement)
+		**out = **in
+	}
+	if in.HugePages != nil {
+		in, out := &in.HugePages, &out.HugePages
+		*out = make([]HugePagesRequirement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SRIOVVirtualFunctions != nil {
+		in, out := &in.SRIOVVirtualFunctions, &out.SRIOVVirtualFunctions
+		*out = new(int32)
+		**out = **in
+	}
+	if in.CPUs != nil {
+		in, out := &in.CPUs, &out.CPUs
+		*out = new(int32)
+		**out = **in
+	}
+	if in.NUMANodes != nil {
+		in, out := &in.NUMANodes, &out.NUMANodes
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeviceRequirements.
+func (in *DeviceRequirements) DeepCopy() *DeviceRequirements {
+	if in == nil {
+		return nil
+	}
+	out := new(DeviceRequirements)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ErrorBackoff) DeepCopyInto(out *ErrorBackoff) {
+	*out = *in
+	if in.NextRetryTime != nil {
+		in, out := &in.NextRetryTime, &out.NextRetryTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ErrorBackoff.
+func (in *ErrorBackoff) DeepCopy() *ErrorBackoff {
+	if in == nil {
+		return nil
+	}
+	out := new(ErrorBackoff)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GPUInfo) DeepCopyInto(out *GPUInfo) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GPUInfo.
+func (in *GPUInfo) DeepCopy() *GPUInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(GPUInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GPURequirement) DeepCopyInto(out *GPURequirement) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GPURequirement.
+func (in *GPURequirement) DeepCopy() *GPURequirement {
+	if in == nil {
+		return nil
+	}
+	out := new(GPURequirement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostConfigurationPolicy) DeepCopyInto(out *HostConfigurationPolicy) {
+	*out = *in
+	if in.DisableSwap != nil {
+		in, out := &in.DisableSwap, &out.DisableSwap
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ConfigureSysctls != nil {
+		in, out := &in.ConfigureSysctls, &out.ConfigureSysctls
+		*out = new(bool)
+		**out = **in
+	}
+	if in.LoadKernelModules != nil {
+		in, out := &in.LoadKernelModules, &out.LoadKernelModules
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostConfigurationPolicy.
+func (in *HostConfigurationPolicy) DeepCopy() *HostConfigurationPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(HostConfigurationPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostInfo) DeepCopyInto(out *HostInfo) {
+	*out = *in
+	if in.GPUs != nil {
+		in, out := &in.GPUs, &out.GPUs
+		*out = make([]GPUInfo, len(*in))
+		copy(*out, *in)
+	}
+	if in.HugePages != nil {
+		in, out := &in.HugePages, &out.HugePages
+		*out = make([]HugePagesInfo, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SRIOVNetworkInterfaces != nil {
+		in, out := &in.SRIOVNetworkInterfaces, &out.SRIOVNetworkInterfaces
+		*out = make([]SRIOVNetworkInterface, len(*in))
+		copy(*out, *in)
+	}
+	if in.CPUTopology != nil {
+		in, out := &in.CPUTopology, &out.CPUTopology
+		*out = new(CPUTopology)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostInfo.
+func (in *HostInfo) DeepCopy() *HostInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(HostInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HugePagesInfo) DeepCopyInto(out *HugePagesInfo) {
+	*out = *in
+	out.PageSize = in.PageSize.DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HugePagesInfo.
+func (in *HugePagesInfo) DeepCopy() *HugePagesInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(HugePagesInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HugePagesRequirement) DeepCopyInto(out *HugePagesRequirement) {
+	*out = *in
+	out.PageSize = in.PageSize.DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HugePagesRequirement.
+func (in *HugePagesRequirement) DeepCopy() *HugePagesRequirement {
+	if in == nil {
+		return nil
+	}
+	out := new(HugePagesRequirement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstallationProgress) DeepCopyInto(out *InstallationProgress) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstallationProgress.
+func (in *InstallationProgress) DeepCopy() *InstallationProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(InstallationProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstallerAuditSummary) DeepCopyInto(out *InstallerAuditSummary) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstallerAuditSummary.
+func (in *InstallerAuditSummary) DeepCopy() *InstallerAuditSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(InstallerAuditSummary)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ByoMachineTemplateList) DeepCopyInto(out *ByoMachineTemplateList) {
+func (in *K8sBundleCatalog) DeepCopyInto(out *K8sBundleCatalog) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]ByoMachineTemplate, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoMachineTemplateList.
-func (in *ByoMachineTemplateList) DeepCopy() *ByoMachineTemplateList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new K8sBundleCatalog.
+func (in *K8sBundleCatalog) DeepCopy() *K8sBundleCatalog {
 	if in == nil {
 		return nil
 	}
-	out := new(ByoMachineTemplateList)
+	out := new(K8sBundleCatalog)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ByoMachineTemplateList) DeepCopyObject() runtime.Object {
+func (in *K8sBundleCatalog) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -511,63 +1521,84 @@ func (in *ByoMachineTemplateList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ByoMachineTemplateResource) DeepCopyInto(out *ByoMachineTemplateResource) {
+func (in *K8sBundleCatalogList) DeepCopyInto(out *K8sBundleCatalogList) {
 	*out = *in
-	in.Spec.DeepCopyInto(&out.Spec)
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]K8sBundleCatalog, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoMachineTemplateResource.
-func (in *ByoMachineTemplateResource) DeepCopy() *ByoMachineTemplateResource {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new K8sBundleCatalogList.
+func (in *K8sBundleCatalogList) DeepCopy() *K8sBundleCatalogList {
 	if in == nil {
 		return nil
 	}
-	out := new(ByoMachineTemplateResource)
+	out := new(K8sBundleCatalogList)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ByoMachineTemplateSpec) DeepCopyInto(out *ByoMachineTemplateSpec) {
-	*out = *in
-	in.Template.DeepCopyInto(&out.Template)
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoMachineTemplateSpec.
-func (in *ByoMachineTemplateSpec) DeepCopy() *ByoMachineTemplateSpec {
-	if in == nil {
-		return nil
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *K8sBundleCatalogList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
 	}
-	out := new(ByoMachineTemplateSpec)
-	in.DeepCopyInto(out)
-	return out
+	return nil
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ByoMachineTemplateStatus) DeepCopyInto(out *ByoMachineTemplateStatus) {
+func (in *K8sBundleCatalogSpec) DeepCopyInto(out *K8sBundleCatalogSpec) {
 	*out = *in
+	if in.RefreshInterval != nil {
+		in, out := &in.RefreshInterval, &out.RefreshInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ByoMachineTemplateStatus.
-func (in *ByoMachineTemplateStatus) DeepCopy() *ByoMachineTemplateStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new K8sBundleCatalogSpec.
+func (in *K8sBundleCatalogSpec) DeepCopy() *K8sBundleCatalogSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ByoMachineTemplateStatus)
+	out := new(K8sBundleCatalogSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HostInfo) DeepCopyInto(out *HostInfo) {
+func (in *K8sBundleCatalogStatus) DeepCopyInto(out *K8sBundleCatalogStatus) {
 	*out = *in
+	if in.Bundles != nil {
+		in, out := &in.Bundles, &out.Bundles
+		*out = make([]CatalogBundle, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastRefreshTime != nil {
+		in, out := &in.LastRefreshTime, &out.LastRefreshTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(apiv1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostInfo.
-func (in *HostInfo) DeepCopy() *HostInfo {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new K8sBundleCatalogStatus.
+func (in *K8sBundleCatalogStatus) DeepCopy() *K8sBundleCatalogStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(HostInfo)
+	out := new(K8sBundleCatalogStatus)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -577,7 +1608,7 @@ func (in *K8sInstallerConfig) DeepCopyInto(out *K8sInstallerConfig) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -634,6 +1665,36 @@ func (in *K8sInstallerConfigList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *K8sInstallerConfigSpec) DeepCopyInto(out *K8sInstallerConfigSpec) {
 	*out = *in
+	if in.HostConfiguration != nil {
+		in, out := &in.HostConfiguration, &out.HostConfiguration
+		*out = new(HostConfigurationPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Containerd != nil {
+		in, out := &in.Containerd, &out.Containerd
+		*out = new(ContainerdConfigPatch)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Components != nil {
+		in, out := &in.Components, &out.Components
+		*out = new(ComponentsPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CPUManagement != nil {
+		in, out := &in.CPUManagement, &out.CPUManagement
+		*out = new(CPUManagementPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DownloadRateLimit != nil {
+		in, out := &in.DownloadRateLimit, &out.DownloadRateLimit
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Rollout != nil {
+		in, out := &in.Rollout, &out.Rollout
+		*out = new(RolloutPolicy)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new K8sInstallerConfigSpec.
@@ -651,9 +1712,16 @@ func (in *K8sInstallerConfigStatus) DeepCopyInto(out *K8sInstallerConfigStatus)
 	*out = *in
 	if in.InstallationSecret != nil {
 		in, out := &in.InstallationSecret, &out.InstallationSecret
-		*out = new(v1.ObjectReference)
+		*out = new(corev1.ObjectReference)
 		**out = **in
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(apiv1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new K8sInstallerConfigStatus.
@@ -671,7 +1739,7 @@ func (in *K8sInstallerConfigTemplate) DeepCopyInto(out *K8sInstallerConfigTempla
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	out.Status = in.Status
 }
 
@@ -728,7 +1796,7 @@ func (in *K8sInstallerConfigTemplateList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *K8sInstallerConfigTemplateResource) DeepCopyInto(out *K8sInstallerConfigTemplateResource) {
 	*out = *in
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new K8sInstallerConfigTemplateResource.
@@ -744,7 +1812,7 @@ func (in *K8sInstallerConfigTemplateResource) DeepCopy() *K8sInstallerConfigTemp
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *K8sInstallerConfigTemplateSpec) DeepCopyInto(out *K8sInstallerConfigTemplateSpec) {
 	*out = *in
-	out.Template = in.Template
+	in.Template.DeepCopyInto(&out.Template)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new K8sInstallerConfigTemplateSpec.
@@ -772,6 +1840,133 @@ func (in *K8sInstallerConfigTemplateStatus) DeepCopy() *K8sInstallerConfigTempla
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeVIPSpec) DeepCopyInto(out *KubeVIPSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeVIPSpec.
+func (in *KubeVIPSpec) DeepCopy() *KubeVIPSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeVIPSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeletConfiguration) DeepCopyInto(out *KubeletConfiguration) {
+	*out = *in
+	if in.MaxPods != nil {
+		in, out := &in.MaxPods, &out.MaxPods
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SystemReserved != nil {
+		in, out := &in.SystemReserved, &out.SystemReserved
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.KubeReserved != nil {
+		in, out := &in.KubeReserved, &out.KubeReserved
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.EvictionHard != nil {
+		in, out := &in.EvictionHard, &out.EvictionHard
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.EvictionSoft != nil {
+		in, out := &in.EvictionSoft, &out.EvictionSoft
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.EvictionSoftGracePeriod != nil {
+		in, out := &in.EvictionSoftGracePeriod, &out.EvictionSoftGracePeriod
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeletConfiguration.
+func (in *KubeletConfiguration) DeepCopy() *KubeletConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeletConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeletSpec) DeepCopyInto(out *KubeletSpec) {
+	*out = *in
+	if in.Configuration != nil {
+		in, out := &in.Configuration, &out.Configuration
+		*out = new(KubeletConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExtraArgs != nil {
+		in, out := &in.ExtraArgs, &out.ExtraArgs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeletSpec.
+func (in *KubeletSpec) DeepCopy() *KubeletSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeletSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoadBalancerSpec) DeepCopyInto(out *LoadBalancerSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerSpec.
+func (in *LoadBalancerSpec) DeepCopy() *LoadBalancerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LoadBalancerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NUMANode) DeepCopyInto(out *NUMANode) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NUMANode.
+func (in *NUMANode) DeepCopy() *NUMANode {
+	if in == nil {
+		return nil
+	}
+	out := new(NUMANode)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetworkStatus) DeepCopyInto(out *NetworkStatus) {
 	*out = *in
@@ -780,6 +1975,16 @@ func (in *NetworkStatus) DeepCopyInto(out *NetworkStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.IPv4Addrs != nil {
+		in, out := &in.IPv4Addrs, &out.IPv4Addrs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IPv6Addrs != nil {
+		in, out := &in.IPv6Addrs, &out.IPv6Addrs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkStatus.
@@ -791,3 +1996,127 @@ func (in *NetworkStatus) DeepCopy() *NetworkStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeCondition) DeepCopyInto(out *NodeCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeCondition.
+func (in *NodeCondition) DeepCopy() *NodeCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeDrainSpec) DeepCopyInto(out *NodeDrainSpec) {
+	*out = *in
+	if in.GracePeriodSeconds != nil {
+		in, out := &in.GracePeriodSeconds, &out.GracePeriodSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeDrainSpec.
+func (in *NodeDrainSpec) DeepCopy() *NodeDrainSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeDrainSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeStatus) DeepCopyInto(out *NodeStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]NodeCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeStatus.
+func (in *NodeStatus) DeepCopy() *NodeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProvisioningTimeline) DeepCopyInto(out *ProvisioningTimeline) {
+	*out = *in
+	if in.BootstrapSecretReadyTime != nil {
+		in, out := &in.BootstrapSecretReadyTime, &out.BootstrapSecretReadyTime
+		*out = (*in).DeepCopy()
+	}
+	if in.HostSelectedTime != nil {
+		in, out := &in.HostSelectedTime, &out.HostSelectedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.K8sInstalledTime != nil {
+		in, out := &in.K8sInstalledTime, &out.K8sInstalledTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NodeJoinedTime != nil {
+		in, out := &in.NodeJoinedTime, &out.NodeJoinedTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProvisioningTimeline.
+func (in *ProvisioningTimeline) DeepCopy() *ProvisioningTimeline {
+	if in == nil {
+		return nil
+	}
+	out := new(ProvisioningTimeline)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutPolicy) DeepCopyInto(out *RolloutPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutPolicy.
+func (in *RolloutPolicy) DeepCopy() *RolloutPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SRIOVNetworkInterface) DeepCopyInto(out *SRIOVNetworkInterface) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SRIOVNetworkInterface.
+func (in *SRIOVNetworkInterface) DeepCopy() *SRIOVNetworkInterface {
+	if in == nil {
+		return nil
+	}
+	out := new(SRIOVNetworkInterface)
+	in.DeepCopyInto(out)
+	return out
+}
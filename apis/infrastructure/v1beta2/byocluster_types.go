@@ -0,0 +1,178 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1beta2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+// ByoClusterSpec defines the desired state of ByoCluster
+type ByoClusterSpec struct {
+	// ControlPlaneEndpoint represents the endpoint used to communicate with the control plane.
+	// +optional
+	ControlPlaneEndpoint APIEndpoint `json:"controlPlaneEndpoint"`
+
+	// BundleLookupBaseRegistry is the base Registry URL that is used for pulling byoh bundle images,
+	// if not set, the default will be set to https://projects.registry.vmware.com/cluster_api_provider_bringyourownhost
+	// +optional
+	BundleLookupBaseRegistry string `json:"bundleLookupBaseRegistry,omitempty"`
+
+	// BundleLookupTag is the tag of the BYOH bundle to be used
+	BundleLookupTag string `json:"bundleLookupTag,omitempty"`
+
+	// KubeVIP, when set, has kube-vip deployed as a static pod on the control plane hosts,
+	// serving the ControlPlaneEndpoint host as a virtual IP
+	// +optional
+	KubeVIP *KubeVIPSpec `json:"kubeVIP,omitempty"`
+
+	// LoadBalancer, when set, has the control plane machines reconciled as the backends of
+	// an external load balancer serving the ControlPlaneEndpoint
+	// +optional
+	LoadBalancer *LoadBalancerSpec `json:"loadBalancer,omitempty"`
+
+	// FailureDomainLabel is the label of the ByoHosts whose values are the failure domains of the
+	// cluster, e.g. a rack or site label. The failure domains are reported in the status, for the
+	// control plane and the MachineDeployments to spread their machines across them, and the
+	// ByoMachines are attached to the hosts of the failure domain of their Machine.
+	// +optional
+	FailureDomainLabel string `json:"failureDomainLabel,omitempty"`
+}
+
+// KubeVIPSpec configures the kube-vip static pods serving the control plane endpoint
+type KubeVIPSpec struct {
+	// Image is the kube-vip image, defaults to the kube-vip image of the v1beta1 API
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Interface is the network interface the virtual IP is advertised on,
+	// defaults to the default network interface of every control plane host
+	// +optional
+	Interface string `json:"interface,omitempty"`
+}
+
+// LoadBalancerType is the kind of external load balancer serving the control plane endpoint
+type LoadBalancerType string
+
+// LoadBalancerSpec configures the external load balancer serving the control plane endpoint
+type LoadBalancerSpec struct {
+	// Type is the kind of load balancer the configuration is generated for
+	// +kubebuilder:validation:Enum=HAProxy;NGINX
+	// +kubebuilder:default=HAProxy
+	// +optional
+	Type LoadBalancerType `json:"type,omitempty"`
+
+	// ConfigMapName is the ConfigMap, in the namespace of the ByoCluster, the load balancer
+	// configuration is written to. Defaults to "<byocluster name>-control-plane-lb"
+	// +optional
+	ConfigMapName string `json:"configMapName,omitempty"`
+
+	// BackendPort is the port the API servers of the control plane machines serve on,
+	// defaults to 6443
+	// +optional
+	BackendPort int32 `json:"backendPort,omitempty"`
+}
+
+// ByoClusterStatus defines the observed state of ByoCluster
+type ByoClusterStatus struct {
+	// Initialization provides the observations of the initialization of the ByoCluster.
+	// +optional
+	Initialization *ByoClusterInitializationStatus `json:"initialization,omitempty"`
+
+	// Conditions defines current service state of the ByoCluster.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +kubebuilder:validation:MaxItems=32
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// FailureDomains is a list of failure domain objects synced from the infrastructure provider.
+	// They are the values of the FailureDomainLabel of the ByoHosts the cluster can use.
+	// +optional
+	FailureDomains clusterv1.FailureDomains `json:"failureDomains,omitempty"`
+
+	// ReadyHosts is the number of ByoHosts attached to the cluster whose node is bootstrapped
+	// and which report no error
+	// +optional
+	ReadyHosts int32 `json:"readyHosts"`
+
+	// ProvisioningMachines is the number of ByoMachines of the cluster which are not ready yet
+	// +optional
+	ProvisioningMachines int32 `json:"provisioningMachines"`
+
+	// FailedMachines is the number of ByoMachines of the cluster which failed to provision,
+	// or whose ByoHost reports an error
+	// +optional
+	FailedMachines int32 `json:"failedMachines"`
+
+	// ObservedGeneration is the latest generation of the ByoCluster reconciled by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Deprecated groups the fields of the status which are only kept for the v1beta1 API.
+	// +optional
+	Deprecated *ByoClusterDeprecatedStatus `json:"deprecated,omitempty"`
+}
+
+// ByoClusterInitializationStatus provides the observations of the initialization of the ByoCluster
+type ByoClusterInitializationStatus struct {
+	// Provisioned is true when the infrastructure of the cluster is ready, e.g. its control plane endpoint.
+	// It is the "ready" field of the v1beta1 API.
+	// +optional
+	Provisioned *bool `json:"provisioned,omitempty"`
+}
+
+// ByoClusterDeprecatedStatus groups the fields of the ByoCluster status which are only kept for older API versions
+type ByoClusterDeprecatedStatus struct {
+	// V1Beta1 groups the fields of the status which are only kept for the v1beta1 API.
+	// +optional
+	V1Beta1 *ByoClusterV1Beta1DeprecatedStatus `json:"v1beta1,omitempty"`
+}
+
+// ByoClusterV1Beta1DeprecatedStatus groups the fields of the ByoCluster status which are only kept for the v1beta1 API
+type ByoClusterV1Beta1DeprecatedStatus struct {
+	// Conditions are the Cluster API conditions of the ByoCluster, with their severity.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+}
+
+// APIEndpoint represents a reachable Kubernetes API endpoint.
+type APIEndpoint struct {
+	// Host is the hostname on which the API server is serving.
+	Host string `json:"host"`
+
+	// Port is the port on which the API server is serving.
+	Port int32 `json:"port"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:path=byoclusters,scope=Namespaced,shortName=byoc
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Provisioned",type="boolean",JSONPath=".status.initialization.provisioned"
+//+kubebuilder:printcolumn:name="ReadyHosts",type="integer",JSONPath=".status.readyHosts"
+//+kubebuilder:printcolumn:name="Provisioning",type="integer",JSONPath=".status.provisioningMachines"
+//+kubebuilder:printcolumn:name="Failed",type="integer",JSONPath=".status.failedMachines"
+//+kubebuilder:printcolumn:name="FleetHealthy",type="string",JSONPath=".status.conditions[?(@.type=='FleetHealthy')].status",priority=1
+
+// ByoCluster is the Schema for the byoclusters API
+type ByoCluster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ByoClusterSpec   `json:"spec,omitempty"`
+	Status ByoClusterStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ByoClusterList contains a list of ByoCluster
+type ByoClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ByoCluster `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ByoCluster{}, &ByoClusterList{})
+}
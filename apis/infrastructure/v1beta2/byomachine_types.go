@@ -0,0 +1,449 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1beta2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+// ByoMachineSpec defines the desired state of ByoMachine
+type ByoMachineSpec struct {
+	// Label Selector to choose the byohost
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	ProviderID string `json:"providerID,omitempty"`
+
+	// InstallerRef is an optional reference to a installer-specific resource that holds
+	// the details of InstallationSecret to be used to install BYOH Bundle. It is set from the
+	// installer-config-template annotation of the Machine, when the Machine has one.
+	// +optional
+	InstallerRef *corev1.ObjectReference `json:"installerRef,omitempty"`
+
+	// AntiAffinity spreads the ByoMachines of the same control plane or
+	// MachineDeployment across ByoHosts with different values of a topology label.
+	// +optional
+	AntiAffinity *AntiAffinity `json:"antiAffinity,omitempty"`
+
+	// HostRef pins the ByoMachine to a specific ByoHost, which can be reserved.
+	// The namespace defaults to the one of the ByoMachine.
+	// +optional
+	HostRef *corev1.ObjectReference `json:"hostRef,omitempty"`
+
+	// ClaimRef attaches the ByoMachine to the ByoHost bound to the ByoHostClaim of the
+	// namespace of the ByoMachine, instead of selecting a host.
+	// +optional
+	ClaimRef *corev1.LocalObjectReference `json:"claimRef,omitempty"`
+
+	// Kubelet customizes the kubelet of the host, on top of the kubelet configuration of the cluster.
+	// It is applied by the host agent before the host joins the cluster.
+	// +optional
+	Kubelet *KubeletSpec `json:"kubelet,omitempty"`
+
+	// Distribution is the Kubernetes distribution installed and bootstrapped on the host,
+	// it has to match the bootstrap provider of the machine. Defaults to kubeadm.
+	// +kubebuilder:validation:Enum=kubeadm;rke2
+	// +optional
+	Distribution KubernetesDistribution `json:"distribution,omitempty"`
+
+	// NodeDrain configures the drain of the node when the ByoMachine is deleted, which is
+	// cordoned and has its pods deleted before the host is reset.
+	// +optional
+	NodeDrain *NodeDrainSpec `json:"nodeDrain,omitempty"`
+
+	// Devices restricts the selection of the ByoMachine to the ByoHosts reporting the devices,
+	// e.g. GPUs, on top of the selector.
+	// +optional
+	Devices *DeviceRequirements `json:"devices,omitempty"`
+
+	// NodeLabels are the labels the kubelet registers the Node of the host with. The kubelet can only
+	// set the labels of the kubernetes.io and k8s.io namespaces allowed by the NodeRestriction admission
+	// plugin, i.e. the kubelet.kubernetes.io and node.kubernetes.io ones, and the topology zone and region.
+	// +optional
+	NodeLabels map[string]string `json:"nodeLabels,omitempty"`
+
+	// NodeTaints are the taints the kubelet registers the Node of the host with.
+	// +optional
+	NodeTaints []corev1.Taint `json:"nodeTaints,omitempty"`
+}
+
+// DeviceRequirements are the devices a ByoHost needs to report to be selected by a ByoMachine
+type DeviceRequirements struct {
+	// GPUs is the minimum number of GPUs of the host, of a vendor and model when set.
+	// +optional
+	GPUs *GPURequirement `json:"gpus,omitempty"`
+
+	// HugePages are the minimum numbers of huge pages preallocated on the host, for each page size.
+	// +listType=map
+	// +listMapKey=pageSize
+	// +optional
+	HugePages []HugePagesRequirement `json:"hugePages,omitempty"`
+
+	// SRIOVVirtualFunctions is the minimum number of virtual functions supported by one of the
+	// network interfaces of the host capable of SR-IOV.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	SRIOVVirtualFunctions *int32 `json:"sriovVirtualFunctions,omitempty"`
+
+	// CPUs is the minimum number of logical CPUs of the host.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	CPUs *int32 `json:"cpus,omitempty"`
+
+	// NUMANodes is the minimum number of NUMA nodes of the host.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	NUMANodes *int32 `json:"numaNodes,omitempty"`
+}
+
+// GPURequirement is the minimum number of GPUs of a host
+type GPURequirement struct {
+	// Count is the minimum number of GPUs.
+	// +kubebuilder:validation:Minimum=1
+	Count int32 `json:"count"`
+
+	// Vendor restricts the GPUs to a vendor, matched against the vendor ID or name, e.g. 10de or NVIDIA.
+	// +optional
+	Vendor string `json:"vendor,omitempty"`
+
+	// Model restricts the GPUs to a model, matched against the device ID, or contained in
+	// the model name, e.g. 20b5 or A100.
+	// +optional
+	Model string `json:"model,omitempty"`
+}
+
+// HugePagesRequirement is the minimum number of huge pages of a page size
+type HugePagesRequirement struct {
+	// PageSize is the size of the huge pages, e.g. 2Mi or 1Gi.
+	PageSize resource.Quantity `json:"pageSize"`
+
+	// Count is the minimum number of huge pages of the size.
+	// +kubebuilder:validation:Minimum=1
+	Count int64 `json:"count"`
+}
+
+// NodeDrainSpec configures the drain of the node of a deleted ByoMachine
+type NodeDrainSpec struct {
+	// GracePeriodSeconds overrides the termination grace period of the drained pods.
+	// Defaults to the grace period of each pod.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	GracePeriodSeconds *int64 `json:"gracePeriodSeconds,omitempty"`
+
+	// Timeout is how long the pods are waited for before the host is reset anyway.
+	// Defaults to 5m, 0s skips the drain.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// KubernetesDistribution is a Kubernetes distribution the host agent installs and bootstraps
+type KubernetesDistribution string
+
+// KubeletSpec customizes the kubelet of a host, e.g. with the reserved resources of a host class
+type KubeletSpec struct {
+	// Configuration overrides the fields of the kubelet configuration of the cluster
+	// +optional
+	Configuration *KubeletConfiguration `json:"configuration,omitempty"`
+
+	// ExtraArgs are the extra flags of the kubelet, without the leading dashes, e.g. {"max-pods": "200"}.
+	// They take precedence over Configuration.
+	// +optional
+	ExtraArgs map[string]string `json:"extraArgs,omitempty"`
+}
+
+// KubeletConfiguration are the fields of the kubelet configuration which are set per host
+type KubeletConfiguration struct {
+	// MaxPods is the number of pods the kubelet can run
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxPods *int32 `json:"maxPods,omitempty"`
+
+	// SystemReserved are the resources reserved for the system daemons, e.g. {"cpu": "500m", "memory": "1Gi"}
+	// +optional
+	SystemReserved map[string]string `json:"systemReserved,omitempty"`
+
+	// KubeReserved are the resources reserved for the kubernetes daemons, e.g. {"cpu": "500m", "memory": "1Gi"}
+	// +optional
+	KubeReserved map[string]string `json:"kubeReserved,omitempty"`
+
+	// EvictionHard are the thresholds evicting the pods right away, e.g. {"memory.available": "500Mi"}
+	// +optional
+	EvictionHard map[string]string `json:"evictionHard,omitempty"`
+
+	// EvictionSoft are the thresholds evicting the pods after their grace period, e.g. {"memory.available": "1Gi"}
+	// +optional
+	EvictionSoft map[string]string `json:"evictionSoft,omitempty"`
+
+	// EvictionSoftGracePeriod are the grace periods of the soft eviction thresholds, e.g. {"memory.available": "1m30s"}
+	// +optional
+	EvictionSoftGracePeriod map[string]string `json:"evictionSoftGracePeriod,omitempty"`
+}
+
+// AntiAffinityPolicy defines how strictly the anti-affinity is enforced
+type AntiAffinityPolicy string
+
+// AntiAffinity defines the topology the ByoMachines are spread across
+type AntiAffinity struct {
+	// TopologyKey is the ByoHost label whose values define the topology
+	// domains, e.g. topology.byoh/rack
+	TopologyKey string `json:"topologyKey"`
+
+	// Policy is either Required or Preferred
+	// +kubebuilder:validation:Enum=Required;Preferred
+	// +kubebuilder:default=Preferred
+	// +optional
+	Policy AntiAffinityPolicy `json:"policy,omitempty"`
+}
+
+// ByoMachineStatus defines the observed state of ByoMachine
+type ByoMachineStatus struct {
+	// HostInfo has the attached host platform details.
+	// +optional
+	HostInfo HostInfo `json:"hostinfo,omitempty"`
+
+	// Initialization provides the observations of the initialization of the ByoMachine.
+	// +optional
+	Initialization *ByoMachineInitializationStatus `json:"initialization,omitempty"`
+
+	// Conditions defines current service state of the ByoMachine.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +kubebuilder:validation:MaxItems=32
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Timeline records when the ByoMachine reached each phase of its provisioning.
+	// +optional
+	Timeline ProvisioningTimeline `json:"timeline,omitempty"`
+
+	// ProvisioningDuration is the time from the creation of the ByoMachine to its node joining the cluster.
+	// +optional
+	ProvisioningDuration *metav1.Duration `json:"provisioningDuration,omitempty"`
+
+	// FailureDomain is the failure domain of the attached ByoHost, the value of its label
+	// set as the FailureDomainLabel of the ByoCluster.
+	// +optional
+	FailureDomain string `json:"failureDomain,omitempty"`
+
+	// Node mirrors the health and the kubelet version of the node of the attached ByoHost
+	// in the workload cluster.
+	// +optional
+	Node *NodeStatus `json:"node,omitempty"`
+
+	// ObservedGeneration is the latest generation of the ByoMachine reconciled by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Deprecated groups the fields of the status which are only kept for the v1beta1 API.
+	// +optional
+	Deprecated *ByoMachineDeprecatedStatus `json:"deprecated,omitempty"`
+}
+
+// ByoMachineInitializationStatus provides the observations of the initialization of the ByoMachine
+type ByoMachineInitializationStatus struct {
+	// Provisioned is true when the node of the attached ByoHost joined the cluster.
+	// It is the "ready" field of the v1beta1 API.
+	// +optional
+	Provisioned *bool `json:"provisioned,omitempty"`
+}
+
+// ByoMachineDeprecatedStatus groups the fields of the ByoMachine status which are only kept for older API versions
+type ByoMachineDeprecatedStatus struct {
+	// V1Beta1 groups the fields of the status which are only kept for the v1beta1 API.
+	// +optional
+	V1Beta1 *ByoMachineV1Beta1DeprecatedStatus `json:"v1beta1,omitempty"`
+}
+
+// ByoMachineV1Beta1DeprecatedStatus groups the fields of the ByoMachine status which are only kept for the v1beta1 API
+type ByoMachineV1Beta1DeprecatedStatus struct {
+	// Conditions are the Cluster API conditions of the ByoMachine, with their severity.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+}
+
+// HostInfo is a set of details about the host platform.
+type HostInfo struct {
+	// The Operating System reported by the host.
+	OSName string `json:"osname,omitempty"`
+
+	// OS Image reported by the host.
+	OSImage string `json:"osimage,omitempty"`
+
+	// The Architecture reported by the host.
+	Architecture string `json:"architecture,omitempty"`
+
+	// GPUs are the GPUs found by a PCI scan of the host, grouped by vendor and model.
+	// The display controllers of the BMCs are left out.
+	// +optional
+	GPUs []GPUInfo `json:"gpus,omitempty"`
+
+	// HugePages are the huge pages preallocated on the host, for each page size.
+	// +optional
+	HugePages []HugePagesInfo `json:"hugePages,omitempty"`
+
+	// SRIOVNetworkInterfaces are the network interfaces of the host capable of SR-IOV.
+	// +optional
+	SRIOVNetworkInterfaces []SRIOVNetworkInterface `json:"sriovNetworkInterfaces,omitempty"`
+
+	// CPUTopology is the topology of the CPUs of the host, with its NUMA nodes.
+	// +optional
+	CPUTopology *CPUTopology `json:"cpuTopology,omitempty"`
+}
+
+// GPUInfo is a group of identical GPUs of a host
+type GPUInfo struct {
+	// VendorID is the PCI vendor ID of the GPUs, e.g. 10de.
+	VendorID string `json:"vendorID"`
+
+	// DeviceID is the PCI device ID of the GPUs, e.g. 20b5.
+	DeviceID string `json:"deviceID"`
+
+	// Vendor is the name of the vendor, e.g. NVIDIA, or the vendor ID when it is unknown.
+	// +optional
+	Vendor string `json:"vendor,omitempty"`
+
+	// Model is the name of the model from the PCI ID database of the host, or the device ID
+	// when it is unknown.
+	// +optional
+	Model string `json:"model,omitempty"`
+
+	// Count is the number of GPUs of the vendor and model.
+	Count int32 `json:"count"`
+}
+
+// HugePagesInfo is the number of huge pages of a page size
+type HugePagesInfo struct {
+	// PageSize is the size of the huge pages, e.g. 2Mi or 1Gi.
+	PageSize resource.Quantity `json:"pageSize"`
+
+	// Count is the number of huge pages of the size.
+	Count int64 `json:"count"`
+}
+
+// SRIOVNetworkInterface is a network interface capable of SR-IOV
+type SRIOVNetworkInterface struct {
+	// Name is the name of the network interface.
+	Name string `json:"name"`
+
+	// TotalVFs is the number of virtual functions the network interface supports.
+	TotalVFs int32 `json:"totalVFs"`
+}
+
+// CPUTopology is the topology of the CPUs of a host
+type CPUTopology struct {
+	// Sockets is the number of CPU sockets.
+	Sockets int32 `json:"sockets"`
+
+	// Cores is the number of physical cores, across the sockets.
+	Cores int32 `json:"cores"`
+
+	// CPUs is the number of logical CPUs, i.e. the hardware threads of the cores.
+	CPUs int32 `json:"cpus"`
+
+	// NUMANodes are the NUMA nodes of the host, with their CPUs.
+	// +optional
+	NUMANodes []NUMANode `json:"numaNodes,omitempty"`
+}
+
+// NUMANode is a NUMA node of a host
+type NUMANode struct {
+	// ID is the number of the node.
+	ID int32 `json:"id"`
+
+	// CPUs is the list of the logical CPUs of the node, in the format of the kernel, e.g. 0-15,32-47.
+	CPUs string `json:"cpus"`
+}
+
+// ProvisioningTimeline records when the phases of the provisioning of a ByoMachine were reached.
+// A time is only recorded once, the first time the phase is reached.
+type ProvisioningTimeline struct {
+	// BootstrapSecretReadyTime is when the bootstrap provider provided the bootstrap data secret.
+	// +optional
+	BootstrapSecretReadyTime *metav1.Time `json:"bootstrapSecretReadyTime,omitempty"`
+
+	// HostSelectedTime is when a ByoHost was attached to the ByoMachine.
+	// +optional
+	HostSelectedTime *metav1.Time `json:"hostSelectedTime,omitempty"`
+
+	// K8sInstalledTime is when the host agent installed the k8s components. It is not recorded
+	// when the installation is skipped, or left to an installer.
+	// +optional
+	K8sInstalledTime *metav1.Time `json:"k8sInstalledTime,omitempty"`
+
+	// NodeJoinedTime is when the node of the host joined the cluster and got the provider ID.
+	// +optional
+	NodeJoinedTime *metav1.Time `json:"nodeJoinedTime,omitempty"`
+}
+
+// NodeStatus mirrors the node of a host in the workload cluster.
+type NodeStatus struct {
+	// Name is the name of the node.
+	Name string `json:"name"`
+
+	// KubeletVersion is the version of the kubelet reported by the node.
+	// +optional
+	KubeletVersion string `json:"kubeletVersion,omitempty"`
+
+	// Ready is the status of the Ready condition of the node, True, False or Unknown.
+	// +optional
+	Ready corev1.ConditionStatus `json:"ready,omitempty"`
+
+	// Conditions are the Ready and the pressure conditions of the node.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []NodeCondition `json:"conditions,omitempty"`
+}
+
+// NodeCondition is a condition of a node, without its heartbeat, so that it only changes
+// when the condition does.
+type NodeCondition struct {
+	// Type is the type of the condition, e.g. Ready or DiskPressure.
+	Type corev1.NodeConditionType `json:"type"`
+
+	// Status is the status of the condition, True, False or Unknown.
+	Status corev1.ConditionStatus `json:"status"`
+
+	// Reason is the reason of the last transition of the condition.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Message is the human readable details of the last transition of the condition.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// LastTransitionTime is when the condition last changed its status.
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:path=byomachines,scope=Namespaced,shortName=byom
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="NodeReady",type="string",JSONPath=`.status.node.ready`
+//+kubebuilder:printcolumn:name="Kubelet",type="string",JSONPath=`.status.node.kubeletVersion`
+
+// ByoMachine is the Schema for the byomachines API
+type ByoMachine struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ByoMachineSpec   `json:"spec,omitempty"`
+	Status ByoMachineStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ByoMachineList contains a list of ByoMachine
+type ByoMachineList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ByoMachine `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ByoMachine{}, &ByoMachineList{})
+}
@@ -0,0 +1,163 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1beta2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apiconversion "k8s.io/apimachinery/pkg/conversion"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+)
+
+// NoReasonReported is the reason of the conditions converted from v1beta1 without a reason,
+// the reason of the standard conditions is required
+const NoReasonReported = "NoReasonReported"
+
+// ConvertTo converts this ByoCluster to the Hub version (v1beta1).
+func (src *ByoCluster) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*infrav1.ByoCluster)
+	return Convert_v1beta2_ByoCluster_To_v1beta1_ByoCluster(src, dst, nil)
+}
+
+// ConvertFrom converts from the Hub version (v1beta1) to this version.
+func (dst *ByoCluster) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*infrav1.ByoCluster)
+	return Convert_v1beta1_ByoCluster_To_v1beta2_ByoCluster(src, dst, nil)
+}
+
+// ConvertTo converts this ByoClusterList to the Hub version (v1beta1).
+func (src *ByoClusterList) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*infrav1.ByoClusterList)
+	return Convert_v1beta2_ByoClusterList_To_v1beta1_ByoClusterList(src, dst, nil)
+}
+
+// ConvertFrom converts from the Hub version (v1beta1) to this version.
+func (dst *ByoClusterList) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*infrav1.ByoClusterList)
+	return Convert_v1beta1_ByoClusterList_To_v1beta2_ByoClusterList(src, dst, nil)
+}
+
+// ConvertTo converts this ByoMachine to the Hub version (v1beta1).
+func (src *ByoMachine) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*infrav1.ByoMachine)
+	return Convert_v1beta2_ByoMachine_To_v1beta1_ByoMachine(src, dst, nil)
+}
+
+// ConvertFrom converts from the Hub version (v1beta1) to this version.
+func (dst *ByoMachine) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*infrav1.ByoMachine)
+	return Convert_v1beta1_ByoMachine_To_v1beta2_ByoMachine(src, dst, nil)
+}
+
+// ConvertTo converts this ByoMachineList to the Hub version (v1beta1).
+func (src *ByoMachineList) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*infrav1.ByoMachineList)
+	return Convert_v1beta2_ByoMachineList_To_v1beta1_ByoMachineList(src, dst, nil)
+}
+
+// ConvertFrom converts from the Hub version (v1beta1) to this version.
+func (dst *ByoMachineList) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*infrav1.ByoMachineList)
+	return Convert_v1beta1_ByoMachineList_To_v1beta2_ByoMachineList(src, dst, nil)
+}
+
+// Convert_v1beta1_ByoClusterStatus_To_v1beta2_ByoClusterStatus converts the ready field to the initialization
+// of the ByoCluster, and keeps the v1beta1 conditions along with the standard ones.
+func Convert_v1beta1_ByoClusterStatus_To_v1beta2_ByoClusterStatus(in *infrav1.ByoClusterStatus, out *ByoClusterStatus, s apiconversion.Scope) error { // nolint: stylecheck
+	if err := autoConvert_v1beta1_ByoClusterStatus_To_v1beta2_ByoClusterStatus(in, out, s); err != nil {
+		return err
+	}
+	if in.Ready {
+		out.Initialization = &ByoClusterInitializationStatus{Provisioned: pointer.Bool(true)}
+	}
+	setObservedGeneration(out.Conditions, in.ObservedGeneration)
+	if in.Conditions != nil {
+		out.Deprecated = &ByoClusterDeprecatedStatus{V1Beta1: &ByoClusterV1Beta1DeprecatedStatus{Conditions: in.Conditions.DeepCopy()}}
+	}
+	return nil
+}
+
+// Convert_v1beta2_ByoClusterStatus_To_v1beta1_ByoClusterStatus converts the initialization of the ByoCluster
+// to the ready field, and restores the v1beta1 conditions when they were kept.
+func Convert_v1beta2_ByoClusterStatus_To_v1beta1_ByoClusterStatus(in *ByoClusterStatus, out *infrav1.ByoClusterStatus, s apiconversion.Scope) error { // nolint: stylecheck
+	if err := autoConvert_v1beta2_ByoClusterStatus_To_v1beta1_ByoClusterStatus(in, out, s); err != nil {
+		return err
+	}
+	out.Ready = in.Initialization != nil && pointer.BoolDeref(in.Initialization.Provisioned, false)
+	if in.Deprecated != nil && in.Deprecated.V1Beta1 != nil {
+		out.Conditions = in.Deprecated.V1Beta1.Conditions.DeepCopy()
+	}
+	return nil
+}
+
+// Convert_v1beta1_ByoMachineStatus_To_v1beta2_ByoMachineStatus converts the ready field to the initialization
+// of the ByoMachine, and keeps the v1beta1 conditions along with the standard ones.
+func Convert_v1beta1_ByoMachineStatus_To_v1beta2_ByoMachineStatus(in *infrav1.ByoMachineStatus, out *ByoMachineStatus, s apiconversion.Scope) error { // nolint: stylecheck
+	if err := autoConvert_v1beta1_ByoMachineStatus_To_v1beta2_ByoMachineStatus(in, out, s); err != nil {
+		return err
+	}
+	if in.Ready {
+		out.Initialization = &ByoMachineInitializationStatus{Provisioned: pointer.Bool(true)}
+	}
+	setObservedGeneration(out.Conditions, in.ObservedGeneration)
+	if in.Conditions != nil {
+		out.Deprecated = &ByoMachineDeprecatedStatus{V1Beta1: &ByoMachineV1Beta1DeprecatedStatus{Conditions: in.Conditions.DeepCopy()}}
+	}
+	return nil
+}
+
+// Convert_v1beta2_ByoMachineStatus_To_v1beta1_ByoMachineStatus converts the initialization of the ByoMachine
+// to the ready field, and restores the v1beta1 conditions when they were kept.
+func Convert_v1beta2_ByoMachineStatus_To_v1beta1_ByoMachineStatus(in *ByoMachineStatus, out *infrav1.ByoMachineStatus, s apiconversion.Scope) error { // nolint: stylecheck
+	if err := autoConvert_v1beta2_ByoMachineStatus_To_v1beta1_ByoMachineStatus(in, out, s); err != nil {
+		return err
+	}
+	out.Ready = in.Initialization != nil && pointer.BoolDeref(in.Initialization.Provisioned, false)
+	if in.Deprecated != nil && in.Deprecated.V1Beta1 != nil {
+		out.Conditions = in.Deprecated.V1Beta1.Conditions.DeepCopy()
+	}
+	return nil
+}
+
+// Convert_v1beta1_Condition_To_v1_Condition converts a Cluster API condition to a standard condition,
+// dropping its severity.
+func Convert_v1beta1_Condition_To_v1_Condition(in *clusterv1.Condition, out *metav1.Condition, s apiconversion.Scope) error { // nolint: stylecheck
+	out.Type = string(in.Type)
+	out.Status = metav1.ConditionStatus(in.Status)
+	out.LastTransitionTime = in.LastTransitionTime
+	out.Reason = in.Reason
+	if out.Reason == "" {
+		out.Reason = NoReasonReported
+	}
+	out.Message = in.Message
+	return nil
+}
+
+// Convert_v1_Condition_To_v1beta1_Condition converts a standard condition to a Cluster API condition.
+// The severity of the false conditions is not kept by the standard conditions, it defaults to Info.
+func Convert_v1_Condition_To_v1beta1_Condition(in *metav1.Condition, out *clusterv1.Condition, s apiconversion.Scope) error { // nolint: stylecheck
+	out.Type = clusterv1.ConditionType(in.Type)
+	out.Status = corev1.ConditionStatus(in.Status)
+	out.LastTransitionTime = in.LastTransitionTime
+	out.Reason = in.Reason
+	if out.Reason == NoReasonReported {
+		out.Reason = ""
+	}
+	out.Message = in.Message
+	if in.Status == metav1.ConditionFalse {
+		out.Severity = clusterv1.ConditionSeverityInfo
+	}
+	return nil
+}
+
+// setObservedGeneration sets the generation the v1beta1 conditions were observed at, the one of the status
+func setObservedGeneration(conditions []metav1.Condition, generation int64) {
+	for i := range conditions {
+		conditions[i].ObservedGeneration = generation
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1beta2_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta2"
+)
+
+var _ = Describe("Conversion", func() {
+	var (
+		now        metav1.Time
+		conditions clusterv1.Conditions
+	)
+
+	BeforeEach(func() {
+		now = metav1.NewTime(metav1.Now().Rfc3339Copy().Time)
+		conditions = clusterv1.Conditions{
+			{Type: clusterv1.ReadyCondition, Status: corev1.ConditionFalse, Severity: clusterv1.ConditionSeverityWarning,
+				Reason: infrav1.WaitingForBootstrapDataSecretReason, LastTransitionTime: now},
+			{Type: infrav1.BYOHostReady, Status: corev1.ConditionTrue, LastTransitionTime: now},
+		}
+	})
+
+	Context("ByoMachine", func() {
+		var hub *infrav1.ByoMachine
+
+		BeforeEach(func() {
+			hub = &infrav1.ByoMachine{
+				ObjectMeta: metav1.ObjectMeta{Name: "byomachine", Namespace: "default", Generation: 3},
+				Spec: infrav1.ByoMachineSpec{
+					Selector:     &metav1.LabelSelector{MatchLabels: map[string]string{"site": "apac"}},
+					ProviderID:   "byoh://host1/abcdef",
+					AntiAffinity: &infrav1.AntiAffinity{TopologyKey: "topology.byoh/rack", Policy: infrav1.AntiAffinityPolicyRequired},
+					Kubelet:      &infrav1.KubeletSpec{ExtraArgs: map[string]string{"max-pods": "200"}},
+					Distribution: infrav1.KubernetesDistributionRKE2,
+				},
+				Status: infrav1.ByoMachineStatus{
+					Ready:              true,
+					Conditions:         conditions,
+					HostInfo:           infrav1.HostInfo{OSName: "linux", Architecture: "amd64"},
+					Timeline:           infrav1.ProvisioningTimeline{HostSelectedTime: &now},
+					FailureDomain:      "rack-1",
+					ObservedGeneration: 3,
+				},
+			}
+		})
+
+		It("should convert the ready field to the initialization", func() {
+			spoke := &v1beta2.ByoMachine{}
+			Expect(spoke.ConvertFrom(hub)).To(Succeed())
+			Expect(spoke.Status.Initialization).NotTo(BeNil())
+			Expect(spoke.Status.Initialization.Provisioned).To(Equal(pointer.Bool(true)))
+
+			hub.Status.Ready = false
+			spoke = &v1beta2.ByoMachine{}
+			Expect(spoke.ConvertFrom(hub)).To(Succeed())
+			Expect(spoke.Status.Initialization).To(BeNil())
+		})
+
+		It("should convert the conditions to standard conditions with the observed generation", func() {
+			spoke := &v1beta2.ByoMachine{}
+			Expect(spoke.ConvertFrom(hub)).To(Succeed())
+			Expect(spoke.Status.Conditions).To(Equal([]metav1.Condition{
+				{Type: string(clusterv1.ReadyCondition), Status: metav1.ConditionFalse, ObservedGeneration: 3,
+					Reason: infrav1.WaitingForBootstrapDataSecretReason, LastTransitionTime: now},
+				{Type: string(infrav1.BYOHostReady), Status: metav1.ConditionTrue, ObservedGeneration: 3,
+					Reason: v1beta2.NoReasonReported, LastTransitionTime: now},
+			}))
+			Expect(spoke.Status.Deprecated.V1Beta1.Conditions).To(Equal(conditions))
+		})
+
+		It("should convert a ByoMachine to v1beta2 and back without loss", func() {
+			spoke := &v1beta2.ByoMachine{}
+			Expect(spoke.ConvertFrom(hub)).To(Succeed())
+			Expect(spoke.Spec.Selector).To(Equal(hub.Spec.Selector))
+			Expect(spoke.Status.FailureDomain).To(Equal("rack-1"))
+
+			restored := &infrav1.ByoMachine{}
+			Expect(spoke.ConvertTo(restored)).To(Succeed())
+			Expect(restored).To(Equal(hub))
+		})
+
+		It("should convert the standard conditions of a v1beta2 ByoMachine without v1beta1 conditions", func() {
+			spoke := &v1beta2.ByoMachine{
+				Status: v1beta2.ByoMachineStatus{
+					Conditions: []metav1.Condition{
+						{Type: string(clusterv1.ReadyCondition), Status: metav1.ConditionFalse,
+							Reason: infrav1.WaitingForBootstrapDataSecretReason, LastTransitionTime: now},
+						{Type: string(infrav1.BYOHostReady), Status: metav1.ConditionTrue,
+							Reason: v1beta2.NoReasonReported, LastTransitionTime: now},
+					},
+				},
+			}
+
+			restored := &infrav1.ByoMachine{}
+			Expect(spoke.ConvertTo(restored)).To(Succeed())
+			Expect(restored.Status.Ready).To(BeFalse())
+			Expect(restored.Status.Conditions).To(Equal(clusterv1.Conditions{
+				{Type: clusterv1.ReadyCondition, Status: corev1.ConditionFalse, Severity: clusterv1.ConditionSeverityInfo,
+					Reason: infrav1.WaitingForBootstrapDataSecretReason, LastTransitionTime: now},
+				{Type: infrav1.BYOHostReady, Status: corev1.ConditionTrue, LastTransitionTime: now},
+			}))
+		})
+	})
+
+	Context("ByoCluster", func() {
+		It("should convert a ByoCluster to v1beta2 and back without loss", func() {
+			hub := &infrav1.ByoCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "byocluster", Namespace: "default", Generation: 2},
+				Spec: infrav1.ByoClusterSpec{
+					ControlPlaneEndpoint: infrav1.APIEndpoint{Host: "10.0.0.1", Port: 6443},
+					LoadBalancer:         &infrav1.LoadBalancerSpec{Type: infrav1.HAProxyLoadBalancer},
+					FailureDomainLabel:   "topology.byoh/rack",
+				},
+				Status: infrav1.ByoClusterStatus{
+					Ready:              true,
+					Conditions:         conditions,
+					FailureDomains:     clusterv1.FailureDomains{"rack-1": clusterv1.FailureDomainSpec{ControlPlane: true}},
+					ReadyHosts:         3,
+					ObservedGeneration: 2,
+				},
+			}
+
+			spoke := &v1beta2.ByoCluster{}
+			Expect(spoke.ConvertFrom(hub)).To(Succeed())
+			Expect(spoke.Status.Initialization.Provisioned).To(Equal(pointer.Bool(true)))
+			Expect(spoke.Status.Conditions).To(HaveLen(2))
+			Expect(spoke.Status.Conditions[0].ObservedGeneration).To(Equal(int64(2)))
+			Expect(spoke.Status.ReadyHosts).To(Equal(int32(3)))
+
+			restored := &infrav1.ByoCluster{}
+			Expect(spoke.ConvertTo(restored)).To(Succeed())
+			Expect(restored).To(Equal(hub))
+		})
+	})
+})
@@ -0,0 +1,6 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package v1beta2 contains the v1beta2 API implementation.
+// +k8s:conversion-gen=github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1
+package v1beta2
@@ -0,0 +1,16 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package agentrbac_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestAgentRBAC(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Agent RBAC Suite")
+}
@@ -0,0 +1,118 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package agentrbac defines the least privileges of a host agent restricted to a namespace, granted
+// by the controller manager to the namespace-scoped agent kubeconfigs and verified by the agent
+package agentrbac
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+const infrastructureGroup = "infrastructure.cluster.x-k8s.io"
+
+// NamespaceRules returns the rules a host agent needs in the namespace of its ByoHost: registering
+// and managing the ByoHosts, recording events, uploading its logs and debug bundles as ConfigMaps
+// and reading the bootstrap and installation secrets of the machines of the namespace
+func NamespaceRules() []rbacv1.PolicyRule {
+	return []rbacv1.PolicyRule{
+		{
+			APIGroups: []string{infrastructureGroup},
+			Resources: []string{"byohosts"},
+			Verbs:     []string{"create", "get", "list", "watch", "update", "patch", "delete"},
+		},
+		{
+			APIGroups: []string{infrastructureGroup},
+			Resources: []string{"byohosts/status"},
+			Verbs:     []string{"get", "update", "patch"},
+		},
+		{
+			APIGroups: []string{corev1.GroupName},
+			Resources: []string{"events"},
+			Verbs:     []string{"create", "patch"},
+		},
+		{
+			APIGroups: []string{corev1.GroupName},
+			Resources: []string{"configmaps"},
+			Verbs:     []string{"create", "get", "update", "patch"},
+		},
+		{
+			APIGroups: []string{corev1.GroupName},
+			Resources: []string{"secrets"},
+			Verbs:     []string{"get"},
+		},
+	}
+}
+
+// defaultRules are granted to every authenticated user by the default roles of Kubernetes
+var defaultRules = []rbacv1.PolicyRule{
+	{
+		APIGroups: []string{"authorization.k8s.io"},
+		Resources: []string{"selfsubjectaccessreviews", "selfsubjectrulesreviews"},
+		Verbs:     []string{"create"},
+	},
+	{
+		APIGroups: []string{"authentication.k8s.io"},
+		Resources: []string{"selfsubjectreviews"},
+		Verbs:     []string{"create"},
+	},
+}
+
+// ExcessPermissions returns the permissions of the granted rules, as "<verb> <resource>" sorted,
+// which are neither part of the NamespaceRules nor of the default roles of Kubernetes. The
+// wildcards are reported as is.
+func ExcessPermissions(granted []authorizationv1.ResourceRule) []string {
+	allowed := append(NamespaceRules(), defaultRules...)
+	excess := map[string]bool{}
+	for _, rule := range granted {
+		for _, group := range rule.APIGroups {
+			for _, resource := range rule.Resources {
+				for _, verb := range rule.Verbs {
+					if !allows(allowed, group, resource, verb) {
+						excess[fmt.Sprintf("%s %s", verb, qualifiedResource(group, resource))] = true
+					}
+				}
+			}
+		}
+	}
+	permissions := make([]string, 0, len(excess))
+	for permission := range excess {
+		permissions = append(permissions, permission)
+	}
+	sort.Strings(permissions)
+	return permissions
+}
+
+// allows reports if one of the rules grants the verb on the resource, the wildcards of the granted
+// permission are only allowed by wildcard rules
+func allows(rules []rbacv1.PolicyRule, group, resource, verb string) bool {
+	for _, rule := range rules {
+		if contains(rule.APIGroups, group) && contains(rule.Resources, resource) && contains(rule.Verbs, verb) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value || v == rbacv1.ResourceAll {
+			return true
+		}
+	}
+	return false
+}
+
+// qualifiedResource returns the resource as "<resource>.<group>", the core resources unqualified
+func qualifiedResource(group, resource string) string {
+	if group == corev1.GroupName {
+		return resource
+	}
+	return strings.Join([]string{resource, group}, ".")
+}
@@ -0,0 +1,45 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package agentrbac_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/common/agentrbac"
+	authorizationv1 "k8s.io/api/authorization/v1"
+)
+
+var _ = Describe("ExcessPermissions", func() {
+	It("should not report the namespace rules of the agent", func() {
+		var granted []authorizationv1.ResourceRule
+		for _, rule := range agentrbac.NamespaceRules() {
+			granted = append(granted, authorizationv1.ResourceRule{
+				Verbs:     rule.Verbs,
+				APIGroups: rule.APIGroups,
+				Resources: rule.Resources,
+			})
+		}
+		Expect(agentrbac.ExcessPermissions(granted)).To(BeEmpty())
+	})
+
+	It("should not report the narrower and the default permissions", func() {
+		Expect(agentrbac.ExcessPermissions([]authorizationv1.ResourceRule{
+			{Verbs: []string{"get"}, APIGroups: []string{"infrastructure.cluster.x-k8s.io"}, Resources: []string{"byohosts"}, ResourceNames: []string{"host"}},
+			{Verbs: []string{"create"}, APIGroups: []string{"authorization.k8s.io"}, Resources: []string{"selfsubjectrulesreviews"}},
+		})).To(BeEmpty())
+	})
+
+	It("should report the permissions beyond the namespace rules of the agent", func() {
+		Expect(agentrbac.ExcessPermissions([]authorizationv1.ResourceRule{
+			{Verbs: []string{"get", "list"}, APIGroups: []string{""}, Resources: []string{"secrets"}},
+			{Verbs: []string{"get"}, APIGroups: []string{"cluster.x-k8s.io"}, Resources: []string{"machines"}},
+		})).To(Equal([]string{"get machines.cluster.x-k8s.io", "list secrets"}))
+	})
+
+	It("should report the wildcards", func() {
+		Expect(agentrbac.ExcessPermissions([]authorizationv1.ResourceRule{
+			{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}},
+		})).To(Equal([]string{"* *.*"}))
+	})
+})
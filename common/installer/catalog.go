@@ -0,0 +1,102 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package installer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/installer"
+)
+
+// CatalogBundle is a bundle published in a bundle repository
+type CatalogBundle struct {
+	// K8sVersion is the Kubernetes version of the bundle, its tag
+	K8sVersion string
+	// OS is the OS the bundle is built for, e.g. Ubuntu_20.04.1, empty for the bundles of any OS
+	OS string
+	// Arch is the architecture of the hosts, e.g. amd64
+	Arch string
+	// Image is the address of the bundle
+	Image string
+}
+
+// TagLister lists the tags of an OCI repository. It returns no tags when the repository does not exist.
+type TagLister func(ctx context.Context, repository string) ([]string, error)
+
+// ListCatalog queries the bundle repository for the bundles of the bundle type the host agent installs,
+// sorted by OS, architecture and Kubernetes version
+func ListCatalog(ctx context.Context, bundleType, repoAddr string, listTags TagLister) ([]CatalogBundle, error) {
+	var bundles []CatalogBundle
+	for osBundle, repository := range installer.BundleRepositories(installer.BundleType(bundleType), repoAddr) {
+		tags, err := listTags(ctx, repository)
+		if err != nil {
+			return nil, err
+		}
+		osDist, arch := splitOsBundle(osBundle)
+		for _, tag := range tags {
+			if !installer.SupportsK8s(installer.BundleType(bundleType), osBundle, tag) {
+				continue
+			}
+			bundles = append(bundles, CatalogBundle{K8sVersion: tag, OS: osDist, Arch: arch, Image: repository + ":" + tag})
+		}
+	}
+	sort.Slice(bundles, func(i, j int) bool {
+		if bundles[i].OS != bundles[j].OS {
+			return bundles[i].OS < bundles[j].OS
+		}
+		if bundles[i].Arch != bundles[j].Arch {
+			return bundles[i].Arch < bundles[j].Arch
+		}
+		return bundles[i].K8sVersion < bundles[j].K8sVersion
+	})
+	return bundles, nil
+}
+
+// ListRegistryTags is the TagLister querying the registry, with the credentials of the docker config
+func ListRegistryTags(ctx context.Context, repository string) ([]string, error) {
+	repo, err := name.NewRepository(repository)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := remote.ListWithContext(ctx, repo, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	var transportErr *transport.Error
+	if errors.As(err, &transportErr) && transportErr.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	return tags, err
+}
+
+// BundleImage returns the address in the bundle repository of the bundle installing the Kubernetes version
+// on the OS and the architecture of a host, or an empty string when no bundle installs the OS
+func BundleImage(bundleType, repoAddr, osDist, arch, k8sVersion string) string {
+	osBundle := installer.ResolveOsBundle(installer.BundleType(bundleType), bundleOsArch(osDist, arch))
+	repository, ok := installer.BundleRepositories(installer.BundleType(bundleType), repoAddr)[osBundle]
+	if !ok {
+		return ""
+	}
+	return repository + ":" + k8sVersion
+}
+
+// splitOsBundle returns the OS and the architecture, as reported by the hosts, of an OS bundle
+func splitOsBundle(osBundle string) (osDist, arch string) {
+	if i := strings.LastIndex(osBundle, "_"); i >= 0 {
+		osDist, arch = osBundle[:i], osBundle[i+1:]
+	} else {
+		arch = osBundle
+	}
+	for newName, oldName := range archOldNameMap {
+		if arch == oldName {
+			arch = newName
+		}
+	}
+	return osDist, arch
+}
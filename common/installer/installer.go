@@ -5,6 +5,7 @@ package installer
 
 import (
 	"context"
+	"sort"
 	"strings"
 
 	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/agent/installer"
@@ -17,27 +18,67 @@ type K8sInstaller interface {
 	Uninstall() string
 }
 
+// HostConfiguration selects the OS settings changed by the installation script
+type HostConfiguration = algo.HostConfiguration
+
+// ContainerdConfig is the containerd configuration patch of the installation script
+type ContainerdConfig = algo.ContainerdConfig
+
+// ComponentPolicies are how the installation script handles the components of the bundle
+// which may be preinstalled on the host
+type ComponentPolicies = algo.ComponentPolicies
+
+// ComponentPolicy is how the installation script handles a component of the bundle
+type ComponentPolicy = algo.ComponentPolicy
+
+// DownloadRateLimit is the bandwidth the installation script downloads the bundle with, in bytes per second
+type DownloadRateLimit = algo.DownloadRateLimit
+
+const (
+	// ErrOsK8sNotSupported is returned when the bundle does not support the OS of the host
+	ErrOsK8sNotSupported = installer.ErrOsK8sNotSupported
+	// ErrK8sVersionNotSupported is returned when the bundle supports the OS of the host, but not the Kubernetes version
+	ErrK8sVersionNotSupported = installer.Error("No bundle support for the k8s version")
+)
+
 // archOldNameMap keeps the mapping of architecture new name to old name mapping
 var archOldNameMap = map[string]string{
 	"amd64": "x86-64",
 }
 
 // NewInstaller will return a new installer
-func NewInstaller(ctx context.Context, osDist, arch, k8sVersion string, downloader BundleDownloader) (K8sInstaller, error) {
-	bundleArchName := arch
-	// replacing the arch name to old name to match with the bundle name
-	if _, exists := archOldNameMap[arch]; exists {
-		bundleArchName = archOldNameMap[arch]
-	}
-	// normalizing os image name and adding arch
-	osArch := strings.ReplaceAll(osDist, " ", "_") + "_" + bundleArchName
+func NewInstaller(ctx context.Context, osDist, arch, k8sVersion string, downloader BundleDownloader, hostConfig HostConfiguration, containerdConfig ContainerdConfig, components ComponentPolicies, rateLimit DownloadRateLimit) (K8sInstaller, error) {
+	osArch := bundleOsArch(osDist, arch)
 
 	reg := installer.GetSupportedRegistry(nil)
 	if len(reg.ListK8s(osArch)) == 0 {
-		return nil, installer.ErrOsK8sNotSupported
+		return nil, ErrOsK8sNotSupported
+	}
+	osk8si, osbundle := reg.GetInstaller(osArch, k8sVersion)
+	if osk8si == nil && k8sVersion != "" {
+		return nil, ErrK8sVersionNotSupported
 	}
-	_, osbundle := reg.GetInstaller(osArch, k8sVersion)
 	addrs := downloader.GetBundleAddr(osbundle, k8sVersion, k8sVersion)
 
-	return algo.NewUbuntu20_04Installer(ctx, arch, addrs)
+	return algo.NewUbuntu20_04Installer(ctx, arch, addrs, k8sVersion, hostConfig, containerdConfig, components, rateLimit)
+}
+
+// SupportedK8sVersions returns the Kubernetes versions the bundles support on the OS and the
+// architecture of a host, e.g. v1.22.*. There are none when the OS is not supported.
+func SupportedK8sVersions(osDist, arch string) []string {
+	reg := installer.GetSupportedRegistry(nil)
+	versions := reg.ListK8s(bundleOsArch(osDist, arch))
+	sort.Strings(versions)
+	return versions
+}
+
+// bundleOsArch returns the name of the OS and the architecture of a host in the bundle registry
+func bundleOsArch(osDist, arch string) string {
+	bundleArchName := arch
+	// replacing the arch name to old name to match with the bundle name
+	if _, exists := archOldNameMap[arch]; exists {
+		bundleArchName = archOldNameMap[arch]
+	}
+	// normalizing os image name and adding arch
+	return strings.ReplaceAll(osDist, " ", "_") + "_" + bundleArchName
 }
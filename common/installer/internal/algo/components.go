@@ -0,0 +1,106 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package algo
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// InstallAlways installs the component of the bundle, whatever the host has
+	InstallAlways = "Always"
+	// InstallIfNotPresent installs the component of the bundle unless the host has a compatible version
+	InstallIfNotPresent = "IfNotPresent"
+	// InstallNever never installs the component of the bundle, the host must have a compatible version
+	InstallNever = "Never"
+)
+
+// ComponentPolicy is how the installation script handles a component of the bundle which may be
+// preinstalled on the host
+type ComponentPolicy struct {
+	// Install is InstallAlways, InstallIfNotPresent or InstallNever, InstallAlways when empty
+	Install string
+	// MinVersion is the oldest compatible version of the preinstalled component, any when empty
+	MinVersion string
+}
+
+// ComponentPolicies are the policies of the components of the bundle
+type ComponentPolicies struct {
+	Containerd ComponentPolicy
+	CRITools   ComponentPolicy
+	// Kubernetes are kubelet, kubeadm, kubectl and the kubernetes CNI plugins, compatible when
+	// they have the Kubernetes version of the bundle
+	Kubernetes ComponentPolicy
+}
+
+// preinstalledComponent is how the installation script detects a preinstalled component
+type preinstalledComponent struct {
+	// name is the name of the component in the script, and of its preinstalled marker
+	name string
+	// present is the shell condition of the host having the component
+	present string
+	// version is the shell command printing the version of the component
+	version string
+}
+
+var (
+	containerdComponent = preinstalledComponent{
+		name:    "containerd",
+		present: "command -v containerd >>/dev/null",
+		version: "containerd --version | awk '{print $3}'",
+	}
+	criToolsComponent = preinstalledComponent{
+		name:    "cri-tools",
+		present: "command -v crictl >>/dev/null",
+		version: "crictl --version | awk '{print $NF}'",
+	}
+	kubernetesComponent = preinstalledComponent{
+		name:    "kubernetes",
+		present: "command -v kubelet >>/dev/null && command -v kubeadm >>/dev/null && command -v kubectl >>/dev/null",
+		version: "kubelet --version | awk '{print $2}'",
+	}
+)
+
+// checks returns the steps of the installation script checking the components preinstalled on
+// the host against their policies. The components which are not installed are marked with a
+// .preinstalled-<component> file in the bundle path, for the installation and the uninstallation
+// scripts to leave them in place.
+func (p ComponentPolicies) checks(k8sVersion string) string {
+	var sb strings.Builder
+	sb.WriteString(p.Containerd.check(containerdComponent, p.Containerd.MinVersion, false))
+	sb.WriteString(p.CRITools.check(criToolsComponent, p.CRITools.MinVersion, false))
+	sb.WriteString(p.Kubernetes.check(kubernetesComponent, k8sVersion, true))
+	return sb.String()
+}
+
+// check returns the steps checking the preinstalled component against the policy, comparing
+// its version with the given one, exactly or as the oldest compatible version
+func (p ComponentPolicy) check(c preinstalledComponent, version string, exact bool) string {
+	if p.Install == "" || p.Install == InstallAlways {
+		return ""
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "\n## checking the preinstalled %s, installed %s\n", c.name, p.Install)
+	fmt.Fprintf(&sb, "if %s; then\n", c.present)
+	fmt.Fprintf(&sb, "\tPRESENT_VERSION=$(%s)\n", c.version)
+	if version = strings.TrimPrefix(version, "v"); version != "" {
+		if exact {
+			fmt.Fprintf(&sb, "\tif [ \"${PRESENT_VERSION#v}\" != %s ]; then\n", shellQuote(version))
+		} else {
+			fmt.Fprintf(&sb, "\tif ! version_at_least \"$PRESENT_VERSION\" %s; then\n", shellQuote(version))
+		}
+		fmt.Fprintf(&sb, "\t\techo \"%s $PRESENT_VERSION is preinstalled, it is not compatible with %s\" >&2\n", c.name, version)
+		sb.WriteString("\t\texit 1\n\tfi\n")
+	}
+	fmt.Fprintf(&sb, "\techo \"%s $PRESENT_VERSION is preinstalled, skipping its installation\"\n", c.name)
+	fmt.Fprintf(&sb, "\ttouch \"$BUNDLE_PATH/.preinstalled-%s\"\n", c.name)
+	if p.Install == InstallNever {
+		sb.WriteString("else\n")
+		fmt.Fprintf(&sb, "\techo \"%s is not preinstalled, and its install policy is Never\" >&2\n", c.name)
+		sb.WriteString("\texit 1\n")
+	}
+	sb.WriteString("fi\n")
+	return sb.String()
+}
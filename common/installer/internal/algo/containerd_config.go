@@ -0,0 +1,81 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package algo
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const criPlugin = `plugins."io.containerd.grpc.v1.cri"`
+
+// ContainerdConfig is the site specific containerd configuration, written as a patch
+// imported by the containerd configuration of the bundle
+type ContainerdConfig struct {
+	RegistryMirrors    map[string][]string
+	InsecureRegistries []string
+	SandboxImage       string
+	HTTPProxy          string
+	HTTPSProxy         string
+	NoProxy            string
+}
+
+// configPatch returns the containerd configuration patch, or "" when there is nothing to patch
+func (c ContainerdConfig) configPatch() string {
+	if len(c.RegistryMirrors) == 0 && len(c.InsecureRegistries) == 0 && c.SandboxImage == "" {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("version = 2\n")
+	if c.SandboxImage != "" {
+		fmt.Fprintf(&sb, "\n[%s]\n  sandbox_image = %s\n", criPlugin, strconv.Quote(c.SandboxImage))
+	}
+
+	registries := make([]string, 0, len(c.RegistryMirrors))
+	for registry := range c.RegistryMirrors {
+		registries = append(registries, registry)
+	}
+	sort.Strings(registries)
+	for _, registry := range registries {
+		endpoints := make([]string, 0, len(c.RegistryMirrors[registry]))
+		for _, endpoint := range c.RegistryMirrors[registry] {
+			endpoints = append(endpoints, strconv.Quote(endpoint))
+		}
+		fmt.Fprintf(&sb, "\n[%s.registry.mirrors.%s]\n  endpoint = [%s]\n", criPlugin, strconv.Quote(registry), strings.Join(endpoints, ", "))
+	}
+
+	for _, registry := range c.InsecureRegistries {
+		fmt.Fprintf(&sb, "\n[%s.registry.configs.%s.tls]\n  insecure_skip_verify = true\n", criPlugin, strconv.Quote(registry))
+	}
+	return sb.String()
+}
+
+// proxyDropIn returns the systemd drop-in setting the proxy environment of containerd,
+// or "" when no proxy is set
+func (c ContainerdConfig) proxyDropIn() string {
+	if c.HTTPProxy == "" && c.HTTPSProxy == "" && c.NoProxy == "" {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("[Service]\n")
+	for _, env := range []struct{ name, value string }{
+		{"HTTP_PROXY", c.HTTPProxy},
+		{"HTTPS_PROXY", c.HTTPSProxy},
+		{"NO_PROXY", c.NoProxy},
+	} {
+		if env.value != "" {
+			fmt.Fprintf(&sb, "Environment=\"%s=%s\"\n", env.name, env.value)
+			fmt.Fprintf(&sb, "Environment=\"%s=%s\"\n", strings.ToLower(env.name), env.value)
+		}
+	}
+	return sb.String()
+}
+
+// shellQuote single quotes s for bash
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -7,7 +7,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"html/template"
+	"text/template"
 )
 
 const (
@@ -15,6 +15,22 @@ const (
 	ImgpkgVersion = "v0.27.0"
 )
 
+// DownloadRateLimit is the bandwidth the installation script downloads with, in bytes per second.
+// The downloads are not throttled when it is zero.
+type DownloadRateLimit int64
+
+// kibibytes returns the rate limit in KiB per second, the unit of trickle, rounded up
+func (l DownloadRateLimit) kibibytes() int64 {
+	return (int64(l) + 1023) / 1024
+}
+
+// HostConfiguration selects the OS settings changed by the installation script
+type HostConfiguration struct {
+	DisableSwap       bool
+	ConfigureSysctls  bool
+	LoadKernelModules bool
+}
+
 // Ubuntu20_04Installer represent the installer implementation for ubunto20.04.* os distribution
 type Ubuntu20_04Installer struct {
 	install   string
@@ -22,18 +38,32 @@ type Ubuntu20_04Installer struct {
 }
 
 // NewUbuntu20_04Installer will return new Ubuntu20_04Installer instance
-func NewUbuntu20_04Installer(ctx context.Context, arch, bundleAddrs string) (*Ubuntu20_04Installer, error) {
+func NewUbuntu20_04Installer(ctx context.Context, arch, bundleAddrs, k8sVersion string, hostConfig HostConfiguration, containerdConfig ContainerdConfig, components ComponentPolicies, rateLimit DownloadRateLimit) (*Ubuntu20_04Installer, error) {
+	containerdConfigPatch, containerdProxyDropIn := "", ""
+	if patch := containerdConfig.configPatch(); patch != "" {
+		containerdConfigPatch = shellQuote(patch)
+	}
+	if dropIn := containerdConfig.proxyDropIn(); dropIn != "" {
+		containerdProxyDropIn = shellQuote(dropIn)
+	}
+
 	parseFn := func(script string) (string, error) {
 		parser, err := template.New("parser").Parse(script)
 		if err != nil {
 			return "", fmt.Errorf("unable to parse install script")
 		}
 		var tpl bytes.Buffer
-		if err = parser.Execute(&tpl, map[string]string{
+		if err = parser.Execute(&tpl, map[string]interface{}{
 			"BundleAddrs":        bundleAddrs,
 			"Arch":               arch,
 			"ImgpkgVersion":      ImgpkgVersion,
 			"BundleDownloadPath": "{{.BundleDownloadPath}}",
+			"HostConfig":         hostConfig,
+			// the containerd configuration is shell quoted, the script writes it as is
+			"ContainerdConfigPatch": containerdConfigPatch,
+			"ContainerdProxyDropIn": containerdProxyDropIn,
+			"ComponentChecks":       components.checks(k8sVersion),
+			"DownloadRateLimitKiB":  rateLimit.kibibytes(),
 		}); err != nil {
 			return "", fmt.Errorf("unable to apply install parsed template to the data object")
 		}
@@ -75,43 +105,92 @@ IMGPKG_VERSION={{.ImgpkgVersion}}
 ARCH={{.Arch}}
 BUNDLE_PATH=$BUNDLE_DOWNLOAD_PATH/$BUNDLE_ADDR
 
+## preinstalled tells if the component was preinstalled on the host, and is not installed
+preinstalled() {
+	[ -f "$BUNDLE_PATH/.preinstalled-$1" ]
+}
+
+## version_at_least tells if the first version is the second one or a later one
+version_at_least() {
+	[ "$(printf '%s\n%s\n' "${2#v}" "${1#v}" | sort -V | head -n1)" = "${2#v}" ]
+}
 
+WGET_OPTIONS=""
+THROTTLE=""
+{{ if .DownloadRateLimitKiB }}
+## throttling the downloads, imgpkg has no rate limit of its own and is run through trickle
+WGET_OPTIONS="--limit-rate={{ .DownloadRateLimitKiB }}k"
+if command -v trickle >>/dev/null; then
+	THROTTLE="trickle -s -d {{ .DownloadRateLimitKiB }}"
+else
+	echo "trickle is not installed, the bundle download is not throttled"
+fi
+{{ end }}
 if ! command -v imgpkg >>/dev/null; then
 	echo "installing imgpkg"
-	wget -nv -O- github.com/vmware-tanzu/carvel-imgpkg/releases/download/$IMGPKG_VERSION/imgpkg-linux-$ARCH > /tmp/imgpkg
+	wget -nv $WGET_OPTIONS -O- github.com/vmware-tanzu/carvel-imgpkg/releases/download/$IMGPKG_VERSION/imgpkg-linux-$ARCH > /tmp/imgpkg
 	mv /tmp/imgpkg /usr/local/bin/imgpkg
 	chmod +x /usr/local/bin/imgpkg
 fi
 
 echo "downloading bundle"
 mkdir -p $BUNDLE_PATH
-imgpkg pull -r -i $BUNDLE_ADDR -o $BUNDLE_PATH
-
-
-## disable swap
-swapoff -a && sed -ri '/\sswap\s/s/^#?/#/' /etc/fstab
-
+$THROTTLE imgpkg pull -r -i $BUNDLE_ADDR -o $BUNDLE_PATH
+{{ .ComponentChecks }}
+{{ if .HostConfig.DisableSwap }}
+## disable swap, the swap units of systemd are masked as they are not all listed in /etc/fstab
+swapoff -a && sed -ri '/\sswap\s/s/^#?/#/' /etc/fstab && systemctl mask swap.target
+{{ end }}
 ## disable firewall
 if command -v ufw >>/dev/null; then
 	ufw disable
 fi
-
+{{ if .HostConfig.LoadKernelModules }}
 ## load kernal modules
 modprobe overlay && modprobe br_netfilter
-
+{{ end }}{{ if .HostConfig.ConfigureSysctls }}
 ## adding os configuration
 tar -C / -xvf "$BUNDLE_PATH/conf.tar" && sysctl --system 
+{{ end }}
 
 ## installing deb packages
-for pkg in cri-tools kubernetes-cni kubectl kubeadm kubelet; do
-	dpkg --install "$BUNDLE_PATH/$pkg.deb" && apt-mark hold $pkg
-done
+DPKG_OPTIONS=""
+if preinstalled cri-tools; then
+	DPKG_OPTIONS="--ignore-depends=cri-tools"
+else
+	dpkg --install "$BUNDLE_PATH/cri-tools.deb" && apt-mark hold cri-tools
+fi
+if ! preinstalled kubernetes; then
+	for pkg in kubernetes-cni kubectl kubeadm kubelet; do
+		dpkg --install $DPKG_OPTIONS "$BUNDLE_PATH/$pkg.deb" && apt-mark hold $pkg
+	done
+fi
 
 ## intalling containerd
-tar -C / -xvf "$BUNDLE_PATH/containerd.tar"
-
-## starting containerd service
-systemctl daemon-reload && systemctl enable containerd && systemctl start containerd`
+if ! preinstalled containerd; then
+	tar -C / -xvf "$BUNDLE_PATH/containerd.tar"
+fi
+{{ if .ContainerdConfigPatch }}
+## patching containerd configuration, imported by the configuration of the bundle
+mkdir -p /etc/containerd/conf.d
+printf '%s' {{ .ContainerdConfigPatch }} > /etc/containerd/conf.d/byoh.toml
+if [ ! -f /etc/containerd/config.toml ]; then
+	printf 'version = 2\n' > /etc/containerd/config.toml
+fi
+if ! grep -q '/etc/containerd/conf.d/\*.toml' /etc/containerd/config.toml; then
+	sed -i '1i imports = ["/etc/containerd/conf.d/*.toml"]' /etc/containerd/config.toml
+fi
+{{ end }}{{ if .ContainerdProxyDropIn }}
+## setting the proxy of containerd
+mkdir -p /etc/systemd/system/containerd.service.d
+printf '%s' {{ .ContainerdProxyDropIn }} > /etc/systemd/system/containerd.service.d/http-proxy.conf
+{{ end }}
+## starting containerd service, the preinstalled containerd is restarted with the configuration patch
+if preinstalled containerd; then
+	systemctl daemon-reload && systemctl enable containerd && systemctl restart containerd
+else
+	systemctl daemon-reload && systemctl enable containerd && systemctl start containerd
+fi`
 
 	UndoUbuntu20_4K8s1_22 = `
 set -euo pipefail
@@ -120,30 +199,50 @@ BUNDLE_DOWNLOAD_PATH={{.BundleDownloadPath}}
 BUNDLE_ADDR={{.BundleAddrs}}
 BUNDLE_PATH=$BUNDLE_DOWNLOAD_PATH/$BUNDLE_ADDR
 
+## preinstalled tells if the component was preinstalled on the host, and was not installed
+preinstalled() {
+	[ -f "$BUNDLE_PATH/.preinstalled-$1" ]
+}
+{{ if .HostConfig.DisableSwap }}
 ## enable swap
-swapon -a && sed -ri '/\sswap\s/s/^#?//' /etc/fstab
-
+systemctl unmask swap.target && sed -ri '/\sswap\s/s/^#?//' /etc/fstab && swapon -a
+{{ end }}
 ## enable firewall
 if command -v ufw >>/dev/null; then
 	ufw enable
 fi
-
+{{ if .HostConfig.LoadKernelModules }}
 ## remove kernal modules
 modprobe -r overlay && modprobe -r br_netfilter
-
+{{ end }}{{ if .HostConfig.ConfigureSysctls }}
 ## removing os configuration
 tar tf "$BUNDLE_PATH/conf.tar" | xargs -n 1 echo '/' | sed 's/ //g' | xargs rm -f
+{{ end }}
 
-## removing deb packages
-for pkg in cri-tools kubernetes-cni kubectl kubeadm kubelet; do
-	dpkg --purge $pkg
-done
+## removing deb packages, the preinstalled components are left in place
+if ! preinstalled cri-tools; then
+	dpkg --purge cri-tools
+fi
+if ! preinstalled kubernetes; then
+	for pkg in kubernetes-cni kubectl kubeadm kubelet; do
+		dpkg --purge $pkg
+	done
+fi
 
 ## removing containerd configurations and cni plugins
-rm -rf /opt/cni/ && rm -rf /opt/containerd/ &&  tar tf "$BUNDLE_PATH/containerd.tar" | xargs -n 1 echo '/' | sed 's/ //g'  | grep -e '[^/]$' | xargs rm -f
+if ! preinstalled containerd; then
+	rm -rf /opt/cni/ && rm -rf /opt/containerd/ &&  tar tf "$BUNDLE_PATH/containerd.tar" | xargs -n 1 echo '/' | sed 's/ //g'  | grep -e '[^/]$' | xargs rm -f
+fi
 
-## disabling containerd service
-systemctl stop containerd && systemctl disable containerd && systemctl daemon-reload
+## removing the containerd configuration patch and proxy
+rm -f /etc/containerd/conf.d/byoh.toml /etc/systemd/system/containerd.service.d/http-proxy.conf
+
+## disabling containerd service, the preinstalled containerd is restarted without the configuration patch
+if preinstalled containerd; then
+	systemctl daemon-reload && systemctl restart containerd
+else
+	systemctl stop containerd && systemctl disable containerd && systemctl daemon-reload
+fi
 
 rm -rf $BUNDLE_PATH`
 )
@@ -0,0 +1,75 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2/klogr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Main entry point for the host inventory import CLI
+func Main() {
+	file := flag.String("file", "", "Path of the CSV host inventory, - reads it from the standard input")
+	namespace := flag.String("namespace", "default", "Namespace of the ByoHosts of the hosts without a namespace column")
+	dryRun := flag.Bool("dry-run", false, "Report what the import would do without changing the ByoHosts")
+	flag.Parse()
+
+	logger := klogr.New()
+	if *file == "" {
+		logger.Error(fmt.Errorf("--file is not set"), "unable to read the host inventory")
+		os.Exit(1)
+	}
+	var data []byte
+	var err error
+	if *file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(*file)
+	}
+	if err != nil {
+		logger.Error(err, "unable to read the host inventory", "file", *file)
+		os.Exit(1)
+	}
+	hosts, err := Parse(bytes.NewReader(data), *namespace)
+	if err != nil {
+		logger.Error(err, "unable to parse the host inventory", "file", *file)
+		os.Exit(1)
+	}
+
+	scheme := runtime.NewScheme()
+	if err := infrastructurev1beta1.AddToScheme(scheme); err != nil {
+		logger.Error(err, "unable to add the ByoHost types to the scheme")
+		os.Exit(1)
+	}
+	config, err := ctrl.GetConfig()
+	if err != nil {
+		logger.Error(err, "unable to load the kubeconfig")
+		os.Exit(1)
+	}
+	c, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		logger.Error(err, "unable to create the client")
+		os.Exit(1)
+	}
+
+	importer := &Importer{Client: c, DryRun: *dryRun}
+	results, err := importer.Import(context.Background(), hosts)
+	for _, result := range results {
+		fmt.Printf("byohost %s/%s %s\n", result.Host.Namespace, result.Host.Name, result.Action)
+	}
+	if err != nil {
+		logger.Error(err, "unable to import the host inventory")
+		os.Exit(1)
+	}
+}
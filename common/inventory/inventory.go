@@ -0,0 +1,211 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// NameColumn is the column of the names of the hosts, the only required column
+	NameColumn = "name"
+	// NamespaceColumn is the column of the namespaces of the ByoHosts, the default namespace when empty
+	NamespaceColumn = "namespace"
+	// LabelColumnPrefix prefixes the columns of the labels of the ByoHosts, e.g. label:site
+	LabelColumnPrefix = "label:"
+	// AnnotationColumnPrefix prefixes the columns of the annotations of the ByoHosts, e.g. the site
+	// metadata with annotation:example.com/rack
+	AnnotationColumnPrefix = "annotation:"
+)
+
+// Action is what the import did with the ByoHost of a host
+type Action string
+
+const (
+	// Created is a placeholder ByoHost created for a host which has not registered yet
+	Created Action = "created"
+	// Updated is a ByoHost whose labels or annotations were updated from the inventory
+	Updated Action = "updated"
+	// Unchanged is a ByoHost already matching the inventory
+	Unchanged Action = "unchanged"
+)
+
+// Host is a host of the inventory
+type Host struct {
+	Name        string
+	Namespace   string
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// Result is the outcome of the import of a host
+type Result struct {
+	Host   *Host
+	Action Action
+}
+
+// Parse returns the hosts of a CSV inventory. Its header names the columns: NameColumn, then optionally
+// NamespaceColumn and the label and annotation columns. The empty cells are skipped.
+func Parse(r io.Reader, defaultNamespace string) ([]Host, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("inventory has no header")
+		}
+		return nil, err
+	}
+	nameIndex := -1
+	for i, column := range header {
+		header[i] = strings.TrimSpace(column)
+		switch column := header[i]; {
+		case column == NameColumn:
+			nameIndex = i
+		case column == NamespaceColumn:
+		case strings.HasPrefix(column, LabelColumnPrefix):
+			if errs := validation.IsQualifiedName(strings.TrimPrefix(column, LabelColumnPrefix)); len(errs) > 0 {
+				return nil, fmt.Errorf("invalid label column %q: %s", column, strings.Join(errs, ", "))
+			}
+		case strings.HasPrefix(column, AnnotationColumnPrefix):
+			if errs := validation.IsQualifiedName(strings.TrimPrefix(column, AnnotationColumnPrefix)); len(errs) > 0 {
+				return nil, fmt.Errorf("invalid annotation column %q: %s", column, strings.Join(errs, ", "))
+			}
+		default:
+			return nil, fmt.Errorf("unknown column %q, must be %s, %s, or prefixed with %s or %s", column,
+				NameColumn, NamespaceColumn, LabelColumnPrefix, AnnotationColumnPrefix)
+		}
+	}
+	if nameIndex < 0 {
+		return nil, fmt.Errorf("inventory has no %s column", NameColumn)
+	}
+
+	hosts := []Host{}
+	seen := map[string]int{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return hosts, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		host, err := parseHost(header, record, defaultNamespace)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		key := host.Namespace + "/" + host.Name
+		if previous, ok := seen[key]; ok {
+			return nil, fmt.Errorf("line %d: host %s is already listed on line %d", line, key, previous)
+		}
+		seen[key] = line
+		hosts = append(hosts, *host)
+	}
+}
+
+func parseHost(header, record []string, defaultNamespace string) (*Host, error) {
+	host := &Host{Namespace: defaultNamespace, Labels: map[string]string{}, Annotations: map[string]string{}}
+	for i, value := range record {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		switch column := header[i]; {
+		case column == NameColumn:
+			host.Name = value
+		case column == NamespaceColumn:
+			host.Namespace = value
+		case strings.HasPrefix(column, LabelColumnPrefix):
+			if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+				return nil, fmt.Errorf("invalid value %q of column %s: %s", value, column, strings.Join(errs, ", "))
+			}
+			host.Labels[strings.TrimPrefix(column, LabelColumnPrefix)] = value
+		case strings.HasPrefix(column, AnnotationColumnPrefix):
+			host.Annotations[strings.TrimPrefix(column, AnnotationColumnPrefix)] = value
+		}
+	}
+	if host.Name == "" {
+		return nil, fmt.Errorf("host has no name")
+	}
+	if errs := validation.IsDNS1123Subdomain(host.Name); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid host name %q: %s", host.Name, strings.Join(errs, ", "))
+	}
+	return host, nil
+}
+
+// Importer creates the ByoHosts of the hosts of an inventory
+type Importer struct {
+	Client client.Client
+	// DryRun reports what the import would do without changing the ByoHosts
+	DryRun bool
+}
+
+// Import creates a placeholder ByoHost, labelled with the ExpectedHostLabel, for each host which has not
+// registered yet. The host agent binds it when the host registers under the same name. The labels and
+// annotations of the inventory are set on the ByoHosts which already exist, the other ones are kept.
+func (i *Importer) Import(ctx context.Context, hosts []Host) ([]Result, error) {
+	results := make([]Result, 0, len(hosts))
+	for j := range hosts {
+		host := &hosts[j]
+		action, err := i.importHost(ctx, host)
+		if err != nil {
+			return results, fmt.Errorf("error importing host %s in namespace %s: %v", host.Name, host.Namespace, err)
+		}
+		results = append(results, Result{Host: host, Action: action})
+	}
+	return results, nil
+}
+
+func (i *Importer) importHost(ctx context.Context, host *Host) (Action, error) {
+	byoHost := &infrastructurev1beta1.ByoHost{}
+	err := i.Client.Get(ctx, client.ObjectKey{Namespace: host.Namespace, Name: host.Name}, byoHost)
+	if apierrors.IsNotFound(err) {
+		byoHost = &infrastructurev1beta1.ByoHost{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        host.Name,
+				Namespace:   host.Namespace,
+				Labels:      map[string]string{infrastructurev1beta1.ExpectedHostLabel: ""},
+				Annotations: host.Annotations,
+			},
+		}
+		for key, value := range host.Labels {
+			byoHost.Labels[key] = value
+		}
+		if i.DryRun {
+			return Created, nil
+		}
+		return Created, i.Client.Create(ctx, byoHost)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	original := byoHost.DeepCopy()
+	for key, value := range host.Labels {
+		metav1.SetMetaDataLabel(&byoHost.ObjectMeta, key, value)
+	}
+	for key, value := range host.Annotations {
+		metav1.SetMetaDataAnnotation(&byoHost.ObjectMeta, key, value)
+	}
+	if reflect.DeepEqual(original.ObjectMeta, byoHost.ObjectMeta) {
+		return Unchanged, nil
+	}
+	if i.DryRun {
+		return Updated, nil
+	}
+	return Updated, i.Client.Patch(ctx, byoHost, client.MergeFrom(original))
+}
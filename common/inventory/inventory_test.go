@@ -0,0 +1,125 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory_test
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/common/inventory"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Host inventory", func() {
+	Context("When the CSV inventory is parsed", func() {
+		It("should return the hosts with their labels and annotations", func() {
+			hosts, err := inventory.Parse(strings.NewReader(`name,namespace,label:site,annotation:example.com/rack
+edge-host-1,,edge-1,r12
+edge-host-2,edge,edge-2,
+`), "default")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hosts).To(Equal([]inventory.Host{
+				{
+					Name:        "edge-host-1",
+					Namespace:   "default",
+					Labels:      map[string]string{"site": "edge-1"},
+					Annotations: map[string]string{"example.com/rack": "r12"},
+				},
+				{
+					Name:        "edge-host-2",
+					Namespace:   "edge",
+					Labels:      map[string]string{"site": "edge-2"},
+					Annotations: map[string]string{},
+				},
+			}))
+		})
+
+		It("should reject an inventory without a name column", func() {
+			_, err := inventory.Parse(strings.NewReader("label:site\nedge-1\n"), "default")
+			Expect(err).To(MatchError("inventory has no name column"))
+		})
+
+		It("should reject an unknown column", func() {
+			_, err := inventory.Parse(strings.NewReader("name,site\nedge-host-1,edge-1\n"), "default")
+			Expect(err).To(MatchError(ContainSubstring(`unknown column "site"`)))
+		})
+
+		It("should reject a host listed twice", func() {
+			_, err := inventory.Parse(strings.NewReader("name\nedge-host-1\nedge-host-1\n"), "default")
+			Expect(err).To(MatchError("line 3: host default/edge-host-1 is already listed on line 2"))
+		})
+
+		It("should reject an invalid host name", func() {
+			_, err := inventory.Parse(strings.NewReader("name\nEdge_Host\n"), "default")
+			Expect(err).To(MatchError(ContainSubstring(`line 2: invalid host name "Edge_Host"`)))
+		})
+	})
+
+	Context("When the hosts are imported", func() {
+		var (
+			ctx        context.Context
+			c          client.Client
+			importer   *inventory.Importer
+			hosts      []inventory.Host
+			registered *infrastructurev1beta1.ByoHost
+		)
+
+		BeforeEach(func() {
+			ctx = context.TODO()
+			scheme := runtime.NewScheme()
+			Expect(infrastructurev1beta1.AddToScheme(scheme)).To(Succeed())
+			registered = &infrastructurev1beta1.ByoHost{ObjectMeta: metav1.ObjectMeta{
+				Name:      "registered-host",
+				Namespace: "default",
+				Labels:    map[string]string{"arch": "amd64"},
+			}}
+			c = fake.NewClientBuilder().WithScheme(scheme).WithObjects(registered).Build()
+			importer = &inventory.Importer{Client: c}
+			hosts = []inventory.Host{
+				{Name: "expected-host", Namespace: "default", Labels: map[string]string{"site": "edge-1"}, Annotations: map[string]string{"example.com/rack": "r12"}},
+				{Name: "registered-host", Namespace: "default", Labels: map[string]string{"site": "edge-2"}},
+			}
+		})
+
+		It("should create the ByoHosts of the hosts which have not registered yet", func() {
+			results, err := importer.Import(ctx, hosts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results).To(HaveLen(2))
+			Expect(results[0].Action).To(Equal(inventory.Created))
+			Expect(results[1].Action).To(Equal(inventory.Updated))
+
+			byoHost := &infrastructurev1beta1.ByoHost{}
+			Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "expected-host"}, byoHost)).To(Succeed())
+			Expect(byoHost.Labels).To(Equal(map[string]string{infrastructurev1beta1.ExpectedHostLabel: "", "site": "edge-1"}))
+			Expect(byoHost.Annotations).To(HaveKeyWithValue("example.com/rack", "r12"))
+
+			Expect(c.Get(ctx, client.ObjectKeyFromObject(registered), byoHost)).To(Succeed())
+			Expect(byoHost.Labels).To(Equal(map[string]string{"arch": "amd64", "site": "edge-2"}))
+
+			results, err = importer.Import(ctx, hosts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results[0].Action).To(Equal(inventory.Unchanged))
+			Expect(results[1].Action).To(Equal(inventory.Unchanged))
+		})
+
+		It("should not change the ByoHosts in dry run", func() {
+			importer.DryRun = true
+			results, err := importer.Import(ctx, hosts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results[0].Action).To(Equal(inventory.Created))
+			Expect(results[1].Action).To(Equal(inventory.Updated))
+
+			Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "expected-host"}, &infrastructurev1beta1.ByoHost{})).NotTo(Succeed())
+			byoHost := &infrastructurev1beta1.ByoHost{}
+			Expect(c.Get(ctx, client.ObjectKeyFromObject(registered), byoHost)).To(Succeed())
+			Expect(byoHost.Labels).NotTo(HaveKey("site"))
+		})
+	})
+})
@@ -0,0 +1,87 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package maintenance evaluates the maintenance windows of the hosts, outside of which the
+// disruptive operations, e.g. the agent upgrades and the host cleanups, are deferred
+package maintenance
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Window is a recurring maintenance window, opened by a cron schedule for a duration
+type Window struct {
+	Schedule cron.Schedule
+	Duration time.Duration
+}
+
+// Parse returns the maintenance windows of a value like "0 2 * * 6 4h; 0 2 * * 3 2h", a semicolon
+// separated list of standard cron schedules followed by the duration of the window. The schedules
+// are evaluated in the local time of the agent or the controller, or in the time zone prefixed
+// with CRON_TZ=, e.g. "CRON_TZ=Europe/Paris 0 2 * * * 3h".
+func Parse(value string) ([]Window, error) {
+	var windows []Window
+	for _, spec := range strings.Split(value, ";") {
+		fields := strings.Fields(spec)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("maintenance window %q has no duration", strings.TrimSpace(spec))
+		}
+		duration, err := time.ParseDuration(fields[len(fields)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration of maintenance window %q: %v", strings.TrimSpace(spec), err)
+		}
+		if duration <= 0 {
+			return nil, fmt.Errorf("duration of maintenance window %q must be positive", strings.TrimSpace(spec))
+		}
+		schedule, err := cron.ParseStandard(strings.Join(fields[:len(fields)-1], " "))
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule of maintenance window %q: %v", strings.TrimSpace(spec), err)
+		}
+		windows = append(windows, Window{Schedule: schedule, Duration: duration})
+	}
+	if len(windows) == 0 {
+		return nil, fmt.Errorf("no maintenance window in %q", value)
+	}
+	return windows, nil
+}
+
+// Open tells whether one of the windows is open at now, and otherwise when the next one opens
+func Open(windows []Window, now time.Time) (bool, time.Time) {
+	var next time.Time
+	for _, window := range windows {
+		// the first activation after now - duration is open at now when it is not after now
+		activation := window.Schedule.Next(now.Add(-window.Duration))
+		if activation.IsZero() {
+			// the schedule never activates, e.g. on February 30
+			continue
+		}
+		if !activation.After(now) {
+			return true, time.Time{}
+		}
+		if next.IsZero() || activation.Before(next) {
+			next = activation
+		}
+	}
+	return false, next
+}
+
+// Check tells whether the disruptive operations can run at now under the maintenance windows of
+// the value, and otherwise when the next window opens. They can always run without windows.
+func Check(value string, now time.Time) (bool, time.Time, error) {
+	if strings.TrimSpace(value) == "" {
+		return true, time.Time{}, nil
+	}
+	windows, err := Parse(value)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	open, next := Open(windows, now)
+	return open, next, nil
+}
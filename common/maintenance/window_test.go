@@ -0,0 +1,70 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package maintenance_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/common/maintenance"
+)
+
+var _ = Describe("Maintenance windows", func() {
+	// Saturday 2022-06-04
+	saturday := func(hour, minute int) time.Time {
+		return time.Date(2022, time.June, 4, hour, minute, 0, 0, time.UTC)
+	}
+
+	It("should be open from the activation of the schedule for the duration of the window", func() {
+		windows, err := maintenance.Parse("CRON_TZ=UTC 0 2 * * SAT 4h")
+		Expect(err).NotTo(HaveOccurred())
+
+		open, next := maintenance.Open(windows, saturday(1, 59))
+		Expect(open).To(BeFalse())
+		Expect(next).To(BeTemporally("==", saturday(2, 0)))
+
+		open, _ = maintenance.Open(windows, saturday(2, 0))
+		Expect(open).To(BeTrue())
+		open, _ = maintenance.Open(windows, saturday(5, 59))
+		Expect(open).To(BeTrue())
+
+		open, next = maintenance.Open(windows, saturday(6, 0))
+		Expect(open).To(BeFalse())
+		Expect(next).To(BeTemporally("==", saturday(2, 0).AddDate(0, 0, 7)))
+	})
+
+	It("should return the next opening of several windows", func() {
+		windows, err := maintenance.Parse("CRON_TZ=UTC 0 2 * * SAT 1h; CRON_TZ=UTC 0 22 * * * 30m")
+		Expect(err).NotTo(HaveOccurred())
+		open, next := maintenance.Open(windows, saturday(12, 0))
+		Expect(open).To(BeFalse())
+		Expect(next).To(BeTemporally("==", saturday(22, 0)))
+	})
+
+	It("should never be open for a schedule which never activates", func() {
+		windows, err := maintenance.Parse("0 0 30 2 * 1h")
+		Expect(err).NotTo(HaveOccurred())
+		open, next := maintenance.Open(windows, saturday(12, 0))
+		Expect(open).To(BeFalse())
+		Expect(next.IsZero()).To(BeTrue())
+	})
+
+	It("should reject the invalid windows", func() {
+		_, err := maintenance.Parse("0 2 * * SAT")
+		Expect(err).To(MatchError(ContainSubstring(`invalid duration of maintenance window "0 2 * * SAT"`)))
+		_, err = maintenance.Parse("4h")
+		Expect(err).To(MatchError(`maintenance window "4h" has no duration`))
+		_, err = maintenance.Parse("0 2 * * FUNDAY 4h")
+		Expect(err).To(MatchError(ContainSubstring("invalid schedule of maintenance window")))
+		_, err = maintenance.Parse("0 2 * * SAT -1h")
+		Expect(err).To(MatchError(ContainSubstring("must be positive")))
+	})
+
+	It("should always allow the operations without windows", func() {
+		open, _, err := maintenance.Check("", saturday(12, 0))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(open).To(BeTrue())
+	})
+})
@@ -0,0 +1,142 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package tracing traces the provisioning of the machines with OpenTelemetry, across the controller
+// manager and the host agents. The trace context of a machine is propagated through the
+// TraceParentAnnotation of its ByoMachine and of the ByoHost attached to it.
+package tracing
+
+import (
+	"context"
+
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TracerName is the name of the tracer of the spans of the provider
+const TracerName = "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost"
+
+// traceParentHeader is the W3C trace context header stored in the TraceParentAnnotation
+const traceParentHeader = "traceparent"
+
+// propagator propagates the trace context through the annotations whatever the global propagator
+var propagator = propagation.TraceContext{}
+
+// Setup has the spans of the service exported to the OTLP/HTTP collector at endpoint, e.g. otel-collector:4318,
+// over TLS unless insecure. Nothing is traced when the endpoint is empty. The returned func flushes the spans
+// not exported yet, it is called before exiting.
+func Setup(ctx context.Context, serviceName, endpoint string, insecure bool) (func(context.Context) error, error) {
+	if endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint)}
+	if insecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagator)
+	return provider.Shutdown, nil
+}
+
+// Start starts a span child of the span of ctx, if any
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(TracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// Continue starts a span in the trace of the object, or a trace of its own when the object has no trace context
+func Continue(ctx context.Context, obj metav1.Object, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Start(Extract(ctx, obj), name, attrs...)
+}
+
+// Begin starts a span in the trace of the object like Continue. When the object has no trace context, the span
+// starts the trace of the object and its context is stored on the object, for the later spans to join it.
+func Begin(ctx context.Context, obj metav1.Object, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	ctx, span := Continue(ctx, obj, name, attrs...)
+	if !Traced(obj) {
+		Inject(ctx, obj)
+	}
+	return ctx, span
+}
+
+// End ends the span, failed with the error if not nil
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Extract returns the context with the trace context of the object as the remote parent of the spans started
+// from it, or ctx when the object has none
+func Extract(ctx context.Context, obj metav1.Object) context.Context {
+	return propagator.Extract(ctx, annotationCarrier{obj})
+}
+
+// Inject stores the trace context of the span of ctx on the object. Nothing is stored when the span is not
+// recorded, i.e. when tracing is not set up.
+func Inject(ctx context.Context, obj metav1.Object) {
+	propagator.Inject(ctx, annotationCarrier{obj})
+}
+
+// Traced tells if the object has a trace context
+func Traced(obj metav1.Object) bool {
+	return obj.GetAnnotations()[infrav1.TraceParentAnnotation] != ""
+}
+
+// Propagate copies the trace context of an object onto another, e.g. of a ByoMachine onto its ByoHost
+func Propagate(from, to metav1.Object) {
+	traceParent, ok := from.GetAnnotations()[infrav1.TraceParentAnnotation]
+	if !ok {
+		return
+	}
+	annotationCarrier{to}.Set(traceParentHeader, traceParent)
+}
+
+// annotationCarrier carries the trace context in the TraceParentAnnotation of an object
+type annotationCarrier struct {
+	obj metav1.Object
+}
+
+// Get returns the value of the trace context header
+func (c annotationCarrier) Get(key string) string {
+	if key != traceParentHeader {
+		return ""
+	}
+	return c.obj.GetAnnotations()[infrav1.TraceParentAnnotation]
+}
+
+// Set stores the value of the trace context header, the trace state is not propagated
+func (c annotationCarrier) Set(key, value string) {
+	if key != traceParentHeader {
+		return
+	}
+	annotations := c.obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[infrav1.TraceParentAnnotation] = value
+	c.obj.SetAnnotations(annotations)
+}
+
+// Keys returns the trace context headers carried
+func (c annotationCarrier) Keys() []string {
+	return []string{traceParentHeader}
+}
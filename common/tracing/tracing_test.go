@@ -0,0 +1,115 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package tracing_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/common/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Tracing", func() {
+	var (
+		ctx        context.Context
+		recorder   *tracetest.SpanRecorder
+		byoMachine *infrav1.ByoMachine
+		byoHost    *infrav1.ByoHost
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		recorder = tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+		byoMachine = &infrav1.ByoMachine{ObjectMeta: metav1.ObjectMeta{Name: "machine"}}
+		byoHost = &infrav1.ByoHost{ObjectMeta: metav1.ObjectMeta{Name: "host"}}
+	})
+
+	AfterEach(func() {
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+	})
+
+	It("should store the trace context of the span beginning the trace of an object", func() {
+		_, span := tracing.Begin(ctx, byoMachine, "ByoMachine.Reconcile")
+		span.End()
+
+		Expect(byoMachine.Annotations).To(HaveKeyWithValue(infrav1.TraceParentAnnotation,
+			"00-"+span.SpanContext().TraceID().String()+"-"+span.SpanContext().SpanID().String()+"-01"))
+	})
+
+	It("should keep the trace context of an object already traced", func() {
+		_, first := tracing.Begin(ctx, byoMachine, "ByoMachine.Reconcile")
+		first.End()
+		traceParent := byoMachine.Annotations[infrav1.TraceParentAnnotation]
+
+		_, second := tracing.Begin(ctx, byoMachine, "ByoMachine.Reconcile")
+		second.End()
+
+		Expect(byoMachine.Annotations[infrav1.TraceParentAnnotation]).To(Equal(traceParent))
+		Expect(second.SpanContext().TraceID()).To(Equal(first.SpanContext().TraceID()))
+		Expect(recorder.Ended()[1].Parent().SpanID()).To(Equal(first.SpanContext().SpanID()))
+	})
+
+	It("should continue the trace of a ByoMachine on the ByoHost it is propagated to", func() {
+		_, machineSpan := tracing.Begin(ctx, byoMachine, "ByoMachine.Reconcile")
+		machineSpan.End()
+		tracing.Propagate(byoMachine, byoHost)
+
+		_, hostSpan := tracing.Continue(ctx, byoHost, "ByoHost.Bootstrap")
+		hostSpan.End()
+
+		Expect(hostSpan.SpanContext().TraceID()).To(Equal(machineSpan.SpanContext().TraceID()))
+		Expect(recorder.Ended()[1].Parent().IsRemote()).To(BeTrue())
+	})
+
+	It("should start a trace of its own for an object without trace context", func() {
+		_, span := tracing.Continue(ctx, byoHost, "ByoHost.Bootstrap")
+		span.End()
+
+		Expect(span.SpanContext().IsValid()).To(BeTrue())
+		Expect(byoHost.Annotations).NotTo(HaveKey(infrav1.TraceParentAnnotation))
+	})
+
+	It("should not store a trace context when tracing is not set up", func() {
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+		_, span := tracing.Begin(ctx, byoMachine, "ByoMachine.Reconcile")
+		span.End()
+
+		Expect(byoMachine.Annotations).NotTo(HaveKey(infrav1.TraceParentAnnotation))
+	})
+
+	It("should start the spans of a step as children of the span of the context", func() {
+		reconcileCtx, reconcileSpan := tracing.Continue(ctx, byoHost, "ByoHost.Bootstrap")
+		_, stepSpan := tracing.Start(reconcileCtx, "DownloadBundle")
+		stepSpan.End()
+		reconcileSpan.End()
+
+		Expect(recorder.Ended()[0].Parent().SpanID()).To(Equal(reconcileSpan.SpanContext().SpanID()))
+	})
+
+	It("should record the error ending a span", func() {
+		_, span := tracing.Start(ctx, "DownloadBundle")
+		tracing.End(span, errors.New("registry unreachable"))
+
+		Expect(recorder.Ended()).To(HaveLen(1))
+		Expect(recorder.Ended()[0].Status().Code).To(Equal(codes.Error))
+		Expect(recorder.Ended()[0].Status().Description).To(Equal("registry unreachable"))
+	})
+
+	It("should not export the spans without endpoint", func() {
+		shutdown, err := tracing.Setup(ctx, "byoh-hostagent", "", false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(shutdown(ctx)).To(Succeed())
+	})
+})
@@ -0,0 +1,202 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// cloudConfigFormat is the bootstrap data format of kubeadm, the default when the bootstrap secret has no format
+	cloudConfigFormat = "cloud-config"
+	// ignitionFormat is the bootstrap data format of the ignition config
+	ignitionFormat = "ignition"
+	// kubeadmConfigKind is the kind of the bootstrap configs of CABPK, the only ones regenerated with a fresh bootstrap token
+	kubeadmConfigKind = "KubeadmConfig"
+)
+
+// cloudConfigDirectives are the cloud-config directives the host agent runs, the others are ignored
+var cloudConfigDirectives = []string{"write_files", "runcmd"}
+
+// checkBootstrapData returns why the data of the bootstrap secret cannot be run by the host agent, or an
+// empty string when it can. The built-in formats of the agent, cloud-config and ignition, are checked, the
+// other formats are left to the bootstrap executors registered on the agents.
+func checkBootstrapData(ctx context.Context, c client.Client, namespace, name string) (string, error) {
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, secret); err != nil {
+		return "", err
+	}
+
+	data := strings.TrimSpace(string(secret.Data["value"]))
+	if data == "" {
+		return fmt.Sprintf("bootstrap secret %s has no data", name), nil
+	}
+	switch format := string(secret.Data["format"]); format {
+	case "", cloudConfigFormat:
+		return checkCloudConfig(data), nil
+	case ignitionFormat:
+		return checkIgnition(data), nil
+	}
+	return "", nil
+}
+
+// checkCloudConfig returns why the cloud-config cannot be run by the host agent, e.g. a MIME multi-part
+// archive or a script generated by a bootstrap provider for another infrastructure
+func checkCloudConfig(data string) string {
+	switch {
+	case strings.HasPrefix(data, "#!"):
+		return "bootstrap data is a script, the host agent only runs cloud-config"
+	case strings.HasPrefix(strings.ToLower(data), "content-type: multipart"):
+		return "bootstrap data is a MIME multi-part archive, the host agent only runs cloud-config"
+	}
+
+	directives := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(data), &directives); err != nil {
+		return fmt.Sprintf("bootstrap data is not a valid cloud-config: %v", err)
+	}
+	for _, directive := range cloudConfigDirectives {
+		if _, ok := directives[directive]; ok {
+			return ""
+		}
+	}
+	found := make([]string, 0, len(directives))
+	for directive := range directives {
+		found = append(found, directive)
+	}
+	sort.Strings(found)
+	return fmt.Sprintf("cloud-config has none of the %s directives run by the host agent, found %s",
+		strings.Join(cloudConfigDirectives, ", "), strings.Join(found, ", "))
+}
+
+// checkIgnition returns why the ignition config cannot be run by the host agent
+func checkIgnition(data string) string {
+	config := struct {
+		Ignition struct {
+			Version string `json:"version"`
+		} `json:"ignition"`
+	}{}
+	if err := json.Unmarshal([]byte(data), &config); err != nil {
+		return fmt.Sprintf("bootstrap data is not a valid ignition config: %v", err)
+	}
+	if config.Ignition.Version == "" {
+		return "ignition config has no version"
+	}
+	return ""
+}
+
+// regenerateBootstrapData has CABPK regenerate the bootstrap data of the machine, after kubeadm failed to join
+// the node of the host with the expired bootstrap token of the data. The token and the status of the KubeadmConfig
+// are cleared along with the data secret name of the Machine, so that CABPK generates a fresh token and overwrites
+// the data secret. The Machine is paused meanwhile, the Machine controller would otherwise set the data secret name
+// back from the status of the KubeadmConfig. The bootstrap secret of the host is cleared until the data is regenerated.
+func (r *ByoMachineReconciler) regenerateBootstrapData(ctx context.Context, machineScope *byoMachineScope) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithValues("cluster", machineScope.Cluster.Name)
+	byoMachine, machine := machineScope.ByoMachine, machineScope.Machine
+	configRef := machine.Spec.Bootstrap.ConfigRef
+	if configRef == nil || configRef.Kind != kubeadmConfigKind {
+		logger.Info("The bootstrap token of the bootstrap data expired, the bootstrap data cannot be regenerated")
+		conditions.MarkFalse(byoMachine, infrav1.BYOHostReady, infrav1.BootstrapTokenExpiredReason, clusterv1.ConditionSeverityError,
+			"the bootstrap token of the bootstrap data expired, the bootstrap data has to be regenerated")
+		return ctrl.Result{}, nil
+	}
+
+	logger.Info("The bootstrap token of the bootstrap data expired, regenerating the bootstrap data", "config", configRef.Name)
+	machineHelper, err := patch.NewHelper(machine, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if machine.Annotations == nil {
+		machine.Annotations = make(map[string]string)
+	}
+	machine.Annotations[clusterv1.PausedAnnotation] = ""
+	machine.Spec.Bootstrap.DataSecretName = nil
+	if err = machineHelper.Patch(ctx, machine); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	config := &unstructured.Unstructured{}
+	config.SetGroupVersionKind(schema.FromAPIVersionAndKind(configRef.APIVersion, configRef.Kind))
+	if err = r.Client.Get(ctx, client.ObjectKey{Namespace: machine.Namespace, Name: configRef.Name}, config); err != nil {
+		return ctrl.Result{}, err
+	}
+	// the token is required by the schema, CABPK generates a new one when it is empty
+	tokenPath := []string{"spec", "joinConfiguration", "discovery", "bootstrapToken", "token"}
+	if _, found, _ := unstructured.NestedString(config.Object, tokenPath...); found {
+		specPatch := client.MergeFrom(config.DeepCopy())
+		if err = unstructured.SetNestedField(config.Object, "", tokenPath...); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err = r.Client.Patch(ctx, config, specPatch); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	statusPatch := client.MergeFrom(config.DeepCopy())
+	unstructured.RemoveNestedField(config.Object, "status", "dataSecretName")
+	if err = unstructured.SetNestedField(config.Object, false, "status", "ready"); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err = r.Client.Status().Patch(ctx, config, statusPatch); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	machineHelper, err = patch.NewHelper(machine, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	delete(machine.Annotations, clusterv1.PausedAnnotation)
+	if err = machineHelper.Patch(ctx, machine); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// the host waits for the regenerated data, instead of retrying with the expired token. Its agent, the only
+	// writer of its K8sNodeBootstrapSucceeded condition, reports the bootstrap data secret as unavailable meanwhile.
+	hostHelper, err := patch.NewHelper(machineScope.ByoHost, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	machineScope.ByoHost.Spec.BootstrapSecret = nil
+	if err = hostHelper.Patch(ctx, machineScope.ByoHost); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	r.Recorder.Eventf(byoMachine, corev1.EventTypeWarning, "BootstrapTokenExpired", "Regenerating the bootstrap data of %s with a fresh bootstrap token", configRef.Name)
+	conditions.MarkFalse(byoMachine, infrav1.BYOHostReady, infrav1.BootstrapTokenExpiredReason, clusterv1.ConditionSeverityWarning,
+		"regenerating the bootstrap data with a fresh bootstrap token")
+	return ctrl.Result{}, nil
+}
+
+// restoreBootstrapSecret sets the regenerated bootstrap data secret back on the host, and has the host agent
+// retry the bootstrap right away rather than after the backoff of its failures
+func (r *ByoMachineReconciler) restoreBootstrapSecret(ctx context.Context, machineScope *byoMachineScope) error {
+	hostHelper, err := patch.NewHelper(machineScope.ByoHost, r.Client)
+	if err != nil {
+		return err
+	}
+	machineScope.ByoHost.Spec.BootstrapSecret = &corev1.ObjectReference{
+		Kind:      "Secret",
+		Namespace: machineScope.ByoMachine.Namespace,
+		Name:      *machineScope.Machine.Spec.Bootstrap.DataSecretName,
+	}
+	if machineScope.ByoHost.Annotations == nil {
+		machineScope.ByoHost.Annotations = make(map[string]string)
+	}
+	machineScope.ByoHost.Annotations[infrav1.RetryAnnotation] = ""
+	return hostHelper.Patch(ctx, machineScope.ByoHost)
+}
@@ -0,0 +1,255 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/common/agentrbac"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	bootstrapapi "k8s.io/cluster-bootstrap/token/api"
+	bootstraputil "k8s.io/cluster-bootstrap/token/util"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// DefaultBootstrapTokenTTL is how long the bootstrap credentials are valid for when not set
+	DefaultBootstrapTokenTTL = 30 * time.Minute
+	// BootstrapTokenExtraGroup is the group of the bootstrap tokens, it is only allowed to create CSRs
+	BootstrapTokenExtraGroup = "system:bootstrappers:byoh"
+	// BootstrapTokenNamespaceGroupPrefix prefixes the group recording the namespace of the BootstrapKubeconfig
+	BootstrapTokenNamespaceGroupPrefix = BootstrapTokenExtraGroup + ":"
+	// bootstrapKubeconfigUserName is the name of the user in the minted kubeconfig
+	bootstrapKubeconfigUserName = "byoh-bootstrap"
+	// bootstrapKubeconfigContextName is the name of the context in the minted kubeconfig
+	bootstrapKubeconfigContextName = "byoh-bootstrap@byoh"
+	// agentServiceAccountPrefix prefixes the ServiceAccount of a namespace-scoped agent kubeconfig
+	agentServiceAccountPrefix = "byoh-agent-"
+	// agentKubeconfigUserName is the name of the user in the minted namespace-scoped agent kubeconfig
+	agentKubeconfigUserName = "byoh-agent"
+	// agentKubeconfigContextName is the name of the context in the minted namespace-scoped agent kubeconfig
+	agentKubeconfigContextName = "byoh-agent@byoh"
+	// agentTokenPollInterval is how often the token of the agent ServiceAccount is checked until it is issued
+	agentTokenPollInterval = 2 * time.Second
+)
+
+// BootstrapKubeconfigReconciler reconciles a BootstrapKubeconfig object
+type BootstrapKubeconfigReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=bootstrapkubeconfigs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=bootstrapkubeconfigs/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=bootstrapkubeconfigs/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles;rolebindings,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile mints a bootstrap token and the kubeconfig using it for the BootstrapKubeconfig, or the
+// namespace-scoped agent kubeconfig when requested. The credentials are minted once, a new
+// BootstrapKubeconfig is needed once they expire.
+func (r *BootstrapKubeconfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	logger := log.FromContext(ctx)
+
+	bootstrapKubeconfig := &infrav1.BootstrapKubeconfig{}
+	if err := r.Client.Get(ctx, req.NamespacedName, bootstrapKubeconfig); err != nil {
+		if apierrors.IsNotFound(err) {
+			logger.V(4).Info("BootstrapKubeconfig not found, won't reconcile", "key", req.NamespacedName)
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	if bootstrapKubeconfig.Status.BootstrapKubeconfigData != nil || bootstrapKubeconfig.Status.AgentKubeconfigData != nil {
+		return reconcile.Result{}, nil
+	}
+
+	helper, err := patch.NewHelper(bootstrapKubeconfig, r.Client)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrapf(err,
+			"failed to init patch helper for %s/%s", bootstrapKubeconfig.Namespace, bootstrapKubeconfig.Name)
+	}
+	defer func() {
+		if err := helper.Patch(ctx, bootstrapKubeconfig); err != nil {
+			logger.Error(err, "failed to patch BootstrapKubeconfig")
+			if reterr == nil {
+				reterr = err
+			}
+		}
+	}()
+
+	if bootstrapKubeconfig.Spec.NamespaceScopedAgent {
+		return r.mintAgentKubeconfig(ctx, bootstrapKubeconfig)
+	}
+
+	ttl := DefaultBootstrapTokenTTL
+	if bootstrapKubeconfig.Spec.TTL != nil {
+		ttl = bootstrapKubeconfig.Spec.TTL.Duration
+	}
+	expiration := metav1.NewTime(time.Now().Add(ttl))
+
+	token, err := r.createBootstrapToken(ctx, bootstrapKubeconfig, expiration)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	kubeconfig, err := kubeconfigData(&bootstrapKubeconfig.Spec, bootstrapKubeconfigUserName, bootstrapKubeconfigContextName, "", token)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	bootstrapKubeconfig.Status.BootstrapKubeconfigData = &kubeconfig
+	bootstrapKubeconfig.Status.ExpirationTime = &expiration
+	logger.Info("minted bootstrap kubeconfig", "expiration", expiration)
+
+	return reconcile.Result{}, nil
+}
+
+// createBootstrapToken creates a bootstrap token secret which expires at the given time
+// and is only part of the BootstrapTokenExtraGroup and of the group of its namespace
+func (r *BootstrapKubeconfigReconciler) createBootstrapToken(ctx context.Context, bootstrapKubeconfig *infrav1.BootstrapKubeconfig, expiration metav1.Time) (string, error) {
+	token, err := bootstraputil.GenerateBootstrapToken()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to generate bootstrap token")
+	}
+	tokenID, tokenSecret := splitBootstrapToken(token)
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      bootstraputil.BootstrapTokenSecretName(tokenID),
+			Namespace: metav1.NamespaceSystem,
+		},
+		Type: bootstrapapi.SecretTypeBootstrapToken,
+		StringData: map[string]string{
+			bootstrapapi.BootstrapTokenIDKey:               tokenID,
+			bootstrapapi.BootstrapTokenSecretKey:           tokenSecret,
+			bootstrapapi.BootstrapTokenExpirationKey:       expiration.UTC().Format(time.RFC3339),
+			bootstrapapi.BootstrapTokenUsageAuthentication: "true",
+			bootstrapapi.BootstrapTokenExtraGroupsKey:      BootstrapTokenExtraGroup + "," + BootstrapTokenNamespaceGroupPrefix + bootstrapKubeconfig.Namespace,
+			bootstrapapi.BootstrapTokenDescriptionKey: fmt.Sprintf("Bootstrap token for BootstrapKubeconfig %s/%s",
+				bootstrapKubeconfig.Namespace, bootstrapKubeconfig.Name),
+		},
+	}
+	if err := r.Client.Create(ctx, secret); err != nil {
+		return "", errors.Wrap(err, "failed to create bootstrap token secret")
+	}
+	return token, nil
+}
+
+// mintAgentKubeconfig grants a ServiceAccount of the namespace the least privileges of the host
+// agents, and renders the kubeconfig authenticating with its token once the token is issued. The
+// ServiceAccount, its Role, RoleBinding and token are owned by the BootstrapKubeconfig.
+func (r *BootstrapKubeconfigReconciler) mintAgentKubeconfig(ctx context.Context, bootstrapKubeconfig *infrav1.BootstrapKubeconfig) (reconcile.Result, error) {
+	logger := log.FromContext(ctx)
+	name := agentServiceAccountPrefix + bootstrapKubeconfig.Name
+	namespace := bootstrapKubeconfig.Namespace
+	setOwner := func(obj client.Object) error {
+		return controllerutil.SetControllerReference(bootstrapKubeconfig, obj, r.Client.Scheme())
+	}
+
+	serviceAccount := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, serviceAccount, func() error {
+		return setOwner(serviceAccount)
+	}); err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to create the agent ServiceAccount")
+	}
+
+	role := &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, role, func() error {
+		role.Rules = agentrbac.NamespaceRules()
+		return setOwner(role)
+	}); err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to create the agent Role")
+	}
+
+	roleBinding := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, roleBinding, func() error {
+		roleBinding.RoleRef = rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: role.Name}
+		roleBinding.Subjects = []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: name, Namespace: namespace}}
+		return setOwner(roleBinding)
+	}); err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to create the agent RoleBinding")
+	}
+
+	// the token of the secret is issued by the token controller of the management cluster
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name + "-token", Namespace: namespace}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		if secret.Annotations == nil {
+			secret.Annotations = map[string]string{}
+		}
+		secret.Annotations[corev1.ServiceAccountNameKey] = name
+		secret.Type = corev1.SecretTypeServiceAccountToken
+		return setOwner(secret)
+	}); err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to create the agent ServiceAccount token")
+	}
+	token := secret.Data[corev1.ServiceAccountTokenKey]
+	if len(token) == 0 {
+		logger.Info("waiting for the token of the agent ServiceAccount", "serviceAccount", name)
+		return reconcile.Result{RequeueAfter: agentTokenPollInterval}, nil
+	}
+
+	kubeconfig, err := kubeconfigData(&bootstrapKubeconfig.Spec, agentKubeconfigUserName, agentKubeconfigContextName, namespace, string(token))
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	bootstrapKubeconfig.Status.AgentKubeconfigData = &kubeconfig
+	bootstrapKubeconfig.Status.ServiceAccountName = name
+	logger.Info("minted namespace-scoped agent kubeconfig", "serviceAccount", name)
+	return reconcile.Result{}, nil
+}
+
+// kubeconfigData renders the kubeconfig authenticating with the token, defaulting to the namespace when set
+func kubeconfigData(spec *infrav1.BootstrapKubeconfigSpec, userName, contextName, namespace, token string) (string, error) {
+	config := clientcmdapi.NewConfig()
+	config.Clusters[infrav1.GroupVersion.Group] = &clientcmdapi.Cluster{
+		Server:                   spec.APIServer,
+		CertificateAuthorityData: []byte(spec.CertificateAuthorityData),
+		InsecureSkipTLSVerify:    spec.InsecureSkipTLSVerify,
+	}
+	config.AuthInfos[userName] = &clientcmdapi.AuthInfo{
+		Token: token,
+	}
+	config.Contexts[contextName] = &clientcmdapi.Context{
+		Cluster:   infrav1.GroupVersion.Group,
+		AuthInfo:  userName,
+		Namespace: namespace,
+	}
+	config.CurrentContext = contextName
+
+	data, err := clientcmd.Write(*config)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to serialize kubeconfig")
+	}
+	return string(data), nil
+}
+
+// splitBootstrapToken splits a "<id>.<secret>" bootstrap token
+func splitBootstrapToken(token string) (tokenID, tokenSecret string) {
+	parts := strings.SplitN(token, ".", 2)
+	return parts[0], parts[1]
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *BootstrapKubeconfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&infrav1.BootstrapKubeconfig{}).
+		Complete(r)
+}
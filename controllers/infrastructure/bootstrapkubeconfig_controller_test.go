@@ -0,0 +1,165 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers_test
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/common/agentrbac"
+	controllers "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/controllers/infrastructure"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/test/builder"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	bootstrapapi "k8s.io/cluster-bootstrap/token/api"
+	bootstraputil "k8s.io/cluster-bootstrap/token/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("Controllers/BootstrapKubeconfigController", func() {
+	var (
+		ctx                          context.Context
+		k8sClientUncached            client.Client
+		bootstrapKubeconfig          *infrav1.BootstrapKubeconfig
+		bootstrapKubeconfigLookupKey types.NamespacedName
+		testAPIServer                = "https://1.2.3.4:6443"
+		testCAData                   = "test-ca-data"
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		var clientErr error
+		k8sClientUncached, clientErr = client.New(cfg, client.Options{Scheme: scheme.Scheme})
+		Expect(clientErr).NotTo(HaveOccurred())
+
+		bootstrapKubeconfig = builder.BootstrapKubeconfig(defaultNamespace, "my-bootstrap-kubeconfig").
+			WithServer(testAPIServer, testCAData).
+			WithTTL(10 * time.Minute).
+			Build()
+		Expect(k8sClientUncached.Create(ctx, bootstrapKubeconfig)).Should(Succeed())
+		WaitForObjectsToBePopulatedInCache(bootstrapKubeconfig)
+		bootstrapKubeconfigLookupKey = types.NamespacedName{Name: bootstrapKubeconfig.Name, Namespace: bootstrapKubeconfig.Namespace}
+	})
+
+	AfterEach(func() {
+		Expect(k8sClientUncached.Delete(ctx, bootstrapKubeconfig)).Should(Succeed())
+	})
+
+	It("should ignore BootstrapKubeconfig if it is not found", func() {
+		_, err := bootstrapKubeconfigReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      "non-existent-bootstrap-kubeconfig",
+				Namespace: defaultNamespace,
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should mint a bootstrap kubeconfig with an expiring bootstrap token", func() {
+		_, err := bootstrapKubeconfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: bootstrapKubeconfigLookupKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		updated := &infrav1.BootstrapKubeconfig{}
+		Expect(k8sClientUncached.Get(ctx, bootstrapKubeconfigLookupKey, updated)).Should(Succeed())
+		Expect(updated.Status.BootstrapKubeconfigData).NotTo(BeNil())
+		Expect(updated.Status.ExpirationTime).NotTo(BeNil())
+		Expect(updated.Status.ExpirationTime.Time).To(BeTemporally("~", time.Now().Add(10*time.Minute), time.Minute))
+
+		config, err := clientcmd.Load([]byte(*updated.Status.BootstrapKubeconfigData))
+		Expect(err).NotTo(HaveOccurred())
+		cluster := config.Clusters[config.Contexts[config.CurrentContext].Cluster]
+		Expect(cluster.Server).To(Equal(testAPIServer))
+		Expect(string(cluster.CertificateAuthorityData)).To(Equal(testCAData))
+		token := config.AuthInfos[config.Contexts[config.CurrentContext].AuthInfo].Token
+		Expect(bootstraputil.IsValidBootstrapToken(token)).To(BeTrue())
+
+		tokenSecret := &corev1.Secret{}
+		tokenID := strings.Split(token, ".")[0]
+		Expect(k8sClientUncached.Get(ctx, types.NamespacedName{
+			Name:      bootstraputil.BootstrapTokenSecretName(tokenID),
+			Namespace: metav1.NamespaceSystem,
+		}, tokenSecret)).Should(Succeed())
+		Expect(tokenSecret.Type).To(Equal(bootstrapapi.SecretTypeBootstrapToken))
+		Expect(string(tokenSecret.Data[bootstrapapi.BootstrapTokenExtraGroupsKey])).To(Equal(
+			controllers.BootstrapTokenExtraGroup + "," + controllers.BootstrapTokenNamespaceGroupPrefix + defaultNamespace))
+		Expect(string(tokenSecret.Data[bootstrapapi.BootstrapTokenUsageAuthentication])).To(Equal("true"))
+		Expect(string(tokenSecret.Data[bootstrapapi.BootstrapTokenExpirationKey])).To(Equal(updated.Status.ExpirationTime.UTC().Format(time.RFC3339)))
+	})
+
+	It("should not mint the bootstrap kubeconfig again", func() {
+		_, err := bootstrapKubeconfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: bootstrapKubeconfigLookupKey})
+		Expect(err).NotTo(HaveOccurred())
+		minted := &infrav1.BootstrapKubeconfig{}
+		Expect(k8sClientUncached.Get(ctx, bootstrapKubeconfigLookupKey, minted)).Should(Succeed())
+		WaitForObjectToBeUpdatedInCache(minted, func(object client.Object) bool {
+			return object.(*infrav1.BootstrapKubeconfig).Status.BootstrapKubeconfigData != nil
+		})
+
+		_, err = bootstrapKubeconfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: bootstrapKubeconfigLookupKey})
+		Expect(err).NotTo(HaveOccurred())
+		updated := &infrav1.BootstrapKubeconfig{}
+		Expect(k8sClientUncached.Get(ctx, bootstrapKubeconfigLookupKey, updated)).Should(Succeed())
+		Expect(*updated.Status.BootstrapKubeconfigData).To(Equal(*minted.Status.BootstrapKubeconfigData))
+	})
+
+	It("should mint a namespace-scoped agent kubeconfig with the least privileges of the host agents", func() {
+		agentKubeconfig := builder.BootstrapKubeconfig(defaultNamespace, "agent-kubeconfig").
+			WithServer(testAPIServer, testCAData).
+			WithNamespaceScopedAgent().
+			Build()
+		Expect(k8sClientUncached.Create(ctx, agentKubeconfig)).Should(Succeed())
+		defer func() {
+			Expect(k8sClientUncached.Delete(ctx, agentKubeconfig)).Should(Succeed())
+		}()
+		agentKubeconfigKey := types.NamespacedName{Name: agentKubeconfig.Name, Namespace: defaultNamespace}
+		agentKey := types.NamespacedName{Name: "byoh-agent-" + agentKubeconfig.Name, Namespace: defaultNamespace}
+		WaitForObjectsToBePopulatedInCache(agentKubeconfig)
+
+		result, err := bootstrapKubeconfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: agentKubeconfigKey})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).NotTo(BeZero())
+
+		Expect(k8sClientUncached.Get(ctx, agentKey, &corev1.ServiceAccount{})).Should(Succeed())
+		role := &rbacv1.Role{}
+		Expect(k8sClientUncached.Get(ctx, agentKey, role)).Should(Succeed())
+		Expect(role.Rules).To(Equal(agentrbac.NamespaceRules()))
+		roleBinding := &rbacv1.RoleBinding{}
+		Expect(k8sClientUncached.Get(ctx, agentKey, roleBinding)).Should(Succeed())
+		Expect(roleBinding.Subjects).To(Equal([]rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: agentKey.Name, Namespace: defaultNamespace}}))
+
+		// envtest runs no token controller, the token is issued by the test
+		tokenSecret := &corev1.Secret{}
+		Expect(k8sClientUncached.Get(ctx, types.NamespacedName{Name: agentKey.Name + "-token", Namespace: defaultNamespace}, tokenSecret)).Should(Succeed())
+		Expect(tokenSecret.Type).To(Equal(corev1.SecretTypeServiceAccountToken))
+		Expect(tokenSecret.Annotations).To(HaveKeyWithValue(corev1.ServiceAccountNameKey, agentKey.Name))
+		tokenSecret.Data = map[string][]byte{corev1.ServiceAccountTokenKey: []byte("agent-token")}
+		Expect(k8sClientUncached.Update(ctx, tokenSecret)).Should(Succeed())
+
+		updated := &infrav1.BootstrapKubeconfig{}
+		Eventually(func() *string {
+			_, _ = bootstrapKubeconfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: agentKubeconfigKey})
+			Expect(k8sClientUncached.Get(ctx, agentKubeconfigKey, updated)).Should(Succeed())
+			return updated.Status.AgentKubeconfigData
+		}).ShouldNot(BeNil())
+		Expect(updated.Status.BootstrapKubeconfigData).To(BeNil())
+		Expect(updated.Status.ServiceAccountName).To(Equal(agentKey.Name))
+
+		config, err := clientcmd.Load([]byte(*updated.Status.AgentKubeconfigData))
+		Expect(err).NotTo(HaveOccurred())
+		currentContext := config.Contexts[config.CurrentContext]
+		Expect(currentContext.Namespace).To(Equal(defaultNamespace))
+		Expect(config.Clusters[currentContext.Cluster].Server).To(Equal(testAPIServer))
+		Expect(config.AuthInfos[currentContext.AuthInfo].Token).To(Equal("agent-token"))
+	})
+})
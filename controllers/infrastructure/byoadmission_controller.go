@@ -5,27 +5,51 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"strings"
+	"time"
 
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
 	certv1 "k8s.io/api/certificates/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clientset "k8s.io/client-go/kubernetes"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
-// ByoAdmissionReconciler reconciles a ByoAdmission object
+const (
+	// hostCommonNamePrefix prefixes the name of the host in the common name of its CSR
+	hostCommonNamePrefix = "byoh:host:"
+	// hostQuotaRequeueAfter is how often a CSR over the host quota of its namespace is checked again,
+	// as the deletion of the ByoHosts does not trigger its reconcile
+	hostQuotaRequeueAfter = time.Minute
+)
+
+// ByoAdmissionReconciler approves the host CSRs, and registers the ByoHost of the hosts approved
 type ByoAdmissionReconciler struct {
+	// Client creates the ByoHosts of the hosts approved
+	Client    client.Client
 	ClientSet clientset.Interface
+	// Policy restricts the CSRs approved, all the BYOH CSRs are approved when nil
+	Policy *CSRApprovalPolicy
 }
 
-//+kubebuilder:rbac:groups=certificates.k8s.io,resources=certificatesigningrequests,verbs=create;get;list;watch
+//+kubebuilder:rbac:groups=certificates.k8s.io,resources=certificatesigningrequests,verbs=create;get;list;watch;update
+//+kubebuilder:rbac:groups=certificates.k8s.io,resources=certificatesigningrequests/approval,verbs=update
+//+kubebuilder:rbac:groups=certificates.k8s.io,resources=signers,resourceNames=kubernetes.io/kube-apiserver-client,verbs=approve
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byohosts,verbs=get;list;watch;create
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byohostquotas,verbs=get;list;watch
 
-// Reconcile continuosuly checks for CSRs and approves them
+// Reconcile approves the pending host CSRs allowed by the approval policy, and denies those annotated
+// to be denied. Once a CSR is approved, by the reconciler or manually, the ByoHost of the host is created
+// in the namespace the CSR was requested from. The annotations of the CSR being set by its requester
+// too, none of them approves it: the CSRs not allowed by the policy are approved with the approval
+// subresource, e.g. with kubectl certificate approve.
 func (r *ByoAdmissionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	var err error
 	logger := log.FromContext(ctx)
@@ -44,14 +68,48 @@ func (r *ByoAdmissionReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	// Check if the CSR is already approved or denied
 	csrApproved := checkCSRCondition(csr.Status.Conditions, certv1.CertificateApproved)
 	csrDenied := checkCSRCondition(csr.Status.Conditions, certv1.CertificateDenied)
-	if csrApproved || csrDenied {
-		if csrApproved {
-			logger.Info("CertificateSigningRequest is already approved", "CSR", csr.Name)
+	if csrDenied {
+		logger.Info("CertificateSigningRequest is already denied", "CSR", csr.Name)
+		return ctrl.Result{}, nil
+	}
+	if csrApproved {
+		logger.Info("CertificateSigningRequest is already approved", "CSR", csr.Name)
+		return ctrl.Result{}, r.registerHost(ctx, csr)
+	}
+
+	if message, ok := csr.Annotations[infrav1.DenyAnnotation]; ok {
+		csr.Status.Conditions = append(csr.Status.Conditions, certv1.CertificateSigningRequestCondition{
+			Type:    certv1.CertificateDenied,
+			Reason:  "Denied by annotation",
+			Message: message,
+		})
+		logger.Info("Denying CSR", "object", req.NamespacedName)
+		_, err = r.ClientSet.CertificatesV1().CertificateSigningRequests().UpdateApproval(ctx, csr.Name, csr, metav1.UpdateOptions{})
+		return ctrl.Result{}, err
+	}
+
+	if r.Policy != nil {
+		reason, err := r.Policy.Admit(csr)
+		if err != nil {
+			return reconcile.Result{}, err
 		}
-		if csrDenied {
-			logger.Info("CertificateSigningRequest is already denied", "CSR", csr.Name)
+		if reason == "" {
+			if reason, err = r.checkHostQuota(ctx, csr); err != nil {
+				return reconcile.Result{}, err
+			}
+			if reason != "" {
+				logger.Info("CertificateSigningRequest over the host quota, leaving it for manual approval", "CSR", csr.Name, "reason", reason)
+				return reconcile.Result{RequeueAfter: hostQuotaRequeueAfter}, nil
+			}
+		}
+		if reason != "" {
+			logger.Info("CertificateSigningRequest not allowed by the approval policy, leaving it for manual approval", "CSR", csr.Name, "reason", reason)
+			return reconcile.Result{}, nil
+		}
+		if wait := r.Policy.ReserveApproval(time.Now()); wait > 0 {
+			logger.Info("Hourly CSR approval limit reached, delaying approval", "CSR", csr.Name, "after", wait)
+			return reconcile.Result{RequeueAfter: wait}, nil
 		}
-		return ctrl.Result{}, nil
 	}
 
 	// Update the CSR to the "Approved" condition
@@ -62,14 +120,80 @@ func (r *ByoAdmissionReconciler) Reconcile(ctx context.Context, req ctrl.Request
 
 	// Approve the CSR
 	logger.Info("Approving CSR", "object", req.NamespacedName)
-	_, err = r.ClientSet.CertificatesV1().CertificateSigningRequests().UpdateApproval(ctx, csr.Name, csr, metav1.UpdateOptions{})
+	csr, err = r.ClientSet.CertificatesV1().CertificateSigningRequests().UpdateApproval(ctx, csr.Name, csr, metav1.UpdateOptions{})
 	if err != nil {
 		return reconcile.Result{}, err
 	}
 
 	logger.Info("CSR Approved", "object", req.NamespacedName)
 
-	return ctrl.Result{}, nil
+	return ctrl.Result{}, r.registerHost(ctx, csr)
+}
+
+// checkHostQuota returns why the namespace the CSR registers its host in, or the bootstrap token
+// it is requested with, is over its host quota, or an empty string when it is not
+func (r *ByoAdmissionReconciler) checkHostQuota(ctx context.Context, csr *certv1.CertificateSigningRequest) (string, error) {
+	namespace := csrHostNamespace(csr)
+	reason, err := infrav1.CheckByoHostQuota(ctx, r.Client, namespace, csrBootstrapTokenID(csr))
+	if err != nil || reason != "" {
+		return reason, err
+	}
+	if r.Policy.MaxHostsPerNamespace <= 0 {
+		return "", nil
+	}
+	hosts := &infrav1.ByoHostList{}
+	if err := r.Client.List(ctx, hosts, client.InNamespace(namespace)); err != nil {
+		return "", err
+	}
+	if len(hosts.Items) >= r.Policy.MaxHostsPerNamespace {
+		return fmt.Sprintf("namespace %q has reached its quota of %d hosts", namespace, r.Policy.MaxHostsPerNamespace), nil
+	}
+	return "", nil
+}
+
+// registerHost creates the ByoHost of the host of an approved CSR, in the namespace the CSR was
+// requested from. The CSR is annotated once the ByoHost is created, so that a ByoHost deleted
+// afterwards, e.g. on decommission, is not created again.
+func (r *ByoAdmissionReconciler) registerHost(ctx context.Context, csr *certv1.CertificateSigningRequest) error {
+	logger := log.FromContext(ctx)
+	if _, ok := csr.Annotations[infrav1.HostRegisteredAnnotation]; ok {
+		return nil
+	}
+	commonName, err := csrCommonName(csr)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(commonName, hostCommonNamePrefix) {
+		logger.Info("CertificateSigningRequest is not requested by a host, no ByoHost registered", "CSR", csr.Name, "commonName", commonName)
+		return nil
+	}
+	// the CSRs approved manually register the hosts allowed by the common name pattern only
+	if r.Policy != nil && r.Policy.CommonNamePattern != nil && !r.Policy.CommonNamePattern.MatchString(commonName) {
+		logger.Info("CertificateSigningRequest common name does not match the approval policy, no ByoHost registered", "CSR", csr.Name,
+			"commonName", commonName, "pattern", r.Policy.CommonNamePattern.String())
+		return nil
+	}
+
+	byoHost := &infrav1.ByoHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      strings.TrimPrefix(commonName, hostCommonNamePrefix),
+			Namespace: csrHostNamespace(csr),
+		},
+	}
+	if tokenID := csrBootstrapTokenID(csr); tokenID != "" {
+		byoHost.Labels = map[string]string{infrav1.BootstrapTokenLabel: tokenID}
+	}
+	if err := r.Client.Create(ctx, byoHost); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	logger.Info("ByoHost registered", "CSR", csr.Name, "ByoHost", byoHost.Namespace+"/"+byoHost.Name)
+
+	if csr.Annotations == nil {
+		csr.Annotations = map[string]string{}
+	}
+	csr.Annotations[infrav1.HostRegisteredAnnotation] = byoHost.Namespace + "/" + byoHost.Name
+	_, err = r.ClientSet.CertificatesV1().CertificateSigningRequests().Update(ctx, csr, metav1.UpdateOptions{})
+	return err
 }
 
 // Check if the CSR has the given condition.
@@ -5,13 +5,21 @@ package controllers_test
 
 import (
 	"context"
+	"regexp"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	controllers "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/controllers/infrastructure"
 	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/test/builder"
 	certv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -94,4 +102,284 @@ var _ = Describe("Controllers/ByoadmissionController", func() {
 
 	})
 
+	Context("When an approval policy is set", func() {
+		var (
+			policy              *controllers.CSRApprovalPolicy
+			policyReconciler    *controllers.ByoAdmissionReconciler
+			hostClient          client.Client
+			namespaceGroup      = controllers.BootstrapTokenNamespaceGroupPrefix + defaultNamespace
+			isApproved          func(name string) bool
+			createCSRWithPolicy func(name, cn string) *certv1.CertificateSigningRequest
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			policy = &controllers.CSRApprovalPolicy{
+				CommonNamePattern: regexp.MustCompile(controllers.DefaultCSRCommonNamePattern),
+				AllowedNamespaces: []string{defaultNamespace},
+			}
+			hostClient = fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+			policyReconciler = &controllers.ByoAdmissionReconciler{
+				Client:    hostClient,
+				ClientSet: clientSetFake,
+				Policy:    policy,
+			}
+
+			isApproved = func(name string) bool {
+				csr, getErr := clientSetFake.CertificatesV1().CertificateSigningRequests().Get(ctx, name, v1.GetOptions{})
+				Expect(getErr).ToNot(HaveOccurred())
+				for _, condition := range csr.Status.Conditions {
+					if condition.Type == certv1.CertificateApproved {
+						return true
+					}
+				}
+				return false
+			}
+
+			createCSRWithPolicy = func(name, cn string) *certv1.CertificateSigningRequest {
+				csr, buildErr := builder.CertificateSigningRequest(name, cn, "byoh:hosts", 2048).Build()
+				Expect(buildErr).NotTo(HaveOccurred())
+				csr.Spec.Groups = []string{controllers.BootstrapTokenExtraGroup, namespaceGroup}
+				return csr
+			}
+		})
+
+		AfterEach(func() {
+			Expect(clientSetFake.CertificatesV1().CertificateSigningRequests().Delete(ctx, defaultByoHostName, v1.DeleteOptions{})).ShouldNot(HaveOccurred())
+		})
+
+		It("should approve the CSR allowed by the policy", func() {
+			CSR = createCSRWithPolicy(defaultByoHostName, "byoh:host:"+defaultByoHostName)
+			_, err = clientSetFake.CertificatesV1().CertificateSigningRequests().Create(ctx, CSR, v1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = policyReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: defaultByoHostName}})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(isApproved(defaultByoHostName)).To(BeTrue())
+		})
+
+		It("should leave the CSR pending if the common name does not match", func() {
+			CSR = createCSRWithPolicy(defaultByoHostName, "test-cn")
+			_, err = clientSetFake.CertificatesV1().CertificateSigningRequests().Create(ctx, CSR, v1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = policyReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: defaultByoHostName}})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(isApproved(defaultByoHostName)).To(BeFalse())
+		})
+
+		It("should leave the CSR pending if it is requested from a namespace not allowed", func() {
+			CSR = createCSRWithPolicy(defaultByoHostName, "byoh:host:"+defaultByoHostName)
+			CSR.Spec.Groups = []string{controllers.BootstrapTokenExtraGroup, controllers.BootstrapTokenNamespaceGroupPrefix + "other"}
+			_, err = clientSetFake.CertificatesV1().CertificateSigningRequests().Create(ctx, CSR, v1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = policyReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: defaultByoHostName}})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(isApproved(defaultByoHostName)).To(BeFalse())
+		})
+
+		It("should leave the CSR pending if it is requested from a signer not allowed", func() {
+			CSR = createCSRWithPolicy(defaultByoHostName, "byoh:host:"+defaultByoHostName)
+			CSR.Spec.SignerName = "clusterissuers.cert-manager.io/byoh-ca"
+			_, err = clientSetFake.CertificatesV1().CertificateSigningRequests().Create(ctx, CSR, v1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = policyReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: defaultByoHostName}})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(isApproved(defaultByoHostName)).To(BeFalse())
+		})
+
+		It("should approve the CSR requested from a custom signer allowed by the policy", func() {
+			policy.SignerNames = []string{certv1.KubeAPIServerClientSignerName, "clusterissuers.cert-manager.io/byoh-ca"}
+			CSR = createCSRWithPolicy(defaultByoHostName, "byoh:host:"+defaultByoHostName)
+			CSR.Spec.SignerName = "clusterissuers.cert-manager.io/byoh-ca"
+			_, err = clientSetFake.CertificatesV1().CertificateSigningRequests().Create(ctx, CSR, v1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = policyReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: defaultByoHostName}})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(isApproved(defaultByoHostName)).To(BeTrue())
+		})
+
+		It("should only approve attested CSRs when attestation is required", func() {
+			policy.RequireAttestation = true
+			CSR = createCSRWithPolicy(defaultByoHostName, "byoh:host:"+defaultByoHostName)
+			_, err = clientSetFake.CertificatesV1().CertificateSigningRequests().Create(ctx, CSR, v1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = policyReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: defaultByoHostName}})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(isApproved(defaultByoHostName)).To(BeFalse())
+
+			// the attestation service adds the condition through the status subresource
+			CSR, err = clientSetFake.CertificatesV1().CertificateSigningRequests().Get(ctx, defaultByoHostName, v1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			CSR.Status.Conditions = append(CSR.Status.Conditions, certv1.CertificateSigningRequestCondition{
+				Type:   infrav1.HostAttestedCondition,
+				Status: corev1.ConditionTrue,
+				Reason: "HostVerified",
+			})
+			_, err = clientSetFake.CertificatesV1().CertificateSigningRequests().UpdateStatus(ctx, CSR, v1.UpdateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = policyReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: defaultByoHostName}})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(isApproved(defaultByoHostName)).To(BeTrue())
+		})
+
+		It("should not approve the CSR its requester annotated as attested when attestation is required", func() {
+			policy.RequireAttestation = true
+			CSR = createCSRWithPolicy(defaultByoHostName, "byoh:host:"+defaultByoHostName)
+			CSR.Annotations = map[string]string{infrav1.AttestedAnnotation: ""}
+			_, err = clientSetFake.CertificatesV1().CertificateSigningRequests().Create(ctx, CSR, v1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = policyReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: defaultByoHostName}})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(isApproved(defaultByoHostName)).To(BeFalse())
+		})
+
+		It("should register the ByoHost of the approved CSR once", func() {
+			CSR = createCSRWithPolicy(defaultByoHostName, "byoh:host:"+defaultByoHostName)
+			_, err = clientSetFake.CertificatesV1().CertificateSigningRequests().Create(ctx, CSR, v1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = policyReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: defaultByoHostName}})
+			Expect(err).ShouldNot(HaveOccurred())
+			byoHost := &infrav1.ByoHost{}
+			Expect(hostClient.Get(ctx, types.NamespacedName{Name: defaultByoHostName, Namespace: defaultNamespace}, byoHost)).To(Succeed())
+			CSR, err = clientSetFake.CertificatesV1().CertificateSigningRequests().Get(ctx, defaultByoHostName, v1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(CSR.Annotations).To(HaveKeyWithValue(infrav1.HostRegisteredAnnotation, defaultNamespace+"/"+defaultByoHostName))
+
+			// a decommissioned host is not registered again
+			Expect(hostClient.Delete(ctx, byoHost)).To(Succeed())
+			_, err = policyReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: defaultByoHostName}})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(hostClient.Get(ctx, types.NamespacedName{Name: defaultByoHostName, Namespace: defaultNamespace}, byoHost)).NotTo(Succeed())
+		})
+
+		It("should leave the CSR pending once the namespace reached its host quota", func() {
+			policy.MaxHostsPerNamespace = 1
+			Expect(hostClient.Create(ctx, &infrav1.ByoHost{ObjectMeta: v1.ObjectMeta{Name: "other-host", Namespace: defaultNamespace}})).To(Succeed())
+			CSR = createCSRWithPolicy(defaultByoHostName, "byoh:host:"+defaultByoHostName)
+			_, err = clientSetFake.CertificatesV1().CertificateSigningRequests().Create(ctx, CSR, v1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			result, err := policyReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: defaultByoHostName}})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(time.Minute))
+			Expect(isApproved(defaultByoHostName)).To(BeFalse())
+		})
+
+		It("should leave the CSR pending once its bootstrap token reached its ByoHostQuota", func() {
+			maxHosts := int32(1)
+			Expect(hostClient.Create(ctx, &infrav1.ByoHostQuota{
+				ObjectMeta: v1.ObjectMeta{Name: "edge-quota", Namespace: defaultNamespace},
+				Spec:       infrav1.ByoHostQuotaSpec{MaxHostsPerBootstrapToken: &maxHosts},
+			})).To(Succeed())
+			Expect(hostClient.Create(ctx, &infrav1.ByoHost{ObjectMeta: v1.ObjectMeta{
+				Name:      "other-host",
+				Namespace: defaultNamespace,
+				Labels:    map[string]string{infrav1.BootstrapTokenLabel: "abcdef"},
+			}})).To(Succeed())
+			CSR = createCSRWithPolicy(defaultByoHostName, "byoh:host:"+defaultByoHostName)
+			CSR.Spec.Username = "system:bootstrap:abcdef"
+			_, err = clientSetFake.CertificatesV1().CertificateSigningRequests().Create(ctx, CSR, v1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			result, err := policyReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: defaultByoHostName}})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(time.Minute))
+			Expect(isApproved(defaultByoHostName)).To(BeFalse())
+
+			// the CSR is approved once the bootstrap token is back under its quota
+			Expect(hostClient.Delete(ctx, &infrav1.ByoHost{ObjectMeta: v1.ObjectMeta{Name: "other-host", Namespace: defaultNamespace}})).To(Succeed())
+			_, err = policyReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: defaultByoHostName}})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(isApproved(defaultByoHostName)).To(BeTrue())
+			byoHost := &infrav1.ByoHost{}
+			Expect(hostClient.Get(ctx, types.NamespacedName{Name: defaultByoHostName, Namespace: defaultNamespace}, byoHost)).To(Succeed())
+			Expect(byoHost.Labels).To(HaveKeyWithValue(infrav1.BootstrapTokenLabel, "abcdef"))
+		})
+
+		It("should not approve the CSR its requester annotated to be approved", func() {
+			CSR = createCSRWithPolicy(defaultByoHostName, "byoh:host:"+defaultByoHostName)
+			CSR.Spec.Groups = []string{controllers.BootstrapTokenExtraGroup, controllers.BootstrapTokenNamespaceGroupPrefix + "other"}
+			CSR.Annotations = map[string]string{infrav1.ApproveAnnotation: ""}
+			_, err = clientSetFake.CertificatesV1().CertificateSigningRequests().Create(ctx, CSR, v1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = policyReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: defaultByoHostName}})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(isApproved(defaultByoHostName)).To(BeFalse())
+			Expect(hostClient.Get(ctx, types.NamespacedName{Name: defaultByoHostName, Namespace: "other"}, &infrav1.ByoHost{})).NotTo(Succeed())
+		})
+
+		It("should register the ByoHost of the CSR approved manually", func() {
+			CSR = createCSRWithPolicy(defaultByoHostName, "byoh:host:"+defaultByoHostName)
+			CSR.Spec.Groups = []string{controllers.BootstrapTokenExtraGroup, controllers.BootstrapTokenNamespaceGroupPrefix + "other"}
+			CSR.Status.Conditions = []certv1.CertificateSigningRequestCondition{{Type: certv1.CertificateApproved, Reason: "KubectlApprove"}}
+			_, err = clientSetFake.CertificatesV1().CertificateSigningRequests().Create(ctx, CSR, v1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = policyReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: defaultByoHostName}})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(hostClient.Get(ctx, types.NamespacedName{Name: defaultByoHostName, Namespace: "other"}, &infrav1.ByoHost{})).To(Succeed())
+		})
+
+		It("should not register the ByoHost of the CSR approved manually whose common name does not match", func() {
+			policy.CommonNamePattern = regexp.MustCompile(`^byoh:host:edge-.+$`)
+			CSR = createCSRWithPolicy(defaultByoHostName, "byoh:host:"+defaultByoHostName)
+			CSR.Status.Conditions = []certv1.CertificateSigningRequestCondition{{Type: certv1.CertificateApproved, Reason: "KubectlApprove"}}
+			_, err = clientSetFake.CertificatesV1().CertificateSigningRequests().Create(ctx, CSR, v1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = policyReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: defaultByoHostName}})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(hostClient.Get(ctx, types.NamespacedName{Name: defaultByoHostName, Namespace: defaultNamespace}, &infrav1.ByoHost{})).NotTo(Succeed())
+		})
+
+		It("should deny the CSR annotated to be denied", func() {
+			CSR = createCSRWithPolicy(defaultByoHostName, "byoh:host:"+defaultByoHostName)
+			CSR.Annotations = map[string]string{infrav1.DenyAnnotation: "unknown host"}
+			_, err = clientSetFake.CertificatesV1().CertificateSigningRequests().Create(ctx, CSR, v1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = policyReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: defaultByoHostName}})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(isApproved(defaultByoHostName)).To(BeFalse())
+			CSR, err = clientSetFake.CertificatesV1().CertificateSigningRequests().Get(ctx, defaultByoHostName, v1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(CSR.Status.Conditions).To(ContainElement(certv1.CertificateSigningRequestCondition{
+				Type:    certv1.CertificateDenied,
+				Reason:  "Denied by annotation",
+				Message: "unknown host",
+			}))
+			Expect(hostClient.Get(ctx, types.NamespacedName{Name: defaultByoHostName, Namespace: defaultNamespace}, &infrav1.ByoHost{})).NotTo(Succeed())
+		})
+
+		It("should delay the approvals once the hourly limit is reached", func() {
+			policy.MaxApprovalsPerHour = 1
+			otherCSRName := "other-host"
+			for _, name := range []string{defaultByoHostName, otherCSRName} {
+				_, err = clientSetFake.CertificatesV1().CertificateSigningRequests().Create(ctx, createCSRWithPolicy(name, "byoh:host:"+name), v1.CreateOptions{})
+				Expect(err).ToNot(HaveOccurred())
+			}
+			defer func() {
+				Expect(clientSetFake.CertificatesV1().CertificateSigningRequests().Delete(ctx, otherCSRName, v1.DeleteOptions{})).ShouldNot(HaveOccurred())
+			}()
+
+			result, err := policyReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: defaultByoHostName}})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeZero())
+			Expect(isApproved(defaultByoHostName)).To(BeTrue())
+
+			result, err = policyReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: otherCSRName}})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically(">", 59*time.Minute))
+			Expect(isApproved(otherCSRName)).To(BeFalse())
+		})
+	})
 })
@@ -0,0 +1,171 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	certv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// DefaultCSRCommonNamePattern is the CN pattern of the CSRs created by the host agent
+	DefaultCSRCommonNamePattern = `^byoh:host:.+$`
+
+	serviceAccountUserPrefix = "system:serviceaccount:"
+	bootstrapTokenUserPrefix = "system:bootstrap:"
+	approvalRateWindow       = time.Hour
+)
+
+// CSRApprovalPolicy restricts the CSRs approved by the ByoAdmissionReconciler,
+// the CSRs not matching it are left pending for a manual approval
+type CSRApprovalPolicy struct {
+	// CommonNamePattern is the pattern the common name of the CSR has to match, any when nil
+	CommonNamePattern *regexp.Regexp
+	// AllowedNamespaces are the namespaces the requester of the CSR has to come from, any when empty
+	AllowedNamespaces []string
+	// MaxApprovalsPerHour caps the number of CSRs approved in the last hour, unlimited when 0
+	MaxApprovalsPerHour int
+	// RequireAttestation only approves the CSRs whose HostAttestedCondition is true
+	RequireAttestation bool
+	// MaxHostsPerNamespace caps the number of ByoHosts of the namespace the hosts are registered in,
+	// unlimited when 0
+	MaxHostsPerNamespace int
+	// SignerNames are the signers the CSR may be requested from, e.g. the signer of an internal CA
+	// issuing the host client certificates, kubernetes.io/kube-apiserver-client only when empty
+	SignerNames []string
+
+	mu        sync.Mutex
+	approvals []time.Time
+}
+
+// Admit returns why the CSR is not allowed by the policy, or an empty string when it is
+func (p *CSRApprovalPolicy) Admit(csr *certv1.CertificateSigningRequest) (string, error) {
+	signerNames := p.SignerNames
+	if len(signerNames) == 0 {
+		signerNames = []string{certv1.KubeAPIServerClientSignerName}
+	}
+	if !containsString(signerNames, csr.Spec.SignerName) {
+		return fmt.Sprintf("signer %q is not allowed", csr.Spec.SignerName), nil
+	}
+
+	if p.CommonNamePattern != nil {
+		commonName, err := csrCommonName(csr)
+		if err != nil {
+			return "", err
+		}
+		if !p.CommonNamePattern.MatchString(commonName) {
+			return fmt.Sprintf("common name %q does not match %q", commonName, p.CommonNamePattern), nil
+		}
+	}
+
+	if len(p.AllowedNamespaces) > 0 {
+		namespace := csrRequesterNamespace(csr)
+		if !containsString(p.AllowedNamespaces, namespace) {
+			return fmt.Sprintf("requester namespace %q is not allowed", namespace), nil
+		}
+	}
+
+	if p.RequireAttestation && !csrAttested(csr) {
+		return "host is not attested", nil
+	}
+	return "", nil
+}
+
+// csrAttested tells if an attestation service verified the host of the CSR. The attestation is read from
+// the status of the CSR, its annotations are set by the requester.
+func csrAttested(csr *certv1.CertificateSigningRequest) bool {
+	for _, condition := range csr.Status.Conditions {
+		if condition.Type == infrav1.HostAttestedCondition && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// ReserveApproval records an approval, or returns how long to wait when the hourly
+// limit is reached
+func (p *CSRApprovalPolicy) ReserveApproval(now time.Time) time.Duration {
+	if p.MaxApprovalsPerHour <= 0 {
+		return 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	recent := p.approvals[:0]
+	for _, approval := range p.approvals {
+		if now.Sub(approval) < approvalRateWindow {
+			recent = append(recent, approval)
+		}
+	}
+	p.approvals = recent
+
+	if len(p.approvals) >= p.MaxApprovalsPerHour {
+		return p.approvals[0].Add(approvalRateWindow).Sub(now)
+	}
+	p.approvals = append(p.approvals, now)
+	return 0
+}
+
+// csrCommonName returns the common name of the certificate requested by the CSR
+func csrCommonName(csr *certv1.CertificateSigningRequest) (string, error) {
+	block, _ := pem.Decode(csr.Spec.Request)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return "", fmt.Errorf("CSR %s does not contain a PEM encoded certificate request", csr.Name)
+	}
+	request, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return "", err
+	}
+	return request.Subject.CommonName, nil
+}
+
+// csrRequesterNamespace returns the namespace of the BootstrapKubeconfig or of the service
+// account the CSR was requested with
+func csrRequesterNamespace(csr *certv1.CertificateSigningRequest) string {
+	for _, group := range csr.Spec.Groups {
+		if strings.HasPrefix(group, BootstrapTokenNamespaceGroupPrefix) {
+			return strings.TrimPrefix(group, BootstrapTokenNamespaceGroupPrefix)
+		}
+	}
+	if strings.HasPrefix(csr.Spec.Username, serviceAccountUserPrefix) {
+		return strings.SplitN(strings.TrimPrefix(csr.Spec.Username, serviceAccountUserPrefix), ":", 2)[0]
+	}
+	return ""
+}
+
+// csrHostNamespace returns the namespace the host of the CSR is registered in, the namespace the
+// CSR was requested from, "default" when unknown
+func csrHostNamespace(csr *certv1.CertificateSigningRequest) string {
+	if namespace := csrRequesterNamespace(csr); namespace != "" {
+		return namespace
+	}
+	return "default"
+}
+
+// csrBootstrapTokenID returns the ID of the bootstrap token the CSR was requested with, empty when
+// not requested with a bootstrap token
+func csrBootstrapTokenID(csr *certv1.CertificateSigningRequest) string {
+	if strings.HasPrefix(csr.Spec.Username, bootstrapTokenUserPrefix) {
+		return strings.TrimPrefix(csr.Spec.Username, bootstrapTokenUserPrefix)
+	}
+	return ""
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -8,8 +8,10 @@ import (
 	"reflect"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -40,12 +42,21 @@ var (
 type ByoClusterReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// EndpointProviders manage the control plane endpoints of the ByoClusters,
+	// defaults to DefaultControlPlaneEndpointProviders
+	EndpointProviders []ControlPlaneEndpointProvider
 }
 
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byoclusters,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byoclusters/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byoclusters/finalizers,verbs=update
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byomachines,verbs=get;list;watch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byohosts,verbs=get;list;watch
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
 
 // Reconcile handles the byo cluster reconciliations
 func (r *ByoClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
@@ -104,13 +115,14 @@ func (r *ByoClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	}
 
 	// Handle non-deleted clusters
-	return r.reconcileNormal(ctx, byoCluster)
+	return r.reconcileNormal(ctx, cluster, byoCluster)
 }
 
 func patchByoCluster(ctx context.Context, patchHelper *patch.Helper, byoCluster *infrav1.ByoCluster) error {
 	// Always update the readyCondition by summarizing the state of other conditions.
 	// A step counter is added to represent progress during the provisioning process (instead we are hiding it during the deletion process).
 	conditions.SetSummary(byoCluster,
+		conditions.WithConditions(readySummaryConditions(byoCluster)...),
 		conditions.WithStepCounterIf(byoCluster.ObjectMeta.DeletionTimestamp.IsZero()),
 	)
 
@@ -120,7 +132,11 @@ func patchByoCluster(ctx context.Context, patchHelper *patch.Helper, byoCluster
 		byoCluster,
 		patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{
 			clusterv1.ReadyCondition,
+			infrav1.ControlPlaneEndpointVIPReady,
+			infrav1.ControlPlaneEndpointLoadBalancerReady,
+			infrav1.FleetHealthy,
 		}},
+		patch.WithStatusObservedGeneration{},
 	)
 }
 
@@ -167,7 +183,7 @@ func (r ByoClusterReconciler) reconcileDelete(ctx context.Context, byoCluster *i
 	return ctrl.Result{}, nil
 }
 
-func (r ByoClusterReconciler) reconcileNormal(ctx context.Context, byoCluster *infrav1.ByoCluster) (reconcile.Result, error) {
+func (r ByoClusterReconciler) reconcileNormal(ctx context.Context, cluster *clusterv1.Cluster, byoCluster *infrav1.ByoCluster) (reconcile.Result, error) {
 	// If the ByoCluster doesn't have our finalizer, add it.
 	controllerutil.AddFinalizer(byoCluster, infrav1.ClusterFinalizer)
 
@@ -177,7 +193,15 @@ func (r ByoClusterReconciler) reconcileNormal(ctx context.Context, byoCluster *i
 
 	byoCluster.Status.Ready = true
 
-	return reconcile.Result{}, nil
+	if err := r.reconcileFleetStatus(ctx, cluster, byoCluster); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := r.reconcileFailureDomains(ctx, byoCluster); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return r.reconcileControlPlaneEndpoint(ctx, cluster, byoCluster)
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -190,5 +214,34 @@ func (r *ByoClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			&source.Kind{Type: &clusterv1.Cluster{}},
 			handler.EnqueueRequestsFromMapFunc(clusterutilv1.ClusterToInfrastructureMapFunc(infrav1.GroupVersion.WithKind(clusterControlledTypeGVK.Kind))),
 		).
+		// Watch the machines, backing the control plane endpoint and counted in the fleet status,
+		// and the hosts attached to the cluster.
+		Watches(
+			&source.Kind{Type: &infrav1.ByoMachine{}},
+			handler.EnqueueRequestsFromMapFunc(r.clusterObjectToByoCluster),
+		).
+		Watches(
+			&source.Kind{Type: &infrav1.ByoHost{}},
+			handler.EnqueueRequestsFromMapFunc(r.clusterObjectToByoCluster),
+		).
+		// Watch the hosts labelled with the failure domain label of a cluster.
+		Watches(
+			&source.Kind{Type: &infrav1.ByoHost{}},
+			handler.EnqueueRequestsFromMapFunc(r.byoHostToFailureDomainByoClusters),
+		).
+		Owns(&corev1.ConfigMap{}).
 		Complete(r)
 }
+
+// clusterObjectToByoCluster maps a ByoMachine, or a ByoHost attached to a cluster, to the ByoCluster of its cluster
+func (r *ByoClusterReconciler) clusterObjectToByoCluster(o client.Object) []reconcile.Request {
+	clusterName, ok := o.GetLabels()[clusterv1.ClusterLabelName]
+	if !ok {
+		return nil
+	}
+	cluster := &clusterv1.Cluster{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: o.GetNamespace(), Name: clusterName}, cluster); err != nil {
+		return nil
+	}
+	return clusterutilv1.ClusterToInfrastructureMapFunc(clusterControlledTypeGVK)(cluster)
+}
@@ -6,15 +6,25 @@ package controllers_test
 import (
 	"context"
 	"fmt"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
 	controllers "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/controllers/infrastructure"
 	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/test/builder"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/kubeconfig"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/secret"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -135,4 +145,312 @@ var _ = Describe("Controllers/ByoclusterController", func() {
 		Expect(createdByoCluster.Spec.ControlPlaneEndpoint.Port).To(Equal(int32(controllers.DefaultAPIEndpointPort)))
 	})
 
+	Context("When the ByoCluster manages its control plane endpoint with kube-vip", func() {
+		var (
+			byoClusterLookupKey types.NamespacedName
+			kubeconfigSecret    *corev1.Secret
+			lease               *coordinationv1.Lease
+		)
+
+		BeforeEach(func() {
+			clusterName := "byocluster-kube-vip-" + util.RandomString(6)
+			cluster = builder.Cluster(defaultNamespace, clusterName).Build()
+			Expect(k8sClientUncached.Create(ctx, cluster)).Should(Succeed())
+
+			byoCluster = builder.ByoCluster(defaultNamespace, clusterName).
+				WithOwnerCluster(cluster).
+				WithKubeVIP(&infrastructurev1beta1.KubeVIPSpec{}).
+				Build()
+			Expect(k8sClientUncached.Create(ctx, byoCluster)).Should(Succeed())
+			WaitForObjectsToBePopulatedInCache(cluster, byoCluster)
+			byoClusterLookupKey = types.NamespacedName{Name: byoCluster.Name, Namespace: byoCluster.Namespace}
+
+			// the envtest cluster stands for the workload cluster
+			kubeconfigSecret = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      secret.Name(cluster.Name, secret.Kubeconfig),
+					Namespace: cluster.Namespace,
+				},
+				Data: map[string][]byte{secret.KubeconfigDataName: kubeconfig.FromEnvTestConfig(cfg, cluster)},
+			}
+			Expect(k8sClientUncached.Create(ctx, kubeconfigSecret)).Should(Succeed())
+			WaitForObjectsToBePopulatedInCache(kubeconfigSecret)
+
+			lease = &coordinationv1.Lease{
+				ObjectMeta: metav1.ObjectMeta{Name: controllers.KubeVIPLeaseName, Namespace: metav1.NamespaceSystem},
+				Spec: coordinationv1.LeaseSpec{
+					HolderIdentity:       pointer.String("control-plane-host"),
+					LeaseDurationSeconds: pointer.Int32(15),
+					RenewTime:            &metav1.MicroTime{Time: time.Now()},
+				},
+			}
+		})
+
+		AfterEach(func() {
+			Expect(k8sClientUncached.Delete(ctx, kubeconfigSecret)).Should(Succeed())
+			Expect(client.IgnoreNotFound(k8sClientUncached.Delete(ctx, lease))).Should(Succeed())
+		})
+
+		initializeControlPlane := func() {
+			ph, err := patch.NewHelper(cluster, k8sClientUncached)
+			Expect(err).ShouldNot(HaveOccurred())
+			conditions.MarkTrue(cluster, clusterv1.ControlPlaneInitializedCondition)
+			Expect(ph.Patch(ctx, cluster)).Should(Succeed())
+			WaitForObjectToBeUpdatedInCache(cluster, func(object client.Object) bool {
+				return conditions.IsTrue(object.(*clusterv1.Cluster), clusterv1.ControlPlaneInitializedCondition)
+			})
+		}
+
+		getVIPCondition := func() *clusterv1.Condition {
+			_, err := byoClusterReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoClusterLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			updatedByoCluster := &infrastructurev1beta1.ByoCluster{}
+			Expect(k8sClientUncached.Get(ctx, byoClusterLookupKey, updatedByoCluster)).Should(Succeed())
+			return conditions.Get(updatedByoCluster, infrastructurev1beta1.ControlPlaneEndpointVIPReady)
+		}
+
+		It("should wait for the control plane to be initialized", func() {
+			Expect(*getVIPCondition()).To(conditions.MatchCondition(clusterv1.Condition{
+				Type:     infrastructurev1beta1.ControlPlaneEndpointVIPReady,
+				Status:   corev1.ConditionFalse,
+				Reason:   infrastructurev1beta1.WaitingForControlPlaneInitializedReason,
+				Severity: clusterv1.ConditionSeverityInfo,
+			}))
+		})
+
+		It("should report the VIP ready when the kube-vip lease is held", func() {
+			initializeControlPlane()
+			Expect(k8sClientUncached.Create(ctx, lease)).Should(Succeed())
+
+			Expect(*getVIPCondition()).To(conditions.MatchCondition(clusterv1.Condition{
+				Type:   infrastructurev1beta1.ControlPlaneEndpointVIPReady,
+				Status: corev1.ConditionTrue,
+			}))
+		})
+
+		It("should report the VIP not ready when the kube-vip lease is not renewed", func() {
+			initializeControlPlane()
+			lease.Spec.RenewTime = &metav1.MicroTime{Time: time.Now().Add(-time.Minute)}
+			Expect(k8sClientUncached.Create(ctx, lease)).Should(Succeed())
+
+			condition := getVIPCondition()
+			Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(infrastructurev1beta1.VIPLeaseExpiredReason))
+			Expect(condition.Message).To(HavePrefix("lease kube-system/plndr-cp-lock held by control-plane-host expired at"))
+		})
+
+		It("should report the VIP not ready when no kube-vip holds the lease", func() {
+			initializeControlPlane()
+
+			condition := getVIPCondition()
+			Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(infrastructurev1beta1.VIPLeaseNotHeldReason))
+		})
+	})
+
+	Context("When the ByoCluster manages its control plane endpoint with an external load balancer", func() {
+		var (
+			byoClusterLookupKey types.NamespacedName
+			byoMachine          *infrastructurev1beta1.ByoMachine
+		)
+
+		BeforeEach(func() {
+			clusterName := "byocluster-lb-" + util.RandomString(6)
+			cluster = builder.Cluster(defaultNamespace, clusterName).Build()
+			Expect(k8sClientUncached.Create(ctx, cluster)).Should(Succeed())
+
+			byoCluster = builder.ByoCluster(defaultNamespace, clusterName).
+				WithOwnerCluster(cluster).
+				WithLoadBalancer(&infrastructurev1beta1.LoadBalancerSpec{Type: infrastructurev1beta1.HAProxyLoadBalancer}).
+				Build()
+			byoCluster.Spec.ControlPlaneEndpoint = infrastructurev1beta1.APIEndpoint{Host: "lb.example.com", Port: 8443}
+			Expect(k8sClientUncached.Create(ctx, byoCluster)).Should(Succeed())
+
+			byoMachine = builder.ByoMachine(defaultNamespace, clusterName+"-control-plane").
+				WithClusterLabel(clusterName).
+				WithControlPlaneLabel().
+				Build()
+			Expect(k8sClientUncached.Create(ctx, byoMachine)).Should(Succeed())
+
+			WaitForObjectsToBePopulatedInCache(cluster, byoCluster, byoMachine)
+			byoClusterLookupKey = types.NamespacedName{Name: byoCluster.Name, Namespace: byoCluster.Namespace}
+		})
+
+		reconcileAndGetCondition := func() *clusterv1.Condition {
+			_, err := byoClusterReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoClusterLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			updatedByoCluster := &infrastructurev1beta1.ByoCluster{}
+			Expect(k8sClientUncached.Get(ctx, byoClusterLookupKey, updatedByoCluster)).Should(Succeed())
+			return conditions.Get(updatedByoCluster, infrastructurev1beta1.ControlPlaneEndpointLoadBalancerReady)
+		}
+
+		It("should wait for a control plane machine to be attached to a host", func() {
+			Expect(*reconcileAndGetCondition()).To(conditions.MatchCondition(clusterv1.Condition{
+				Type:     infrastructurev1beta1.ControlPlaneEndpointLoadBalancerReady,
+				Status:   corev1.ConditionFalse,
+				Reason:   infrastructurev1beta1.WaitingForControlPlaneBackendsReason,
+				Severity: clusterv1.ConditionSeverityInfo,
+			}))
+		})
+
+		It("should write the hosts of the control plane machines as the load balancer backends", func() {
+			byoHost := builder.ByoHost(defaultNamespace, "control-plane-host").
+				WithLabels(map[string]string{infrastructurev1beta1.AttachedByoMachineLabel: byoMachine.Namespace + "." + byoMachine.Name}).
+				WithAnnotations(map[string]string{infrastructurev1beta1.NodeIPAnnotation: "10.0.0.11"}).
+				Build()
+			Expect(k8sClientUncached.Create(ctx, byoHost)).Should(Succeed())
+			defer func() {
+				Expect(k8sClientUncached.Delete(ctx, byoHost)).Should(Succeed())
+			}()
+			WaitForObjectsToBePopulatedInCache(byoHost)
+
+			Expect(*reconcileAndGetCondition()).To(conditions.MatchCondition(clusterv1.Condition{
+				Type:   infrastructurev1beta1.ControlPlaneEndpointLoadBalancerReady,
+				Status: corev1.ConditionTrue,
+			}))
+
+			configMap := &corev1.ConfigMap{}
+			Expect(k8sClientUncached.Get(ctx, types.NamespacedName{
+				Name:      byoCluster.Name + controllers.LoadBalancerConfigMapSuffix,
+				Namespace: byoCluster.Namespace,
+			}, configMap)).Should(Succeed())
+			Expect(configMap.OwnerReferences).To(HaveLen(1))
+			Expect(configMap.OwnerReferences[0].Name).To(Equal(byoCluster.Name))
+			Expect(configMap.Data[controllers.HAProxyConfigKey]).To(ContainSubstring("bind *:8443"))
+			Expect(configMap.Data[controllers.HAProxyConfigKey]).To(ContainSubstring(
+				fmt.Sprintf("server %s 10.0.0.11:6443 check", byoHost.Name)))
+		})
+	})
+
+	Context("When the machines of the ByoCluster are provisioned", func() {
+		var (
+			byoClusterLookupKey types.NamespacedName
+			clusterName         string
+		)
+
+		BeforeEach(func() {
+			clusterName = "byocluster-fleet-" + util.RandomString(6)
+			cluster = builder.Cluster(defaultNamespace, clusterName).Build()
+			Expect(k8sClientUncached.Create(ctx, cluster)).Should(Succeed())
+			byoCluster = builder.ByoCluster(defaultNamespace, clusterName).WithOwnerCluster(cluster).Build()
+			Expect(k8sClientUncached.Create(ctx, byoCluster)).Should(Succeed())
+			WaitForObjectsToBePopulatedInCache(cluster, byoCluster)
+			byoClusterLookupKey = types.NamespacedName{Name: byoCluster.Name, Namespace: byoCluster.Namespace}
+		})
+
+		createMachine := func(name string, mutate func(*infrastructurev1beta1.ByoMachine)) *infrastructurev1beta1.ByoMachine {
+			byoMachine := builder.ByoMachine(defaultNamespace, clusterName+"-"+name).WithClusterLabel(clusterName).Build()
+			Expect(k8sClientUncached.Create(ctx, byoMachine)).Should(Succeed())
+			ph, err := patch.NewHelper(byoMachine, k8sClientUncached)
+			Expect(err).ShouldNot(HaveOccurred())
+			mutate(byoMachine)
+			Expect(ph.Patch(ctx, byoMachine)).Should(Succeed())
+			return byoMachine
+		}
+
+		reconcileAndGetByoCluster := func() *infrastructurev1beta1.ByoCluster {
+			_, err := byoClusterReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoClusterLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			updatedByoCluster := &infrastructurev1beta1.ByoCluster{}
+			Expect(k8sClientUncached.Get(ctx, byoClusterLookupKey, updatedByoCluster)).Should(Succeed())
+			return updatedByoCluster
+		}
+
+		It("should aggregate the hosts and machines of the cluster into its status", func() {
+			readyMachine := createMachine("ready", func(byoMachine *infrastructurev1beta1.ByoMachine) {
+				byoMachine.Status.Ready = true
+				conditions.MarkTrue(byoMachine, infrastructurev1beta1.BYOHostReady)
+			})
+			provisioningMachine := createMachine("provisioning", func(byoMachine *infrastructurev1beta1.ByoMachine) {
+				conditions.MarkFalse(byoMachine, infrastructurev1beta1.BYOHostReady, infrastructurev1beta1.WaitingForAvailableHostReason, clusterv1.ConditionSeverityInfo, "")
+			})
+
+			byoHost := builder.ByoHost(defaultNamespace, "fleet-host").
+				WithLabels(map[string]string{clusterv1.ClusterLabelName: clusterName}).
+				Build()
+			Expect(k8sClientUncached.Create(ctx, byoHost)).Should(Succeed())
+			defer func() {
+				Expect(k8sClientUncached.Delete(ctx, byoHost)).Should(Succeed())
+			}()
+			ph, err := patch.NewHelper(byoHost, k8sClientUncached)
+			Expect(err).ShouldNot(HaveOccurred())
+			byoHost.Status.MachineRef = &corev1.ObjectReference{Name: readyMachine.Name, Namespace: readyMachine.Namespace}
+			conditions.MarkTrue(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded)
+			Expect(ph.Patch(ctx, byoHost)).Should(Succeed())
+
+			WaitForObjectsToBePopulatedInCache(readyMachine, provisioningMachine)
+			WaitForObjectToBeUpdatedInCache(byoHost, func(object client.Object) bool {
+				return object.(*infrastructurev1beta1.ByoHost).Status.MachineRef != nil
+			})
+
+			updatedByoCluster := reconcileAndGetByoCluster()
+			Expect(updatedByoCluster.Status.ReadyHosts).To(Equal(int32(1)))
+			Expect(updatedByoCluster.Status.ProvisioningMachines).To(Equal(int32(1)))
+			Expect(updatedByoCluster.Status.FailedMachines).To(Equal(int32(0)))
+			Expect(conditions.IsTrue(updatedByoCluster, infrastructurev1beta1.FleetHealthy)).To(BeTrue())
+
+			failedMachine := createMachine("failed", func(byoMachine *infrastructurev1beta1.ByoMachine) {
+				conditions.MarkFalse(byoMachine, infrastructurev1beta1.BYOHostReady, infrastructurev1beta1.BootstrapDataInvalidReason, clusterv1.ConditionSeverityError, "")
+			})
+			WaitForObjectToBeUpdatedInCache(failedMachine, func(object client.Object) bool {
+				return conditions.IsFalse(object.(*infrastructurev1beta1.ByoMachine), infrastructurev1beta1.BYOHostReady)
+			})
+
+			updatedByoCluster = reconcileAndGetByoCluster()
+			Expect(updatedByoCluster.Status.FailedMachines).To(Equal(int32(1)))
+			Expect(*conditions.Get(updatedByoCluster, infrastructurev1beta1.FleetHealthy)).To(conditions.MatchCondition(clusterv1.Condition{
+				Type:     infrastructurev1beta1.FleetHealthy,
+				Status:   corev1.ConditionFalse,
+				Reason:   infrastructurev1beta1.MachinesFailedReason,
+				Severity: clusterv1.ConditionSeverityError,
+				Message:  "1 of 3 machines failed",
+			}))
+			// the failed machines do not make the infrastructure of the cluster unready
+			Expect(conditions.IsFalse(updatedByoCluster, clusterv1.ReadyCondition)).To(BeFalse())
+		})
+	})
+
+	Context("When the ByoCluster derives its failure domains from a host label", func() {
+		var byoClusterLookupKey types.NamespacedName
+
+		BeforeEach(func() {
+			clusterName := "byocluster-fd-" + util.RandomString(6)
+			cluster = builder.Cluster(defaultNamespace, clusterName).Build()
+			Expect(k8sClientUncached.Create(ctx, cluster)).Should(Succeed())
+			byoCluster = builder.ByoCluster(defaultNamespace, clusterName).WithOwnerCluster(cluster).Build()
+			byoCluster.Spec.FailureDomainLabel = "rack-" + util.RandomString(6)
+			Expect(k8sClientUncached.Create(ctx, byoCluster)).Should(Succeed())
+			byoClusterLookupKey = types.NamespacedName{Name: byoCluster.Name, Namespace: byoCluster.Namespace}
+		})
+
+		It("should report the values of the label of the hosts as failure domains", func() {
+			var hosts []client.Object
+			for _, rack := range []string{"r1", "r1", "r2"} {
+				byoHost := builder.ByoHost(defaultNamespace, "fd-host").
+					WithLabels(map[string]string{byoCluster.Spec.FailureDomainLabel: rack}).
+					Build()
+				Expect(k8sClientUncached.Create(ctx, byoHost)).Should(Succeed())
+				hosts = append(hosts, byoHost)
+			}
+			defer func() {
+				for _, host := range hosts {
+					Expect(k8sClientUncached.Delete(ctx, host)).Should(Succeed())
+				}
+			}()
+			WaitForObjectsToBePopulatedInCache(append(hosts, cluster, byoCluster)...)
+
+			_, err := byoClusterReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoClusterLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+			updatedByoCluster := &infrastructurev1beta1.ByoCluster{}
+			Expect(k8sClientUncached.Get(ctx, byoClusterLookupKey, updatedByoCluster)).Should(Succeed())
+			Expect(updatedByoCluster.Status.FailureDomains).To(Equal(clusterv1.FailureDomains{
+				"r1": {ControlPlane: true, Attributes: map[string]string{byoCluster.Spec.FailureDomainLabel: "r1"}},
+				"r2": {ControlPlane: true, Attributes: map[string]string{byoCluster.Spec.FailureDomainLabel: "r2"}},
+			}))
+		})
+	})
+
 })
@@ -0,0 +1,130 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	clusterutilv1 "sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ControlPlaneEndpointProvider keeps the control plane endpoint of a ByoCluster serving the API
+// servers of its control plane machines, as they come and go
+type ControlPlaneEndpointProvider interface {
+	// Condition is the condition of the ByoCluster the provider reports the endpoint state in
+	Condition() clusterv1.ConditionType
+
+	// Enabled returns whether the control plane endpoint of the ByoCluster is managed by the provider
+	Enabled(byoCluster *infrav1.ByoCluster) bool
+
+	// ReconcileEndpoint updates the endpoint with the control plane machines of the cluster
+	ReconcileEndpoint(ctx context.Context, cluster *clusterv1.Cluster, byoCluster *infrav1.ByoCluster) (ctrl.Result, error)
+}
+
+// ControlPlaneBackend is a control plane machine the control plane endpoint forwards to
+type ControlPlaneBackend struct {
+	// Name is the name of the ByoHost the control plane machine is attached to
+	Name string
+	// Address is the IP address of the ByoHost
+	Address string
+}
+
+// DefaultControlPlaneEndpointProviders returns the built-in control plane endpoint providers
+func DefaultControlPlaneEndpointProviders(c client.Client) []ControlPlaneEndpointProvider {
+	return []ControlPlaneEndpointProvider{
+		&KubeVIPEndpointProvider{Client: c},
+		&ConfigMapLoadBalancerProvider{Client: c},
+	}
+}
+
+// reconcileControlPlaneEndpoint runs the control plane endpoint providers enabled on the ByoCluster,
+// and removes the conditions of the disabled ones
+func (r ByoClusterReconciler) reconcileControlPlaneEndpoint(ctx context.Context, cluster *clusterv1.Cluster, byoCluster *infrav1.ByoCluster) (ctrl.Result, error) {
+	providers := r.EndpointProviders
+	if providers == nil {
+		providers = DefaultControlPlaneEndpointProviders(r.Client)
+	}
+
+	result := ctrl.Result{}
+	for _, provider := range providers {
+		if !provider.Enabled(byoCluster) {
+			conditions.Delete(byoCluster, provider.Condition())
+			continue
+		}
+		providerResult, err := provider.ReconcileEndpoint(ctx, cluster, byoCluster)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		result = clusterutilv1.LowestNonZeroResult(result, providerResult)
+	}
+	return result, nil
+}
+
+// ControlPlaneBackends returns, sorted by name, the hosts attached to the control plane machines
+// of the cluster which are not being deleted and have a known address
+func ControlPlaneBackends(ctx context.Context, c client.Client, cluster *clusterv1.Cluster) ([]ControlPlaneBackend, error) {
+	machineList := &infrav1.ByoMachineList{}
+	if err := c.List(ctx, machineList,
+		client.InNamespace(cluster.Namespace),
+		client.MatchingLabels{clusterv1.ClusterLabelName: cluster.Name},
+		client.HasLabels{clusterv1.MachineControlPlaneLabelName}); err != nil {
+		return nil, err
+	}
+
+	backends := []ControlPlaneBackend{}
+	for i := range machineList.Items {
+		machine := &machineList.Items[i]
+		if !machine.DeletionTimestamp.IsZero() {
+			continue
+		}
+		hostList := &infrav1.ByoHostList{}
+		if err := c.List(ctx, hostList, client.MatchingLabels{
+			infrav1.AttachedByoMachineLabel: machine.Namespace + "." + machine.Name,
+		}); err != nil {
+			return nil, err
+		}
+		for j := range hostList.Items {
+			if address := hostAddress(&hostList.Items[j]); address != "" {
+				backends = append(backends, ControlPlaneBackend{Name: hostList.Items[j].Name, Address: address})
+			}
+		}
+	}
+	sort.Slice(backends, func(i, j int) bool { return backends[i].Name < backends[j].Name })
+	return backends, nil
+}
+
+// hostAddress returns the node IP pinned on the host, or else the address of the primary IP family
+// of the cluster of an IPv6-only or dual-stack cluster, or else the first address of its default
+// network interface
+func hostAddress(host *infrav1.ByoHost) string {
+	if nodeIP := host.Annotations[infrav1.NodeIPAnnotation]; nodeIP != "" {
+		// the primary address of a dual-stack node comes first
+		return strings.Split(nodeIP, ",")[0]
+	}
+	if families := infrav1.ParseIPFamilies(host.Annotations[infrav1.IPFamiliesAnnotation]); len(families) > 0 {
+		if address := infrav1.DefaultAddress(host.Status.Network, families[0]); address != "" {
+			return address
+		}
+	}
+	for _, network := range host.Status.Network {
+		if !network.IsDefault || len(network.IPAddrs) == 0 {
+			continue
+		}
+		// the agent reports the addresses in CIDR notation
+		address := network.IPAddrs[0]
+		if ip, _, err := net.ParseCIDR(address); err == nil {
+			return ip.String()
+		}
+		return strings.Split(address, "/")[0]
+	}
+	return ""
+}
@@ -0,0 +1,66 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// reconcileFailureDomains reports the values of the FailureDomainLabel of the ByoHosts the cluster
+// can use as its failure domains, the control plane machines can be placed in all of them
+func (r ByoClusterReconciler) reconcileFailureDomains(ctx context.Context, byoCluster *infrav1.ByoCluster) error {
+	failureDomainLabel := byoCluster.Spec.FailureDomainLabel
+	if failureDomainLabel == "" {
+		byoCluster.Status.FailureDomains = nil
+		return nil
+	}
+
+	hostList := &infrav1.ByoHostList{}
+	if err := r.Client.List(ctx, hostList, client.HasLabels{failureDomainLabel}); err != nil {
+		return err
+	}
+	hosts, err := filterAccessibleByoHosts(ctx, r.Client, byoCluster.Namespace, hostList.Items)
+	if err != nil {
+		return err
+	}
+
+	failureDomains := clusterv1.FailureDomains{}
+	for i := range hosts {
+		domain := hosts[i].Labels[failureDomainLabel]
+		if domain == "" {
+			continue
+		}
+		failureDomains[domain] = clusterv1.FailureDomainSpec{
+			ControlPlane: true,
+			Attributes:   map[string]string{failureDomainLabel: domain},
+		}
+	}
+	byoCluster.Status.FailureDomains = failureDomains
+	return nil
+}
+
+// byoHostToFailureDomainByoClusters maps a ByoHost to the ByoClusters whose failure domains are
+// derived from one of its labels
+func (r *ByoClusterReconciler) byoHostToFailureDomainByoClusters(o client.Object) []reconcile.Request {
+	byoClusters := &infrav1.ByoClusterList{}
+	if err := r.Client.List(context.TODO(), byoClusters); err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range byoClusters.Items {
+		byoCluster := &byoClusters.Items[i]
+		if byoCluster.Spec.FailureDomainLabel == "" {
+			continue
+		}
+		if _, ok := o.GetLabels()[byoCluster.Spec.FailureDomainLabel]; ok {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(byoCluster)})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,92 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// reconcileFleetStatus counts the ready hosts, and the provisioning and failed machines, of the
+// cluster into the status of the ByoCluster, and sets its FleetHealthy condition
+func (r ByoClusterReconciler) reconcileFleetStatus(ctx context.Context, cluster *clusterv1.Cluster, byoCluster *infrav1.ByoCluster) error {
+	clusterLabels := client.MatchingLabels{clusterv1.ClusterLabelName: cluster.Name}
+	hostList := &infrav1.ByoHostList{}
+	if err := r.Client.List(ctx, hostList, client.InNamespace(cluster.Namespace), clusterLabels); err != nil {
+		return err
+	}
+	machineList := &infrav1.ByoMachineList{}
+	if err := r.Client.List(ctx, machineList, client.InNamespace(cluster.Namespace), clusterLabels); err != nil {
+		return err
+	}
+
+	var readyHosts, unhealthyHosts int32
+	machineHosts := map[string]*infrav1.ByoHost{}
+	for i := range hostList.Items {
+		host := &hostList.Items[i]
+		if host.Status.MachineRef != nil {
+			machineHosts[host.Status.MachineRef.Name] = host
+		}
+		switch {
+		case isUnhealthy(host):
+			unhealthyHosts++
+		case conditions.IsTrue(host, infrav1.K8sNodeBootstrapSucceeded):
+			readyHosts++
+		}
+	}
+
+	var provisioningMachines, failedMachines int32
+	for i := range machineList.Items {
+		machine := &machineList.Items[i]
+		switch {
+		case isMachineFailed(machine, machineHosts[machine.Name]):
+			failedMachines++
+		case !machine.Status.Ready:
+			provisioningMachines++
+		}
+	}
+
+	byoCluster.Status.ReadyHosts = readyHosts
+	byoCluster.Status.ProvisioningMachines = provisioningMachines
+	byoCluster.Status.FailedMachines = failedMachines
+
+	switch {
+	case failedMachines > 0:
+		conditions.MarkFalse(byoCluster, infrav1.FleetHealthy, infrav1.MachinesFailedReason, clusterv1.ConditionSeverityError,
+			"%d of %d machines failed", failedMachines, len(machineList.Items))
+	case unhealthyHosts > 0:
+		conditions.MarkFalse(byoCluster, infrav1.FleetHealthy, infrav1.HostsUnhealthyReason, clusterv1.ConditionSeverityWarning,
+			"%d of %d hosts report an error", unhealthyHosts, len(hostList.Items))
+	default:
+		conditions.MarkTrue(byoCluster, infrav1.FleetHealthy)
+	}
+	return nil
+}
+
+// isMachineFailed tells if the provisioning of the machine failed, or if the host attached to it
+// reports an error
+func isMachineFailed(machine *infrav1.ByoMachine, host *infrav1.ByoHost) bool {
+	if condition := conditions.Get(machine, infrav1.BYOHostReady); condition != nil &&
+		condition.Status == corev1.ConditionFalse && condition.Severity == clusterv1.ConditionSeverityError {
+		return true
+	}
+	return host != nil && isUnhealthy(host)
+}
+
+// readySummaryConditions returns the conditions of the ByoCluster summarized into its Ready condition,
+// all but FleetHealthy: the machines failing do not make the infrastructure of the cluster unready
+func readySummaryConditions(byoCluster *infrav1.ByoCluster) []clusterv1.ConditionType {
+	conditionTypes := []clusterv1.ConditionType{}
+	for _, condition := range byoCluster.Status.Conditions {
+		if condition.Type != clusterv1.ReadyCondition && condition.Type != infrav1.FleetHealthy {
+			conditionTypes = append(conditionTypes, condition.Type)
+		}
+	}
+	return conditionTypes
+}
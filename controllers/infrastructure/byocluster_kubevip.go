@@ -0,0 +1,100 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/controllers/remote"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// KubeVIPLeaseName is the name of the lease the kube-vip instances of the control plane
+	// hosts elect the one serving the control plane endpoint with
+	KubeVIPLeaseName = "plndr-cp-lock"
+
+	// kubeVIPLeaseCheckInterval is the interval the kube-vip lease is checked at
+	kubeVIPLeaseCheckInterval = 30 * time.Second
+)
+
+// KubeVIPEndpointProvider serves the control plane endpoint with the kube-vip static pods the
+// host agents of the control plane hosts deploy. It reports in the ControlPlaneEndpointVIPReady
+// condition whether the kube-vip lease of the endpoint is held and renewed in the workload cluster.
+type KubeVIPEndpointProvider struct {
+	Client client.Client
+}
+
+var _ ControlPlaneEndpointProvider = &KubeVIPEndpointProvider{}
+
+// Condition implements ControlPlaneEndpointProvider
+func (p *KubeVIPEndpointProvider) Condition() clusterv1.ConditionType {
+	return infrav1.ControlPlaneEndpointVIPReady
+}
+
+// Enabled implements ControlPlaneEndpointProvider
+func (p *KubeVIPEndpointProvider) Enabled(byoCluster *infrav1.ByoCluster) bool {
+	return byoCluster.Spec.KubeVIP != nil
+}
+
+// ReconcileEndpoint implements ControlPlaneEndpointProvider. The kube-vip static pods themselves
+// are written by the host agents, from the annotations the ByoMachine controller sets on the hosts.
+func (p *KubeVIPEndpointProvider) ReconcileEndpoint(ctx context.Context, cluster *clusterv1.Cluster, byoCluster *infrav1.ByoCluster) (ctrl.Result, error) {
+	// kube-vip runs as a static pod of the control plane hosts, the lease only exists
+	// once the first one is bootstrapped
+	if !conditions.IsTrue(cluster, clusterv1.ControlPlaneInitializedCondition) {
+		conditions.MarkFalse(byoCluster, infrav1.ControlPlaneEndpointVIPReady, infrav1.WaitingForControlPlaneInitializedReason, clusterv1.ConditionSeverityInfo, "")
+		return ctrl.Result{}, nil
+	}
+
+	remoteClient, err := remote.NewClusterClient(ctx, "byocluster-controller", p.Client, client.ObjectKeyFromObject(cluster))
+	if err != nil {
+		conditions.MarkFalse(byoCluster, infrav1.ControlPlaneEndpointVIPReady, infrav1.VIPLeaseCheckFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return ctrl.Result{RequeueAfter: kubeVIPLeaseCheckInterval}, nil
+	}
+
+	lease := &coordinationv1.Lease{}
+	if err := remoteClient.Get(ctx, types.NamespacedName{Name: KubeVIPLeaseName, Namespace: metav1.NamespaceSystem}, lease); err != nil {
+		if apierrors.IsNotFound(err) {
+			conditions.MarkFalse(byoCluster, infrav1.ControlPlaneEndpointVIPReady, infrav1.VIPLeaseNotHeldReason, clusterv1.ConditionSeverityWarning,
+				"lease %s/%s not found", metav1.NamespaceSystem, KubeVIPLeaseName)
+		} else {
+			conditions.MarkFalse(byoCluster, infrav1.ControlPlaneEndpointVIPReady, infrav1.VIPLeaseCheckFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		}
+		return ctrl.Result{RequeueAfter: kubeVIPLeaseCheckInterval}, nil
+	}
+
+	if reason, message := kubeVIPLeaseHealth(lease, time.Now()); reason != "" {
+		conditions.MarkFalse(byoCluster, infrav1.ControlPlaneEndpointVIPReady, reason, clusterv1.ConditionSeverityWarning, message)
+	} else {
+		conditions.MarkTrue(byoCluster, infrav1.ControlPlaneEndpointVIPReady)
+	}
+	return ctrl.Result{RequeueAfter: kubeVIPLeaseCheckInterval}, nil
+}
+
+// kubeVIPLeaseHealth returns the reason and message of an unhealthy kube-vip lease, or an empty reason
+func kubeVIPLeaseHealth(lease *coordinationv1.Lease, now time.Time) (reason, message string) {
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == "" {
+		return infrav1.VIPLeaseNotHeldReason, fmt.Sprintf("lease %s/%s has no holder", lease.Namespace, lease.Name)
+	}
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return infrav1.VIPLeaseExpiredReason, fmt.Sprintf("lease %s/%s held by %s is not renewed", lease.Namespace, lease.Name, *lease.Spec.HolderIdentity)
+	}
+	expiry := lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+	if now.After(expiry) {
+		return infrav1.VIPLeaseExpiredReason, fmt.Sprintf("lease %s/%s held by %s expired at %s",
+			lease.Namespace, lease.Name, *lease.Spec.HolderIdentity, expiry.UTC().Format(time.RFC3339))
+	}
+	return "", ""
+}
@@ -0,0 +1,160 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"text/template"
+
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// LoadBalancerConfigMapSuffix is appended to the ByoCluster name to name the load balancer
+	// ConfigMap when LoadBalancerSpec.ConfigMapName is not set
+	LoadBalancerConfigMapSuffix = "-control-plane-lb"
+
+	// HAProxyConfigKey is the ConfigMap key the HAProxy configuration is written to
+	HAProxyConfigKey = "haproxy.cfg"
+	// NGINXConfigKey is the ConfigMap key the NGINX configuration is written to
+	NGINXConfigKey = "nginx.conf"
+)
+
+var (
+	haproxyConfig = template.Must(template.New("haproxy").Parse(`frontend kube-apiserver
+    bind *:{{ .Port }}
+    mode tcp
+    option tcplog
+    default_backend kube-apiserver
+
+backend kube-apiserver
+    mode tcp
+    balance roundrobin
+    option tcp-check
+{{- range .Servers }}
+    server {{ .Name }} {{ .Address }} check
+{{- end }}
+`))
+
+	nginxConfig = template.Must(template.New("nginx").Parse(`stream {
+    upstream kube-apiserver {
+{{- range .Servers }}
+        server {{ .Address }};
+{{- end }}
+    }
+
+    server {
+        listen {{ .Port }};
+        proxy_pass kube-apiserver;
+    }
+}
+`))
+)
+
+// ConfigMapLoadBalancerProvider writes the configuration of an external HAProxy or NGINX load
+// balancer serving the control plane endpoint to a ConfigMap, listing the control plane machines
+// as its backends. The load balancer is expected to reload the ConfigMap content as it changes.
+type ConfigMapLoadBalancerProvider struct {
+	Client client.Client
+}
+
+var _ ControlPlaneEndpointProvider = &ConfigMapLoadBalancerProvider{}
+
+// Condition implements ControlPlaneEndpointProvider
+func (p *ConfigMapLoadBalancerProvider) Condition() clusterv1.ConditionType {
+	return infrav1.ControlPlaneEndpointLoadBalancerReady
+}
+
+// Enabled implements ControlPlaneEndpointProvider
+func (p *ConfigMapLoadBalancerProvider) Enabled(byoCluster *infrav1.ByoCluster) bool {
+	return byoCluster.Spec.LoadBalancer != nil
+}
+
+// ReconcileEndpoint implements ControlPlaneEndpointProvider. The configuration is left untouched
+// while the cluster has no control plane backend, as an empty upstream is not a valid NGINX configuration.
+func (p *ConfigMapLoadBalancerProvider) ReconcileEndpoint(ctx context.Context, cluster *clusterv1.Cluster, byoCluster *infrav1.ByoCluster) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	backends, err := ControlPlaneBackends(ctx, p.Client, cluster)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if len(backends) == 0 {
+		conditions.MarkFalse(byoCluster, infrav1.ControlPlaneEndpointLoadBalancerReady, infrav1.WaitingForControlPlaneBackendsReason, clusterv1.ConditionSeverityInfo, "")
+		return ctrl.Result{}, nil
+	}
+
+	key, config, err := loadBalancerConfig(byoCluster, backends)
+	if err != nil {
+		conditions.MarkFalse(byoCluster, infrav1.ControlPlaneEndpointLoadBalancerReady, infrav1.LoadBalancerConfigFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return ctrl.Result{}, err
+	}
+
+	configMap := &corev1.ConfigMap{}
+	configMap.Name = loadBalancerConfigMapName(byoCluster)
+	configMap.Namespace = byoCluster.Namespace
+	result, err := controllerutil.CreateOrUpdate(ctx, p.Client, configMap, func() error {
+		configMap.Labels = map[string]string{clusterv1.ClusterLabelName: cluster.Name}
+		configMap.Data = map[string]string{key: config}
+		return controllerutil.SetControllerReference(byoCluster, configMap, p.Client.Scheme())
+	})
+	if err != nil {
+		conditions.MarkFalse(byoCluster, infrav1.ControlPlaneEndpointLoadBalancerReady, infrav1.LoadBalancerConfigFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return ctrl.Result{}, err
+	}
+	if result != controllerutil.OperationResultNone {
+		logger.Info("Updated the control plane load balancer configuration", "configmap", configMap.Name, "backends", len(backends))
+	}
+	conditions.MarkTrue(byoCluster, infrav1.ControlPlaneEndpointLoadBalancerReady)
+	return ctrl.Result{}, nil
+}
+
+// loadBalancerConfigMapName returns the name of the ConfigMap the load balancer configuration is written to
+func loadBalancerConfigMapName(byoCluster *infrav1.ByoCluster) string {
+	if byoCluster.Spec.LoadBalancer.ConfigMapName != "" {
+		return byoCluster.Spec.LoadBalancer.ConfigMapName
+	}
+	return byoCluster.Name + LoadBalancerConfigMapSuffix
+}
+
+// loadBalancerConfig renders the load balancer configuration, returning the ConfigMap key it is written to
+func loadBalancerConfig(byoCluster *infrav1.ByoCluster, backends []ControlPlaneBackend) (key, config string, err error) {
+	spec := byoCluster.Spec.LoadBalancer
+	backendPort := strconv.Itoa(int(spec.BackendPort))
+	if spec.BackendPort == 0 {
+		backendPort = strconv.Itoa(infrav1.DefaultAPIEndpointPort)
+	}
+	port := byoCluster.Spec.ControlPlaneEndpoint.Port
+	if port == 0 {
+		port = infrav1.DefaultAPIEndpointPort
+	}
+
+	servers := make([]ControlPlaneBackend, 0, len(backends))
+	for _, backend := range backends {
+		servers = append(servers, ControlPlaneBackend{
+			Name:    backend.Name,
+			Address: net.JoinHostPort(backend.Address, backendPort),
+		})
+	}
+
+	tmpl, key := haproxyConfig, HAProxyConfigKey
+	if spec.Type == infrav1.NGINXLoadBalancer {
+		tmpl, key = nginxConfig, NGINXConfigKey
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, map[string]interface{}{"Port": port, "Servers": servers}); err != nil {
+		return "", "", err
+	}
+	return key, out.String(), nil
+}
@@ -5,43 +5,326 @@ package controllers
 
 import (
 	"context"
+	"time"
 
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/common/maintenance"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/common/tracing"
+	byohconditions "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/pkg/conditions"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ByoHostReconciler reconciles a ByoHost object
 type ByoHostReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// AgentVersion is the host agent version every ByoHost is requested to run.
+	// No upgrade is requested when it is empty.
+	AgentVersion string
+	// AgentBinaryRepo is the OCI repository the host agent binaries are published to
+	AgentBinaryRepo string
 }
 
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byohosts,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byohosts/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byohosts/finalizers,verbs=update
 //+kubebuilder:rbac:groups=certificates.k8s.io,resources=certificatesigningrequests,verbs=create;get;watch
+//+kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines,verbs=get;list;watch;delete;patch
+//+kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters,verbs=get;list;watch
 
-// Reconcile is part of the main kubernetes reconciliation loop which aims to
-// move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the ByoHost object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
-//
-// For more details, check Reconcile and its Result here:
-// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.8.3/pkg/reconcile
+// Reconcile records the reservation, the schedulability and the readiness of the ByoHost, detaches the ByoHosts being quarantined or decommissioned,
+// and sets the desired host agent version on the ByoHost, which is picked up by the host agent
+// to upgrade itself.
 func (r *ByoHostReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
-	_ = log.FromContext(ctx)
-	return ctrl.Result{}, nil
+	logger := log.FromContext(ctx)
+
+	byoHost := &infrastructurev1beta1.ByoHost{}
+	if err := r.Client.Get(ctx, req.NamespacedName, byoHost); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	if !byoHost.ObjectMeta.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	// the reconciles of a host being provisioned join the trace of its machine
+	if tracing.Traced(byoHost) && byoHost.Status.MachineRef != nil && !conditions.IsTrue(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded) {
+		var span trace.Span
+		ctx, span = tracing.Continue(ctx, byoHost, "ByoHost.Reconcile", attribute.String("byohost", req.String()))
+		defer func() { tracing.End(span, reterr) }()
+	}
+
+	if err := r.reconcileReservation(ctx, byoHost); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := r.reconcileSchedulability(ctx, byoHost); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := r.reconcileReadiness(ctx, byoHost); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if isQuarantined(byoHost) {
+		return ctrl.Result{}, r.reconcileQuarantine(ctx, byoHost)
+	}
+
+	if _, ok := byoHost.Annotations[infrastructurev1beta1.DecommissionAnnotation]; ok {
+		return r.reconcileDecommission(ctx, byoHost)
+	}
+
+	if r.AgentVersion == "" {
+		return ctrl.Result{}, nil
+	}
+
+	hostAnnotations := byoHost.GetAnnotations()
+	if hostAnnotations[infrastructurev1beta1.DesiredAgentVersionAnnotation] == r.AgentVersion &&
+		hostAnnotations[infrastructurev1beta1.AgentBinaryRepoAnnotation] == r.AgentBinaryRepo {
+		return ctrl.Result{}, nil
+	}
+
+	helper, err := patch.NewHelper(byoHost, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if byoHost.Annotations == nil {
+		byoHost.Annotations = map[string]string{}
+	}
+	byoHost.Annotations[infrastructurev1beta1.DesiredAgentVersionAnnotation] = r.AgentVersion
+	byoHost.Annotations[infrastructurev1beta1.AgentBinaryRepoAnnotation] = r.AgentBinaryRepo
+
+	logger.Info("Requesting host agent upgrade", "version", r.AgentVersion)
+	return ctrl.Result{}, helper.Patch(ctx, byoHost)
+}
+
+// reconcileDecommission detaches the ByoHost being decommissioned. The Machine it is attached
+// to is deleted, so that the node is drained before the host is cleaned up. A host attached
+// to a ByoMachinePool is released and replaced by the pool. The host is detached once its
+// maintenance window opens.
+func (r *ByoHostReconciler) reconcileDecommission(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	machineRef := byoHost.Status.MachineRef
+	if machineRef == nil {
+		return ctrl.Result{}, nil
+	}
+
+	open, next, err := maintenance.Check(byoHost.Annotations[infrastructurev1beta1.MaintenanceWindowAnnotation], time.Now())
+	switch {
+	case err != nil:
+		logger.Info("Invalid maintenance window, deferring the decommission", "error", err.Error())
+		return ctrl.Result{}, nil
+	case !open && next.IsZero():
+		logger.Info("The maintenance window never opens, deferring the decommission")
+		return ctrl.Result{}, nil
+	case !open:
+		logger.Info("Deferring the decommission until the maintenance window opens", "opensAt", next)
+		return ctrl.Result{RequeueAfter: time.Until(next)}, nil
+	}
+
+	if machineRef.Kind != "ByoMachine" {
+		if _, ok := byoHost.Annotations[infrastructurev1beta1.HostCleanupAnnotation]; ok {
+			return ctrl.Result{}, nil
+		}
+		helper, err := patch.NewHelper(byoHost, r.Client)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		byoHost.Annotations[infrastructurev1beta1.HostCleanupAnnotation] = ""
+		logger.Info("Releasing decommissioned ByoHost", "owner", machineRef.Kind+"/"+machineRef.Name)
+		return ctrl.Result{}, helper.Patch(ctx, byoHost)
+	}
+
+	byoMachine := &infrastructurev1beta1.ByoMachine{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Namespace: machineRef.Namespace, Name: machineRef.Name}, byoMachine); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	machine, err := util.GetOwnerMachine(ctx, r.Client, byoMachine.ObjectMeta)
+	if err != nil || machine == nil {
+		return ctrl.Result{}, err
+	}
+	if !machine.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	logger.Info("Deleting the Machine of the decommissioned ByoHost", "machine", machine.Name)
+	return ctrl.Result{}, client.IgnoreNotFound(r.Client.Delete(ctx, machine))
+}
+
+// reconcileQuarantine detaches the quarantined ByoHost without the host agent resetting it, regardless of its
+// maintenance windows. The Machine it is attached to is deleted, and replaced by Cluster API, the ByoMachine
+// detaching the host on deletion. A host attached to a ByoMachinePool is detached right away.
+func (r *ByoHostReconciler) reconcileQuarantine(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost) error {
+	logger := log.FromContext(ctx)
+
+	machineRef := byoHost.Status.MachineRef
+	if machineRef == nil {
+		return nil
+	}
+
+	if machineRef.Kind == "ByoMachine" {
+		byoMachine := &infrastructurev1beta1.ByoMachine{}
+		err := r.Client.Get(ctx, client.ObjectKey{Namespace: machineRef.Namespace, Name: machineRef.Name}, byoMachine)
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		if err == nil {
+			machine, err := util.GetOwnerMachine(ctx, r.Client, byoMachine.ObjectMeta)
+			if err != nil {
+				return err
+			}
+			if machine != nil {
+				if !machine.DeletionTimestamp.IsZero() {
+					return nil
+				}
+				// the pods of the node are kept for forensics as well
+				machineHelper, err := patch.NewHelper(machine, r.Client)
+				if err != nil {
+					return err
+				}
+				annotations.AddAnnotations(machine, map[string]string{clusterv1.ExcludeNodeDrainingAnnotation: ""})
+				if err := machineHelper.Patch(ctx, machine); err != nil {
+					return client.IgnoreNotFound(err)
+				}
+				logger.Info("Deleting the Machine of the quarantined ByoHost", "machine", machine.Name)
+				return client.IgnoreNotFound(r.Client.Delete(ctx, machine))
+			}
+		}
+	}
+
+	helper, err := patch.NewHelper(byoHost, r.Client)
+	if err != nil {
+		return err
+	}
+	logger.Info("Detaching the quarantined ByoHost without cleanup", "owner", machineRef.Kind+"/"+machineRef.Name)
+	detachWithoutCleanup(byoHost)
+	return helper.Patch(ctx, byoHost)
+}
+
+// reconcileReservation sets the HostReserved condition of the reserved ByoHosts, and
+// removes it once the reservation is lifted
+func (r *ByoHostReconciler) reconcileReservation(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost) error {
+	reserved := byoHost.Spec.Reserved || byoHost.Spec.ReservedFor != nil
+	if !reserved && !conditions.Has(byoHost, infrastructurev1beta1.HostReserved) {
+		return nil
+	}
+
+	helper, err := patch.NewHelper(byoHost, r.Client)
+	if err != nil {
+		return err
+	}
+	switch reservedFor := byoHost.Spec.ReservedFor; {
+	case !reserved:
+		conditions.Delete(byoHost, infrastructurev1beta1.HostReserved)
+	case reservedFor != nil:
+		namespace := reservedFor.Namespace
+		if namespace == "" {
+			namespace = byoHost.Namespace
+		}
+		cluster := &clusterv1.Cluster{}
+		err = r.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: reservedFor.Name}, cluster)
+		switch {
+		case apierrors.IsNotFound(err):
+			conditions.MarkFalse(byoHost, infrastructurev1beta1.HostReserved, infrastructurev1beta1.ReservedClusterNotFoundReason,
+				clusterv1.ConditionSeverityWarning, "Cluster %s/%s not found", namespace, reservedFor.Name)
+		case err != nil:
+			return err
+		default:
+			conditions.MarkTrue(byoHost, infrastructurev1beta1.HostReserved)
+		}
+	default:
+		conditions.MarkTrue(byoHost, infrastructurev1beta1.HostReserved)
+	}
+	return helper.Patch(ctx, byoHost, patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{infrastructurev1beta1.HostReserved}})
+}
+
+// reconcileSchedulability sets the HostSchedulable condition of the unschedulable ByoHosts, and
+// removes it once the host is back in the capacity pool
+func (r *ByoHostReconciler) reconcileSchedulability(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost) error {
+	unschedulable := isUnschedulable(byoHost)
+	reason, message := infrastructurev1beta1.HostUnschedulableReason, "the host is not attached to new machines"
+	severity := clusterv1.ConditionSeverityInfo
+	switch {
+	case isQuarantined(byoHost):
+		reason, message = infrastructurev1beta1.HostQuarantinedReason, "the host is quarantined"
+		if quarantineReason := byoHost.Annotations[infrastructurev1beta1.QuarantineAnnotation]; quarantineReason != "" {
+			message += ": " + quarantineReason
+		}
+		severity = clusterv1.ConditionSeverityWarning
+	case isExpected(byoHost):
+		reason, message = infrastructurev1beta1.HostNotEnrolledReason, "the host is expected from the host inventory, its agent has not registered it yet"
+	}
+	if unschedulable == conditions.IsFalse(byoHost, infrastructurev1beta1.HostSchedulable) &&
+		(!unschedulable || conditions.GetReason(byoHost, infrastructurev1beta1.HostSchedulable) == reason) {
+		return nil
+	}
+
+	helper, err := patch.NewHelper(byoHost, r.Client)
+	if err != nil {
+		return err
+	}
+	if unschedulable {
+		conditions.MarkFalse(byoHost, infrastructurev1beta1.HostSchedulable, reason, severity, "%s", message)
+	} else {
+		conditions.Delete(byoHost, infrastructurev1beta1.HostSchedulable)
+	}
+	return helper.Patch(ctx, byoHost, patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{infrastructurev1beta1.HostSchedulable}})
+}
+
+// byoHostReadyConditions are the conditions the Ready condition of a ByoHost summarizes: those of the
+// provisioning of the host and of the health of its agent. Its reservation and its schedulability are left out.
+var byoHostReadyConditions = []clusterv1.ConditionType{
+	infrastructurev1beta1.HostPreflightSucceeded,
+	infrastructurev1beta1.HostSecurityPosture,
+	infrastructurev1beta1.K8sComponentsInstallationSucceeded,
+	infrastructurev1beta1.K8sNodeBootstrapSucceeded,
+	infrastructurev1beta1.AgentUpgradeSucceeded,
+	infrastructurev1beta1.AgentDegraded,
+	infrastructurev1beta1.NodeComponentsHealthy,
+}
+
+// reconcileReadiness summarizes the conditions of the ByoHost in its Ready condition, and records the
+// generation of the host it was summarized for
+func (r *ByoHostReconciler) reconcileReadiness(ctx context.Context, byoHost *infrastructurev1beta1.ByoHost) error {
+	helper, err := patch.NewHelper(byoHost, r.Client)
+	if err != nil {
+		return err
+	}
+	ready := conditions.Get(byoHost, clusterv1.ReadyCondition)
+	byohconditions.SetSummary(byoHost, byoHostReadyConditions...)
+	if byoHost.Status.ObservedGeneration == byoHost.Generation &&
+		equality.Semantic.DeepEqual(ready, conditions.Get(byoHost, clusterv1.ReadyCondition)) {
+		return nil
+	}
+	return helper.Patch(ctx, byoHost,
+		patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{clusterv1.ReadyCondition}},
+		patch.WithStatusObservedGeneration{},
+	)
 }
 
 // SetupWithManager sets up the controller with the Manager.
-func (r *ByoHostReconciler) SetupWithManager(mgr ctrl.Manager) error {
+func (r *ByoHostReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&infrastructurev1beta1.ByoHost{}).
+		WithOptions(options).
 		Complete(r)
 }
@@ -0,0 +1,407 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	controllers "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/controllers/infrastructure"
+	byohconditions "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/pkg/conditions"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/test/builder"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("Controllers/ByohostController", func() {
+
+	var (
+		ctx                 context.Context
+		k8sClientUncached   client.Client
+		byoHost             *infrastructurev1beta1.ByoHost
+		byoHostReconciler   *controllers.ByoHostReconciler
+		byoHostLookupKey    types.NamespacedName
+		desiredAgentVersion = "v0.2.0"
+		agentBinaryRepo     = "projects.blah.com"
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		var clientErr error
+
+		k8sClientUncached, clientErr = client.New(cfg, client.Options{Scheme: scheme.Scheme})
+		Expect(clientErr).NotTo(HaveOccurred())
+
+		byoHostReconciler = &controllers.ByoHostReconciler{
+			Client:          k8sClientUncached,
+			AgentVersion:    desiredAgentVersion,
+			AgentBinaryRepo: agentBinaryRepo,
+		}
+
+		byoHost = builder.ByoHost(defaultNamespace, "byohost-agent-upgrade").Build()
+		Expect(k8sClientUncached.Create(ctx, byoHost)).Should(Succeed())
+		byoHostLookupKey = types.NamespacedName{Name: byoHost.Name, Namespace: byoHost.Namespace}
+	})
+
+	AfterEach(func() {
+		Expect(k8sClientUncached.Delete(ctx, byoHost)).Should(Succeed())
+	})
+
+	It("should not throw error when byohost does not exist", func() {
+		_, err := byoHostReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      "non-existent-byohost",
+				Namespace: defaultNamespace}})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should set the desired agent version on the byohost", func() {
+		_, err := byoHostReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoHostLookupKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		updatedByoHost := &infrastructurev1beta1.ByoHost{}
+		Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, updatedByoHost)).Should(Succeed())
+		Expect(updatedByoHost.Annotations).To(HaveKeyWithValue(infrastructurev1beta1.DesiredAgentVersionAnnotation, desiredAgentVersion))
+		Expect(updatedByoHost.Annotations).To(HaveKeyWithValue(infrastructurev1beta1.AgentBinaryRepoAnnotation, agentBinaryRepo))
+	})
+
+	It("should not request an upgrade when the agent version is not set", func() {
+		byoHostReconciler.AgentVersion = ""
+		_, err := byoHostReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoHostLookupKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		updatedByoHost := &infrastructurev1beta1.ByoHost{}
+		Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, updatedByoHost)).Should(Succeed())
+		Expect(updatedByoHost.Annotations).NotTo(HaveKey(infrastructurev1beta1.DesiredAgentVersionAnnotation))
+	})
+
+	Context("When the byohost is decommissioned", func() {
+		BeforeEach(func() {
+			byoHostReconciler.AgentVersion = ""
+			patchHelper, err := patch.NewHelper(byoHost, k8sClientUncached)
+			Expect(err).NotTo(HaveOccurred())
+			byoHost.Annotations = map[string]string{infrastructurev1beta1.DecommissionAnnotation: ""}
+			Expect(patchHelper.Patch(ctx, byoHost)).Should(Succeed())
+		})
+
+		It("should do nothing if the byohost is not attached", func() {
+			_, err := byoHostReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoHostLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			updatedByoHost := &infrastructurev1beta1.ByoHost{}
+			Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, updatedByoHost)).Should(Succeed())
+			Expect(updatedByoHost.Annotations).NotTo(HaveKey(infrastructurev1beta1.HostCleanupAnnotation))
+		})
+
+		It("should delete the machine the byohost is attached to", func() {
+			machine := builder.Machine(defaultNamespace, "decommissioned-machine").
+				WithClusterName(defaultClusterName).
+				Build()
+			Expect(k8sClientUncached.Create(ctx, machine)).Should(Succeed())
+			byoMachine := builder.ByoMachine(defaultNamespace, "decommissioned-byomachine").
+				WithClusterLabel(defaultClusterName).
+				WithOwnerMachine(machine).
+				Build()
+			Expect(k8sClientUncached.Create(ctx, byoMachine)).Should(Succeed())
+			defer func() {
+				Expect(k8sClientUncached.Delete(ctx, byoMachine)).Should(Succeed())
+			}()
+
+			patchHelper, err := patch.NewHelper(byoHost, k8sClientUncached)
+			Expect(err).NotTo(HaveOccurred())
+			byoHost.Status.MachineRef = &corev1.ObjectReference{
+				APIVersion: byoMachine.APIVersion,
+				Kind:       "ByoMachine",
+				Namespace:  byoMachine.Namespace,
+				Name:       byoMachine.Name,
+				UID:        byoMachine.UID,
+			}
+			Expect(patchHelper.Patch(ctx, byoHost)).Should(Succeed())
+
+			_, err = byoHostReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoHostLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			deletedMachine := &clusterv1.Machine{}
+			err = k8sClientUncached.Get(ctx, client.ObjectKeyFromObject(machine), deletedMachine)
+			Expect(apierrors.IsNotFound(err) || !deletedMachine.DeletionTimestamp.IsZero()).To(BeTrue())
+		})
+
+		It("should release the byohost attached to a byomachinepool", func() {
+			patchHelper, err := patch.NewHelper(byoHost, k8sClientUncached)
+			Expect(err).NotTo(HaveOccurred())
+			byoHost.Status.MachineRef = &corev1.ObjectReference{
+				APIVersion: infrastructurev1beta1.GroupVersion.String(),
+				Kind:       "ByoMachinePool",
+				Namespace:  defaultNamespace,
+				Name:       "my-pool",
+			}
+			Expect(patchHelper.Patch(ctx, byoHost)).Should(Succeed())
+
+			_, err = byoHostReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoHostLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			updatedByoHost := &infrastructurev1beta1.ByoHost{}
+			Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, updatedByoHost)).Should(Succeed())
+			Expect(updatedByoHost.Annotations).To(HaveKey(infrastructurev1beta1.HostCleanupAnnotation))
+		})
+
+		It("should defer the release until the maintenance window opens", func() {
+			patchHelper, err := patch.NewHelper(byoHost, k8sClientUncached)
+			Expect(err).NotTo(HaveOccurred())
+			byoHost.Annotations[infrastructurev1beta1.MaintenanceWindowAnnotation] = "0 0 1 1 * 1m"
+			byoHost.Status.MachineRef = &corev1.ObjectReference{
+				APIVersion: infrastructurev1beta1.GroupVersion.String(),
+				Kind:       "ByoMachinePool",
+				Namespace:  defaultNamespace,
+				Name:       "my-pool",
+			}
+			Expect(patchHelper.Patch(ctx, byoHost)).Should(Succeed())
+
+			result, err := byoHostReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoHostLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+
+			updatedByoHost := &infrastructurev1beta1.ByoHost{}
+			Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, updatedByoHost)).Should(Succeed())
+			Expect(updatedByoHost.Annotations).NotTo(HaveKey(infrastructurev1beta1.HostCleanupAnnotation))
+		})
+	})
+
+	Context("When the byohost is quarantined", func() {
+		BeforeEach(func() {
+			byoHostReconciler.AgentVersion = ""
+			patchHelper, err := patch.NewHelper(byoHost, k8sClientUncached)
+			Expect(err).NotTo(HaveOccurred())
+			byoHost.Annotations = map[string]string{
+				infrastructurev1beta1.QuarantineAnnotation:        "incident-42",
+				infrastructurev1beta1.MaintenanceWindowAnnotation: "0 0 1 1 * 1m",
+			}
+			Expect(patchHelper.Patch(ctx, byoHost)).Should(Succeed())
+		})
+
+		It("should mark the byohost quarantined", func() {
+			_, err := byoHostReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoHostLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			updatedByoHost := &infrastructurev1beta1.ByoHost{}
+			Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, updatedByoHost)).Should(Succeed())
+			condition := conditions.Get(updatedByoHost, infrastructurev1beta1.HostSchedulable)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Reason).To(Equal(infrastructurev1beta1.HostQuarantinedReason))
+			Expect(condition.Severity).To(Equal(clusterv1.ConditionSeverityWarning))
+			Expect(condition.Message).To(Equal("the host is quarantined: incident-42"))
+		})
+
+		It("should delete the machine the byohost is attached to without draining its node", func() {
+			machine := builder.Machine(defaultNamespace, "quarantined-machine").
+				WithClusterName(defaultClusterName).
+				Build()
+			Expect(k8sClientUncached.Create(ctx, machine)).Should(Succeed())
+			byoMachine := builder.ByoMachine(defaultNamespace, "quarantined-byomachine").
+				WithClusterLabel(defaultClusterName).
+				WithOwnerMachine(machine).
+				Build()
+			Expect(k8sClientUncached.Create(ctx, byoMachine)).Should(Succeed())
+			defer func() {
+				Expect(k8sClientUncached.Delete(ctx, byoMachine)).Should(Succeed())
+			}()
+
+			patchHelper, err := patch.NewHelper(byoHost, k8sClientUncached)
+			Expect(err).NotTo(HaveOccurred())
+			byoHost.Status.MachineRef = &corev1.ObjectReference{
+				APIVersion: byoMachine.APIVersion,
+				Kind:       "ByoMachine",
+				Namespace:  byoMachine.Namespace,
+				Name:       byoMachine.Name,
+				UID:        byoMachine.UID,
+			}
+			Expect(patchHelper.Patch(ctx, byoHost)).Should(Succeed())
+
+			_, err = byoHostReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoHostLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			deletedMachine := &clusterv1.Machine{}
+			err = k8sClientUncached.Get(ctx, client.ObjectKeyFromObject(machine), deletedMachine)
+			if err == nil {
+				Expect(deletedMachine.DeletionTimestamp.IsZero()).To(BeFalse())
+				Expect(deletedMachine.Annotations).To(HaveKey(clusterv1.ExcludeNodeDrainingAnnotation))
+			} else {
+				Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			}
+		})
+
+		It("should detach the byohost attached to a byomachinepool without cleanup", func() {
+			patchHelper, err := patch.NewHelper(byoHost, k8sClientUncached)
+			Expect(err).NotTo(HaveOccurred())
+			byoHost.Labels = map[string]string{
+				clusterv1.ClusterLabelName:                        defaultClusterName,
+				infrastructurev1beta1.AttachedByoMachinePoolLabel: defaultNamespace + ".my-pool",
+			}
+			byoHost.Status.MachineRef = &corev1.ObjectReference{
+				APIVersion: infrastructurev1beta1.GroupVersion.String(),
+				Kind:       "ByoMachinePool",
+				Namespace:  defaultNamespace,
+				Name:       "my-pool",
+			}
+			Expect(patchHelper.Patch(ctx, byoHost)).Should(Succeed())
+
+			_, err = byoHostReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoHostLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			updatedByoHost := &infrastructurev1beta1.ByoHost{}
+			Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, updatedByoHost)).Should(Succeed())
+			Expect(updatedByoHost.Status.MachineRef).To(BeNil())
+			Expect(updatedByoHost.Labels).NotTo(HaveKey(clusterv1.ClusterLabelName))
+			Expect(updatedByoHost.Labels).NotTo(HaveKey(infrastructurev1beta1.AttachedByoMachinePoolLabel))
+			Expect(updatedByoHost.Annotations).To(HaveKey(infrastructurev1beta1.HostCleanupAnnotation))
+			Expect(updatedByoHost.Annotations).To(HaveKey(infrastructurev1beta1.QuarantineAnnotation))
+		})
+	})
+
+	Context("When the byohost is reserved", func() {
+		reserve := func(reserved bool, reservedFor *corev1.ObjectReference) {
+			patchHelper, err := patch.NewHelper(byoHost, k8sClientUncached)
+			Expect(err).NotTo(HaveOccurred())
+			byoHost.Spec.Reserved = reserved
+			byoHost.Spec.ReservedFor = reservedFor
+			Expect(patchHelper.Patch(ctx, byoHost)).Should(Succeed())
+
+			_, err = byoHostReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoHostLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, byoHost)).Should(Succeed())
+		}
+
+		It("should mark the byohost reserved", func() {
+			reserve(true, nil)
+			Expect(conditions.IsTrue(byoHost, infrastructurev1beta1.HostReserved)).To(BeTrue())
+		})
+
+		It("should mark the byohost reserved for an existing cluster", func() {
+			reserve(false, &corev1.ObjectReference{Name: defaultClusterName})
+			Expect(conditions.IsTrue(byoHost, infrastructurev1beta1.HostReserved)).To(BeTrue())
+		})
+
+		It("should report the cluster the byohost is reserved for is not found", func() {
+			reserve(false, &corev1.ObjectReference{Name: "non-existent-cluster"})
+			Expect(*conditions.Get(byoHost, infrastructurev1beta1.HostReserved)).To(conditions.MatchCondition(clusterv1.Condition{
+				Type:     infrastructurev1beta1.HostReserved,
+				Status:   corev1.ConditionFalse,
+				Reason:   infrastructurev1beta1.ReservedClusterNotFoundReason,
+				Severity: clusterv1.ConditionSeverityWarning,
+				Message:  "Cluster " + defaultNamespace + "/non-existent-cluster not found",
+			}))
+		})
+
+		It("should remove the condition once the reservation is lifted", func() {
+			reserve(true, nil)
+			reserve(false, nil)
+			Expect(conditions.Has(byoHost, infrastructurev1beta1.HostReserved)).To(BeFalse())
+		})
+	})
+
+	Context("When the byohost is under maintenance", func() {
+		setUnschedulable := func(unschedulable bool, annotations map[string]string) {
+			patchHelper, err := patch.NewHelper(byoHost, k8sClientUncached)
+			Expect(err).NotTo(HaveOccurred())
+			byoHost.Spec.Unschedulable = unschedulable
+			byoHost.Annotations = annotations
+			Expect(patchHelper.Patch(ctx, byoHost)).Should(Succeed())
+
+			_, err = byoHostReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoHostLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, byoHost)).Should(Succeed())
+		}
+
+		It("should mark the byohost unschedulable", func() {
+			setUnschedulable(true, nil)
+			Expect(*conditions.Get(byoHost, infrastructurev1beta1.HostSchedulable)).To(conditions.MatchCondition(clusterv1.Condition{
+				Type:     infrastructurev1beta1.HostSchedulable,
+				Status:   corev1.ConditionFalse,
+				Reason:   infrastructurev1beta1.HostUnschedulableReason,
+				Severity: clusterv1.ConditionSeverityInfo,
+				Message:  "the host is not attached to new machines",
+			}))
+		})
+
+		It("should mark the byohost with the unschedulable annotation unschedulable", func() {
+			setUnschedulable(false, map[string]string{infrastructurev1beta1.UnschedulableAnnotation: ""})
+			Expect(conditions.IsFalse(byoHost, infrastructurev1beta1.HostSchedulable)).To(BeTrue())
+		})
+
+		It("should remove the condition once the maintenance is over", func() {
+			setUnschedulable(true, nil)
+			setUnschedulable(false, nil)
+			Expect(conditions.Has(byoHost, infrastructurev1beta1.HostSchedulable)).To(BeFalse())
+		})
+
+		It("should mark the byohost expected from the host inventory not enrolled", func() {
+			setUnschedulable(true, nil)
+			patchHelper, err := patch.NewHelper(byoHost, k8sClientUncached)
+			Expect(err).NotTo(HaveOccurred())
+			byoHost.Spec.Unschedulable = false
+			byoHost.Labels = map[string]string{infrastructurev1beta1.ExpectedHostLabel: ""}
+			Expect(patchHelper.Patch(ctx, byoHost)).Should(Succeed())
+
+			_, err = byoHostReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoHostLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, byoHost)).Should(Succeed())
+			Expect(conditions.GetReason(byoHost, infrastructurev1beta1.HostSchedulable)).To(Equal(infrastructurev1beta1.HostNotEnrolledReason))
+		})
+	})
+
+	Context("When the byohost reports its conditions", func() {
+		setConditions := func(setters ...func(*infrastructurev1beta1.ByoHost)) {
+			patchHelper, err := patch.NewHelper(byoHost, k8sClientUncached)
+			Expect(err).NotTo(HaveOccurred())
+			for _, set := range setters {
+				set(byoHost)
+			}
+			Expect(patchHelper.Patch(ctx, byoHost)).Should(Succeed())
+
+			_, err = byoHostReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoHostLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, byoHost)).Should(Succeed())
+		}
+
+		It("should mark the byohost ready for its generation", func() {
+			setConditions(func(h *infrastructurev1beta1.ByoHost) {
+				conditions.MarkTrue(h, infrastructurev1beta1.K8sComponentsInstallationSucceeded)
+			})
+			Expect(conditions.IsTrue(byoHost, clusterv1.ReadyCondition)).To(BeTrue())
+			Expect(byoHost.Status.ObservedGeneration).To(Equal(byoHost.Generation))
+		})
+
+		It("should summarize the failed conditions in the ready condition", func() {
+			setConditions(func(h *infrastructurev1beta1.ByoHost) {
+				conditions.MarkFalse(h, infrastructurev1beta1.K8sComponentsInstallationSucceeded, infrastructurev1beta1.K8sComponentsInstallationFailedReason,
+					clusterv1.ConditionSeverityError, "")
+			})
+			Expect(conditions.IsFalse(byoHost, clusterv1.ReadyCondition)).To(BeTrue())
+			Expect(conditions.GetReason(byoHost, clusterv1.ReadyCondition)).To(Equal(infrastructurev1beta1.K8sComponentsInstallationFailedReason))
+		})
+
+		It("should not be ready while its agent is degraded", func() {
+			setConditions(func(h *infrastructurev1beta1.ByoHost) {
+				byohconditions.MarkNegative(h, infrastructurev1beta1.AgentDegraded, infrastructurev1beta1.CrashLoopReason,
+					clusterv1.ConditionSeverityWarning, "")
+			})
+			Expect(conditions.IsFalse(byoHost, clusterv1.ReadyCondition)).To(BeTrue())
+			Expect(conditions.GetReason(byoHost, clusterv1.ReadyCondition)).To(Equal(infrastructurev1beta1.CrashLoopReason))
+
+			setConditions(func(h *infrastructurev1beta1.ByoHost) {
+				byohconditions.ClearNegative(h, infrastructurev1beta1.AgentDegraded)
+			})
+			Expect(conditions.IsTrue(byoHost, clusterv1.ReadyCondition)).To(BeTrue())
+		})
+	})
+})
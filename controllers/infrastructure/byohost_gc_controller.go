@@ -0,0 +1,70 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+)
+
+// ByoHostGCReconciler garbage collects the registrations of the hosts gone for good, e.g. reimaged
+// under another name: the ByoHosts without a MachineRef whose host agent has not sent a heartbeat
+// for longer than the StaleTimeout are deleted
+type ByoHostGCReconciler struct {
+	client.Client
+
+	// StaleTimeout is how long a ByoHost is kept after the last heartbeat of its host agent
+	StaleTimeout time.Duration
+}
+
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byohosts,verbs=get;list;watch;delete
+
+// Reconcile deletes the ByoHost once it is stale, or requeues it for when it would be. The ByoHosts
+// annotated with SkipGarbageCollectionAnnotation or QuarantineAnnotation, and those of host agents
+// not sending heartbeats, are never deleted.
+func (r *ByoHostGCReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	byoHost := &infrastructurev1beta1.ByoHost{}
+	if err := r.Client.Get(ctx, req.NamespacedName, byoHost); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	if !byoHost.ObjectMeta.DeletionTimestamp.IsZero() || byoHost.Status.MachineRef != nil || byoHost.Status.LastHeartbeatTime == nil {
+		return ctrl.Result{}, nil
+	}
+	if _, ok := byoHost.Annotations[infrastructurev1beta1.SkipGarbageCollectionAnnotation]; ok {
+		return ctrl.Result{}, nil
+	}
+	// a quarantined host is detached from its machine and kept for forensics, with its pending cleanup
+	if isQuarantined(byoHost) {
+		return ctrl.Result{}, nil
+	}
+
+	// the heartbeats of live hosts requeue them, the requeue only matters for the dead ones
+	if remaining := r.StaleTimeout - time.Since(byoHost.Status.LastHeartbeatTime.Time); remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+
+	logger.Info("Deleting stale ByoHost", "lastHeartbeatTime", byoHost.Status.LastHeartbeatTime)
+	return ctrl.Result{}, client.IgnoreNotFound(r.Client.Delete(ctx, byoHost))
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ByoHostGCReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("byohostgc").
+		For(&infrastructurev1beta1.ByoHost{}).
+		Complete(r)
+}
@@ -0,0 +1,139 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	controllers "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/controllers/infrastructure"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/test/builder"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("Controllers/ByohostGCController", func() {
+
+	var (
+		ctx                 context.Context
+		k8sClientUncached   client.Client
+		byoHost             *infrastructurev1beta1.ByoHost
+		byoHostGCReconciler *controllers.ByoHostGCReconciler
+		byoHostLookupKey    types.NamespacedName
+		staleTimeout        = time.Hour
+	)
+
+	setHeartbeat := func(age time.Duration) {
+		patchHelper, err := patch.NewHelper(byoHost, k8sClientUncached)
+		Expect(err).NotTo(HaveOccurred())
+		lastHeartbeatTime := metav1.NewTime(time.Now().Add(-age))
+		byoHost.Status.LastHeartbeatTime = &lastHeartbeatTime
+		Expect(patchHelper.Patch(ctx, byoHost)).Should(Succeed())
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		var clientErr error
+
+		k8sClientUncached, clientErr = client.New(cfg, client.Options{Scheme: scheme.Scheme})
+		Expect(clientErr).NotTo(HaveOccurred())
+
+		byoHostGCReconciler = &controllers.ByoHostGCReconciler{
+			Client:       k8sClientUncached,
+			StaleTimeout: staleTimeout,
+		}
+
+		byoHost = builder.ByoHost(defaultNamespace, "byohost-gc").Build()
+		Expect(k8sClientUncached.Create(ctx, byoHost)).Should(Succeed())
+		byoHostLookupKey = types.NamespacedName{Name: byoHost.Name, Namespace: byoHost.Namespace}
+	})
+
+	AfterEach(func() {
+		Expect(client.IgnoreNotFound(k8sClientUncached.Delete(ctx, byoHost))).Should(Succeed())
+	})
+
+	It("should delete the byohost once its heartbeat is stale", func() {
+		setHeartbeat(2 * staleTimeout)
+
+		_, err := byoHostGCReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoHostLookupKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		err = k8sClientUncached.Get(ctx, byoHostLookupKey, &infrastructurev1beta1.ByoHost{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should requeue the byohost for when its heartbeat would be stale", func() {
+		setHeartbeat(staleTimeout / 2)
+
+		result, err := byoHostGCReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoHostLookupKey})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeNumerically("~", staleTimeout/2, time.Minute))
+		Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, &infrastructurev1beta1.ByoHost{})).Should(Succeed())
+	})
+
+	It("should not delete the byohost of an agent not sending heartbeats", func() {
+		result, err := byoHostGCReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoHostLookupKey})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeZero())
+		Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, &infrastructurev1beta1.ByoHost{})).Should(Succeed())
+	})
+
+	It("should not delete the byohost attached to a machine", func() {
+		patchHelper, err := patch.NewHelper(byoHost, k8sClientUncached)
+		Expect(err).NotTo(HaveOccurred())
+		byoHost.Status.MachineRef = &corev1.ObjectReference{Kind: "ByoMachine", Namespace: defaultNamespace, Name: "byomachine-gc"}
+		Expect(patchHelper.Patch(ctx, byoHost)).Should(Succeed())
+		setHeartbeat(2 * staleTimeout)
+
+		_, err = byoHostGCReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoHostLookupKey})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, byoHost)).Should(Succeed())
+
+		patchHelper, err = patch.NewHelper(byoHost, k8sClientUncached)
+		Expect(err).NotTo(HaveOccurred())
+		byoHost.Status.MachineRef = nil
+		Expect(patchHelper.Patch(ctx, byoHost)).Should(Succeed())
+	})
+
+	It("should not delete the byohost opted out of the garbage collection", func() {
+		patchHelper, err := patch.NewHelper(byoHost, k8sClientUncached)
+		Expect(err).NotTo(HaveOccurred())
+		byoHost.Annotations = map[string]string{infrastructurev1beta1.SkipGarbageCollectionAnnotation: ""}
+		Expect(patchHelper.Patch(ctx, byoHost)).Should(Succeed())
+		setHeartbeat(2 * staleTimeout)
+
+		_, err = byoHostGCReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoHostLookupKey})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, &infrastructurev1beta1.ByoHost{})).Should(Succeed())
+	})
+
+	It("should not delete the quarantined byohost detached from its machine", func() {
+		patchHelper, err := patch.NewHelper(byoHost, k8sClientUncached)
+		Expect(err).NotTo(HaveOccurred())
+		byoHost.Annotations = map[string]string{
+			infrastructurev1beta1.QuarantineAnnotation:  "compromised",
+			infrastructurev1beta1.HostCleanupAnnotation: "",
+		}
+		Expect(patchHelper.Patch(ctx, byoHost)).Should(Succeed())
+		setHeartbeat(2 * staleTimeout)
+
+		result, err := byoHostGCReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoHostLookupKey})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeZero())
+
+		updatedByoHost := &infrastructurev1beta1.ByoHost{}
+		Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, updatedByoHost)).Should(Succeed())
+		Expect(updatedByoHost.Annotations).To(HaveKey(infrastructurev1beta1.QuarantineAnnotation))
+		Expect(updatedByoHost.Annotations).To(HaveKey(infrastructurev1beta1.HostCleanupAnnotation))
+	})
+})
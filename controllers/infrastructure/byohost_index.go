@@ -0,0 +1,137 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ByoHostAvailableIndex indexes the available ByoHosts, i.e. neither attached to a machine nor bound
+	// to a claim, by the namespaces whose machines can use them, and by their labels in these namespaces
+	// in the <namespace>/<key>=<value> form, for the host selection to look the candidates up by the
+	// selector of the machine rather than listing all the hosts
+	ByoHostAvailableIndex = "byohost.available"
+
+	// ByoHostClaimIndex indexes the ByoHosts bound to a ByoHostClaim by the value of their ByoHostClaimLabel
+	ByoHostClaimIndex = "byohost.claim"
+
+	// ByoMachineWaitingIndex indexes the ByoMachines waiting for an available host or for their claim
+	// to be bound, for the updates of the hosts to requeue them without listing all the machines
+	ByoMachineWaitingIndex = "byomachine.waiting"
+)
+
+// addByoHostIndexes adds the indexes of the host selection to the cache of the manager
+func addByoHostIndexes(ctx context.Context, mgr ctrl.Manager) error {
+	indexer := mgr.GetFieldIndexer()
+	if err := indexer.IndexField(ctx, &infrav1.ByoHost{}, ByoHostAvailableIndex, indexAvailableByoHost); err != nil {
+		return err
+	}
+	if err := indexer.IndexField(ctx, &infrav1.ByoHost{}, ByoHostClaimIndex, indexClaimedByoHost); err != nil {
+		return err
+	}
+	return indexer.IndexField(ctx, &infrav1.ByoMachine{}, ByoMachineWaitingIndex, indexWaitingByoMachine)
+}
+
+// indexAvailableByoHost returns the keys of the ByoHostAvailableIndex of the host, none when it is not available
+func indexAvailableByoHost(o client.Object) []string {
+	host, ok := o.(*infrav1.ByoHost)
+	if !ok {
+		return nil
+	}
+	if _, attached := host.Labels[clusterv1.ClusterLabelName]; attached {
+		return nil
+	}
+	if _, claimed := host.Labels[infrav1.ByoHostClaimLabel]; claimed {
+		return nil
+	}
+	namespaces := append([]string{host.Namespace}, host.Spec.AllowedNamespaces...)
+	keys := make([]string, 0, len(namespaces)*(len(host.Labels)+1))
+	for _, namespace := range namespaces {
+		keys = append(keys, namespace)
+		for key, value := range host.Labels {
+			keys = append(keys, availableByoHostKey(namespace, key+"="+value))
+		}
+	}
+	return keys
+}
+
+// indexClaimedByoHost returns the claim the host is bound to, if any
+func indexClaimedByoHost(o client.Object) []string {
+	claim, ok := o.GetLabels()[infrav1.ByoHostClaimLabel]
+	if !ok {
+		return nil
+	}
+	return []string{claim}
+}
+
+// indexWaitingByoMachine returns "true" for the machines waiting for a host
+func indexWaitingByoMachine(o client.Object) []string {
+	byoMachine, ok := o.(*infrav1.ByoMachine)
+	if !ok {
+		return nil
+	}
+	reason := conditions.GetReason(byoMachine, infrav1.BYOHostReady)
+	if reason != infrav1.WaitingForAvailableHostReason && reason != infrav1.WaitingForClaimReason {
+		return nil
+	}
+	return []string{"true"}
+}
+
+// availableByoHostKey returns the key of the ByoHostAvailableIndex of the hosts with the label, or of all
+// the hosts when the label is empty, the machines of the namespace can use
+func availableByoHostKey(namespace, label string) string {
+	if label == "" {
+		return namespace
+	}
+	return namespace + "/" + label
+}
+
+// listAvailableByoHosts lists the available hosts matching the selector which the machines of the namespace
+// may use, i.e. of the namespace or shared with it, before their host pools are checked. They are looked up
+// in the ByoHostAvailableIndex by a label the selector requires, if any.
+func listAvailableByoHosts(ctx context.Context, c client.Reader, namespace string, selector labels.Selector) ([]infrav1.ByoHost, error) {
+	label := indexedLabel(selector)
+	seen := map[types.NamespacedName]bool{}
+	var hosts []infrav1.ByoHost
+	for _, usableFrom := range []string{namespace, AllNamespaces} {
+		hostsList := &infrav1.ByoHostList{}
+		if err := c.List(ctx, hostsList, client.MatchingFields{ByoHostAvailableIndex: availableByoHostKey(usableFrom, label)},
+			client.MatchingLabelsSelector{Selector: selector}); err != nil {
+			return nil, err
+		}
+		for i := range hostsList.Items {
+			key := client.ObjectKeyFromObject(&hostsList.Items[i])
+			if !seen[key] {
+				seen[key] = true
+				hosts = append(hosts, hostsList.Items[i])
+			}
+		}
+	}
+	return hosts, nil
+}
+
+// indexedLabel returns a label required by the selector in the key=value form, empty when it requires none
+func indexedLabel(selector labels.Selector) string {
+	requirements, _ := selector.Requirements()
+	for _, requirement := range requirements {
+		values := requirement.Values().List()
+		switch requirement.Operator() {
+		case selection.Equals, selection.DoubleEquals, selection.In:
+			if len(values) == 1 {
+				return requirement.Key() + "=" + values[0]
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,392 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// filterSchedulableByoHosts drops the unschedulable hosts, and the hosts whose cleanup is pending,
+// e.g. the hosts detached while quarantined
+func filterSchedulableByoHosts(hosts []infrav1.ByoHost) []infrav1.ByoHost {
+	schedulable := make([]infrav1.ByoHost, 0, len(hosts))
+	for i := range hosts {
+		if _, cleanupPending := hosts[i].Annotations[infrav1.HostCleanupAnnotation]; cleanupPending || isUnschedulable(&hosts[i]) {
+			continue
+		}
+		schedulable = append(schedulable, hosts[i])
+	}
+	return schedulable
+}
+
+// isUnschedulable tells if the host is marked unschedulable, through its spec or the UnschedulableAnnotation,
+// is quarantined, or is expected from a host inventory and not registered yet
+func isUnschedulable(host *infrav1.ByoHost) bool {
+	_, ok := host.Annotations[infrav1.UnschedulableAnnotation]
+	return ok || host.Spec.Unschedulable || isQuarantined(host) || isExpected(host)
+}
+
+// isQuarantined tells if the host is quarantined for forensics, see QuarantineAnnotation
+func isQuarantined(host *infrav1.ByoHost) bool {
+	_, ok := host.Annotations[infrav1.QuarantineAnnotation]
+	return ok
+}
+
+// detachWithoutCleanup detaches the quarantined host from its machine without the host agent resetting it.
+// The cleanup of the host is left pending, the host agent runs it once the quarantine is lifted.
+func detachWithoutCleanup(host *infrav1.ByoHost) {
+	host.Status.MachineRef = nil
+	host.Spec.BootstrapSecret = nil
+	delete(host.Labels, clusterv1.ClusterLabelName)
+	delete(host.Labels, infrav1.AttachedByoMachineLabel)
+	delete(host.Labels, infrav1.AttachedByoMachinePoolLabel)
+	if host.Annotations == nil {
+		host.Annotations = map[string]string{}
+	}
+	host.Annotations[infrav1.HostCleanupAnnotation] = ""
+}
+
+// isExpected tells if the host is a placeholder imported from a host inventory, see ExpectedHostLabel
+func isExpected(host *infrav1.ByoHost) bool {
+	_, ok := host.Labels[infrav1.ExpectedHostLabel]
+	return ok
+}
+
+// filterByoHostsByDevices keeps the hosts reporting the devices required by the ByoMachine, see DeviceRequirements
+func filterByoHostsByDevices(hosts []infrav1.ByoHost, requirements *infrav1.DeviceRequirements) []infrav1.ByoHost {
+	if requirements == nil {
+		return hosts
+	}
+	matching := make([]infrav1.ByoHost, 0, len(hosts))
+	for i := range hosts {
+		if hasDevices(&hosts[i].Status.HostDetails, requirements) {
+			matching = append(matching, hosts[i])
+		}
+	}
+	return matching
+}
+
+// hasDevices tells if the host reports the required GPUs, huge pages, SR-IOV virtual functions, CPUs and NUMA nodes
+func hasDevices(hostInfo *infrav1.HostInfo, requirements *infrav1.DeviceRequirements) bool {
+	if requirements.CPUs != nil || requirements.NUMANodes != nil {
+		topology := hostInfo.CPUTopology
+		if topology == nil {
+			return false
+		}
+		if requirements.CPUs != nil && topology.CPUs < *requirements.CPUs {
+			return false
+		}
+		if requirements.NUMANodes != nil && int32(len(topology.NUMANodes)) < *requirements.NUMANodes {
+			return false
+		}
+	}
+	if gpus := requirements.GPUs; gpus != nil {
+		count := int32(0)
+		for _, gpu := range hostInfo.GPUs {
+			if matchesGPU(gpu, gpus) {
+				count += gpu.Count
+			}
+		}
+		if count < gpus.Count {
+			return false
+		}
+	}
+	for _, hugePages := range requirements.HugePages {
+		count := int64(0)
+		for _, hostHugePages := range hostInfo.HugePages {
+			if hostHugePages.PageSize.Cmp(hugePages.PageSize) == 0 {
+				count = hostHugePages.Count
+			}
+		}
+		if count < hugePages.Count {
+			return false
+		}
+	}
+	if requirements.SRIOVVirtualFunctions != nil {
+		for _, iface := range hostInfo.SRIOVNetworkInterfaces {
+			if iface.TotalVFs >= *requirements.SRIOVVirtualFunctions {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// matchesGPU tells if the GPUs are of the vendor and model of the requirement, when set
+func matchesGPU(gpu infrav1.GPUInfo, requirement *infrav1.GPURequirement) bool {
+	if vendor := requirement.Vendor; vendor != "" && !strings.EqualFold(vendor, gpu.VendorID) && !strings.EqualFold(vendor, gpu.Vendor) {
+		return false
+	}
+	if model := requirement.Model; model != "" && !strings.EqualFold(model, gpu.DeviceID) &&
+		!strings.Contains(strings.ToLower(gpu.Model), strings.ToLower(model)) {
+		return false
+	}
+	return true
+}
+
+// filterReservedByoHosts applies the host reservations. With a hostRef only the pinned host is
+// kept, otherwise the reserved hosts are dropped. The hosts reserved for another cluster are
+// always dropped.
+func filterReservedByoHosts(hosts []infrav1.ByoHost, cluster *clusterv1.Cluster, hostRef *corev1.ObjectReference, namespace string) []infrav1.ByoHost {
+	available := make([]infrav1.ByoHost, 0, len(hosts))
+	for i := range hosts {
+		host := &hosts[i]
+		if hostRef != nil {
+			hostRefNamespace := hostRef.Namespace
+			if hostRefNamespace == "" {
+				hostRefNamespace = namespace
+			}
+			if host.Name != hostRef.Name || host.Namespace != hostRefNamespace {
+				continue
+			}
+		} else if host.Spec.Reserved {
+			continue
+		}
+		if !isReservedForCluster(host, cluster) {
+			continue
+		}
+		available = append(available, *host)
+	}
+	return available
+}
+
+// pinnedByoHostUnavailability tells why the ByoHost pinned by the ByoMachine cannot be attached to it,
+// e.g. the machine or the cluster it is already attached to
+func pinnedByoHostUnavailability(ctx context.Context, c client.Client, machineScope *byoMachineScope) (string, error) {
+	byoMachine := machineScope.ByoMachine
+	key := client.ObjectKey{Namespace: byoMachine.Spec.HostRef.Namespace, Name: byoMachine.Spec.HostRef.Name}
+	if key.Namespace == "" {
+		key.Namespace = byoMachine.Namespace
+	}
+	host := &infrav1.ByoHost{}
+	if err := c.Get(ctx, key, host); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "it does not exist", nil
+		}
+		return "", err
+	}
+	accessible, err := filterAccessibleByoHosts(ctx, c, byoMachine.Namespace, []infrav1.ByoHost{*host})
+	if err != nil {
+		return "", err
+	}
+	selector := labels.Everything()
+	if byoMachine.Spec.Selector != nil {
+		if selector, err = metav1.LabelSelectorAsSelector(byoMachine.Spec.Selector); err != nil {
+			return "", err
+		}
+	}
+
+	switch machineRef := host.Status.MachineRef; {
+	case len(accessible) == 0:
+		return fmt.Sprintf("it cannot be used from namespace %s", byoMachine.Namespace), nil
+	case machineRef != nil:
+		return fmt.Sprintf("it is attached to %s %s/%s", machineRef.Kind, machineRef.Namespace, machineRef.Name), nil
+	case host.Labels[infrav1.ByoHostClaimLabel] != "":
+		return fmt.Sprintf("it is bound to ByoHostClaim %s", host.Labels[infrav1.ByoHostClaimLabel]), nil
+	case host.Labels[clusterv1.ClusterLabelName] != "":
+		return fmt.Sprintf("it is attached to cluster %s", host.Labels[clusterv1.ClusterLabelName]), nil
+	case !isReservedForCluster(host, machineScope.Cluster):
+		return fmt.Sprintf("it is reserved for cluster %s", host.Spec.ReservedFor.Name), nil
+	case !selector.Matches(labels.Set(host.Labels)):
+		return "it does not match the selector of the ByoMachine", nil
+	case byoMachine.Spec.Devices != nil && !hasDevices(&host.Status.HostDetails, byoMachine.Spec.Devices):
+		return "it does not have the devices of the ByoMachine", nil
+	default:
+		return "it is not available", nil
+	}
+}
+
+// isReservedForCluster tells if the host can be attached to the cluster, i.e. it is
+// not reserved for another cluster
+func isReservedForCluster(host *infrav1.ByoHost, cluster *clusterv1.Cluster) bool {
+	reservedFor := host.Spec.ReservedFor
+	if reservedFor == nil {
+		return true
+	}
+	reservedNamespace := reservedFor.Namespace
+	if reservedNamespace == "" {
+		reservedNamespace = host.Namespace
+	}
+	return reservedFor.Name == cluster.Name && reservedNamespace == cluster.Namespace
+}
+
+// unavailableByoHosts counts the ByoHosts the namespace of a ByoMachine can use, and those of them
+// filtered out of its host selection by each criterion
+type unavailableByoHosts struct {
+	total    int
+	selector int
+	devices  int
+	capacity int
+	taints   int
+}
+
+func (u unavailableByoHosts) String() string {
+	return fmt.Sprintf("none of the %d ByoHosts is available: %d filtered by the selector, %d by devices, %d by capacity, %d by taints",
+		u.total, u.selector, u.devices, u.capacity, u.taints)
+}
+
+// countUnavailableByoHosts counts the hosts filtered out of the selection of the ByoMachine by its selector,
+// by devices, by capacity, i.e. attached, claimed or reserved, and by taints, i.e. unschedulable
+func countUnavailableByoHosts(ctx context.Context, c client.Client, machineScope *byoMachineScope) (unavailableByoHosts, error) {
+	hostsList := &infrav1.ByoHostList{}
+	if err := c.List(ctx, hostsList); err != nil {
+		return unavailableByoHosts{}, err
+	}
+	hosts, err := filterAccessibleByoHosts(ctx, c, machineScope.ByoMachine.Namespace, hostsList.Items)
+	if err != nil {
+		return unavailableByoHosts{}, err
+	}
+	unavailable := unavailableByoHosts{total: len(hosts)}
+
+	selector := labels.Everything()
+	if machineScope.ByoMachine.Spec.Selector != nil {
+		selector, err = metav1.LabelSelectorAsSelector(machineScope.ByoMachine.Spec.Selector)
+		if err != nil {
+			return unavailableByoHosts{}, err
+		}
+	}
+	devices := machineScope.ByoMachine.Spec.Devices
+	free := make([]infrav1.ByoHost, 0, len(hosts))
+	for i := range hosts {
+		switch {
+		case !selector.Matches(labels.Set(hosts[i].Labels)):
+			unavailable.selector++
+		case devices != nil && !hasDevices(&hosts[i].Status.HostDetails, devices):
+			unavailable.devices++
+		case hosts[i].Labels[clusterv1.ClusterLabelName] != "" || hosts[i].Labels[infrav1.ByoHostClaimLabel] != "":
+			unavailable.capacity++
+		default:
+			free = append(free, hosts[i])
+		}
+	}
+	reserved := filterReservedByoHosts(free, machineScope.Cluster, machineScope.ByoMachine.Spec.HostRef, machineScope.ByoMachine.Namespace)
+	unavailable.capacity += len(free) - len(reserved)
+	unavailable.taints = len(reserved) - len(filterSchedulableByoHosts(reserved))
+	return unavailable, nil
+}
+
+// failureDomainRequirement restricts the hosts to the failure domain of the Machine, when the ByoCluster
+// derives its failure domains from a host label. It is nil for the machines without a failure domain,
+// and for the ones pinned to a host or attached to a claimed host.
+func failureDomainRequirement(machineScope *byoMachineScope) (*labels.Requirement, error) {
+	failureDomainLabel := machineScope.ByoCluster.Spec.FailureDomainLabel
+	failureDomain := machineScope.Machine.Spec.FailureDomain
+	if failureDomainLabel == "" || failureDomain == nil || *failureDomain == "" ||
+		machineScope.ByoMachine.Spec.HostRef != nil || machineScope.ByoMachine.Spec.ClaimRef != nil {
+		return nil, nil
+	}
+	return labels.NewRequirement(failureDomainLabel, selection.Equals, []string{*failureDomain})
+}
+
+// spreadByoHosts keeps the hosts whose topology domain is not used yet by the peers
+// of the ByoMachine. With the Preferred policy all the hosts are returned when every
+// domain is already used.
+func spreadByoHosts(ctx context.Context, c client.Client, machineScope *byoMachineScope, hosts []infrav1.ByoHost) ([]infrav1.ByoHost, error) {
+	antiAffinity := machineScope.ByoMachine.Spec.AntiAffinity
+	usedDomains, err := usedTopologyDomains(ctx, c, machineScope)
+	if err != nil {
+		return nil, err
+	}
+
+	spread := make([]infrav1.ByoHost, 0, len(hosts))
+	for i := range hosts {
+		domain, ok := hosts[i].Labels[antiAffinity.TopologyKey]
+		if !ok {
+			continue
+		}
+		if _, used := usedDomains[domain]; !used {
+			spread = append(spread, hosts[i])
+		}
+	}
+
+	if len(spread) == 0 && antiAffinity.Policy != infrav1.AntiAffinityPolicyRequired {
+		return hosts, nil
+	}
+	return spread, nil
+}
+
+// usedTopologyDomains returns the topology domains of the hosts attached to the peers of the ByoMachine
+func usedTopologyDomains(ctx context.Context, c client.Client, machineScope *byoMachineScope) (map[string]struct{}, error) {
+	byoMachines := &infrav1.ByoMachineList{}
+	if err := c.List(ctx, byoMachines,
+		client.InNamespace(machineScope.ByoMachine.Namespace),
+		client.MatchingLabels{clusterv1.ClusterLabelName: machineScope.Cluster.Name}); err != nil {
+		return nil, err
+	}
+	peers := make(map[string]struct{}, len(byoMachines.Items))
+	for i := range byoMachines.Items {
+		if byoMachines.Items[i].Name == machineScope.ByoMachine.Name || !isPeerByoMachine(machineScope.ByoMachine, &byoMachines.Items[i]) {
+			continue
+		}
+		peers[byoMachines.Items[i].Namespace+"."+byoMachines.Items[i].Name] = struct{}{}
+	}
+
+	hosts := &infrav1.ByoHostList{}
+	if err := c.List(ctx, hosts, client.MatchingLabels{clusterv1.ClusterLabelName: machineScope.Cluster.Name}); err != nil {
+		return nil, err
+	}
+	topologyKey := machineScope.ByoMachine.Spec.AntiAffinity.TopologyKey
+	usedDomains := map[string]struct{}{}
+	for i := range hosts.Items {
+		if _, ok := peers[hosts.Items[i].Labels[infrav1.AttachedByoMachineLabel]]; !ok {
+			continue
+		}
+		if domain, ok := hosts.Items[i].Labels[topologyKey]; ok {
+			usedDomains[domain] = struct{}{}
+		}
+	}
+	return usedDomains, nil
+}
+
+// isPeerByoMachine tells if both ByoMachines belong to the control plane, or to the same MachineDeployment
+func isPeerByoMachine(byoMachine, other *infrav1.ByoMachine) bool {
+	_, isControlPlane := byoMachine.Labels[clusterv1.MachineControlPlaneLabelName]
+	_, otherIsControlPlane := other.Labels[clusterv1.MachineControlPlaneLabelName]
+	if isControlPlane || otherIsControlPlane {
+		return isControlPlane && otherIsControlPlane
+	}
+	return byoMachine.Labels[clusterv1.MachineDeploymentLabelName] == other.Labels[clusterv1.MachineDeploymentLabelName]
+}
+
+// claimByoHost labels the host with the cluster and the machine it is attached to, under an optimistic
+// lock so that the machines reconciled concurrently cannot claim the same host. False is returned when
+// the host was updated since it was listed, e.g. claimed by another machine.
+func claimByoHost(ctx context.Context, c client.Client, host *infrav1.ByoHost, clusterName, attachedLabel, attachedTo string) (bool, error) {
+	return labelByoHostWithLock(ctx, c, host, map[string]string{
+		clusterv1.ClusterLabelName: clusterName,
+		attachedLabel:              attachedTo,
+	})
+}
+
+// labelByoHostWithLock adds the labels to the host under an optimistic lock. False is returned, and
+// the host left unchanged, when the host was updated since it was read.
+func labelByoHostWithLock(ctx context.Context, c client.Client, host *infrav1.ByoHost, hostLabels map[string]string) (bool, error) {
+	original := host.DeepCopy()
+	if host.Labels == nil {
+		host.Labels = make(map[string]string)
+	}
+	for key, value := range hostLabels {
+		host.Labels[key] = value
+	}
+	if err := c.Patch(ctx, host, client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{})); err != nil {
+		*host = *original
+		if apierrors.IsConflict(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
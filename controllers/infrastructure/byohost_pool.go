@@ -0,0 +1,72 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// AllNamespaces allows the ByoMachines of every namespace to claim a ByoHost
+	AllNamespaces = "*"
+	// HostPoolUseVerb is the verb the ByoMachine namespace has to be granted on the byohosts
+	// of a host pool namespace to claim its hosts
+	HostPoolUseVerb = "use"
+
+	serviceAccountsGroupPrefix = "system:serviceaccounts:"
+)
+
+// filterAccessibleByoHosts keeps the hosts the ByoMachines of the namespace can claim: the
+// hosts of the namespace itself, and the hosts of the host pool namespaces which allow the
+// namespace and grant it the HostPoolUseVerb on their byohosts
+func filterAccessibleByoHosts(ctx context.Context, c client.Client, namespace string, hosts []infrav1.ByoHost) ([]infrav1.ByoHost, error) {
+	accessible := make([]infrav1.ByoHost, 0, len(hosts))
+	grantedPools := map[string]bool{}
+	for i := range hosts {
+		host := &hosts[i]
+		if host.Namespace != namespace {
+			if !containsString(host.Spec.AllowedNamespaces, namespace) && !containsString(host.Spec.AllowedNamespaces, AllNamespaces) {
+				continue
+			}
+			granted, ok := grantedPools[host.Namespace]
+			if !ok {
+				var err error
+				granted, err = canUseHostPool(ctx, c, namespace, host.Namespace)
+				if err != nil {
+					return nil, err
+				}
+				grantedPools[host.Namespace] = granted
+			}
+			if !granted {
+				continue
+			}
+		}
+		accessible = append(accessible, *host)
+	}
+	return accessible, nil
+}
+
+// canUseHostPool checks with a SubjectAccessReview whether the service accounts of the
+// namespace are granted the HostPoolUseVerb on the byohosts of the host pool namespace
+func canUseHostPool(ctx context.Context, c client.Client, namespace, poolNamespace string) (bool, error) {
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			Groups: []string{serviceAccountsGroupPrefix + namespace},
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: poolNamespace,
+				Verb:      HostPoolUseVerb,
+				Group:     infrav1.GroupVersion.Group,
+				Resource:  "byohosts",
+			},
+		},
+	}
+	if err := c.Create(ctx, review); err != nil {
+		return false, err
+	}
+	return review.Status.Allowed, nil
+}
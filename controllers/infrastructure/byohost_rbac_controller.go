@@ -0,0 +1,202 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// HostRBACLabel records the "<namespace>.<name>" of the ByoHost on the Roles and RoleBindings
+	// generated for its host agent
+	HostRBACLabel = "byoh.infrastructure.cluster.x-k8s.io/host-rbac"
+
+	hostRBACNamePrefix = "byoh-host-"
+	// hostAgentLogsSuffix names the ConfigMap the host agent streams its logs to after its ByoHost
+	hostAgentLogsSuffix = "-agent-logs"
+)
+
+// ByoHostRBACReconciler generates, for every ByoHost, the Roles and RoleBindings granting
+// its host agent identity access to its own ByoHost and to the secrets it references
+type ByoHostRBACReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byohosts,verbs=get;list;watch
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles;rolebindings,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile grants the host agent of the ByoHost the access to its ByoHost, to the events, the
+// debug bundle and agent logs ConfigMaps of its namespace and to the bootstrap and installation
+// secrets of the ByoHost. The access granted in other namespaces is revoked when the ByoHost no
+// longer references secrets there, or is deleted.
+func (r *ByoHostRBACReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	hostKey := req.Namespace + "." + req.Name
+
+	byoHost := &infrav1.ByoHost{}
+	if err := r.Client.Get(ctx, req.NamespacedName, byoHost); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		byoHost = nil
+	}
+	if byoHost != nil && !byoHost.DeletionTimestamp.IsZero() {
+		byoHost = nil
+	}
+
+	desiredRules := map[string][]rbacv1.PolicyRule{}
+	if byoHost != nil {
+		desiredRules = hostAgentRules(byoHost)
+	}
+
+	for namespace, rules := range desiredRules {
+		if err := r.reconcileRole(ctx, byoHost, namespace, rules); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	roles := &rbacv1.RoleList{}
+	if err := r.Client.List(ctx, roles, client.MatchingLabels{HostRBACLabel: hostKey}); err != nil {
+		return ctrl.Result{}, err
+	}
+	for i := range roles.Items {
+		if _, ok := desiredRules[roles.Items[i].Namespace]; ok {
+			continue
+		}
+		logger.Info("Revoking host agent access", "namespace", roles.Items[i].Namespace)
+		if err := r.Client.Delete(ctx, &roles.Items[i]); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	roleBindings := &rbacv1.RoleBindingList{}
+	if err := r.Client.List(ctx, roleBindings, client.MatchingLabels{HostRBACLabel: hostKey}); err != nil {
+		return ctrl.Result{}, err
+	}
+	for i := range roleBindings.Items {
+		if _, ok := desiredRules[roleBindings.Items[i].Namespace]; ok {
+			continue
+		}
+		if err := r.Client.Delete(ctx, &roleBindings.Items[i]); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{}, nil
+}
+
+// reconcileRole creates or updates the Role and RoleBinding of the host agent in the namespace.
+// Those in the namespace of the ByoHost are owned by the ByoHost.
+func (r *ByoHostRBACReconciler) reconcileRole(ctx context.Context, byoHost *infrav1.ByoHost, namespace string, rules []rbacv1.PolicyRule) error {
+	hostLabels := map[string]string{HostRBACLabel: byoHost.Namespace + "." + byoHost.Name}
+	setOwner := func(obj client.Object) error {
+		if namespace != byoHost.Namespace {
+			return nil
+		}
+		return controllerutil.SetControllerReference(byoHost, obj, r.Client.Scheme())
+	}
+
+	role := &rbacv1.Role{}
+	role.Name = hostRBACNamePrefix + byoHost.Name
+	role.Namespace = namespace
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, role, func() error {
+		role.Labels = hostLabels
+		role.Rules = rules
+		return setOwner(role)
+	}); err != nil {
+		return err
+	}
+
+	roleBinding := &rbacv1.RoleBinding{}
+	roleBinding.Name = role.Name
+	roleBinding.Namespace = namespace
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, roleBinding, func() error {
+		roleBinding.Labels = hostLabels
+		roleBinding.RoleRef = rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: role.Name}
+		roleBinding.Subjects = []rbacv1.Subject{{
+			APIGroup: rbacv1.GroupName,
+			Kind:     rbacv1.UserKind,
+			Name:     infrav1.HostAgentUsernamePrefix + byoHost.Name,
+		}}
+		return setOwner(roleBinding)
+	})
+	return err
+}
+
+// hostAgentRules returns, by namespace, the rules the host agent of the ByoHost is granted
+func hostAgentRules(byoHost *infrav1.ByoHost) map[string][]rbacv1.PolicyRule {
+	rules := map[string][]rbacv1.PolicyRule{
+		byoHost.Namespace: {
+			{
+				APIGroups:     []string{infrav1.GroupVersion.Group},
+				Resources:     []string{"byohosts"},
+				ResourceNames: []string{byoHost.Name},
+				Verbs:         []string{"get", "list", "watch", "update", "patch", "delete"},
+			},
+			{
+				APIGroups:     []string{infrav1.GroupVersion.Group},
+				Resources:     []string{"byohosts/status"},
+				ResourceNames: []string{byoHost.Name},
+				Verbs:         []string{"get", "update", "patch"},
+			},
+			{
+				APIGroups: []string{corev1.GroupName},
+				Resources: []string{"events"},
+				Verbs:     []string{"create", "patch"},
+			},
+			// the debug bundles and the streamed logs are uploaded as ConfigMaps
+			{
+				APIGroups: []string{corev1.GroupName},
+				Resources: []string{"configmaps"},
+				Verbs:     []string{"create"},
+			},
+			{
+				APIGroups:     []string{corev1.GroupName},
+				Resources:     []string{"configmaps"},
+				ResourceNames: []string{byoHost.Name + hostAgentLogsSuffix},
+				Verbs:         []string{"get", "update", "patch"},
+			},
+		},
+	}
+
+	secretNames := map[string][]string{}
+	for _, ref := range []*corev1.ObjectReference{byoHost.Spec.BootstrapSecret, byoHost.Spec.InstallationSecret} {
+		if ref == nil {
+			continue
+		}
+		namespace := ref.Namespace
+		if namespace == "" {
+			namespace = byoHost.Namespace
+		}
+		secretNames[namespace] = append(secretNames[namespace], ref.Name)
+	}
+	for namespace, names := range secretNames {
+		rules[namespace] = append(rules[namespace], rbacv1.PolicyRule{
+			APIGroups:     []string{corev1.GroupName},
+			Resources:     []string{"secrets"},
+			ResourceNames: names,
+			Verbs:         []string{"get"},
+		})
+	}
+	return rules
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ByoHostRBACReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("byohostrbac").
+		For(&infrav1.ByoHost{}).
+		Owns(&rbacv1.Role{}).
+		Owns(&rbacv1.RoleBinding{}).
+		Complete(r)
+}
@@ -0,0 +1,149 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	controllers "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/controllers/infrastructure"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/test/builder"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("Controllers/ByohostRBACController", func() {
+	var (
+		ctx                   context.Context
+		k8sClientUncached     client.Client
+		byoHost               *infrastructurev1beta1.ByoHost
+		byoHostRBACReconciler *controllers.ByoHostRBACReconciler
+		byoHostLookupKey      types.NamespacedName
+		clusterNamespace      *corev1.Namespace
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		var clientErr error
+
+		k8sClientUncached, clientErr = client.New(cfg, client.Options{Scheme: scheme.Scheme})
+		Expect(clientErr).NotTo(HaveOccurred())
+
+		byoHostRBACReconciler = &controllers.ByoHostRBACReconciler{
+			Client: k8sClientUncached,
+		}
+
+		clusterNamespace = builder.Namespace("byoh-cluster-namespace").Build()
+		Expect(k8sClientUncached.Create(ctx, clusterNamespace)).Should(Succeed())
+
+		byoHost = builder.ByoHost(defaultNamespace, "byohost-rbac").Build()
+		Expect(k8sClientUncached.Create(ctx, byoHost)).Should(Succeed())
+		byoHostLookupKey = types.NamespacedName{Name: byoHost.Name, Namespace: byoHost.Namespace}
+	})
+
+	AfterEach(func() {
+		Expect(client.IgnoreNotFound(k8sClientUncached.Delete(ctx, byoHost))).Should(Succeed())
+	})
+
+	getRole := func(namespace string) (*rbacv1.Role, error) {
+		role := &rbacv1.Role{}
+		err := k8sClientUncached.Get(ctx, types.NamespacedName{Name: "byoh-host-" + byoHost.Name, Namespace: namespace}, role)
+		return role, err
+	}
+
+	attach := func() {
+		patchHelper, err := patch.NewHelper(byoHost, k8sClientUncached)
+		Expect(err).NotTo(HaveOccurred())
+		byoHost.Spec.BootstrapSecret = &corev1.ObjectReference{Name: "bootstrap-secret", Namespace: clusterNamespace.Name}
+		Expect(patchHelper.Patch(ctx, byoHost)).Should(Succeed())
+
+		_, err = byoHostRBACReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoHostLookupKey})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	It("should grant the host agent the access to its own byohost", func() {
+		_, err := byoHostRBACReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoHostLookupKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		role, err := getRole(defaultNamespace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(role.Labels).To(HaveKeyWithValue(controllers.HostRBACLabel, defaultNamespace+"."+byoHost.Name))
+		Expect(role.Rules).To(ContainElement(rbacv1.PolicyRule{
+			APIGroups:     []string{infrastructurev1beta1.GroupVersion.Group},
+			Resources:     []string{"byohosts"},
+			ResourceNames: []string{byoHost.Name},
+			Verbs:         []string{"get", "list", "watch", "update", "patch", "delete"},
+		}))
+		Expect(role.Rules).To(ContainElement(rbacv1.PolicyRule{
+			APIGroups: []string{corev1.GroupName},
+			Resources: []string{"configmaps"},
+			Verbs:     []string{"create"},
+		}))
+		Expect(role.Rules).To(ContainElement(rbacv1.PolicyRule{
+			APIGroups:     []string{corev1.GroupName},
+			Resources:     []string{"configmaps"},
+			ResourceNames: []string{byoHost.Name + "-agent-logs"},
+			Verbs:         []string{"get", "update", "patch"},
+		}))
+		Expect(role.OwnerReferences).To(HaveLen(1))
+		Expect(role.OwnerReferences[0].Name).To(Equal(byoHost.Name))
+
+		roleBinding := &rbacv1.RoleBinding{}
+		Expect(k8sClientUncached.Get(ctx, types.NamespacedName{Name: role.Name, Namespace: defaultNamespace}, roleBinding)).Should(Succeed())
+		Expect(roleBinding.RoleRef.Name).To(Equal(role.Name))
+		Expect(roleBinding.Subjects).To(ConsistOf(rbacv1.Subject{
+			APIGroup: rbacv1.GroupName,
+			Kind:     rbacv1.UserKind,
+			Name:     infrastructurev1beta1.HostAgentUsernamePrefix + byoHost.Name,
+		}))
+	})
+
+	It("should grant the host agent the access to the bootstrap secret of the byohost", func() {
+		attach()
+
+		role, err := getRole(clusterNamespace.Name)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(role.Rules).To(ConsistOf(rbacv1.PolicyRule{
+			APIGroups:     []string{""},
+			Resources:     []string{"secrets"},
+			ResourceNames: []string{"bootstrap-secret"},
+			Verbs:         []string{"get"},
+		}))
+	})
+
+	It("should revoke the access to the bootstrap secret once the byohost is released", func() {
+		attach()
+
+		patchHelper, err := patch.NewHelper(byoHost, k8sClientUncached)
+		Expect(err).NotTo(HaveOccurred())
+		byoHost.Spec.BootstrapSecret = nil
+		Expect(patchHelper.Patch(ctx, byoHost)).Should(Succeed())
+		_, err = byoHostRBACReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoHostLookupKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = getRole(clusterNamespace.Name)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		_, err = getRole(defaultNamespace)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should revoke the access granted in other namespaces once the byohost is deleted", func() {
+		attach()
+
+		Expect(k8sClientUncached.Delete(ctx, byoHost)).Should(Succeed())
+		_, err := byoHostRBACReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoHostLookupKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = getRole(clusterNamespace.Name)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+})
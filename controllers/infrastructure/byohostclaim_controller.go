@@ -0,0 +1,289 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+)
+
+// ByoHostClaimReconciler binds the ByoHostClaims to the available ByoHosts matching their
+// requirements, and releases the hosts of the deleted claims. The pending claims of a namespace
+// are bound in the order they were created: a host matching an older pending claim is kept for it.
+type ByoHostClaimReconciler struct {
+	client.Client
+	Recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byohostclaims,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byohostclaims/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byohostclaims/finalizers,verbs=update
+
+// Reconcile binds the pending ByoHostClaim, or checks the host of the bound claim still exists
+func (r *ByoHostClaimReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	logger := log.FromContext(ctx)
+
+	claim := &infrav1.ByoHostClaim{}
+	if err := r.Client.Get(ctx, req.NamespacedName, claim); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	helper, err := patch.NewHelper(claim, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer func() {
+		if err = helper.Patch(ctx, claim); err != nil && reterr == nil {
+			logger.Error(err, "failed to patch byohostclaim")
+			reterr = err
+		}
+	}()
+
+	if !claim.ObjectMeta.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.reconcileDelete(ctx, claim)
+	}
+
+	controllerutil.AddFinalizer(claim, infrav1.ByoHostClaimFinalizer)
+	if claim.Status.HostRef != nil {
+		return ctrl.Result{}, r.reconcileBound(ctx, claim)
+	}
+	return ctrl.Result{}, r.bind(ctx, claim)
+}
+
+// reconcileBound marks the claim lost once its host is deleted
+func (r *ByoHostClaimReconciler) reconcileBound(ctx context.Context, claim *infrav1.ByoHostClaim) error {
+	host := &infrav1.ByoHost{}
+	err := r.Client.Get(ctx, client.ObjectKey{Namespace: claim.Status.HostRef.Namespace, Name: claim.Status.HostRef.Name}, host)
+	switch {
+	case apierrors.IsNotFound(err):
+		claim.Status.Phase = infrav1.ByoHostClaimLost
+		conditions.MarkFalse(claim, infrav1.HostBound, infrav1.ClaimedHostLostReason, clusterv1.ConditionSeverityWarning,
+			"ByoHost %s was deleted", claim.Status.HostRef.Name)
+		return nil
+	case err != nil:
+		return err
+	}
+	claim.Status.Phase = infrav1.ByoHostClaimBound
+	conditions.MarkTrue(claim, infrav1.HostBound)
+	return nil
+}
+
+// bind binds the claim to the first available host matching it which is not kept for an older claim
+func (r *ByoHostClaimReconciler) bind(ctx context.Context, claim *infrav1.ByoHostClaim) error {
+	logger := log.FromContext(ctx)
+
+	// the host may already be bound, e.g. when the claim lost its status while moved by clusterctl
+	boundHosts := &infrav1.ByoHostList{}
+	if err := r.Client.List(ctx, boundHosts, client.MatchingLabels{infrav1.ByoHostClaimLabel: claim.Namespace + "." + claim.Name}); err != nil {
+		return err
+	}
+	if len(boundHosts.Items) > 0 {
+		logger.Info("Restoring the binding of the ByoHostClaim", "byohost", boundHosts.Items[0].Name)
+		setClaimBound(claim, &boundHosts.Items[0])
+		return nil
+	}
+
+	candidates, err := r.listAvailableByoHosts(ctx, claim.Namespace)
+	if err != nil {
+		return err
+	}
+	olderClaims, err := r.listOlderPendingClaims(ctx, claim)
+	if err != nil {
+		return err
+	}
+	for i := range olderClaims {
+		for j := range candidates {
+			if claimMatches(&olderClaims[i], &candidates[j]) {
+				candidates = append(candidates[:j], candidates[j+1:]...)
+				break
+			}
+		}
+	}
+
+	for i := range candidates {
+		host := candidates[i]
+		if !claimMatches(claim, &host) {
+			continue
+		}
+		bound, err := labelByoHostWithLock(ctx, r.Client, &host, map[string]string{
+			infrav1.ByoHostClaimLabel: claim.Namespace + "." + claim.Name,
+		})
+		if err != nil {
+			return err
+		}
+		if !bound {
+			continue
+		}
+		logger.Info("Bound ByoHostClaim", "byohost", host.Name)
+		setClaimBound(claim, &host)
+		r.Recorder.Eventf(claim, corev1.EventTypeNormal, "ByoHostClaimBound", "Bound to ByoHost %s", host.Name)
+		return nil
+	}
+
+	logger.Info("No hosts available for the ByoHostClaim, waiting..")
+	claim.Status.Phase = infrav1.ByoHostClaimPending
+	conditions.MarkFalse(claim, infrav1.HostBound, infrav1.BYOHostsUnavailableReason, clusterv1.ConditionSeverityInfo, "")
+	return nil
+}
+
+// setClaimBound records the host the claim is bound to
+func setClaimBound(claim *infrav1.ByoHostClaim, host *infrav1.ByoHost) {
+	claim.Status.HostRef = &corev1.ObjectReference{
+		APIVersion: infrav1.GroupVersion.String(),
+		Kind:       "ByoHost",
+		Namespace:  host.Namespace,
+		Name:       host.Name,
+		UID:        host.UID,
+	}
+	claim.Status.Phase = infrav1.ByoHostClaimBound
+	conditions.MarkTrue(claim, infrav1.HostBound)
+}
+
+// listAvailableByoHosts lists the schedulable hosts the namespace can use, neither attached nor bound to a claim
+func (r *ByoHostClaimReconciler) listAvailableByoHosts(ctx context.Context, namespace string) ([]infrav1.ByoHost, error) {
+	unattached, _ := labels.NewRequirement(clusterv1.ClusterLabelName, selection.DoesNotExist, nil)
+	unclaimed, _ := labels.NewRequirement(infrav1.ByoHostClaimLabel, selection.DoesNotExist, nil)
+	hostsList := &infrav1.ByoHostList{}
+	if err := r.Client.List(ctx, hostsList, &client.ListOptions{LabelSelector: labels.NewSelector().Add(*unattached, *unclaimed)}); err != nil {
+		return nil, err
+	}
+	hosts, err := filterAccessibleByoHosts(ctx, r.Client, namespace, hostsList.Items)
+	if err != nil {
+		return nil, err
+	}
+	hosts = filterSchedulableByoHosts(hosts)
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].Name < hosts[j].Name })
+	return hosts, nil
+}
+
+// listOlderPendingClaims lists the pending claims of the namespace created before the claim, oldest first
+func (r *ByoHostClaimReconciler) listOlderPendingClaims(ctx context.Context, claim *infrav1.ByoHostClaim) ([]infrav1.ByoHostClaim, error) {
+	claims := &infrav1.ByoHostClaimList{}
+	if err := r.Client.List(ctx, claims, client.InNamespace(claim.Namespace)); err != nil {
+		return nil, err
+	}
+	older := []infrav1.ByoHostClaim{}
+	for i := range claims.Items {
+		other := &claims.Items[i]
+		if other.Status.HostRef != nil || !other.ObjectMeta.DeletionTimestamp.IsZero() || !createdBefore(&other.ObjectMeta, &claim.ObjectMeta) {
+			continue
+		}
+		older = append(older, *other)
+	}
+	sort.Slice(older, func(i, j int) bool { return createdBefore(&older[i].ObjectMeta, &older[j].ObjectMeta) })
+	return older, nil
+}
+
+// createdBefore orders the objects by creation time, then by name
+func createdBefore(a, b *metav1.ObjectMeta) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	return a.Name < b.Name
+}
+
+// claimMatches tells if the host meets the requirements of the claim. The reserved hosts only
+// match the claims pre-bound to them.
+func claimMatches(claim *infrav1.ByoHostClaim, host *infrav1.ByoHost) bool {
+	if hostRef := claim.Spec.HostRef; hostRef != nil {
+		hostRefNamespace := hostRef.Namespace
+		if hostRefNamespace == "" {
+			hostRefNamespace = claim.Namespace
+		}
+		if host.Name != hostRef.Name || host.Namespace != hostRefNamespace {
+			return false
+		}
+	} else if host.Spec.Reserved || host.Spec.ReservedFor != nil {
+		return false
+	}
+	if claim.Spec.Selector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(claim.Spec.Selector)
+		if err != nil || !selector.Matches(labels.Set(host.Labels)) {
+			return false
+		}
+	}
+	if claim.Spec.Architecture != "" && claim.Spec.Architecture != host.Status.HostDetails.Architecture {
+		return false
+	}
+	if claim.Spec.OSName != "" && claim.Spec.OSName != host.Status.HostDetails.OSName {
+		return false
+	}
+	return true
+}
+
+// reconcileDelete releases the host of the deleted claim
+func (r *ByoHostClaimReconciler) reconcileDelete(ctx context.Context, claim *infrav1.ByoHostClaim) error {
+	if claim.Status.HostRef != nil {
+		host := &infrav1.ByoHost{}
+		err := r.Client.Get(ctx, client.ObjectKey{Namespace: claim.Status.HostRef.Namespace, Name: claim.Status.HostRef.Name}, host)
+		switch {
+		case apierrors.IsNotFound(err):
+		case err != nil:
+			return err
+		case host.Labels[infrav1.ByoHostClaimLabel] == claim.Namespace+"."+claim.Name:
+			helper, err := patch.NewHelper(host, r.Client)
+			if err != nil {
+				return err
+			}
+			delete(host.Labels, infrav1.ByoHostClaimLabel)
+			if err = helper.Patch(ctx, host); err != nil {
+				return err
+			}
+			r.Recorder.Eventf(claim, corev1.EventTypeNormal, "ByoHostClaimReleased", "Released ByoHost %s", host.Name)
+		}
+	}
+	controllerutil.RemoveFinalizer(claim, infrav1.ByoHostClaimFinalizer)
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ByoHostClaimReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&infrav1.ByoHostClaim{}).
+		Watches(
+			&source.Kind{Type: &infrav1.ByoHost{}},
+			handler.EnqueueRequestsFromMapFunc(r.ByoHostToByoHostClaims),
+		).
+		Complete(r)
+}
+
+// ByoHostToByoHostClaims maps a ByoHost to the claim it is bound to and, as it may have become
+// available, to the pending claims
+func (r *ByoHostClaimReconciler) ByoHostToByoHostClaims(o client.Object) []reconcile.Request {
+	claims := &infrav1.ByoHostClaimList{}
+	if err := r.Client.List(context.TODO(), claims); err != nil {
+		return nil
+	}
+	boundTo := o.GetLabels()[infrav1.ByoHostClaimLabel]
+	requests := []reconcile.Request{}
+	for i := range claims.Items {
+		claim := &claims.Items[i]
+		if claim.Status.HostRef == nil || boundTo == claim.Namespace+"."+claim.Name {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(claim)})
+		}
+	}
+	return requests
+}
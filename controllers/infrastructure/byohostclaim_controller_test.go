@@ -0,0 +1,134 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	controllers "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/controllers/infrastructure"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/test/builder"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("Controllers/ByohostclaimController", func() {
+	var (
+		ctx                    context.Context
+		fakeClient             client.Client
+		byoHostClaimReconciler *controllers.ByoHostClaimReconciler
+		created                time.Time
+	)
+
+	newHost := func(name, arch string) *infrastructurev1beta1.ByoHost {
+		host := builder.ByoHost(defaultNamespace, name).Build()
+		// the fake client does not generate the names
+		host.Name = name
+		host.Status.HostDetails.Architecture = arch
+		return host
+	}
+
+	newClaim := func(name, arch string) *infrastructurev1beta1.ByoHostClaim {
+		created = created.Add(time.Minute)
+		return &infrastructurev1beta1.ByoHostClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: defaultNamespace, CreationTimestamp: metav1.NewTime(created)},
+			Spec:       infrastructurev1beta1.ByoHostClaimSpec{Architecture: arch},
+		}
+	}
+
+	reconcileClaim := func(claim *infrastructurev1beta1.ByoHostClaim) *infrastructurev1beta1.ByoHostClaim {
+		_, err := byoHostClaimReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(claim)})
+		Expect(err).NotTo(HaveOccurred())
+		updated := &infrastructurev1beta1.ByoHostClaim{}
+		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(claim), updated)).Should(Succeed())
+		return updated
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		created = time.Now().Add(-time.Hour)
+	})
+
+	setup := func(objects ...client.Object) {
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objects...).Build()
+		byoHostClaimReconciler = &controllers.ByoHostClaimReconciler{
+			Client:   fakeClient,
+			Recorder: record.NewFakeRecorder(32),
+		}
+	}
+
+	It("should bind the claim to a host matching its requirements", func() {
+		claim := newClaim("arm-claim", "arm64")
+		setup(newHost("amd-host", "amd64"), newHost("arm-host", "arm64"), claim)
+
+		bound := reconcileClaim(claim)
+		Expect(bound.Status.Phase).To(Equal(infrastructurev1beta1.ByoHostClaimBound))
+		Expect(bound.Status.HostRef.Name).To(Equal("arm-host"))
+		Expect(conditions.IsTrue(bound, infrastructurev1beta1.HostBound)).To(BeTrue())
+		Expect(bound.Finalizers).To(ContainElement(infrastructurev1beta1.ByoHostClaimFinalizer))
+
+		host := &infrastructurev1beta1.ByoHost{}
+		Expect(fakeClient.Get(ctx, client.ObjectKey{Namespace: defaultNamespace, Name: "arm-host"}, host)).Should(Succeed())
+		Expect(host.Labels[infrastructurev1beta1.ByoHostClaimLabel]).To(Equal(defaultNamespace + ".arm-claim"))
+	})
+
+	It("should keep the host for the older pending claim", func() {
+		olderClaim := newClaim("older-claim", "")
+		newerClaim := newClaim("newer-claim", "")
+		setup(newHost("only-host", "amd64"), olderClaim, newerClaim)
+
+		pending := reconcileClaim(newerClaim)
+		Expect(pending.Status.Phase).To(Equal(infrastructurev1beta1.ByoHostClaimPending))
+		Expect(pending.Status.HostRef).To(BeNil())
+		Expect(conditions.GetReason(pending, infrastructurev1beta1.HostBound)).To(Equal(infrastructurev1beta1.BYOHostsUnavailableReason))
+
+		bound := reconcileClaim(olderClaim)
+		Expect(bound.Status.HostRef.Name).To(Equal("only-host"))
+	})
+
+	It("should restore the binding of a claim which lost its status", func() {
+		host := newHost("moved-host", "amd64")
+		host.Labels = map[string]string{infrastructurev1beta1.ByoHostClaimLabel: defaultNamespace + ".moved-claim"}
+		claim := newClaim("moved-claim", "")
+		setup(newHost("other-host", "amd64"), host, claim)
+
+		bound := reconcileClaim(claim)
+		Expect(bound.Status.Phase).To(Equal(infrastructurev1beta1.ByoHostClaimBound))
+		Expect(bound.Status.HostRef.Name).To(Equal("moved-host"))
+	})
+
+	It("should mark the claim lost once its host is deleted", func() {
+		host := newHost("lost-host", "amd64")
+		claim := newClaim("lost-claim", "")
+		setup(host, claim)
+		Expect(reconcileClaim(claim).Status.Phase).To(Equal(infrastructurev1beta1.ByoHostClaimBound))
+
+		Expect(fakeClient.Delete(ctx, host)).Should(Succeed())
+		lost := reconcileClaim(claim)
+		Expect(lost.Status.Phase).To(Equal(infrastructurev1beta1.ByoHostClaimLost))
+		Expect(conditions.GetReason(lost, infrastructurev1beta1.HostBound)).To(Equal(infrastructurev1beta1.ClaimedHostLostReason))
+	})
+
+	It("should release the host of the deleted claim", func() {
+		claim := newClaim("deleted-claim", "")
+		setup(newHost("released-host", "amd64"), claim)
+		bound := reconcileClaim(claim)
+
+		Expect(fakeClient.Delete(ctx, bound)).Should(Succeed())
+		_, err := byoHostClaimReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(claim)})
+		Expect(err).NotTo(HaveOccurred())
+
+		host := &infrastructurev1beta1.ByoHost{}
+		Expect(fakeClient.Get(ctx, client.ObjectKey{Namespace: defaultNamespace, Name: "released-host"}, host)).Should(Succeed())
+		Expect(host.Labels).NotTo(HaveKey(infrastructurev1beta1.ByoHostClaimLabel))
+	})
+})
@@ -7,8 +7,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -17,6 +19,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/cluster-api/controllers/external"
 	"sigs.k8s.io/cluster-api/controllers/remote"
@@ -27,14 +30,22 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
+	"sigs.k8s.io/yaml"
 
 	"github.com/go-logr/logr"
 	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/common/tracing"
+	byohconditions "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/pkg/conditions"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -59,6 +70,12 @@ type ByoMachineReconciler struct {
 	Scheme   *runtime.Scheme
 	Tracker  *remote.ClusterCacheTracker
 	Recorder record.EventRecorder
+	// WorkloadClientSet returns a live client set of a workload cluster, the pods of the drained nodes
+	// are listed and evicted with. Defaults to the client set of the kubeconfig secret of the cluster.
+	WorkloadClientSet func(ctx context.Context, cluster client.ObjectKey) (kubernetes.Interface, error)
+
+	// controller watches the nodes of the workload clusters through the Tracker
+	controller controller.Controller
 }
 
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byomachines,verbs=get;list;watch;create;update;patch;delete
@@ -71,6 +88,9 @@ type ByoMachineReconciler struct {
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines;machines/status,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=events,verbs=get;list;watch;create;update;patch
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines,verbs=patch
+// +kubebuilder:rbac:groups=bootstrap.cluster.x-k8s.io,resources=kubeadmconfigs;kubeadmconfigs/status,verbs=get;patch;update
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -136,14 +156,23 @@ func (r *ByoMachineReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 
 	helper, _ := patch.NewHelper(byoMachine, r.Client)
 	defer func() {
-		if err = helper.Patch(ctx, byoMachine); err != nil && reterr == nil {
+		byohconditions.SetSummary(byoMachine, infrav1.BYOHostReady, infrav1.NodeDrainSucceeded)
+		if err = helper.Patch(ctx, byoMachine, patch.WithStatusObservedGeneration{}); err != nil && reterr == nil {
 			logger.Error(err, "failed to patch byomachine")
 			reterr = err
 		}
 	}()
 
+	// the reconciles are traced until the machine is provisioned, see TraceParentAnnotation
+	if !byoMachine.Status.Ready {
+		var span trace.Span
+		ctx, span = tracing.Begin(ctx, byoMachine, "ByoMachine.Reconcile",
+			attribute.String("byomachine", req.String()), attribute.String("cluster", cluster.Name))
+		defer func() { tracing.End(span, reterr) }()
+	}
+
 	// Fetch the BYOHost which is referencing this machine, if any
-	refByoHost, err := r.FetchAttachedByoHost(ctx, byoMachine.Name, byoMachine.Namespace)
+	refByoHost, err := r.FetchAttachedByoHost(ctx, byoMachine)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
@@ -185,13 +214,15 @@ func (r *ByoMachineReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	return r.reconcileNormal(ctx, machineScope)
 }
 
-// FetchAttachedByoHost fetches BYOHost attached to this machine
-func (r *ByoMachineReconciler) FetchAttachedByoHost(ctx context.Context, byomachineName, byomachineNamespace string) (*infrav1.ByoHost, error) {
+// FetchAttachedByoHost fetches BYOHost attached to this machine. A machine reconciled before the cache
+// caught up with its attachment may have claimed several hosts: the host the machine is provisioned on,
+// or else the first one by name, is kept and the other hosts are released, rather than left attached.
+func (r *ByoMachineReconciler) FetchAttachedByoHost(ctx context.Context, byoMachine *infrav1.ByoMachine) (*infrav1.ByoHost, error) {
 	logger := log.FromContext(ctx)
 	logger.Info("Fetching an attached ByoHost")
 
 	selector := labels.NewSelector()
-	byohostLabels, _ := labels.NewRequirement(infrav1.AttachedByoMachineLabel, selection.Equals, []string{byomachineNamespace + "." + byomachineName})
+	byohostLabels, _ := labels.NewRequirement(infrav1.AttachedByoMachineLabel, selection.Equals, []string{byoMachine.Namespace + "." + byoMachine.Name})
 	selector = selector.Add(*byohostLabels)
 	hostsList := &infrav1.ByoHostList{}
 	err := r.Client.List(
@@ -202,27 +233,83 @@ func (r *ByoMachineReconciler) FetchAttachedByoHost(ctx context.Context, byomach
 	if err != nil {
 		return nil, err
 	}
-	var refByoHost *infrav1.ByoHost = nil
-	if len(hostsList.Items) > 0 {
-		refByoHost = &hostsList.Items[0]
-		logger.Info("Successfully fetched an attached Byohost", "byohost", refByoHost.Name)
-		if len(hostsList.Items) > 1 {
-			errMsg := "more than one Byohost object attached to this Byomachine object. Only take one of it, please take care of the rest manually"
-			logger.Error(errors.New(errMsg), errMsg)
+	if len(hostsList.Items) == 0 {
+		return nil, nil
+	}
+
+	hosts := hostsList.Items
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].Name < hosts[j].Name })
+	kept := 0
+	for i := range hosts {
+		if byoMachine.Spec.ProviderID != "" && strings.HasPrefix(byoMachine.Spec.ProviderID, ProviderIDPrefix+hosts[i].Name+"/") {
+			kept = i
+		}
+	}
+	for i := range hosts {
+		if i == kept {
+			continue
+		}
+		if err = r.releaseExtraByoHost(ctx, byoMachine, &hosts[i]); err != nil {
+			return nil, err
 		}
 	}
+
+	refByoHost := &hosts[kept]
+	logger.Info("Successfully fetched an attached Byohost", "byohost", refByoHost.Name)
 	return refByoHost, nil
 }
 
+// releaseExtraByoHost has the host agent clean up a host claimed by the machine on top of the one it keeps
+func (r *ByoMachineReconciler) releaseExtraByoHost(ctx context.Context, byoMachine *infrav1.ByoMachine, host *infrav1.ByoHost) error {
+	if _, ok := host.Annotations[infrav1.HostCleanupAnnotation]; ok {
+		return nil
+	}
+	log.FromContext(ctx).Info("Releasing a ByoHost attached twice to the ByoMachine", "byohost", host.Name)
+	helper, err := patch.NewHelper(host, r.Client)
+	if err != nil {
+		return err
+	}
+	if host.Annotations == nil {
+		host.Annotations = map[string]string{}
+	}
+	host.Annotations[infrav1.HostCleanupAnnotation] = ""
+	if err = helper.Patch(ctx, host); err != nil {
+		return err
+	}
+	hostDetachments.WithLabelValues(host.Namespace).Inc()
+	r.Recorder.Eventf(byoMachine, corev1.EventTypeWarning, "ByoHostReleaseSucceeded", "Released ByoHost %s attached twice", host.Name)
+	return nil
+}
+
 func (r *ByoMachineReconciler) reconcileDelete(ctx context.Context, machineScope *byoMachineScope) (reconcile.Result, error) {
 	logger := log.FromContext(ctx).WithValues("cluster", machineScope.Cluster.Name)
 	logger.Info("Deleting ByoMachine")
-	if machineScope.ByoHost != nil {
+	if machineScope.ByoHost != nil && isQuarantined(machineScope.ByoHost) {
+		// The quarantined host is left as it is for forensics, neither drained nor reset
+		logger.Info("Detaching the quarantined ByoHost without cleanup", "byohost", machineScope.ByoHost.Name)
+		helper, err := patch.NewHelper(machineScope.ByoHost, r.Client)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		detachWithoutCleanup(machineScope.ByoHost)
+		if err := helper.Patch(ctx, machineScope.ByoHost); err != nil {
+			return ctrl.Result{}, err
+		}
+		hostDetachments.WithLabelValues(machineScope.ByoHost.Namespace).Inc()
+		r.Recorder.Eventf(machineScope.ByoHost, corev1.EventTypeWarning, "ByoHostQuarantined", "ByoHost detached from %s without cleanup", machineScope.ByoMachine.Name)
+		r.Recorder.Eventf(machineScope.ByoMachine, corev1.EventTypeWarning, "ByoHostQuarantined", "Detached the quarantined ByoHost %s without cleanup", machineScope.ByoHost.Name)
+	} else if machineScope.ByoHost != nil {
+		// Drain the node before the host agent resets it
+		if res, err := r.drainNode(ctx, machineScope); err != nil || !res.IsZero() {
+			return res, err
+		}
+
 		// Add annotation to trigger host cleanup
 		logger.Info("Releasing ByoHost", "byohost", machineScope.ByoHost.Name)
 		if err := r.markHostForCleanup(ctx, machineScope); err != nil {
 			return ctrl.Result{}, err
 		}
+		hostDetachments.WithLabelValues(machineScope.ByoHost.Namespace).Inc()
 		r.Recorder.Eventf(machineScope.ByoHost, corev1.EventTypeNormal, "ByoHostReleaseSucceeded", "ByoHost Released by %s", machineScope.ByoMachine.Name)
 		r.Recorder.Eventf(machineScope.ByoMachine, corev1.EventTypeNormal, "ByoHostReleaseSucceeded", "Released ByoHost %s", machineScope.ByoHost.Name)
 	}
@@ -245,6 +332,11 @@ func (r *ByoMachineReconciler) reconcileNormal(ctx context.Context, machineScope
 		}
 	}
 
+	if machineScope.ByoHost == nil {
+		// the installer is selected until a host is attached, the installerRef is immutable afterwards
+		resolveInstallerRef(machineScope)
+	}
+
 	if machineScope.ByoMachine.Spec.InstallerRef != nil {
 		if err := r.createInstallerConfig(ctx, machineScope); err != nil {
 			logger.Error(err, "create installer config failed")
@@ -264,20 +356,79 @@ func (r *ByoMachineReconciler) reconcileNormal(ctx context.Context, machineScope
 		return reconcile.Result{}, nil
 	}
 
+	// the machines provisioned before the timeline was recorded are left without one
+	timeline := &machineScope.ByoMachine.Status.Timeline
+	if timeline.BootstrapSecretReadyTime == nil && !machineScope.ByoMachine.Status.Ready {
+		now := metav1.Now()
+		timeline.BootstrapSecretReadyTime = &now
+	}
+
 	// If there is not yet an byoHost for this byoMachine,
 	// then pick one from the host capacity pool
 	if machineScope.ByoHost == nil {
+		// the bootstrap data is checked before a host is attached, rather than failing on the host
+		invalid, err := checkBootstrapData(ctx, r.Client, machineScope.Machine.Namespace, *machineScope.Machine.Spec.Bootstrap.DataSecretName)
+		if err != nil {
+			logger.Error(err, "failed to check the bootstrap data")
+			return ctrl.Result{}, err
+		}
+		if invalid != "" {
+			logger.Info("Bootstrap data cannot be run by the host agent", "reason", invalid)
+			r.Recorder.Event(machineScope.ByoMachine, corev1.EventTypeWarning, "BootstrapDataInvalid", invalid)
+			conditions.MarkFalse(machineScope.ByoMachine, infrav1.BYOHostReady, infrav1.BootstrapDataInvalidReason, clusterv1.ConditionSeverityError, invalid)
+			return ctrl.Result{}, nil
+		}
+
 		logger.Info("Attempting host reservation")
-		if res, err := r.attachByoHost(ctx, machineScope); err != nil {
+		selectionStart := time.Now()
+		selectionCtx, span := tracing.Start(ctx, "SelectHost")
+		res, err := r.attachByoHost(selectionCtx, machineScope)
+		if machineScope.ByoHost != nil {
+			span.SetAttributes(attribute.String("byohost", machineScope.ByoHost.Name))
+		}
+		tracing.End(span, err)
+		observeHostSelection(selectionStart)
+		if err != nil || machineScope.ByoHost == nil {
 			return res, err
 		}
+		hostAttachments.WithLabelValues(machineScope.ByoHost.Namespace).Inc()
+		now := metav1.Now()
+		timeline.HostSelectedTime = &now
 		conditions.MarkFalse(machineScope.ByoMachine, infrav1.BYOHostReady, infrav1.InstallationSecretNotAvailableReason, clusterv1.ConditionSeverityInfo, "")
 		r.Recorder.Eventf(machineScope.ByoHost, corev1.EventTypeNormal, "ByoHostAttachSucceeded", "Attached to ByoMachine %s", machineScope.ByoMachine.Name)
 		r.Recorder.Eventf(machineScope.ByoMachine, corev1.EventTypeNormal, "ByoHostAttachSucceeded", "Attached ByoHost %s", machineScope.ByoHost.Name)
+	} else if machineScope.ByoHost.Status.MachineRef == nil {
+		// the host was claimed by a reconcile which failed before attaching it
+		logger.Info("Completing the attachment of the claimed ByoHost")
+		if err := r.bindByoHost(ctx, machineScope, machineScope.ByoHost); err != nil {
+			logger.Error(err, "failed to patch byohost")
+			return ctrl.Result{}, err
+		}
+	} else if machineScope.ByoHost.Spec.BootstrapSecret == nil {
+		logger.Info("Setting the regenerated bootstrap data secret on the ByoHost")
+		if err := r.restoreBootstrapSecret(ctx, machineScope); err != nil {
+			logger.Error(err, "failed to patch byohost")
+			return ctrl.Result{}, err
+		}
+	}
+
+	// the expired token is reported by the host agent, until it retries with the regenerated bootstrap data
+	// once the secret is set back on the host
+	if _, retry := machineScope.ByoHost.Annotations[infrav1.RetryAnnotation]; !retry && machineScope.ByoHost.Spec.BootstrapSecret != nil &&
+		conditions.GetReason(machineScope.ByoHost, infrav1.K8sNodeBootstrapSucceeded) == infrav1.BootstrapTokenExpiredReason {
+		return r.regenerateBootstrapData(ctx, machineScope)
+	}
+
+	if installed := conditions.Get(machineScope.ByoHost, infrav1.K8sComponentsInstallationSucceeded); timeline.K8sInstalledTime == nil &&
+		installed != nil && installed.Status == corev1.ConditionTrue {
+		timeline.K8sInstalledTime = installed.LastTransitionTime.DeepCopy()
 	}
 
-	if machineScope.ByoMachine.Status.HostInfo == (infrav1.HostInfo{}) {
-		machineScope.ByoMachine.Status.HostInfo = machineScope.ByoHost.Status.HostDetails
+	if reflect.DeepEqual(machineScope.ByoMachine.Status.HostInfo, infrav1.HostInfo{}) {
+		machineScope.ByoMachine.Status.HostInfo = *machineScope.ByoHost.Status.HostDetails.DeepCopy()
+	}
+	if failureDomainLabel := machineScope.ByoCluster.Spec.FailureDomainLabel; failureDomainLabel != "" {
+		machineScope.ByoMachine.Status.FailureDomain = machineScope.ByoHost.Labels[failureDomainLabel]
 	}
 
 	if machineScope.ByoMachine.Spec.InstallerRef != nil && machineScope.ByoHost.Spec.InstallationSecret == nil {
@@ -303,13 +454,34 @@ func (r *ByoMachineReconciler) updateNodeProviderID(ctx context.Context, machine
 		return ctrl.Result{}, err
 	}
 
-	providerID, err := r.setNodeProviderID(ctx, remoteClient, machineScope.ByoHost)
+	if err = r.watchNodes(ctx, machineScope.Cluster); err != nil {
+		logger.Error(err, "failed to watch the nodes of the workload cluster")
+		return ctrl.Result{}, err
+	}
+
+	providerID, err := setNodeProviderID(ctx, remoteClient, machineScope.ByoHost)
 	if err != nil {
 		logger.Error(err, "failed to set node providerID")
 		r.Recorder.Eventf(machineScope.ByoMachine, corev1.EventTypeWarning, "SetNodeProviderFailed", "Node %s does not exist", machineScope.ByoHost.Name)
 		return ctrl.Result{}, err
 	}
 
+	node := &corev1.Node{}
+	if err = remoteClient.Get(ctx, client.ObjectKey{Name: machineScope.ByoHost.Name}, node); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err = r.syncNodeStatus(ctx, machineScope, node); err != nil {
+		logger.Error(err, "failed to mirror the status of the node")
+		return ctrl.Result{}, err
+	}
+
+	if timeline := &machineScope.ByoMachine.Status.Timeline; timeline.NodeJoinedTime == nil && !machineScope.ByoMachine.Status.Ready {
+		now := metav1.Now()
+		timeline.NodeJoinedTime = &now
+		duration := now.Sub(machineScope.ByoMachine.CreationTimestamp.Time)
+		machineScope.ByoMachine.Status.ProvisioningDuration = &metav1.Duration{Duration: duration}
+		machineBootstrapDuration.WithLabelValues(machineScope.ByoMachine.Namespace).Observe(duration.Seconds())
+	}
 	machineScope.ByoMachine.Spec.ProviderID = providerID
 	machineScope.ByoMachine.Status.Ready = true
 	conditions.MarkTrue(machineScope.ByoMachine, infrav1.BYOHostReady)
@@ -318,7 +490,7 @@ func (r *ByoMachineReconciler) updateNodeProviderID(ctx context.Context, machine
 }
 
 // SetupWithManager sets up the controller with the Manager.
-func (r *ByoMachineReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+func (r *ByoMachineReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
 	var (
 		controlledType     = &infrav1.ByoMachine{}
 		controlledTypeName = reflect.TypeOf(controlledType).Elem().Name()
@@ -326,13 +498,21 @@ func (r *ByoMachineReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Ma
 	)
 	logger := ctrl.LoggerFrom(ctx)
 	ClusterToByoMachines := r.ClusterToByoMachines(logger)
+	if err := addByoHostIndexes(ctx, mgr); err != nil {
+		return err
+	}
 
-	return ctrl.NewControllerManagedBy(mgr).
+	c, err := ctrl.NewControllerManagedBy(mgr).
 		For(controlledType).
+		WithOptions(options).
 		Watches(
 			&source.Kind{Type: &infrav1.ByoHost{}},
 			handler.EnqueueRequestsFromMapFunc(ByoHostToByoMachineMapFunc(controlledTypeGVK)),
 		).
+		Watches(
+			&source.Kind{Type: &infrav1.ByoHost{}},
+			handler.EnqueueRequestsFromMapFunc(r.ByoHostToWaitingByoMachines),
+		).
 		// Watch the CAPI resource that owns this infrastructure resource
 		Watches(
 			&source.Kind{Type: &clusterv1.Machine{}},
@@ -341,9 +521,38 @@ func (r *ByoMachineReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Ma
 		Watches(
 			&source.Kind{Type: &clusterv1.Cluster{}},
 			handler.EnqueueRequestsFromMapFunc(ClusterToByoMachines),
-			builder.WithPredicates(predicates.ClusterUnpausedAndInfrastructureReady(ctrl.LoggerFrom(ctx))),
+			builder.WithPredicates(predicates.Any(logger,
+				predicates.ClusterUnpausedAndInfrastructureReady(logger),
+				ClusterPausedChanged(logger))),
 		).
-		Complete(r)
+		Build(r)
+	if err != nil {
+		return err
+	}
+	r.controller = c
+	return nil
+}
+
+// ClusterPausedChanged returns a predicate that returns true for the updates pausing or unpausing the
+// Cluster, so that the paused state is propagated to the hosts attached to the cluster
+func ClusterPausedChanged(logger logr.Logger) predicate.Funcs {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldCluster, ok := e.ObjectOld.(*clusterv1.Cluster)
+			if !ok {
+				return false
+			}
+			newCluster := e.ObjectNew.(*clusterv1.Cluster)
+			if oldCluster.Spec.Paused != newCluster.Spec.Paused {
+				logger.V(4).Info("Cluster paused state changed, allowing further processing", "cluster", newCluster.Name, "paused", newCluster.Spec.Paused)
+				return true
+			}
+			return false
+		},
+		CreateFunc:  func(e event.CreateEvent) bool { return false },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+	}
 }
 
 // ClusterToByoMachines is a handler.ToRequestsFunc to be used to enqeue requests for reconciliation
@@ -384,7 +593,7 @@ func (r *ByoMachineReconciler) ClusterToByoMachines(logger logr.Logger) handler.
 
 // setNodeProviderID patches the provider id to the node using
 // client pointing to workload cluster
-func (r *ByoMachineReconciler) setNodeProviderID(ctx context.Context, remoteClient client.Client, host *infrav1.ByoHost) (string, error) {
+func setNodeProviderID(ctx context.Context, remoteClient client.Client, host *infrav1.ByoHost) (string, error) {
 	node := &corev1.Node{}
 	key := client.ObjectKey{Name: host.Name, Namespace: host.Namespace}
 	err := remoteClient.Get(ctx, key, node)
@@ -428,7 +637,13 @@ func (r *ByoMachineReconciler) getRemoteClient(ctx context.Context, byoMachine *
 }
 
 func (r *ByoMachineReconciler) setPausedConditionForByoHost(ctx context.Context, machineScope *byoMachineScope, isPaused bool) error {
-	helper, err := patch.NewHelper(machineScope.ByoHost, r.Client)
+	return setPausedAnnotationForByoHost(ctx, r.Client, machineScope.ByoHost, isPaused)
+}
+
+// setPausedAnnotationForByoHost propagates the paused state of the cluster to the attached host,
+// so that its agent stops reconciling it, e.g. while the cluster is moved by clusterctl
+func setPausedAnnotationForByoHost(ctx context.Context, c client.Client, host *infrav1.ByoHost, isPaused bool) error {
+	helper, err := patch.NewHelper(host, c)
 	if err != nil {
 		return err
 	}
@@ -437,12 +652,12 @@ func (r *ByoMachineReconciler) setPausedConditionForByoHost(ctx context.Context,
 		desired := map[string]string{
 			clusterv1.PausedAnnotation: "",
 		}
-		annotations.AddAnnotations(machineScope.ByoHost, desired)
+		annotations.AddAnnotations(host, desired)
 	} else {
-		delete(machineScope.ByoHost.Annotations, clusterv1.PausedAnnotation)
+		delete(host.Annotations, clusterv1.PausedAnnotation)
 	}
 
-	return helper.Patch(ctx, machineScope.ByoHost)
+	return helper.Patch(ctx, host)
 }
 
 func (r *ByoMachineReconciler) setInstallationSecretForByoHost(ctx context.Context, machineScope *byoMachineScope) (ctrl.Result, error) {
@@ -452,6 +667,12 @@ func (r *ByoMachineReconciler) setInstallationSecretForByoHost(ctx context.Conte
 		return ctrl.Result{}, err
 	}
 	if !ready {
+		// the reason is kept, the installer config is reconciled while the ByoMachine waits for the installation secret
+		if condition := conditions.Get(conditions.UnstructuredGetter(installerConfig), infrav1.InstallationSecretAvailableCondition); condition != nil &&
+			condition.Status == corev1.ConditionFalse && condition.Severity == clusterv1.ConditionSeverityError {
+			logger.Info("Installer config failed to generate the installation secret", "reason", condition.Reason, "message", condition.Message)
+			conditions.MarkFalse(machineScope.ByoMachine, infrav1.BYOHostReady, infrav1.InstallationSecretNotAvailableReason, clusterv1.ConditionSeverityError, "%s: %s", condition.Reason, condition.Message)
+		}
 		logger.Info("Installer config is not ready, requeuing")
 		return ctrl.Result{RequeueAfter: RequeueInstallerConfigTime}, nil
 	}
@@ -472,6 +693,13 @@ func (r *ByoMachineReconciler) setInstallationSecretForByoHost(ctx context.Conte
 		return ctrl.Result{}, fmt.Errorf("failed to convert unstructured field, %s", err.Error())
 	}
 	machineScope.ByoHost.Spec.InstallationSecret = secretRef
+	// the kubelet flags rendered by the installer come first, the ones of the ByoMachine take precedence
+	if installerArgs, _, _ := unstructured.NestedString(installerConfig.Object, "status", "kubeletExtraArgs"); installerArgs != "" {
+		if args := machineScope.ByoHost.Annotations[infrav1.KubeletExtraArgsAnnotation]; args != "" {
+			installerArgs += " " + args
+		}
+		annotations.AddAnnotations(machineScope.ByoHost, map[string]string{infrav1.KubeletExtraArgsAnnotation: installerArgs})
+	}
 	return ctrl.Result{}, helper.Patch(ctx, machineScope.ByoHost)
 }
 
@@ -495,7 +723,6 @@ func (r *ByoMachineReconciler) attachByoHost(ctx context.Context, machineScope *
 		return ctrl.Result{}, nil
 	}
 
-	hostsList := &infrav1.ByoHostList{}
 	// LabelSelector filter for byohosts
 	if machineScope.ByoMachine.Spec.Selector != nil {
 		selector, err = metav1.LabelSelectorAsSelector(machineScope.ByoMachine.Spec.Selector)
@@ -509,24 +736,128 @@ func (r *ByoMachineReconciler) attachByoHost(ctx context.Context, machineScope *
 
 	byohostLabels, _ := labels.NewRequirement(clusterv1.ClusterLabelName, selection.DoesNotExist, nil)
 	selector = selector.Add(*byohostLabels)
+	// the hosts bound to a ByoHostClaim are only attached to the machines referencing the claim
+	claimRef := machineScope.ByoMachine.Spec.ClaimRef
+	claimLabel, _ := labels.NewRequirement(infrav1.ByoHostClaimLabel, selection.DoesNotExist, nil)
+	if claimRef != nil {
+		claimLabel, _ = labels.NewRequirement(infrav1.ByoHostClaimLabel, selection.Equals, []string{machineScope.ByoMachine.Namespace + "." + claimRef.Name})
+	}
+	selector = selector.Add(*claimLabel)
+	failureDomain, err := failureDomainRequirement(machineScope)
+	if err != nil {
+		logger.Error(err, "invalid failure domain")
+		return ctrl.Result{}, err
+	}
+	if failureDomain != nil {
+		selector = selector.Add(*failureDomain)
+	}
 
-	err = r.Client.List(ctx, hostsList, &client.ListOptions{LabelSelector: selector})
+	// the hosts are looked up in the indexes of the cache, rather than listed and filtered
+	var hosts []infrav1.ByoHost
+	if claimRef != nil {
+		hostsList := &infrav1.ByoHostList{}
+		err = r.Client.List(ctx, hostsList, client.MatchingFields{ByoHostClaimIndex: machineScope.ByoMachine.Namespace + "." + claimRef.Name},
+			client.MatchingLabelsSelector{Selector: selector})
+		hosts = hostsList.Items
+	} else {
+		hosts, err = listAvailableByoHosts(ctx, r.Client, machineScope.ByoMachine.Namespace, selector)
+	}
 	if err != nil {
 		logger.Error(err, "failed to list byohosts")
 		return ctrl.Result{RequeueAfter: RequeueForbyohost}, err
 	}
-	if len(hostsList.Items) == 0 {
+	candidates, err := filterAccessibleByoHosts(ctx, r.Client, machineScope.ByoMachine.Namespace, hosts)
+	if err != nil {
+		logger.Error(err, "failed to check access to the byohosts")
+		return ctrl.Result{}, err
+	}
+	candidates = filterByoHostsByDevices(candidates, machineScope.ByoMachine.Spec.Devices)
+	if claimRef == nil {
+		// the binder already checked the reservation of the claimed host
+		candidates = filterReservedByoHosts(candidates, machineScope.Cluster, machineScope.ByoMachine.Spec.HostRef, machineScope.ByoMachine.Namespace)
+	}
+	if hostRef := machineScope.ByoMachine.Spec.HostRef; hostRef != nil && len(candidates) == 0 {
+		unavailability, err := pinnedByoHostUnavailability(ctx, r.Client, machineScope)
+		if err != nil {
+			logger.Error(err, "failed to check the pinned byohost")
+			return ctrl.Result{}, err
+		}
+		logger.Info("Pinned host is not available, waiting..", "byohost", hostRef.Name, "reason", unavailability)
+		r.Recorder.Eventf(machineScope.ByoMachine, corev1.EventTypeWarning, "ByoHostSelectionFailed", "Pinned ByoHost %s is not available: %s", hostRef.Name, unavailability)
+		conditions.MarkFalse(machineScope.ByoMachine, infrav1.BYOHostReady, infrav1.ReservedHostUnavailableReason, clusterv1.ConditionSeverityInfo,
+			"pinned ByoHost %s is not available: %s", hostRef.Name, unavailability)
+		return ctrl.Result{RequeueAfter: RequeueForbyohost}, fmt.Errorf("pinned host %s not available: %s", hostRef.Name, unavailability)
+	}
+	candidates = filterSchedulableByoHosts(candidates)
+	if len(candidates) > 0 && machineScope.ByoMachine.Spec.AntiAffinity != nil {
+		candidates, err = spreadByoHosts(ctx, r.Client, machineScope, candidates)
+		if err != nil {
+			logger.Error(err, "failed to apply anti-affinity")
+			return ctrl.Result{}, err
+		}
+		if len(candidates) == 0 {
+			logger.Info("No hosts satisfying the anti-affinity found, waiting..", "topologyKey", machineScope.ByoMachine.Spec.AntiAffinity.TopologyKey)
+			r.Recorder.Eventf(machineScope.ByoMachine, corev1.EventTypeWarning, "ByoHostSelectionFailed", "No available ByoHost in a free %s topology domain", machineScope.ByoMachine.Spec.AntiAffinity.TopologyKey)
+			conditions.MarkFalse(machineScope.ByoMachine, infrav1.BYOHostReady, infrav1.BYOHostsUnavailableReason, clusterv1.ConditionSeverityInfo, "")
+			return ctrl.Result{RequeueAfter: RequeueForbyohost}, errors.New("no hosts found")
+		}
+	}
+	if claimRef != nil && len(candidates) == 0 {
+		logger.Info("ByoHostClaim is not bound, waiting..", "byohostclaim", claimRef.Name)
+		conditions.MarkFalse(machineScope.ByoMachine, infrav1.BYOHostReady, infrav1.WaitingForClaimReason, clusterv1.ConditionSeverityInfo,
+			"waiting for ByoHostClaim %s to be bound", claimRef.Name)
+		// the ByoMachine is requeued once the claim binds a host, see ByoHostToWaitingByoMachines
+		return ctrl.Result{}, nil
+	}
+	if len(candidates) == 0 {
 		logger.Info("No hosts found, waiting..")
-		r.Recorder.Eventf(machineScope.ByoMachine, corev1.EventTypeWarning, "ByoHostSelectionFailed", "No available ByoHost")
-		conditions.MarkFalse(machineScope.ByoMachine, infrav1.BYOHostReady, infrav1.BYOHostsUnavailableReason, clusterv1.ConditionSeverityInfo, "")
-		return ctrl.Result{RequeueAfter: RequeueForbyohost}, errors.New("no hosts found")
+		unavailable, err := countUnavailableByoHosts(ctx, r.Client, machineScope)
+		if err != nil {
+			logger.Error(err, "failed to count the unavailable byohosts")
+			return ctrl.Result{}, err
+		}
+		if failureDomain != nil {
+			r.Recorder.Eventf(machineScope.ByoMachine, corev1.EventTypeWarning, "ByoHostSelectionFailed", "No available ByoHost in failure domain %s", *machineScope.Machine.Spec.FailureDomain)
+		} else {
+			r.Recorder.Eventf(machineScope.ByoMachine, corev1.EventTypeWarning, "ByoHostSelectionFailed", "No available ByoHost")
+		}
+		conditions.MarkFalse(machineScope.ByoMachine, infrav1.BYOHostReady, infrav1.WaitingForAvailableHostReason, clusterv1.ConditionSeverityInfo, "%s", unavailable)
+		// the ByoMachine is requeued once a host becomes available, see ByoHostToWaitingByoMachines
+		return ctrl.Result{}, nil
+	}
+	var host infrav1.ByoHost
+	claimed := false
+	for i := range candidates {
+		host = candidates[i]
+		claimed, err = claimByoHost(ctx, r.Client, &host, machineScope.ByoMachine.Labels[clusterv1.ClusterLabelName],
+			infrav1.AttachedByoMachineLabel, machineScope.ByoMachine.Namespace+"."+machineScope.ByoMachine.Name)
+		if err != nil {
+			logger.Error(err, "failed to claim byohost", "byohost", host.Name)
+			return ctrl.Result{}, err
+		}
+		if claimed {
+			break
+		}
+	}
+	if !claimed {
+		// the candidates were updated since they were listed, they are listed again on the retry
+		return ctrl.Result{}, errors.New("failed to claim a host, the hosts were updated concurrently")
 	}
-	// TODO- Needs smarter logic
-	host := hostsList.Items[0]
 
-	byohostHelper, err := patch.NewHelper(&host, r.Client)
+	if err = r.bindByoHost(ctx, machineScope, &host); err != nil {
+		logger.Error(err, "failed to patch byohost")
+		return ctrl.Result{}, err
+	}
+	logger.Info("Successfully attached Byohost", "byohost", host.Name)
+	machineScope.ByoHost = &host
+	return ctrl.Result{}, nil
+}
+
+// bindByoHost sets the machine reference, the bootstrap secret and the settings of the machine on the claimed host
+func (r *ByoMachineReconciler) bindByoHost(ctx context.Context, machineScope *byoMachineScope, host *infrav1.ByoHost) error {
+	byohostHelper, err := patch.NewHelper(host, r.Client)
 	if err != nil {
-		logger.Error(err, "Creating patch helper failed")
+		return err
 	}
 
 	host.Status.MachineRef = &corev1.ObjectReference{
@@ -557,15 +888,134 @@ func (r *ByoMachineReconciler) attachByoHost(ctx context.Context, machineScope *
 	host.Annotations[infrav1.K8sVersionAnnotation] = strings.Split(*machineScope.Machine.Spec.Version, "+")[0]
 	host.Annotations[infrav1.BundleLookupBaseRegistryAnnotation] = machineScope.ByoCluster.Spec.BundleLookupBaseRegistry
 	host.Annotations[infrav1.BundleLookupTagAnnotation] = machineScope.ByoCluster.Spec.BundleLookupTag
+	setKubeVIPAnnotations(host, machineScope)
+	if families := clusterIPFamilies(machineScope.Cluster); families != "" {
+		host.Annotations[infrav1.IPFamiliesAnnotation] = families
+	}
+	if args := kubeletExtraArgs(&machineScope.ByoMachine.Spec); args != "" {
+		host.Annotations[infrav1.KubeletExtraArgsAnnotation] = args
+	}
+	if kubelet := machineScope.ByoMachine.Spec.Kubelet; kubelet != nil && kubelet.Configuration != nil {
+		configuration, err := kubeletConfigurationPatch(kubelet.Configuration)
+		if err != nil {
+			return err
+		}
+		host.Annotations[infrav1.KubeletConfigurationAnnotation] = configuration
+	}
+	if distribution := machineScope.ByoMachine.Spec.Distribution; distribution != "" && distribution != infrav1.KubernetesDistributionKubeadm {
+		host.Annotations[infrav1.K8sDistributionAnnotation] = string(distribution)
+	}
+	// the host agent continues the trace of the machine
+	tracing.Propagate(machineScope.ByoMachine, host)
 
-	err = byohostHelper.Patch(ctx, &host)
-	if err != nil {
-		logger.Error(err, "failed to patch byohost")
-		return ctrl.Result{}, err
+	return byohostHelper.Patch(ctx, host)
+}
+
+// setKubeVIPAnnotations has the host agent of a control plane host deploy kube-vip for the
+// control plane endpoint, when the ByoCluster enables it
+func setKubeVIPAnnotations(host *infrav1.ByoHost, machineScope *byoMachineScope) {
+	kubeVIP := machineScope.ByoCluster.Spec.KubeVIP
+	if kubeVIP == nil || !util.IsControlPlaneMachine(machineScope.Machine) {
+		return
+	}
+	image := kubeVIP.Image
+	if image == "" {
+		image = infrav1.DefaultKubeVIPImage
+	}
+	host.Annotations[infrav1.EndPointPortAnnotation] = fmt.Sprint(machineScope.Cluster.Spec.ControlPlaneEndpoint.Port)
+	host.Annotations[infrav1.KubeVIPImageAnnotation] = image
+	if kubeVIP.Interface != "" {
+		host.Annotations[infrav1.KubeVIPInterfaceAnnotation] = kubeVIP.Interface
 	}
-	logger.Info("Successfully attached Byohost", "byohost", host.Name)
-	machineScope.ByoHost = &host
-	return ctrl.Result{}, nil
+}
+
+// clusterIPFamilies returns the comma separated IP families of the pod CIDRs of the cluster, or else
+// of its service CIDRs, primary family first. It returns an empty string for the IPv4-only clusters,
+// whose nodes are left to register with the address picked by the kubelet.
+func clusterIPFamilies(cluster *clusterv1.Cluster) string {
+	network := cluster.Spec.ClusterNetwork
+	if network == nil {
+		return ""
+	}
+	cidrs := []string{}
+	if network.Pods != nil {
+		cidrs = network.Pods.CIDRBlocks
+	}
+	if len(cidrs) == 0 && network.Services != nil {
+		cidrs = network.Services.CIDRBlocks
+	}
+
+	families := []string{}
+	hasIPv6 := false
+	for _, cidr := range cidrs {
+		ip, _, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue
+		}
+		family := string(corev1.IPv4Protocol)
+		if ip.To4() == nil {
+			family = string(corev1.IPv6Protocol)
+			hasIPv6 = true
+		}
+		if len(families) == 0 || families[0] != family {
+			families = append(families, family)
+		}
+	}
+	if !hasIPv6 {
+		return ""
+	}
+	return strings.Join(families, ",")
+}
+
+// kubeletExtraArgs renders the node labels and taints, and the extra args of the ByoMachine as kubelet flags,
+// the extra args coming last as the kubelet keeps the last value of a repeated flag
+func kubeletExtraArgs(spec *infrav1.ByoMachineSpec) string {
+	args := []string{}
+	if len(spec.NodeLabels) > 0 {
+		args = append(args, "--node-labels="+joinSorted(spec.NodeLabels, "="))
+	}
+	if len(spec.NodeTaints) > 0 {
+		taints := make([]string, 0, len(spec.NodeTaints))
+		for _, taint := range spec.NodeTaints {
+			taints = append(taints, taint.ToString())
+		}
+		args = append(args, "--register-with-taints="+strings.Join(taints, ","))
+	}
+	if spec.Kubelet == nil {
+		return strings.Join(args, " ")
+	}
+	names := make([]string, 0, len(spec.Kubelet.ExtraArgs))
+	for name := range spec.Kubelet.ExtraArgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		args = append(args, fmt.Sprintf("--%s=%s", name, spec.Kubelet.ExtraArgs[name]))
+	}
+	return strings.Join(args, " ")
+}
+
+// kubeletConfigurationPatch renders the kubelet configuration of the ByoMachine as a KubeletConfiguration
+// patch, the fields of infrav1.KubeletConfiguration being named after the ones of the kubelet
+func kubeletConfigurationPatch(configuration *infrav1.KubeletConfiguration) (string, error) {
+	patch, err := yaml.Marshal(struct {
+		metav1.TypeMeta               `json:",inline"`
+		*infrav1.KubeletConfiguration `json:",inline"`
+	}{
+		TypeMeta:             metav1.TypeMeta{APIVersion: "kubelet.config.k8s.io/v1beta1", Kind: "KubeletConfiguration"},
+		KubeletConfiguration: configuration,
+	})
+	return string(patch), err
+}
+
+// joinSorted joins the key/value pairs of a map flag of the kubelet, sorted by key
+func joinSorted(values map[string]string, sep string) string {
+	pairs := make([]string, 0, len(values))
+	for key, value := range values {
+		pairs = append(pairs, key+sep+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
 }
 
 // ByoHostToByoMachineMapFunc returns a handler.ToRequestsFunc that watches for
@@ -577,7 +1027,7 @@ func ByoHostToByoMachineMapFunc(gvk schema.GroupVersionKind) handler.MapFunc {
 			return nil
 		}
 		if h.Status.MachineRef == nil {
-			// TODO, we can enqueue byomachine which providerID is nil to get better performance than requeue
+			// the ByoMachines waiting for a host are enqueued by ByoHostToWaitingByoMachines
 			return nil
 		}
 
@@ -599,6 +1049,23 @@ func ByoHostToByoMachineMapFunc(gvk schema.GroupVersionKind) handler.MapFunc {
 	}
 }
 
+// ByoHostToWaitingByoMachines maps a ByoHost which is not attached to the ByoMachines waiting for a
+// host, so that they attach it as soon as it becomes available instead of polling
+func (r *ByoMachineReconciler) ByoHostToWaitingByoMachines(o client.Object) []reconcile.Request {
+	if _, attached := o.GetLabels()[clusterv1.ClusterLabelName]; attached {
+		return nil
+	}
+	byoMachines := &infrav1.ByoMachineList{}
+	if err := r.Client.List(context.TODO(), byoMachines, client.MatchingFields{ByoMachineWaitingIndex: "true"}); err != nil {
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for i := range byoMachines.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&byoMachines.Items[i])})
+	}
+	return requests
+}
+
 func (r *ByoMachineReconciler) markHostForCleanup(ctx context.Context, machineScope *byoMachineScope) error {
 	helper, _ := patch.NewHelper(machineScope.ByoHost, r.Client)
 
@@ -611,6 +1078,26 @@ func (r *ByoMachineReconciler) markHostForCleanup(ctx context.Context, machineSc
 	return helper.Patch(ctx, machineScope.ByoHost)
 }
 
+// resolveInstallerRef sets the installerRef of the ByoMachine to the K8sInstallerConfigTemplate named by the
+// InstallerConfigTemplateAnnotation of its Machine. The kind of an installerRef set by the ByoMachineTemplate is kept.
+func resolveInstallerRef(machineScope *byoMachineScope) {
+	name, ok := machineScope.Machine.Annotations[infrav1.InstallerConfigTemplateAnnotation]
+	if !ok || name == "" {
+		return
+	}
+	installerRef := &corev1.ObjectReference{
+		APIVersion: infrav1.GroupVersion.String(),
+		Kind:       "K8sInstallerConfigTemplate",
+		Namespace:  machineScope.Machine.Namespace,
+		Name:       name,
+	}
+	if ref := machineScope.ByoMachine.Spec.InstallerRef; ref != nil {
+		installerRef.APIVersion = ref.APIVersion
+		installerRef.Kind = ref.Kind
+	}
+	machineScope.ByoMachine.Spec.InstallerRef = installerRef
+}
+
 func (r *ByoMachineReconciler) getInstallerConfig(ctx context.Context, byoMachine *infrav1.ByoMachine) (*unstructured.Unstructured, error) {
 	installerConfig := &unstructured.Unstructured{}
 	gvk := byoMachine.Spec.InstallerRef.GroupVersionKind()
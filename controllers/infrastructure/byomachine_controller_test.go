@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/go-logr/logr"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
@@ -15,15 +16,25 @@ import (
 	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/test/builder"
 	eventutils "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/test/utils/events"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/kubernetes/scheme"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/annotations"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -155,19 +166,17 @@ var _ = Describe("Controllers/ByomachineController", func() {
 		Context("When BYO Hosts are not available", func() {
 			It("should mark BYOHostReady as False", func() {
 				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
-				Expect(err).To(MatchError("no hosts found"))
+				Expect(err).NotTo(HaveOccurred())
 
 				createdByoMachine := &infrastructurev1beta1.ByoMachine{}
 				err = k8sClientUncached.Get(ctx, byoMachineLookupKey, createdByoMachine)
 				Expect(err).ToNot(HaveOccurred())
 				actualCondition := conditions.Get(createdByoMachine, infrastructurev1beta1.BYOHostReady)
 
-				Expect(*actualCondition).To(conditions.MatchCondition(clusterv1.Condition{
-					Type:     infrastructurev1beta1.BYOHostReady,
-					Status:   corev1.ConditionFalse,
-					Reason:   infrastructurev1beta1.BYOHostsUnavailableReason,
-					Severity: clusterv1.ConditionSeverityInfo,
-				}))
+				Expect(actualCondition.Status).To(Equal(corev1.ConditionFalse))
+				Expect(actualCondition.Reason).To(Equal(infrastructurev1beta1.WaitingForAvailableHostReason))
+				Expect(actualCondition.Severity).To(Equal(clusterv1.ConditionSeverityInfo))
+				Expect(actualCondition.Message).To(MatchRegexp(`^none of the \d+ ByoHosts is available`))
 
 				// assert events
 				events := eventutils.CollectEvents(recorder.Events)
@@ -176,9 +185,25 @@ var _ = Describe("Controllers/ByomachineController", func() {
 				}))
 			})
 
+			It("should enqueue the ByoMachine once a ByoHost is available", func() {
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).NotTo(HaveOccurred())
+				WaitForObjectToBeUpdatedInCache(byoMachine, func(object client.Object) bool {
+					return conditions.GetReason(object.(*infrastructurev1beta1.ByoMachine), infrastructurev1beta1.BYOHostReady) == infrastructurev1beta1.WaitingForAvailableHostReason
+				})
+
+				availableHost := builder.ByoHost(defaultNamespace, "available-host").Build()
+				Expect(reconciler.ByoHostToWaitingByoMachines(availableHost)).To(ContainElement(reconcile.Request{NamespacedName: byoMachineLookupKey}))
+
+				attachedHost := builder.ByoHost(defaultNamespace, "attached-host").
+					WithLabels(map[string]string{clusterv1.ClusterLabelName: defaultClusterName}).
+					Build()
+				Expect(reconciler.ByoHostToWaitingByoMachines(attachedHost)).To(BeEmpty())
+			})
+
 			It("should add MachineFinalizer on ByoMachine", func() {
 				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
-				Expect(err).To(HaveOccurred())
+				Expect(err).NotTo(HaveOccurred())
 
 				updatedByoMachine := &infrastructurev1beta1.ByoMachine{}
 				err = k8sClientUncached.Get(ctx, byoMachineLookupKey, updatedByoMachine)
@@ -252,6 +277,13 @@ var _ = Describe("Controllers/ByomachineController", func() {
 				Expect(createdByoMachine.Spec.ProviderID).To(ContainSubstring(controllers.ProviderIDPrefix))
 				Expect(createdByoMachine.Status.Ready).To(BeTrue())
 
+				timeline := createdByoMachine.Status.Timeline
+				Expect(timeline.BootstrapSecretReadyTime).NotTo(BeNil())
+				Expect(timeline.HostSelectedTime).NotTo(BeNil())
+				Expect(timeline.K8sInstalledTime).To(BeNil())
+				Expect(timeline.NodeJoinedTime).NotTo(BeNil())
+				Expect(createdByoMachine.Status.ProvisioningDuration).NotTo(BeNil())
+
 				actualCondition := conditions.Get(createdByoMachine, infrastructurev1beta1.BYOHostReady)
 				Expect(*actualCondition).To(conditions.MatchCondition(clusterv1.Condition{
 					Type:   infrastructurev1beta1.BYOHostReady,
@@ -273,6 +305,169 @@ var _ = Describe("Controllers/ByomachineController", func() {
 				Expect(node.Spec.ProviderID).To(ContainSubstring(controllers.ProviderIDPrefix))
 			})
 
+			It("mirrors the health and the kubelet version of the node into the ByoMachine and the ByoHost", func() {
+				node.Status.NodeInfo.KubeletVersion = "v1.23.5"
+				node.Status.Conditions = []corev1.NodeCondition{
+					{Type: corev1.NodeReady, Status: corev1.ConditionTrue, Reason: "KubeletReady"},
+					{Type: corev1.NodeDiskPressure, Status: corev1.ConditionTrue, Reason: "KubeletHasDiskPressure"},
+					{Type: corev1.NodeNetworkUnavailable, Status: corev1.ConditionFalse},
+				}
+				Expect(clientFake.Status().Update(ctx, node)).Should(Succeed())
+
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).ToNot(HaveOccurred())
+
+				expectedStatus := &infrastructurev1beta1.NodeStatus{
+					Name:           byoHost.Name,
+					KubeletVersion: "v1.23.5",
+					Ready:          corev1.ConditionTrue,
+					Conditions: []infrastructurev1beta1.NodeCondition{
+						{Type: corev1.NodeReady, Status: corev1.ConditionTrue, Reason: "KubeletReady"},
+						{Type: corev1.NodeDiskPressure, Status: corev1.ConditionTrue, Reason: "KubeletHasDiskPressure"},
+					},
+				}
+				createdByoMachine := &infrastructurev1beta1.ByoMachine{}
+				Expect(k8sClientUncached.Get(ctx, byoMachineLookupKey, createdByoMachine)).Should(Succeed())
+				Expect(createdByoMachine.Status.Node).To(Equal(expectedStatus))
+
+				createdByoHost := &infrastructurev1beta1.ByoHost{}
+				Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, createdByoHost)).Should(Succeed())
+				Expect(createdByoHost.Status.Node).To(Equal(expectedStatus))
+			})
+
+			It("has the claimed control plane host deploy kube-vip when the ByoCluster enables it", func() {
+				ph, err := patch.NewHelper(byoCluster, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				byoCluster.Spec.KubeVIP = &infrastructurev1beta1.KubeVIPSpec{Interface: "eth1"}
+				Expect(ph.Patch(ctx, byoCluster)).Should(Succeed())
+				defer func() {
+					ph, err = patch.NewHelper(byoCluster, k8sClientUncached)
+					Expect(err).ShouldNot(HaveOccurred())
+					byoCluster.Spec.KubeVIP = nil
+					Expect(ph.Patch(ctx, byoCluster)).Should(Succeed())
+				}()
+
+				ph, err = patch.NewHelper(machine, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				machine.Labels = map[string]string{clusterv1.MachineControlPlaneLabelName: ""}
+				Expect(ph.Patch(ctx, machine)).Should(Succeed())
+
+				WaitForObjectToBeUpdatedInCache(byoCluster, func(object client.Object) bool {
+					return object.(*infrastructurev1beta1.ByoCluster).Spec.KubeVIP != nil
+				})
+				WaitForObjectToBeUpdatedInCache(machine, func(object client.Object) bool {
+					return util.IsControlPlaneMachine(object.(*clusterv1.Machine))
+				})
+
+				_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).ToNot(HaveOccurred())
+
+				createdByoHost := &infrastructurev1beta1.ByoHost{}
+				Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, createdByoHost)).Should(Succeed())
+				createdByoHostAnnotations := createdByoHost.GetAnnotations()
+				Expect(createdByoHostAnnotations[infrastructurev1beta1.KubeVIPImageAnnotation]).To(Equal(infrastructurev1beta1.DefaultKubeVIPImage))
+				Expect(createdByoHostAnnotations[infrastructurev1beta1.KubeVIPInterfaceAnnotation]).To(Equal("eth1"))
+				Expect(createdByoHostAnnotations[infrastructurev1beta1.EndPointPortAnnotation]).To(Equal(fmt.Sprint(capiCluster.Spec.ControlPlaneEndpoint.Port)))
+			})
+
+			It("passes the kubelet customization of the ByoMachine to the claimed host", func() {
+				ph, err := patch.NewHelper(byoMachine, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				byoMachine.Spec.Kubelet = &infrastructurev1beta1.KubeletSpec{
+					Configuration: &infrastructurev1beta1.KubeletConfiguration{
+						MaxPods:        pointer.Int32Ptr(200),
+						SystemReserved: map[string]string{"memory": "1Gi", "cpu": "500m"},
+						EvictionHard:   map[string]string{"memory.available": "500Mi", "nodefs.available": "10%"},
+					},
+					ExtraArgs: map[string]string{"image-gc-high-threshold": "80"},
+				}
+				Expect(ph.Patch(ctx, byoMachine)).Should(Succeed())
+				WaitForObjectToBeUpdatedInCache(byoMachine, func(object client.Object) bool {
+					return object.(*infrastructurev1beta1.ByoMachine).Spec.Kubelet != nil
+				})
+
+				_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).ToNot(HaveOccurred())
+
+				createdByoHost := &infrastructurev1beta1.ByoHost{}
+				Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, createdByoHost)).Should(Succeed())
+				Expect(createdByoHost.Annotations[infrastructurev1beta1.KubeletExtraArgsAnnotation]).To(Equal("--image-gc-high-threshold=80"))
+				Expect(createdByoHost.Annotations[infrastructurev1beta1.KubeletConfigurationAnnotation]).To(Equal(`apiVersion: kubelet.config.k8s.io/v1beta1
+evictionHard:
+  memory.available: 500Mi
+  nodefs.available: 10%
+kind: KubeletConfiguration
+maxPods: 200
+systemReserved:
+  cpu: 500m
+  memory: 1Gi
+`))
+			})
+
+			It("passes the node labels and taints of the ByoMachine to the claimed host", func() {
+				ph, err := patch.NewHelper(byoMachine, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				byoMachine.Spec.NodeLabels = map[string]string{"topology.kubernetes.io/zone": "store42", "node.kubernetes.io/pool": "gpu"}
+				byoMachine.Spec.NodeTaints = []corev1.Taint{
+					{Key: "nvidia.com/gpu", Value: "present", Effect: corev1.TaintEffectNoSchedule},
+					{Key: "dedicated", Effect: corev1.TaintEffectNoExecute},
+				}
+				Expect(ph.Patch(ctx, byoMachine)).Should(Succeed())
+				WaitForObjectToBeUpdatedInCache(byoMachine, func(object client.Object) bool {
+					return len(object.(*infrastructurev1beta1.ByoMachine).Spec.NodeTaints) > 0
+				})
+
+				_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).ToNot(HaveOccurred())
+
+				createdByoHost := &infrastructurev1beta1.ByoHost{}
+				Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, createdByoHost)).Should(Succeed())
+				Expect(createdByoHost.Annotations[infrastructurev1beta1.KubeletExtraArgsAnnotation]).To(Equal(
+					"--node-labels=node.kubernetes.io/pool=gpu,topology.kubernetes.io/zone=store42 --register-with-taints=nvidia.com/gpu=present:NoSchedule,dedicated:NoExecute"))
+			})
+
+			It("passes the IP families of a dual-stack cluster to the claimed host", func() {
+				ph, err := patch.NewHelper(capiCluster, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				capiCluster.Spec.ClusterNetwork = &clusterv1.ClusterNetwork{
+					Pods: &clusterv1.NetworkRanges{CIDRBlocks: []string{"fd00:10:244::/56", "10.244.0.0/16"}},
+				}
+				Expect(ph.Patch(ctx, capiCluster)).Should(Succeed())
+				defer func() {
+					ph, err = patch.NewHelper(capiCluster, k8sClientUncached)
+					Expect(err).ShouldNot(HaveOccurred())
+					capiCluster.Spec.ClusterNetwork = nil
+					Expect(ph.Patch(ctx, capiCluster)).Should(Succeed())
+				}()
+				WaitForObjectToBeUpdatedInCache(capiCluster, func(object client.Object) bool {
+					return object.(*clusterv1.Cluster).Spec.ClusterNetwork != nil
+				})
+
+				_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).ToNot(HaveOccurred())
+
+				createdByoHost := &infrastructurev1beta1.ByoHost{}
+				Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, createdByoHost)).Should(Succeed())
+				Expect(createdByoHost.Annotations).To(HaveKeyWithValue(infrastructurev1beta1.IPFamiliesAnnotation, "IPv6,IPv4"))
+			})
+
+			It("passes the Kubernetes distribution of the ByoMachine to the claimed host", func() {
+				ph, err := patch.NewHelper(byoMachine, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				byoMachine.Spec.Distribution = infrastructurev1beta1.KubernetesDistributionRKE2
+				Expect(ph.Patch(ctx, byoMachine)).Should(Succeed())
+				WaitForObjectToBeUpdatedInCache(byoMachine, func(object client.Object) bool {
+					return object.(*infrastructurev1beta1.ByoMachine).Spec.Distribution != ""
+				})
+
+				_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).ToNot(HaveOccurred())
+
+				createdByoHost := &infrastructurev1beta1.ByoHost{}
+				Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, createdByoHost)).Should(Succeed())
+				Expect(createdByoHost.Annotations).To(HaveKeyWithValue(infrastructurev1beta1.K8sDistributionAnnotation, "rke2"))
+			})
+
 			Context("When ByoMachine is attached to a host", func() {
 				BeforeEach(func() {
 					ph, err := patch.NewHelper(byoHost, k8sClientUncached)
@@ -365,6 +560,109 @@ var _ = Describe("Controllers/ByomachineController", func() {
 
 				})
 
+				Context("When the host failed to join the node with an expired bootstrap token", func() {
+					var kubeadmConfig *bootstrapv1.KubeadmConfig
+
+					BeforeEach(func() {
+						kubeadmConfig = &bootstrapv1.KubeadmConfig{
+							ObjectMeta: metav1.ObjectMeta{Name: fakeBootstrapSecret, Namespace: defaultNamespace},
+							Spec: bootstrapv1.KubeadmConfigSpec{JoinConfiguration: &bootstrapv1.JoinConfiguration{
+								Discovery: bootstrapv1.Discovery{BootstrapToken: &bootstrapv1.BootstrapTokenDiscovery{
+									Token:                    "abcdef.0123456789abcdef",
+									UnsafeSkipCAVerification: true,
+								}},
+							}},
+						}
+						Expect(k8sClientUncached.Create(ctx, kubeadmConfig)).Should(Succeed())
+						configHelper, err := patch.NewHelper(kubeadmConfig, k8sClientUncached)
+						Expect(err).ShouldNot(HaveOccurred())
+						kubeadmConfig.Status.Ready = true
+						kubeadmConfig.Status.DataSecretName = pointer.String(fakeBootstrapSecret)
+						Expect(configHelper.Patch(ctx, kubeadmConfig)).Should(Succeed())
+
+						machineHelper, err := patch.NewHelper(machine, k8sClientUncached)
+						Expect(err).ShouldNot(HaveOccurred())
+						machine.Spec.Bootstrap.ConfigRef = &corev1.ObjectReference{
+							APIVersion: bootstrapv1.GroupVersion.String(),
+							Kind:       "KubeadmConfig",
+							Name:       kubeadmConfig.Name,
+							Namespace:  kubeadmConfig.Namespace,
+						}
+						Expect(machineHelper.Patch(ctx, machine)).Should(Succeed())
+
+						hostHelper, err := patch.NewHelper(byoHost, k8sClientUncached)
+						Expect(err).ShouldNot(HaveOccurred())
+						byoHost.Spec.BootstrapSecret = &corev1.ObjectReference{Kind: "Secret", Namespace: defaultNamespace, Name: fakeBootstrapSecret}
+						conditions.MarkFalse(byoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded, infrastructurev1beta1.BootstrapTokenExpiredReason,
+							clusterv1.ConditionSeverityWarning, "the bootstrap token of the bootstrap data expired")
+						Expect(hostHelper.Patch(ctx, byoHost)).Should(Succeed())
+
+						WaitForObjectToBeUpdatedInCache(machine, func(object client.Object) bool {
+							return object.(*clusterv1.Machine).Spec.Bootstrap.ConfigRef != nil
+						})
+						WaitForObjectToBeUpdatedInCache(byoHost, func(object client.Object) bool {
+							return conditions.GetReason(object.(*infrastructurev1beta1.ByoHost), infrastructurev1beta1.K8sNodeBootstrapSucceeded) ==
+								infrastructurev1beta1.BootstrapTokenExpiredReason
+						})
+					})
+
+					AfterEach(func() {
+						Expect(k8sClientUncached.Delete(ctx, kubeadmConfig)).Should(Succeed())
+					})
+
+					It("should have the bootstrap data regenerated with a fresh token, and the host retry with it", func() {
+						_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+						Expect(err).ToNot(HaveOccurred())
+
+						updatedConfig := &bootstrapv1.KubeadmConfig{}
+						Expect(k8sClientUncached.Get(ctx, client.ObjectKeyFromObject(kubeadmConfig), updatedConfig)).Should(Succeed())
+						Expect(updatedConfig.Spec.JoinConfiguration.Discovery.BootstrapToken.Token).To(BeEmpty())
+						Expect(updatedConfig.Status.Ready).To(BeFalse())
+						Expect(updatedConfig.Status.DataSecretName).To(BeNil())
+
+						updatedMachine := &clusterv1.Machine{}
+						Expect(k8sClientUncached.Get(ctx, client.ObjectKeyFromObject(machine), updatedMachine)).Should(Succeed())
+						Expect(updatedMachine.Spec.Bootstrap.DataSecretName).To(BeNil())
+						Expect(updatedMachine.Annotations).NotTo(HaveKey(clusterv1.PausedAnnotation))
+
+						updatedByoHost := &infrastructurev1beta1.ByoHost{}
+						Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, updatedByoHost)).Should(Succeed())
+						Expect(updatedByoHost.Spec.BootstrapSecret).To(BeNil())
+						// the condition is left to the host agent
+						Expect(conditions.GetReason(updatedByoHost, infrastructurev1beta1.K8sNodeBootstrapSucceeded)).To(Equal(infrastructurev1beta1.BootstrapTokenExpiredReason))
+
+						updatedByoMachine := &infrastructurev1beta1.ByoMachine{}
+						Expect(k8sClientUncached.Get(ctx, byoMachineLookupKey, updatedByoMachine)).Should(Succeed())
+						Expect(conditions.GetReason(updatedByoMachine, infrastructurev1beta1.BYOHostReady)).To(Equal(infrastructurev1beta1.BootstrapTokenExpiredReason))
+						Expect(eventutils.CollectEvents(recorder.Events)).Should(ContainElement(
+							fmt.Sprintf("Warning BootstrapTokenExpired Regenerating the bootstrap data of %s with a fresh bootstrap token", kubeadmConfig.Name)))
+
+						// the bootstrap provider regenerated the data
+						machineHelper, err := patch.NewHelper(updatedMachine, k8sClientUncached)
+						Expect(err).ShouldNot(HaveOccurred())
+						updatedMachine.Spec.Bootstrap.DataSecretName = pointer.String(fakeBootstrapSecret)
+						Expect(machineHelper.Patch(ctx, updatedMachine)).Should(Succeed())
+						WaitForObjectToBeUpdatedInCache(updatedMachine, func(object client.Object) bool {
+							return object.(*clusterv1.Machine).Spec.Bootstrap.DataSecretName != nil
+						})
+						WaitForObjectToBeUpdatedInCache(updatedByoHost, func(object client.Object) bool {
+							return object.(*infrastructurev1beta1.ByoHost).Spec.BootstrapSecret == nil
+						})
+
+						_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, updatedByoHost)).Should(Succeed())
+						Expect(updatedByoHost.Spec.BootstrapSecret.Name).To(Equal(fakeBootstrapSecret))
+						Expect(updatedByoHost.Annotations).To(HaveKey(infrastructurev1beta1.RetryAnnotation))
+
+						// the expired token the host agent still reports is not regenerated again
+						Expect(k8sClientUncached.Get(ctx, client.ObjectKeyFromObject(machine), updatedMachine)).Should(Succeed())
+						Expect(updatedMachine.Annotations).NotTo(HaveKey(clusterv1.PausedAnnotation))
+						Expect(updatedMachine.Spec.Bootstrap.DataSecretName).To(Equal(pointer.String(fakeBootstrapSecret)))
+					})
+				})
+
 				Context("When ByoMachine is deleted", func() {
 					BeforeEach(func() {
 						ph, err := patch.NewHelper(byoMachine, k8sClientUncached)
@@ -407,6 +705,131 @@ var _ = Describe("Controllers/ByomachineController", func() {
 						err = k8sClientUncached.Get(ctx, byoMachineLookupKey, deletedByoMachine)
 						Expect(err).To(MatchError(fmt.Sprintf("byomachines.infrastructure.cluster.x-k8s.io %q not found", byoMachineLookupKey.Name)))
 					})
+
+					Context("When the node of the machine runs pods", func() {
+						var (
+							pod          *corev1.Pod
+							daemonSetPod *corev1.Pod
+							// evictionErr is returned by the evictions of the pods, e.g. when refused by a PodDisruptionBudget
+							evictionErr error
+						)
+
+						BeforeEach(func() {
+							ph, err := patch.NewHelper(machine, k8sClientUncached)
+							Expect(err).ShouldNot(HaveOccurred())
+							machine.Status.NodeRef = &corev1.ObjectReference{Kind: "Node", Name: node.Name, Namespace: node.Namespace}
+							Expect(ph.Patch(ctx, machine)).Should(Succeed())
+							WaitForObjectToBeUpdatedInCache(machine, func(object client.Object) bool {
+								return object.(*clusterv1.Machine).Status.NodeRef != nil
+							})
+
+							pod = &corev1.Pod{
+								ObjectMeta: metav1.ObjectMeta{Name: "workload", Namespace: defaultNamespace},
+								Spec:       corev1.PodSpec{NodeName: node.Name},
+							}
+							Expect(clientFake.Create(ctx, pod)).Should(Succeed())
+							daemonSetPod = &corev1.Pod{
+								ObjectMeta: metav1.ObjectMeta{
+									Name:      "daemon",
+									Namespace: defaultNamespace,
+									OwnerReferences: []metav1.OwnerReference{{
+										APIVersion: "apps/v1",
+										Kind:       "DaemonSet",
+										Name:       "daemon",
+										UID:        "daemon",
+										Controller: pointer.BoolPtr(true),
+									}},
+								},
+								Spec: corev1.PodSpec{NodeName: node.Name},
+							}
+							workloadClientSetFake = fakeclientset.NewSimpleClientset(pod, daemonSetPod)
+							evictionErr = nil
+							workloadClientSetFake.PrependReactor("create", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+								if action.GetSubresource() != "eviction" {
+									return false, nil, nil
+								}
+								if evictionErr != nil {
+									return true, nil, evictionErr
+								}
+								eviction := action.(clienttesting.CreateAction).GetObject().(*policyv1.Eviction)
+								return true, nil, workloadClientSetFake.Tracker().Delete(corev1.SchemeGroupVersion.WithResource("pods"), eviction.Namespace, eviction.Name)
+							})
+						})
+
+						getPod := func(pod *corev1.Pod) error {
+							_, err := workloadClientSetFake.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+							return err
+						}
+
+						It("should cordon and drain the node before releasing the host", func() {
+							result, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+							Expect(err).NotTo(HaveOccurred())
+							Expect(result.RequeueAfter).To(Equal(controllers.RequeueForNodeDrain))
+
+							cordonedNode := &corev1.Node{}
+							Expect(clientFake.Get(ctx, client.ObjectKeyFromObject(node), cordonedNode)).Should(Succeed())
+							Expect(cordonedNode.Spec.Unschedulable).To(BeTrue())
+							Expect(getPod(pod)).To(MatchError(ContainSubstring("not found")))
+							Expect(getPod(daemonSetPod)).Should(Succeed())
+
+							drainingByoMachine := &infrastructurev1beta1.ByoMachine{}
+							Expect(k8sClientUncached.Get(ctx, byoMachineLookupKey, drainingByoMachine)).Should(Succeed())
+							Expect(conditions.GetReason(drainingByoMachine, infrastructurev1beta1.NodeDrainSucceeded)).To(Equal(infrastructurev1beta1.NodeDrainingReason))
+							createdByoHost := &infrastructurev1beta1.ByoHost{}
+							Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, createdByoHost)).Should(Succeed())
+							Expect(createdByoHost.Annotations).NotTo(HaveKey(infrastructurev1beta1.HostCleanupAnnotation))
+
+							WaitForObjectToBeUpdatedInCache(drainingByoMachine, func(object client.Object) bool {
+								return conditions.Has(object.(*infrastructurev1beta1.ByoMachine), infrastructurev1beta1.NodeDrainSucceeded)
+							})
+							result, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+							Expect(err).NotTo(HaveOccurred())
+							Expect(result.IsZero()).To(BeTrue())
+
+							Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, createdByoHost)).Should(Succeed())
+							Expect(createdByoHost.Annotations).To(HaveKey(infrastructurev1beta1.HostCleanupAnnotation))
+							Expect(eventutils.CollectEvents(recorder.Events)).To(ContainElements(
+								fmt.Sprintf("Normal NodeDrainStarted Cordoned node %s", node.Name),
+								fmt.Sprintf("Normal NodeDrainSucceeded Drained node %s", node.Name),
+							))
+						})
+
+						It("should retry the evictions refused by a PodDisruptionBudget", func() {
+							evictionErr = apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 10)
+							result, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+							Expect(err).NotTo(HaveOccurred())
+							Expect(result.RequeueAfter).To(Equal(controllers.RequeueForNodeDrain))
+							Expect(getPod(pod)).Should(Succeed())
+
+							drainingByoMachine := &infrastructurev1beta1.ByoMachine{}
+							Expect(k8sClientUncached.Get(ctx, byoMachineLookupKey, drainingByoMachine)).Should(Succeed())
+							Expect(conditions.Get(drainingByoMachine, infrastructurev1beta1.NodeDrainSucceeded).Message).To(Equal(
+								fmt.Sprintf("1 pods of node %s pending eviction", node.Name)))
+							createdByoHost := &infrastructurev1beta1.ByoHost{}
+							Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, createdByoHost)).Should(Succeed())
+							Expect(createdByoHost.Annotations).NotTo(HaveKey(infrastructurev1beta1.HostCleanupAnnotation))
+						})
+
+						It("should not drain the node when the machine excludes it", func() {
+							ph, err := patch.NewHelper(machine, k8sClientUncached)
+							Expect(err).ShouldNot(HaveOccurred())
+							annotations.AddAnnotations(machine, map[string]string{clusterv1.ExcludeNodeDrainingAnnotation: ""})
+							Expect(ph.Patch(ctx, machine)).Should(Succeed())
+							WaitForObjectToBeUpdatedInCache(machine, func(object client.Object) bool {
+								_, ok := object.GetAnnotations()[clusterv1.ExcludeNodeDrainingAnnotation]
+								return ok
+							})
+
+							result, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+							Expect(err).NotTo(HaveOccurred())
+							Expect(result.IsZero()).To(BeTrue())
+							Expect(getPod(pod)).Should(Succeed())
+
+							createdByoHost := &infrastructurev1beta1.ByoHost{}
+							Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, createdByoHost)).Should(Succeed())
+							Expect(createdByoHost.Annotations).To(HaveKey(infrastructurev1beta1.HostCleanupAnnotation))
+						})
+					})
 				})
 
 				Context("When installer config exists", func() {
@@ -468,6 +891,33 @@ var _ = Describe("Controllers/ByomachineController", func() {
 						Expect(k8sInstallerConfig.Status.InstallationSecret).To(Equal(patchedHost.Spec.InstallationSecret))
 					})
 
+					It("should add the kubelet flags rendered by the installer config to the byohost", func() {
+						ph, err := patch.NewHelper(k8sInstallerConfig, k8sClientUncached)
+						Expect(err).ShouldNot(HaveOccurred())
+						k8sInstallerConfig.Status = infrastructurev1beta1.K8sInstallerConfigStatus{
+							Ready: true,
+							InstallationSecret: &corev1.ObjectReference{
+								Kind:       "Secret",
+								Namespace:  defaultNamespace,
+								Name:       "K8sInstallationSecret",
+								APIVersion: "v1",
+							},
+							KubeletExtraArgs: "--cpu-manager-policy=static --reserved-cpus=0",
+						}
+						Expect(ph.Patch(ctx, k8sInstallerConfig, patch.WithStatusObservedGeneration{})).Should(Succeed())
+
+						WaitForObjectToBeUpdatedInCache(k8sInstallerConfig, func(object client.Object) bool {
+							return object.(*infrastructurev1beta1.K8sInstallerConfig).Status.Ready == true
+						})
+
+						_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+						Expect(err).NotTo(HaveOccurred())
+
+						patchedHost := &infrastructurev1beta1.ByoHost{}
+						Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, patchedHost)).Should(Succeed())
+						Expect(patchedHost.Annotations[infrastructurev1beta1.KubeletExtraArgsAnnotation]).To(HavePrefix("--cpu-manager-policy=static --reserved-cpus=0"))
+					})
+
 					AfterEach(func() {
 						Expect(k8sClientUncached.Delete(ctx, k8sInstallerConfig)).Should(Succeed())
 					})
@@ -575,6 +1025,44 @@ var _ = Describe("Controllers/ByomachineController", func() {
 				}))
 			})
 
+			It("should mark BYOHostReady as False and not attach a host when the bootstrap data cannot be run by the host agent", func() {
+				scriptSecret := builder.Secret(defaultNamespace, "script-bootstrap-secret").WithData("#!/bin/bash\necho test\n").Build()
+				Expect(k8sClientUncached.Create(ctx, scriptSecret)).Should(Succeed())
+				defer func() {
+					Expect(k8sClientUncached.Delete(ctx, scriptSecret)).Should(Succeed())
+				}()
+
+				ph, err := patch.NewHelper(machine, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				machine.Spec.Bootstrap = clusterv1.Bootstrap{DataSecretName: &scriptSecret.Name}
+				Expect(ph.Patch(ctx, machine, patch.WithStatusObservedGeneration{})).Should(Succeed())
+
+				WaitForObjectToBeUpdatedInCache(machine, func(object client.Object) bool {
+					dataSecretName := object.(*clusterv1.Machine).Spec.Bootstrap.DataSecretName
+					return dataSecretName != nil && *dataSecretName == scriptSecret.Name
+				})
+
+				_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).To(BeNil())
+
+				createdByoMachine := &infrastructurev1beta1.ByoMachine{}
+				err = k8sClientUncached.Get(ctx, byoMachineLookupKey, createdByoMachine)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				actualCondition := conditions.Get(createdByoMachine, infrastructurev1beta1.BYOHostReady)
+				Expect(*actualCondition).To(conditions.MatchCondition(clusterv1.Condition{
+					Type:     infrastructurev1beta1.BYOHostReady,
+					Status:   corev1.ConditionFalse,
+					Reason:   infrastructurev1beta1.BootstrapDataInvalidReason,
+					Severity: clusterv1.ConditionSeverityError,
+					Message:  "bootstrap data is a script, the host agent only runs cloud-config",
+				}))
+
+				createdByoHost := &infrastructurev1beta1.ByoHost{}
+				Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, createdByoHost)).Should(Succeed())
+				Expect(createdByoHost.Status.MachineRef).To(BeNil())
+			})
+
 			It("should mark BYOHostReady condition as False when the InstallationSecret is not available", func() {
 				// making the node unavailable by deleting it so that the reason persists
 				Expect(clientFake.Delete(ctx, node)).Should(Succeed())
@@ -596,6 +1084,49 @@ var _ = Describe("Controllers/ByomachineController", func() {
 			})
 		})
 
+		Context("When the ByoMachine claimed hosts without attaching them", func() {
+			var claimedByoHost, extraByoHost *infrastructurev1beta1.ByoHost
+
+			BeforeEach(func() {
+				claimLabels := func() map[string]string {
+					return map[string]string{
+						clusterv1.ClusterLabelName:                    defaultClusterName,
+						infrastructurev1beta1.AttachedByoMachineLabel: byoMachine.Namespace + "." + byoMachine.Name,
+					}
+				}
+				claimedByoHost = builder.ByoHost(defaultNamespace, "claimed-host-a").WithLabels(claimLabels()).Build()
+				Expect(k8sClientUncached.Create(ctx, claimedByoHost)).Should(Succeed())
+				extraByoHost = builder.ByoHost(defaultNamespace, "claimed-host-b").WithLabels(claimLabels()).Build()
+				Expect(k8sClientUncached.Create(ctx, extraByoHost)).Should(Succeed())
+
+				node = builder.Node(defaultNamespace, claimedByoHost.Name).Build()
+				Expect(clientFake.Create(ctx, node)).Should(Succeed())
+				WaitForObjectsToBePopulatedInCache(claimedByoHost, extraByoHost)
+			})
+
+			AfterEach(func() {
+				Expect(k8sClientUncached.Delete(ctx, claimedByoHost)).ToNot(HaveOccurred())
+				Expect(k8sClientUncached.Delete(ctx, extraByoHost)).ToNot(HaveOccurred())
+			})
+
+			It("completes the attachment of the first claimed host and releases the other", func() {
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).ToNot(HaveOccurred())
+
+				createdByoHost := &infrastructurev1beta1.ByoHost{}
+				Expect(k8sClientUncached.Get(ctx, client.ObjectKeyFromObject(claimedByoHost), createdByoHost)).Should(Succeed())
+				Expect(createdByoHost.Status.MachineRef).NotTo(BeNil())
+				Expect(createdByoHost.Status.MachineRef.Name).To(Equal(byoMachine.Name))
+				Expect(createdByoHost.Spec.BootstrapSecret).NotTo(BeNil())
+				Expect(createdByoHost.Annotations).NotTo(HaveKey(infrastructurev1beta1.HostCleanupAnnotation))
+
+				releasedByoHost := &infrastructurev1beta1.ByoHost{}
+				Expect(k8sClientUncached.Get(ctx, client.ObjectKeyFromObject(extraByoHost), releasedByoHost)).Should(Succeed())
+				Expect(releasedByoHost.Status.MachineRef).To(BeNil())
+				Expect(releasedByoHost.Annotations).To(HaveKey(infrastructurev1beta1.HostCleanupAnnotation))
+			})
+		})
+
 		Context("When no matching BYO Hosts are available", func() {
 			BeforeEach(func() {
 				byoHost = builder.ByoHost(defaultNamespace, "byohost-with-different-label").
@@ -620,19 +1151,17 @@ var _ = Describe("Controllers/ByomachineController", func() {
 
 			It("should mark BYOHostReady as False when BYOHosts is available but label mismatch", func() {
 				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
-				Expect(err).To(MatchError("no hosts found"))
+				Expect(err).NotTo(HaveOccurred())
 
 				createdByoMachine := &infrastructurev1beta1.ByoMachine{}
 				err = k8sClientUncached.Get(ctx, byoMachineLookupKey, createdByoMachine)
 				Expect(err).ToNot(HaveOccurred())
 
 				actualCondition := conditions.Get(createdByoMachine, infrastructurev1beta1.BYOHostReady)
-				Expect(*actualCondition).To(conditions.MatchCondition(clusterv1.Condition{
-					Type:     infrastructurev1beta1.BYOHostReady,
-					Status:   corev1.ConditionFalse,
-					Reason:   infrastructurev1beta1.BYOHostsUnavailableReason,
-					Severity: clusterv1.ConditionSeverityInfo,
-				}))
+				Expect(actualCondition.Status).To(Equal(corev1.ConditionFalse))
+				Expect(actualCondition.Reason).To(Equal(infrastructurev1beta1.WaitingForAvailableHostReason))
+				Expect(actualCondition.Severity).To(Equal(clusterv1.ConditionSeverityInfo))
+				Expect(actualCondition.Message).To(MatchRegexp(`[1-9]\d* filtered by the selector`))
 
 				// assert events
 				events := eventutils.CollectEvents(recorder.Events)
@@ -642,6 +1171,57 @@ var _ = Describe("Controllers/ByomachineController", func() {
 			})
 		})
 
+		Context("When a matching BYO Host is available among hosts with other labels", func() {
+			var matchingByoHost *infrastructurev1beta1.ByoHost
+
+			BeforeEach(func() {
+				byoHost = builder.ByoHost(defaultNamespace, "byohost-with-other-label").
+					WithLabels(map[string]string{"CPUs": "2"}).
+					Build()
+				Expect(k8sClientUncached.Create(ctx, byoHost)).Should(Succeed())
+				matchingByoHost = builder.ByoHost(defaultNamespace, "byohost-with-matching-label").
+					WithLabels(map[string]string{"CPUs": "8"}).
+					Build()
+				Expect(k8sClientUncached.Create(ctx, matchingByoHost)).Should(Succeed())
+
+				byoMachine = builder.ByoMachine(defaultNamespace, "byomachine-with-indexed-selector").
+					WithClusterLabel(defaultClusterName).
+					WithOwnerMachine(machine).
+					WithLabelSelector(map[string]string{"CPUs": "8"}).
+					Build()
+				Expect(k8sClientUncached.Create(ctx, byoMachine)).Should(Succeed())
+
+				WaitForObjectsToBePopulatedInCache(byoHost, matchingByoHost, byoMachine)
+				byoMachineLookupKey = types.NamespacedName{Name: byoMachine.Name, Namespace: byoMachine.Namespace}
+			})
+
+			AfterEach(func() {
+				Expect(k8sClientUncached.Delete(ctx, byoHost)).ToNot(HaveOccurred())
+				Expect(k8sClientUncached.Delete(ctx, matchingByoHost)).ToNot(HaveOccurred())
+			})
+
+			It("should index the available hosts by the namespaces they can be used from and by their labels", func() {
+				hosts := &infrastructurev1beta1.ByoHostList{}
+				Expect(reconciler.Client.List(ctx, hosts, client.MatchingFields{controllers.ByoHostAvailableIndex: defaultNamespace + "/CPUs=8"})).To(Succeed())
+				Expect(hosts.Items).To(HaveLen(1))
+				Expect(hosts.Items[0].Name).To(Equal(matchingByoHost.Name))
+			})
+
+			It("should attach the ByoHost matching the selector", func() {
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).NotTo(HaveOccurred())
+
+				attachedByoHost := &infrastructurev1beta1.ByoHost{}
+				Expect(k8sClientUncached.Get(ctx, client.ObjectKeyFromObject(matchingByoHost), attachedByoHost)).Should(Succeed())
+				Expect(attachedByoHost.Status.MachineRef).NotTo(BeNil())
+				Expect(attachedByoHost.Status.MachineRef.Name).To(Equal(byoMachine.Name))
+
+				otherByoHost := &infrastructurev1beta1.ByoHost{}
+				Expect(k8sClientUncached.Get(ctx, client.ObjectKeyFromObject(byoHost), otherByoHost)).Should(Succeed())
+				Expect(otherByoHost.Status.MachineRef).To(BeNil())
+			})
+		})
+
 		Context("When all ByoHost are attached", func() {
 			BeforeEach(func() {
 				byoHost = builder.ByoHost(defaultNamespace, "byohost-attached-different-cluster").
@@ -658,19 +1238,17 @@ var _ = Describe("Controllers/ByomachineController", func() {
 
 			It("should mark BYOHostReady as False", func() {
 				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
-				Expect(err).To(MatchError("no hosts found"))
+				Expect(err).NotTo(HaveOccurred())
 
 				createdByoMachine := &infrastructurev1beta1.ByoMachine{}
 				err = k8sClientUncached.Get(ctx, byoMachineLookupKey, createdByoMachine)
 				Expect(err).ToNot(HaveOccurred())
 
 				actualCondition := conditions.Get(createdByoMachine, infrastructurev1beta1.BYOHostReady)
-				Expect(*actualCondition).To(conditions.MatchCondition(clusterv1.Condition{
-					Type:     infrastructurev1beta1.BYOHostReady,
-					Status:   corev1.ConditionFalse,
-					Reason:   infrastructurev1beta1.BYOHostsUnavailableReason,
-					Severity: clusterv1.ConditionSeverityInfo,
-				}))
+				Expect(actualCondition.Status).To(Equal(corev1.ConditionFalse))
+				Expect(actualCondition.Reason).To(Equal(infrastructurev1beta1.WaitingForAvailableHostReason))
+				Expect(actualCondition.Severity).To(Equal(clusterv1.ConditionSeverityInfo))
+				Expect(actualCondition.Message).To(MatchRegexp(`[1-9]\d* by capacity`))
 
 				// assert events
 				events := eventutils.CollectEvents(recorder.Events)
@@ -680,22 +1258,513 @@ var _ = Describe("Controllers/ByomachineController", func() {
 			})
 		})
 
-		Context("When multiple BYO Host are available", func() {
-			var (
-				byoHost1 *infrastructurev1beta1.ByoHost
-				byoHost2 *infrastructurev1beta1.ByoHost
-			)
-
+		Context("When the only available ByoHost is unschedulable", func() {
 			BeforeEach(func() {
-				byoHost1 = builder.ByoHost(defaultNamespace, defaultByoHostName).Build()
-				Expect(k8sClientUncached.Create(ctx, byoHost1)).Should(Succeed())
-				byoHost2 = builder.ByoHost(defaultNamespace, defaultByoHostName).Build()
-				Expect(k8sClientUncached.Create(ctx, byoHost2)).Should(Succeed())
-
-				WaitForObjectsToBePopulatedInCache(byoHost1, byoHost2)
+				byoHost = builder.ByoHost(defaultNamespace, "byohost-unschedulable").
+					WithAnnotations(map[string]string{infrastructurev1beta1.UnschedulableAnnotation: ""}).
+					Build()
+				Expect(k8sClientUncached.Create(ctx, byoHost)).Should(Succeed())
 
-				Expect(clientFake.Create(ctx, builder.Node(defaultNamespace, byoHost1.Name).Build())).Should(Succeed())
-				Expect(clientFake.Create(ctx, builder.Node(defaultNamespace, byoHost2.Name).Build())).Should(Succeed())
+				WaitForObjectsToBePopulatedInCache(byoHost)
+			})
+
+			AfterEach(func() {
+				Expect(k8sClientUncached.Delete(ctx, byoHost)).ToNot(HaveOccurred())
+			})
+
+			It("should not attach the ByoHost", func() {
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).NotTo(HaveOccurred())
+
+				createdByoHost := &infrastructurev1beta1.ByoHost{}
+				err = k8sClientUncached.Get(ctx, types.NamespacedName{Name: byoHost.Name, Namespace: defaultNamespace}, createdByoHost)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(createdByoHost.Status.MachineRef).To(BeNil())
+			})
+		})
+
+		Context("When the only available ByoHost is under maintenance", func() {
+			BeforeEach(func() {
+				byoHost = builder.ByoHost(defaultNamespace, "byohost-maintenance").Build()
+				byoHost.Spec.Unschedulable = true
+				Expect(k8sClientUncached.Create(ctx, byoHost)).Should(Succeed())
+
+				WaitForObjectsToBePopulatedInCache(byoHost)
+			})
+
+			AfterEach(func() {
+				Expect(k8sClientUncached.Delete(ctx, byoHost)).ToNot(HaveOccurred())
+			})
+
+			It("should not attach the ByoHost", func() {
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).NotTo(HaveOccurred())
+
+				createdByoHost := &infrastructurev1beta1.ByoHost{}
+				err = k8sClientUncached.Get(ctx, types.NamespacedName{Name: byoHost.Name, Namespace: defaultNamespace}, createdByoHost)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(createdByoHost.Status.MachineRef).To(BeNil())
+			})
+		})
+
+		Context("When the only available ByoHost is in a host pool namespace", func() {
+			var poolNamespace *corev1.Namespace
+
+			BeforeEach(func() {
+				poolNamespace = builder.Namespace("byoh-host-pool").Build()
+				Expect(k8sClientUncached.Create(ctx, poolNamespace)).Should(Succeed())
+			})
+
+			AfterEach(func() {
+				Expect(k8sClientUncached.Delete(ctx, byoHost)).ToNot(HaveOccurred())
+			})
+
+			createPoolByoHost := func(allowedNamespaces ...string) {
+				byoHost = builder.ByoHost(poolNamespace.Name, "pool-byohost").
+					WithAllowedNamespaces(allowedNamespaces...).
+					Build()
+				Expect(k8sClientUncached.Create(ctx, byoHost)).Should(Succeed())
+				node = builder.Node(defaultNamespace, byoHost.Name).Build()
+				Expect(clientFake.Create(ctx, node)).Should(Succeed())
+				WaitForObjectsToBePopulatedInCache(byoHost)
+				byoHostLookupKey = types.NamespacedName{Name: byoHost.Name, Namespace: byoHost.Namespace}
+			}
+
+			grantHostPoolUse := func() {
+				role := &rbacv1.Role{
+					ObjectMeta: metav1.ObjectMeta{Name: "byoh-host-pool-user", Namespace: poolNamespace.Name},
+					Rules: []rbacv1.PolicyRule{{
+						APIGroups: []string{infrastructurev1beta1.GroupVersion.Group},
+						Resources: []string{"byohosts"},
+						Verbs:     []string{controllers.HostPoolUseVerb},
+					}},
+				}
+				Expect(k8sClientUncached.Create(ctx, role)).Should(Succeed())
+				roleBinding := &rbacv1.RoleBinding{
+					ObjectMeta: metav1.ObjectMeta{Name: "byoh-host-pool-user", Namespace: poolNamespace.Name},
+					RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: role.Name},
+					Subjects: []rbacv1.Subject{{
+						APIGroup: rbacv1.GroupName,
+						Kind:     rbacv1.GroupKind,
+						Name:     "system:serviceaccounts:" + defaultNamespace,
+					}},
+				}
+				Expect(k8sClientUncached.Create(ctx, roleBinding)).Should(Succeed())
+			}
+
+			It("should not attach the ByoHost when it does not allow the namespace of the ByoMachine", func() {
+				grantHostPoolUse()
+				createPoolByoHost("another-namespace")
+
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).NotTo(HaveOccurred())
+
+				createdByoHost := &infrastructurev1beta1.ByoHost{}
+				Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, createdByoHost)).Should(Succeed())
+				Expect(createdByoHost.Status.MachineRef).To(BeNil())
+			})
+
+			It("should not attach the ByoHost when the namespace of the ByoMachine is not granted the use of the pool", func() {
+				createPoolByoHost(defaultNamespace)
+
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).NotTo(HaveOccurred())
+
+				createdByoHost := &infrastructurev1beta1.ByoHost{}
+				Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, createdByoHost)).Should(Succeed())
+				Expect(createdByoHost.Status.MachineRef).To(BeNil())
+			})
+
+			It("should attach the ByoHost when it allows the namespace of the ByoMachine and the pool use is granted", func() {
+				grantHostPoolUse()
+				createPoolByoHost(controllers.AllNamespaces)
+
+				Eventually(func() *corev1.ObjectReference {
+					_, _ = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+					createdByoHost := &infrastructurev1beta1.ByoHost{}
+					Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, createdByoHost)).Should(Succeed())
+					return createdByoHost.Status.MachineRef
+				}).ShouldNot(BeNil())
+
+				createdByoHost := &infrastructurev1beta1.ByoHost{}
+				Expect(k8sClientUncached.Get(ctx, byoHostLookupKey, createdByoHost)).Should(Succeed())
+				Expect(createdByoHost.Status.MachineRef.Namespace).To(Equal(byoMachine.Namespace))
+				Expect(createdByoHost.Spec.BootstrapSecret.Namespace).To(Equal(byoMachine.Namespace))
+			})
+		})
+
+		Context("When anti-affinity is set on the ByoMachine", func() {
+			var (
+				peerByoMachine *infrastructurev1beta1.ByoMachine
+				peerByoHost    *infrastructurev1beta1.ByoHost
+				rack1ByoHost   *infrastructurev1beta1.ByoHost
+				rack2ByoHost   *infrastructurev1beta1.ByoHost
+				topologyKey    = "topology.byoh/rack"
+			)
+
+			BeforeEach(func() {
+				peerByoMachine = builder.ByoMachine(defaultNamespace, "peer-byomachine").
+					WithClusterLabel(defaultClusterName).
+					Build()
+				Expect(k8sClientUncached.Create(ctx, peerByoMachine)).Should(Succeed())
+
+				peerByoHost = builder.ByoHost(defaultNamespace, "peer-byohost").
+					WithLabels(map[string]string{
+						clusterv1.ClusterLabelName:                    defaultClusterName,
+						infrastructurev1beta1.AttachedByoMachineLabel: peerByoMachine.Namespace + "." + peerByoMachine.Name,
+						topologyKey: "r1",
+					}).
+					Build()
+				Expect(k8sClientUncached.Create(ctx, peerByoHost)).Should(Succeed())
+
+				rack1ByoHost = builder.ByoHost(defaultNamespace, "rack1-byohost").WithLabels(map[string]string{topologyKey: "r1"}).Build()
+				Expect(k8sClientUncached.Create(ctx, rack1ByoHost)).Should(Succeed())
+
+				ph, err := patch.NewHelper(byoMachine, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				byoMachine.Spec.AntiAffinity = &infrastructurev1beta1.AntiAffinity{
+					TopologyKey: topologyKey,
+					Policy:      infrastructurev1beta1.AntiAffinityPolicyRequired,
+				}
+				Expect(ph.Patch(ctx, byoMachine)).Should(Succeed())
+
+				WaitForObjectsToBePopulatedInCache(peerByoMachine, peerByoHost, rack1ByoHost)
+				WaitForObjectToBeUpdatedInCache(byoMachine, func(object client.Object) bool {
+					return object.(*infrastructurev1beta1.ByoMachine).Spec.AntiAffinity != nil
+				})
+			})
+
+			AfterEach(func() {
+				Expect(k8sClientUncached.Delete(ctx, peerByoHost)).ToNot(HaveOccurred())
+				Expect(k8sClientUncached.Delete(ctx, rack1ByoHost)).ToNot(HaveOccurred())
+				Expect(k8sClientUncached.Delete(ctx, peerByoMachine)).ToNot(HaveOccurred())
+			})
+
+			It("should not attach a ByoHost in a topology domain used by a peer", func() {
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).To(MatchError("no hosts found"))
+
+				// assert events
+				events := eventutils.CollectEvents(recorder.Events)
+				Expect(events).Should(ConsistOf([]string{
+					"Warning ByoHostSelectionFailed No available ByoHost in a free " + topologyKey + " topology domain",
+				}))
+			})
+
+			It("should attach the ByoHost in a free topology domain", func() {
+				rack2ByoHost = builder.ByoHost(defaultNamespace, "rack2-byohost").WithLabels(map[string]string{topologyKey: "r2"}).Build()
+				Expect(k8sClientUncached.Create(ctx, rack2ByoHost)).Should(Succeed())
+				Expect(clientFake.Create(ctx, builder.Node(defaultNamespace, rack2ByoHost.Name).Build())).Should(Succeed())
+				WaitForObjectsToBePopulatedInCache(rack2ByoHost)
+
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).ToNot(HaveOccurred())
+
+				createdByoHost := &infrastructurev1beta1.ByoHost{}
+				err = k8sClientUncached.Get(ctx, types.NamespacedName{Name: rack2ByoHost.Name, Namespace: defaultNamespace}, createdByoHost)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(createdByoHost.Status.MachineRef.Name).To(Equal(byoMachine.Name))
+
+				Expect(k8sClientUncached.Delete(ctx, rack2ByoHost)).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("When the ByoMachine requires GPUs", func() {
+			var (
+				cpuByoHost *infrastructurev1beta1.ByoHost
+				gpuByoHost *infrastructurev1beta1.ByoHost
+			)
+
+			BeforeEach(func() {
+				cpuByoHost = builder.ByoHost(defaultNamespace, "cpu-byohost").Build()
+				Expect(k8sClientUncached.Create(ctx, cpuByoHost)).Should(Succeed())
+
+				ph, err := patch.NewHelper(byoMachine, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				byoMachine.Spec.Devices = &infrastructurev1beta1.DeviceRequirements{
+					GPUs: &infrastructurev1beta1.GPURequirement{Count: 2, Vendor: "nvidia", Model: "A100"},
+				}
+				Expect(ph.Patch(ctx, byoMachine)).Should(Succeed())
+
+				WaitForObjectsToBePopulatedInCache(cpuByoHost)
+				WaitForObjectToBeUpdatedInCache(byoMachine, func(object client.Object) bool {
+					return object.(*infrastructurev1beta1.ByoMachine).Spec.Devices != nil
+				})
+			})
+
+			AfterEach(func() {
+				Expect(k8sClientUncached.Delete(ctx, cpuByoHost)).ToNot(HaveOccurred())
+			})
+
+			It("should not attach a ByoHost without the GPUs", func() {
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).NotTo(HaveOccurred())
+
+				createdByoHost := &infrastructurev1beta1.ByoHost{}
+				err = k8sClientUncached.Get(ctx, types.NamespacedName{Name: cpuByoHost.Name, Namespace: defaultNamespace}, createdByoHost)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(createdByoHost.Status.MachineRef).To(BeNil())
+
+				updatedByoMachine := &infrastructurev1beta1.ByoMachine{}
+				Expect(k8sClientUncached.Get(ctx, byoMachineLookupKey, updatedByoMachine)).Should(Succeed())
+				actualCondition := conditions.Get(updatedByoMachine, infrastructurev1beta1.BYOHostReady)
+				Expect(actualCondition.Message).To(MatchRegexp(`[1-9]\d* by devices`))
+			})
+
+			It("should attach the ByoHost reporting the GPUs", func() {
+				gpuByoHost = builder.ByoHost(defaultNamespace, "gpu-byohost").Build()
+				Expect(k8sClientUncached.Create(ctx, gpuByoHost)).Should(Succeed())
+				ph, err := patch.NewHelper(gpuByoHost, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				gpuByoHost.Status.HostDetails.GPUs = []infrastructurev1beta1.GPUInfo{
+					{VendorID: "10de", DeviceID: "20b5", Vendor: "NVIDIA", Model: "GA100 [A100 PCIe 80GB]", Count: 2},
+				}
+				Expect(ph.Patch(ctx, gpuByoHost)).Should(Succeed())
+				Expect(clientFake.Create(ctx, builder.Node(defaultNamespace, gpuByoHost.Name).Build())).Should(Succeed())
+				WaitForObjectToBeUpdatedInCache(gpuByoHost, func(object client.Object) bool {
+					return len(object.(*infrastructurev1beta1.ByoHost).Status.HostDetails.GPUs) > 0
+				})
+
+				_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).ToNot(HaveOccurred())
+
+				createdByoHost := &infrastructurev1beta1.ByoHost{}
+				err = k8sClientUncached.Get(ctx, types.NamespacedName{Name: gpuByoHost.Name, Namespace: defaultNamespace}, createdByoHost)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(createdByoHost.Status.MachineRef.Name).To(Equal(byoMachine.Name))
+
+				Expect(k8sClientUncached.Delete(ctx, gpuByoHost)).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("When the ByoCluster derives its failure domains from a host label", func() {
+			var (
+				site1ByoHost       *infrastructurev1beta1.ByoHost
+				failureDomainLabel = "topology.byoh/site"
+			)
+
+			BeforeEach(func() {
+				ph, err := patch.NewHelper(byoCluster, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				byoCluster.Spec.FailureDomainLabel = failureDomainLabel
+				Expect(ph.Patch(ctx, byoCluster)).Should(Succeed())
+
+				ph, err = patch.NewHelper(machine, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				machine.Spec.FailureDomain = pointer.StringPtr("s2")
+				Expect(ph.Patch(ctx, machine)).Should(Succeed())
+
+				site1ByoHost = builder.ByoHost(defaultNamespace, "site1-byohost").WithLabels(map[string]string{failureDomainLabel: "s1"}).Build()
+				Expect(k8sClientUncached.Create(ctx, site1ByoHost)).Should(Succeed())
+
+				WaitForObjectsToBePopulatedInCache(site1ByoHost)
+				WaitForObjectToBeUpdatedInCache(byoCluster, func(object client.Object) bool {
+					return object.(*infrastructurev1beta1.ByoCluster).Spec.FailureDomainLabel != ""
+				})
+				WaitForObjectToBeUpdatedInCache(machine, func(object client.Object) bool {
+					return object.(*clusterv1.Machine).Spec.FailureDomain != nil
+				})
+			})
+
+			AfterEach(func() {
+				Expect(k8sClientUncached.Delete(ctx, site1ByoHost)).ToNot(HaveOccurred())
+				ph, err := patch.NewHelper(byoCluster, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				byoCluster.Spec.FailureDomainLabel = ""
+				Expect(ph.Patch(ctx, byoCluster)).Should(Succeed())
+				WaitForObjectToBeUpdatedInCache(byoCluster, func(object client.Object) bool {
+					return object.(*infrastructurev1beta1.ByoCluster).Spec.FailureDomainLabel == ""
+				})
+			})
+
+			It("should not attach a ByoHost of another failure domain", func() {
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).NotTo(HaveOccurred())
+
+				createdByoHost := &infrastructurev1beta1.ByoHost{}
+				Expect(k8sClientUncached.Get(ctx, client.ObjectKeyFromObject(site1ByoHost), createdByoHost)).Should(Succeed())
+				Expect(createdByoHost.Status.MachineRef).To(BeNil())
+
+				// assert events
+				events := eventutils.CollectEvents(recorder.Events)
+				Expect(events).Should(ConsistOf([]string{
+					"Warning ByoHostSelectionFailed No available ByoHost in failure domain s2",
+				}))
+			})
+
+			It("should attach the ByoHost of the failure domain of the Machine and report it", func() {
+				site2ByoHost := builder.ByoHost(defaultNamespace, "site2-byohost").WithLabels(map[string]string{failureDomainLabel: "s2"}).Build()
+				Expect(k8sClientUncached.Create(ctx, site2ByoHost)).Should(Succeed())
+				defer func() {
+					Expect(k8sClientUncached.Delete(ctx, site2ByoHost)).ToNot(HaveOccurred())
+				}()
+				Expect(clientFake.Create(ctx, builder.Node(defaultNamespace, site2ByoHost.Name).Build())).Should(Succeed())
+				WaitForObjectsToBePopulatedInCache(site2ByoHost)
+
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).ToNot(HaveOccurred())
+
+				createdByoHost := &infrastructurev1beta1.ByoHost{}
+				Expect(k8sClientUncached.Get(ctx, client.ObjectKeyFromObject(site2ByoHost), createdByoHost)).Should(Succeed())
+				Expect(createdByoHost.Status.MachineRef.Name).To(Equal(byoMachine.Name))
+
+				createdByoMachine := &infrastructurev1beta1.ByoMachine{}
+				Expect(k8sClientUncached.Get(ctx, byoMachineLookupKey, createdByoMachine)).Should(Succeed())
+				Expect(createdByoMachine.Status.FailureDomain).To(Equal("s2"))
+			})
+		})
+
+		Context("When the only available ByoHost is reserved", func() {
+			AfterEach(func() {
+				Expect(k8sClientUncached.Delete(ctx, byoHost)).ToNot(HaveOccurred())
+			})
+
+			It("should not attach a reserved ByoHost to a ByoMachine which does not pin it", func() {
+				byoHost = builder.ByoHost(defaultNamespace, "byohost-reserved").WithReserved().Build()
+				Expect(k8sClientUncached.Create(ctx, byoHost)).Should(Succeed())
+				WaitForObjectsToBePopulatedInCache(byoHost)
+
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("should not attach a ByoHost reserved for another cluster", func() {
+				byoHost = builder.ByoHost(defaultNamespace, "byohost-reserved-for").WithReservedFor("another-cluster").Build()
+				Expect(k8sClientUncached.Create(ctx, byoHost)).Should(Succeed())
+				WaitForObjectsToBePopulatedInCache(byoHost)
+
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("should attach the reserved ByoHost pinned by the ByoMachine", func() {
+				byoHost = builder.ByoHost(defaultNamespace, "byohost-pinned").WithReserved().WithReservedFor(defaultClusterName).Build()
+				Expect(k8sClientUncached.Create(ctx, byoHost)).Should(Succeed())
+				Expect(clientFake.Create(ctx, builder.Node(defaultNamespace, byoHost.Name).Build())).Should(Succeed())
+
+				ph, err := patch.NewHelper(byoMachine, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				byoMachine.Spec.HostRef = &corev1.ObjectReference{Name: byoHost.Name}
+				Expect(ph.Patch(ctx, byoMachine)).Should(Succeed())
+				WaitForObjectsToBePopulatedInCache(byoHost)
+				WaitForObjectToBeUpdatedInCache(byoMachine, func(object client.Object) bool {
+					return object.(*infrastructurev1beta1.ByoMachine).Spec.HostRef != nil
+				})
+
+				_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).ToNot(HaveOccurred())
+
+				createdByoHost := &infrastructurev1beta1.ByoHost{}
+				Expect(k8sClientUncached.Get(ctx, types.NamespacedName{Name: byoHost.Name, Namespace: defaultNamespace}, createdByoHost)).Should(Succeed())
+				Expect(createdByoHost.Status.MachineRef.Name).To(Equal(byoMachine.Name))
+			})
+
+			It("should wait for the ByoHost pinned by the ByoMachine", func() {
+				byoHost = builder.ByoHost(defaultNamespace, "byohost-not-pinned").Build()
+				Expect(k8sClientUncached.Create(ctx, byoHost)).Should(Succeed())
+
+				ph, err := patch.NewHelper(byoMachine, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				byoMachine.Spec.HostRef = &corev1.ObjectReference{Name: "non-existent-byohost"}
+				Expect(ph.Patch(ctx, byoMachine)).Should(Succeed())
+				WaitForObjectsToBePopulatedInCache(byoHost)
+				WaitForObjectToBeUpdatedInCache(byoMachine, func(object client.Object) bool {
+					return object.(*infrastructurev1beta1.ByoMachine).Spec.HostRef != nil
+				})
+
+				_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).To(MatchError("pinned host non-existent-byohost not available: it does not exist"))
+
+				createdByoMachine := &infrastructurev1beta1.ByoMachine{}
+				Expect(k8sClientUncached.Get(ctx, byoMachineLookupKey, createdByoMachine)).Should(Succeed())
+				actualCondition := conditions.Get(createdByoMachine, infrastructurev1beta1.BYOHostReady)
+				Expect(*actualCondition).To(conditions.MatchCondition(clusterv1.Condition{
+					Type:     infrastructurev1beta1.BYOHostReady,
+					Status:   corev1.ConditionFalse,
+					Reason:   infrastructurev1beta1.ReservedHostUnavailableReason,
+					Severity: clusterv1.ConditionSeverityInfo,
+					Message:  "pinned ByoHost non-existent-byohost is not available: it does not exist",
+				}))
+
+				// assert events
+				events := eventutils.CollectEvents(recorder.Events)
+				Expect(events).Should(ConsistOf([]string{
+					"Warning ByoHostSelectionFailed Pinned ByoHost non-existent-byohost is not available: it does not exist",
+				}))
+			})
+		})
+
+		Context("When the ByoHost pinned by the ByoMachine is attached to another ByoMachine", func() {
+			BeforeEach(func() {
+				byoHost = builder.ByoHost(defaultNamespace, "byohost-pinned-attached").WithReserved().
+					WithLabels(map[string]string{clusterv1.ClusterLabelName: defaultClusterName}).Build()
+				Expect(k8sClientUncached.Create(ctx, byoHost)).Should(Succeed())
+				byoHost.Status.MachineRef = &corev1.ObjectReference{
+					APIVersion: infrastructurev1beta1.GroupVersion.String(),
+					Kind:       "ByoMachine",
+					Namespace:  defaultNamespace,
+					Name:       "other-byomachine",
+				}
+				Expect(k8sClientUncached.Status().Update(ctx, byoHost)).Should(Succeed())
+
+				byoMachine = builder.ByoMachine(defaultNamespace, "byomachine-pinned").
+					WithClusterLabel(defaultClusterName).
+					WithOwnerMachine(machine).
+					WithHostRef(byoHost.Name).
+					Build()
+				Expect(k8sClientUncached.Create(ctx, byoMachine)).Should(Succeed())
+
+				WaitForObjectsToBePopulatedInCache(byoHost, byoMachine)
+				WaitForObjectToBeUpdatedInCache(byoHost, func(object client.Object) bool {
+					return object.(*infrastructurev1beta1.ByoHost).Status.MachineRef != nil
+				})
+				byoMachineLookupKey = types.NamespacedName{Name: byoMachine.Name, Namespace: byoMachine.Namespace}
+			})
+
+			AfterEach(func() {
+				Expect(k8sClientUncached.Delete(ctx, byoHost)).ToNot(HaveOccurred())
+			})
+
+			It("should report the ByoMachine the pinned ByoHost is attached to", func() {
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).To(MatchError(fmt.Sprintf("pinned host %s not available: it is attached to ByoMachine %s/other-byomachine",
+					byoHost.Name, defaultNamespace)))
+
+				createdByoMachine := &infrastructurev1beta1.ByoMachine{}
+				Expect(k8sClientUncached.Get(ctx, byoMachineLookupKey, createdByoMachine)).Should(Succeed())
+				Expect(*conditions.Get(createdByoMachine, infrastructurev1beta1.BYOHostReady)).To(conditions.MatchCondition(clusterv1.Condition{
+					Type:     infrastructurev1beta1.BYOHostReady,
+					Status:   corev1.ConditionFalse,
+					Reason:   infrastructurev1beta1.ReservedHostUnavailableReason,
+					Severity: clusterv1.ConditionSeverityInfo,
+					Message:  fmt.Sprintf("pinned ByoHost %s is not available: it is attached to ByoMachine %s/other-byomachine", byoHost.Name, defaultNamespace),
+				}))
+
+				// assert events
+				events := eventutils.CollectEvents(recorder.Events)
+				Expect(events).Should(ConsistOf([]string{
+					fmt.Sprintf("Warning ByoHostSelectionFailed Pinned ByoHost %s is not available: it is attached to ByoMachine %s/other-byomachine",
+						byoHost.Name, defaultNamespace),
+				}))
+			})
+		})
+
+		Context("When multiple BYO Host are available", func() {
+			var (
+				byoHost1 *infrastructurev1beta1.ByoHost
+				byoHost2 *infrastructurev1beta1.ByoHost
+			)
+
+			BeforeEach(func() {
+				byoHost1 = builder.ByoHost(defaultNamespace, defaultByoHostName).Build()
+				Expect(k8sClientUncached.Create(ctx, byoHost1)).Should(Succeed())
+				byoHost2 = builder.ByoHost(defaultNamespace, defaultByoHostName).Build()
+				Expect(k8sClientUncached.Create(ctx, byoHost2)).Should(Succeed())
+
+				WaitForObjectsToBePopulatedInCache(byoHost1, byoHost2)
+
+				Expect(clientFake.Create(ctx, builder.Node(defaultNamespace, byoHost1.Name).Build())).Should(Succeed())
+				Expect(clientFake.Create(ctx, builder.Node(defaultNamespace, byoHost2.Name).Build())).Should(Succeed())
 			})
 
 			It("claims one of the available host", func() {
@@ -807,7 +1876,7 @@ var _ = Describe("Controllers/ByomachineController", func() {
 				})
 
 				_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
-				Expect(err).Should(MatchError("no hosts found"))
+				Expect(err).ShouldNot(HaveOccurred())
 
 				createdK8sInstallerConfig := &infrastructurev1beta1.K8sInstallerConfig{}
 				err = k8sClientUncached.Get(ctx, byoMachineLookupKey, createdK8sInstallerConfig)
@@ -816,6 +1885,48 @@ var _ = Describe("Controllers/ByomachineController", func() {
 				Expect(k8sInstallerConfigTemplate.Spec.Template.Spec).To(Equal(createdK8sInstallerConfig.Spec))
 				Expect(createdK8sInstallerConfig.GetAnnotations()[infrastructurev1beta1.K8sVersionAnnotation]).To(Equal(*machine.Spec.Version))
 			})
+
+			It("should create installer config from the template named by the annotation of the machine", func() {
+				machineInstallerConfigTemplate := builder.K8sInstallerConfigTemplate(defaultNamespace, defaultK8sInstallerConfigTemplateName).
+					WithBundleRepo("registry.example.com/byoh-bundles").
+					WithBundleType("k8s").
+					Build()
+				Expect(k8sClientUncached.Create(ctx, machineInstallerConfigTemplate)).Should(Succeed())
+				WaitForObjectsToBePopulatedInCache(machineInstallerConfigTemplate)
+
+				ph, err := patch.NewHelper(byoMachine, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				byoMachine.Spec.InstallerRef = &corev1.ObjectReference{
+					Kind:       "K8sInstallerConfigTemplate",
+					Namespace:  k8sInstallerConfigTemplate.Namespace,
+					Name:       k8sInstallerConfigTemplate.Name,
+					APIVersion: infrastructurev1beta1.GroupVersion.String(),
+				}
+				Expect(ph.Patch(ctx, byoMachine, patch.WithStatusObservedGeneration{})).Should(Succeed())
+
+				ph, err = patch.NewHelper(machine, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				annotations.AddAnnotations(machine, map[string]string{infrastructurev1beta1.InstallerConfigTemplateAnnotation: machineInstallerConfigTemplate.Name})
+				Expect(ph.Patch(ctx, machine)).Should(Succeed())
+
+				WaitForObjectToBeUpdatedInCache(byoMachine, func(object client.Object) bool {
+					return object.(*infrastructurev1beta1.ByoMachine).Spec.InstallerRef != nil
+				})
+				WaitForObjectToBeUpdatedInCache(machine, func(object client.Object) bool {
+					return object.GetAnnotations()[infrastructurev1beta1.InstallerConfigTemplateAnnotation] != ""
+				})
+
+				_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachineLookupKey})
+				Expect(err).ShouldNot(HaveOccurred())
+
+				createdByoMachine := &infrastructurev1beta1.ByoMachine{}
+				Expect(k8sClientUncached.Get(ctx, byoMachineLookupKey, createdByoMachine)).Should(Succeed())
+				Expect(createdByoMachine.Spec.InstallerRef.Name).To(Equal(machineInstallerConfigTemplate.Name))
+
+				createdK8sInstallerConfig := &infrastructurev1beta1.K8sInstallerConfig{}
+				Expect(k8sClientUncached.Get(ctx, byoMachineLookupKey, createdK8sInstallerConfig)).Should(Succeed())
+				Expect(createdK8sInstallerConfig.Spec).To(Equal(machineInstallerConfigTemplate.Spec.Template.Spec))
+			})
 		})
 
 		Context("When installer config template resource does not exists", func() {
@@ -879,3 +1990,16 @@ var _ = Describe("Controllers/ByomachineController", func() {
 		})
 	})
 })
+
+var _ = Describe("Controllers/ClusterPausedChanged", func() {
+	It("should only pass the updates pausing or unpausing the Cluster", func() {
+		pausedChanged := controllers.ClusterPausedChanged(logr.Discard())
+		cluster := builder.Cluster(defaultNamespace, "move-cluster").Build()
+		pausedCluster := builder.Cluster(defaultNamespace, "move-cluster").WithPausedField(true).Build()
+
+		Expect(pausedChanged.Update(event.UpdateEvent{ObjectOld: cluster, ObjectNew: pausedCluster})).To(BeTrue())
+		Expect(pausedChanged.Update(event.UpdateEvent{ObjectOld: pausedCluster, ObjectNew: cluster})).To(BeTrue())
+		Expect(pausedChanged.Update(event.UpdateEvent{ObjectOld: cluster, ObjectNew: cluster.DeepCopy()})).To(BeFalse())
+		Expect(pausedChanged.Create(event.CreateEvent{Object: pausedCluster})).To(BeFalse())
+	})
+})
@@ -0,0 +1,197 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/controllers/remote"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// DefaultNodeDrainTimeout is how long the pods of the node of a deleted ByoMachine are waited for
+	DefaultNodeDrainTimeout = 5 * time.Minute
+	// RequeueForNodeDrain requeue delay while the pods of the node are being deleted
+	RequeueForNodeDrain = 5 * time.Second
+)
+
+// drainNode cordons the node of the deleted machine and evicts its pods, so that they are terminated
+// gracefully before the host is reset. It requeues until no pod is left on the node or the drain timeout
+// is reached, the progress being reported in the NodeDrainSucceeded condition. The evictions refused by
+// a PodDisruptionBudget are retried on the next requeue.
+//
+// The Machine controller of Cluster API drains the node before the ByoMachine is deleted, in which case
+// no pod is left and the condition is only marked. This drain evicts the pods the Machine drain left
+// behind, e.g. once the NodeDrainTimeout of the Machine is reached, with the grace period of the
+// ByoMachine, since the host is reset right after and its pods would otherwise be killed abruptly.
+func (r *ByoMachineReconciler) drainNode(ctx context.Context, machineScope *byoMachineScope) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithValues("cluster", machineScope.Cluster.Name)
+	byoMachine := machineScope.ByoMachine
+	if !shouldDrainNode(machineScope) {
+		return ctrl.Result{}, nil
+	}
+
+	nodeRef := machineScope.Machine.Status.NodeRef
+	nodeName := nodeRef.Name
+	timeout := nodeDrainTimeout(byoMachine)
+	if conditions.GetReason(byoMachine, infrav1.NodeDrainSucceeded) == infrav1.NodeDrainingReason {
+		if started := conditions.GetLastTransitionTime(byoMachine, infrav1.NodeDrainSucceeded); started != nil && time.Since(started.Time) > timeout {
+			logger.Info("Node drain timed out, releasing the host anyway", "node", nodeName)
+			conditions.MarkFalse(byoMachine, infrav1.NodeDrainSucceeded, infrav1.NodeDrainTimeoutReason, clusterv1.ConditionSeverityWarning,
+				"pods of node %s were still running after %s", nodeName, timeout)
+			r.Recorder.Eventf(byoMachine, corev1.EventTypeWarning, "NodeDrainTimeout", "Node %s not drained after %s", nodeName, timeout)
+			return ctrl.Result{}, nil
+		}
+	} else {
+		conditions.MarkFalse(byoMachine, infrav1.NodeDrainSucceeded, infrav1.NodeDrainingReason, clusterv1.ConditionSeverityInfo, "cordoning node %s", nodeName)
+	}
+
+	remoteClient, err := r.getRemoteClient(ctx, byoMachine)
+	if err != nil {
+		logger.Error(err, "failed to get remote client")
+		return ctrl.Result{}, err
+	}
+
+	node := &corev1.Node{}
+	if err = remoteClient.Get(ctx, client.ObjectKey{Name: nodeRef.Name, Namespace: nodeRef.Namespace}, node); err != nil {
+		if apierrors.IsNotFound(err) {
+			conditions.MarkTrue(byoMachine, infrav1.NodeDrainSucceeded)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	if !node.Spec.Unschedulable {
+		var helper *patch.Helper
+		if helper, err = patch.NewHelper(node, remoteClient); err != nil {
+			return ctrl.Result{}, err
+		}
+		node.Spec.Unschedulable = true
+		if err = helper.Patch(ctx, node); err != nil {
+			logger.Error(err, "failed to cordon node", "node", nodeName)
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Eventf(byoMachine, corev1.EventTypeNormal, "NodeDrainStarted", "Cordoned node %s", nodeName)
+	}
+
+	clientSet, err := r.workloadClientSet(ctx, machineScope.Cluster)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	pods, err := podsToDrain(ctx, clientSet, nodeName)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if len(pods) == 0 {
+		logger.Info("Node drained", "node", nodeName)
+		conditions.MarkTrue(byoMachine, infrav1.NodeDrainSucceeded)
+		r.Recorder.Eventf(byoMachine, corev1.EventTypeNormal, "NodeDrainSucceeded", "Drained node %s", nodeName)
+		return ctrl.Result{}, nil
+	}
+
+	deleteOptions := &metav1.DeleteOptions{}
+	if drain := byoMachine.Spec.NodeDrain; drain != nil && drain.GracePeriodSeconds != nil {
+		deleteOptions.GracePeriodSeconds = drain.GracePeriodSeconds
+	}
+	for i := range pods {
+		if !pods[i].DeletionTimestamp.IsZero() {
+			continue
+		}
+		err = clientSet.PolicyV1().Evictions(pods[i].Namespace).Evict(ctx, &policyv1.Eviction{
+			ObjectMeta:    metav1.ObjectMeta{Name: pods[i].Name, Namespace: pods[i].Namespace},
+			DeleteOptions: deleteOptions,
+		})
+		switch {
+		case err == nil, apierrors.IsNotFound(err):
+		case apierrors.IsTooManyRequests(err):
+			// the eviction would violate a PodDisruptionBudget, it is retried on the next requeue
+			logger.V(4).Info("Pod eviction refused, retrying", "pod", client.ObjectKeyFromObject(&pods[i]), "reason", err.Error())
+		default:
+			logger.Error(err, "failed to evict pod", "pod", client.ObjectKeyFromObject(&pods[i]))
+			return ctrl.Result{}, err
+		}
+	}
+	conditions.MarkFalse(byoMachine, infrav1.NodeDrainSucceeded, infrav1.NodeDrainingReason, clusterv1.ConditionSeverityInfo,
+		"%d pods of node %s pending eviction", len(pods), nodeName)
+	return ctrl.Result{RequeueAfter: RequeueForNodeDrain}, nil
+}
+
+// workloadClientSet returns a live client set of the workload cluster, which is not backed by the
+// cache of the Tracker, so that listing the pods of a node does not watch all the pods of the cluster
+func (r *ByoMachineReconciler) workloadClientSet(ctx context.Context, cluster *clusterv1.Cluster) (kubernetes.Interface, error) {
+	if r.WorkloadClientSet != nil {
+		return r.WorkloadClientSet(ctx, client.ObjectKeyFromObject(cluster))
+	}
+	config, err := remote.RESTConfig(ctx, "byomachine-controller", r.Client, client.ObjectKeyFromObject(cluster))
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(config)
+}
+
+// shouldDrainNode tells if the node of the deleted machine is drained, following the
+// exclusion annotation of the Machine. The node is not drained once the drain is over,
+// nor when the whole cluster is deleted.
+func shouldDrainNode(machineScope *byoMachineScope) bool {
+	if machineScope.Machine.Status.NodeRef == nil {
+		return false
+	}
+	if _, exclude := machineScope.Machine.Annotations[clusterv1.ExcludeNodeDrainingAnnotation]; exclude {
+		return false
+	}
+	if !machineScope.Cluster.DeletionTimestamp.IsZero() {
+		return false
+	}
+	if nodeDrainTimeout(machineScope.ByoMachine) == 0 {
+		return false
+	}
+	return !conditions.IsTrue(machineScope.ByoMachine, infrav1.NodeDrainSucceeded) &&
+		conditions.GetReason(machineScope.ByoMachine, infrav1.NodeDrainSucceeded) != infrav1.NodeDrainTimeoutReason
+}
+
+func nodeDrainTimeout(byoMachine *infrav1.ByoMachine) time.Duration {
+	if drain := byoMachine.Spec.NodeDrain; drain != nil && drain.Timeout != nil {
+		return drain.Timeout.Duration
+	}
+	return DefaultNodeDrainTimeout
+}
+
+// podsToDrain returns the pods running on the node, but for the mirror pods of the static
+// pods and the DaemonSet pods, which are not rescheduled elsewhere
+func podsToDrain(ctx context.Context, clientSet kubernetes.Interface, nodeName string) ([]corev1.Pod, error) {
+	podList, err := clientSet.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	pods := []corev1.Pod{}
+	for i := range podList.Items {
+		pod := podList.Items[i]
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if _, mirror := pod.Annotations[corev1.MirrorPodAnnotationKey]; mirror {
+			continue
+		}
+		if owner := metav1.GetControllerOf(&pod); owner != nil && owner.Kind == "DaemonSet" {
+			continue
+		}
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/controllers/remote"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// mirroredNodeConditions are the conditions of the nodes mirrored into the status of their ByoMachine and ByoHost
+var mirroredNodeConditions = []corev1.NodeConditionType{
+	corev1.NodeReady,
+	corev1.NodeMemoryPressure,
+	corev1.NodeDiskPressure,
+	corev1.NodePIDPressure,
+}
+
+// watchNodes watches the nodes of the workload cluster, so that a change of their health or of their
+// kubelet version is mirrored as soon as it happens, rather than on the next resync
+func (r *ByoMachineReconciler) watchNodes(ctx context.Context, cluster *clusterv1.Cluster) error {
+	return r.Tracker.Watch(ctx, remote.WatchInput{
+		Name:         "byomachine-watchNodes",
+		Cluster:      util.ObjectKey(cluster),
+		Watcher:      r.controller,
+		Kind:         &corev1.Node{},
+		EventHandler: handler.EnqueueRequestsFromMapFunc(r.NodeToByoMachine(cluster)),
+		Predicates:   []predicate.Predicate{NodeStatusChanged()},
+	})
+}
+
+// syncNodeStatus mirrors the kubelet version and the key conditions of the node of the host into
+// the status of the ByoMachine and, when they changed, into the status of the ByoHost
+func (r *ByoMachineReconciler) syncNodeStatus(ctx context.Context, machineScope *byoMachineScope, node *corev1.Node) error {
+	status := nodeStatus(node)
+	machineScope.ByoMachine.Status.Node = status
+	if reflect.DeepEqual(machineScope.ByoHost.Status.Node, status) {
+		return nil
+	}
+
+	helper, err := patch.NewHelper(machineScope.ByoHost, r.Client)
+	if err != nil {
+		return err
+	}
+	machineScope.ByoHost.Status.Node = status.DeepCopy()
+	return helper.Patch(ctx, machineScope.ByoHost)
+}
+
+// nodeStatus returns the kubelet version and the mirrored conditions of the node
+func nodeStatus(node *corev1.Node) *infrav1.NodeStatus {
+	status := &infrav1.NodeStatus{
+		Name:           node.Name,
+		KubeletVersion: node.Status.NodeInfo.KubeletVersion,
+		Ready:          corev1.ConditionUnknown,
+	}
+	for _, conditionType := range mirroredNodeConditions {
+		for _, condition := range node.Status.Conditions {
+			if condition.Type != conditionType {
+				continue
+			}
+			status.Conditions = append(status.Conditions, infrav1.NodeCondition{
+				Type:               condition.Type,
+				Status:             condition.Status,
+				Reason:             condition.Reason,
+				Message:            condition.Message,
+				LastTransitionTime: condition.LastTransitionTime,
+			})
+			if condition.Type == corev1.NodeReady {
+				status.Ready = condition.Status
+			}
+		}
+	}
+	return status
+}
+
+// NodeStatusChanged returns a predicate that returns true for the nodes created, and for the updates
+// changing the mirrored status of the nodes, leaving out the heartbeats of the kubelets
+func NodeStatusChanged() predicate.Funcs {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldNode, ok := e.ObjectOld.(*corev1.Node)
+			if !ok {
+				return false
+			}
+			newNode := e.ObjectNew.(*corev1.Node)
+			return !reflect.DeepEqual(nodeStatus(oldNode), nodeStatus(newNode))
+		},
+		CreateFunc:  func(e event.CreateEvent) bool { return true },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+	}
+}
+
+// NodeToByoMachine is a handler.ToRequestsFunc to be used to enqueue the ByoMachine of a node of
+// the workload cluster, found by the provider ID of the node
+func (r *ByoMachineReconciler) NodeToByoMachine(cluster *clusterv1.Cluster) handler.MapFunc {
+	return func(o client.Object) []ctrl.Request {
+		node, ok := o.(*corev1.Node)
+		if !ok || node.Spec.ProviderID == "" {
+			return nil
+		}
+
+		byoMachineList := &infrav1.ByoMachineList{}
+		if err := r.Client.List(context.TODO(), byoMachineList, client.InNamespace(cluster.Namespace),
+			client.MatchingLabels{clusterv1.ClusterLabelName: cluster.Name}); err != nil {
+			log.Log.Error(err, "failed to list the ByoMachines of the node", "node", node.Name)
+			return nil
+		}
+		for i := range byoMachineList.Items {
+			if byoMachineList.Items[i].Spec.ProviderID == node.Spec.ProviderID {
+				return []ctrl.Request{{NamespacedName: client.ObjectKeyFromObject(&byoMachineList.Items[i])}}
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,504 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/controllers/remote"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
+	exputil "sigs.k8s.io/cluster-api/exp/util"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// ByoMachinePoolReconciler reconciles a ByoMachinePool object
+type ByoMachinePoolReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Tracker  *remote.ClusterCacheTracker
+	Recorder record.EventRecorder
+}
+
+// byoMachinePoolScope defines a scope defined around a ByoMachinePool and its attached ByoHosts
+type byoMachinePoolScope struct {
+	Cluster        *clusterv1.Cluster
+	MachinePool    *expv1.MachinePool
+	ByoCluster     *infrav1.ByoCluster
+	ByoMachinePool *infrav1.ByoMachinePool
+	ByoHosts       []infrav1.ByoHost
+}
+
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byomachinepools,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byomachinepools/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byomachinepools/finalizers,verbs=update
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinepools;machinepools/status,verbs=get;list;watch
+
+// Reconcile handles ByoMachinePool events
+func (r *ByoMachinePoolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	logger := log.FromContext(ctx)
+	logger.Info("Reconcile request received")
+
+	// Fetch the ByoMachinePool instance
+	byoMachinePool := &infrav1.ByoMachinePool{}
+	err := r.Client.Get(ctx, req.NamespacedName, byoMachinePool)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Fetch the MachinePool
+	machinePool, err := exputil.GetOwnerMachinePool(ctx, r.Client, byoMachinePool.ObjectMeta)
+	if err != nil {
+		logger.Error(err, "failed to get Owner MachinePool")
+		return ctrl.Result{}, err
+	}
+
+	if machinePool == nil {
+		logger.Info("Waiting for MachinePool Controller to set OwnerRef on ByoMachinePool")
+		return ctrl.Result{}, nil
+	}
+
+	// Fetch the Cluster
+	cluster, err := util.GetClusterFromMetadata(ctx, r.Client, machinePool.ObjectMeta)
+	if err != nil {
+		logger.Error(err, "ByoMachinePool owner MachinePool is missing cluster label or cluster does not exist")
+		return ctrl.Result{}, err
+	}
+
+	if cluster == nil {
+		logger.Info(fmt.Sprintf("Please associate this machine pool with a cluster using the label %s: <name of cluster>", clusterv1.ClusterLabelName))
+		return ctrl.Result{}, nil
+	}
+	logger = logger.WithValues("cluster", cluster.Name)
+
+	byoCluster := &infrav1.ByoCluster{}
+	infraClusterName := client.ObjectKey{
+		Namespace: byoMachinePool.Namespace,
+		Name:      cluster.Spec.InfrastructureRef.Name,
+	}
+
+	if err = r.Client.Get(ctx, infraClusterName, byoCluster); err != nil {
+		logger.Error(err, "failed to get infra cluster")
+		return ctrl.Result{}, nil
+	}
+
+	helper, _ := patch.NewHelper(byoMachinePool, r.Client)
+	defer func() {
+		if err = helper.Patch(ctx, byoMachinePool); err != nil && reterr == nil {
+			logger.Error(err, "failed to patch byomachinepool")
+			reterr = err
+		}
+	}()
+
+	// Fetch the BYOHosts which are referencing this machine pool
+	byoHosts, err := r.FetchAttachedByoHosts(ctx, byoMachinePool.Name, byoMachinePool.Namespace)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	poolScope := &byoMachinePoolScope{
+		Cluster:        cluster,
+		MachinePool:    machinePool,
+		ByoCluster:     byoCluster,
+		ByoMachinePool: byoMachinePool,
+		ByoHosts:       byoHosts,
+	}
+
+	// Return early if the object or Cluster is paused
+	if annotations.IsPaused(cluster, byoMachinePool) {
+		logger.Info("byoMachinePool or linked Cluster is marked as paused. Won't reconcile")
+		for i := range byoHosts {
+			if err = setPausedAnnotationForByoHost(ctx, r.Client, &byoHosts[i], true); err != nil {
+				logger.Error(err, "cannot set paused annotation for byohost", "byohost", byoHosts[i].Name)
+			}
+		}
+		conditions.MarkFalse(byoMachinePool, infrav1.ReplicasReady, infrav1.ClusterOrResourcePausedReason, clusterv1.ConditionSeverityInfo, "")
+		return ctrl.Result{}, nil
+	}
+
+	// Handle deleted machine pools
+	if !byoMachinePool.ObjectMeta.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, poolScope)
+	}
+
+	// Handle non-deleted machine pools
+	return r.reconcileNormal(ctx, poolScope)
+}
+
+// FetchAttachedByoHosts fetches the BYOHosts attached to this machine pool.
+// Hosts which are already released and waiting for cleanup are skipped.
+func (r *ByoMachinePoolReconciler) FetchAttachedByoHosts(ctx context.Context, byoMachinePoolName, byoMachinePoolNamespace string) ([]infrav1.ByoHost, error) {
+	selector := labels.NewSelector()
+	byohostLabels, _ := labels.NewRequirement(infrav1.AttachedByoMachinePoolLabel, selection.Equals, []string{byoMachinePoolNamespace + "." + byoMachinePoolName})
+	selector = selector.Add(*byohostLabels)
+	hostsList := &infrav1.ByoHostList{}
+	err := r.Client.List(
+		ctx,
+		hostsList,
+		&client.ListOptions{LabelSelector: selector},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	byoHosts := make([]infrav1.ByoHost, 0, len(hostsList.Items))
+	for i := range hostsList.Items {
+		if _, ok := hostsList.Items[i].Annotations[infrav1.HostCleanupAnnotation]; ok {
+			continue
+		}
+		byoHosts = append(byoHosts, hostsList.Items[i])
+	}
+	sort.Slice(byoHosts, func(i, j int) bool { return byoHosts[i].Name < byoHosts[j].Name })
+	return byoHosts, nil
+}
+
+func (r *ByoMachinePoolReconciler) reconcileDelete(ctx context.Context, poolScope *byoMachinePoolScope) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithValues("cluster", poolScope.Cluster.Name)
+	logger.Info("Deleting ByoMachinePool")
+
+	for i := range poolScope.ByoHosts {
+		if err := r.releaseByoHost(ctx, poolScope, &poolScope.ByoHosts[i]); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	controllerutil.RemoveFinalizer(poolScope.ByoMachinePool, infrav1.MachinePoolFinalizer)
+	return ctrl.Result{}, nil
+}
+
+func (r *ByoMachinePoolReconciler) reconcileNormal(ctx context.Context, poolScope *byoMachinePoolScope) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithValues("cluster", poolScope.Cluster.Name)
+	logger.Info("Reconciling ByoMachinePool")
+
+	controllerutil.AddFinalizer(poolScope.ByoMachinePool, infrav1.MachinePoolFinalizer)
+
+	for i := range poolScope.ByoHosts {
+		if err := setPausedAnnotationForByoHost(ctx, r.Client, &poolScope.ByoHosts[i], false); err != nil {
+			logger.Error(err, "Set resume flag for byohost failed", "byohost", poolScope.ByoHosts[i].Name)
+			return ctrl.Result{}, err
+		}
+	}
+
+	if !poolScope.Cluster.Status.InfrastructureReady {
+		logger.Info("Cluster infrastructure is not ready yet")
+		conditions.MarkFalse(poolScope.ByoMachinePool, infrav1.ReplicasReady, infrav1.WaitingForClusterInfrastructureReason, clusterv1.ConditionSeverityInfo, "")
+		return ctrl.Result{}, nil
+	}
+
+	if poolScope.MachinePool.Spec.Template.Spec.Bootstrap.DataSecretName == nil {
+		logger.Info("Bootstrap Data Secret not available yet")
+		conditions.MarkFalse(poolScope.ByoMachinePool, infrav1.ReplicasReady, infrav1.WaitingForBootstrapDataSecretReason, clusterv1.ConditionSeverityInfo, "")
+		return ctrl.Result{}, nil
+	}
+
+	desiredReplicas := 1
+	if poolScope.MachinePool.Spec.Replicas != nil {
+		desiredReplicas = int(*poolScope.MachinePool.Spec.Replicas)
+	}
+
+	// Scale down by releasing the hosts in excess
+	for len(poolScope.ByoHosts) > desiredReplicas {
+		host := &poolScope.ByoHosts[len(poolScope.ByoHosts)-1]
+		if err := r.releaseByoHost(ctx, poolScope, host); err != nil {
+			return ctrl.Result{}, err
+		}
+		poolScope.ByoHosts = poolScope.ByoHosts[:len(poolScope.ByoHosts)-1]
+	}
+
+	// Complete the attachment of the hosts claimed by a reconcile which failed before attaching them
+	for i := range poolScope.ByoHosts {
+		if host := &poolScope.ByoHosts[i]; host.Status.MachineRef == nil {
+			logger.Info("Completing the attachment of the claimed ByoHost", "byohost", host.Name)
+			if err := r.attachByoHost(ctx, poolScope, host); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	// Scale up by picking hosts from the host capacity pool
+	if len(poolScope.ByoHosts) < desiredReplicas {
+		invalid, err := checkBootstrapData(ctx, r.Client, poolScope.MachinePool.Namespace, *poolScope.MachinePool.Spec.Template.Spec.Bootstrap.DataSecretName)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if invalid != "" {
+			logger.Info("Bootstrap data cannot be run by the host agent", "reason", invalid)
+			r.Recorder.Event(poolScope.ByoMachinePool, corev1.EventTypeWarning, "BootstrapDataInvalid", invalid)
+			conditions.MarkFalse(poolScope.ByoMachinePool, infrav1.ReplicasReady, infrav1.BootstrapDataInvalidReason, clusterv1.ConditionSeverityError, invalid)
+			return ctrl.Result{}, nil
+		}
+		logger.Info("Attempting host reservation", "attached", len(poolScope.ByoHosts), "desired", desiredReplicas)
+		if err := r.attachByoHosts(ctx, poolScope, desiredReplicas-len(poolScope.ByoHosts)); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	logger.Info("Updating Nodes with ProviderID")
+	return r.updateNodeProviderIDs(ctx, poolScope, desiredReplicas)
+}
+
+func (r *ByoMachinePoolReconciler) updateNodeProviderIDs(ctx context.Context, poolScope *byoMachinePoolScope, desiredReplicas int) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithValues("cluster", poolScope.Cluster.Name)
+	remoteClient, err := r.Tracker.GetClient(ctx, util.ObjectKey(poolScope.Cluster))
+	if err != nil {
+		logger.Error(err, "failed to get remote client")
+		return ctrl.Result{}, err
+	}
+
+	providerIDList := make([]string, 0, len(poolScope.ByoHosts))
+	for i := range poolScope.ByoHosts {
+		providerID, err := setNodeProviderID(ctx, remoteClient, &poolScope.ByoHosts[i])
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				// the host is not yet bootstrapped as a node
+				continue
+			}
+			logger.Error(err, "failed to set node providerID", "byohost", poolScope.ByoHosts[i].Name)
+			r.Recorder.Eventf(poolScope.ByoMachinePool, corev1.EventTypeWarning, "SetNodeProviderFailed", "Setting providerID on Node %s failed: %v", poolScope.ByoHosts[i].Name, err)
+			return ctrl.Result{}, err
+		}
+		providerIDList = append(providerIDList, providerID)
+	}
+
+	poolScope.ByoMachinePool.Spec.ProviderIDList = providerIDList
+	poolScope.ByoMachinePool.Status.Replicas = int32(len(providerIDList))
+	poolScope.ByoMachinePool.Status.Ready = len(providerIDList) == desiredReplicas
+
+	switch {
+	case len(poolScope.ByoHosts) < desiredReplicas:
+		conditions.MarkFalse(poolScope.ByoMachinePool, infrav1.ReplicasReady, infrav1.BYOHostsUnavailableReason, clusterv1.ConditionSeverityInfo,
+			"%d of %d ByoHosts attached", len(poolScope.ByoHosts), desiredReplicas)
+	case len(providerIDList) < desiredReplicas:
+		conditions.MarkFalse(poolScope.ByoMachinePool, infrav1.ReplicasReady, infrav1.WaitingForNodesReason, clusterv1.ConditionSeverityInfo,
+			"%d of %d Nodes provisioned", len(providerIDList), desiredReplicas)
+	default:
+		conditions.MarkTrue(poolScope.ByoMachinePool, infrav1.ReplicasReady)
+		return ctrl.Result{}, nil
+	}
+
+	// Nodes in the workload cluster are not watched, so poll until the pool is ready
+	return ctrl.Result{RequeueAfter: RequeueForbyohost}, nil
+}
+
+func (r *ByoMachinePoolReconciler) attachByoHosts(ctx context.Context, poolScope *byoMachinePoolScope, count int) error {
+	logger := log.FromContext(ctx).WithValues("cluster", poolScope.Cluster.Name)
+	defer observeHostSelection(time.Now())
+	var selector labels.Selector
+	var err error
+
+	hostsList := &infrav1.ByoHostList{}
+	// LabelSelector filter for byohosts
+	if poolScope.ByoMachinePool.Spec.Selector != nil {
+		selector, err = metav1.LabelSelectorAsSelector(poolScope.ByoMachinePool.Spec.Selector)
+		if err != nil {
+			logger.Error(err, "Label Selector as selector failed")
+			return err
+		}
+	} else {
+		selector = labels.NewSelector()
+	}
+
+	byohostLabels, _ := labels.NewRequirement(clusterv1.ClusterLabelName, selection.DoesNotExist, nil)
+	unclaimed, _ := labels.NewRequirement(infrav1.ByoHostClaimLabel, selection.DoesNotExist, nil)
+	selector = selector.Add(*byohostLabels, *unclaimed)
+
+	err = r.Client.List(ctx, hostsList, &client.ListOptions{LabelSelector: selector})
+	if err != nil {
+		logger.Error(err, "failed to list byohosts")
+		return err
+	}
+	candidates, err := filterAccessibleByoHosts(ctx, r.Client, poolScope.ByoMachinePool.Namespace, hostsList.Items)
+	if err != nil {
+		logger.Error(err, "failed to check access to the byohosts")
+		return err
+	}
+	candidates = filterReservedByoHosts(candidates, poolScope.Cluster, nil, poolScope.ByoMachinePool.Namespace)
+	candidates = filterSchedulableByoHosts(candidates)
+	if len(candidates) < count {
+		logger.Info("Not enough hosts found, waiting..", "available", len(candidates), "required", count)
+		r.Recorder.Eventf(poolScope.ByoMachinePool, corev1.EventTypeWarning, "ByoHostSelectionFailed", "%d of %d required ByoHosts available", len(candidates), count)
+		count = len(candidates)
+	}
+
+	attachedTo := poolScope.ByoMachinePool.Namespace + "." + poolScope.ByoMachinePool.Name
+	for i := 0; i < len(candidates) && count > 0; i++ {
+		host := candidates[i]
+		claimed, err := claimByoHost(ctx, r.Client, &host, poolScope.Cluster.Name, infrav1.AttachedByoMachinePoolLabel, attachedTo)
+		if err != nil {
+			logger.Error(err, "failed to claim byohost", "byohost", host.Name)
+			return err
+		}
+		if !claimed {
+			continue
+		}
+		count--
+		if err = r.attachByoHost(ctx, poolScope, &host); err != nil {
+			logger.Error(err, "failed to patch byohost", "byohost", host.Name)
+			return err
+		}
+		logger.Info("Successfully attached Byohost", "byohost", host.Name)
+		hostAttachments.WithLabelValues(host.Namespace).Inc()
+		r.Recorder.Eventf(&host, corev1.EventTypeNormal, "ByoHostAttachSucceeded", "Attached to ByoMachinePool %s", poolScope.ByoMachinePool.Name)
+		r.Recorder.Eventf(poolScope.ByoMachinePool, corev1.EventTypeNormal, "ByoHostAttachSucceeded", "Attached ByoHost %s", host.Name)
+		poolScope.ByoHosts = append(poolScope.ByoHosts, host)
+	}
+	return nil
+}
+
+func (r *ByoMachinePoolReconciler) attachByoHost(ctx context.Context, poolScope *byoMachinePoolScope, host *infrav1.ByoHost) error {
+	byohostHelper, err := patch.NewHelper(host, r.Client)
+	if err != nil {
+		return err
+	}
+
+	host.Status.MachineRef = &corev1.ObjectReference{
+		APIVersion: infrav1.GroupVersion.String(),
+		Kind:       "ByoMachinePool",
+		Namespace:  poolScope.ByoMachinePool.Namespace,
+		Name:       poolScope.ByoMachinePool.Name,
+		UID:        poolScope.ByoMachinePool.UID,
+	}
+	// Set the cluster Label
+	if host.Labels == nil {
+		host.Labels = make(map[string]string)
+	}
+	host.Labels[clusterv1.ClusterLabelName] = poolScope.Cluster.Name
+	host.Labels[infrav1.AttachedByoMachinePoolLabel] = poolScope.ByoMachinePool.Namespace + "." + poolScope.ByoMachinePool.Name
+
+	host.Spec.BootstrapSecret = &corev1.ObjectReference{
+		Kind:      "Secret",
+		Namespace: poolScope.ByoMachinePool.Namespace,
+		Name:      *poolScope.MachinePool.Spec.Template.Spec.Bootstrap.DataSecretName,
+	}
+	if host.Annotations == nil {
+		host.Annotations = make(map[string]string)
+	}
+	host.Annotations[infrav1.EndPointIPAnnotation] = poolScope.Cluster.Spec.ControlPlaneEndpoint.Host
+	if poolScope.MachinePool.Spec.Template.Spec.Version != nil {
+		host.Annotations[infrav1.K8sVersionAnnotation] = strings.Split(*poolScope.MachinePool.Spec.Template.Spec.Version, "+")[0]
+	}
+	host.Annotations[infrav1.BundleLookupBaseRegistryAnnotation] = poolScope.ByoCluster.Spec.BundleLookupBaseRegistry
+	host.Annotations[infrav1.BundleLookupTagAnnotation] = poolScope.ByoCluster.Spec.BundleLookupTag
+	if families := clusterIPFamilies(poolScope.Cluster); families != "" {
+		host.Annotations[infrav1.IPFamiliesAnnotation] = families
+	}
+
+	return byohostHelper.Patch(ctx, host)
+}
+
+// releaseByoHost adds the cleanup annotation to the host, the host agent then
+// resets the node and returns the host to the capacity pool
+func (r *ByoMachinePoolReconciler) releaseByoHost(ctx context.Context, poolScope *byoMachinePoolScope, host *infrav1.ByoHost) error {
+	logger := log.FromContext(ctx).WithValues("cluster", poolScope.Cluster.Name)
+	logger.Info("Releasing ByoHost", "byohost", host.Name)
+
+	helper, err := patch.NewHelper(host, r.Client)
+	if err != nil {
+		return err
+	}
+	if host.Annotations == nil {
+		host.Annotations = map[string]string{}
+	}
+	host.Annotations[infrav1.HostCleanupAnnotation] = ""
+	if err = helper.Patch(ctx, host); err != nil {
+		return err
+	}
+	hostDetachments.WithLabelValues(host.Namespace).Inc()
+
+	r.Recorder.Eventf(host, corev1.EventTypeNormal, "ByoHostReleaseSucceeded", "ByoHost Released by %s", poolScope.ByoMachinePool.Name)
+	r.Recorder.Eventf(poolScope.ByoMachinePool, corev1.EventTypeNormal, "ByoHostReleaseSucceeded", "Released ByoHost %s", host.Name)
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ByoMachinePoolReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	var (
+		controlledType     = &infrav1.ByoMachinePool{}
+		controlledTypeName = reflect.TypeOf(controlledType).Elem().Name()
+		controlledTypeGVK  = infrav1.GroupVersion.WithKind(controlledTypeName)
+	)
+	logger := ctrl.LoggerFrom(ctx)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(controlledType).
+		WithOptions(options).
+		Watches(
+			&source.Kind{Type: &infrav1.ByoHost{}},
+			handler.EnqueueRequestsFromMapFunc(ByoHostToByoMachineMapFunc(controlledTypeGVK)),
+		).
+		// Watch the CAPI resource that owns this infrastructure resource
+		Watches(
+			&source.Kind{Type: &expv1.MachinePool{}},
+			handler.EnqueueRequestsFromMapFunc(exputil.MachinePoolToInfrastructureMapFunc(controlledTypeGVK, logger)),
+		).
+		Watches(
+			&source.Kind{Type: &clusterv1.Cluster{}},
+			handler.EnqueueRequestsFromMapFunc(r.ClusterToByoMachinePools(logger)),
+			builder.WithPredicates(predicates.Any(logger,
+				predicates.ClusterUnpausedAndInfrastructureReady(logger),
+				ClusterPausedChanged(logger))),
+		).
+		Complete(r)
+}
+
+// ClusterToByoMachinePools is a handler.ToRequestsFunc to be used to enqeue requests for reconciliation
+// of ByoMachinePools
+func (r *ByoMachinePoolReconciler) ClusterToByoMachinePools(logger logr.Logger) handler.MapFunc {
+	return func(o client.Object) []ctrl.Request {
+		c, ok := o.(*clusterv1.Cluster)
+		if !ok {
+			errMsg := fmt.Sprintf("Expected a Cluster but got a %T", o)
+			logger.Error(errors.New(errMsg), errMsg)
+			return nil
+		}
+
+		// Don't handle deleted clusters
+		if !c.ObjectMeta.DeletionTimestamp.IsZero() {
+			return nil
+		}
+
+		clusterLabels := map[string]string{clusterv1.ClusterLabelName: c.Name}
+		byoMachinePoolList := &infrav1.ByoMachinePoolList{}
+		if err := r.Client.List(context.TODO(), byoMachinePoolList, client.InNamespace(c.Namespace), client.MatchingLabels(clusterLabels)); err != nil {
+			logger.Error(err, "Failed to get ByoMachinePool, skipping mapping.")
+			return nil
+		}
+
+		result := make([]ctrl.Request, 0, len(byoMachinePoolList.Items))
+		for i := range byoMachinePoolList.Items {
+			result = append(result, ctrl.Request{NamespacedName: client.ObjectKey{Namespace: byoMachinePoolList.Items[i].Namespace, Name: byoMachinePoolList.Items[i].Name}})
+		}
+		return result
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	controllers "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/controllers/infrastructure"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/test/builder"
+	eventutils "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/test/utils/events"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("Controllers/ByoMachinePoolController", func() {
+	var (
+		ctx                     context.Context
+		k8sClientUncached       client.Client
+		byoMachinePoolReconcile *controllers.ByoMachinePoolReconciler
+		poolRecorder            *record.FakeRecorder
+		machinePool             *expv1.MachinePool
+		byoMachinePool          *infrastructurev1beta1.ByoMachinePool
+		byoMachinePoolLookupKey types.NamespacedName
+		byoHosts                []*infrastructurev1beta1.ByoHost
+		poolSelector            = map[string]string{"pool": "byomachinepool-test"}
+		testClusterVersion      = "v1.22.1_xyz"
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		var clientErr error
+
+		k8sClientUncached, clientErr = client.New(cfg, client.Options{Scheme: scheme.Scheme})
+		Expect(clientErr).NotTo(HaveOccurred())
+
+		poolRecorder = record.NewFakeRecorder(32)
+		byoMachinePoolReconcile = &controllers.ByoMachinePoolReconciler{
+			Client:   k8sClientUncached,
+			Tracker:  reconciler.Tracker,
+			Recorder: poolRecorder,
+		}
+
+		machinePool = builder.MachinePool(defaultNamespace, "my-machinepool").
+			WithClusterName(defaultClusterName).
+			WithClusterVersion(testClusterVersion).
+			WithBootstrapDataSecret(fakeBootstrapSecret).
+			WithReplicas(2).
+			Build()
+		Expect(k8sClientUncached.Create(ctx, machinePool)).Should(Succeed())
+
+		byoMachinePool = builder.ByoMachinePool(defaultNamespace, "my-byomachinepool").
+			WithClusterLabel(defaultClusterName).
+			WithOwnerMachinePool(machinePool).
+			WithLabelSelector(poolSelector).
+			Build()
+		Expect(k8sClientUncached.Create(ctx, byoMachinePool)).Should(Succeed())
+		byoMachinePoolLookupKey = types.NamespacedName{Name: byoMachinePool.Name, Namespace: byoMachinePool.Namespace}
+
+		byoHosts = nil
+		for i := 0; i < 2; i++ {
+			byoHost := builder.ByoHost(defaultNamespace, "pool-host").WithLabels(map[string]string{"pool": "byomachinepool-test"}).Build()
+			Expect(k8sClientUncached.Create(ctx, byoHost)).Should(Succeed())
+			Expect(clientFake.Create(ctx, builder.Node(defaultNamespace, byoHost.Name).Build())).Should(Succeed())
+			byoHosts = append(byoHosts, byoHost)
+		}
+
+		ph, err := patch.NewHelper(capiCluster, k8sClientUncached)
+		Expect(err).ShouldNot(HaveOccurred())
+		capiCluster.Status.InfrastructureReady = true
+		Expect(ph.Patch(ctx, capiCluster, patch.WithStatusObservedGeneration{})).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		eventutils.DrainEvents(poolRecorder.Events)
+		for _, byoHost := range byoHosts {
+			Expect(k8sClientUncached.Get(ctx, client.ObjectKeyFromObject(byoHost), byoHost)).Should(Succeed())
+			ph, err := patch.NewHelper(byoHost, k8sClientUncached)
+			Expect(err).ShouldNot(HaveOccurred())
+			byoHost.Status.MachineRef = nil
+			Expect(ph.Patch(ctx, byoHost)).Should(Succeed())
+			Expect(k8sClientUncached.Delete(ctx, byoHost)).Should(Succeed())
+		}
+	})
+
+	It("should ignore byomachinepool if it is not found", func() {
+		_, err := byoMachinePoolReconcile.Reconcile(ctx, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      "non-existent-byomachinepool",
+				Namespace: defaultNamespace}})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should attach a byohost for each replica and report the node provider ids", func() {
+		_, err := byoMachinePoolReconcile.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachinePoolLookupKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		createdByoMachinePool := &infrastructurev1beta1.ByoMachinePool{}
+		Expect(k8sClientUncached.Get(ctx, byoMachinePoolLookupKey, createdByoMachinePool)).Should(Succeed())
+		Expect(createdByoMachinePool.Spec.ProviderIDList).To(HaveLen(2))
+		Expect(createdByoMachinePool.Status.Replicas).To(Equal(int32(2)))
+		Expect(createdByoMachinePool.Status.Ready).To(BeTrue())
+		Expect(conditions.IsTrue(createdByoMachinePool, infrastructurev1beta1.ReplicasReady)).To(BeTrue())
+
+		for _, byoHost := range byoHosts {
+			attachedByoHost := &infrastructurev1beta1.ByoHost{}
+			Expect(k8sClientUncached.Get(ctx, client.ObjectKeyFromObject(byoHost), attachedByoHost)).Should(Succeed())
+			Expect(attachedByoHost.Status.MachineRef.Name).To(Equal(byoMachinePool.Name))
+			Expect(attachedByoHost.Labels).To(HaveKeyWithValue(clusterv1.ClusterLabelName, defaultClusterName))
+			Expect(attachedByoHost.Labels).To(HaveKeyWithValue(infrastructurev1beta1.AttachedByoMachinePoolLabel, byoMachinePool.Namespace+"."+byoMachinePool.Name))
+			Expect(attachedByoHost.Spec.BootstrapSecret.Name).To(Equal(fakeBootstrapSecret))
+			Expect(attachedByoHost.Annotations).To(HaveKeyWithValue(infrastructurev1beta1.K8sVersionAnnotation, testClusterVersion))
+		}
+	})
+
+	It("should release the byohosts in excess when the pool is scaled down", func() {
+		_, err := byoMachinePoolReconcile.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachinePoolLookupKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		ph, err := patch.NewHelper(machinePool, k8sClientUncached)
+		Expect(err).ShouldNot(HaveOccurred())
+		replicas := int32(1)
+		machinePool.Spec.Replicas = &replicas
+		Expect(ph.Patch(ctx, machinePool)).Should(Succeed())
+
+		_, err = byoMachinePoolReconcile.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachinePoolLookupKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		createdByoMachinePool := &infrastructurev1beta1.ByoMachinePool{}
+		Expect(k8sClientUncached.Get(ctx, byoMachinePoolLookupKey, createdByoMachinePool)).Should(Succeed())
+		Expect(createdByoMachinePool.Spec.ProviderIDList).To(HaveLen(1))
+		Expect(createdByoMachinePool.Status.Replicas).To(Equal(int32(1)))
+
+		releasedHosts := 0
+		for _, byoHost := range byoHosts {
+			updatedByoHost := &infrastructurev1beta1.ByoHost{}
+			Expect(k8sClientUncached.Get(ctx, client.ObjectKeyFromObject(byoHost), updatedByoHost)).Should(Succeed())
+			if _, ok := updatedByoHost.Annotations[infrastructurev1beta1.HostCleanupAnnotation]; ok {
+				releasedHosts++
+			}
+		}
+		Expect(releasedHosts).To(Equal(1))
+	})
+
+	It("should mark ReplicasReady as False when there are not enough byohosts", func() {
+		ph, err := patch.NewHelper(machinePool, k8sClientUncached)
+		Expect(err).ShouldNot(HaveOccurred())
+		replicas := int32(3)
+		machinePool.Spec.Replicas = &replicas
+		Expect(ph.Patch(ctx, machinePool)).Should(Succeed())
+
+		result, err := byoMachinePoolReconcile.Reconcile(ctx, reconcile.Request{NamespacedName: byoMachinePoolLookupKey})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(controllers.RequeueForbyohost))
+
+		createdByoMachinePool := &infrastructurev1beta1.ByoMachinePool{}
+		Expect(k8sClientUncached.Get(ctx, byoMachinePoolLookupKey, createdByoMachinePool)).Should(Succeed())
+		Expect(createdByoMachinePool.Status.Ready).To(BeFalse())
+		actualCondition := conditions.Get(createdByoMachinePool, infrastructurev1beta1.ReplicasReady)
+		Expect(actualCondition.Reason).To(Equal(infrastructurev1beta1.BYOHostsUnavailableReason))
+	})
+})
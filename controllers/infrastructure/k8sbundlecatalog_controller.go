@@ -0,0 +1,124 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/common/installer"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// DefaultCatalogRefreshInterval is how often the bundle repository of a K8sBundleCatalog is queried by default
+const DefaultCatalogRefreshInterval = time.Hour
+
+// K8sBundleCatalogReconciler periodically lists the bundles of the bundle repository of the K8sBundleCatalogs
+type K8sBundleCatalogReconciler struct {
+	client.Client
+
+	// ListTags lists the tags of the bundle repositories. The registry is queried when not set.
+	ListTags installer.TagLister
+}
+
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=k8sbundlecatalogs,verbs=get;list;watch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=k8sbundlecatalogs/status,verbs=get;update;patch
+
+// Reconcile refreshes the bundles of the K8sBundleCatalog when its refresh interval elapsed or its spec
+// changed, and requeues it for its next refresh. The bundles of the last successful refresh are kept
+// when the bundle repository cannot be queried.
+func (r *K8sBundleCatalogReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	logger := log.FromContext(ctx)
+
+	catalog := &infrav1.K8sBundleCatalog{}
+	if err := r.Client.Get(ctx, req.NamespacedName, catalog); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	refreshInterval := DefaultCatalogRefreshInterval
+	if catalog.Spec.RefreshInterval != nil {
+		refreshInterval = catalog.Spec.RefreshInterval.Duration
+	}
+	if catalog.Status.ObservedGeneration == catalog.Generation && catalog.Status.LastRefreshTime != nil {
+		if remaining := refreshInterval - time.Since(catalog.Status.LastRefreshTime.Time); remaining > 0 {
+			return ctrl.Result{RequeueAfter: remaining}, nil
+		}
+	}
+
+	helper, err := patch.NewHelper(catalog, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer func() {
+		if err := helper.Patch(ctx, catalog); err != nil && reterr == nil {
+			logger.Error(err, "failed to patch K8sBundleCatalog")
+			reterr = err
+		}
+	}()
+
+	listTags := r.ListTags
+	if listTags == nil {
+		listTags = installer.ListRegistryTags
+	}
+	logger.Info("Listing the bundles of the bundle repository", "bundleRepo", catalog.Spec.BundleRepo, "bundleType", catalog.Spec.BundleType)
+	bundles, err := installer.ListCatalog(ctx, catalog.Spec.BundleType, catalog.Spec.BundleRepo, listTags)
+	if err != nil {
+		logger.Error(err, "failed to list the bundles of the bundle repository")
+		conditions.MarkFalse(catalog, infrav1.CatalogRefreshedCondition, infrav1.CatalogRefreshFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return ctrl.Result{}, err
+	}
+
+	catalog.Status.Bundles = make([]infrav1.CatalogBundle, 0, len(bundles))
+	for _, bundle := range bundles {
+		catalog.Status.Bundles = append(catalog.Status.Bundles, infrav1.CatalogBundle{
+			K8sVersion: bundle.K8sVersion,
+			OS:         bundle.OS,
+			Arch:       bundle.Arch,
+			Image:      bundle.Image,
+		})
+	}
+	now := metav1.Now()
+	catalog.Status.LastRefreshTime = &now
+	catalog.Status.ObservedGeneration = catalog.Generation
+	conditions.MarkTrue(catalog, infrav1.CatalogRefreshedCondition)
+	return ctrl.Result{RequeueAfter: refreshInterval}, nil
+}
+
+// findBundleCatalog returns a refreshed K8sBundleCatalog of the namespace listing the bundle repository,
+// or nil when there is none
+func findBundleCatalog(ctx context.Context, c client.Client, namespace, bundleRepo, bundleType string) (*infrav1.K8sBundleCatalog, error) {
+	catalogs := &infrav1.K8sBundleCatalogList{}
+	if err := c.List(ctx, catalogs, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	for i := range catalogs.Items {
+		catalog := &catalogs.Items[i]
+		if catalog.Spec.BundleRepo == bundleRepo && catalog.Spec.BundleType == bundleType &&
+			catalog.Status.LastRefreshTime != nil && conditions.IsTrue(catalog, infrav1.CatalogRefreshedCondition) {
+			return catalog, nil
+		}
+	}
+	return nil, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *K8sBundleCatalogReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		// the status updates made by the controller itself are ignored, the refreshes are requeued
+		For(&infrav1.K8sBundleCatalog{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
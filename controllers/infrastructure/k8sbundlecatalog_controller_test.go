@@ -0,0 +1,127 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	controllers "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/controllers/infrastructure"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("Controllers/K8sBundleCatalogController", func() {
+
+	const bundleRepo = "registry.example.com/byoh-catalog"
+
+	var (
+		ctx                        context.Context
+		k8sClientUncached          client.Client
+		catalog                    *infrastructurev1beta1.K8sBundleCatalog
+		k8sBundleCatalogReconciler *controllers.K8sBundleCatalogReconciler
+		catalogLookupKey           types.NamespacedName
+		listedRepositories         []string
+		tags                       map[string][]string
+		listErr                    error
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		var clientErr error
+
+		k8sClientUncached, clientErr = client.New(cfg, client.Options{Scheme: scheme.Scheme})
+		Expect(clientErr).NotTo(HaveOccurred())
+
+		listedRepositories = nil
+		listErr = nil
+		tags = map[string][]string{
+			bundleRepo + "/byoh-bundle-ubuntu_20.04.1_x86-64_k8s": {"v1.22.3", "v1.19.1", "v1.21.2", "latest"},
+		}
+		k8sBundleCatalogReconciler = &controllers.K8sBundleCatalogReconciler{
+			Client: k8sClientUncached,
+			ListTags: func(_ context.Context, repository string) ([]string, error) {
+				listedRepositories = append(listedRepositories, repository)
+				return tags[repository], listErr
+			},
+		}
+
+		catalog = &infrastructurev1beta1.K8sBundleCatalog{
+			ObjectMeta: metav1.ObjectMeta{GenerateName: "k8sbundlecatalog-", Namespace: defaultNamespace},
+			Spec: infrastructurev1beta1.K8sBundleCatalogSpec{
+				BundleRepo:      bundleRepo,
+				BundleType:      "k8s",
+				RefreshInterval: &metav1.Duration{Duration: 10 * time.Minute},
+			},
+		}
+		Expect(k8sClientUncached.Create(ctx, catalog)).Should(Succeed())
+		catalogLookupKey = types.NamespacedName{Name: catalog.Name, Namespace: catalog.Namespace}
+	})
+
+	AfterEach(func() {
+		Expect(client.IgnoreNotFound(k8sClientUncached.Delete(ctx, catalog))).Should(Succeed())
+	})
+
+	It("should publish the bundles of the bundle repository the host agent installs", func() {
+		result, err := k8sBundleCatalogReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: catalogLookupKey})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(10 * time.Minute))
+		Expect(listedRepositories).To(ContainElements(
+			bundleRepo+"/byoh-bundle-ubuntu_20.04.1_x86-64_k8s",
+			bundleRepo+"/byoh-bundle-ubuntu_20.04.1_arm64_k8s"))
+
+		Expect(k8sClientUncached.Get(ctx, catalogLookupKey, catalog)).Should(Succeed())
+		Expect(catalog.Status.Bundles).To(Equal([]infrastructurev1beta1.CatalogBundle{
+			{K8sVersion: "v1.21.2", OS: "Ubuntu_20.04.1", Arch: "amd64", Image: bundleRepo + "/byoh-bundle-ubuntu_20.04.1_x86-64_k8s:v1.21.2"},
+			{K8sVersion: "v1.22.3", OS: "Ubuntu_20.04.1", Arch: "amd64", Image: bundleRepo + "/byoh-bundle-ubuntu_20.04.1_x86-64_k8s:v1.22.3"},
+		}))
+		Expect(catalog.Status.LastRefreshTime).NotTo(BeNil())
+		Expect(catalog.Status.ObservedGeneration).To(Equal(catalog.Generation))
+		Expect(conditions.IsTrue(catalog, infrastructurev1beta1.CatalogRefreshedCondition)).To(BeTrue())
+	})
+
+	It("should not query the bundle repository again before the refresh interval", func() {
+		_, err := k8sBundleCatalogReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: catalogLookupKey})
+		Expect(err).NotTo(HaveOccurred())
+		listedRepositories = nil
+
+		result, err := k8sBundleCatalogReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: catalogLookupKey})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeNumerically("~", 10*time.Minute, time.Minute))
+		Expect(listedRepositories).To(BeEmpty())
+	})
+
+	It("should keep the bundles when the bundle repository cannot be queried", func() {
+		_, err := k8sBundleCatalogReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: catalogLookupKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClientUncached.Get(ctx, catalogLookupKey, catalog)).Should(Succeed())
+		catalog.Spec.RefreshInterval = &metav1.Duration{Duration: time.Minute}
+		Expect(k8sClientUncached.Update(ctx, catalog)).Should(Succeed())
+		listErr = errors.New("registry unavailable")
+
+		_, err = k8sBundleCatalogReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: catalogLookupKey})
+		Expect(err).To(MatchError("registry unavailable"))
+
+		Expect(k8sClientUncached.Get(ctx, catalogLookupKey, catalog)).Should(Succeed())
+		Expect(catalog.Status.Bundles).To(HaveLen(2))
+		Expect(*conditions.Get(catalog, infrastructurev1beta1.CatalogRefreshedCondition)).To(conditions.MatchCondition(clusterv1.Condition{
+			Type:     infrastructurev1beta1.CatalogRefreshedCondition,
+			Status:   corev1.ConditionFalse,
+			Reason:   infrastructurev1beta1.CatalogRefreshFailedReason,
+			Severity: clusterv1.ConditionSeverityWarning,
+			Message:  "registry unavailable",
+		}))
+	})
+})
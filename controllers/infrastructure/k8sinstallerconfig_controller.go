@@ -6,6 +6,8 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
@@ -13,6 +15,7 @@ import (
 	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/common/installer"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -50,6 +53,8 @@ type k8sInstallerConfigScope struct {
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=k8sinstallerconfigs/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=k8sinstallerconfigs/finalizers,verbs=update
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byomachines,verbs=get;list;watch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byohosts,verbs=get;list;watch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=k8sbundlecatalogs,verbs=get;list;watch
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=byomachines/status,verbs=get
 // +kubebuilder:rbac:groups="",resources=secrets;events,verbs=get;list;watch;create;update;patch;delete
 
@@ -149,11 +154,43 @@ func (r *K8sInstallerConfigReconciler) reconcileNormal(ctx context.Context, scop
 
 	k8sVersion := scope.Config.GetAnnotations()[infrav1.K8sVersionAnnotation]
 	downloader := installer.DefaultBundleDownloader(scope.Config.Spec.BundleType, scope.Config.Spec.BundleRepo, "{{.BUNDLE_DOWNLOAD_PATH}}", logger)
-	installerObj, err := installer.NewInstaller(ctx, scope.ByoMachine.Status.HostInfo.OSImage, scope.ByoMachine.Status.HostInfo.Architecture, k8sVersion, downloader)
+	policy := scope.Config.Spec.HostConfiguration
+	hostConfig := installer.HostConfiguration{
+		DisableSwap:       policy.SwapDisabled(),
+		ConfigureSysctls:  policy.SysctlsConfigured(),
+		LoadKernelModules: policy.KernelModulesLoaded(),
+	}
+	hostInfo := scope.ByoMachine.Status.HostInfo
+	installerObj, err := installer.NewInstaller(ctx, hostInfo.OSImage, hostInfo.Architecture, k8sVersion, downloader, hostConfig, containerdConfig(scope.Config.Spec.Containerd), componentPolicies(scope.Config.Spec.Components), downloadRateLimit(scope.Config.Spec.DownloadRateLimit))
+	if err != nil {
+		logger.Error(err, "failed to create installer instance", "osImage", hostInfo.OSImage, "architecture", hostInfo.Architecture, "k8sVersion", k8sVersion)
+		markBundleUnsupported(scope, err, k8sVersion)
+		return ctrl.Result{}, err
+	}
+
+	// the bundle is looked up in the catalog of the bundle repository, when there is one, rather than failing to download on the host
+	catalog, err := findBundleCatalog(ctx, r.Client, scope.Config.Namespace, scope.Config.Spec.BundleRepo, scope.Config.Spec.BundleType)
+	if err != nil {
+		logger.Error(err, "failed to list the bundle catalogs")
+		return ctrl.Result{}, err
+	}
+	if bundleImage := installer.BundleImage(scope.Config.Spec.BundleType, scope.Config.Spec.BundleRepo, hostInfo.OSImage, hostInfo.Architecture, k8sVersion); catalog != nil && !catalog.HasBundle(bundleImage) {
+		conditions.MarkFalse(scope.Config, infrav1.InstallationSecretAvailableCondition, infrav1.BundleUnsupportedReason, clusterv1.ConditionSeverityError,
+			"the bundle %s is not published, according to the K8sBundleCatalog %s", bundleImage, catalog.Name)
+		return ctrl.Result{}, errors.Errorf("bundle %s not found in K8sBundleCatalog %s", bundleImage, catalog.Name)
+	}
+
+	kubeletArgs, err := kubeletCPUManagementArgs(scope.Config.Spec.CPUManagement, scope.ByoMachine.Status.HostInfo.CPUTopology)
 	if err != nil {
-		logger.Error(err, "failed to create installer instance", "osImage", scope.ByoMachine.Status.HostInfo.OSImage, "architecture", scope.ByoMachine.Status.HostInfo.Architecture, "k8sVersion", k8sVersion)
+		logger.Error(err, "failed to render the CPU management of the kubelet")
 		return ctrl.Result{}, err
 	}
+	scope.Config.Status.KubeletExtraArgs = kubeletArgs
+
+	// the installations of a site are staggered by the rollout policy
+	if res, held, err := r.holdForRollout(ctx, scope); err != nil || held {
+		return res, err
+	}
 
 	// creating installation secret
 	if err := r.storeInstallationData(ctx, scope, installerObj.Install(), installerObj.Uninstall()); err != nil {
@@ -163,6 +200,138 @@ func (r *K8sInstallerConfigReconciler) reconcileNormal(ctx context.Context, scop
 	return ctrl.Result{}, nil
 }
 
+// markBundleUnsupported marks why the bundle cannot install the host, so that the ByoMachine reports it
+// rather than the installation failing on the host
+func markBundleUnsupported(scope *k8sInstallerConfigScope, err error, k8sVersion string) {
+	hostInfo := scope.ByoMachine.Status.HostInfo
+	switch err {
+	case installer.ErrOsK8sNotSupported:
+		conditions.MarkFalse(scope.Config, infrav1.InstallationSecretAvailableCondition, infrav1.BundleUnsupportedReason, clusterv1.ConditionSeverityError,
+			"the %s bundle %s does not support %s on %s", scope.Config.Spec.BundleType, scope.Config.Spec.BundleRepo, hostInfo.OSImage, hostInfo.Architecture)
+	case installer.ErrK8sVersionNotSupported:
+		conditions.MarkFalse(scope.Config, infrav1.InstallationSecretAvailableCondition, infrav1.BundleUnsupportedReason, clusterv1.ConditionSeverityError,
+			"the %s bundle %s does not support Kubernetes %s on %s %s, supported versions are %s", scope.Config.Spec.BundleType, scope.Config.Spec.BundleRepo,
+			k8sVersion, hostInfo.OSImage, hostInfo.Architecture, strings.Join(installer.SupportedK8sVersions(hostInfo.OSImage, hostInfo.Architecture), ", "))
+	}
+}
+
+// containerdConfig returns the containerd configuration patch of the installation script
+func containerdConfig(patch *infrav1.ContainerdConfigPatch) installer.ContainerdConfig {
+	if patch == nil {
+		return installer.ContainerdConfig{}
+	}
+	config := installer.ContainerdConfig{
+		RegistryMirrors:    patch.RegistryMirrors,
+		InsecureRegistries: patch.InsecureRegistries,
+		SandboxImage:       patch.SandboxImage,
+	}
+	if patch.Proxy != nil {
+		config.HTTPProxy = patch.Proxy.HTTPProxy
+		config.HTTPSProxy = patch.Proxy.HTTPSProxy
+		config.NoProxy = patch.Proxy.NoProxy
+	}
+	return config
+}
+
+// componentPolicies returns how the installation script handles the components of the bundle
+func componentPolicies(policy *infrav1.ComponentsPolicy) installer.ComponentPolicies {
+	if policy == nil {
+		return installer.ComponentPolicies{}
+	}
+	componentPolicy := func(p *infrav1.ComponentPolicy) installer.ComponentPolicy {
+		if p == nil {
+			return installer.ComponentPolicy{}
+		}
+		return installer.ComponentPolicy{Install: string(p.InstallPolicy()), MinVersion: p.MinVersion}
+	}
+	return installer.ComponentPolicies{
+		Containerd: componentPolicy(policy.Containerd),
+		CRITools:   componentPolicy(policy.CRITools),
+		Kubernetes: componentPolicy(policy.Kubernetes),
+	}
+}
+
+// downloadRateLimit returns the bandwidth the installation script downloads the bundle with, the download
+// is not throttled when the rate limit is not set or not positive
+func downloadRateLimit(limit *resource.Quantity) installer.DownloadRateLimit {
+	if limit == nil || limit.Sign() <= 0 {
+		return 0
+	}
+	return installer.DownloadRateLimit(limit.Value())
+}
+
+// kubeletCPUManagementArgs renders the CPU management policy as kubelet flags. The CPUs reserved with the
+// static CPU manager policy are the first ones of each NUMA node of the host, a host without NUMA nodes
+// being a single node.
+func kubeletCPUManagementArgs(policy *infrav1.CPUManagementPolicy, topology *infrav1.CPUTopology) (string, error) {
+	if policy == nil {
+		return "", nil
+	}
+	cpuManagerPolicy := policy.CPUManagerPolicy
+	if cpuManagerPolicy == "" {
+		cpuManagerPolicy = "static"
+	}
+	args := []string{"--cpu-manager-policy=" + cpuManagerPolicy}
+
+	reservedPerNode := policy.ReservedCPUsPerNUMANode
+	if reservedPerNode == nil && cpuManagerPolicy == "static" {
+		reservedPerNode = pointer.Int32(1)
+	}
+	if reservedPerNode != nil {
+		if topology == nil || topology.CPUs == 0 {
+			return "", errors.New("the CPU topology of the host is unknown, it is reported by the host agent when it registers the host")
+		}
+		nodes := topology.NUMANodes
+		if len(nodes) == 0 {
+			nodes = []infrav1.NUMANode{{CPUs: fmt.Sprintf("0-%d", topology.CPUs-1)}}
+		}
+		reserved := []string{}
+		for _, node := range nodes {
+			cpus, err := parseCPUList(node.CPUs)
+			if err != nil {
+				return "", errors.Wrapf(err, "invalid CPUs of NUMA node %d", node.ID)
+			}
+			if len(cpus) <= int(*reservedPerNode) {
+				return "", errors.Errorf("NUMA node %d has %d CPUs, not enough to reserve %d of them", node.ID, len(cpus), *reservedPerNode)
+			}
+			for _, cpu := range cpus[:*reservedPerNode] {
+				reserved = append(reserved, strconv.Itoa(cpu))
+			}
+		}
+		args = append(args, "--reserved-cpus="+strings.Join(reserved, ","))
+	}
+
+	if policy.TopologyManagerPolicy != "" {
+		args = append(args, "--topology-manager-policy="+policy.TopologyManagerPolicy)
+	}
+	if policy.TopologyManagerScope != "" {
+		args = append(args, "--topology-manager-scope="+policy.TopologyManagerScope)
+	}
+	return strings.Join(args, " "), nil
+}
+
+// parseCPUList parses a list of CPUs in the format of the kernel, e.g. 0-15,32-47
+func parseCPUList(list string) ([]int, error) {
+	cpus := []int{}
+	for _, cpuRange := range strings.Split(list, ",") {
+		bounds := strings.SplitN(cpuRange, "-", 2)
+		first, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, err
+		}
+		last := first
+		if len(bounds) == 2 {
+			if last, err = strconv.Atoi(bounds[1]); err != nil {
+				return nil, err
+			}
+		}
+		for cpu := first; cpu <= last; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus, nil
+}
+
 // storeInstallationData creates a new secret with the install and unstall data passed in as input,
 // sets the reference in the configuration status and ready to true.
 func (r *K8sInstallerConfigReconciler) storeInstallationData(ctx context.Context, scope *k8sInstallerConfigScope, install, uninstall string) error {
@@ -210,6 +379,7 @@ func (r *K8sInstallerConfigReconciler) storeInstallationData(ctx context.Context
 		Name:      secret.Name,
 	}
 	scope.Config.Status.Ready = true
+	conditions.MarkTrue(scope.Config, infrav1.InstallationSecretAvailableCondition)
 	logger.Info("created installation secret")
 	return nil
 }
@@ -10,9 +10,11 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	controllers "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/controllers/infrastructure"
 	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/test/builder"
 	eventutils "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/test/utils/events"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -267,6 +269,105 @@ var _ = Describe("Controllers/K8sInstallerConfigController", func() {
 					Name:      k8sinstallerConfig.Name,
 					Namespace: k8sinstallerConfig.Namespace}})
 			Expect(err).Should(MatchError("No k8s support for OS"))
+
+			updatedConfig := &infrav1.K8sInstallerConfig{}
+			Expect(k8sClientUncached.Get(ctx, types.NamespacedName{Name: k8sinstallerConfig.Name, Namespace: k8sinstallerConfig.Namespace}, updatedConfig)).Should(Succeed())
+			Expect(*conditions.Get(updatedConfig, infrav1.InstallationSecretAvailableCondition)).Should(conditions.MatchCondition(clusterv1.Condition{
+				Type:     infrav1.InstallationSecretAvailableCondition,
+				Status:   corev1.ConditionFalse,
+				Reason:   infrav1.BundleUnsupportedReason,
+				Severity: clusterv1.ConditionSeverityError,
+				Message:  fmt.Sprintf("the %s bundle %s does not support %s on %s", updatedConfig.Spec.BundleType, updatedConfig.Spec.BundleRepo, byoMachine.Status.HostInfo.OSImage, unsupportedArch),
+			}))
+		})
+
+		It("should throw error if the kubernetes version is not supported", func() {
+			ph, err := patch.NewHelper(k8sinstallerConfig, k8sClientUncached)
+			Expect(err).ShouldNot(HaveOccurred())
+			unsupportedVersion := "v1.19.3"
+			k8sinstallerConfig.SetAnnotations(map[string]string{infrav1.K8sVersionAnnotation: unsupportedVersion})
+			Expect(ph.Patch(ctx, k8sinstallerConfig, patch.WithStatusObservedGeneration{})).Should(Succeed())
+			WaitForObjectToBeUpdatedInCache(k8sinstallerConfig, func(object client.Object) bool {
+				return object.GetAnnotations()[infrav1.K8sVersionAnnotation] == unsupportedVersion
+			})
+
+			_, err = k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      k8sinstallerConfig.Name,
+					Namespace: k8sinstallerConfig.Namespace}})
+			Expect(err).Should(MatchError("No bundle support for the k8s version"))
+
+			updatedConfig := &infrav1.K8sInstallerConfig{}
+			Expect(k8sClientUncached.Get(ctx, types.NamespacedName{Name: k8sinstallerConfig.Name, Namespace: k8sinstallerConfig.Namespace}, updatedConfig)).Should(Succeed())
+			Expect(updatedConfig.Status.Ready).To(BeFalse())
+			Expect(conditions.GetReason(updatedConfig, infrav1.InstallationSecretAvailableCondition)).To(Equal(infrav1.BundleUnsupportedReason))
+			Expect(conditions.GetMessage(updatedConfig, infrav1.InstallationSecretAvailableCondition)).To(ContainSubstring("does not support Kubernetes v1.19.3"))
+			Expect(conditions.GetMessage(updatedConfig, infrav1.InstallationSecretAvailableCondition)).To(ContainSubstring("supported versions are v1.21.*, v1.22.*, v1.23.*"))
+		})
+
+		Context("When a K8sBundleCatalog lists the bundle repository", func() {
+			var catalog *infrav1.K8sBundleCatalog
+
+			BeforeEach(func() {
+				catalog = &infrav1.K8sBundleCatalog{
+					ObjectMeta: metav1.ObjectMeta{GenerateName: "k8sbundlecatalog-", Namespace: defaultNamespace},
+					Spec:       infrav1.K8sBundleCatalogSpec{BundleRepo: testBundleRepo, BundleType: testBundleType},
+				}
+				Expect(k8sClientUncached.Create(ctx, catalog)).Should(Succeed())
+				ph, err := patch.NewHelper(catalog, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				now := metav1.Now()
+				catalog.Status.LastRefreshTime = &now
+				catalog.Status.Bundles = []infrav1.CatalogBundle{{
+					K8sVersion: "v1.22.3",
+					OS:         "Ubuntu_20.04.1",
+					Arch:       "amd64",
+					Image:      testBundleRepo + "/byoh-bundle-ubuntu_20.04.1_x86-64_k8s:v1.22.3",
+				}}
+				conditions.MarkTrue(catalog, infrav1.CatalogRefreshedCondition)
+				Expect(ph.Patch(ctx, catalog)).Should(Succeed())
+				WaitForObjectToBeUpdatedInCache(catalog, func(object client.Object) bool {
+					return object.(*infrav1.K8sBundleCatalog).Status.LastRefreshTime != nil
+				})
+			})
+
+			AfterEach(func() {
+				Expect(k8sClientUncached.Delete(ctx, catalog)).Should(Succeed())
+			})
+
+			setK8sVersion := func(version string) {
+				ph, err := patch.NewHelper(k8sinstallerConfig, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				k8sinstallerConfig.SetAnnotations(map[string]string{infrav1.K8sVersionAnnotation: version})
+				Expect(ph.Patch(ctx, k8sinstallerConfig)).Should(Succeed())
+				WaitForObjectToBeUpdatedInCache(k8sinstallerConfig, func(object client.Object) bool {
+					return object.GetAnnotations()[infrav1.K8sVersionAnnotation] == version
+				})
+			}
+
+			It("should create the installation secret of a published bundle", func() {
+				setK8sVersion("v1.22.3")
+
+				_, err := k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: k8sInstallerConfigLookupKey})
+				Expect(err).NotTo(HaveOccurred())
+
+				updatedConfig := &infrav1.K8sInstallerConfig{}
+				Expect(k8sClientUncached.Get(ctx, k8sInstallerConfigLookupKey, updatedConfig)).Should(Succeed())
+				Expect(updatedConfig.Status.Ready).To(BeTrue())
+				Expect(conditions.IsTrue(updatedConfig, infrav1.InstallationSecretAvailableCondition)).To(BeTrue())
+			})
+
+			It("should throw error if the bundle is not published", func() {
+				setK8sVersion("v1.22.7")
+
+				_, err := k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: k8sInstallerConfigLookupKey})
+				Expect(err).To(MatchError(fmt.Sprintf("bundle %s/byoh-bundle-ubuntu_20.04.1_x86-64_k8s:v1.22.7 not found in K8sBundleCatalog %s", testBundleRepo, catalog.Name)))
+
+				updatedConfig := &infrav1.K8sInstallerConfig{}
+				Expect(k8sClientUncached.Get(ctx, k8sInstallerConfigLookupKey, updatedConfig)).Should(Succeed())
+				Expect(updatedConfig.Status.Ready).To(BeFalse())
+				Expect(conditions.GetReason(updatedConfig, infrav1.InstallationSecretAvailableCondition)).To(Equal(infrav1.BundleUnsupportedReason))
+			})
 		})
 
 		It("should create secret of same name as of K8sInstallerConfig", func() {
@@ -297,6 +398,180 @@ var _ = Describe("Controllers/K8sInstallerConfigController", func() {
 			Expect(exists).To(BeTrue())
 		})
 
+		It("should configure the host in the installation script by default", func() {
+			_, err := k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      k8sinstallerConfig.Name,
+					Namespace: k8sinstallerConfig.Namespace}})
+			Expect(err).NotTo(HaveOccurred())
+
+			createdSecret := &corev1.Secret{}
+			Expect(k8sClientUncached.Get(ctx, installerSecretLookupKey, createdSecret)).To(Succeed())
+			Expect(string(createdSecret.Data["install"])).To(ContainSubstring("swapoff -a"))
+			Expect(string(createdSecret.Data["install"])).To(ContainSubstring("systemctl mask swap.target"))
+			Expect(string(createdSecret.Data["install"])).To(ContainSubstring("modprobe overlay && modprobe br_netfilter"))
+			Expect(string(createdSecret.Data["install"])).To(ContainSubstring("sysctl --system"))
+			Expect(string(createdSecret.Data["uninstall"])).To(ContainSubstring("systemctl unmask swap.target"))
+		})
+
+		It("should leave out the host configuration disabled by the HostConfigurationPolicy", func() {
+			ph, err := patch.NewHelper(k8sinstallerConfig, k8sClientUncached)
+			Expect(err).ShouldNot(HaveOccurred())
+			k8sinstallerConfig.Spec.HostConfiguration = &infrav1.HostConfigurationPolicy{
+				DisableSwap:       pointer.BoolPtr(false),
+				LoadKernelModules: pointer.BoolPtr(false),
+			}
+			Expect(ph.Patch(ctx, k8sinstallerConfig)).Should(Succeed())
+			WaitForObjectToBeUpdatedInCache(k8sinstallerConfig, func(object client.Object) bool {
+				return object.(*infrav1.K8sInstallerConfig).Spec.HostConfiguration != nil
+			})
+
+			_, err = k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      k8sinstallerConfig.Name,
+					Namespace: k8sinstallerConfig.Namespace}})
+			Expect(err).NotTo(HaveOccurred())
+
+			createdSecret := &corev1.Secret{}
+			Expect(k8sClientUncached.Get(ctx, installerSecretLookupKey, createdSecret)).To(Succeed())
+			Expect(string(createdSecret.Data["install"])).NotTo(ContainSubstring("swapoff"))
+			Expect(string(createdSecret.Data["install"])).NotTo(ContainSubstring("modprobe"))
+			Expect(string(createdSecret.Data["install"])).To(ContainSubstring("sysctl --system"))
+			Expect(string(createdSecret.Data["uninstall"])).NotTo(ContainSubstring("swapon"))
+			Expect(string(createdSecret.Data["uninstall"])).NotTo(ContainSubstring("modprobe"))
+		})
+
+		It("should patch the containerd configuration in the installation script", func() {
+			ph, err := patch.NewHelper(k8sinstallerConfig, k8sClientUncached)
+			Expect(err).ShouldNot(HaveOccurred())
+			k8sinstallerConfig.Spec.Containerd = &infrav1.ContainerdConfigPatch{
+				RegistryMirrors:    map[string][]string{"docker.io": {"https://mirror.example.com"}},
+				InsecureRegistries: []string{"registry.example.com:5000"},
+				SandboxImage:       "registry.example.com:5000/pause:3.5",
+				Proxy:              &infrav1.ContainerdProxy{HTTPSProxy: "http://proxy.example.com:3128"},
+			}
+			Expect(ph.Patch(ctx, k8sinstallerConfig)).Should(Succeed())
+			WaitForObjectToBeUpdatedInCache(k8sinstallerConfig, func(object client.Object) bool {
+				return object.(*infrav1.K8sInstallerConfig).Spec.Containerd != nil
+			})
+
+			_, err = k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      k8sinstallerConfig.Name,
+					Namespace: k8sinstallerConfig.Namespace}})
+			Expect(err).NotTo(HaveOccurred())
+
+			createdSecret := &corev1.Secret{}
+			Expect(k8sClientUncached.Get(ctx, installerSecretLookupKey, createdSecret)).To(Succeed())
+			install := string(createdSecret.Data["install"])
+			Expect(install).To(ContainSubstring(`sandbox_image = "registry.example.com:5000/pause:3.5"`))
+			Expect(install).To(ContainSubstring(`[plugins."io.containerd.grpc.v1.cri".registry.mirrors."docker.io"]
+  endpoint = ["https://mirror.example.com"]`))
+			Expect(install).To(ContainSubstring(`[plugins."io.containerd.grpc.v1.cri".registry.configs."registry.example.com:5000".tls]
+  insecure_skip_verify = true`))
+			Expect(install).To(ContainSubstring(`Environment="HTTPS_PROXY=http://proxy.example.com:3128"`))
+			Expect(install).To(ContainSubstring(`imports = ["/etc/containerd/conf.d/*.toml"]`))
+		})
+
+		It("should check the components preinstalled on the host in the installation script", func() {
+			ph, err := patch.NewHelper(k8sinstallerConfig, k8sClientUncached)
+			Expect(err).ShouldNot(HaveOccurred())
+			k8sinstallerConfig.Spec.Components = &infrav1.ComponentsPolicy{
+				Containerd: &infrav1.ComponentPolicy{Install: infrav1.ComponentInstallIfNotPresent, MinVersion: "1.6.0"},
+				Kubernetes: &infrav1.ComponentPolicy{Install: infrav1.ComponentInstallNever},
+			}
+			Expect(ph.Patch(ctx, k8sinstallerConfig)).Should(Succeed())
+			WaitForObjectToBeUpdatedInCache(k8sinstallerConfig, func(object client.Object) bool {
+				return object.(*infrav1.K8sInstallerConfig).Spec.Components != nil
+			})
+
+			_, err = k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      k8sinstallerConfig.Name,
+					Namespace: k8sinstallerConfig.Namespace}})
+			Expect(err).NotTo(HaveOccurred())
+
+			createdSecret := &corev1.Secret{}
+			Expect(k8sClientUncached.Get(ctx, installerSecretLookupKey, createdSecret)).To(Succeed())
+			install := string(createdSecret.Data["install"])
+			Expect(install).To(ContainSubstring("## checking the preinstalled containerd, installed IfNotPresent"))
+			Expect(install).To(ContainSubstring(`if ! version_at_least "$PRESENT_VERSION" '1.6.0'; then`))
+			Expect(install).To(ContainSubstring("kubernetes is not preinstalled, and its install policy is Never"))
+			Expect(install).NotTo(ContainSubstring("## checking the preinstalled cri-tools"))
+			Expect(string(createdSecret.Data["uninstall"])).To(ContainSubstring("if ! preinstalled containerd; then"))
+		})
+
+		It("should throttle the bundle download of the installation script", func() {
+			ph, err := patch.NewHelper(k8sinstallerConfig, k8sClientUncached)
+			Expect(err).ShouldNot(HaveOccurred())
+			rateLimit := resource.MustParse("512Ki")
+			k8sinstallerConfig.Spec.DownloadRateLimit = &rateLimit
+			Expect(ph.Patch(ctx, k8sinstallerConfig)).Should(Succeed())
+			WaitForObjectToBeUpdatedInCache(k8sinstallerConfig, func(object client.Object) bool {
+				return object.(*infrav1.K8sInstallerConfig).Spec.DownloadRateLimit != nil
+			})
+
+			_, err = k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      k8sinstallerConfig.Name,
+					Namespace: k8sinstallerConfig.Namespace}})
+			Expect(err).NotTo(HaveOccurred())
+
+			createdSecret := &corev1.Secret{}
+			Expect(k8sClientUncached.Get(ctx, installerSecretLookupKey, createdSecret)).To(Succeed())
+			install := string(createdSecret.Data["install"])
+			Expect(install).To(ContainSubstring(`WGET_OPTIONS="--limit-rate=512k"`))
+			Expect(install).To(ContainSubstring(`THROTTLE="trickle -s -d 512"`))
+			Expect(install).To(ContainSubstring("$THROTTLE imgpkg pull -r -i $BUNDLE_ADDR -o $BUNDLE_PATH"))
+		})
+
+		Context("When the K8sInstallerConfig has a CPU management policy", func() {
+			BeforeEach(func() {
+				ph, err := patch.NewHelper(k8sinstallerConfig, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				k8sinstallerConfig.Spec.CPUManagement = &infrav1.CPUManagementPolicy{
+					TopologyManagerPolicy:   "single-numa-node",
+					ReservedCPUsPerNUMANode: pointer.Int32(2),
+				}
+				Expect(ph.Patch(ctx, k8sinstallerConfig)).Should(Succeed())
+				WaitForObjectToBeUpdatedInCache(k8sinstallerConfig, func(object client.Object) bool {
+					return object.(*infrav1.K8sInstallerConfig).Spec.CPUManagement != nil
+				})
+			})
+
+			It("should render the kubelet flags from the CPU topology of the host", func() {
+				ph, err := patch.NewHelper(byoMachine, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				byoMachine.Status.HostInfo.CPUTopology = &infrav1.CPUTopology{
+					Sockets: 2, Cores: 4, CPUs: 8,
+					NUMANodes: []infrav1.NUMANode{{ID: 0, CPUs: "0-1,4-5"}, {ID: 1, CPUs: "2-3,6-7"}},
+				}
+				Expect(ph.Patch(ctx, byoMachine)).Should(Succeed())
+				WaitForObjectToBeUpdatedInCache(byoMachine, func(object client.Object) bool {
+					return object.(*infrav1.ByoMachine).Status.HostInfo.CPUTopology != nil
+				})
+
+				_, err = k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      k8sinstallerConfig.Name,
+						Namespace: k8sinstallerConfig.Namespace}})
+				Expect(err).NotTo(HaveOccurred())
+
+				updatedConfig := &infrav1.K8sInstallerConfig{}
+				Expect(k8sClientUncached.Get(ctx, k8sInstallerConfigLookupKey, updatedConfig)).To(Succeed())
+				Expect(updatedConfig.Status.KubeletExtraArgs).To(Equal(
+					"--cpu-manager-policy=static --reserved-cpus=0,1,2,3 --topology-manager-policy=single-numa-node"))
+			})
+
+			It("should fail without the CPU topology of the host", func() {
+				_, err := k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      k8sinstallerConfig.Name,
+						Namespace: k8sinstallerConfig.Namespace}})
+				Expect(err).To(MatchError(ContainSubstring("the CPU topology of the host is unknown")))
+			})
+		})
+
 		It("should be add secret reference to K8sInstallerConfig", func() {
 			_, err := k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{
 				NamespacedName: types.NamespacedName{
@@ -369,6 +644,107 @@ var _ = Describe("Controllers/K8sInstallerConfigController", func() {
 			Expect(updatedConfig.Status.Ready).To(BeTrue())
 		})
 
+		Context("When the K8sInstallerConfig has a rollout policy", func() {
+			var (
+				site             string
+				otherByoHost     *infrav1.ByoHost
+				otherByoMachine  *infrav1.ByoMachine
+				otherConfig      *infrav1.K8sInstallerConfig
+				byoHost          *infrav1.ByoHost
+				rolloutSiteLabel = "site"
+			)
+
+			BeforeEach(func() {
+				site = "store-" + util.RandomString(6)
+
+				byoHost = builder.ByoHost(defaultNamespace, "rollout-host-").
+					WithLabels(map[string]string{
+						infrav1.AttachedByoMachineLabel: byoMachine.Namespace + "." + byoMachine.Name,
+						rolloutSiteLabel:                site,
+					}).
+					Build()
+				Expect(k8sClientUncached.Create(ctx, byoHost)).Should(Succeed())
+
+				otherByoMachine = builder.ByoMachine(defaultNamespace, "rollout-byomachine-").
+					WithClusterLabel(defaultClusterName).
+					WithOwnerMachine(machine).
+					Build()
+				Expect(k8sClientUncached.Create(ctx, otherByoMachine)).Should(Succeed())
+
+				otherByoHost = builder.ByoHost(defaultNamespace, "rollout-host-").
+					WithLabels(map[string]string{
+						infrav1.AttachedByoMachineLabel: otherByoMachine.Namespace + "." + otherByoMachine.Name,
+						rolloutSiteLabel:                site,
+					}).
+					Build()
+				Expect(k8sClientUncached.Create(ctx, otherByoHost)).Should(Succeed())
+
+				otherConfig = builder.K8sInstallerConfig(defaultNamespace, "rollout-k8sinstallerconfig-").
+					WithClusterLabel(defaultClusterName).
+					WithOwnerByoMachine(otherByoMachine).
+					WithBundleRepo(testBundleRepo).
+					WithBundleType(testBundleType).
+					Build()
+				Expect(k8sClientUncached.Create(ctx, otherConfig)).Should(Succeed())
+				ph, err := patch.NewHelper(otherConfig, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				otherConfig.Status.Ready = true
+				Expect(ph.Patch(ctx, otherConfig)).Should(Succeed())
+
+				ph, err = patch.NewHelper(k8sinstallerConfig, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				k8sinstallerConfig.Spec.Rollout = &infrav1.RolloutPolicy{
+					MaxConcurrentInstalls: 1,
+					SiteLabel:             rolloutSiteLabel,
+				}
+				Expect(ph.Patch(ctx, k8sinstallerConfig)).Should(Succeed())
+
+				WaitForObjectsToBePopulatedInCache(byoHost, otherByoHost, otherByoMachine)
+				WaitForObjectToBeUpdatedInCache(otherConfig, func(object client.Object) bool {
+					return object.(*infrav1.K8sInstallerConfig).Status.Ready
+				})
+				WaitForObjectToBeUpdatedInCache(k8sinstallerConfig, func(object client.Object) bool {
+					return object.(*infrav1.K8sInstallerConfig).Spec.Rollout != nil
+				})
+			})
+
+			AfterEach(func() {
+				Expect(k8sClientUncached.Delete(ctx, otherConfig)).Should(Succeed())
+				Expect(k8sClientUncached.Delete(ctx, otherByoHost)).Should(Succeed())
+				Expect(k8sClientUncached.Delete(ctx, otherByoMachine)).Should(Succeed())
+				Expect(k8sClientUncached.Delete(ctx, byoHost)).Should(Succeed())
+			})
+
+			It("should hold the installation secret while the site is installing at the limit", func() {
+				result, err := k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: k8sInstallerConfigLookupKey})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RequeueAfter).To(Equal(controllers.RolloutRequeueInterval))
+
+				updatedConfig := &infrav1.K8sInstallerConfig{}
+				Expect(k8sClientUncached.Get(ctx, k8sInstallerConfigLookupKey, updatedConfig)).To(Succeed())
+				Expect(updatedConfig.Status.Ready).To(BeFalse())
+				Expect(conditions.GetReason(updatedConfig, infrav1.InstallationSecretAvailableCondition)).To(Equal(infrav1.WaitingForRolloutReason))
+				Expect(k8sClientUncached.Get(ctx, installerSecretLookupKey, &corev1.Secret{})).NotTo(Succeed())
+			})
+
+			It("should release the installation secret once the installing host is bootstrapped", func() {
+				ph, err := patch.NewHelper(otherByoHost, k8sClientUncached)
+				Expect(err).ShouldNot(HaveOccurred())
+				conditions.MarkTrue(otherByoHost, infrav1.K8sNodeBootstrapSucceeded)
+				Expect(ph.Patch(ctx, otherByoHost)).Should(Succeed())
+				WaitForObjectToBeUpdatedInCache(otherByoHost, func(object client.Object) bool {
+					return conditions.IsTrue(object.(*infrav1.ByoHost), infrav1.K8sNodeBootstrapSucceeded)
+				})
+
+				_, err = k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: k8sInstallerConfigLookupKey})
+				Expect(err).NotTo(HaveOccurred())
+
+				updatedConfig := &infrav1.K8sInstallerConfig{}
+				Expect(k8sClientUncached.Get(ctx, k8sInstallerConfigLookupKey, updatedConfig)).To(Succeed())
+				Expect(updatedConfig.Status.Ready).To(BeTrue())
+			})
+		})
+
 		Context("When K8sInstallerConfig is deleted", func() {
 			BeforeEach(func() {
 				_, err := k8sInstallerConfigReconciler.Reconcile(ctx, reconcile.Request{
@@ -0,0 +1,101 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RolloutRequeueInterval is how often the installation secret held by the rollout policy is reconsidered
+const RolloutRequeueInterval = 30 * time.Second
+
+// holdForRollout tells if the installation secret of the K8sInstallerConfig is held, as the hosts of the
+// site of its host installing at once reached the MaxConcurrentInstalls of its rollout policy
+func (r *K8sInstallerConfigReconciler) holdForRollout(ctx context.Context, scope *k8sInstallerConfigScope) (ctrl.Result, bool, error) {
+	policy := scope.Config.Spec.Rollout
+	if policy == nil {
+		return ctrl.Result{}, false, nil
+	}
+
+	hosts, err := attachedByoHosts(ctx, r.Client)
+	if err != nil {
+		return ctrl.Result{}, false, err
+	}
+	host, ok := hosts[attachment(scope.ByoMachine.Namespace, scope.ByoMachine.Name)]
+	if !ok {
+		// the site of a host which is not attached yet is unknown
+		return ctrl.Result{}, false, nil
+	}
+	site := host.Labels[policy.SiteLabel]
+
+	configs := &infrav1.K8sInstallerConfigList{}
+	if err := r.Client.List(ctx, configs, client.InNamespace(scope.Config.Namespace)); err != nil {
+		return ctrl.Result{}, false, err
+	}
+	installing := int32(0)
+	for i := range configs.Items {
+		config := &configs.Items[i]
+		if config.Name == scope.Config.Name || !config.Status.Ready || !config.DeletionTimestamp.IsZero() {
+			continue
+		}
+		byoMachine := ownerByoMachineName(config)
+		if byoMachine == "" {
+			continue
+		}
+		other, ok := hosts[attachment(config.Namespace, byoMachine)]
+		if !ok || other.Labels[policy.SiteLabel] != site || conditions.IsTrue(other, infrav1.K8sNodeBootstrapSucceeded) {
+			continue
+		}
+		installing++
+	}
+	if installing < policy.MaxConcurrentInstalls {
+		return ctrl.Result{}, false, nil
+	}
+
+	scope.Logger.Info("Holding the installation secret for the rollout", "site", site, "installing", installing, "maxConcurrentInstalls", policy.MaxConcurrentInstalls)
+	conditions.MarkFalse(scope.Config, infrav1.InstallationSecretAvailableCondition, infrav1.WaitingForRolloutReason, clusterv1.ConditionSeverityInfo,
+		"%d hosts of the site %q are installing, the rollout allows %d at once", installing, site, policy.MaxConcurrentInstalls)
+	return ctrl.Result{RequeueAfter: RolloutRequeueInterval}, true, nil
+}
+
+// attachedByoHosts returns the ByoHosts attached to a ByoMachine, by attachment
+func attachedByoHosts(ctx context.Context, c client.Client) (map[string]*infrav1.ByoHost, error) {
+	attached, err := labels.NewRequirement(infrav1.AttachedByoMachineLabel, selection.Exists, nil)
+	if err != nil {
+		return nil, err
+	}
+	hostsList := &infrav1.ByoHostList{}
+	if err := c.List(ctx, hostsList, &client.ListOptions{LabelSelector: labels.NewSelector().Add(*attached)}); err != nil {
+		return nil, err
+	}
+	hosts := make(map[string]*infrav1.ByoHost, len(hostsList.Items))
+	for i := range hostsList.Items {
+		hosts[hostsList.Items[i].Labels[infrav1.AttachedByoMachineLabel]] = &hostsList.Items[i]
+	}
+	return hosts, nil
+}
+
+// attachment returns the value of the AttachedByoMachineLabel of the host attached to the ByoMachine
+func attachment(namespace, byoMachine string) string {
+	return namespace + "." + byoMachine
+}
+
+// ownerByoMachineName returns the name of the ByoMachine owning the K8sInstallerConfig, if any
+func ownerByoMachineName(config *infrav1.K8sInstallerConfig) string {
+	for _, ref := range config.OwnerReferences {
+		if ref.Kind == "ByoMachine" {
+			return ref.Name
+		}
+	}
+	return ""
+}
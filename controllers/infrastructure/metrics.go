@@ -0,0 +1,167 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+)
+
+const metricsNamespace = "byoh_controller"
+
+var (
+	// hostSelectionDuration is the time taken to select and attach a ByoHost
+	hostSelectionDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "host_selection_duration_seconds",
+		Help:      "Duration of the selection and attachment of a ByoHost in seconds",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	})
+
+	// hostAttachments counts the ByoHosts attached to a machine, by namespace of the ByoHost
+	hostAttachments = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "host_attachments_total",
+		Help:      "Total number of ByoHosts attached to a machine",
+	}, []string{"namespace"})
+
+	// hostDetachments counts the ByoHosts released by a machine, by namespace of the ByoHost
+	hostDetachments = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "host_detachments_total",
+		Help:      "Total number of ByoHosts released by a machine",
+	}, []string{"namespace"})
+
+	// machineBootstrapDuration is the time from the creation of a ByoMachine to its node being provisioned
+	machineBootstrapDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "machine_bootstrap_duration_seconds",
+		Help:      "Duration from the creation of a ByoMachine to its node being provisioned in seconds",
+		Buckets:   []float64{30, 60, 120, 300, 600, 900, 1200, 1800, 3600},
+	}, []string{"namespace"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		hostSelectionDuration,
+		hostAttachments,
+		hostDetachments,
+		machineBootstrapDuration,
+	)
+}
+
+// observeHostSelection records the duration of a host selection started at start
+func observeHostSelection(start time.Time) {
+	hostSelectionDuration.Observe(time.Since(start).Seconds())
+}
+
+var (
+	byoHostsDesc = prometheus.NewDesc(metricsNamespace+"_byohosts",
+		"Number of ByoHosts", []string{"namespace", "pool"}, nil)
+	byoHostsAttachedDesc = prometheus.NewDesc(metricsNamespace+"_byohosts_attached",
+		"Number of ByoHosts attached to a machine", []string{"namespace", "pool"}, nil)
+	byoHostsAvailableDesc = prometheus.NewDesc(metricsNamespace+"_byohosts_available",
+		"Number of ByoHosts a machine can be attached to", []string{"namespace", "pool"}, nil)
+	byoHostsUnhealthyDesc = prometheus.NewDesc(metricsNamespace+"_byohosts_unhealthy",
+		"Number of ByoHosts with a failed condition", []string{"namespace", "pool"}, nil)
+	byoHostsExpectedDesc = prometheus.NewDesc(metricsNamespace+"_byohosts_expected",
+		"Number of ByoHosts expected from the host inventory and not registered yet", []string{"namespace", "pool"}, nil)
+)
+
+// ByoHostPoolCollector reports the utilization of the host pools: the number of ByoHosts, and
+// of the attached, available, unhealthy and expected ones, by namespace and value of the PoolLabel
+type ByoHostPoolCollector struct {
+	Reader client.Reader
+
+	// PoolLabel is the label of the ByoHosts they are grouped by, e.g. their site. The hosts
+	// are only grouped by namespace when it is empty.
+	PoolLabel string
+}
+
+type hostPoolKey struct {
+	namespace string
+	pool      string
+}
+
+type hostPoolCounts struct {
+	total     int
+	attached  int
+	available int
+	unhealthy int
+	expected  int
+}
+
+// Describe implements prometheus.Collector
+func (c *ByoHostPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- byoHostsDesc
+	ch <- byoHostsAttachedDesc
+	ch <- byoHostsAvailableDesc
+	ch <- byoHostsUnhealthyDesc
+	ch <- byoHostsExpectedDesc
+}
+
+// Collect implements prometheus.Collector, counting the ByoHosts listed from the Reader
+func (c *ByoHostPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	hosts := &infrav1.ByoHostList{}
+	if err := c.Reader.List(context.Background(), hosts); err != nil {
+		ctrl.Log.WithName("metrics").Error(err, "failed to list byohosts")
+		return
+	}
+
+	pools := map[hostPoolKey]*hostPoolCounts{}
+	for i := range hosts.Items {
+		host := &hosts.Items[i]
+		key := hostPoolKey{namespace: host.Namespace}
+		if c.PoolLabel != "" {
+			key.pool = host.Labels[c.PoolLabel]
+		}
+		counts, ok := pools[key]
+		if !ok {
+			counts = &hostPoolCounts{}
+			pools[key] = counts
+		}
+
+		counts.total++
+		unhealthy := isUnhealthy(host)
+		if unhealthy {
+			counts.unhealthy++
+		}
+		if isExpected(host) {
+			counts.expected++
+		}
+		switch {
+		case host.Status.MachineRef != nil:
+			counts.attached++
+		case !unhealthy && !isUnschedulable(host):
+			counts.available++
+		}
+	}
+
+	for key, counts := range pools {
+		ch <- prometheus.MustNewConstMetric(byoHostsDesc, prometheus.GaugeValue, float64(counts.total), key.namespace, key.pool)
+		ch <- prometheus.MustNewConstMetric(byoHostsAttachedDesc, prometheus.GaugeValue, float64(counts.attached), key.namespace, key.pool)
+		ch <- prometheus.MustNewConstMetric(byoHostsAvailableDesc, prometheus.GaugeValue, float64(counts.available), key.namespace, key.pool)
+		ch <- prometheus.MustNewConstMetric(byoHostsUnhealthyDesc, prometheus.GaugeValue, float64(counts.unhealthy), key.namespace, key.pool)
+		ch <- prometheus.MustNewConstMetric(byoHostsExpectedDesc, prometheus.GaugeValue, float64(counts.expected), key.namespace, key.pool)
+	}
+}
+
+// isUnhealthy tells if one of the conditions of the host is false with the error severity
+func isUnhealthy(host *infrav1.ByoHost) bool {
+	for _, condition := range host.Status.Conditions {
+		if condition.Status == corev1.ConditionFalse && condition.Severity == clusterv1.ConditionSeverityError {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	controllers "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/controllers/infrastructure"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/test/builder"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Controllers/Metrics", func() {
+	siteHost := func(name, site string) *infrastructurev1beta1.ByoHost {
+		host := builder.ByoHost(defaultNamespace, name).WithLabels(map[string]string{"site": site}).Build()
+		// the fake client does not generate the names
+		host.Name = name
+		return host
+	}
+
+	It("should report the ByoHosts of each host pool", func() {
+		attachedHost := siteHost("attached-host", "edge-1")
+		attachedHost.Status.MachineRef = &corev1.ObjectReference{Kind: "ByoMachine", Namespace: defaultNamespace, Name: "byomachine"}
+		availableHost := siteHost("available-host", "edge-1")
+		unhealthyHost := siteHost("unhealthy-host", "edge-1")
+		conditions.MarkFalse(unhealthyHost, infrastructurev1beta1.HostPreflightSucceeded, infrastructurev1beta1.SwapEnabledReason, clusterv1.ConditionSeverityError, "")
+		otherSiteHost := siteHost("other-site-host", "edge-2")
+		expectedHost := siteHost("expected-host", "edge-2")
+		expectedHost.Labels[infrastructurev1beta1.ExpectedHostLabel] = ""
+
+		collector := &controllers.ByoHostPoolCollector{
+			Reader:    fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(attachedHost, availableHost, unhealthyHost, otherSiteHost, expectedHost).Build(),
+			PoolLabel: "site",
+		}
+
+		expected := `
+# HELP byoh_controller_byohosts Number of ByoHosts
+# TYPE byoh_controller_byohosts gauge
+byoh_controller_byohosts{namespace="default",pool="edge-1"} 3
+byoh_controller_byohosts{namespace="default",pool="edge-2"} 2
+# HELP byoh_controller_byohosts_attached Number of ByoHosts attached to a machine
+# TYPE byoh_controller_byohosts_attached gauge
+byoh_controller_byohosts_attached{namespace="default",pool="edge-1"} 1
+byoh_controller_byohosts_attached{namespace="default",pool="edge-2"} 0
+# HELP byoh_controller_byohosts_available Number of ByoHosts a machine can be attached to
+# TYPE byoh_controller_byohosts_available gauge
+byoh_controller_byohosts_available{namespace="default",pool="edge-1"} 1
+byoh_controller_byohosts_available{namespace="default",pool="edge-2"} 1
+# HELP byoh_controller_byohosts_unhealthy Number of ByoHosts with a failed condition
+# TYPE byoh_controller_byohosts_unhealthy gauge
+byoh_controller_byohosts_unhealthy{namespace="default",pool="edge-1"} 1
+byoh_controller_byohosts_unhealthy{namespace="default",pool="edge-2"} 0
+# HELP byoh_controller_byohosts_expected Number of ByoHosts expected from the host inventory and not registered yet
+# TYPE byoh_controller_byohosts_expected gauge
+byoh_controller_byohosts_expected{namespace="default",pool="edge-1"} 0
+byoh_controller_byohosts_expected{namespace="default",pool="edge-2"} 1
+`
+		Expect(testutil.CollectAndCompare(collector, strings.NewReader(expected))).To(Succeed())
+	})
+})
@@ -17,6 +17,7 @@ import (
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	"sigs.k8s.io/controller-runtime/pkg/envtest/printer"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -28,10 +29,12 @@ import (
 
 	//+kubebuilder:scaffold:imports
 
+	"k8s.io/client-go/kubernetes"
 	fakeclientset "k8s.io/client-go/kubernetes/fake"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1beta1"
 	"sigs.k8s.io/cluster-api/controllers/remote"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
@@ -42,10 +45,12 @@ var (
 	testEnv                               *envtest.Environment
 	clientFake                            client.Client
 	clientSetFake                         = fakeclientset.NewSimpleClientset()
+	workloadClientSetFake                 = fakeclientset.NewSimpleClientset()
 	reconciler                            *controllers.ByoMachineReconciler
 	byoClusterReconciler                  *controllers.ByoClusterReconciler
 	byoAdmissionReconciler                *controllers.ByoAdmissionReconciler
 	k8sInstallerConfigReconciler          *controllers.K8sInstallerConfigReconciler
+	bootstrapKubeconfigReconciler         *controllers.BootstrapKubeconfigReconciler
 	recorder                              *record.FakeRecorder
 	byoCluster                            *infrastructurev1beta1.ByoCluster
 	capiCluster                           *clusterv1.Cluster
@@ -100,6 +105,9 @@ var _ = BeforeSuite(func() {
 	err = bootstrapv1.AddToScheme(scheme.Scheme)
 	Expect(err).NotTo(HaveOccurred())
 
+	err = expv1.AddToScheme(scheme.Scheme)
+	Expect(err).NotTo(HaveOccurred())
+
 	//+kubebuilder:scaffold:scheme
 
 	k8sManager, err = ctrl.NewManager(cfg, ctrl.Options{
@@ -117,6 +125,9 @@ var _ = BeforeSuite(func() {
 	capiCluster = builder.Cluster(defaultNamespace, defaultClusterName).WithInfrastructureRef(byoCluster).Build()
 	Expect(k8sManager.GetClient().Create(context.Background(), capiCluster)).Should(Succeed())
 
+	bootstrapSecret := builder.Secret(defaultNamespace, fakeBootstrapSecret).WithData("runcmd:\n- echo test\n").Build()
+	Expect(k8sManager.GetClient().Create(context.Background(), bootstrapSecret)).Should(Succeed())
+
 	node := builder.Node(defaultNamespace, defaultNodeName).Build()
 	clientFake = fake.NewClientBuilder().WithObjects(
 		capiCluster,
@@ -126,10 +137,13 @@ var _ = BeforeSuite(func() {
 	recorder = record.NewFakeRecorder(32)
 	reconciler = &controllers.ByoMachineReconciler{
 		Client:   k8sManager.GetClient(),
-		Tracker:  remote.NewTestClusterCacheTracker(logr.New(logf.NullLogSink{}), clientFake, scheme.Scheme, client.ObjectKey{Name: capiCluster.Name, Namespace: capiCluster.Namespace}),
+		Tracker:  remote.NewTestClusterCacheTracker(logr.New(logf.NullLogSink{}), clientFake, scheme.Scheme, client.ObjectKey{Name: capiCluster.Name, Namespace: capiCluster.Namespace}, "byomachine-watchNodes"),
 		Recorder: recorder,
+		WorkloadClientSet: func(context.Context, client.ObjectKey) (kubernetes.Interface, error) {
+			return workloadClientSetFake, nil
+		},
 	}
-	err = reconciler.SetupWithManager(context.TODO(), k8sManager)
+	err = reconciler.SetupWithManager(context.TODO(), k8sManager, controller.Options{})
 	Expect(err).NotTo(HaveOccurred())
 
 	byoClusterReconciler = &controllers.ByoClusterReconciler{
@@ -139,6 +153,7 @@ var _ = BeforeSuite(func() {
 	Expect(err).NotTo(HaveOccurred())
 
 	byoAdmissionReconciler = &controllers.ByoAdmissionReconciler{
+		Client:    k8sManager.GetClient(),
 		ClientSet: clientSetFake,
 	}
 	err = byoAdmissionReconciler.SetupWithManager(k8sManager)
@@ -150,6 +165,12 @@ var _ = BeforeSuite(func() {
 	err = k8sInstallerConfigReconciler.SetupWithManager(k8sManager)
 	Expect(err).NotTo(HaveOccurred())
 
+	bootstrapKubeconfigReconciler = &controllers.BootstrapKubeconfigReconciler{
+		Client: k8sManager.GetClient(),
+	}
+	err = bootstrapKubeconfigReconciler.SetupWithManager(k8sManager)
+	Expect(err).NotTo(HaveOccurred())
+
 	go func() {
 		err = k8sManager.GetCache().Start(ctx)
 		Expect(err).NotTo(HaveOccurred())
@@ -10,6 +10,14 @@ import (
 
 const (
 	SecureAccess featuregate.Feature = "SecureAccess"
+
+	// AgentAutoUpgrade lets the host agent replace itself with the version
+	// requested by the management cluster.
+	AgentAutoUpgrade featuregate.Feature = "AgentAutoUpgrade"
+
+	// MachinePool enables the ByoMachinePool controller, backing CAPI MachinePools
+	// with ByoHosts from the capacity pool.
+	MachinePool featuregate.Feature = "MachinePool"
 )
 
 var (
@@ -24,5 +32,7 @@ func init() {
 // defaultClusterAPIBYOHFeatureGates consists of all known cluster-api-byoh feature keys.
 // To add a new feature, define a key for it above and add it here.
 var defaultClusterAPIBYOHFeatureGates = map[featuregate.Feature]featuregate.FeatureSpec{
-	SecureAccess: {Default: false, PreRelease: featuregate.Alpha},
+	SecureAccess:     {Default: false, PreRelease: featuregate.Alpha},
+	AgentAutoUpgrade: {Default: false, PreRelease: featuregate.Alpha},
+	MachinePool:      {Default: false, PreRelease: featuregate.Alpha},
 }
@@ -7,7 +7,11 @@ import (
 	"context"
 	"flag"
 	"os"
+	"regexp"
+	"time"
 
+	pflag "github.com/spf13/pflag"
+	"golang.org/x/time/rate"
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
@@ -15,21 +19,28 @@ import (
 	"k8s.io/klog/v2/klogr"
 
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	certv1 "k8s.io/api/certificates/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientset "k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	byohcontrollers "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/controllers/infrastructure"
 
 	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	infrastructurev1beta2 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta2"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/common/tracing"
+	"github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/feature"
 
 	//+kubebuilder:scaffold:imports
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/controllers/remote"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 )
 
@@ -39,6 +50,26 @@ var (
 	metricsAddr          string
 	enableLeaderElection bool
 	probeAddr            string
+	agentVersion         string
+	agentBinaryRepo      string
+	staleHostTimeout     time.Duration
+	hostPoolLabel        string
+	tracingEndpoint      string
+	tracingInsecure      bool
+
+	byoMachineConcurrency int
+	byoHostConcurrency    int
+	rateLimiterBaseDelay  time.Duration
+	rateLimiterMaxDelay   time.Duration
+	rateLimiterQPS        float64
+	rateLimiterBurst      int
+
+	csrApprovalCNPattern            string
+	csrApprovalNamespaces           []string
+	csrApprovalMaxPerHour           int
+	csrApprovalRequireAttestation   bool
+	csrApprovalMaxHostsPerNamespace int
+	csrApprovalSignerNames          []string
 )
 
 func init() {
@@ -48,9 +79,11 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(infrastructurev1beta1.AddToScheme(scheme))
+	utilruntime.Must(infrastructurev1beta2.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 
 	utilruntime.Must(clusterv1.AddToScheme(scheme))
+	utilruntime.Must(expv1.AddToScheme(scheme))
 	utilruntime.Must(admissionv1beta1.AddToScheme(scheme))
 }
 
@@ -59,7 +92,45 @@ func setFlags() {
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
-	flag.Parse()
+	flag.StringVar(&agentVersion, "agent-version", "", "The host agent version all the ByoHosts are upgraded to. Agent upgrades are not requested when empty.")
+	flag.StringVar(&agentBinaryRepo, "agent-binary-repo", "projects.registry.vmware.com/cluster_api_provider_bringyourownhost", "The OCI repository the host agent binaries are pulled from on upgrade.")
+	flag.DurationVar(&staleHostTimeout, "stale-host-timeout", 0, "How long the ByoHosts without a machine are kept after the last heartbeat of their host agent before being deleted. The stale ByoHosts are not deleted when 0.")
+	flag.StringVar(&hostPoolLabel, "host-pool-label", "", "The label of the ByoHosts the host pool metrics are grouped by, along with their namespace.")
+	flag.StringVar(&tracingEndpoint, "tracing-endpoint", "", "The OTLP/HTTP endpoint of the OpenTelemetry collector the spans of the provisioning of the machines are exported to, e.g. otel-collector:4318. Nothing is traced when empty.")
+	flag.BoolVar(&tracingInsecure, "tracing-insecure", false, "Export the spans to the tracing endpoint without TLS.")
+	flag.IntVar(&byoMachineConcurrency, "byomachine-concurrency", 10, "The number of ByoMachines and ByoMachinePools reconciled concurrently.")
+	flag.IntVar(&byoHostConcurrency, "byohost-concurrency", 10, "The number of ByoHosts reconciled concurrently.")
+	flag.DurationVar(&rateLimiterBaseDelay, "rate-limiter-base-delay", 5*time.Millisecond, "The delay of the first retry of a failed reconcile, doubled on every failure.")
+	flag.DurationVar(&rateLimiterMaxDelay, "rate-limiter-max-delay", 1000*time.Second, "The maximum delay of the retries of a failed reconcile.")
+	flag.Float64Var(&rateLimiterQPS, "rate-limiter-qps", 10, "The overall number of reconciles per second of each controller.")
+	flag.IntVar(&rateLimiterBurst, "rate-limiter-burst", 100, "The burst of reconciles allowed above --rate-limiter-qps.")
+	flag.StringVar(&csrApprovalCNPattern, "csr-approval-cn-pattern", byohcontrollers.DefaultCSRCommonNamePattern, "The pattern the common name of the host CSRs has to match to be approved automatically.")
+	flag.IntVar(&csrApprovalMaxPerHour, "csr-approval-max-per-hour", 0, "The maximum number of host CSRs approved automatically per hour, unlimited when 0.")
+	flag.BoolVar(&csrApprovalRequireAttestation, "csr-approval-require-attestation", false, "Only approve automatically the host CSRs whose HostAttested status condition an attestation service set to True.")
+	flag.IntVar(&csrApprovalMaxHostsPerNamespace, "csr-approval-max-hosts-per-namespace", 0, "The maximum number of ByoHosts of a namespace for the host CSRs requested from it to be approved automatically, unlimited when 0.")
+	pflag.StringSliceVar(&csrApprovalNamespaces, "csr-approval-namespaces", nil, "The namespaces the host CSRs have to be requested from to be approved automatically, any when empty.")
+	pflag.StringSliceVar(&csrApprovalSignerNames, "csr-approval-signer-names", []string{certv1.KubeAPIServerClientSignerName}, "The signers the host CSRs have to be requested from to be approved automatically, e.g. the signer of an internal CA. The controller manager has to be granted the approve verb on the signers.")
+	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
+	feature.MutableGates.AddFlag(pflag.CommandLine)
+	pflag.Parse()
+}
+
+func newCSRApprovalPolicy() (*byohcontrollers.CSRApprovalPolicy, error) {
+	policy := &byohcontrollers.CSRApprovalPolicy{
+		AllowedNamespaces:    csrApprovalNamespaces,
+		MaxApprovalsPerHour:  csrApprovalMaxPerHour,
+		RequireAttestation:   csrApprovalRequireAttestation,
+		MaxHostsPerNamespace: csrApprovalMaxHostsPerNamespace,
+		SignerNames:          csrApprovalSignerNames,
+	}
+	if csrApprovalCNPattern != "" {
+		pattern, err := regexp.Compile(csrApprovalCNPattern)
+		if err != nil {
+			return nil, err
+		}
+		policy.CommonNamePattern = pattern
+	}
+	return policy, nil
 }
 
 func main() {
@@ -100,15 +171,55 @@ func main() {
 		Scheme:   mgr.GetScheme(),
 		Tracker:  tracker,
 		Recorder: mgr.GetEventRecorderFor("byomachine-controller"),
-	}).SetupWithManager(context.TODO(), mgr); err != nil {
+	}).SetupWithManager(context.TODO(), mgr, controllerOptions(byoMachineConcurrency)); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ByoMachine")
 		os.Exit(1)
 	}
+	if feature.Gates.Enabled(feature.MachinePool) {
+		if err = (&byohcontrollers.ByoMachinePoolReconciler{
+			Client:   mgr.GetClient(),
+			Scheme:   mgr.GetScheme(),
+			Tracker:  tracker,
+			Recorder: mgr.GetEventRecorderFor("byomachinepool-controller"),
+		}).SetupWithManager(context.TODO(), mgr, controllerOptions(byoMachineConcurrency)); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "ByoMachinePool")
+			os.Exit(1)
+		}
+	}
 	if err = (&byohcontrollers.ByoHostReconciler{
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		AgentVersion:    agentVersion,
+		AgentBinaryRepo: agentBinaryRepo,
+	}).SetupWithManager(mgr, controllerOptions(byoHostConcurrency)); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ByoHost")
+		os.Exit(1)
+	}
+	if staleHostTimeout > 0 {
+		if err = (&byohcontrollers.ByoHostGCReconciler{
+			Client:       mgr.GetClient(),
+			StaleTimeout: staleHostTimeout,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "ByoHostGC")
+			os.Exit(1)
+		}
+	}
+	if err = (&byohcontrollers.ByoHostClaimReconciler{
+		Client:   mgr.GetClient(),
+		Recorder: mgr.GetEventRecorderFor("byohostclaim-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ByoHostClaim")
+		os.Exit(1)
+	}
+	metrics.Registry.MustRegister(&byohcontrollers.ByoHostPoolCollector{
+		Reader:    mgr.GetClient(),
+		PoolLabel: hostPoolLabel,
+	})
+	if err = (&byohcontrollers.ByoHostRBACReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ByoHost")
+		setupLog.Error(err, "unable to create controller", "controller", "ByoHostRBAC")
 		os.Exit(1)
 	}
 	if err = (&byohcontrollers.ByoMachineTemplateReconciler{
@@ -125,8 +236,15 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "ByoCluster")
 		os.Exit(1)
 	}
+	csrApprovalPolicy, err := newCSRApprovalPolicy()
+	if err != nil {
+		setupLog.Error(err, "invalid CSR approval policy")
+		os.Exit(1)
+	}
 	if err = (&byohcontrollers.ByoAdmissionReconciler{
+		Client:    mgr.GetClient(),
 		ClientSet: clientset.NewForConfigOrDie(ctrl.GetConfigOrDie()),
+		Policy:    csrApprovalPolicy,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ByoAdmission")
 		os.Exit(1)
@@ -136,13 +254,33 @@ func main() {
 		setupLog.Error(err, "unable to create webhook", "webhook", "ByoCluster")
 		os.Exit(1)
 	}
+	if err = (&infrastructurev1beta1.ByoClusterTemplate{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "ByoClusterTemplate")
+		os.Exit(1)
+	}
+	if err = (&infrastructurev1beta1.ByoMachine{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "ByoMachine")
+		os.Exit(1)
+	}
+	if err = (&infrastructurev1beta1.ByoMachineTemplate{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "ByoMachineTemplate")
+		os.Exit(1)
+	}
 
 	if err = (&byohcontrollers.K8sInstallerConfigReconciler{Client: mgr.GetClient(), Scheme: mgr.GetScheme()}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "K8sInstallerConfig")
 		os.Exit(1)
 	}
+	if err = (&byohcontrollers.K8sBundleCatalogReconciler{Client: mgr.GetClient()}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "K8sBundleCatalog")
+		os.Exit(1)
+	}
+	if err = (&byohcontrollers.BootstrapKubeconfigReconciler{Client: mgr.GetClient(), Scheme: mgr.GetScheme()}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "BootstrapKubeconfig")
+		os.Exit(1)
+	}
 
-	mgr.GetWebhookServer().Register("/validate-infrastructure-cluster-x-k8s-io-v1beta1-byohost", &webhook.Admission{Handler: &infrastructurev1beta1.ByoHostValidator{}})
+	mgr.GetWebhookServer().Register("/validate-infrastructure-cluster-x-k8s-io-v1beta1-byohost", &webhook.Admission{Handler: &infrastructurev1beta1.ByoHostValidator{Client: mgr.GetClient()}})
 
 	//+kubebuilder:scaffold:builder
 
@@ -155,13 +293,33 @@ func main() {
 		os.Exit(1)
 	}
 
+	shutdownTracing, err := tracing.Setup(context.TODO(), "byoh-controller-manager", tracingEndpoint, tracingInsecure)
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
+
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
+	if err := shutdownTracing(context.TODO()); err != nil {
+		setupLog.Error(err, "unable to export the pending spans")
+	}
 }
 
 func concurrency(c int) controller.Options {
 	return controller.Options{MaxConcurrentReconciles: c}
 }
+
+// controllerOptions returns the options of the controllers reconciling c objects concurrently, with
+// the per-item exponential backoff and the overall rate limit set by the rate limiter flags
+func controllerOptions(c int) controller.Options {
+	options := concurrency(c)
+	options.RateLimiter = workqueue.NewMaxOfRateLimiter(
+		workqueue.NewItemExponentialFailureRateLimiter(rateLimiterBaseDelay, rateLimiterMaxDelay),
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(rateLimiterQPS), rateLimiterBurst)},
+	)
+	return options
+}
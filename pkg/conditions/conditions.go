@@ -0,0 +1,78 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package conditions implements the conventions of the Cluster API conditions the BYOH objects follow on
+// top of the Cluster API helpers, so that the tools relying on them, e.g. clusterctl describe, work with
+// the BYOH objects unmodified: the Ready condition summarizes the other conditions of the object, and the
+// few negative polarity conditions, true while something is wrong, are removed rather than set to false.
+package conditions
+
+import (
+	"fmt"
+
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+)
+
+// negativePolarity are the conditions true while something is wrong
+var negativePolarity = map[clusterv1.ConditionType]bool{
+	infrav1.AgentDegraded: true,
+}
+
+// IsNegativePolarity tells whether the condition is true while something is wrong, unlike the Cluster API conditions
+func IsNegativePolarity(t clusterv1.ConditionType) bool {
+	return negativePolarity[t]
+}
+
+// MarkNegative raises the negative polarity condition, with the severity of the problem
+func MarkNegative(to conditions.Setter, t clusterv1.ConditionType, reason string, severity clusterv1.ConditionSeverity, messageFormat string, messageArgs ...interface{}) {
+	conditions.Set(to, &clusterv1.Condition{
+		Type:     t,
+		Status:   corev1.ConditionTrue,
+		Severity: severity,
+		Reason:   reason,
+		Message:  fmt.Sprintf(messageFormat, messageArgs...),
+	})
+}
+
+// ClearNegative removes the negative polarity condition once the problem is gone, rather than setting it to false
+func ClearNegative(to conditions.Setter, t clusterv1.ConditionType) {
+	conditions.Delete(to, t)
+}
+
+// SetSummary sets the Ready condition of the object from the conditions of the given types, with the Cluster API
+// merge strategies: it is false, or unknown, with the reason of the most severe of the conditions false, or unknown,
+// and true otherwise. The negative polarity conditions count as false while they are true. The Ready condition is
+// true when none of the conditions is set.
+func SetSummary(to conditions.Setter, types ...clusterv1.ConditionType) {
+	summarized, ok := to.DeepCopyObject().(conditions.Setter)
+	if !ok {
+		return
+	}
+	inScope := make(clusterv1.Conditions, 0, len(types))
+	for _, t := range types {
+		condition := conditions.Get(to, t)
+		if condition == nil {
+			continue
+		}
+		condition = condition.DeepCopy()
+		if IsNegativePolarity(t) {
+			if condition.Status != corev1.ConditionTrue {
+				continue
+			}
+			condition.Status = corev1.ConditionFalse
+		}
+		inScope = append(inScope, *condition)
+	}
+	summarized.SetConditions(inScope)
+	conditions.SetSummary(summarized, conditions.WithConditions(types...))
+
+	ready := conditions.Get(summarized, clusterv1.ReadyCondition)
+	if ready == nil {
+		conditions.MarkTrue(to, clusterv1.ReadyCondition)
+		return
+	}
+	conditions.Set(to, ready)
+}
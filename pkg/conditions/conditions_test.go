@@ -0,0 +1,80 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package conditions_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	infrav1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
+	byohconditions "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/pkg/conditions"
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+)
+
+var _ = Describe("Conditions", func() {
+	var byoHost *infrav1.ByoHost
+
+	BeforeEach(func() {
+		byoHost = &infrav1.ByoHost{}
+	})
+
+	It("should be ready when none of the summarized conditions is set", func() {
+		byohconditions.SetSummary(byoHost, infrav1.K8sComponentsInstallationSucceeded, infrav1.AgentDegraded)
+		Expect(conditions.IsTrue(byoHost, clusterv1.ReadyCondition)).To(BeTrue())
+	})
+
+	It("should summarize the most severe of the false conditions", func() {
+		conditions.MarkTrue(byoHost, infrav1.HostPreflightSucceeded)
+		conditions.MarkFalse(byoHost, infrav1.K8sComponentsInstallationSucceeded, infrav1.K8sComponentsInstallationFailedReason,
+			clusterv1.ConditionSeverityError, "")
+		conditions.MarkFalse(byoHost, infrav1.K8sNodeBootstrapSucceeded, infrav1.WaitingForMachineRefReason, clusterv1.ConditionSeverityInfo, "")
+		byohconditions.SetSummary(byoHost, infrav1.HostPreflightSucceeded, infrav1.K8sComponentsInstallationSucceeded, infrav1.K8sNodeBootstrapSucceeded)
+
+		Expect(conditions.IsFalse(byoHost, clusterv1.ReadyCondition)).To(BeTrue())
+		Expect(conditions.GetReason(byoHost, clusterv1.ReadyCondition)).To(Equal(infrav1.K8sComponentsInstallationFailedReason))
+		Expect(*conditions.GetSeverity(byoHost, clusterv1.ReadyCondition)).To(Equal(clusterv1.ConditionSeverityError))
+	})
+
+	It("should not summarize the conditions of other types", func() {
+		conditions.MarkTrue(byoHost, infrav1.HostPreflightSucceeded)
+		conditions.MarkFalse(byoHost, infrav1.HostSchedulable, infrav1.HostUnschedulableReason, clusterv1.ConditionSeverityInfo, "")
+		byohconditions.SetSummary(byoHost, infrav1.HostPreflightSucceeded)
+		Expect(conditions.IsTrue(byoHost, clusterv1.ReadyCondition)).To(BeTrue())
+	})
+
+	Context("When a negative polarity condition is raised", func() {
+		BeforeEach(func() {
+			conditions.MarkTrue(byoHost, infrav1.K8sComponentsInstallationSucceeded)
+			byohconditions.MarkNegative(byoHost, infrav1.AgentDegraded, infrav1.CrashLoopReason, clusterv1.ConditionSeverityWarning,
+				"%d panics", 3)
+		})
+
+		It("should be true with the severity of the problem", func() {
+			Expect(byohconditions.IsNegativePolarity(infrav1.AgentDegraded)).To(BeTrue())
+			Expect(*conditions.Get(byoHost, infrav1.AgentDegraded)).To(conditions.MatchCondition(clusterv1.Condition{
+				Type:     infrav1.AgentDegraded,
+				Status:   corev1.ConditionTrue,
+				Severity: clusterv1.ConditionSeverityWarning,
+				Reason:   infrav1.CrashLoopReason,
+				Message:  "3 panics",
+			}))
+		})
+
+		It("should summarize the condition as false while raised", func() {
+			byohconditions.SetSummary(byoHost, infrav1.K8sComponentsInstallationSucceeded, infrav1.AgentDegraded)
+			Expect(conditions.IsFalse(byoHost, clusterv1.ReadyCondition)).To(BeTrue())
+			Expect(conditions.GetReason(byoHost, clusterv1.ReadyCondition)).To(Equal(infrav1.CrashLoopReason))
+			Expect(conditions.IsTrue(byoHost, infrav1.AgentDegraded)).To(BeTrue())
+		})
+
+		It("should remove the condition once cleared", func() {
+			byohconditions.ClearNegative(byoHost, infrav1.AgentDegraded)
+			Expect(conditions.Has(byoHost, infrav1.AgentDegraded)).To(BeFalse())
+
+			byohconditions.SetSummary(byoHost, infrav1.K8sComponentsInstallationSucceeded, infrav1.AgentDegraded)
+			Expect(conditions.IsTrue(byoHost, clusterv1.ReadyCondition)).To(BeTrue())
+		})
+	})
+})
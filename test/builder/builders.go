@@ -9,12 +9,14 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
+	"time"
 
 	infrastructurev1beta1 "github.com/vmware-tanzu/cluster-api-provider-bringyourownhost/apis/infrastructure/v1beta1"
 	certv1 "k8s.io/api/certificates/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
 )
 
 // ByoMachineBuilder holds the variables and objects required to build an infrastructurev1beta1.ByoMachine
@@ -24,6 +26,8 @@ type ByoMachineBuilder struct {
 	clusterLabel string
 	machine      *clusterv1.Machine
 	selector     map[string]string
+	hostName     string
+	controlPlane bool
 }
 
 // ByoMachine returns a ByoMachineBuilder with the given name and namespace
@@ -52,6 +56,18 @@ func (b *ByoMachineBuilder) WithLabelSelector(selector map[string]string) *ByoMa
 	return b
 }
 
+// WithHostRef pins the ByoMachine to the ByoHost with the passed name
+func (b *ByoMachineBuilder) WithHostRef(hostName string) *ByoMachineBuilder {
+	b.hostName = hostName
+	return b
+}
+
+// WithControlPlaneLabel marks the ByoMachine as a control plane machine
+func (b *ByoMachineBuilder) WithControlPlaneLabel() *ByoMachineBuilder {
+	b.controlPlane = true
+	return b
+}
+
 // Build returns a ByoMachine with the attributes added to the ByoMachineBuilder
 func (b *ByoMachineBuilder) Build() *infrastructurev1beta1.ByoMachine {
 	byoMachine := &infrastructurev1beta1.ByoMachine{
@@ -80,18 +96,31 @@ func (b *ByoMachineBuilder) Build() *infrastructurev1beta1.ByoMachine {
 			clusterv1.ClusterLabelName: b.clusterLabel,
 		}
 	}
+	if b.controlPlane {
+		if byoMachine.ObjectMeta.Labels == nil {
+			byoMachine.ObjectMeta.Labels = map[string]string{}
+		}
+		byoMachine.ObjectMeta.Labels[clusterv1.MachineControlPlaneLabelName] = ""
+	}
 	if b.selector != nil {
 		byoMachine.Spec.Selector = &metav1.LabelSelector{MatchLabels: b.selector}
 	}
+	if b.hostName != "" {
+		byoMachine.Spec.HostRef = &corev1.ObjectReference{Name: b.hostName}
+	}
 
 	return byoMachine
 }
 
 // ByoHostBuilder holds the variables and objects required to build an infrastructurev1beta1.ByoHost
 type ByoHostBuilder struct {
-	namespace string
-	name      string
-	labels    map[string]string
+	namespace         string
+	name              string
+	labels            map[string]string
+	annotations       map[string]string
+	allowedNamespaces []string
+	reserved          bool
+	reservedFor       string
 }
 
 // ByoHost returns a ByoHostBuilder with the given name and namespace
@@ -108,6 +137,30 @@ func (b *ByoHostBuilder) WithLabels(labels map[string]string) *ByoHostBuilder {
 	return b
 }
 
+// WithAnnotations adds the passed annotations to the ByoHostBuilder
+func (b *ByoHostBuilder) WithAnnotations(annotations map[string]string) *ByoHostBuilder {
+	b.annotations = annotations
+	return b
+}
+
+// WithAllowedNamespaces adds the namespaces allowed to claim the ByoHost to the ByoHostBuilder
+func (b *ByoHostBuilder) WithAllowedNamespaces(namespaces ...string) *ByoHostBuilder {
+	b.allowedNamespaces = namespaces
+	return b
+}
+
+// WithReserved reserves the ByoHost for the ByoMachines pinning it
+func (b *ByoHostBuilder) WithReserved() *ByoHostBuilder {
+	b.reserved = true
+	return b
+}
+
+// WithReservedFor reserves the ByoHost for the Cluster with the passed name
+func (b *ByoHostBuilder) WithReservedFor(clusterName string) *ByoHostBuilder {
+	b.reservedFor = clusterName
+	return b
+}
+
 // Build returns a ByoHost with the attributes added to the ByoHostBuilder
 func (b *ByoHostBuilder) Build() *infrastructurev1beta1.ByoHost {
 	byoHost := &infrastructurev1beta1.ByoHost{
@@ -119,11 +172,20 @@ func (b *ByoHostBuilder) Build() *infrastructurev1beta1.ByoHost {
 			GenerateName: b.name,
 			Namespace:    b.namespace,
 		},
-		Spec: infrastructurev1beta1.ByoHostSpec{},
+		Spec: infrastructurev1beta1.ByoHostSpec{
+			AllowedNamespaces: b.allowedNamespaces,
+			Reserved:          b.reserved,
+		},
+	}
+	if b.reservedFor != "" {
+		byoHost.Spec.ReservedFor = &corev1.ObjectReference{Name: b.reservedFor}
 	}
 	if b.labels != nil {
 		byoHost.Labels = b.labels
 	}
+	if b.annotations != nil {
+		byoHost.Annotations = b.annotations
+	}
 
 	return byoHost
 }
@@ -143,6 +205,8 @@ type ByoClusterBuilder struct {
 	name           string
 	bundleRegistry string
 	bundleTag      string
+	kubeVIP        *infrastructurev1beta1.KubeVIPSpec
+	loadBalancer   *infrastructurev1beta1.LoadBalancerSpec
 	cluster        *clusterv1.Cluster
 }
 
@@ -172,6 +236,18 @@ func (c *ByoClusterBuilder) WithBundleTag(tag string) *ByoClusterBuilder {
 	return c
 }
 
+// WithKubeVIP adds the passed kube-vip configuration to the ByoClusterBuilder
+func (c *ByoClusterBuilder) WithKubeVIP(kubeVIP *infrastructurev1beta1.KubeVIPSpec) *ByoClusterBuilder {
+	c.kubeVIP = kubeVIP
+	return c
+}
+
+// WithLoadBalancer adds the passed load balancer configuration to the ByoClusterBuilder
+func (c *ByoClusterBuilder) WithLoadBalancer(loadBalancer *infrastructurev1beta1.LoadBalancerSpec) *ByoClusterBuilder {
+	c.loadBalancer = loadBalancer
+	return c
+}
+
 // Build returns a Cluster with the attributes added to the ByoClusterBuilder
 func (c *ByoClusterBuilder) Build() *infrastructurev1beta1.ByoCluster {
 	cluster := &infrastructurev1beta1.ByoCluster{
@@ -203,6 +279,8 @@ func (c *ByoClusterBuilder) Build() *infrastructurev1beta1.ByoCluster {
 	if c.bundleTag != "" {
 		cluster.Spec.BundleLookupTag = c.bundleTag
 	}
+	cluster.Spec.KubeVIP = c.kubeVIP
+	cluster.Spec.LoadBalancer = c.loadBalancer
 
 	return cluster
 }
@@ -332,9 +410,19 @@ func Secret(namespace, name string) *SecretBuilder {
 
 // WithData adds the passed data to the SecretBuilder
 func (s *SecretBuilder) WithData(value string) *SecretBuilder {
-	s.data = map[string][]byte{
-		"value": []byte(value),
+	if s.data == nil {
+		s.data = map[string][]byte{}
 	}
+	s.data["value"] = []byte(value)
+	return s
+}
+
+// WithFormat adds the format of the bootstrap data to the SecretBuilder
+func (s *SecretBuilder) WithFormat(format string) *SecretBuilder {
+	if s.data == nil {
+		s.data = map[string][]byte{}
+	}
+	s.data["format"] = []byte(format)
 	return s
 }
 
@@ -606,3 +694,214 @@ func (b *K8sInstallerConfigTemplateBuilder) Build() *infrastructurev1beta1.K8sIn
 	}
 	return k8sinstallerconfigtemplate
 }
+
+// ByoMachinePoolBuilder holds the variables and objects required to build an infrastructurev1beta1.ByoMachinePool
+type ByoMachinePoolBuilder struct {
+	namespace    string
+	name         string
+	clusterLabel string
+	machinePool  *expv1.MachinePool
+	selector     map[string]string
+}
+
+// ByoMachinePool returns a ByoMachinePoolBuilder with the given name and namespace
+func ByoMachinePool(namespace, name string) *ByoMachinePoolBuilder {
+	return &ByoMachinePoolBuilder{
+		namespace: namespace,
+		name:      name,
+	}
+}
+
+// WithOwnerMachinePool adds the passed Owner MachinePool to the ByoMachinePoolBuilder
+func (b *ByoMachinePoolBuilder) WithOwnerMachinePool(machinePool *expv1.MachinePool) *ByoMachinePoolBuilder {
+	b.machinePool = machinePool
+	return b
+}
+
+// WithClusterLabel adds the passed cluster label to the ByoMachinePoolBuilder
+func (b *ByoMachinePoolBuilder) WithClusterLabel(clusterName string) *ByoMachinePoolBuilder {
+	b.clusterLabel = clusterName
+	return b
+}
+
+// WithLabelSelector adds the passed label selector to the ByoMachinePoolBuilder
+func (b *ByoMachinePoolBuilder) WithLabelSelector(selector map[string]string) *ByoMachinePoolBuilder {
+	b.selector = selector
+	return b
+}
+
+// Build returns a ByoMachinePool with the attributes added to the ByoMachinePoolBuilder
+func (b *ByoMachinePoolBuilder) Build() *infrastructurev1beta1.ByoMachinePool {
+	byoMachinePool := &infrastructurev1beta1.ByoMachinePool{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ByoMachinePool",
+			APIVersion: infrastructurev1beta1.GroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: b.name,
+			Namespace:    b.namespace,
+		},
+	}
+	if b.machinePool != nil {
+		byoMachinePool.ObjectMeta.OwnerReferences = []metav1.OwnerReference{
+			{
+				Kind:       "MachinePool",
+				Name:       b.machinePool.Name,
+				APIVersion: expv1.GroupVersion.String(),
+				UID:        b.machinePool.UID,
+			},
+		}
+	}
+	if b.clusterLabel != "" {
+		byoMachinePool.ObjectMeta.Labels = map[string]string{
+			clusterv1.ClusterLabelName: b.clusterLabel,
+		}
+	}
+	if b.selector != nil {
+		byoMachinePool.Spec.Selector = &metav1.LabelSelector{MatchLabels: b.selector}
+	}
+
+	return byoMachinePool
+}
+
+// MachinePoolBuilder holds the variables and objects required to build an expv1.MachinePool
+type MachinePoolBuilder struct {
+	namespace           string
+	name                string
+	cluster             string
+	version             string
+	replicas            int32
+	bootstrapDataSecret string
+}
+
+// MachinePool returns a MachinePoolBuilder with the given name and namespace
+func MachinePool(namespace, name string) *MachinePoolBuilder {
+	return &MachinePoolBuilder{
+		namespace: namespace,
+		name:      name,
+		replicas:  1,
+	}
+}
+
+// WithClusterName adds the passed Cluster to the MachinePoolBuilder
+func (m *MachinePoolBuilder) WithClusterName(cluster string) *MachinePoolBuilder {
+	m.cluster = cluster
+	return m
+}
+
+// WithClusterVersion adds the passed cluster version to the MachinePoolBuilder
+func (m *MachinePoolBuilder) WithClusterVersion(version string) *MachinePoolBuilder {
+	m.version = version
+	return m
+}
+
+// WithReplicas sets the desired replicas of the MachinePoolBuilder
+func (m *MachinePoolBuilder) WithReplicas(replicas int32) *MachinePoolBuilder {
+	m.replicas = replicas
+	return m
+}
+
+// WithBootstrapDataSecret adds the passed bootstrap secret to the MachinePoolBuilder
+func (m *MachinePoolBuilder) WithBootstrapDataSecret(secret string) *MachinePoolBuilder {
+	m.bootstrapDataSecret = secret
+	return m
+}
+
+// Build returns a MachinePool with the attributes added to the MachinePoolBuilder
+func (m *MachinePoolBuilder) Build() *expv1.MachinePool {
+	machinePool := &expv1.MachinePool{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "MachinePool",
+			APIVersion: expv1.GroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: m.name,
+			Namespace:    m.namespace,
+			Labels: map[string]string{
+				clusterv1.ClusterLabelName: m.cluster,
+			},
+		},
+		Spec: expv1.MachinePoolSpec{
+			ClusterName: m.cluster,
+			Replicas:    &m.replicas,
+			Template: clusterv1.MachineTemplateSpec{
+				Spec: clusterv1.MachineSpec{
+					ClusterName: m.cluster,
+					Version:     &m.version,
+				},
+			},
+		},
+	}
+	if m.bootstrapDataSecret != "" {
+		machinePool.Spec.Template.Spec.Bootstrap = clusterv1.Bootstrap{
+			DataSecretName: &m.bootstrapDataSecret,
+		}
+	}
+
+	return machinePool
+}
+
+// BootstrapKubeconfigBuilder holds the variables and objects required to build an infrastructurev1beta1.BootstrapKubeconfig
+type BootstrapKubeconfigBuilder struct {
+	namespace    string
+	name         string
+	apiServer    string
+	caData       string
+	insecureSkip bool
+	ttl          *metav1.Duration
+	agentScoped  bool
+}
+
+// BootstrapKubeconfig returns a BootstrapKubeconfigBuilder with the given name and namespace
+func BootstrapKubeconfig(namespace, name string) *BootstrapKubeconfigBuilder {
+	return &BootstrapKubeconfigBuilder{
+		namespace: namespace,
+		name:      name,
+	}
+}
+
+// WithServer adds the passed API server and CA data to the BootstrapKubeconfigBuilder
+func (b *BootstrapKubeconfigBuilder) WithServer(apiServer, caData string) *BootstrapKubeconfigBuilder {
+	b.apiServer = apiServer
+	b.caData = caData
+	return b
+}
+
+// WithInsecureSkipTLSVerify adds the passed insecureSkipTLSVerify to the BootstrapKubeconfigBuilder
+func (b *BootstrapKubeconfigBuilder) WithInsecureSkipTLSVerify(insecureSkip bool) *BootstrapKubeconfigBuilder {
+	b.insecureSkip = insecureSkip
+	return b
+}
+
+// WithTTL adds the passed TTL to the BootstrapKubeconfigBuilder
+func (b *BootstrapKubeconfigBuilder) WithTTL(ttl time.Duration) *BootstrapKubeconfigBuilder {
+	b.ttl = &metav1.Duration{Duration: ttl}
+	return b
+}
+
+// WithNamespaceScopedAgent requests a namespace-scoped agent kubeconfig from the BootstrapKubeconfigBuilder
+func (b *BootstrapKubeconfigBuilder) WithNamespaceScopedAgent() *BootstrapKubeconfigBuilder {
+	b.agentScoped = true
+	return b
+}
+
+// Build returns a BootstrapKubeconfig with the attributes added to the BootstrapKubeconfigBuilder
+func (b *BootstrapKubeconfigBuilder) Build() *infrastructurev1beta1.BootstrapKubeconfig {
+	return &infrastructurev1beta1.BootstrapKubeconfig{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "BootstrapKubeconfig",
+			APIVersion: infrastructurev1beta1.GroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      b.name,
+			Namespace: b.namespace,
+		},
+		Spec: infrastructurev1beta1.BootstrapKubeconfigSpec{
+			APIServer:                b.apiServer,
+			CertificateAuthorityData: b.caData,
+			InsecureSkipTLSVerify:    b.insecureSkip,
+			TTL:                      b.ttl,
+			NamespaceScopedAgent:     b.agentScoped,
+		},
+	}
+}
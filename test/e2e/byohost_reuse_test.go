@@ -69,12 +69,12 @@ var _ = Describe("When BYO Host rejoins the capacity pool", func() {
 
 		runner := ByoHostRunner{
 			Context:               ctx,
-			clusterConName:        clusterConName,
+			ClusterConName:        clusterConName,
 			Namespace:             namespace.Name,
 			PathToHostAgentBinary: pathToHostAgentBinary,
 			DockerClient:          dockerClient,
 			NetworkInterface:      "kind",
-			bootstrapClusterProxy: bootstrapClusterProxy,
+			BootstrapClusterProxy: bootstrapClusterProxy,
 			CommandArgs: map[string]string{
 				"--kubeconfig": "/mgmt.conf",
 				"--namespace":  namespace.Name,
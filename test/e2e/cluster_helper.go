@@ -0,0 +1,101 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package e2e
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/docker/docker/client"
+	. "github.com/onsi/gomega" // nolint: stylecheck
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/cluster-api/test/framework"
+	"sigs.k8s.io/cluster-api/test/framework/clusterctl"
+)
+
+// KubernetesVersion is the e2e config variable of the Kubernetes version of the workload clusters
+const KubernetesVersion = "KUBERNETES_VERSION"
+
+// MultiNodeClusterInput is the input for MultiNodeClusterTemplateInput
+type MultiNodeClusterInput struct {
+	Context                  context.Context
+	BootstrapClusterProxy    framework.ClusterProxy
+	DockerClient             *client.Client
+	E2EConfig                *clusterctl.E2EConfig
+	ClusterctlConfigPath     string
+	ArtifactFolder           string
+	SpecName                 string
+	Namespace                string
+	ClusterName              string
+	Flavor                   string
+	ControlPlaneMachineCount int64
+	WorkerMachineCount       int64
+}
+
+// HostCount returns the number of byohosts the cluster needs, one per control plane and worker machine
+func (i MultiNodeClusterInput) HostCount() int {
+	return int(i.ControlPlaneMachineCount + i.WorkerMachineCount)
+}
+
+// MultiNodeClusterTemplateInput returns the input to apply a cluster template with several control plane
+// and worker machines on byohosts of the kind network and wait for it. The control plane endpoint IP is
+// picked in the kind network unless CONTROL_PLANE_ENDPOINT_IP is set.
+func MultiNodeClusterTemplateInput(input MultiNodeClusterInput) clusterctl.ApplyClusterTemplateAndWaitInput {
+	Expect(input.BootstrapClusterProxy).NotTo(BeNil(), "BootstrapClusterProxy is required for MultiNodeClusterTemplateInput")
+	Expect(input.E2EConfig).NotTo(BeNil(), "E2EConfig is required for MultiNodeClusterTemplateInput")
+	Expect(input.E2EConfig.Variables).To(HaveKey(KubernetesVersion))
+	Expect(input.ControlPlaneMachineCount).To(BeNumerically(">", 0), "ControlPlaneMachineCount must be positive")
+
+	if input.DockerClient != nil {
+		setControlPlaneIP(input.Context, input.DockerClient)
+	}
+	flavor := input.Flavor
+	if flavor == "" {
+		flavor = clusterctl.DefaultFlavor
+	}
+
+	return clusterctl.ApplyClusterTemplateAndWaitInput{
+		ClusterProxy: input.BootstrapClusterProxy,
+		ConfigCluster: clusterctl.ConfigClusterInput{
+			LogFolder:                filepath.Join(input.ArtifactFolder, "clusters", input.BootstrapClusterProxy.GetName()),
+			ClusterctlConfigPath:     input.ClusterctlConfigPath,
+			KubeconfigPath:           input.BootstrapClusterProxy.GetKubeconfigPath(),
+			InfrastructureProvider:   clusterctl.DefaultInfrastructureProvider,
+			Flavor:                   flavor,
+			Namespace:                input.Namespace,
+			ClusterName:              input.ClusterName,
+			KubernetesVersion:        input.E2EConfig.GetVariable(KubernetesVersion),
+			ControlPlaneMachineCount: pointer.Int64Ptr(input.ControlPlaneMachineCount),
+			WorkerMachineCount:       pointer.Int64Ptr(input.WorkerMachineCount),
+		},
+		WaitForClusterIntervals:      input.E2EConfig.GetIntervals(input.SpecName, "wait-cluster"),
+		WaitForControlPlaneIntervals: input.E2EConfig.GetIntervals(input.SpecName, "wait-control-plane"),
+		WaitForMachineDeployments:    input.E2EConfig.GetIntervals(input.SpecName, "wait-worker-nodes"),
+	}
+}
+
+// WorkloadClusterProxyInput is the input for WorkloadClusterProxy
+type WorkloadClusterProxyInput struct {
+	BootstrapClusterProxy framework.ClusterProxy
+	Namespace             string
+	ClusterName           string
+	KubernetesVersion     string
+	NodeCount             int
+	WaitForNodesReady     []interface{}
+}
+
+// WorkloadClusterProxy returns the ClusterProxy of a workload cluster once its NodeCount nodes
+// run the KubernetesVersion and are ready
+func WorkloadClusterProxy(ctx context.Context, input WorkloadClusterProxyInput) framework.ClusterProxy {
+	Expect(input.BootstrapClusterProxy).NotTo(BeNil(), "BootstrapClusterProxy is required for WorkloadClusterProxy")
+
+	workloadClusterProxy := input.BootstrapClusterProxy.GetWorkloadCluster(ctx, input.Namespace, input.ClusterName)
+	framework.WaitForNodesReady(ctx, framework.WaitForNodesReadyInput{
+		Lister:            workloadClusterProxy.GetClient(),
+		KubernetesVersion: input.KubernetesVersion,
+		Count:             input.NodeCount,
+		WaitForNodesReady: input.WaitForNodesReady,
+	})
+	return workloadClusterProxy
+}
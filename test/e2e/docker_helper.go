@@ -5,6 +5,7 @@ package e2e
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
@@ -40,13 +41,13 @@ type cpConfig struct {
 // ByoHostRunner runs bring-you-own-host cluster in docker
 type ByoHostRunner struct {
 	Context               context.Context
-	clusterConName        string
+	ClusterConName        string
 	ByoHostName           string
 	PathToHostAgentBinary string
 	Namespace             string
 	DockerClient          *client.Client
 	NetworkInterface      string
-	bootstrapClusterProxy framework.ClusterProxy
+	BootstrapClusterProxy framework.ClusterProxy
 	CommandArgs           map[string]string
 	Port                  string
 	KubeconfigFile        string
@@ -187,7 +188,7 @@ func (r *ByoHostRunner) copyKubeconfig(config cpConfig, listopt types.ContainerL
 		re := regexp.MustCompile("server:.*")
 		kubeconfig = re.ReplaceAll(kubeconfig, []byte("server: https://127.0.0.1:"+r.Port))
 	} else {
-		listopt.Filters.Add("name", r.clusterConName+"-control-plane")
+		listopt.Filters.Add("name", r.ClusterConName+"-control-plane")
 		containers, err := r.DockerClient.ContainerList(r.Context, listopt)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(len(containers)).To(Equal(1))
@@ -195,7 +196,7 @@ func (r *ByoHostRunner) copyKubeconfig(config cpConfig, listopt types.ContainerL
 		profile, err := r.DockerClient.ContainerInspect(r.Context, containers[0].ID)
 		Expect(err).NotTo(HaveOccurred())
 
-		kubeconfig, err = os.ReadFile(r.bootstrapClusterProxy.GetKubeconfigPath())
+		kubeconfig, err = os.ReadFile(r.BootstrapClusterProxy.GetKubeconfigPath())
 		Expect(err).NotTo(HaveOccurred())
 
 		re := regexp.MustCompile("server:.*")
@@ -253,6 +254,74 @@ func (r *ByoHostRunner) ExecByoDockerHost(byohost *container.ContainerCreateCrea
 	return output, byohost.ID, err
 }
 
+// ByoDockerHost is a byohost docker container running the host agent
+type ByoDockerHost struct {
+	Name         string
+	ContainerID  string
+	AgentLogFile string
+	logFile      *os.File
+}
+
+// SetupByoDockerHosts sets up count byohost docker containers named <ByoHostName>-<index>
+// and runs the host agent in each of them, the log of the agent being written to logDir
+func (r *ByoHostRunner) SetupByoDockerHosts(count int, logDir string) ([]ByoDockerHost, error) {
+	hosts := make([]ByoDockerHost, 0, count)
+	for i := 0; i < count; i++ {
+		runner := *r
+		runner.ByoHostName = fmt.Sprintf("%s-%d", r.ByoHostName, i)
+
+		byohost, err := runner.SetupByoDockerHost()
+		if err != nil {
+			return hosts, errors.Wrapf(err, "failed to set up the byohost %s", runner.ByoHostName)
+		}
+		output, byohostContainerID, err := runner.ExecByoDockerHost(byohost)
+		host := ByoDockerHost{
+			Name:        runner.ByoHostName,
+			ContainerID: byohostContainerID,
+		}
+		if err != nil {
+			hosts = append(hosts, host)
+			return hosts, errors.Wrapf(err, "failed to run the host agent on the byohost %s", runner.ByoHostName)
+		}
+
+		host.AgentLogFile = filepath.Join(logDir, fmt.Sprintf("host-agent-%s.log", runner.ByoHostName))
+		host.logFile = WriteDockerLog(output, host.AgentLogFile)
+		hosts = append(hosts, host)
+	}
+	return hosts, nil
+}
+
+// CleanupByoDockerHosts stops and removes the byohost docker containers and their agent log files
+func CleanupByoDockerHosts(ctx context.Context, dockerClient *client.Client, hosts []ByoDockerHost) {
+	for _, host := range hosts {
+		if host.ContainerID != "" {
+			Expect(dockerClient.ContainerStop(ctx, host.ContainerID, nil)).NotTo(HaveOccurred())
+			Expect(dockerClient.ContainerRemove(ctx, host.ContainerID, types.ContainerRemoveOptions{})).NotTo(HaveOccurred())
+		}
+		if host.logFile != nil {
+			if err := host.logFile.Close(); err != nil {
+				Showf("error closing file %s:, %v", host.AgentLogFile, err)
+			}
+		}
+		if host.AgentLogFile != "" {
+			if err := os.Remove(host.AgentLogFile); err != nil {
+				Showf("error removing file %s: %v", host.AgentLogFile, err)
+			}
+		}
+	}
+}
+
+// AgentLogFiles returns the agent log files of the byohosts
+func AgentLogFiles(hosts []ByoDockerHost) []string {
+	logFiles := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		if host.AgentLogFile != "" {
+			logFiles = append(logFiles, host.AgentLogFile)
+		}
+	}
+	return logFiles
+}
+
 func setControlPlaneIP(ctx context.Context, dockerClient *client.Client) {
 	_, ok := os.LookupEnv("CONTROL_PLANE_ENDPOINT_IP")
 	if ok {
@@ -28,10 +28,9 @@ import (
 )
 
 const (
-	KubernetesVersion = "KUBERNETES_VERSION"
-	CNIPath           = "CNI"
-	CNIResources      = "CNI_RESOURCES"
-	IPFamily          = "IP_FAMILY"
+	CNIPath      = "CNI"
+	CNIResources = "CNI_RESOURCES"
+	IPFamily     = "IP_FAMILY"
 )
 
 // Test suite flags
@@ -65,12 +65,12 @@ var _ = Describe("When BYOH joins existing cluster [PR-Blocking]", func() {
 
 		runner := ByoHostRunner{
 			Context:               ctx,
-			clusterConName:        clusterConName,
+			ClusterConName:        clusterConName,
 			Namespace:             namespace.Name,
 			PathToHostAgentBinary: pathToHostAgentBinary,
 			DockerClient:          dockerClient,
 			NetworkInterface:      "kind",
-			bootstrapClusterProxy: bootstrapClusterProxy,
+			BootstrapClusterProxy: bootstrapClusterProxy,
 			CommandArgs: map[string]string{
 				"--kubeconfig": "/mgmt.conf",
 				"--namespace":  namespace.Name,
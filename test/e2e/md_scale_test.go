@@ -66,13 +66,13 @@ var _ = Describe("When testing MachineDeployment scale out/in", func() {
 
 			runner := ByoHostRunner{
 				Context:               ctx,
-				clusterConName:        clusterConName,
+				ClusterConName:        clusterConName,
 				ByoHostName:           byoHostName,
 				Namespace:             namespace.Name,
 				PathToHostAgentBinary: pathToHostAgentBinary,
 				DockerClient:          dockerClient,
 				NetworkInterface:      "kind",
-				bootstrapClusterProxy: bootstrapClusterProxy,
+				BootstrapClusterProxy: bootstrapClusterProxy,
 				CommandArgs: map[string]string{
 					"--kubeconfig": "/mgmt.conf",
 					"--namespace":  namespace.Name,
@@ -0,0 +1,126 @@
+// Copyright 2022 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// nolint: testpackage
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/docker/docker/client"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/cluster-api/test/framework/clusterctl"
+	"sigs.k8s.io/cluster-api/util"
+)
+
+var _ = Describe("When BYOH joins a multi-node workload cluster", func() {
+
+	var (
+		ctx              context.Context
+		specName         = "multi-node"
+		namespace        *corev1.Namespace
+		cancelWatches    context.CancelFunc
+		clusterResources *clusterctl.ApplyClusterTemplateAndWaitResult
+		dockerClient     *client.Client
+		byoHosts         []ByoDockerHost
+	)
+
+	BeforeEach(func() {
+
+		ctx = context.TODO()
+		Expect(ctx).NotTo(BeNil(), "ctx is required for %s spec", specName)
+
+		Expect(e2eConfig).NotTo(BeNil(), "Invalid argument. e2eConfig can't be nil when calling %s spec", specName)
+		Expect(clusterctlConfigPath).To(BeAnExistingFile(), "Invalid argument. clusterctlConfigPath must be an existing file when calling %s spec", specName)
+		Expect(bootstrapClusterProxy).NotTo(BeNil(), "Invalid argument. bootstrapClusterProxy can't be nil when calling %s spec", specName)
+		Expect(os.MkdirAll(artifactFolder, 0755)).To(Succeed(), "Invalid argument. artifactFolder can't be created for %s spec", specName)
+		Expect(e2eConfig.Variables).To(HaveKey(KubernetesVersion))
+
+		// set up a Namespace where to host objects for this spec and create a watcher for the namespace events.
+		namespace, cancelWatches = setupSpecNamespace(ctx, specName, bootstrapClusterProxy, artifactFolder)
+		clusterResources = new(clusterctl.ApplyClusterTemplateAndWaitResult)
+	})
+
+	It("Should create a workload cluster with three control plane nodes and two worker nodes", func() {
+		var err error
+		dockerClient, err = client.NewClientWithOpts(client.FromEnv)
+		Expect(err).NotTo(HaveOccurred())
+
+		input := MultiNodeClusterInput{
+			Context:                  ctx,
+			BootstrapClusterProxy:    bootstrapClusterProxy,
+			DockerClient:             dockerClient,
+			E2EConfig:                e2eConfig,
+			ClusterctlConfigPath:     clusterctlConfigPath,
+			ArtifactFolder:           artifactFolder,
+			SpecName:                 specName,
+			Namespace:                namespace.Name,
+			ClusterName:              fmt.Sprintf("%s-%s", specName, util.RandomString(6)),
+			ControlPlaneMachineCount: 3,
+			WorkerMachineCount:       2,
+		}
+
+		By("Creating a byohost for each machine of the cluster")
+		runner := ByoHostRunner{
+			Context:               ctx,
+			ClusterConName:        clusterConName,
+			ByoHostName:           fmt.Sprintf("byohost-%s", util.RandomString(6)),
+			Namespace:             namespace.Name,
+			PathToHostAgentBinary: pathToHostAgentBinary,
+			DockerClient:          dockerClient,
+			NetworkInterface:      "kind",
+			BootstrapClusterProxy: bootstrapClusterProxy,
+			CommandArgs: map[string]string{
+				"--kubeconfig": "/mgmt.conf",
+				"--namespace":  namespace.Name,
+				"--v":          "1",
+			},
+		}
+		byoHosts, err = runner.SetupByoDockerHosts(input.HostCount(), os.TempDir())
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Creating a workload cluster with three control plane nodes and two worker nodes")
+		clusterctl.ApplyClusterTemplateAndWait(ctx, MultiNodeClusterTemplateInput(input), clusterResources)
+		Expect(clusterResources.MachineDeployments[0].Spec.Replicas).To(Equal(pointer.Int32Ptr(2)))
+
+		By("Waiting for all the nodes of the workload cluster to be ready")
+		workloadClusterProxy := WorkloadClusterProxy(ctx, WorkloadClusterProxyInput{
+			BootstrapClusterProxy: bootstrapClusterProxy,
+			Namespace:             namespace.Name,
+			ClusterName:           input.ClusterName,
+			KubernetesVersion:     e2eConfig.GetVariable(KubernetesVersion),
+			NodeCount:             input.HostCount(),
+			WaitForNodesReady:     e2eConfig.GetIntervals(specName, "wait-nodes-ready"),
+		})
+		defer workloadClusterProxy.Dispose(ctx)
+	})
+
+	JustAfterEach(func() {
+		if CurrentGinkgoTestDescription().Failed {
+			ShowInfo(AgentLogFiles(byoHosts))
+		}
+	})
+
+	AfterEach(func() {
+		// Dumps all the resources in the spec namespace, then cleanups the cluster object and the spec namespace itself.
+		dumpSpecResourcesAndCleanup(ctx, specName, bootstrapClusterProxy, artifactFolder, namespace, cancelWatches, clusterResources.Cluster, e2eConfig.GetIntervals, skipCleanup)
+
+		if dockerClient != nil {
+			CleanupByoDockerHosts(ctx, dockerClient, byoHosts)
+		}
+
+		err := os.Remove(ReadByohControllerManagerLogShellFile)
+		if err != nil {
+			Showf("error removing file %s: %v", ReadByohControllerManagerLogShellFile, err)
+		}
+		err = os.Remove(ReadAllPodsShellFile)
+		if err != nil {
+			Showf("error removing file %s: %v", ReadAllPodsShellFile, err)
+		}
+	})
+})